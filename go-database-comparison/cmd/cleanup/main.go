@@ -0,0 +1,12 @@
+// Command cleanup runs "dbcompare cleanup", removing the data benchmarks created.
+package main
+
+import (
+	"os"
+
+	"go-database-comparison/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.ExecuteSubcommand("cleanup", os.Args[1:]))
+}
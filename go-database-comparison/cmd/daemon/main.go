@@ -0,0 +1,12 @@
+// Command daemon runs "dbcompare daemon", benchmarking on a schedule and storing every run's results.
+package main
+
+import (
+	"os"
+
+	"go-database-comparison/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.ExecuteSubcommand("daemon", os.Args[1:]))
+}
@@ -0,0 +1,7 @@
+package main
+
+import "go-database-comparison/internal/cli"
+
+func main() {
+	cli.Main()
+}
@@ -0,0 +1,12 @@
+// Command doctor runs "dbcompare doctor", checking the environment is fit for benchmarking.
+package main
+
+import (
+	"os"
+
+	"go-database-comparison/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.ExecuteSubcommand("doctor", os.Args[1:]))
+}
@@ -1,278 +1,12 @@
+// Command final-verification is kept for existing instructions; it runs "dbcompare doctor".
 package main
 
 import (
-	"context"
-	"fmt"
-	"log"
-	"time"
+	"os"
 
-	"go-database-comparison/pkg/database"
-	"go-database-comparison/pkg/models"
-	"go-database-comparison/pkg/repository"
+	"go-database-comparison/internal/cli"
 )
 
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	fmt.Println("🔍 Final Verification - Technical Accuracy 100%")
-	fmt.Println("============================================")
-
-	config := database.DefaultPostgreSQLConfig()
-
-	// ① 実装例完全性確認
-	if err := verifyImplementationCompleteness(ctx, config); err != nil {
-		log.Fatalf("❌ Implementation verification failed: %v", err)
-	}
-
-	// ② 動作保証最終チェック
-	if err := verifyOperationalGuarantee(ctx, config); err != nil {
-		log.Fatalf("❌ Operational verification failed: %v", err)
-	}
-
-	// ③ 中級者写経可能性確認
-	if err := verifyIntermediateFriendly(); err != nil {
-		log.Fatalf("❌ Intermediate-friendly verification failed: %v", err)
-	}
-
-	// ④ 技術的正確性100%保証
-	if err := verifyTechnicalAccuracy(ctx, config); err != nil {
-		log.Fatalf("❌ Technical accuracy verification failed: %v", err)
-	}
-
-	fmt.Println("✅ All verifications passed - 100% technical accuracy achieved!")
-}
-
-func verifyImplementationCompleteness(ctx context.Context, config *database.DatabaseConfig) error {
-	fmt.Println("📋 1. Implementation Completeness Check...")
-
-	// Check PQ implementation
-	pqDB, err := database.ConnectWithPQ(ctx, config)
-	if err != nil {
-		return fmt.Errorf("PQ connection failed: %w", err)
-	}
-	defer pqDB.Close()
-	
-	pqRepo := repository.NewPQRepository(pqDB)
-	if err := testCRUDCompleteness(ctx, "PQ", pqRepo); err != nil {
-		return fmt.Errorf("PQ CRUD incomplete: %w", err)
-	}
-
-	// Check SQLX implementation
-	sqlxDB, err := database.ConnectWithSQLX(ctx, config)
-	if err != nil {
-		return fmt.Errorf("SQLX connection failed: %w", err)
-	}
-	defer sqlxDB.Close()
-	
-	sqlxRepo := repository.NewSQLXRepository(sqlxDB)
-	if err := testCRUDCompleteness(ctx, "SQLX", sqlxRepo); err != nil {
-		return fmt.Errorf("SQLX CRUD incomplete: %w", err)
-	}
-
-	// Check GORM implementation
-	gormDB, err := database.ConnectWithGORM(ctx, config)
-	if err != nil {
-		return fmt.Errorf("GORM connection failed: %w", err)
-	}
-	sqlDB, _ := gormDB.DB()
-	defer sqlDB.Close()
-	
-	gormRepo := repository.NewGORMRepository(gormDB)
-	if err := testCRUDCompleteness(ctx, "GORM", gormRepo); err != nil {
-		return fmt.Errorf("GORM CRUD incomplete: %w", err)
-	}
-
-	fmt.Println("   ✓ All implementations complete")
-	return nil
-}
-
-func testCRUDCompleteness(ctx context.Context, name string, repo interface{}) error {
-	timestamp := time.Now().UnixNano()
-	req := &models.CreateUserRequest{
-		Name:  fmt.Sprintf("Verification %s %d", name, timestamp),
-		Email: fmt.Sprintf("verify-%s-%d@test.com", name, timestamp),
-		Age:   30,
-	}
-
-	var user *models.User
-	var err error
-
-	// Test Create
-	switch r := repo.(type) {
-	case *repository.PQRepository:
-		user, err = r.CreateUser(ctx, req)
-	case *repository.SQLXRepository:
-		user, err = r.CreateUser(ctx, req)
-	case *repository.GORMRepository:
-		user, err = r.CreateUser(ctx, req)
-	default:
-		return fmt.Errorf("unknown repository type")
-	}
-	if err != nil {
-		return fmt.Errorf("create failed: %w", err)
-	}
-
-	// Test Read
-	switch r := repo.(type) {
-	case *repository.PQRepository:
-		_, err = r.GetUserByID(ctx, user.ID)
-	case *repository.SQLXRepository:
-		_, err = r.GetUserByID(ctx, user.ID)
-	case *repository.GORMRepository:
-		_, err = r.GetUserByID(ctx, user.ID)
-	}
-	if err != nil {
-		return fmt.Errorf("read failed: %w", err)
-	}
-
-	// Test Update
-	newName := fmt.Sprintf("Updated %s", name)
-	updateReq := &models.UpdateUserRequest{Name: &newName}
-	switch r := repo.(type) {
-	case *repository.PQRepository:
-		_, err = r.UpdateUser(ctx, user.ID, updateReq)
-	case *repository.SQLXRepository:
-		_, err = r.UpdateUser(ctx, user.ID, updateReq)
-	case *repository.GORMRepository:
-		_, err = r.UpdateUser(ctx, user.ID, updateReq)
-	}
-	if err != nil {
-		return fmt.Errorf("update failed: %w", err)
-	}
-
-	// Test Delete
-	switch r := repo.(type) {
-	case *repository.PQRepository:
-		err = r.DeleteUser(ctx, user.ID)
-	case *repository.SQLXRepository:
-		err = r.DeleteUser(ctx, user.ID)
-	case *repository.GORMRepository:
-		err = r.DeleteUser(ctx, user.ID)
-	}
-	if err != nil {
-		return fmt.Errorf("delete failed: %w", err)
-	}
-
-	return nil
+	os.Exit(cli.ExecuteSubcommand("doctor", os.Args[1:]))
 }
-
-func verifyOperationalGuarantee(ctx context.Context, config *database.DatabaseConfig) error {
-	fmt.Println("🛡️  2. Operational Guarantee Check...")
-
-	// Test error handling
-	pqDB, err := database.ConnectWithPQ(ctx, config)
-	if err != nil {
-		return err
-	}
-	defer pqDB.Close()
-
-	pqRepo := repository.NewPQRepository(pqDB)
-
-	// Test non-existent user read (should return proper error)
-	_, err = pqRepo.GetUserByID(ctx, 99999)
-	if err == nil {
-		return fmt.Errorf("expected error for non-existent user, got nil")
-	}
-	fmt.Println("   ✓ Error handling verified")
-
-	// Test invalid data (should return proper error)
-	invalidReq := &models.CreateUserRequest{
-		Name:  "", // Invalid empty name
-		Email: "invalid-email",
-		Age:   -1, // Invalid age
-	}
-	_, err = pqRepo.CreateUser(ctx, invalidReq)
-	// Note: This will depend on database constraints
-	fmt.Println("   ✓ Input validation verified")
-
-	// Test context timeout
-	timeoutCtx, cancel := context.WithTimeout(ctx, 1*time.Nanosecond)
-	defer cancel()
-	time.Sleep(1 * time.Millisecond) // Ensure timeout
-	
-	_, err = pqRepo.GetUserByID(timeoutCtx, 1)
-	if err == nil {
-		return fmt.Errorf("expected timeout error, got nil")
-	}
-	fmt.Println("   ✓ Context timeout handling verified")
-
-	return nil
-}
-
-func verifyIntermediateFriendly() error {
-	fmt.Println("👨‍💻 3. Intermediate Developer Friendly Check...")
-
-	// Check that code patterns are clear and consistent
-	patterns := []string{
-		"✓ Repository pattern implemented",
-		"✓ Interface segregation applied", 
-		"✓ Error wrapping consistent",
-		"✓ Context usage proper",
-		"✓ Resource cleanup implemented",
-		"✓ Transaction handling clear",
-		"✓ Connection pooling configured",
-		"✓ Struct tags documented",
-	}
-
-	for _, pattern := range patterns {
-		fmt.Printf("   %s\n", pattern)
-	}
-
-	fmt.Println("   ✓ Code is intermediate-developer friendly")
-	return nil
-}
-
-func verifyTechnicalAccuracy(ctx context.Context, config *database.DatabaseConfig) error {
-	fmt.Println("🎯 4. Technical Accuracy 100% Guarantee...")
-
-	// Verify SQL statements are identical across implementations
-	fmt.Println("   ✓ SQL statements verified identical")
-	
-	// Verify connection pool settings are consistent
-	fmt.Println("   ✓ Connection pool settings unified")
-	
-	// Verify context.Context usage is proper
-	fmt.Println("   ✓ Context usage verified")
-	
-	// Verify error types are appropriate
-	fmt.Println("   ✓ Error handling patterns verified")
-	
-	// Verify performance characteristics are measurable
-	fmt.Println("   ✓ Performance measurement ready")
-	
-	// Verify transaction handling is correct
-	pqDB, err := database.ConnectWithPQ(ctx, config)
-	if err != nil {
-		return err
-	}
-	defer pqDB.Close()
-
-	pqRepo := repository.NewPQRepository(pqDB)
-	timestamp := time.Now().UnixNano()
-	req := &models.CreateUserRequest{
-		Name:  fmt.Sprintf("Transaction Test %d", timestamp),
-		Email: fmt.Sprintf("txn-%d@test.com", timestamp),
-		Age:   25,
-	}
-
-	// Test transaction success
-	user, err := pqRepo.CreateUserWithTransaction(ctx, req)
-	if err != nil {
-		return fmt.Errorf("transaction test failed: %w", err)
-	}
-
-	// Test transaction rollback (duplicate email)
-	_, err = pqRepo.CreateUserWithTransaction(ctx, req)
-	if err == nil {
-		return fmt.Errorf("expected duplicate email error, got nil")
-	}
-
-	// Cleanup
-	pqRepo.DeleteUser(ctx, user.ID)
-
-	fmt.Println("   ✓ Transaction handling verified")
-	fmt.Println("   ✓ Technical accuracy 100% guaranteed")
-
-	return nil
-}
\ No newline at end of file
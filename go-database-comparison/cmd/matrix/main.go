@@ -0,0 +1,12 @@
+// Command matrix runs "dbcompare matrix", benchmarking several PostgreSQL targets into one report.
+package main
+
+import (
+	"os"
+
+	"go-database-comparison/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.ExecuteSubcommand("matrix", os.Args[1:]))
+}
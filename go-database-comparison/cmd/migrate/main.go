@@ -0,0 +1,12 @@
+// Command migrate runs "dbcompare migrate", creating the benchmark schema.
+package main
+
+import (
+	"os"
+
+	"go-database-comparison/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.ExecuteSubcommand("migrate", os.Args[1:]))
+}
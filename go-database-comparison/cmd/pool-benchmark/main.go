@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-database-comparison/pkg/buildinfo"
+	"go-database-comparison/pkg/concurrency"
+)
+
+// result is one executor's cost at one worker count, printed with -format json
+type result struct {
+	Workers      int    `json:"workers"`
+	Executor     string `json:"executor"`
+	NsPerJob     int64  `json:"ns_per_job"`
+	AllocsPerJob int64  `json:"allocs_per_job"`
+	BytesPerJob  int64  `json:"bytes_per_job"`
+}
+
+// report is the document printed with -format json
+type report struct {
+	Build   buildinfo.Info `json:"build"`
+	Results []result       `json:"results"`
+}
+
+// executorFactory creates a fresh executor for one benchmark run
+type executorFactory func(ctx context.Context, workers int) concurrency.Executor[int]
+
+func main() {
+	workerList := flag.String("workers", "4,16,64", "comma-separated worker counts to compare")
+	jobs := flag.Int("jobs", 1000, "jobs submitted per benchmark iteration")
+	work := flag.Duration("work", 0, "simulated duration of each job (0 for no-op jobs)")
+	format := flag.String("format", "text", "output format: text or json")
+	flag.Parse()
+
+	workerCounts, err := parseWorkerCounts(*workerList)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	var w io.Writer = os.Stdout
+	switch *format {
+	case "text":
+	case "json":
+		w = io.Discard // Only the JSON document goes to stdout
+	default:
+		log.Fatalf("❌ unknown format %q (expected text or json)", *format)
+	}
+
+	fmt.Fprintln(w, "🧪 Goroutine Pool Executor Comparison")
+	fmt.Fprintln(w, "=====================================")
+	fmt.Fprintf(w, "   Jobs per iteration: %d, Job duration: %v\n", *jobs, *work)
+	fmt.Fprintf(w, "   Build: %s\n", buildinfo.Get())
+
+	executors := []struct {
+		name    string
+		factory executorFactory
+	}{
+		{"WorkerPool (channel queue)", func(ctx context.Context, workers int) concurrency.Executor[int] {
+			return concurrency.NewTypedWorkerPool[int](ctx, workers)
+		}},
+		{"WorkerPool (work stealing)", func(ctx context.Context, workers int) concurrency.Executor[int] {
+			return concurrency.NewTypedWorkerPool[int](ctx, workers, concurrency.WithWorkStealing())
+		}},
+		{"ErrGroupExecutor (errgroup + semaphore)", func(ctx context.Context, workers int) concurrency.Executor[int] {
+			return concurrency.NewErrGroupExecutor[int](ctx, workers)
+		}},
+	}
+
+	var results []result
+	for _, workers := range workerCounts {
+		fmt.Fprintf(w, "\n📊 %d workers\n", workers)
+		fmt.Fprintf(w, "%-42s %14s %14s %12s\n", "Executor", "ns/job", "allocs/job", "B/job")
+		for _, e := range executors {
+			bench := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := runBatch(e.factory, workers, *jobs, *work); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			perJob := int64(bench.N) * int64(*jobs)
+			r := result{
+				Workers:      workers,
+				Executor:     e.name,
+				NsPerJob:     bench.T.Nanoseconds() / perJob,
+				AllocsPerJob: int64(bench.MemAllocs) / perJob,
+				BytesPerJob:  int64(bench.MemBytes) / perJob,
+			}
+			results = append(results, r)
+			fmt.Fprintf(w, "%-42s %14d %14d %12d\n", r.Executor, r.NsPerJob, r.AllocsPerJob, r.BytesPerJob)
+		}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report{Build: buildinfo.Get(), Results: results}); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+}
+
+// parseWorkerCounts parses the comma-separated -workers flag
+func parseWorkerCounts(s string) ([]int, error) {
+	var counts []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid worker count %q", part)
+		}
+		counts = append(counts, n)
+	}
+	return counts, nil
+}
+
+// runBatch submits jobs to a new executor and drains all results
+func runBatch(factory executorFactory, workers, jobs int, work time.Duration) error {
+	exec := factory(context.Background(), workers)
+	exec.Start()
+	defer exec.Stop()
+
+	collected := make(chan int, 1)
+	go func() {
+		count := 0
+		for range exec.Results() {
+			count++
+		}
+		collected <- count
+	}()
+
+	for i := 0; i < jobs; i++ {
+		job := concurrency.Job[int]{
+			ID: i,
+			TaskFunc: func(ctx context.Context) (int, error) {
+				if work > 0 {
+					time.Sleep(work)
+				}
+				return i, nil
+			},
+		}
+		// The channel pool rejects jobs when its queue is full, so retry until accepted
+		for exec.Submit(job) != nil {
+			time.Sleep(10 * time.Microsecond)
+		}
+	}
+	exec.Close()
+
+	if count := <-collected; count != jobs {
+		return fmt.Errorf("expected %d results, got %d", jobs, count)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+// Command seed runs "dbcompare seed", building reproducible datasets for read benchmarks.
+package main
+
+import (
+	"os"
+
+	"go-database-comparison/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.ExecuteSubcommand("seed", os.Args[1:]))
+}
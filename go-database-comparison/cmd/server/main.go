@@ -0,0 +1,12 @@
+// Command server runs "dbcompare serve", the REST API over the repositories.
+package main
+
+import (
+	"os"
+
+	"go-database-comparison/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.ExecuteSubcommand("serve", os.Args[1:]))
+}
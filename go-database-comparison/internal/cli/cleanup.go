@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+)
+
+// cleanupDocument is printed with --format json
+type cleanupDocument struct {
+	benchdata.Report
+	RunID  string `json:"run_id,omitempty"` // Empty when all benchmark data was targeted
+	DryRun bool   `json:"dry_run"`
+}
+
+// cleanupOptions holds the flags of the cleanup command
+type cleanupOptions struct {
+	all      bool
+	seedData bool
+	dryRun   bool
+}
+
+func newCleanupCommand(opts *globalOptions) *cobra.Command {
+	cleanupOpts := cleanupOptions{}
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Remove the rows and schemas benchmarks created",
+		Long: `Remove the users and per-run schemas benchmarks and checks created, to
+keep shared databases tidy, and report what was removed.
+
+Every command marks the users it creates with its run ID, printed at its
+start and set with --run-id. cleanup --run-id=<id> removes one run's data;
+--all-bench-data removes the data of every run, including users created
+before run IDs existed. Datasets built by "dbcompare seed" are kept unless
+--seed-data is given as well. Orders of removed users are removed with them.`,
+		Example: "  dbcompare cleanup --run-id=20261017-101500-3f2a\n  dbcompare cleanup --all-bench-data --dry-run",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCleanup(cmd, opts, cleanupOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&cleanupOpts.all, "all-bench-data", false, "Remove the data of every run")
+	flags.BoolVar(&cleanupOpts.seedData, "seed-data", false, "With --all-bench-data, also remove seeded datasets")
+	flags.BoolVar(&cleanupOpts.dryRun, "dry-run", false, "Report what would be removed without removing it")
+	return cmd
+}
+
+func runCleanup(cmd *cobra.Command, opts *globalOptions, cleanupOpts cleanupOptions) error {
+	target := benchdata.Target{All: cleanupOpts.all, SeedData: cleanupOpts.seedData, DryRun: cleanupOpts.dryRun}
+	switch {
+	case opts.runIDSet && cleanupOpts.all:
+		return fmt.Errorf("--run-id and --all-bench-data are mutually exclusive")
+	case opts.runIDSet:
+		target.RunID = opts.runID
+	case !cleanupOpts.all:
+		return fmt.Errorf("set --run-id to remove one run's data or --all-bench-data to remove all of it")
+	}
+	if cleanupOpts.seedData && !cleanupOpts.all {
+		return fmt.Errorf("--seed-data requires --all-bench-data")
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 5*time.Minute)
+	defer cancel()
+
+	db, err := database.ConnectWithPQ(ctx, opts.dbConfig())
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer db.Close()
+
+	report, err := benchdata.Cleanup(ctx, db, target)
+	if err != nil {
+		return err
+	}
+
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, cleanupDocument{Report: report, RunID: target.RunID, DryRun: target.DryRun})
+	}
+
+	w := cmd.OutOrStdout()
+	verb := "Removed"
+	if target.DryRun {
+		verb = "Would remove"
+	}
+	scope := "all benchmark data"
+	if target.RunID != "" {
+		scope = "run " + target.RunID
+	}
+	fmt.Fprintf(w, "🧹 %s for %s:\n", verb, scope)
+	fmt.Fprintf(w, "   Users:   %d\n", report.Users)
+	fmt.Fprintf(w, "   Orders:  %d\n", report.Orders)
+	schemas := "none"
+	if len(report.Schemas) > 0 {
+		schemas = strings.Join(report.Schemas, ", ")
+	}
+	fmt.Fprintf(w, "   Schemas: %s\n", schemas)
+	return nil
+}
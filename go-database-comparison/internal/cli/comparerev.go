@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/database"
+)
+
+// compareRevOptions holds the flags of the compare-rev command
+type compareRevOptions struct {
+	maxRegression float64
+	keepWorktrees bool
+}
+
+// revisionRun is one side of a compare-rev run
+type revisionRun struct {
+	Revision string `json:"revision"` // As given on the command line
+	Commit   string `json:"commit"`
+	Results  string `json:"results_file"`
+
+	file benchmark.ResultsFile
+}
+
+// revisionChange compares one library and operation across the revisions
+type revisionChange struct {
+	Library   string  `json:"library"`
+	Operation string  `json:"operation"`
+	BaseAvg   string  `json:"base_avg"`
+	HeadAvg   string  `json:"head_avg"`
+	Change    float64 `json:"change_percent"` // Positive when head is slower
+}
+
+// compareRevDocument is printed with --format json
+type compareRevDocument struct {
+	Base        revisionRun            `json:"base"`
+	Head        revisionRun            `json:"head"`
+	Changes     []revisionChange       `json:"changes"`
+	Regressions []benchmark.Regression `json:"regressions,omitempty"`
+}
+
+func newCompareRevCommand(opts *globalOptions) *cobra.Command {
+	compareOpts := compareRevOptions{}
+	cmd := &cobra.Command{
+		Use:   "compare-rev <base-revision> <head-revision> [-- comprehensive-benchmark flags]",
+		Short: "Benchmark two git revisions of this repository against each other",
+		Long: `Benchmark two git revisions of this repository against each other, to see
+how changes to the repositories affect performance between article updates.
+
+Each revision is checked out into a temporary git worktree, built, and its
+comprehensive benchmark run against the database the global flags select
+with the flags given after --, the same for both revisions. Revisions
+older than the dbcompare binary are run through their
+cmd/comprehensive-benchmark binary instead. It takes no flags and
+connects to its built-in default database, so the flags after -- and
+the database flags only apply to revisions with dbcompare. The runs happen one after the other,
+base first, so they don't compete for the database.
+
+The average time of every library and operation is then compared; when
+head is more than --max-regression percent slower than base for any of
+them the command exits with code 3, as comprehensive-benchmark does for
+--baseline.`,
+		Example: "  dbcompare compare-rev main HEAD\n  dbcompare compare-rev v1.2.0 main -- --iterations 500 --operations create,read,update",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dash := cmd.ArgsLenAtDash(); dash >= 0 && dash != 2 {
+				return fmt.Errorf("expected exactly two revisions before --, got %d", dash)
+			} else if dash < 0 && len(args) != 2 {
+				return fmt.Errorf("expected two revisions, pass benchmark flags after --")
+			}
+			return runCompareRev(cmd, opts, compareOpts, args[0], args[1], args[2:])
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Float64Var(&compareOpts.maxRegression, "max-regression", 10, "Percent head's average time may exceed base's before it counts as a regression")
+	flags.BoolVar(&compareOpts.keepWorktrees, "keep-worktrees", false, "Keep the worktrees, binaries and results in the temporary directory")
+	return cmd
+}
+
+func runCompareRev(cmd *cobra.Command, opts *globalOptions, compareOpts compareRevOptions, baseRev, headRev string, benchArgs []string) error {
+	if compareOpts.maxRegression < 0 {
+		return fmt.Errorf("--max-regression must not be negative, got %v", compareOpts.maxRegression)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+
+	topLevel, err := gitOutput(ctx, "", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("not in a git repository: %w", err)
+	}
+	goMod, err := commandOutput(ctx, "", "go", "env", "GOMOD")
+	if err != nil || goMod == "" || goMod == os.DevNull {
+		return fmt.Errorf("not in a Go module, run compare-rev from the repository")
+	}
+	moduleDir, err := filepath.Rel(topLevel, filepath.Dir(goMod))
+	if err != nil {
+		return fmt.Errorf("failed to locate the module in the repository: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "dbcompare-rev-")
+	if err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+	if compareOpts.keepWorktrees {
+		log.Info("keeping work directory", "path", workDir)
+	} else {
+		defer os.RemoveAll(workDir)
+	}
+
+	banner(w, "Go Database Comparison - Revision Comparison")
+	runs := []*revisionRun{{Revision: baseRev}, {Revision: headRev}}
+	for i, run := range runs {
+		if run.Commit, err = gitOutput(ctx, topLevel, "rev-parse", "--verify", run.Revision+"^{commit}"); err != nil {
+			return fmt.Errorf("unknown revision %q: %w", run.Revision, err)
+		}
+		fmt.Fprintf(w, "%s: %s (%s)\n", []string{"Base", "Head"}[i], run.Revision, run.Commit[:12])
+	}
+
+	for i, run := range runs {
+		side := []string{"base", "head"}[i]
+		worktree := filepath.Join(workDir, side)
+		if _, err := gitOutput(ctx, topLevel, "worktree", "add", "--detach", worktree, run.Commit); err != nil {
+			return fmt.Errorf("failed to check out %s: %w", run.Revision, err)
+		}
+		if !compareOpts.keepWorktrees {
+			defer gitOutput(context.WithoutCancel(ctx), topLevel, "worktree", "remove", "--force", worktree)
+		}
+
+		log.Info("building revision", "revision", run.Revision, "commit", run.Commit)
+		binary, subcommand, err := buildRevision(ctx, filepath.Join(worktree, moduleDir), filepath.Join(workDir, "dbcompare-"+side))
+		if err != nil {
+			return fmt.Errorf("failed to build %s: %w", run.Revision, err)
+		}
+
+		run.Results = filepath.Join(workDir, "results-"+side+".json")
+		args := append(append(subcommand, benchArgs...), "--results-file", run.Results)
+		// The legacy binary takes no flags and writes its results to
+		// legacyResultsFile in its working directory
+		legacy := subcommand == nil
+		if legacy {
+			args = nil
+			if len(benchArgs) > 0 {
+				log.Warn("revision predates dbcompare, running it without the benchmark flags", "revision", run.Revision)
+			}
+		}
+		log.Info("benchmarking revision", "revision", run.Revision, "args", strings.Join(args, " "))
+		fmt.Fprintf(w, "\nBenchmarking %s...\n", run.Revision)
+
+		bench := exec.CommandContext(ctx, binary, args...)
+		bench.Dir = workDir // Reports and other outputs stay out of the way
+		bench.Env = append(os.Environ(), databaseEnv(&opts.db)...)
+		// Our stdout is for the comparison, the runs' output is progress
+		bench.Stdout, bench.Stderr = cmd.ErrOrStderr(), cmd.ErrOrStderr()
+		if err := bench.Run(); err != nil {
+			// A regression against a --baseline in benchArgs still wrote results
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != ExitRegression {
+				return fmt.Errorf("benchmark of %s failed: %w", run.Revision, err)
+			}
+		}
+		if legacy {
+			if err := os.Rename(filepath.Join(workDir, legacyResultsFile), run.Results); err != nil {
+				return fmt.Errorf("benchmark of %s wrote no results: %w", run.Revision, err)
+			}
+		}
+		if run.file, err = benchmark.LoadResultsFile(run.Results); err != nil {
+			return err
+		}
+	}
+
+	base, head := runs[0], runs[1]
+	// Every pair changes by more than -Inf percent, so this lists them all
+	all := benchmark.CompareToBaseline(head.file.Results, base.file.Results, math.Inf(-1))
+	doc := compareRevDocument{Base: *base, Head: *head, Changes: make([]revisionChange, 0, len(all))}
+	for _, c := range all {
+		doc.Changes = append(doc.Changes, revisionChange{
+			Library: c.Library, Operation: c.Operation,
+			BaseAvg: c.BaselineAvg.String(), HeadAvg: c.AvgTime.String(), Change: c.Increase,
+		})
+		if c.Increase > compareOpts.maxRegression {
+			doc.Regressions = append(doc.Regressions, c)
+		}
+	}
+
+	fmt.Fprintln(w)
+	printRevisionChanges(w, doc, compareOpts.maxRegression)
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if len(doc.Regressions) > 0 {
+		return regressionDetected(fmt.Errorf("%d operations regressed from %s to %s", len(doc.Regressions), baseRev, headRev))
+	}
+	return nil
+}
+
+// legacyResultsFile is where cmd/comprehensive-benchmark, which predates
+// dbcompare, writes its results, relative to its working directory
+const legacyResultsFile = "benchmark_results.json"
+
+// buildRevision builds the benchmark of the module checked out in dir to
+// output. It returns the binary with the arguments selecting the
+// comprehensive benchmark: dbcompare's subcommand, or none for revisions
+// predating dbcompare.
+func buildRevision(ctx context.Context, dir, output string) (string, []string, error) {
+	candidates := []struct {
+		pkg        string
+		subcommand []string
+	}{
+		{"./cmd/dbcompare", []string{"comprehensive-benchmark"}},
+		{"./cmd/comprehensive-benchmark", nil},
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, c.pkg)); err != nil {
+			continue
+		}
+		if _, err := commandOutput(ctx, dir, "go", "build", "-o", output, c.pkg); err != nil {
+			return "", nil, err
+		}
+		return output, append([]string(nil), c.subcommand...), nil
+	}
+	return "", nil, fmt.Errorf("no cmd/dbcompare or cmd/comprehensive-benchmark in %s", dir)
+}
+
+// databaseEnv passes the database the global flags select to a benchmark
+// run as the environment variables the flags read
+func databaseEnv(config *database.DatabaseConfig) []string {
+	return []string{
+		envName("host") + "=" + config.Host,
+		envName("port") + "=" + strconv.Itoa(config.Port),
+		envName("user") + "=" + config.User,
+		envName("password") + "=" + config.Password,
+		envName("dbname") + "=" + config.DBName,
+		envName("sslmode") + "=" + config.SSLMode,
+	}
+}
+
+// printRevisionChanges prints the average time of every library and
+// operation in both revisions, marking the regressions
+func printRevisionChanges(w io.Writer, doc compareRevDocument, maxRegression float64) {
+	fmt.Fprintf(w, "%-6s | %-14s | %-12s | %-12s | %s\n", "Lib", "Operation", "Base Avg", "Head Avg", "Change")
+	fmt.Fprintln(w, "-------|----------------|--------------|--------------|--------")
+	for _, c := range doc.Changes {
+		mark := ""
+		if c.Change > maxRegression {
+			mark = " (regression)"
+		}
+		fmt.Fprintf(w, "%-6s | %-14s | %-12s | %-12s | %+.1f%%%s\n", c.Library, c.Operation, c.BaseAvg, c.HeadAvg, c.Change, mark)
+	}
+	if len(doc.Regressions) == 0 {
+		fmt.Fprintf(w, "\nNo operation more than %.1f%% slower in %s\n", maxRegression, doc.Head.Revision)
+	} else {
+		fmt.Fprintf(w, "\n%d operations more than %.1f%% slower in %s\n", len(doc.Regressions), maxRegression, doc.Head.Revision)
+	}
+}
+
+// gitOutput runs git in dir and returns its trimmed output
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	return commandOutput(ctx, dir, "git", args...)
+}
+
+// commandOutput runs name in dir and returns its trimmed stdout, with its
+// stderr in the error when it fails
+func commandOutput(ctx context.Context, dir, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, name, args...)
+	c.Dir = dir
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s %s: %w: %s", name, args[0], err, message)
+		}
+		return "", fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,708 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/buildinfo"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/gormsql"
+	"go-database-comparison/pkg/partition"
+	"go-database-comparison/pkg/replay"
+)
+
+// comprehensiveOptions holds the flags of the comprehensive-benchmark command
+type comprehensiveOptions struct {
+	iterations     int
+	concurrency    int
+	warmup         int
+	operations     []string
+	opTimeout      time.Duration
+	dataSize       int
+	outputs        outputPaths
+	lang           string
+	reportTemplate string
+	exportPDF      bool
+	pdfNoSandbox   bool
+	anonymize      bool
+	direct         bool
+	saturation     bool
+	chaosInterval  time.Duration
+	netLatency     time.Duration
+	netLoss        float64
+	connAffinity   bool
+	layer          string
+	layout         string
+	httpURL        string
+	baseline       string
+	maxRegression  float64
+	dryRun         bool
+	preset         string
+	sweep          []int
+	watch          bool
+	record         string
+}
+
+// comprehensiveDocument is printed with --format json: the results as
+// written to --results-file, plus the regressions against --baseline
+type comprehensiveDocument struct {
+	benchmark.ResultsFile
+	Files       map[string]string      `json:"files"` // Output paths written, by flag
+	Regressions []benchmark.Regression `json:"regressions,omitempty"`
+	Partitions  *partitionReport       `json:"partitions,omitempty"` // With --layout partitioned
+}
+
+func newComprehensiveBenchmarkCommand(opts *globalOptions) *cobra.Command {
+	bench := &comprehensiveOptions{}
+	defaults := benchmark.DefaultBenchmarkConfig()
+
+	cmd := &cobra.Command{
+		Use:   "comprehensive-benchmark",
+		Short: "Benchmark all libraries concurrently and write the reports",
+		Long: `Benchmark all libraries concurrently and write the reports.
+
+--layer http runs the operations through the REST server of "dbcompare
+serve" instead of calling the repositories directly, measuring end-to-end
+latency including routing, JSON and the HTTP round trip; --layer both runs
+both and reports the HTTP overhead per library. The server is started
+in-process on a loopback port unless --http-url points at a running one.
+The HTTP layer supports the create, read, update, delete and search
+operations; its results are named http_<operation>. --record writes the
+repository calls the in-process server makes, with their parameters, to a
+workload file that "dbcompare replay" re-executes on any library.
+
+--baseline compares the average time of every library and operation with
+the results file of an earlier run; when one grew by more than
+--max-regression percent the command exits with code 3.
+
+--chaos-interval terminates the backends of every client connected to the
+database as the same user with pg_terminate_backend this often, and the
+report shows how each library's pool recovered: the operations failed
+between a kill and the first later success, and how long that took. Use a
+database and user of its own, other sessions of the user are killed too.
+
+--net-latency and --net-loss route every connection through an in-process
+proxy emulating a network with that round-trip time and percent of lost
+data, as "dbcompare proxy" does, so the round trips each library makes
+weigh as they would across a real network.
+
+--layout partitioned benchmarks the users table hash partitioned on id by
+migration 0009 instead of the plain one: every connection gets a search
+path resolving "users" to it, so the libraries run their usual statements.
+Migration 0011 gives it the columns, indexes and tenant policy of the plain
+table; only email is not unique, which the report's table layout notes.
+The report then shows how many of each library's users the server routed
+to each partition, and how many partitions a read by id and a search by
+email scanned per library, pruned when planning or only when executing a
+generic plan of a prepared statement.
+
+--dry-run prints the SQL each library would execute for each operation
+instead of benchmarking, without connecting to the database.
+
+--preset fills in the sizing flags (iterations, concurrency, warmup,
+operations, data-size, sweep-concurrency) and the default --timeout from a
+named preset; flags, environment variables and the config file still
+override single values. Presets:
+
+` + presetHelp() + `
+--sweep-concurrency runs every operation once per concurrency level; its
+results are named <operation>@c<concurrency>. The HTTP layer is not swept.
+
+--watch runs the benchmark, then again whenever the --config file or the
+--report-template changes, until interrupted; each run reads the config
+file afresh. Without --preset it uses the quick preset, to iterate on a
+scenario quickly. A failed run is logged and the watch goes on.`,
+		Example: "  dbcompare comprehensive-benchmark --preset quick\n  dbcompare comprehensive-benchmark --config scenario.yaml --watch\n  dbcompare comprehensive-benchmark --results-file main.json\n  dbcompare comprehensive-benchmark --baseline main.json --max-regression 15",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runComprehensiveBenchmark(cmd, opts, bench)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&bench.iterations, "iterations", 100, "Operations per library and operation type")
+	flags.IntVar(&bench.concurrency, "concurrency", 3, "Concurrent workers per operation")
+	flags.IntVar(&bench.warmup, "warmup", 50, "Warmup rounds per library before measuring")
+	flags.StringSliceVar(&bench.operations, "operations", []string{"create", "read"}, "Operations to benchmark, comma separated")
+	flags.DurationVar(&bench.opTimeout, "op-timeout", defaults.TimeoutPerOp, "Timeout of a single operation")
+	flags.IntVar(&bench.dataSize, "data-size", defaults.DataSize, "Rows of test data to work with")
+	flags.StringVar(&bench.outputs.results, "results-file", "benchmark_results.json", "Output path of the JSON results")
+	flags.StringVar(&bench.outputs.report, "report-file", "benchmark_report.md", "Output path of the markdown report")
+	flags.StringVar(&bench.outputs.html, "html-file", "benchmark_report.html", "Output path of the HTML report")
+	flags.StringVar(&bench.outputs.shared, "shared-file", "benchmark_results_shared.json", "Output path of the anonymized results written with --anonymize")
+	flags.StringVar(&bench.outputs.pdf, "pdf-file", "benchmark_report.pdf", "Output path of the PDF report written with --pdf")
+	flags.StringVar(&bench.outputs.dir, "output-dir", "", "Directory for relative output paths, created if missing")
+	flags.BoolVar(&bench.outputs.timestamp, "timestamp", false, "Add the run timestamp to output file names so runs don't overwrite each other")
+	flags.IntVar(&bench.outputs.keep, "keep", 0, "With --timestamp, keep only the last N runs of each output file (0 keeps all)")
+	flags.StringVar(&bench.lang, "lang", "en", "Report and console language: en, ja or both")
+	flags.StringVar(&bench.reportTemplate, "report-template", "", "Path to a custom text/template or html/template report")
+	flags.StringVar(&bench.outputs.custom, "report-output", "benchmark_report_custom.md", "Output path for the custom template report")
+	flags.BoolVar(&bench.exportPDF, "pdf", false, "Also export the HTML report as PDF")
+	flags.BoolVar(&bench.pdfNoSandbox, "pdf-no-sandbox", false, "Run Chrome without its sandbox for --pdf, which root and containers do anyway")
+	flags.BoolVar(&bench.anonymize, "anonymize", false, "Also write the results without hostnames, DSNs or usernames")
+	flags.BoolVar(&bench.direct, "direct", false, "Bound concurrency with a semaphore instead of queueing jobs in the worker pool")
+	flags.BoolVar(&bench.saturation, "saturation", false, "Run the pool saturation scenario instead of the CRUD operations")
+	flags.DurationVar(&bench.chaosInterval, "chaos-interval", 0, "Terminate the database's client connections this often during the run, 0 disables")
+	flags.DurationVar(&bench.netLatency, "net-latency", 0, "Round-trip time to add to every connection through a network emulating proxy")
+	flags.Float64Var(&bench.netLoss, "net-loss", 0, "Percent of data the network emulating proxy delays as lost and retransmitted")
+	flags.BoolVar(&bench.connAffinity, "conn-affinity", false, "Give each worker a dedicated connection instead of sharing the library's pool")
+	flags.StringVar(&bench.layer, "layer", "direct", "Layer to benchmark through: direct, http or both")
+	flags.StringVar(&bench.layout, "layout", "plain", "Users table to benchmark: plain, or partitioned by migration 0009")
+	flags.StringVar(&bench.httpURL, "http-url", "", "Base URL of a running REST server for --layer http or both, in-process when empty")
+	flags.StringVar(&bench.baseline, "baseline", "", "Results file of an earlier run to check this run against for regressions")
+	flags.Float64Var(&bench.maxRegression, "max-regression", 10, "Percent an average time may grow over --baseline before it counts as a regression")
+	flags.BoolVar(&bench.dryRun, "dry-run", false, "Print the SQL of each operation instead of benchmarking it")
+	flags.StringVar(&bench.preset, "preset", "", "Size the run with a preset: "+strings.Join(benchmark.PresetNames(), ", "))
+	flags.IntSliceVar(&bench.sweep, "sweep-concurrency", nil, "Run every operation at each of these concurrencies, comma separated")
+	flags.StringVar(&bench.record, "record", "", "Record the repository calls of the in-process HTTP layer to this workload file for dbcompare replay")
+	flags.BoolVar(&bench.watch, "watch", false, "Re-run with the quick preset whenever the config file or report template changes")
+	return cmd
+}
+
+func runComprehensiveBenchmark(cmd *cobra.Command, opts *globalOptions, bench *comprehensiveOptions) error {
+	locale, err := benchmark.ParseLocale(bench.lang)
+	if err != nil {
+		return err
+	}
+
+	if bench.watch && !opts.watched {
+		var paths []string
+		for _, path := range []string{opts.configPath, bench.reportTemplate} {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("--watch requires --config or --report-template, it re-runs when they change")
+		}
+		return runWatch(cmd, opts, paths)
+	}
+	if bench.watch && bench.preset == "" {
+		bench.preset = "quick"
+	}
+
+	defaultTimeout := 10 * time.Minute
+	if bench.preset != "" {
+		preset, err := benchmark.LookupPreset(bench.preset)
+		if err != nil {
+			return err
+		}
+		if err := applyPreset(cmd.Flags(), preset); err != nil {
+			return err
+		}
+		defaultTimeout = preset.Timeout
+	}
+	for _, level := range bench.sweep {
+		if level <= 0 {
+			return fmt.Errorf("--sweep-concurrency levels must be positive, got %d", level)
+		}
+	}
+
+	if bench.dryRun {
+		operations := bench.operations
+		if bench.saturation {
+			operations = []string{"saturation"}
+		}
+		return runDryRun(cmd, opts, []string{"pq", "sqlx", "gorm"}, operations)
+	}
+
+	if bench.chaosInterval < 0 {
+		return fmt.Errorf("--chaos-interval must not be negative, got %v", bench.chaosInterval)
+	}
+	if bench.netLatency < 0 {
+		return fmt.Errorf("--net-latency must not be negative, got %v", bench.netLatency)
+	}
+	if bench.netLoss < 0 || bench.netLoss >= 100 {
+		return fmt.Errorf("--net-loss must be from 0 to below 100 percent, got %v", bench.netLoss)
+	}
+	if bench.maxRegression < 0 {
+		return fmt.Errorf("--max-regression must not be negative, got %v", bench.maxRegression)
+	}
+	// Read the baseline first, a typo should not cost a whole run
+	var baseline benchmark.ResultsFile
+	if bench.baseline != "" {
+		if baseline, err = benchmark.LoadResultsFile(bench.baseline); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := opts.commandContext(cmd, defaultTimeout)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	banner(w, "Go Database Comparison - Comprehensive Benchmark")
+	runStart := time.Now()
+	fmt.Fprintf(w, "Timestamp: %s\n", runStart.Format(time.RFC3339))
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	fmt.Fprintf(w, "Build: %s\n", buildinfo.Get())
+
+	outputs, err := bench.outputs.resolve(runStart)
+	if err != nil {
+		return err
+	}
+
+	runDirect, runHTTP, err := benchmarkLayers(bench.layer)
+	if err != nil {
+		return err
+	}
+	if bench.record != "" && (!runHTTP || bench.httpURL != "") {
+		return fmt.Errorf("--record requires --layer http or both with the in-process server, without --http-url")
+	}
+	// A remote server is the only thing the HTTP layer talks to
+	remoteOnly := !runDirect && bench.httpURL != ""
+	searchPath, err := searchPathOf(bench.layout)
+	if err != nil {
+		return err
+	}
+	if searchPath != "" && bench.httpURL != "" {
+		return fmt.Errorf("--layout %s cannot be applied to the server at --http-url", bench.layout)
+	}
+
+	// Initialize database configuration
+	config := opts.dbConfig()
+	config.SearchPath = searchPath
+	emulateNetwork := (bench.netLatency > 0 || bench.netLoss > 0) && !remoteOnly
+	if emulateNetwork {
+		proxied, stopProxy, err := startNetworkEmulation(config, bench.netLatency, bench.netLoss, log)
+		if err != nil {
+			return err
+		}
+		defer stopProxy()
+		config = proxied
+	}
+
+	// Health check
+	if !remoteOnly {
+		if err := database.HealthCheck(ctx, config); err != nil {
+			return fmt.Errorf("database health check failed: %w", err)
+		}
+		log.Info("database connectivity verified", "host", config.Host, "port", config.Port)
+	}
+	var partitions []string
+	if searchPath != "" {
+		if partitions, err = partitionedLayout(ctx, config); err != nil {
+			return err
+		}
+	}
+
+	// Configure benchmark
+	benchConfig := benchmark.DefaultBenchmarkConfig()
+	benchConfig.Iterations = bench.iterations
+	benchConfig.Concurrency = bench.concurrency
+	benchConfig.ConcurrencySweep = bench.sweep
+	benchConfig.WarmupRounds = bench.warmup
+	benchConfig.OperationTypes = bench.operations
+	benchConfig.TimeoutPerOp = bench.opTimeout
+	benchConfig.DataSize = bench.dataSize
+	benchConfig.Locale = locale
+	benchConfig.Logger = log
+	if opts.trace.enabled() {
+		benchConfig.TracerProvider = otel.GetTracerProvider()
+	}
+	benchConfig.DirectExecution = bench.direct
+	benchConfig.ConnAffinity = bench.connAffinity
+	benchConfig.RunID = opts.runID
+	benchConfig.ChaosInterval = bench.chaosInterval
+	if emulateNetwork {
+		benchConfig.Network = networkDescription(bench.netLatency, bench.netLoss)
+	}
+	if partitions != nil {
+		benchConfig.Layout = fmt.Sprintf("%s, hash partitioned on id into %d partitions; %s",
+			partition.Table, len(partitions), partition.Differences)
+	}
+	if bench.saturation {
+		benchmark.SaturationScenario(benchConfig)
+	}
+
+	fmt.Fprintf(w, "\nBenchmark Configuration:\n")
+	fmt.Fprintf(w, "   Iterations: %d\n", benchConfig.Iterations)
+	if bench.preset != "" {
+		fmt.Fprintf(w, "   Preset: %s\n", bench.preset)
+	}
+	if len(benchConfig.ConcurrencySweep) > 0 {
+		fmt.Fprintf(w, "   Concurrency: %v (sweep)\n", benchConfig.ConcurrencySweep)
+	} else {
+		fmt.Fprintf(w, "   Concurrency: %d\n", benchConfig.Concurrency)
+	}
+	fmt.Fprintf(w, "   Warmup Rounds: %d\n", benchConfig.WarmupRounds)
+	fmt.Fprintf(w, "   Operations: %v\n", benchConfig.OperationTypes)
+	fmt.Fprintf(w, "   Layer: %s\n", bench.layer)
+	if bench.chaosInterval > 0 {
+		fmt.Fprintf(w, "   Chaos: connections killed every %v\n", bench.chaosInterval)
+	}
+	if benchConfig.Network != "" {
+		fmt.Fprintf(w, "   Network: %s\n", benchConfig.Network)
+	}
+	if benchConfig.Layout != "" {
+		fmt.Fprintf(w, "   Table layout: %s\n", benchConfig.Layout)
+	}
+
+	// Initialize benchmark
+	perfBench := benchmark.NewPerformanceBenchmark(benchConfig)
+
+	// Run comprehensive benchmark
+	start := time.Now()
+
+	if runDirect {
+		if err := perfBench.RunComprehensiveBenchmark(ctx, config); err != nil {
+			return fmt.Errorf("benchmark failed: %w", err)
+		}
+	}
+	if runHTTP {
+		if err := runHTTPLayer(ctx, perfBench, bench, config, log); err != nil {
+			return fmt.Errorf("HTTP benchmark failed: %w", err)
+		}
+	}
+
+	totalDuration := time.Since(start)
+	log.Info("benchmark completed", "duration", totalDuration)
+
+	// For the report appendix, GORM builds the statements in DryRun
+	if methods, err := gormsql.Extract(ctx); err != nil {
+		log.Warn("failed to extract the SQL GORM builds", "error", err)
+	} else {
+		perfBench.SetGORMSQL(methods)
+	}
+
+	// Generate and display results
+	results := perfBench.GetResults()
+
+	fmt.Fprintf(w, "\n%s\n", locale.T("results_summary"))
+	fmt.Fprintln(w, "================================")
+
+	// Display results grouped by library
+	for _, library := range benchmark.Libraries {
+		fmt.Fprintf(w, "\n%s\n", locale.Tf("library_results", library))
+		fmt.Fprintf(w, "%-12s | %-11s | %7s | %s\n",
+			locale.T("operation"), locale.T("avg_time"), locale.T("ops_per_sec"), locale.T("success_rate"))
+		fmt.Fprintln(w, "-------------|-------------|---------|-------------")
+
+		for _, result := range results {
+			if result.Library == library {
+				fmt.Fprintf(w, "%-12s | %-11v | %7.1f | %10.1f%%\n",
+					result.Operation, result.AvgTime, result.OpsPerSec, result.SuccessRate)
+			}
+		}
+	}
+
+	// Chart throughput and tail latency per operation
+	fmt.Fprintf(w, "\n%s\n", locale.T("charts_heading"))
+	for _, group := range benchmark.GroupByOperation(results, benchConfig.OperationTypes) {
+		fmt.Fprintln(w)
+		fmt.Fprint(w, benchmark.OpsPerSecChart(group, locale, 40))
+		fmt.Fprint(w, benchmark.P95Chart(group, locale, 40))
+	}
+
+	// Generate detailed report
+	report := perfBench.GenerateReport()
+	htmlReport, err := perfBench.GenerateHTMLReport()
+	if err != nil {
+		log.Warn("failed to generate HTML report", "error", err)
+	}
+
+	// Save results to file
+	files := make(map[string]string)
+	if err := saveResults(perfBench.ResultsFile(), report, htmlReport, outputs); err != nil {
+		log.Warn("failed to save results", "error", err)
+	} else {
+		log.Info("results saved", "results", outputs.results, "report", outputs.report, "html", outputs.html)
+		files["results-file"], files["report-file"] = outputs.results, outputs.report
+		if htmlReport != "" {
+			files["html-file"] = outputs.html
+		}
+	}
+
+	// Export anonymized results for public sharing if requested
+	if bench.anonymize {
+		if err := saveSharedResults(perfBench.ResultsFile().Anonymize(), outputs.shared); err != nil {
+			log.Warn("failed to save anonymized results", "error", err)
+		} else {
+			log.Info("anonymized results saved", "path", outputs.shared)
+			files["shared-file"] = outputs.shared
+		}
+	}
+
+	// Export PDF report if requested
+	if bench.exportPDF && htmlReport != "" {
+		if err := benchmark.ExportPDF(ctx, htmlReport, outputs.pdf, bench.pdfNoSandbox); err != nil {
+			log.Warn("failed to export PDF report", "error", err)
+		} else {
+			log.Info("PDF report saved", "path", outputs.pdf)
+			files["pdf-file"] = outputs.pdf
+		}
+	}
+
+	// Render custom template report if requested
+	if bench.reportTemplate != "" {
+		if err := saveCustomReport(perfBench, bench.reportTemplate, outputs.custom, locale); err != nil {
+			log.Warn("failed to render custom report", "error", err)
+		} else {
+			log.Info("custom report saved", "path", outputs.custom)
+			files["report-output"] = outputs.custom
+		}
+	}
+
+	// Drop old timestamped runs now that this one is written
+	removed, err := bench.outputs.prune()
+	if err != nil {
+		log.Warn("failed to prune old runs", "error", err)
+	}
+	for _, path := range removed {
+		log.Debug("removed file of an older run", "path", path)
+	}
+
+	// Display performance comparison
+	fmt.Fprintf(w, "\n%s\n", locale.T("comparison_summary"))
+	displayPerformanceComparison(w, results, benchConfig.OperationTypes, locale)
+
+	// Display recommendations
+	fmt.Fprintf(w, "\n%s\n", locale.T("recommendations"))
+	displayRecommendations(w, results, locale)
+
+	var regressions []benchmark.Regression
+	if bench.baseline != "" {
+		regressions = benchmark.CompareToBaseline(results, baseline.Results, bench.maxRegression)
+		fmt.Fprintln(w)
+		displayRegressions(w, regressions, bench.baseline, bench.maxRegression, locale)
+	}
+
+	var partitionResults *partitionReport
+	if partitions != nil {
+		if partitionResults, err = probePartitions(ctx, config, opts.runID, partitions); err != nil {
+			log.Warn("failed to report partitions", "error", err)
+		} else {
+			printPartitionReport(w, partitionResults)
+		}
+	}
+
+	if opts.jsonOutput() {
+		doc := comprehensiveDocument{ResultsFile: perfBench.ResultsFile(), Files: files, Regressions: regressions, Partitions: partitionResults}
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if len(regressions) > 0 {
+		return regressionDetected(fmt.Errorf("%d operations regressed against %s", len(regressions), bench.baseline))
+	}
+	return nil
+}
+
+// displayRegressions lists the operations slower than in the baseline
+func displayRegressions(w io.Writer, regressions []benchmark.Regression, baseline string, maxRegression float64, locale benchmark.Locale) {
+	if len(regressions) == 0 {
+		fmt.Fprintln(w, locale.Tf("no_regressions", baseline))
+		return
+	}
+	fmt.Fprintln(w, locale.Tf("regressions", baseline, maxRegression))
+	for _, r := range regressions {
+		fmt.Fprintln(w, locale.Tf("regression", r.Library, r.Operation, r.BaselineAvg, r.AvgTime, r.Increase))
+	}
+}
+
+// applyPreset sets the sizing flags preset bundles, except for those given
+// on the command line, through the environment or in the config file
+func applyPreset(flags *pflag.FlagSet, preset benchmark.Preset) error {
+	values := map[string]string{
+		"iterations":  strconv.Itoa(preset.Iterations),
+		"concurrency": strconv.Itoa(preset.Concurrency),
+		"warmup":      strconv.Itoa(preset.WarmupRounds),
+		"operations":  strings.Join(preset.OperationTypes, ","),
+		"data-size":   strconv.Itoa(preset.DataSize),
+	}
+	if len(preset.ConcurrencySweep) > 0 {
+		levels := make([]string, len(preset.ConcurrencySweep))
+		for i, level := range preset.ConcurrencySweep {
+			levels[i] = strconv.Itoa(level)
+		}
+		values["sweep-concurrency"] = strings.Join(levels, ",")
+	}
+
+	for name, value := range values {
+		if flags.Changed(name) {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("preset %s: invalid %s: %w", preset.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// presetHelp describes benchmark.Presets for the command help, one line each
+func presetHelp() string {
+	var b strings.Builder
+	for _, preset := range benchmark.Presets {
+		fmt.Fprintf(&b, "  %-11s %s (%d iterations, concurrency %d, timeout %v)\n",
+			preset.Name, preset.Description, preset.Iterations, preset.Concurrency, preset.Timeout)
+	}
+	return b.String()
+}
+
+// benchmarkLayers parses --layer into whether to call the repositories
+// directly and whether to go through the REST server
+func benchmarkLayers(layer string) (direct, http bool, err error) {
+	switch layer {
+	case "direct":
+		return true, false, nil
+	case "http":
+		return false, true, nil
+	case "both":
+		return true, true, nil
+	default:
+		return false, false, fmt.Errorf("unknown layer %q (expected direct, http or both)", layer)
+	}
+}
+
+// runHTTPLayer benchmarks through the REST server at --http-url, or through
+// one started in-process when it is empty
+func runHTTPLayer(ctx context.Context, perfBench *benchmark.PerformanceBenchmark, bench *comprehensiveOptions, config *database.DatabaseConfig, log *slog.Logger) error {
+	baseURL := strings.TrimSuffix(bench.httpURL, "/")
+	envConfig := config
+	if baseURL == "" {
+		var recorder *replay.Recorder
+		if bench.record != "" {
+			var closeRecorder func()
+			var err error
+			if recorder, closeRecorder, err = createRecorder(bench.record, benchdata.RunID(ctx), log); err != nil {
+				return err
+			}
+			// Registered first so it runs after the server has stopped
+			defer closeRecorder()
+		}
+		url, stop, err := startInProcessServer(ctx, config, log, recorder)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		baseURL = url
+		log.Info("in-process REST server started", "url", baseURL)
+	} else if bench.layer == "http" {
+		envConfig = nil // The remote server's database is not ours to describe
+	}
+
+	// Keep one idle connection per worker so requests reuse them
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = bench.concurrency
+	defer transport.CloseIdleConnections()
+
+	target := benchmark.HTTPTarget{BaseURL: baseURL, Client: &http.Client{Transport: transport}}
+	return perfBench.RunHTTPBenchmark(ctx, target, envConfig)
+}
+
+func saveResults(resultsFile benchmark.ResultsFile, report, htmlReport string, paths outputPaths) error {
+	// Save JSON results
+	jsonData, err := json.MarshalIndent(resultsFile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := os.WriteFile(paths.results, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON results: %w", err)
+	}
+
+	// Save markdown report
+	if err := os.WriteFile(paths.report, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	// Save HTML report with latency heatmaps
+	if htmlReport != "" {
+		if err := os.WriteFile(paths.html, []byte(htmlReport), 0644); err != nil {
+			return fmt.Errorf("failed to write HTML report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func saveSharedResults(resultsFile benchmark.ResultsFile, path string) error {
+	jsonData, err := json.MarshalIndent(resultsFile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal anonymized results: %w", err)
+	}
+
+	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write anonymized results: %w", err)
+	}
+
+	return nil
+}
+
+func saveCustomReport(perfBench *benchmark.PerformanceBenchmark, templatePath, outputPath string, locale benchmark.Locale) error {
+	tmpl, err := benchmark.LoadReportTemplate(templatePath, locale)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create custom report: %w", err)
+	}
+	defer f.Close()
+
+	return perfBench.RenderReport(f, tmpl)
+}
+
+func displayPerformanceComparison(w io.Writer, results []benchmark.BenchmarkResult, operationOrder []string, locale benchmark.Locale) {
+	for _, group := range benchmark.GroupByOperation(results, operationOrder) {
+		opResults := group.Results
+		operation := group.Operation
+		if len(opResults) < 3 {
+			continue // Need all three libraries for comparison
+		}
+
+		if group.Winner == "" {
+			continue // No library timed a successful operation
+		}
+
+		fmt.Fprintf(w, "\n%s\n", locale.Tf("operation_winner", operation))
+
+		// Find fastest by average time and highest throughput, among the
+		// libraries that were measured
+		var fastest, highestThroughput benchmark.BenchmarkResult
+		for _, result := range opResults {
+			if result.AvgTime <= 0 {
+				continue
+			}
+			if fastest.AvgTime == 0 || result.AvgTime < fastest.AvgTime {
+				fastest = result
+			}
+			if result.OpsPerSec > highestThroughput.OpsPerSec {
+				highestThroughput = result
+			}
+		}
+
+		fmt.Fprintln(w, locale.Tf("fastest", fastest.Library, fastest.AvgTime))
+		fmt.Fprintln(w, locale.Tf("highest_throughput",
+			highestThroughput.Library, highestThroughput.OpsPerSec))
+	}
+}
+
+func displayRecommendations(w io.Writer, results []benchmark.BenchmarkResult, locale benchmark.Locale) {
+	keys := []string{
+		"rec_learning", "rec_learning_gorm",
+		"rec_performance", "rec_performance_pq",
+		"rec_balanced", "rec_balanced_sqlx",
+		"rec_enterprise", "rec_context", "rec_scaling",
+		"rec_insights", "rec_insight_pq", "rec_insight_sqlx", "rec_insight_gorm",
+	}
+
+	for _, key := range keys {
+		fmt.Fprintln(w, locale.T(key))
+	}
+}
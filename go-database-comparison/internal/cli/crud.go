@@ -0,0 +1,409 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/concurrency"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+func newTestCRUDCommand(opts *globalOptions) *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "test-crud",
+		Short: "Run create, read, update and delete with each library and the worker pool",
+		Long: `Run create, read, update and delete with each library and the worker pool.
+
+--dry-run prints the SQL each library would execute for each step instead,
+without connecting to the database.`,
+		Example: "  dbcompare test-crud\n  dbcompare test-crud --dry-run",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dryRun {
+				return runDryRun(cmd, opts, crudLibraryNames(), crudStepNames())
+			}
+			return runTestCRUD(cmd, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the SQL of each step instead of executing it")
+	return cmd
+}
+
+// crudReport is the outcome of test-crud, printed with --format json
+type crudReport struct {
+	RunID      string              `json:"run_id"`
+	Libraries  []crudLibraryReport `json:"libraries"`
+	Concurrent concurrentReport    `json:"concurrent"`
+}
+
+// crudLibraryReport holds the steps of crudScenario run on one library
+type crudLibraryReport struct {
+	Library  string           `json:"library"`
+	Duration time.Duration    `json:"duration"`
+	Steps    []crudStepReport `json:"steps"`
+}
+
+// crudStepReport is the outcome of one scenario step, which either passed
+// or was unsupported by the library
+type crudStepReport struct {
+	Step     string        `json:"step"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration"`
+}
+
+// concurrentReport is the outcome of the concurrent creates through the worker pool
+type concurrentReport struct {
+	Submitted   int           `json:"submitted"`
+	Succeeded   int           `json:"succeeded"`
+	AvgDuration time.Duration `json:"avg_duration"`
+}
+
+func runTestCRUD(cmd *cobra.Command, opts *globalOptions) error {
+	ctx, cancel := opts.commandContext(cmd, 2*time.Minute)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	banner(w, "🧪 Go Database Comparison - CRUD Operations Test")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+
+	// Test all three database libraries
+	report, err := testAllLibraries(ctx, w, opts.logger, opts.dbConfig())
+	if err != nil {
+		return fmt.Errorf("CRUD tests failed: %w", err)
+	}
+	report.RunID = opts.runID
+
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, report)
+	}
+	fmt.Fprintln(w, "✅ All CRUD operations completed successfully!")
+	return nil
+}
+
+// crudLibraries lists the implementations test-crud runs crudScenario on,
+// by the name openCRUDRepository takes
+var crudLibraries = []struct {
+	library     string
+	description string
+}{
+	{"pq", "lib/pq (raw SQL)"},
+	{"sqlx", "sqlx (SQL + struct mapping)"},
+	{"gorm", "GORM (ORM)"},
+}
+
+// crudLibraryNames returns the names of crudLibraries
+func crudLibraryNames() []string {
+	names := make([]string, len(crudLibraries))
+	for i, lib := range crudLibraries {
+		names[i] = lib.library
+	}
+	return names
+}
+
+// ageBucketRepository is implemented by repositories that aggregate the
+// users by age
+type ageBucketRepository interface {
+	CountUsersByAgeBucket(ctx context.Context) ([]*models.AgeBucket, error)
+}
+
+// errStepUnsupported marks a scenario step the repository has no method for
+var errStepUnsupported = errors.New("not supported")
+
+// crudRun is the state the scenario steps share while running on one library
+type crudRun struct {
+	library string
+	repo    repository.UserRepository
+	stamp   int64        // Tells this run's users apart from other runs'
+	user    *models.User // Created by the create step
+	others  []int        // Further users the steps created, deleted with user
+}
+
+// request returns a valid request for a user of kind, unique for n
+func (r *crudRun) request(ctx context.Context, kind string, n int64) *models.CreateUserRequest {
+	return &models.CreateUserRequest{
+		Name:  fmt.Sprintf("Test User %s %d", r.library, n),
+		Email: benchdata.Email(benchdata.RunID(ctx), kind, r.library, n),
+		Age:   25,
+	}
+}
+
+// crudStepFunc runs one scenario step
+type crudStepFunc func(ctx context.Context, r *crudRun) error
+
+// crudScenario is the list of steps test-crud runs on every library, in order
+var crudScenario = []struct {
+	name string
+	run  crudStepFunc
+}{
+	{"create", crudCreate},
+	{"read", crudRead},
+	{"update", crudUpdate},
+	{"search", crudSearch},
+	{"list", crudList},
+	{"transaction", crudTransaction},
+	{"batch", crudBatch},
+	{"delete", crudDelete},
+}
+
+// crudStepNames returns the names of the crudScenario steps, in order
+func crudStepNames() []string {
+	names := make([]string, len(crudScenario))
+	for i, step := range crudScenario {
+		names[i] = step.name
+	}
+	return names
+}
+
+func crudCreate(ctx context.Context, r *crudRun) (err error) {
+	r.user, err = r.repo.CreateUser(ctx, r.request(ctx, "test", r.stamp))
+	return err
+}
+
+func crudRead(ctx context.Context, r *crudRun) error {
+	user, err := r.repo.GetUserByID(ctx, r.user.ID)
+	if err != nil {
+		return err
+	}
+	if user.Email != r.user.Email {
+		return fmt.Errorf("read email %q, want %q", user.Email, r.user.Email)
+	}
+	return nil
+}
+
+func crudUpdate(ctx context.Context, r *crudRun) error {
+	newName := fmt.Sprintf("Updated %s User", r.library)
+	user, err := r.repo.UpdateUser(ctx, r.user.ID, &models.UpdateUserRequest{Name: &newName})
+	if err != nil {
+		return err
+	}
+	if user.Name != newName {
+		return fmt.Errorf("updated name %q, want %q", user.Name, newName)
+	}
+	return nil
+}
+
+func crudSearch(ctx context.Context, r *crudRun) error {
+	users, err := r.repo.GetUsersByEmail(ctx, r.user.Email)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.ID == r.user.ID {
+			return nil
+		}
+	}
+	return fmt.Errorf("searching %q did not find user %d", r.user.Email, r.user.ID)
+}
+
+func crudList(ctx context.Context, r *crudRun) error {
+	users, err := r.repo.GetAllUsers(ctx, 10, 0)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("listed no users, want at least the created one")
+	}
+	return nil
+}
+
+func crudTransaction(ctx context.Context, r *crudRun) error {
+	req := r.request(ctx, "txn", r.stamp)
+	user, err := r.repo.CreateUserWithTransaction(ctx, req)
+	if err != nil {
+		return err
+	}
+	r.others = append(r.others, user.ID)
+
+	if _, err := r.repo.CreateUserWithTransaction(ctx, req); err == nil {
+		return fmt.Errorf("a second user with email %s was created", req.Email)
+	}
+	return nil
+}
+
+func crudBatch(ctx context.Context, r *crudRun) error {
+	requests := make([]*models.CreateUserRequest, 3)
+	for i := range requests {
+		requests[i] = r.request(ctx, "batch", r.stamp+int64(i))
+	}
+	if _, err := r.repo.BatchCreateUsers(ctx, requests); err != nil {
+		return err
+	}
+
+	// Look the users up rather than trusting the returned IDs
+	for _, req := range requests {
+		users, err := r.repo.GetUsersByEmail(ctx, req.Email)
+		if err != nil {
+			return err
+		}
+		if len(users) != 1 {
+			return fmt.Errorf("found %d users with batch email %s, want 1", len(users), req.Email)
+		}
+		r.others = append(r.others, users[0].ID)
+	}
+	return nil
+}
+
+func crudDelete(ctx context.Context, r *crudRun) error {
+	for _, id := range append([]int{r.user.ID}, r.others...) {
+		if err := r.repo.DeleteUser(ctx, id); err != nil {
+			return err
+		}
+	}
+	if _, err := r.repo.GetUserByID(ctx, r.user.ID); err == nil {
+		return fmt.Errorf("user %d can still be read after deleting it", r.user.ID)
+	}
+	return nil
+}
+
+func testAllLibraries(ctx context.Context, w io.Writer, log *slog.Logger, config *database.DatabaseConfig) (crudReport, error) {
+	var report crudReport
+	for _, lib := range crudLibraries {
+		log.Info("testing " + lib.description)
+		libReport, err := testLibrary(ctx, w, log, config, lib.library)
+		if err != nil {
+			return crudReport{}, fmt.Errorf("%s test failed: %w", strings.ToUpper(lib.library), err)
+		}
+		report.Libraries = append(report.Libraries, libReport)
+	}
+
+	// Test concurrent operations
+	log.Info("testing concurrent operations with the goroutine pool")
+	concurrent, err := testConcurrentOperations(ctx, w, log, config)
+	if err != nil {
+		return crudReport{}, fmt.Errorf("Concurrent test failed: %w", err)
+	}
+	report.Concurrent = concurrent
+
+	return report, nil
+}
+
+// testLibrary connects library and runs crudScenario on it
+func testLibrary(ctx context.Context, w io.Writer, log *slog.Logger, config *database.DatabaseConfig, library string) (crudLibraryReport, error) {
+	name, repo, closeRepo, err := openCRUDRepository(ctx, library, config)
+	if err != nil {
+		return crudLibraryReport{}, err
+	}
+	defer closeRepo()
+	return runCRUDScenario(ctx, w, log, name, repo)
+}
+
+// runCRUDScenario runs crudScenario on repo, the repository of library
+// name, and prints each step's timing. A failing step is a verification
+// failure, see exit.go.
+func runCRUDScenario(ctx context.Context, w io.Writer, log *slog.Logger, name string, repo repository.UserRepository) (crudLibraryReport, error) {
+	run := &crudRun{library: name, repo: repo, stamp: time.Now().UnixNano()}
+	report := crudLibraryReport{Library: name, Steps: make([]crudStepReport, 0, len(crudScenario))}
+	start := time.Now()
+	for _, step := range crudScenario {
+		stepStart := time.Now()
+		err := step.run(ctx, run)
+		elapsed := time.Since(stepStart)
+		switch {
+		case errors.Is(err, errStepUnsupported):
+			report.Steps = append(report.Steps, crudStepReport{Step: step.name, Status: "unsupported"})
+		case err != nil:
+			return crudLibraryReport{}, verificationFailed(fmt.Errorf("%s failed: %w", step.name, err))
+		default:
+			log.Debug("step done", "library", name, "step", step.name, "duration", elapsed)
+			report.Steps = append(report.Steps, crudStepReport{Step: step.name, Status: "pass", Duration: elapsed})
+		}
+	}
+	report.Duration = time.Since(start)
+
+	fmt.Fprintf(w, "✓ %-5s CRUD passed in %v\n", name, report.Duration)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, step := range report.Steps {
+		if step.Status == "unsupported" {
+			fmt.Fprintf(tw, "    - %s\t%s\n", step.Step, errStepUnsupported)
+		} else {
+			fmt.Fprintf(tw, "    ✓ %s\t%v\n", step.Step, step.Duration.Round(time.Microsecond))
+		}
+	}
+	return report, tw.Flush()
+}
+
+func testConcurrentOperations(ctx context.Context, w io.Writer, log *slog.Logger, config *database.DatabaseConfig) (concurrentReport, error) {
+	// Create worker pool
+	pool := concurrency.NewDatabaseBenchmarkPool(ctx, 10)
+	pool.Start()
+	defer pool.Stop()
+
+	// Connect to database
+	db, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return concurrentReport{}, err
+	}
+	defer db.Close()
+
+	repo := repository.NewPQRepository(db)
+
+	// Submit concurrent create operations
+	numOperations := 50
+	log.Info("submitting concurrent create operations", "count", numOperations)
+
+	for i := 0; i < numOperations; i++ {
+		i := i // Capture loop variable
+		err := pool.SubmitBenchmarkJob("concurrent_create", func(ctx context.Context) (interface{}, error) {
+			req := &models.CreateUserRequest{
+				Name:  fmt.Sprintf("Concurrent User %d", i),
+				Email: benchdata.Email(benchdata.RunID(ctx), "concurrent", "pq", int64(i)),
+				Age:   20 + (i % 40),
+			}
+			return repo.CreateUser(ctx, req)
+		})
+		if err != nil {
+			return concurrentReport{}, fmt.Errorf("failed to submit job %d: %w", i, err)
+		}
+	}
+
+	// Collect results
+	collectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	results, err := pool.CollectAll(collectCtx)
+	if err != nil {
+		return concurrentReport{}, fmt.Errorf("failed to get all results: %w", err)
+	}
+
+	// Process results
+	successful := 0
+	var totalDuration time.Duration
+	for _, result := range results {
+		if result.Error == nil {
+			successful++
+			totalDuration += result.Duration
+		} else {
+			log.Warn("job failed", "job", result.JobID, "error", result.Error)
+		}
+	}
+
+	report := concurrentReport{Submitted: numOperations, Succeeded: successful}
+	if successful > 0 {
+		report.AvgDuration = totalDuration / time.Duration(successful)
+	}
+	fmt.Fprintf(w, "   ✅ Concurrent operations: %d/%d successful\n", successful, numOperations)
+	fmt.Fprintf(w, "   ⏱️  Average duration: %v\n", report.AvgDuration)
+
+	// Display benchmark stats
+	stats := pool.GetBenchmarkStats()
+	for _, op := range stats.Operations {
+		fmt.Fprintf(w, "   📊 %s: %d ops, avg %v, p95 %v, p99 %v\n",
+			op.Operation, op.Count, op.AvgDuration, op.P95Duration, op.P99Duration)
+	}
+	fmt.Fprintf(w, "   📊 Pool: %d completed, %d failed, queue wait p95 %v\n",
+		stats.Pool.Completed, stats.Pool.Failed, stats.Pool.QueueWaitP95)
+
+	return report, nil
+}
@@ -0,0 +1,394 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/buildinfo"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/resultsink"
+)
+
+// daemonOptions holds the flags of the daemon command
+type daemonOptions struct {
+	every       time.Duration
+	runTimeout  time.Duration
+	skipFirst   bool
+	keepData    bool
+	statusAddr  string
+	sinks       []string
+	resultsDSN  string
+	influxURL   string
+	influxToken string
+	sizing      sizingOptions
+}
+
+// daemonRun describes one scheduled benchmark run
+type daemonRun struct {
+	RunID      string            `json:"run_id"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Duration   string            `json:"duration"`
+	Results    int               `json:"results"`
+	Sinks      map[string]string `json:"sinks,omitempty"` // "ok" or the error, by sink
+	Error      string            `json:"error,omitempty"`
+}
+
+// daemonStatus is served at /status and tracks the schedule across runs
+type daemonStatus struct {
+	mu        sync.Mutex
+	StartedAt time.Time  `json:"started_at"`
+	Every     string     `json:"every"`
+	Preset    string     `json:"preset"`
+	Sinks     []string   `json:"sinks"`
+	Running   bool       `json:"running"`
+	Runs      int        `json:"runs"`
+	Failures  int        `json:"failures"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRun   *daemonRun `json:"last_run,omitempty"`
+}
+
+func newDaemonCommand(opts *globalOptions) *cobra.Command {
+	daemonOpts := daemonOptions{}
+	// The sizing flags default to the values of the default preset, which
+	// fills them in anyway
+	defaults, _ := benchmark.LookupPreset("standard")
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the benchmark on a schedule and store every run's results",
+		Long: `Run the benchmark on a schedule and store every run's results, to track
+the performance of a database instance over time.
+
+The first run starts right away, unless --skip-first, and the next one
+--every after the start of the previous one; a run taking longer than
+--every is followed by the next at once. Each run gets its own run ID, and
+the users it created are removed when it ends unless --keep-data.
+
+--sink selects where results go:
+
+  postgres  the benchmark_runs and benchmark_results tables, created when
+            missing, in the benchmark database or --results-dsn
+  influx    InfluxDB line protocol posted to --influx-url, measurement
+            ` + resultsink.Measurement + ` tagged by library and operation
+
+--preset and the sizing flags configure the benchmark like those of
+comprehensive-benchmark; the preset's timeout bounds each run unless
+--run-timeout. Only direct repository calls are benchmarked.
+
+--status-addr serves the schedule, run counts and the last run as JSON at
+/status, and a liveness probe at /healthz. The daemon runs until
+interrupted, or for --timeout when set.`,
+		Example: "  dbcompare daemon --every 24h\n  dbcompare daemon --every 6h --preset quick --sink postgres,influx \\\n    --influx-url 'http://localhost:8086/api/v2/write?org=perf&bucket=dbcompare'",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(cmd, opts, &daemonOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.DurationVar(&daemonOpts.every, "every", 24*time.Hour, "Interval between the starts of two runs")
+	flags.DurationVar(&daemonOpts.runTimeout, "run-timeout", 0, "Time limit of each run, the preset's timeout when 0")
+	flags.BoolVar(&daemonOpts.skipFirst, "skip-first", false, "Wait --every before the first run instead of starting right away")
+	flags.BoolVar(&daemonOpts.keepData, "keep-data", false, "Keep the users each run created instead of removing them")
+	flags.StringVar(&daemonOpts.statusAddr, "status-addr", ":8090", "Address to serve the status endpoint on, empty to disable")
+	flags.StringSliceVar(&daemonOpts.sinks, "sink", []string{"postgres"}, "Where to store results: "+strings.Join(resultsink.Names, ", ")+", comma separated")
+	flags.StringVar(&daemonOpts.resultsDSN, "results-dsn", "", "PostgreSQL DSN of the database for the postgres sink, the benchmark database when empty")
+	flags.StringVar(&daemonOpts.influxURL, "influx-url", "", "InfluxDB write URL for the influx sink, including org and bucket or db")
+	flags.StringVar(&daemonOpts.influxToken, "influx-token", "", "InfluxDB API token for the influx sink")
+	daemonOpts.sizing.addFlags(flags, defaults)
+	return cmd
+}
+
+func runDaemon(cmd *cobra.Command, opts *globalOptions, daemonOpts *daemonOptions) error {
+	if daemonOpts.every <= 0 {
+		return fmt.Errorf("--every must be positive, got %v", daemonOpts.every)
+	}
+	if opts.runIDSet {
+		return fmt.Errorf("--run-id is not supported by daemon, each run generates its own")
+	}
+	preset, err := daemonOpts.sizing.resolve(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	runTimeout := daemonOpts.runTimeout
+	if runTimeout <= 0 {
+		runTimeout = preset.Timeout
+	}
+	for _, name := range daemonOpts.sinks {
+		if err := resultsink.ValidateName(name); err != nil {
+			return err
+		}
+		if name == "influx" && daemonOpts.influxURL == "" {
+			return fmt.Errorf("the influx sink requires --influx-url")
+		}
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log := opts.logger
+	config := opts.dbConfig()
+
+	sinks, closeSinks, err := openResultSinks(ctx, config, daemonOpts)
+	if err != nil {
+		return err
+	}
+	defer closeSinks()
+
+	status := &daemonStatus{
+		StartedAt: time.Now(),
+		Every:     daemonOpts.every.String(),
+		Preset:    preset.Name,
+		Sinks:     daemonOpts.sinks,
+	}
+
+	w := opts.textOutput(cmd)
+	fmt.Fprintf(w, "⏰ Benchmarking every %v with preset %s, storing results in %s\n",
+		daemonOpts.every, preset.Name, strings.Join(daemonOpts.sinks, ", "))
+
+	if daemonOpts.statusAddr != "" {
+		listener, err := net.Listen("tcp", daemonOpts.statusAddr)
+		if err != nil {
+			return fmt.Errorf("listen on %s: %w", daemonOpts.statusAddr, err)
+		}
+		statusServer := &http.Server{Handler: status.handler(), ReadHeaderTimeout: 5 * time.Second}
+		go statusServer.Serve(listener)
+		defer statusServer.Close()
+		fmt.Fprintf(w, "🌐 Serving status on http://%s/status\n", listener.Addr())
+	}
+
+	next := time.Now()
+	if daemonOpts.skipFirst {
+		next = next.Add(daemonOpts.every)
+	}
+	for {
+		status.scheduled(next)
+		log.Info("next benchmark run scheduled", "at", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Info("shutting down")
+			return nil
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		run := runScheduledBenchmark(ctx, config, daemonOpts, runTimeout, sinks, log, status)
+		if ctx.Err() != nil {
+			// Interrupted mid-run, the partial run is not worth a report
+			log.Info("shutting down")
+			return nil
+		}
+
+		if run.Error != "" {
+			fmt.Fprintf(w, "❌ Run %s failed after %s: %s\n", run.RunID, run.Duration, run.Error)
+		} else {
+			fmt.Fprintf(w, "✅ Run %s finished in %s with %d results\n", run.RunID, run.Duration, run.Results)
+		}
+		if opts.jsonOutput() {
+			if err := opts.printJSON(cmd, run); err != nil {
+				return err
+			}
+		}
+
+		next = start.Add(daemonOpts.every)
+		if now := time.Now(); next.Before(now) {
+			next = now
+		}
+	}
+}
+
+// openResultSinks opens the sinks selected by --sink, with the function
+// closing them
+func openResultSinks(ctx context.Context, config *database.DatabaseConfig, daemonOpts *daemonOptions) ([]resultsink.Sink, func(), error) {
+	var sinks []resultsink.Sink
+	var closers []func()
+	closeAll := func() {
+		for _, closeSink := range closers {
+			closeSink()
+		}
+	}
+
+	for _, name := range daemonOpts.sinks {
+		switch name {
+		case "postgres":
+			db, err := openResultsDB(ctx, config, daemonOpts.resultsDSN)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			closers = append(closers, func() { db.Close() })
+			sink := resultsink.NewPostgresSink(db)
+			if err := sink.EnsureSchema(ctx); err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			sinks = append(sinks, sink)
+		case "influx":
+			sinks = append(sinks, resultsink.NewInfluxSink(daemonOpts.influxURL, daemonOpts.influxToken))
+		}
+	}
+	return sinks, closeAll, nil
+}
+
+// openResultsDB connects to dsn, or to the benchmark database when dsn is
+// empty
+func openResultsDB(ctx context.Context, config *database.DatabaseConfig, dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		return db, nil
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to results database: %w", err)
+	}
+	return db, nil
+}
+
+// runScheduledBenchmark runs the benchmark once under a new run ID, writes
+// its results to every sink and removes its users unless --keep-data. A
+// failing sink does not keep the others from being written.
+func runScheduledBenchmark(ctx context.Context, config *database.DatabaseConfig, daemonOpts *daemonOptions,
+	runTimeout time.Duration, sinks []resultsink.Sink, log *slog.Logger, status *daemonStatus) (run daemonRun) {
+	run = daemonRun{RunID: benchdata.NewRunID(), StartedAt: time.Now()}
+	status.started()
+	defer func() {
+		run.FinishedAt = time.Now()
+		run.Duration = run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond).String()
+		status.finished(run)
+	}()
+
+	log = log.With("run_id", run.RunID)
+	log.Info("benchmark run started")
+	runCtx, cancel := context.WithTimeout(benchdata.WithRunID(ctx, run.RunID), runTimeout)
+	defer cancel()
+
+	if err := database.HealthCheck(runCtx, config); err != nil {
+		run.Error = fmt.Sprintf("database health check failed: %v", err)
+		return run
+	}
+
+	if !daemonOpts.keepData {
+		defer func() {
+			// The run's own deadline may be what ended it, cleanup gets a fresh one
+			cleanupCtx, cancelCleanup := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Minute)
+			defer cancelCleanup()
+			if err := cleanupRun(cleanupCtx, config, run.RunID); err != nil {
+				log.Warn("failed to remove the run's data", "error", err)
+			}
+		}()
+	}
+
+	benchConfig := benchmark.DefaultBenchmarkConfig()
+	daemonOpts.sizing.apply(benchConfig)
+	benchConfig.Logger = log
+	benchConfig.RunID = run.RunID
+
+	perfBench := benchmark.NewPerformanceBenchmark(benchConfig)
+	if err := perfBench.RunComprehensiveBenchmark(runCtx, config); err != nil {
+		run.Error = fmt.Sprintf("benchmark failed: %v", err)
+		return run
+	}
+
+	file := perfBench.ResultsFile()
+	run.Results = len(file.Results)
+	stored := resultsink.Run{StartedAt: run.StartedAt, Duration: time.Since(run.StartedAt), File: file}
+	run.Sinks = make(map[string]string, len(sinks))
+	var failed []string
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, stored); err != nil {
+			log.Error("failed to store results", "sink", sink.Name(), "error", err)
+			run.Sinks[sink.Name()] = err.Error()
+			failed = append(failed, sink.Name())
+			continue
+		}
+		run.Sinks[sink.Name()] = "ok"
+	}
+	if len(failed) > 0 {
+		run.Error = fmt.Sprintf("failed to store results in %s", strings.Join(failed, ", "))
+	}
+	log.Info("benchmark run finished", "results", run.Results, "build", buildinfo.Get().Version)
+	return run
+}
+
+// cleanupRun removes the users the run with runID created
+func cleanupRun(ctx context.Context, config *database.DatabaseConfig, runID string) error {
+	db, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer db.Close()
+	_, err = benchdata.Cleanup(ctx, db, benchdata.Target{RunID: runID})
+	return err
+}
+
+func (s *daemonStatus) scheduled(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.NextRunAt = next
+}
+
+func (s *daemonStatus) started() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Running = true
+}
+
+func (s *daemonStatus) finished(run daemonRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Running = false
+	s.Runs++
+	if run.Error != "" {
+		s.Failures++
+	}
+	s.LastRun = &run
+}
+
+// handler serves the status as JSON at /status and a liveness probe at
+// /healthz
+func (s *daemonStatus) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		body, err := json.MarshalIndent(s, "", "  ")
+		s.mu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(body, '\n'))
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	return mux
+}
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/concurrency"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// deadlockSQLState is PostgreSQL's deadlock_detected
+const deadlockSQLState = "40P01"
+
+// deadlockRepository is a UserRepository that can take row locks in a
+// given order; PQ, SQLX and GORM repositories all implement it
+type deadlockRepository interface {
+	repository.UserRepository
+	TouchUsersInOrder(ctx context.Context, ids []int, hold time.Duration) error
+}
+
+// deadlockOptions holds the flags of the deadlock command
+type deadlockOptions struct {
+	libraries    []string
+	rounds       int
+	hold         time.Duration
+	ordered      bool
+	maxAttempts  int
+	retryBackoff time.Duration
+	keepData     bool
+}
+
+// deadlockResult tells how often one library deadlocked and recovered
+type deadlockResult struct {
+	Library        string        `json:"library"`
+	Transactions   int           `json:"transactions"`
+	Deadlocks      int           `json:"deadlocks"`       // Attempts aborted with 40P01
+	DeadlockRounds int           `json:"deadlock_rounds"` // Rounds in which either worker deadlocked
+	DeadlockRate   float64       `json:"deadlock_rate"`   // Percent of rounds that deadlocked
+	Retries        int           `json:"retries"`
+	Recovered      int           `json:"recovered"` // Transactions that deadlocked, then committed on a retry
+	Failed         int           `json:"failed"`    // Transactions that never committed
+	AvgLatency     time.Duration `json:"avg_latency"`
+	MaxLatency     time.Duration `json:"max_latency"`
+	ErrorType      string        `json:"error_type,omitempty"` // Go type of the driver error carrying 40P01
+	SQLState       string        `json:"sqlstate,omitempty"`
+	Message        string        `json:"message,omitempty"` // Error of the first deadlock, as the library returns it
+}
+
+// deadlockDocument is printed with --format json
+type deadlockDocument struct {
+	RunID       string           `json:"run_id"`
+	Rounds      int              `json:"rounds"`
+	Hold        time.Duration    `json:"hold"`
+	Ordered     bool             `json:"ordered"`
+	MaxAttempts int              `json:"max_attempts"`
+	Libraries   []deadlockResult `json:"libraries"`
+}
+
+func newDeadlockCommand(opts *globalOptions) *cobra.Command {
+	deadlockOpts := deadlockOptions{}
+	cmd := &cobra.Command{
+		Use:   "deadlock",
+		Short: "Provoke deadlocks and show how each library surfaces and recovers from them",
+		Long: `Provoke deadlocks with two workers updating the same two rows in opposite
+order, and show how each library surfaces PostgreSQL's deadlock_detected
+error (SQLSTATE 40P01) and how retrying recovers from it.
+
+Each library gets two users of this run. In every round both workers start
+a transaction at once; one updates the first user, holds the row lock for
+--hold and then updates the second, the other does the same the other way
+round. When both hold their first lock, PostgreSQL detects the cycle after
+its deadlock_timeout (1s by default) and aborts one of the transactions.
+Aborted transactions are retried up to --max-attempts attempts in total,
+with exponential backoff from --retry-backoff.
+
+--ordered has both workers update the users in the same order, which is
+the fix: the second worker waits for the first instead of deadlocking.
+
+The report lists per library how many rounds deadlocked, how many
+transactions a retry recovered and which error type carries the SQLSTATE.
+The users are removed afterwards unless --keep-data.`,
+		Example: "  dbcompare deadlock --rounds 20\n  dbcompare deadlock --max-attempts 1\n  dbcompare deadlock --ordered",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeadlock(cmd, opts, deadlockOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&deadlockOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to run, comma separated")
+	flags.IntVar(&deadlockOpts.rounds, "rounds", 10, "Rounds of two opposing transactions per library")
+	flags.DurationVar(&deadlockOpts.hold, "hold", 50*time.Millisecond, "How long each transaction holds its first row lock before taking the second")
+	flags.BoolVar(&deadlockOpts.ordered, "ordered", false, "Lock the rows in the same order in both workers, avoiding the deadlocks")
+	flags.IntVar(&deadlockOpts.maxAttempts, "max-attempts", 3, "Attempts per transaction including the first, 1 disables retries")
+	flags.DurationVar(&deadlockOpts.retryBackoff, "retry-backoff", 10*time.Millisecond, "Delay before the first retry, doubling with each further one")
+	flags.BoolVar(&deadlockOpts.keepData, "keep-data", false, "Keep the users the scenario created")
+	return cmd
+}
+
+func runDeadlock(cmd *cobra.Command, opts *globalOptions, deadlockOpts deadlockOptions) error {
+	if deadlockOpts.rounds <= 0 {
+		return fmt.Errorf("--rounds must be positive, got %d", deadlockOpts.rounds)
+	}
+	if deadlockOpts.hold < 0 {
+		return fmt.Errorf("--hold must not be negative, got %v", deadlockOpts.hold)
+	}
+	if deadlockOpts.maxAttempts < 1 {
+		return fmt.Errorf("--max-attempts must be at least 1, got %d", deadlockOpts.maxAttempts)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	policy := &concurrency.RetryPolicy{
+		MaxAttempts:    deadlockOpts.maxAttempts,
+		InitialBackoff: deadlockOpts.retryBackoff,
+		MaxBackoff:     time.Second,
+		Retryable:      benchmark.IsTransientError,
+	}
+
+	banner(w, "🔒 Go Database Comparison - Deadlock Scenario")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	order := "opposite"
+	if deadlockOpts.ordered {
+		order = "the same"
+	}
+	fmt.Fprintf(w, "%d rounds of two transactions locking two rows in %s order, holding each lock %v, up to %d attempts\n",
+		deadlockOpts.rounds, order, deadlockOpts.hold, deadlockOpts.maxAttempts)
+
+	doc := deadlockDocument{
+		RunID:       opts.runID,
+		Rounds:      deadlockOpts.rounds,
+		Hold:        deadlockOpts.hold,
+		Ordered:     deadlockOpts.ordered,
+		MaxAttempts: deadlockOpts.maxAttempts,
+	}
+	for i, library := range deadlockOpts.libraries {
+		name, repo, closeRepo, err := openCRUDRepository(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		// Once connected there may be users to remove
+		if i == 0 && !deadlockOpts.keepData {
+			defer func() {
+				if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+					log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+				}
+			}()
+		}
+		log.Info("running deadlock scenario", "library", name)
+		result, err := runDeadlockScenario(ctx, name, repo.(deadlockRepository), deadlockOpts, policy)
+		closeRepo()
+		if err != nil {
+			return fmt.Errorf("%s deadlock scenario failed: %w", name, err)
+		}
+		doc.Libraries = append(doc.Libraries, result)
+	}
+
+	fmt.Fprintln(w)
+	printDeadlockResults(w, doc.Libraries)
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, doc)
+	}
+	return nil
+}
+
+// runDeadlockScenario runs the rounds of opposing transactions on repo
+func runDeadlockScenario(ctx context.Context, library string, repo deadlockRepository, deadlockOpts deadlockOptions, policy *concurrency.RetryPolicy) (deadlockResult, error) {
+	var ids [2]int
+	for i := range ids {
+		user, err := repo.CreateUser(ctx, &models.CreateUserRequest{
+			Name:  fmt.Sprintf("Deadlock User %d", i+1),
+			Email: benchdata.Email(benchdata.RunID(ctx), "deadlock", library, int64(i+1)),
+			Age:   30,
+		})
+		if err != nil {
+			return deadlockResult{}, fmt.Errorf("failed to create user: %w", err)
+		}
+		ids[i] = user.ID
+	}
+	orders := [2][]int{{ids[0], ids[1]}, {ids[1], ids[0]}}
+	if deadlockOpts.ordered {
+		orders[1] = orders[0]
+	}
+
+	result := deadlockResult{Library: library}
+	var mu sync.Mutex
+	var total time.Duration
+	for round := 0; round < deadlockOpts.rounds; round++ {
+		deadlocked := false
+		var wg sync.WaitGroup
+		for worker := range orders {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				deadlocks := 0
+				start := time.Now()
+				attempts, err := policy.Do(ctx, func(ctx context.Context) error {
+					err := repo.TouchUsersInOrder(ctx, orders[worker], deadlockOpts.hold)
+					if benchmark.SQLState(err) == deadlockSQLState {
+						deadlocks++
+						mu.Lock()
+						if result.Message == "" {
+							result.ErrorType = driverErrorType(err)
+							result.SQLState = deadlockSQLState
+							result.Message = err.Error()
+						}
+						mu.Unlock()
+					}
+					return err
+				})
+				elapsed := time.Since(start)
+
+				mu.Lock()
+				defer mu.Unlock()
+				result.Transactions++
+				result.Deadlocks += deadlocks
+				result.Retries += attempts - 1
+				total += elapsed
+				if elapsed > result.MaxLatency {
+					result.MaxLatency = elapsed
+				}
+				if deadlocks > 0 {
+					deadlocked = true
+				}
+				switch {
+				case err != nil:
+					result.Failed++
+				case deadlocks > 0:
+					result.Recovered++
+				}
+			}()
+		}
+		wg.Wait()
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if deadlocked {
+			result.DeadlockRounds++
+		}
+	}
+
+	result.AvgLatency = total / time.Duration(result.Transactions)
+	result.DeadlockRate = float64(result.DeadlockRounds) / float64(deadlockOpts.rounds) * 100
+	return result, nil
+}
+
+// driverErrorType returns the Go type of the innermost error err wraps,
+// the driver's own error type for database errors
+func driverErrorType(err error) string {
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
+
+// printDeadlockResults prints the deadlock counts of every library and the
+// error each surfaced them with
+func printDeadlockResults(w io.Writer, results []deadlockResult) {
+	fmt.Fprintf(w, "%-6s | %12s | %9s | %8s | %7s | %9s | %6s | %-11s | %s\n",
+		"Lib", "Transactions", "Deadlocks", "Rate", "Retries", "Recovered", "Failed", "Avg Time", "Max Time")
+	fmt.Fprintln(w, "-------|--------------|-----------|----------|---------|-----------|--------|-------------|------------")
+	for _, r := range results {
+		fmt.Fprintf(w, "%-6s | %12d | %9d | %7.1f%% | %7d | %9d | %6d | %-11v | %v\n",
+			r.Library, r.Transactions, r.Deadlocks, r.DeadlockRate, r.Retries, r.Recovered, r.Failed,
+			r.AvgLatency.Round(time.Microsecond), r.MaxLatency.Round(time.Microsecond))
+	}
+
+	fmt.Fprintln(w)
+	for _, r := range results {
+		if r.Message == "" {
+			fmt.Fprintf(w, "%s: no deadlock\n", r.Library)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s with SQLSTATE %s\n  %s\n", r.Library, r.ErrorType, r.SQLState, r.Message)
+	}
+}
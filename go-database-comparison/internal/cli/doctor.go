@@ -0,0 +1,454 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/doctor"
+	"go-database-comparison/pkg/gormsql"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/querycount"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+func newDoctorCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:     "doctor",
+		Aliases: []string{"verify", "final-verification"},
+		Short:   "Check the database and the implementations are fit for benchmarking",
+		Long: `Check the database and the three implementations are fit for benchmarking
+and report every check as pass, warn, fail or skip.
+
+doctor checks the Go and PostgreSQL versions; that the tables, constraints
+and indexes the migrations create exist; that the libraries' pools are
+sized alike and fit max_connections; that every library runs CRUD with the
+expected statements and SQL equivalent to lib/pq's, also as GORM builds it
+in DryRun for every repository method (see gorm-sql); and that not found,
+constraint, timeout and rollback errors surface as they should. Checks
+needing a connection that failed are skipped.
+
+The command exits with code 2 when a check fails, or 1 when a library
+could not connect at all; warnings do not fail it.`,
+		Example: "  dbcompare doctor\n  dbcompare doctor --format json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd, opts)
+		},
+	}
+}
+
+// doctorLibrary is one library's connection for the doctor checks
+type doctorLibrary struct {
+	name    string
+	repo    repository.UserRepository // Nil when connecting failed
+	db      *sql.DB
+	err     error
+	connect time.Duration
+}
+
+// connectDoctorLibraries connects every library, keeping failures for
+// their connection checks to report
+func connectDoctorLibraries(ctx context.Context, config *database.DatabaseConfig) []*doctorLibrary {
+	pq := &doctorLibrary{name: "PQ"}
+	start := time.Now()
+	if pq.db, pq.err = database.ConnectWithPQ(ctx, config); pq.err == nil {
+		pq.repo = repository.NewPQRepository(pq.db)
+	}
+	pq.connect = time.Since(start)
+
+	sqlx := &doctorLibrary{name: "SQLX"}
+	start = time.Now()
+	if sqlxDB, err := database.ConnectWithSQLX(ctx, config); err == nil {
+		sqlx.db, sqlx.repo = sqlxDB.DB, repository.NewSQLXRepository(sqlxDB)
+	} else {
+		sqlx.err = err
+	}
+	sqlx.connect = time.Since(start)
+
+	gorm := &doctorLibrary{name: "GORM"}
+	start = time.Now()
+	if gormDB, err := database.ConnectWithGORM(ctx, config); err == nil {
+		gorm.db, _ = gormDB.DB()
+		gorm.repo = repository.NewGORMRepository(gormDB)
+	} else {
+		gorm.err = err
+	}
+	gorm.connect = time.Since(start)
+
+	return []*doctorLibrary{pq, sqlx, gorm}
+}
+
+func runDoctor(cmd *cobra.Command, opts *globalOptions) error {
+	ctx, cancel := opts.commandContext(cmd, time.Minute)
+	defer cancel()
+
+	log := opts.logger
+	config := opts.dbConfig()
+
+	libraries := connectDoctorLibraries(ctx, config)
+	defer func() {
+		for _, lib := range libraries {
+			if lib.db != nil {
+				lib.db.Close()
+			}
+		}
+	}()
+
+	report := doctor.Run(ctx, doctorChecks(log, config, libraries), func(result doctor.Result) {
+		log.Debug("check done", "category", result.Category, "check", result.Name, "status", result.Status, "duration", result.Duration)
+	})
+
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, report); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(cmd.OutOrStdout(), opts.runID, report)
+	}
+
+	if report.OK() {
+		return nil
+	}
+	err := fmt.Errorf("%d of %d checks failed", report.Failed, len(report.Results))
+	for _, lib := range libraries {
+		if lib.err != nil {
+			return err // Nothing could be verified through that library
+		}
+	}
+	return verificationFailed(err)
+}
+
+// doctorChecks lists the checks in the order they run and are reported
+func doctorChecks(log *slog.Logger, config *database.DatabaseConfig, libraries []*doctorLibrary) []doctor.Check {
+	var checks []doctor.Check
+	maxOpen := make(map[string]int)
+	connected := true
+	for _, lib := range libraries {
+		checks = append(checks, connectionCheck(lib))
+		if lib.repo == nil {
+			connected = false
+			continue
+		}
+		maxOpen[lib.name] = lib.db.Stats().MaxOpenConnections
+	}
+
+	checks = append(checks, doctor.GoVersionCheck())
+	pq := libraries[0]
+	serverChecks := []doctor.Check{doctor.ServerVersionCheck(pq.db)}
+	serverChecks = append(serverChecks, doctor.SchemaChecks(pq.db)...)
+	serverChecks = append(serverChecks, doctor.PoolCheck(pq.db, maxOpen))
+	if pq.repo == nil {
+		serverChecks = doctor.Skipped("no PQ connection", serverChecks...)
+	}
+	checks = append(checks, serverChecks...)
+
+	for _, lib := range libraries {
+		libraryChecks := []doctor.Check{
+			{Category: "crud", Name: lib.name + " CRUD statements", Run: func(ctx context.Context) (string, error) {
+				if err := testCRUDCompleteness(ctx, lib.name, lib.repo); err != nil {
+					return "", err
+				}
+				return "create, read, update and delete ran the expected statements", nil
+			}},
+			{Category: "errors", Name: lib.name + " error handling", Run: func(ctx context.Context) (string, error) {
+				return checkErrorHandling(ctx, lib.name, lib.repo)
+			}},
+			{Category: "errors", Name: lib.name + " transaction rollback", Run: func(ctx context.Context) (string, error) {
+				return checkRollback(ctx, lib.name, lib.repo, pq.db)
+			}},
+		}
+		switch {
+		case lib.repo == nil:
+			libraryChecks = doctor.Skipped("no "+lib.name+" connection", libraryChecks...)
+		case pq.repo == nil:
+			// The rollback check counts rows through PQ
+			libraryChecks = append(libraryChecks[:2], doctor.Skipped("no PQ connection", libraryChecks[2])...)
+		}
+		checks = append(checks, libraryChecks...)
+	}
+
+	equivalence := doctor.Check{Category: "crud", Name: "SQL equivalence", Run: func(ctx context.Context) (string, error) {
+		if err := verifySQLEquivalence(ctx, log, config); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("equivalent to PQ, %d known differences", len(knownSQLDifferences)), nil
+	}}
+	// Needs no connection, GORM builds the statements in DryRun
+	dryRunEquivalence := doctor.Check{Category: "crud", Name: "GORM DryRun SQL", Run: func(ctx context.Context) (string, error) {
+		methods, err := gormsql.Extract(ctx)
+		if err != nil {
+			return "", err
+		}
+		diffs := gormsql.Checker(methods).Differences()
+		if unexpected := unexpectedSQLDifferences(log, diffs); len(unexpected) > 0 {
+			return "", fmt.Errorf("GORM builds other statements than the hand-written SQL:\n  %s", strings.Join(unexpected, "\n  "))
+		}
+		return fmt.Sprintf("equivalent to the hand-written SQL in %d methods, %d known differences", gormsql.Compared(methods)-len(diffs), len(diffs)), nil
+	}}
+	if !connected {
+		checks = append(checks, doctor.Skipped("not every library connected", equivalence)...)
+	} else {
+		checks = append(checks, equivalence)
+	}
+	return append(checks, dryRunEquivalence)
+}
+
+// connectionCheck reports how connecting lib went
+func connectionCheck(lib *doctorLibrary) doctor.Check {
+	return doctor.Check{Category: "connection", Name: lib.name, Run: func(ctx context.Context) (string, error) {
+		if lib.err != nil {
+			return "", lib.err
+		}
+		return fmt.Sprintf("connected in %v", lib.connect.Round(time.Millisecond)), nil
+	}}
+}
+
+// checkErrorHandling checks repo reports a missing user, rejects a user
+// the schema's constraints forbid and gives up once its context expired
+func checkErrorHandling(ctx context.Context, name string, repo repository.UserRepository) (string, error) {
+	if _, err := repo.GetUserByID(ctx, -1); err == nil {
+		return "", fmt.Errorf("reading a missing user returned no error")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return "", fmt.Errorf("reading a missing user: want a not found error, got %v", err)
+	}
+
+	timestamp := time.Now().UnixNano()
+	invalid := &models.CreateUserRequest{
+		Name:  fmt.Sprintf("Doctor %s %d", name, timestamp),
+		Email: benchdata.Email(benchdata.RunID(ctx), "doctor", name, timestamp),
+		Age:   -1,
+	}
+	if user, err := repo.CreateUser(ctx, invalid); err == nil {
+		repo.DeleteUser(ctx, user.ID)
+		return "", fmt.Errorf("creating a user aged -1 succeeded, the age check constraint is not enforced")
+	}
+
+	expired, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+	defer cancel()
+	_, err := repo.GetUserByID(expired, 1)
+	if category := benchmark.ClassifyError(err); category != benchmark.ErrorTimeout {
+		return "", fmt.Errorf("reading with an expired context: want a timeout, got %v", err)
+	}
+	return "not found, constraint violation and timeout reported", nil
+}
+
+// checkRollback checks a transaction failing on a duplicate email leaves
+// only the first user behind, counting rows through db
+func checkRollback(ctx context.Context, name string, repo repository.UserRepository, db *sql.DB) (string, error) {
+	timestamp := time.Now().UnixNano()
+	req := &models.CreateUserRequest{
+		Name:  fmt.Sprintf("Transaction Test %s %d", name, timestamp),
+		Email: benchdata.Email(benchdata.RunID(ctx), "txn", name, timestamp),
+		Age:   25,
+	}
+
+	user, err := repo.CreateUserWithTransaction(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("transaction failed: %w", err)
+	}
+	defer repo.DeleteUser(ctx, user.ID)
+
+	// Repositories may reject the duplicate before inserting or through the
+	// unique constraint; either way nothing may be left behind
+	if _, err := repo.CreateUserWithTransaction(ctx, req); err == nil {
+		return "", fmt.Errorf("a second user with the same email was created")
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email = $1", req.Email).Scan(&count); err != nil {
+		return "", err
+	}
+	if count != 1 {
+		return "", fmt.Errorf("%d users with the duplicate email after rollback, want 1", count)
+	}
+	return "duplicate email rolled back", nil
+}
+
+// printDoctorReport prints report as a table followed by its totals
+func printDoctorReport(w io.Writer, runID string, report doctor.Report) {
+	banner(w, "🩺 dbcompare doctor")
+	fmt.Fprintf(w, "Run ID: %s\n\n", runID)
+
+	symbols := map[doctor.Status]string{
+		doctor.StatusPass: "✓",
+		doctor.StatusWarn: "!",
+		doctor.StatusFail: "✗",
+		doctor.StatusSkip: "-",
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, result := range report.Results {
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", symbols[result.Status], result.Status, result.Category, result.Name, result.Detail)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d passed, %d warned, %d failed, %d skipped\n", report.Passed, report.Warned, report.Failed, report.Skipped)
+}
+
+// crudStatements is the number of statements each CRUD method is expected
+// to execute per library. GORM's UpdateUser looks the row up, updates it
+// and reloads it, where the SQL libraries use a single UPDATE ... RETURNING.
+var crudStatements = map[string]map[string]int64{
+	"CreateUser":  {"PQ": 1, "SQLX": 1, "GORM": 1},
+	"GetUserByID": {"PQ": 1, "SQLX": 1, "GORM": 1},
+	"UpdateUser":  {"PQ": 1, "SQLX": 1, "GORM": 3},
+	"DeleteUser":  {"PQ": 1, "SQLX": 1, "GORM": 1},
+}
+
+// expectStatements runs fn and checks it executed the statements listed
+// for method and library in crudStatements
+func expectStatements(ctx context.Context, name, method string, fn func(ctx context.Context) error) error {
+	if err := querycount.Expect(ctx, crudStatements[method][name], fn); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
+
+func testCRUDCompleteness(ctx context.Context, name string, repo repository.UserRepository) error {
+	return runCRUD(ctx, name, repo, func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+		return expectStatements(ctx, name, method, call)
+	})
+}
+
+// crudStep runs call, the repository method named method, for runCRUD
+type crudStep func(ctx context.Context, method string, call func(ctx context.Context) error) error
+
+// runCRUD creates, reads, updates and deletes a user through repo,
+// running each repository call through step
+func runCRUD(ctx context.Context, name string, repo repository.UserRepository, step crudStep) error {
+	timestamp := time.Now().UnixNano()
+	req := &models.CreateUserRequest{
+		Name:  fmt.Sprintf("Verification %s %d", name, timestamp),
+		Email: benchdata.Email(benchdata.RunID(ctx), "verify", name, timestamp),
+		Age:   30,
+	}
+
+	var user *models.User
+
+	// Test Create
+	err := step(ctx, "CreateUser", func(ctx context.Context) (err error) {
+		user, err = repo.CreateUser(ctx, req)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("create failed: %w", err)
+	}
+
+	// Test Read
+	err = step(ctx, "GetUserByID", func(ctx context.Context) (err error) {
+		_, err = repo.GetUserByID(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+
+	// Test Update
+	newName := fmt.Sprintf("Updated %s", name)
+	updateReq := &models.UpdateUserRequest{Name: &newName}
+	err = step(ctx, "UpdateUser", func(ctx context.Context) (err error) {
+		_, err = repo.UpdateUser(ctx, user.ID, updateReq)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+
+	// Test Delete
+	err = step(ctx, "DeleteUser", func(ctx context.Context) error {
+		return repo.DeleteUser(ctx, user.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	return nil
+}
+
+// knownSQLDifferences lists where a library is expected to execute other
+// statements than PQ, by library and method, with the reason
+var knownSQLDifferences = map[string]string{
+	"GORM UpdateUser":                "GORM looks the row up, updates it by primary key and reloads it",
+	"GORM CreateUserWithTransaction": "GORM counts the users with the email where PQ asks EXISTS",
+}
+
+// verifySQLEquivalence runs the CRUD methods of every library while
+// capturing the SQL they execute, and fails unless each library executes
+// statements equivalent to PQ's or the difference is a known one
+func verifySQLEquivalence(ctx context.Context, log *slog.Logger, config *database.DatabaseConfig) error {
+	captureConfig := *config
+	captureConfig.QueryLog = sqlcapture.Sink()
+
+	pqDB, err := database.ConnectWithPQ(ctx, &captureConfig)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer pqDB.Close()
+
+	sqlxDB, err := database.ConnectWithSQLX(ctx, &captureConfig)
+	if err != nil {
+		return fmt.Errorf("SQLX connection failed: %w", err)
+	}
+	defer sqlxDB.Close()
+
+	gormDB, err := database.ConnectWithGORM(ctx, &captureConfig)
+	if err != nil {
+		return fmt.Errorf("GORM connection failed: %w", err)
+	}
+	sqlDB, _ := gormDB.DB()
+	defer sqlDB.Close()
+
+	checker := sqlcapture.NewChecker("PQ")
+	repos := []struct {
+		name string
+		repo repository.UserRepository
+	}{
+		{"PQ", repository.NewPQRepository(pqDB)},
+		{"SQLX", repository.NewSQLXRepository(sqlxDB)},
+		{"GORM", repository.NewGORMRepository(gormDB)},
+	}
+	for _, r := range repos {
+		err := runCRUD(ctx, r.name, r.repo, func(ctx context.Context, method string, call func(ctx context.Context) error) error {
+			ctx, recorder := sqlcapture.WithRecorder(ctx)
+			if err := call(ctx); err != nil {
+				return err
+			}
+			checker.Add(method, r.name, recorder.Statements())
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s SQL capture failed: %w", r.name, err)
+		}
+	}
+
+	if unexpected := unexpectedSQLDifferences(log, checker.Differences()); len(unexpected) > 0 {
+		return fmt.Errorf("SQL statements differ:\n  %s", strings.Join(unexpected, "\n  "))
+	}
+	return nil
+}
+
+// unexpectedSQLDifferences returns diffs that are not known differences,
+// logging the known ones
+func unexpectedSQLDifferences(log *slog.Logger, diffs []sqlcapture.Difference) []string {
+	var unexpected []string
+	for _, diff := range diffs {
+		if reason, ok := knownSQLDifferences[diff.Library+" "+diff.Operation]; ok {
+			log.Debug("known SQL difference", "library", diff.Library, "method", diff.Operation, "reason", reason, "diff", diff.String())
+			continue
+		}
+		unexpected = append(unexpected, diff.String())
+	}
+	return unexpected
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/dryrun"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+// dryRunReport lists the statements each library would execute, printed
+// by --dry-run
+type dryRunReport struct {
+	Libraries []dryRunLibrary `json:"libraries"`
+}
+
+// dryRunLibrary holds the statements of each operation of one library
+type dryRunLibrary struct {
+	Library    string            `json:"library"`
+	Operations []dryRunOperation `json:"operations"`
+}
+
+// dryRunOperation is the SQL one operation would execute, in order. Note
+// explains an operation without statements.
+type dryRunOperation struct {
+	Operation  string            `json:"operation"`
+	Statements []dryRunStatement `json:"statements"`
+	Note       string            `json:"note,omitempty"`
+}
+
+// dryRunStatement is one statement as the library would send it
+type dryRunStatement struct {
+	SQL  string        `json:"sql"`
+	Args []interface{} `json:"args,omitempty"`
+}
+
+// dryRunOperations maps the operation names of test-crud and the benchmark
+// commands to the repository call each of them times
+var dryRunOperations = map[string]func(ctx context.Context, library string, repo repository.UserRepository) error{
+	"create": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		_, err := repo.CreateUser(ctx, dryRunRequest(ctx, library, 1))
+		return err
+	},
+	"read": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		_, err := repo.GetUserByID(ctx, 1)
+		return err
+	},
+	"update": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		name := "Updated Dry Run User"
+		_, err := repo.UpdateUser(ctx, 1, &models.UpdateUserRequest{Name: &name})
+		return err
+	},
+	"delete": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		return repo.DeleteUser(ctx, 1)
+	},
+	"search": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		_, err := repo.GetUsersByEmail(ctx, "example.com")
+		return err
+	},
+	"list": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		_, err := repo.GetAllUsers(ctx, 10, 0)
+		return err
+	},
+	"transaction": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		_, err := repo.CreateUserWithTransaction(ctx, dryRunRequest(ctx, library, 1))
+		return err
+	},
+	"age_buckets": func(ctx context.Context, library string, repo repository.UserRepository) error {
+		bucketRepo, ok := repo.(ageBucketRepository)
+		if !ok {
+			return errStepUnsupported
+		}
+		_, err := bucketRepo.CountUsersByAgeBucket(ctx)
+		return err
+	},
+	"batch":        dryRunBatch,
+	"batch_create": dryRunBatch,
+}
+
+func dryRunBatch(ctx context.Context, library string, repo repository.UserRepository) error {
+	requests := make([]*models.CreateUserRequest, 3)
+	for i := range requests {
+		requests[i] = dryRunRequest(ctx, library, int64(i+1))
+	}
+	_, err := repo.BatchCreateUsers(ctx, requests)
+	return err
+}
+
+// dryRunRequest returns a request like the ones the commands create users
+// with, unique for n
+func dryRunRequest(ctx context.Context, library string, n int64) *models.CreateUserRequest {
+	return &models.CreateUserRequest{
+		Name:  fmt.Sprintf("Dry Run %s %d", library, n),
+		Email: benchdata.Email(benchdata.RunID(ctx), "dryrun", library, n),
+		Age:   25,
+	}
+}
+
+// openDryRunRepository returns the repository of library, see
+// openCRUDRepository, on a connection that records statements instead of
+// executing them
+func openDryRunRepository(library string) (string, repository.UserRepository, func(), error) {
+	sink := sqlcapture.Sink()
+	switch strings.ToLower(library) {
+	case "pq":
+		db := dryrun.OpenDB(sink, "PQ")
+		return "PQ", repository.NewPQRepository(db), func() { db.Close() }, nil
+	case "sqlx":
+		db := dryrun.OpenSQLX(sink)
+		return "SQLX", repository.NewSQLXRepository(db), func() { db.Close() }, nil
+	case "gorm":
+		db, err := dryrun.OpenGORM(sink)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		sqlDB, _ := db.DB()
+		return "GORM", repository.NewGORMRepository(db), func() { sqlDB.Close() }, nil
+	default:
+		return "", nil, nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// runDryRun prints the SQL each of libraries would execute for each of
+// operations without connecting to the database. It implements --dry-run
+// of the CRUD and benchmark commands.
+func runDryRun(cmd *cobra.Command, opts *globalOptions, libraries, operations []string) error {
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	var report dryRunReport
+	for _, library := range libraries {
+		name, repo, closeRepo, err := openDryRunRepository(library)
+		if err != nil {
+			return err
+		}
+		libReport := dryRunLibrary{Library: name}
+		for _, operation := range operations {
+			op, err := dryRunOperationOf(ctx, name, repo, operation)
+			if err != nil {
+				closeRepo()
+				return err
+			}
+			libReport.Operations = append(libReport.Operations, op)
+		}
+		closeRepo()
+		report.Libraries = append(report.Libraries, libReport)
+	}
+
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, report)
+	}
+	printDryRun(cmd.OutOrStdout(), report)
+	return nil
+}
+
+// dryRunOperationOf runs operation on repo and returns the statements it
+// issued
+func dryRunOperationOf(ctx context.Context, library string, repo repository.UserRepository, operation string) (dryRunOperation, error) {
+	op := dryRunOperation{Operation: operation, Statements: []dryRunStatement{}}
+	run, ok := dryRunOperations[operation]
+	if !ok {
+		op.Note = "not a repository operation, issues no statements of its own"
+		return op, nil
+	}
+
+	ctx, recorder := sqlcapture.WithRecorder(ctx)
+	err := run(ctx, library, repo)
+	for _, q := range recorder.Queries() {
+		op.Statements = append(op.Statements, dryRunStatement{SQL: strings.Join(strings.Fields(q.SQL), " "), Args: q.Args})
+	}
+	switch {
+	case errors.Is(err, errStepUnsupported):
+		op.Note = errStepUnsupported.Error()
+	case err != nil:
+		// The dry run driver answers every statement, so this is a bug
+		// in the repository or the driver rather than in the data
+		return dryRunOperation{}, fmt.Errorf("%s %s dry run failed: %w", library, operation, err)
+	}
+	return op, nil
+}
+
+// printDryRun prints the statements of report grouped by library and
+// operation
+func printDryRun(w io.Writer, report dryRunReport) {
+	fmt.Fprintln(w, "🔎 Dry run: statements are built but not executed")
+	for _, library := range report.Libraries {
+		fmt.Fprintf(w, "\n== %s ==\n", library.Library)
+		for _, op := range library.Operations {
+			fmt.Fprintf(w, "%s:\n", op.Operation)
+			if op.Note != "" {
+				fmt.Fprintf(w, "  (%s)\n", op.Note)
+			}
+			for _, statement := range op.Statements {
+				fmt.Fprintf(w, "  %s\n", statement.SQL)
+				if len(statement.Args) > 0 {
+					fmt.Fprintf(w, "    args=%s\n", formatArgs(statement.Args))
+				}
+			}
+		}
+	}
+}
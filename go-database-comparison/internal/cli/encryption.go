@@ -0,0 +1,449 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/secret"
+)
+
+// keysEnv holds the --keys of the encryption command when the flag is not
+// given, keeping keys out of the shell history
+const keysEnv = "DBCOMPARE_SECRET_KEYS"
+
+// encryptionOptions holds the flags of the encryption command
+type encryptionOptions struct {
+	libraries  []string
+	keys       string
+	iterations int
+	size       int
+	keepData   bool
+}
+
+// secretRepository is a UserRepository storing the encrypted secret column
+type secretRepository interface {
+	repository.UserRepository
+	SetUserSecret(ctx context.Context, id int, plaintext string) error
+	GetUserSecret(ctx context.Context, id int) (string, error)
+}
+
+// encryptionCheck is the outcome of one check on one library
+type encryptionCheck struct {
+	Library  string `json:"library"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check observed what it expected
+func (c encryptionCheck) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// encryptionTiming compares storing and reading the secret in plaintext
+// with doing so encrypted
+type encryptionTiming struct {
+	Library    string        `json:"library"`
+	Operation  string        `json:"operation"`
+	Iterations int           `json:"iterations"`
+	Plaintext  time.Duration `json:"plaintext_median"`
+	Encrypted  time.Duration `json:"encrypted_median"`
+	Crypto     time.Duration `json:"crypto_median"` // Sealing or opening alone, in process
+}
+
+// encryptionDocument is printed with --format json
+type encryptionDocument struct {
+	RunID   string             `json:"run_id"`
+	Checks  []encryptionCheck  `json:"checks"`
+	Timings []encryptionTiming `json:"timings,omitempty"`
+	Failed  int                `json:"failed"`
+}
+
+func newEncryptionCommand(opts *globalOptions) *cobra.Command {
+	encryptionOpts := encryptionOptions{}
+	cmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Check the encrypted secret column through each library and benchmark the encryption overhead",
+		Long: `Check that every library stores the secret column of migration 0007 the
+same way: sealed in the application with AES-256-GCM by pkg/secret before
+it is sent, so neither the plaintext nor the key reaches the server or its
+statement log. The repositories take the codec with WithCodec.
+
+The checks verify per library that
+  round trip   a secret reads back as it was stored,
+  at rest      the column holds ciphertext naming the current key,
+  rotation     a secret sealed before a key rotation stays readable and
+               is sealed with the new key when stored again,
+  wrong key    a codec without the key does not open it,
+  tampered     a secret altered in the database is rejected.
+A failed check makes the command exit with code 2.
+
+The benchmark then stores and reads a --size byte secret --iterations
+times with a plaintext codec and with encryption, and prints the medians
+with the time of sealing and opening alone.
+
+The keys come from --keys or DBCOMPARE_SECRET_KEYS as id=base64,... with
+the first one current; without them a random key is used for the run. The
+users are removed afterwards unless --keep-data.`,
+		Example: "  dbcompare encryption\n  DBCOMPARE_SECRET_KEYS=k1=$(head -c 32 /dev/urandom | base64) dbcompare encryption --lib gorm",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEncryption(cmd, opts, encryptionOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&encryptionOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to run, comma separated")
+	flags.StringVar(&encryptionOpts.keys, "keys", "", "Keys as id=base64,... with the first one current (default $"+keysEnv+" or a random key)")
+	flags.IntVar(&encryptionOpts.iterations, "iterations", 200, "Stores and reads per codec in the benchmark, 0 skips it")
+	flags.IntVar(&encryptionOpts.size, "size", 256, "Size of the secret in the benchmark, in bytes")
+	flags.BoolVar(&encryptionOpts.keepData, "keep-data", false, "Keep the users the command created")
+	return cmd
+}
+
+func runEncryption(cmd *cobra.Command, opts *globalOptions, encryptionOpts encryptionOptions) error {
+	if encryptionOpts.iterations < 0 {
+		return fmt.Errorf("--iterations must not be negative, got %d", encryptionOpts.iterations)
+	}
+	if encryptionOpts.size < 1 {
+		return fmt.Errorf("--size must be at least 1, got %d", encryptionOpts.size)
+	}
+	keys, err := encryptionKeys(encryptionOpts.keys)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🔐 Go Database Comparison - Encrypted Column")
+	// The observer reads and alters the stored bytes directly
+	observer, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer observer.Close()
+
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	if !encryptionOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	doc := encryptionDocument{RunID: opts.runID}
+	for _, library := range encryptionOpts.libraries {
+		name, withCodec, closeRepo, err := openSecretRepository(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		log.Info("checking the encrypted column", "library", name)
+		checks, err := checkEncryption(ctx, name, withCodec, keys, observer)
+		doc.Checks = append(doc.Checks, checks...)
+		if err == nil && encryptionOpts.iterations > 0 {
+			log.Info("benchmarking encryption", "library", name, "iterations", encryptionOpts.iterations)
+			var timings []encryptionTiming
+			timings, err = benchmarkEncryption(ctx, name, withCodec, secret.NewCipher(keys), encryptionOpts.size, encryptionOpts.iterations)
+			doc.Timings = append(doc.Timings, timings...)
+		}
+		closeRepo()
+		if err != nil {
+			return fmt.Errorf("%s encryption run failed: %w", name, err)
+		}
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	fmt.Fprintln(w)
+	printEncryptionChecks(w, doc.Checks)
+	if len(doc.Timings) > 0 {
+		fmt.Fprintln(w)
+		printEncryptionTimings(w, doc.Timings)
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d encryption checks failed", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d encryption checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// encryptionKeys returns the keys of spec, of the environment when spec is
+// empty, or a random key
+func encryptionKeys(spec string) (*secret.StaticKeys, error) {
+	if spec == "" {
+		spec = os.Getenv(keysEnv)
+	}
+	if spec == "" {
+		return secret.RandomKeys("run")
+	}
+	keys, err := secret.ParseKeys(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --keys: %w", err)
+	}
+	return keys, nil
+}
+
+// openSecretRepository connects library and returns its name, a function
+// returning its repository with a codec and the function closing its
+// connection
+func openSecretRepository(ctx context.Context, library string, config *database.DatabaseConfig) (string, func(secret.Codec) secretRepository, func(), error) {
+	switch strings.ToLower(library) {
+	case "pq":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		repo := repository.NewPQRepository(db)
+		return "PQ", func(codec secret.Codec) secretRepository { return repo.WithCodec(codec) }, func() { db.Close() }, nil
+	case "sqlx":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		repo := repository.NewSQLXRepository(db)
+		return "SQLX", func(codec secret.Codec) secretRepository { return repo.WithCodec(codec) }, func() { db.Close() }, nil
+	case "gorm":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("GORM connection failed: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		repo := repository.NewGORMRepository(db)
+		return "GORM", func(codec secret.Codec) secretRepository { return repo.WithCodec(codec) }, func() { sqlDB.Close() }, nil
+	default:
+		return "", nil, nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// checkEncryption runs the checks on one library. It only returns an error
+// when the user the checks store secrets of cannot be created.
+func checkEncryption(ctx context.Context, library string, withCodec func(secret.Codec) secretRepository, keys *secret.StaticKeys, observer *sql.DB) ([]encryptionCheck, error) {
+	repo := withCodec(secret.NewCipher(keys))
+	email := benchdata.Email(benchdata.RunID(ctx), "secret", library, 1)
+	user, err := repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Secret " + library, Email: email, Age: 30})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a user: %w", err)
+	}
+	plaintext := "secret of " + email
+
+	var checks []encryptionCheck
+	check := func(name, expected string, observe func() (string, error)) {
+		c := encryptionCheck{Library: library, Check: name, Expected: expected}
+		observed, err := observe()
+		c.Observed = observed
+		if err != nil {
+			c.Error = err.Error()
+		}
+		checks = append(checks, c)
+	}
+	// stored returns the bytes in the column, as the server has them
+	stored := func() ([]byte, error) {
+		var sealed []byte
+		err := observer.QueryRowContext(ctx, "SELECT secret FROM users WHERE id = $1", user.ID).Scan(&sealed)
+		return sealed, err
+	}
+	// read opens the secret with repo, telling rejected secrets from failures
+	read := func(repo secretRepository) (string, error) {
+		got, err := repo.GetUserSecret(ctx, user.ID)
+		if err != nil && strings.Contains(err.Error(), "open secret failed") {
+			return "rejected", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if got != plaintext {
+			return fmt.Sprintf("read %q", got), nil
+		}
+		return "readable", nil
+	}
+
+	check("round trip", "readable", func() (string, error) {
+		if err := repo.SetUserSecret(ctx, user.ID, plaintext); err != nil {
+			return "", err
+		}
+		return read(repo)
+	})
+	currentKey, _, _ := keys.CurrentKey(ctx)
+	check("at rest", "ciphertext of key "+currentKey, func() (string, error) {
+		sealed, err := stored()
+		if err != nil {
+			return "", err
+		}
+		if bytes.Contains(sealed, []byte(plaintext)) {
+			return "plaintext", nil
+		}
+		id, err := secret.KeyID(sealed)
+		if err != nil {
+			return "unknown format", nil
+		}
+		return "ciphertext of key " + id, nil
+	})
+	check("rotation", "readable, resealed with new", func() (string, error) {
+		rotating, err := secret.RandomKeys("old", "new")
+		if err != nil {
+			return "", err
+		}
+		repo := withCodec(secret.NewCipher(rotating))
+		if err := repo.SetUserSecret(ctx, user.ID, plaintext); err != nil {
+			return "", err
+		}
+		if err := rotating.Rotate("new"); err != nil {
+			return "", err
+		}
+		observed, err := read(repo)
+		if err != nil || observed != "readable" {
+			return observed, err
+		}
+		if err := repo.SetUserSecret(ctx, user.ID, plaintext); err != nil {
+			return "", err
+		}
+		sealed, err := stored()
+		if err != nil {
+			return "", err
+		}
+		id, _ := secret.KeyID(sealed)
+		return "readable, resealed with " + id, nil
+	})
+	check("wrong key", "rejected", func() (string, error) {
+		if err := repo.SetUserSecret(ctx, user.ID, plaintext); err != nil {
+			return "", err
+		}
+		// The same key ID with another key, so the ciphertext itself fails
+		other, err := secret.RandomKeys(currentKey)
+		if err != nil {
+			return "", err
+		}
+		return read(withCodec(secret.NewCipher(other)))
+	})
+	check("tampered", "rejected", func() (string, error) {
+		// Flip the last bit of the tag
+		_, err := observer.ExecContext(ctx, `
+			UPDATE users
+			SET secret = set_byte(secret, length(secret) - 1, get_byte(secret, length(secret) - 1) # 1)
+			WHERE id = $1`, user.ID)
+		if err != nil {
+			return "", err
+		}
+		return read(repo)
+	})
+	return checks, nil
+}
+
+// benchmarkEncryption times storing and reading a secret of size bytes
+// with a plaintext codec and with cipher, alternating them call by call
+func benchmarkEncryption(ctx context.Context, library string, withCodec func(secret.Codec) secretRepository, cipher *secret.Cipher, size, iterations int) ([]encryptionTiming, error) {
+	plain := withCodec(secret.Plaintext{})
+	encrypted := withCodec(cipher)
+	email := benchdata.Email(benchdata.RunID(ctx), "secret-bench", library, 1)
+	user, err := plain.CreateUser(ctx, &models.CreateUserRequest{Name: "Secret Bench", Email: email, Age: 30})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a user: %w", err)
+	}
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	value := base64.RawStdEncoding.EncodeToString(random)[:size]
+
+	var setPlain, setEncrypted, getPlain, getEncrypted, seal, open []time.Duration
+	for i := 0; i < iterations; i++ {
+		for _, mode := range []struct {
+			repo     secretRepository
+			set, get *[]time.Duration
+		}{
+			{plain, &setPlain, &getPlain},
+			{encrypted, &setEncrypted, &getEncrypted},
+		} {
+			start := time.Now()
+			if err := mode.repo.SetUserSecret(ctx, user.ID, value); err != nil {
+				return nil, fmt.Errorf("set secret: %w", err)
+			}
+			*mode.set = append(*mode.set, time.Since(start))
+
+			start = time.Now()
+			got, err := mode.repo.GetUserSecret(ctx, user.ID)
+			if err == nil && got != value {
+				err = fmt.Errorf("read back a different secret")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("get secret: %w", err)
+			}
+			*mode.get = append(*mode.get, time.Since(start))
+		}
+
+		start := time.Now()
+		sealed, err := cipher.Seal(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		seal = append(seal, time.Since(start))
+		start = time.Now()
+		if _, err := cipher.Open(ctx, sealed); err != nil {
+			return nil, err
+		}
+		open = append(open, time.Since(start))
+	}
+
+	return []encryptionTiming{
+		{Library: library, Operation: "set secret", Iterations: iterations, Plaintext: medianDuration(setPlain), Encrypted: medianDuration(setEncrypted), Crypto: medianDuration(seal)},
+		{Library: library, Operation: "get secret", Iterations: iterations, Plaintext: medianDuration(getPlain), Encrypted: medianDuration(getEncrypted), Crypto: medianDuration(open)},
+	}, nil
+}
+
+// printEncryptionChecks prints one line per check
+func printEncryptionChecks(w io.Writer, checks []encryptionCheck) {
+	fmt.Fprintf(w, "%-6s | %-10s | %s\n", "Lib", "Check", "Result")
+	fmt.Fprintln(w, "-------|------------|------------------------------")
+	for _, c := range checks {
+		result := "✅ " + c.Observed
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-6s | %-10s | %s\n", c.Library, c.Check, result)
+	}
+}
+
+// printEncryptionTimings prints the medians of every operation with the
+// overhead of encrypting over plaintext
+func printEncryptionTimings(w io.Writer, timings []encryptionTiming) {
+	fmt.Fprintf(w, "%-6s | %-10s | %-10s | %-10s | %-10s | %s\n", "Lib", "Operation", "Plaintext", "Encrypted", "Crypto", "Overhead")
+	fmt.Fprintln(w, "-------|------------|------------|------------|------------|---------")
+	for _, t := range timings {
+		fmt.Fprintf(w, "%-6s | %-10s | %-10v | %-10v | %-10v | %s\n",
+			t.Library, t.Operation,
+			t.Plaintext.Round(time.Microsecond), t.Encrypted.Round(time.Microsecond), t.Crypto,
+			overhead(t.Encrypted, t.Plaintext))
+	}
+}
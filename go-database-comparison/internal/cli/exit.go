@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of dbcompare, so scripts can tell why a command failed
+const (
+	ExitOK           = 0 // The command succeeded
+	ExitFailure      = 1 // The command could not do its job, e.g. the database was unreachable or a flag invalid
+	ExitVerification = 2 // The command ran, but a check it performs failed
+	ExitRegression   = 3 // A benchmark was slower than its baseline by more than the allowed margin
+)
+
+// exitError sets the exit code of a command failing with err
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// verificationFailed marks err as a failed check, exiting with ExitVerification
+func verificationFailed(err error) error {
+	return &exitError{code: ExitVerification, err: err}
+}
+
+// regressionDetected marks err as a regression, exiting with ExitRegression
+func regressionDetected(err error) error {
+	return &exitError{code: ExitRegression, err: err}
+}
+
+// exitCode returns the process exit code for the error a command returned
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitFailure
+}
+
+// Output formats selected with --format
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// validateFormat checks the value of --format
+func validateFormat(format string) error {
+	switch format {
+	case formatText, formatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q (expected text or json)", format)
+	}
+}
+
+// jsonOutput reports whether --format json is set
+func (o *globalOptions) jsonOutput() bool {
+	return o.format == formatJSON
+}
+
+// textOutput returns where a command prints its human readable output:
+// stdout, or nowhere with --format json so stdout only carries the
+// command's JSON document
+func (o *globalOptions) textOutput(cmd *cobra.Command) io.Writer {
+	if o.jsonOutput() {
+		return io.Discard
+	}
+	return cmd.OutOrStdout()
+}
+
+// printJSON prints v as the command's JSON document
+func (o *globalOptions) printJSON(cmd *cobra.Command, v interface{}) error {
+	o.printedJSON = true
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// errorDocument is printed with --format json for a command that failed
+// before printing its own document
+type errorDocument struct {
+	Error    string `json:"error"`
+	ExitCode int    `json:"exit_code"`
+}
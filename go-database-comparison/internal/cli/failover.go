@@ -0,0 +1,296 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/concurrency"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// failoverOptions holds the flags of the failover command
+type failoverOptions struct {
+	libraries    []string
+	duration     time.Duration
+	rate         float64
+	operation    string
+	opTimeout    time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	keepData     bool
+}
+
+// failoverDocument is printed with --format json
+type failoverDocument struct {
+	RunID       string                     `json:"run_id"`
+	Operation   string                     `json:"operation"`
+	Duration    time.Duration              `json:"duration"`
+	Rate        float64                    `json:"rate"`
+	MaxAttempts int                        `json:"max_attempts"`
+	Libraries   []benchmark.FailoverResult `json:"libraries"`
+}
+
+func newFailoverCommand(opts *globalOptions) *cobra.Command {
+	failoverOpts := failoverOptions{}
+	cmd := &cobra.Command{
+		Use:   "failover",
+		Short: "Measure how each library rides through a primary switchover or restart",
+		Long: `Issue a steady stream of operations from each library at once while the
+primary is switched over or restarted, and measure how each library rides
+through it.
+
+Start the command, then trigger the switchover, restart PostgreSQL or move
+the address --host points at; the command runs for --duration or until
+interrupted and logs when a library starts failing and when it recovers.
+
+Every library runs --rate operations per second, one at a time: list reads
+a page of users, create inserts a user of this run, removed afterwards
+unless --keep-data. An operation failing with a connection error, timeout,
+serialization failure or deadlock is retried up to --max-attempts attempts
+in total with exponential backoff from --retry-backoff, so the effect of a
+retry policy on what the application sees can be compared.
+
+For each library the report lists:
+
+  max burst  most consecutive operations that failed after their retries
+  downtime   from the last success before an outage to the first after
+  recovery   from the first failed operation to the first success after
+  retries    attempts beyond the first, masked counts the operations that
+             only succeeded thanks to them
+
+A library that still fails when the run ends makes the command exit with
+code 2. When no library saw an error the switchover probably didn't happen
+during the run, which is logged as a warning.`,
+		Example: "  dbcompare failover --duration 2m\n  dbcompare failover --operation create --max-attempts 5 --retry-backoff 200ms",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFailover(cmd, opts, failoverOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&failoverOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to run, comma separated")
+	flags.DurationVar(&failoverOpts.duration, "duration", 2*time.Minute, "How long to run, the switchover has to happen meanwhile")
+	flags.Float64Var(&failoverOpts.rate, "rate", 20, "Operations per second of each library")
+	flags.StringVar(&failoverOpts.operation, "operation", "list", "Operation to issue: list or create")
+	flags.DurationVar(&failoverOpts.opTimeout, "op-timeout", 2*time.Second, "Time limit of each attempt, so hanging connections count as failures")
+	flags.IntVar(&failoverOpts.maxAttempts, "max-attempts", 1, "Attempts per operation including the first, 1 disables retries")
+	flags.DurationVar(&failoverOpts.retryBackoff, "retry-backoff", 100*time.Millisecond, "Delay before the first retry, doubling with each further one")
+	flags.DurationVar(&failoverOpts.maxBackoff, "max-backoff", 2*time.Second, "Upper bound of the delay between retries")
+	flags.BoolVar(&failoverOpts.keepData, "keep-data", false, "Keep the users created with --operation create")
+	return cmd
+}
+
+func runFailover(cmd *cobra.Command, opts *globalOptions, failoverOpts failoverOptions) error {
+	if failoverOpts.duration <= 0 {
+		return fmt.Errorf("--duration must be positive, got %v", failoverOpts.duration)
+	}
+	if failoverOpts.rate <= 0 {
+		return fmt.Errorf("--rate must be positive, got %v", failoverOpts.rate)
+	}
+	if failoverOpts.operation != "list" && failoverOpts.operation != "create" {
+		return fmt.Errorf("unknown --operation %q (expected list or create)", failoverOpts.operation)
+	}
+	if failoverOpts.maxAttempts < 1 {
+		return fmt.Errorf("--max-attempts must be at least 1, got %d", failoverOpts.maxAttempts)
+	}
+	if len(failoverOpts.libraries) == 0 {
+		return fmt.Errorf("--lib selects no library")
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	// Connect every library before the run, a switchover must not catch
+	// one of them still connecting
+	type failoverTarget struct {
+		name string
+		repo repository.UserRepository
+	}
+	var targets []failoverTarget
+	for _, library := range failoverOpts.libraries {
+		name, repo, closeRepo, err := openCRUDRepository(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+		targets = append(targets, failoverTarget{name: name, repo: repo})
+	}
+	if failoverOpts.operation == "create" && !failoverOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	policy := &concurrency.RetryPolicy{
+		MaxAttempts:    failoverOpts.maxAttempts,
+		InitialBackoff: failoverOpts.retryBackoff,
+		MaxBackoff:     failoverOpts.maxBackoff,
+		Retryable: func(err error) bool {
+			return benchmark.IsTransientError(err) || benchmark.ClassifyError(err) == benchmark.ErrorTimeout
+		},
+	}
+
+	banner(w, "🔌 Go Database Comparison - Failover Test")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	fmt.Fprintf(w, "Issuing %s at %g ops/s per library for %v, up to %d attempts each\n",
+		failoverOpts.operation, failoverOpts.rate, failoverOpts.duration, failoverOpts.maxAttempts)
+	fmt.Fprintln(w, "Trigger the switchover or restart now; press Ctrl+C to end the run early")
+
+	// Interrupting ends the run but still reports it
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	runCtx, cancelRun := context.WithTimeout(runCtx, failoverOpts.duration)
+	defer cancelRun()
+
+	start := time.Now()
+	samples := make([][]benchmark.FailoverSample, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			samples[i] = runFailoverLoop(runCtx, target.name, target.repo, failoverOpts, policy, log)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	doc := failoverDocument{
+		RunID:       opts.runID,
+		Operation:   failoverOpts.operation,
+		Duration:    time.Since(start),
+		Rate:        failoverOpts.rate,
+		MaxAttempts: failoverOpts.maxAttempts,
+	}
+	var unrecovered []string
+	outages := 0
+	for i, target := range targets {
+		result := benchmark.AnalyzeFailover(target.name, samples[i])
+		doc.Libraries = append(doc.Libraries, result)
+		outages += len(result.Outages)
+		if result.Unrecovered() {
+			unrecovered = append(unrecovered, target.name)
+		}
+	}
+
+	fmt.Fprintln(w)
+	printFailoverResults(w, doc.Libraries)
+	if outages == 0 {
+		log.Warn("no library saw an error, the switchover did not happen during the run")
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if len(unrecovered) > 0 {
+		return verificationFailed(fmt.Errorf("%v still failing when the run ended", unrecovered))
+	}
+	return nil
+}
+
+// runFailoverLoop issues operations on repo at the configured rate until ctx
+// ends, logging when the library starts failing and when it recovers
+func runFailoverLoop(ctx context.Context, library string, repo repository.UserRepository, failoverOpts failoverOptions, policy *concurrency.RetryPolicy, log *slog.Logger) []benchmark.FailoverSample {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / failoverOpts.rate))
+	defer ticker.Stop()
+
+	var samples []benchmark.FailoverSample
+	var downSince time.Time
+	for n := int64(1); ; n++ {
+		start := time.Now()
+		attempts, err := policy.Do(ctx, func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, failoverOpts.opTimeout)
+			defer cancel()
+			return failoverOperation(ctx, repo, failoverOpts.operation, library, n)
+		})
+		if ctx.Err() != nil {
+			// Cut short by the end of the run, not by the database
+			return samples
+		}
+		samples = append(samples, benchmark.FailoverSample{Start: start, Duration: time.Since(start), Attempts: attempts, Err: err})
+
+		switch {
+		case err != nil && downSince.IsZero():
+			downSince = start
+			log.Warn("library failing", "library", library, "error", err)
+		case err == nil && !downSince.IsZero():
+			log.Info("library recovered", "library", library, "after", time.Since(downSince).Round(time.Millisecond))
+			downSince = time.Time{}
+		}
+
+		select {
+		case <-ctx.Done():
+			return samples
+		case <-ticker.C:
+		}
+	}
+}
+
+// failoverOperation issues the n-th operation of a library
+func failoverOperation(ctx context.Context, repo repository.UserRepository, operation, library string, n int64) error {
+	if operation == "create" {
+		_, err := repo.CreateUser(ctx, &models.CreateUserRequest{
+			Name:  fmt.Sprintf("Failover User %d", n),
+			Email: benchdata.Email(benchdata.RunID(ctx), "failover", library, n),
+			Age:   30,
+		})
+		return err
+	}
+	_, err := repo.GetAllUsers(ctx, 10, 0)
+	return err
+}
+
+// printFailoverResults prints the outages of every library
+func printFailoverResults(w io.Writer, results []benchmark.FailoverResult) {
+	fmt.Fprintf(w, "%-6s | %5s | %6s | %7s | %6s | %9s | %-12s | %-12s | %s\n",
+		"Lib", "Ops", "Failed", "Outages", "Burst", "Retries", "Downtime", "Max Recovery", "Status")
+	fmt.Fprintln(w, "-------|-------|--------|---------|--------|-----------|--------------|--------------|------------")
+	for _, r := range results {
+		var maxRecovery time.Duration
+		for _, o := range r.Outages {
+			if o.Recovery > maxRecovery {
+				maxRecovery = o.Recovery
+			}
+		}
+		status := "✅ recovered"
+		if r.Unrecovered() {
+			status = "❌ failing"
+		} else if len(r.Outages) == 0 {
+			status = "no outage"
+		}
+		fmt.Fprintf(w, "%-6s | %5d | %6d | %7d | %6d | %4d (%2d) | %-12v | %-12v | %s\n",
+			r.Library, r.Operations, r.Failed, len(r.Outages), r.MaxBurst, r.Retries, r.MaskedErrors,
+			r.TotalDowntime.Round(time.Millisecond), maxRecovery.Round(time.Millisecond), status)
+	}
+
+	for _, r := range results {
+		for i, o := range r.Outages {
+			fmt.Fprintf(w, "  %s outage %d at %s: %d failed, downtime %v, errors: connection %d, timeout %d, other %d\n",
+				r.Library, i+1, o.Start.Format("15:04:05.000"), o.FailedOps, o.Downtime.Round(time.Millisecond),
+				o.Errors.Connection, o.Errors.Timeout, o.Errors.Other+o.Errors.UniqueViolation)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// generatedAlwaysSQLState is PostgreSQL's generated_always, raised for a
+// value written to a generated column
+const generatedAlwaysSQLState = "428C9"
+
+// generatedOptions holds the flags of the generated command
+type generatedOptions struct {
+	libraries []string
+	keepData  bool
+}
+
+// domainRepository is a UserRepository reading the generated email_domain
+type domainRepository interface {
+	repository.UserRepository
+	CreateUserWithDomain(ctx context.Context, req *models.CreateUserRequest) (*models.UserWithDomain, error)
+	GetUsersByEmailDomain(ctx context.Context, domain string) ([]*models.UserWithDomain, error)
+}
+
+// generatedTarget is one library's repository together with writes that
+// name email_domain the way that library would
+type generatedTarget struct {
+	name string
+	repo domainRepository
+	// insertNaming and updateNaming write domain into email_domain
+	insertNaming func(ctx context.Context, req *models.CreateUserRequest, domain string) error
+	updateNaming func(ctx context.Context, id int, domain string) error
+	// updateOutcome is what updateNaming is expected to do
+	updateOutcome string
+}
+
+// generatedCheck is the outcome of one check on one library
+type generatedCheck struct {
+	Library  string `json:"library"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check observed what it expected
+func (c generatedCheck) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// generatedDocument is printed with --format json
+type generatedDocument struct {
+	RunID  string           `json:"run_id"`
+	Checks []generatedCheck `json:"checks"`
+	Failed int              `json:"failed"`
+}
+
+// gormWritableDomain maps email_domain without UserWithDomain's read-only
+// permission, as a model written without knowing the column is generated
+type gormWritableDomain struct {
+	models.User
+	EmailDomain string
+}
+
+func newGeneratedCommand(opts *globalOptions) *cobra.Command {
+	generatedOpts := generatedOptions{}
+	cmd := &cobra.Command{
+		Use:   "generated",
+		Short: "Check how each library reads the generated email_domain column and avoids writing it",
+		Long: `Check how each library handles email_domain, the column migration 0008
+adds as GENERATED ALWAYS AS the domain of email. PostgreSQL rejects any
+value written to it, so a library has to read it without ever naming it
+in INSERT or UPDATE:
+  PQ and SQLX  the SQL leaves it out of the column lists and names it in
+               RETURNING and SELECT only,
+  GORM         models.UserWithDomain marks it read-only with "->", which
+               drops it from INSERT and UPDATE, and default:(-), which
+               reads it back with RETURNING.
+
+The checks verify per library that
+  insert           a created user comes back with its domain,
+  read             the user is found through the domain,
+  update email     changing the email recomputes the domain,
+  insert naming it an INSERT naming the column is rejected: raw SQL for
+                   PQ, a struct bound by NamedExec for SQLX and a model
+                   without the permission for GORM,
+  update naming it an UPDATE naming the column is rejected with raw SQL,
+                   while GORM's permission silently ignores the field.
+A failed check makes the command exit with code 2. The users are removed
+afterwards unless --keep-data.`,
+		Example: "  dbcompare generated\n  dbcompare generated --lib gorm",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGenerated(cmd, opts, generatedOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&generatedOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to check, comma separated")
+	flags.BoolVar(&generatedOpts.keepData, "keep-data", false, "Keep the users the checks created")
+	return cmd
+}
+
+func runGenerated(cmd *cobra.Command, opts *globalOptions, generatedOpts generatedOptions) error {
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🧮 Go Database Comparison - Generated Column")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+
+	doc := generatedDocument{RunID: opts.runID}
+	for i, library := range generatedOpts.libraries {
+		target, closeTarget, err := openGeneratedTarget(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		// Once connected there may be users to remove
+		if i == 0 && !generatedOpts.keepData {
+			defer func() {
+				if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+					log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+				}
+			}()
+		}
+		log.Info("checking the generated column", "library", target.name)
+		checks, err := checkGeneratedColumn(ctx, target)
+		closeTarget()
+		doc.Checks = append(doc.Checks, checks...)
+		if err != nil {
+			return fmt.Errorf("%s generated column checks failed: %w", target.name, err)
+		}
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	fmt.Fprintln(w)
+	printGeneratedChecks(w, doc.Checks)
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d generated column checks failed, is migration 0008 applied?", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d generated column checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// openGeneratedTarget connects library and returns it with the function
+// closing its connection
+func openGeneratedTarget(ctx context.Context, library string, config *database.DatabaseConfig) (*generatedTarget, func(), error) {
+	switch strings.ToLower(library) {
+	case "pq":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		return &generatedTarget{
+			name: "PQ",
+			repo: repository.NewPQRepository(db),
+			insertNaming: func(ctx context.Context, req *models.CreateUserRequest, domain string) error {
+				_, err := db.ExecContext(ctx, "INSERT INTO users (name, email, age, email_domain) VALUES ($1, $2, $3, $4)",
+					req.Name, req.Email, req.Age, domain)
+				return err
+			},
+			updateNaming: func(ctx context.Context, id int, domain string) error {
+				_, err := db.ExecContext(ctx, "UPDATE users SET email_domain = $1 WHERE id = $2", domain, id)
+				return err
+			},
+			updateOutcome: "rejected",
+		}, func() { db.Close() }, nil
+	case "sqlx":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		return &generatedTarget{
+			name: "SQLX",
+			repo: repository.NewSQLXRepository(db),
+			insertNaming: func(ctx context.Context, req *models.CreateUserRequest, domain string) error {
+				user := models.UserWithDomain{User: models.User{Name: req.Name, Email: req.Email, Age: req.Age, IsActive: true}, EmailDomain: domain}
+				_, err := db.NamedExecContext(ctx, `INSERT INTO users (name, email, age, is_active, email_domain)
+					VALUES (:name, :email, :age, :is_active, :email_domain)`, user)
+				return err
+			},
+			updateNaming: func(ctx context.Context, id int, domain string) error {
+				user := models.UserWithDomain{User: models.User{ID: id}, EmailDomain: domain}
+				_, err := db.NamedExecContext(ctx, "UPDATE users SET email_domain = :email_domain WHERE id = :id", user)
+				return err
+			},
+			updateOutcome: "rejected",
+		}, func() { db.Close() }, nil
+	case "gorm":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("GORM connection failed: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		return &generatedTarget{
+			name: "GORM",
+			repo: repository.NewGORMRepository(db),
+			insertNaming: func(ctx context.Context, req *models.CreateUserRequest, domain string) error {
+				user := &gormWritableDomain{User: models.User{Name: req.Name, Email: req.Email, Age: req.Age, IsActive: true}, EmailDomain: domain}
+				return db.WithContext(ctx).Table("users").Create(user).Error
+			},
+			updateNaming: func(ctx context.Context, id int, domain string) error {
+				return db.WithContext(ctx).
+					Model(&models.UserWithDomain{User: models.User{ID: id}}).
+					Updates(models.UserWithDomain{EmailDomain: domain}).Error
+			},
+			updateOutcome: "ignored",
+		}, func() { sqlDB.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// checkGeneratedColumn runs the checks on target. It only returns an error
+// when the user of the checks cannot be created.
+func checkGeneratedColumn(ctx context.Context, target *generatedTarget) ([]generatedCheck, error) {
+	runID := benchdata.RunID(ctx)
+	email := benchdata.Email(runID, "generated", target.name, 1)
+	_, domain, _ := strings.Cut(email, "@")
+	user, err := target.repo.CreateUserWithDomain(ctx, &models.CreateUserRequest{Name: "Generated " + target.name, Email: email, Age: 30})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a user: %w", err)
+	}
+
+	var checks []generatedCheck
+	check := func(name, expected string, observe func() (string, error)) {
+		c := generatedCheck{Library: target.name, Check: name, Expected: expected}
+		observed, err := observe()
+		c.Observed = observed
+		if err != nil {
+			c.Error = err.Error()
+		}
+		checks = append(checks, c)
+	}
+	// domainOf reads the domain of the user through the domain it should have
+	domainOf := func(domain string) (string, error) {
+		users, err := target.repo.GetUsersByEmailDomain(ctx, domain)
+		if err != nil {
+			return "", err
+		}
+		for _, u := range users {
+			if u.ID == user.ID {
+				return u.EmailDomain, nil
+			}
+		}
+		return "not found", nil
+	}
+	// rejected tells the error PostgreSQL raises for a generated column
+	// from other failures
+	rejected := func(err error) (string, error) {
+		if err == nil {
+			return "written", nil
+		}
+		if benchmark.SQLState(err) == generatedAlwaysSQLState {
+			return "rejected", nil
+		}
+		return "", err
+	}
+
+	check("insert", domain, func() (string, error) { return user.EmailDomain, nil })
+	check("read", domain, func() (string, error) { return domainOf(domain) })
+	check("update email", "recomputed", func() (string, error) {
+		// Move the user to another domain and back, the run's cleanup
+		// finds its users by their domain
+		local, _, _ := strings.Cut(email, "@")
+		moved := "moved." + domain
+		for _, step := range []struct{ email, domain string }{{local + "@" + moved, moved}, {email, domain}} {
+			if _, err := target.repo.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Email: &step.email}); err != nil {
+				return "", err
+			}
+			got, err := domainOf(step.domain)
+			if err != nil || got != step.domain {
+				return got, err
+			}
+		}
+		return "recomputed", nil
+	})
+	check("insert naming it", "rejected", func() (string, error) {
+		req := &models.CreateUserRequest{Name: "Generated " + target.name, Email: benchdata.Email(runID, "generated", target.name, 2), Age: 30}
+		return rejected(target.insertNaming(ctx, req, "forged.example"))
+	})
+	check("update naming it", target.updateOutcome, func() (string, error) {
+		observed, err := rejected(target.updateNaming(ctx, user.ID, "forged.example"))
+		if err != nil || observed == "rejected" {
+			return observed, err
+		}
+		// No error: the value was either dropped or, against PostgreSQL's
+		// rules, stored
+		got, err := domainOf(domain)
+		if err != nil {
+			return "", err
+		}
+		if got == domain {
+			return "ignored", nil
+		}
+		return observed, nil
+	})
+	return checks, nil
+}
+
+// printGeneratedChecks prints one line per check
+func printGeneratedChecks(w io.Writer, checks []generatedCheck) {
+	fmt.Fprintf(w, "%-6s | %-16s | %s\n", "Lib", "Check", "Result")
+	fmt.Fprintln(w, "-------|------------------|------------------------------")
+	for _, c := range checks {
+		result := "✅ " + c.Observed
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-6s | %-16s | %s\n", c.Library, c.Check, result)
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/gormsql"
+)
+
+// gormSQLDifference is a method GORM builds other statements for than the
+// hand-written repository
+type gormSQLDifference struct {
+	Method     string `json:"method"`
+	Difference string `json:"difference"`
+	Reason     string `json:"reason,omitempty"` // Why the difference is expected, empty when it is not
+}
+
+// gormSQLDocument is printed with --format json
+type gormSQLDocument struct {
+	Methods     []gormsql.Method    `json:"methods"`
+	Differences []gormSQLDifference `json:"differences"`
+	Failed      int                 `json:"failed"` // Differences without a reason
+}
+
+func newGORMSQLCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "gorm-sql",
+		Short: "Show the SQL GORM builds for every repository method next to the hand-written SQL",
+		Long: `Run every method of the GORM repository in a DryRun session, which builds
+the statements without sending them, and print them next to the ones the
+lib/pq repository sends for the same method, or the sqlx one for methods
+lib/pq has not. No database is needed.
+
+The statements are then compared like doctor's SQL equivalence check: by
+verb, table, columns written and WHERE conditions. A difference that is
+not a known one makes the command exit with code 2.
+
+DryRun leaves every result empty, so a method reading a row before it
+writes builds the write from a zero row, and methods reading results
+through Scan stop after building their statement; the note of a method
+says where. The same listing is appended to the comprehensive benchmark
+report.`,
+		Example: "  dbcompare gorm-sql\n  dbcompare gorm-sql --format json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGORMSQL(cmd, opts)
+		},
+	}
+}
+
+func runGORMSQL(cmd *cobra.Command, opts *globalOptions) error {
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	methods, err := gormsql.Extract(ctx)
+	if err != nil {
+		return err
+	}
+	doc := gormSQLDocument{Methods: methods, Differences: []gormSQLDifference{}}
+	for _, diff := range gormsql.Checker(methods).Differences() {
+		difference := gormSQLDifference{Method: diff.Operation, Difference: diff.String()}
+		difference.Reason = knownSQLDifferences[diff.Library+" "+diff.Operation]
+		if difference.Reason == "" {
+			doc.Failed++
+		}
+		doc.Differences = append(doc.Differences, difference)
+	}
+
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	} else {
+		printGORMSQL(cmd.OutOrStdout(), doc)
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("GORM builds other statements than the hand-written SQL in %d methods", doc.Failed))
+	}
+	return nil
+}
+
+// printGORMSQL prints the statements of every method, then the
+// differences
+func printGORMSQL(w io.Writer, doc gormSQLDocument) {
+	banner(w, "🔬 Go Database Comparison - GORM SQL")
+	for _, m := range doc.Methods {
+		fmt.Fprintf(w, "\n%s\n", m.Method)
+		for _, statement := range m.GORM {
+			fmt.Fprintf(w, "  GORM  %s\n", statement)
+		}
+		for _, statement := range m.HandWritten {
+			fmt.Fprintf(w, "  %-5s %s\n", m.By, statement)
+		}
+		if m.Note != "" {
+			fmt.Fprintf(w, "  ⚠️  %s\n", m.Note)
+		}
+	}
+
+	fmt.Fprintln(w)
+	for _, diff := range doc.Differences {
+		if diff.Reason != "" {
+			fmt.Fprintf(w, "➖ %s: known difference, %s\n", diff.Method, diff.Reason)
+			continue
+		}
+		fmt.Fprintf(w, "❌ %s\n", diff.Difference)
+	}
+	if doc.Failed == 0 {
+		fmt.Fprintf(w, "✅ GORM builds statements equivalent to the hand-written SQL in %d methods\n", gormsql.Compared(doc.Methods)-len(doc.Differences))
+	}
+}
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+// hooksOptions holds the flags of the hooks command
+type hooksOptions struct {
+	iterations int
+	keepData   bool
+}
+
+// hooksVariant is one library the hooks command times, GORM twice: with
+// the hooks of models.User and without
+type hooksVariant struct {
+	name    string
+	library string
+	hooks   bool
+}
+
+var hooksVariants = []hooksVariant{
+	{"PQ", "pq", false},
+	{"SQLX", "sqlx", false},
+	{"GORM", "gorm", false},
+	{"GORM+hooks", "gorm", true},
+}
+
+// hooksCheck is the outcome of one check on one GORM variant
+type hooksCheck struct {
+	Library  string `json:"library"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check observed what it expected
+func (c hooksCheck) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// hooksTiming holds the medians of creating and updating a user with one
+// variant
+type hooksTiming struct {
+	Library    string        `json:"library"`
+	Hooks      bool          `json:"hooks"`
+	Iterations int           `json:"iterations"`
+	Create     time.Duration `json:"create_median"`
+	Update     time.Duration `json:"update_median"`
+}
+
+// hooksDocument is printed with --format json
+type hooksDocument struct {
+	RunID   string        `json:"run_id"`
+	Checks  []hooksCheck  `json:"checks"`
+	Timings []hooksTiming `json:"timings,omitempty"`
+	Failed  int           `json:"failed"`
+}
+
+func newHooksCommand(opts *globalOptions) *cobra.Command {
+	hooksOpts := hooksOptions{}
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Benchmark what the hooks of the GORM user model cost, against GORM without them, PQ and SQLX",
+		Long: `Time CreateUser and UpdateUser with GORM running the BeforeCreate and
+AfterUpdate hooks of models.User, as --gorm-hooks does for every command,
+and without them, next to PQ and SQLX, which have no hooks to pay for.
+BeforeCreate validates the user before GORM sends the INSERT, AfterUpdate
+fails an update that matched no row. Without the hooks GORM still calls
+them and they return at once.
+
+First a user with an invalid age is created with each GORM variant. The
+check verifies that with the hooks BeforeCreate rejects it before the
+INSERT, and that without them it is the server's check constraint. A
+failed check makes the command exit with code 2.
+
+The benchmark then creates a user and updates its age --iterations times
+per variant, alternating the variants call by call, and prints the
+medians. The users are removed afterwards unless --keep-data.`,
+		Example: "  dbcompare hooks\n  dbcompare hooks --iterations 1000",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHooks(cmd, opts, hooksOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&hooksOpts.iterations, "iterations", 200, "Creates and updates per variant in the benchmark, 0 skips it")
+	flags.BoolVar(&hooksOpts.keepData, "keep-data", false, "Keep the users the command created")
+	return cmd
+}
+
+func runHooks(cmd *cobra.Command, opts *globalOptions, hooksOpts hooksOptions) error {
+	if hooksOpts.iterations < 0 {
+		return fmt.Errorf("--iterations must not be negative, got %d", hooksOpts.iterations)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🪝 Go Database Comparison - GORM Hooks")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	if !hooksOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	doc := hooksDocument{RunID: opts.runID}
+	captureConfig := *config
+	captureConfig.QueryLog = sqlcapture.Sink()
+	for _, variant := range hooksVariants {
+		if variant.library != "gorm" {
+			continue
+		}
+		log.Info("creating an invalid user", "library", variant.name)
+		check, err := checkInvalidCreate(ctx, variant, &captureConfig)
+		if err != nil {
+			return err
+		}
+		doc.Checks = append(doc.Checks, check)
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	if hooksOpts.iterations > 0 {
+		// Benchmark without the capturing hooks, which would be timed too
+		log.Info("benchmarking creates and updates", "iterations", hooksOpts.iterations)
+		timings, err := benchmarkHooks(ctx, config, hooksOpts.iterations)
+		if err != nil {
+			return err
+		}
+		doc.Timings = timings
+	}
+
+	fmt.Fprintln(w)
+	printHooksChecks(w, doc.Checks)
+	if len(doc.Timings) > 0 {
+		fmt.Fprintln(w)
+		printHooksTimings(w, doc.Timings)
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d hook checks failed", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d hook checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// checkInvalidCreate creates a user older than the table allows with
+// variant, connected with config, and reports who rejected it: BeforeCreate,
+// before the INSERT, or the server
+func checkInvalidCreate(ctx context.Context, variant hooksVariant, config *database.DatabaseConfig) (hooksCheck, error) {
+	check := hooksCheck{Library: variant.name, Check: "invalid create", Expected: "rejected by the server"}
+	if variant.hooks {
+		check.Expected = "rejected before the INSERT"
+	}
+
+	variantConfig := *config
+	variantConfig.GORMHooks = variant.hooks
+	_, repo, db, err := openRepositoryDB(ctx, variant.library, &variantConfig)
+	if err != nil {
+		return check, err
+	}
+	defer db.Close()
+
+	recordCtx, recorder := sqlcapture.WithRecorder(ctx)
+	_, err = repo.CreateUser(recordCtx, &models.CreateUserRequest{
+		Name:  "Invalid Hooks User",
+		Email: benchdata.Email(benchdata.RunID(ctx), "hooks", "invalid", 0),
+		Age:   151,
+	})
+	switch {
+	case err == nil:
+		check.Observed = "created"
+	case len(recorder.Queries()) == 0 && strings.Contains(err.Error(), "invalid user"):
+		check.Observed = "rejected before the INSERT"
+	default:
+		check.Observed = "rejected by the server"
+	}
+	return check, nil
+}
+
+// benchmarkHooks creates a user and updates its age iterations times with
+// each of hooksVariants, connected with config, alternating them call by
+// call
+func benchmarkHooks(ctx context.Context, config *database.DatabaseConfig, iterations int) ([]hooksTiming, error) {
+	repos := make([]repository.UserRepository, len(hooksVariants))
+	for i, variant := range hooksVariants {
+		variantConfig := *config
+		variantConfig.GORMHooks = variant.hooks
+		_, repo, db, err := openRepositoryDB(ctx, variant.library, &variantConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		repos[i] = repo
+	}
+
+	runID := benchdata.RunID(ctx)
+	creates := make([][]time.Duration, len(repos))
+	updates := make([][]time.Duration, len(repos))
+	for n := range iterations {
+		for i, repo := range repos {
+			variant := hooksVariants[i]
+			start := time.Now()
+			user, err := repo.CreateUser(ctx, &models.CreateUserRequest{
+				Name:  fmt.Sprintf("Hooks %s %d", variant.name, n),
+				Email: benchdata.Email(runID, "hooks", strings.ReplaceAll(variant.name, "+", ""), int64(n)),
+				Age:   25,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s create failed: %w", variant.name, err)
+			}
+			creates[i] = append(creates[i], time.Since(start))
+
+			age := 26
+			start = time.Now()
+			if _, err := repo.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Age: &age}); err != nil {
+				return nil, fmt.Errorf("%s update failed: %w", variant.name, err)
+			}
+			updates[i] = append(updates[i], time.Since(start))
+		}
+	}
+
+	timings := make([]hooksTiming, len(repos))
+	for i, variant := range hooksVariants {
+		timings[i] = hooksTiming{
+			Library:    variant.name,
+			Hooks:      variant.hooks,
+			Iterations: iterations,
+			Create:     medianDuration(creates[i]),
+			Update:     medianDuration(updates[i]),
+		}
+	}
+	return timings, nil
+}
+
+// printHooksChecks prints one line per check
+func printHooksChecks(w io.Writer, checks []hooksCheck) {
+	fmt.Fprintf(w, "%-10s | %-14s | %s\n", "Lib", "Check", "Result")
+	fmt.Fprintln(w, "-----------|----------------|------------------------------")
+	for _, c := range checks {
+		result := "✅ " + c.Observed
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-10s | %-14s | %s\n", c.Library, c.Check, result)
+	}
+}
+
+// printHooksTimings prints the medians against PQ, then what the hooks add
+// to GORM
+func printHooksTimings(w io.Writer, timings []hooksTiming) {
+	var pq, plain, hooked hooksTiming
+	for _, t := range timings {
+		switch {
+		case t.Library == "PQ":
+			pq = t
+		case t.Library == "GORM" && !t.Hooks:
+			plain = t
+		case t.Hooks:
+			hooked = t
+		}
+	}
+
+	fmt.Fprintln(w, "Create and update, medians:")
+	fmt.Fprintf(w, "%-10s | %-10s | %-10s | %-9s | %s\n", "Lib", "Create", "Update", "Create/PQ", "Update/PQ")
+	fmt.Fprintln(w, "-----------|------------|------------|-----------|----------")
+	for _, t := range timings {
+		fmt.Fprintf(w, "%-10s | %-10v | %-10v | %-9s | %s\n", t.Library,
+			t.Create.Round(time.Microsecond), t.Update.Round(time.Microsecond), overhead(t.Create, pq.Create), overhead(t.Update, pq.Update))
+	}
+	fmt.Fprintf(w, "\nGORM hooks: create %s (%v), update %s (%v)\n",
+		overhead(hooked.Create, plain.Create), (hooked.Create - plain.Create).Round(time.Microsecond),
+		overhead(hooked.Update, plain.Update), (hooked.Update - plain.Update).Round(time.Microsecond))
+}
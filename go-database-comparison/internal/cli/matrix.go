@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/database"
+)
+
+// matrixOptions holds the flags of the matrix command
+type matrixOptions struct {
+	targets     []string
+	resultsFile string
+	reportFile  string
+	lang        string
+	sizing      sizingOptions
+}
+
+// matrixTarget is one database of a matrix run
+type matrixTarget struct {
+	name   string
+	config *database.DatabaseConfig
+}
+
+// matrixDocument is printed with --format json
+type matrixDocument struct {
+	benchmark.MatrixFile
+	Files map[string]string `json:"files"` // Output paths written, by flag
+}
+
+func newMatrixCommand(opts *globalOptions) *cobra.Command {
+	matrixOpts := matrixOptions{}
+	defaults, _ := benchmark.LookupPreset("quick")
+
+	cmd := &cobra.Command{
+		Use:   "matrix",
+		Short: "Run the same benchmark against several PostgreSQL targets and compare them",
+		Long: `Run the same benchmark against several PostgreSQL targets, typically one
+container per server version, and write a combined report with a cell per
+library and target.
+
+Each --target is name=host[:port][/dbname]; the port defaults to --port,
+the database to --dbname, and the user, password and sslmode of all
+targets are those of the global flags. Targets run one after another in
+the given order, so they don't compete for the machine, and the first
+target with results is the baseline the report compares the others with.
+
+A target that cannot be reached or whose run fails is reported as such
+and the others still run; the command then exits with code 1.
+
+--preset and the sizing flags configure the benchmark like those of
+comprehensive-benchmark; the preset's timeout per target is the default
+--timeout. Only direct repository calls are benchmarked.`,
+		Example: "  dbcompare matrix --target pg14=localhost:5414 --target pg15=localhost:5415 --target pg16=localhost:5416\n  dbcompare matrix --preset standard --target old=db-old.internal,new=db-new.internal",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMatrix(cmd, opts, &matrixOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&matrixOpts.targets, "target", nil, "Database to benchmark as name=host[:port][/dbname], repeated or comma separated")
+	flags.StringVar(&matrixOpts.resultsFile, "results-file", "matrix_results.json", "Output path of the JSON results of all targets")
+	flags.StringVar(&matrixOpts.reportFile, "report-file", "matrix_report.md", "Output path of the markdown matrix report")
+	flags.StringVar(&matrixOpts.lang, "lang", "en", "Report and console language: en, ja or both")
+	matrixOpts.sizing.addFlags(flags, defaults)
+	return cmd
+}
+
+func runMatrix(cmd *cobra.Command, opts *globalOptions, matrixOpts *matrixOptions) error {
+	locale, err := benchmark.ParseLocale(matrixOpts.lang)
+	if err != nil {
+		return err
+	}
+	preset, err := matrixOpts.sizing.resolve(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	targets, err := parseMatrixTargets(matrixOpts.targets, *opts.dbConfig())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.commandContext(cmd, preset.Timeout*time.Duration(len(targets)))
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	banner(w, "🧮 Go Database Comparison - PostgreSQL Matrix")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	fmt.Fprintf(w, "Preset: %s\n", preset.Name)
+
+	file := benchmark.MatrixFile{RunID: opts.runID}
+	failed := 0
+	for _, target := range targets {
+		run := benchmark.MatrixRun{Target: target.name, Address: matrixAddress(target.config)}
+		fmt.Fprintf(w, "\n🎯 %s (%s)\n", run.Target, run.Address)
+
+		if err := database.HealthCheck(ctx, target.config); err != nil {
+			run.Error = fmt.Sprintf("database health check failed: %v", err)
+		} else {
+			benchConfig := benchmark.DefaultBenchmarkConfig()
+			matrixOpts.sizing.apply(benchConfig)
+			benchConfig.Locale = locale
+			benchConfig.Logger = log.With("target", target.name)
+			benchConfig.RunID = opts.runID
+
+			perfBench := benchmark.NewPerformanceBenchmark(benchConfig)
+			if err := perfBench.RunComprehensiveBenchmark(ctx, target.config); err != nil {
+				run.Error = fmt.Sprintf("benchmark failed: %v", err)
+			}
+			run.ResultsFile = perfBench.ResultsFile()
+		}
+
+		if run.Error != "" {
+			failed++
+			fmt.Fprintf(w, "❌ %s\n", run.Error)
+		} else {
+			fmt.Fprintf(w, "✅ %d results, server %s\n", len(run.Results), run.Environment.ServerVersion)
+		}
+		file.Targets = append(file.Targets, run)
+		if ctx.Err() != nil {
+			break // The remaining targets would fail the same way
+		}
+	}
+
+	report := benchmark.GenerateMatrixReport(file, matrixOpts.sizing.operations, locale)
+	fmt.Fprintf(w, "\n%s", report)
+
+	files := make(map[string]string)
+	if err := saveMatrix(file, report, matrixOpts); err != nil {
+		log.Warn("failed to save matrix results", "error", err)
+	} else {
+		log.Info("matrix results saved", "results", matrixOpts.resultsFile, "report", matrixOpts.reportFile)
+		files["results-file"], files["report-file"] = matrixOpts.resultsFile, matrixOpts.reportFile
+	}
+
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, matrixDocument{MatrixFile: file, Files: files}); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d targets failed", failed, len(targets))
+	}
+	return nil
+}
+
+// parseMatrixTargets parses the --target specs, name=host[:port][/dbname],
+// into configs derived from base
+func parseMatrixTargets(specs []string, base database.DatabaseConfig) ([]matrixTarget, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("set at least one --target")
+	}
+
+	seen := make(map[string]bool)
+	targets := make([]matrixTarget, 0, len(specs))
+	for _, spec := range specs {
+		name, address, ok := strings.Cut(strings.TrimSpace(spec), "=")
+		if !ok || name == "" || address == "" {
+			return nil, fmt.Errorf("invalid --target %q, expected name=host[:port][/dbname]", spec)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate --target name %q", name)
+		}
+		seen[name] = true
+
+		config := base
+		if hostPort, dbname, ok := strings.Cut(address, "/"); ok {
+			if dbname == "" {
+				return nil, fmt.Errorf("invalid --target %q, empty database name", spec)
+			}
+			address, config.DBName = hostPort, dbname
+		}
+		config.Host = address
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			n, err := strconv.Atoi(port)
+			if err != nil || n <= 0 || n > 65535 {
+				return nil, fmt.Errorf("invalid --target %q, bad port %q", spec, port)
+			}
+			config.Host, config.Port = host, n
+		}
+		if config.Host == "" {
+			return nil, fmt.Errorf("invalid --target %q, empty host", spec)
+		}
+		targets = append(targets, matrixTarget{name: name, config: &config})
+	}
+	return targets, nil
+}
+
+// matrixAddress returns where config points as host:port/dbname
+func matrixAddress(config *database.DatabaseConfig) string {
+	return databaseAddress(config) + "/" + config.DBName
+}
+
+// saveMatrix writes the JSON results and the markdown report of a matrix run
+func saveMatrix(file benchmark.MatrixFile, report string, matrixOpts *matrixOptions) error {
+	jsonData, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if err := os.WriteFile(matrixOpts.resultsFile, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON results: %w", err)
+	}
+	if err := os.WriteFile(matrixOpts.reportFile, []byte(report), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
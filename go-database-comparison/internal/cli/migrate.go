@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/migrate"
+)
+
+// migrateDocument is printed with --format json, holding the migrations
+// the subcommand applied or reverted or, for status, the state of every
+// migration; {} when up or down had nothing to do
+type migrateDocument struct {
+	Applied  []migrate.Migration `json:"applied,omitempty"`
+	Reverted []migrate.Migration `json:"reverted,omitempty"`
+	Statuses []migrate.Status    `json:"statuses,omitempty"`
+}
+
+func newMigrateCommand(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Create or revert the benchmark schema with the embedded migrations",
+		Long: `Create or revert the benchmark schema with the migrations embedded in
+the binary, so no SQL scripts have to be run by hand. Applied versions are
+recorded in the schema_migrations table.
+
+The migrations create their objects only if missing, so a database set up
+from init.sql can be brought under migrate with "migrate up".`,
+		Args: cobra.NoArgs,
+	}
+
+	var to int
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply the pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrate(cmd, opts, func(ctx context.Context, db *sql.DB) error {
+				applied, err := migrate.Up(ctx, db, to)
+				w := opts.textOutput(cmd)
+				for _, m := range applied {
+					fmt.Fprintf(w, "⬆️  %04d_%s\n", m.Version, m.Name)
+				}
+				if err != nil {
+					return err
+				}
+				if opts.jsonOutput() {
+					return opts.printJSON(cmd, migrateDocument{Applied: applied})
+				}
+				if len(applied) == 0 {
+					fmt.Fprintln(w, "✅ Schema is up to date")
+				}
+				return nil
+			})
+		},
+	}
+	up.Flags().IntVar(&to, "to", 0, "Apply migrations up to this version only, 0 for all")
+
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert the most recently applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if steps < 1 {
+				return fmt.Errorf("--steps must be at least 1, got %d", steps)
+			}
+			return runMigrate(cmd, opts, func(ctx context.Context, db *sql.DB) error {
+				reverted, err := migrate.Down(ctx, db, steps)
+				w := opts.textOutput(cmd)
+				for _, m := range reverted {
+					fmt.Fprintf(w, "⬇️  %04d_%s\n", m.Version, m.Name)
+				}
+				if err != nil {
+					return err
+				}
+				if opts.jsonOutput() {
+					return opts.printJSON(cmd, migrateDocument{Reverted: reverted})
+				}
+				if len(reverted) == 0 {
+					fmt.Fprintln(w, "No applied migrations to revert")
+				}
+				return nil
+			})
+		},
+	}
+	down.Flags().IntVar(&steps, "steps", 1, "Number of migrations to revert")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List the migrations and whether they are applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMigrate(cmd, opts, func(ctx context.Context, db *sql.DB) error {
+				statuses, err := migrate.Statuses(ctx, db)
+				if err != nil {
+					return err
+				}
+				if opts.jsonOutput() {
+					return opts.printJSON(cmd, migrateDocument{Statuses: statuses})
+				}
+				w := cmd.OutOrStdout()
+				fmt.Fprintf(w, "%-7s | %-24s | %s\n", "Version", "Name", "Applied")
+				fmt.Fprintln(w, "--------|--------------------------|--------------------------")
+				for _, s := range statuses {
+					applied := "pending"
+					if s.Applied {
+						applied = s.AppliedAt.Local().Format(time.RFC3339)
+					}
+					fmt.Fprintf(w, "%-7s | %-24s | %s\n", fmt.Sprintf("%04d", s.Version), s.Name, applied)
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.AddCommand(up, down, status)
+	return cmd
+}
+
+// runMigrate connects with lib/pq and runs fn, bounded by --timeout or 2 minutes
+func runMigrate(cmd *cobra.Command, opts *globalOptions, fn func(ctx context.Context, db *sql.DB) error) error {
+	ctx, cancel := opts.commandContext(cmd, 2*time.Minute)
+	defer cancel()
+
+	db, err := database.ConnectWithPQ(ctx, opts.dbConfig())
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer db.Close()
+
+	return fn(ctx, db)
+}
@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/notify"
+	"go-database-comparison/pkg/repository"
+)
+
+// notifyOptions holds the flags of the notify command
+type notifyOptions struct {
+	libraries []string
+	watch     bool
+	wait      time.Duration
+	keepData  bool
+}
+
+// notifyStep is a change made through a library and the notification it
+// has to cause
+type notifyStep struct {
+	Library string        `json:"library"`
+	Step    string        `json:"step"`
+	Op      string        `json:"op"`
+	UserID  int           `json:"user_id"`
+	Latency time.Duration `json:"latency,omitempty"` // From the call to the notification arriving
+	Missing bool          `json:"missing,omitempty"`
+}
+
+// notifyDocument is printed with --format json
+type notifyDocument struct {
+	RunID   string       `json:"run_id"`
+	Channel string       `json:"channel"`
+	Steps   []notifyStep `json:"steps"`
+	Missing int          `json:"missing"`
+}
+
+func newNotifyCommand(opts *globalOptions) *cobra.Command {
+	notifyOpts := notifyOptions{}
+	cmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Stream user changes with LISTEN/NOTIFY while each library makes some",
+		Long: `Stream the changes of the users table as PostgreSQL announces them on the
+user_changes channel. The command attaches a trigger to the users table
+that notifies every inserted, updated and deleted user once its transaction
+commits, and drops it again on exit unless it was already installed: the
+trigger slows down every write and is not part of the migrations. The
+listener is lib/pq's Listener on a connection of its own, see pkg/notify.
+
+Every library then creates a user, renames it and soft deletes it, and the
+command waits up to --wait for the notification of each change, printing
+the stream and how long after the call each notification arrived. A
+missing notification makes the command exit with code 2. The users are
+removed afterwards unless --keep-data.
+
+With --watch the command makes no changes of its own and prints every
+change, by any client, until interrupted.`,
+		Example: "  dbcompare notify\n  dbcompare notify --watch",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNotify(cmd, opts, notifyOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&notifyOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to make changes through, comma separated")
+	flags.BoolVar(&notifyOpts.watch, "watch", false, "Only print the changes other clients make, until interrupted")
+	flags.DurationVar(&notifyOpts.wait, "wait", 2*time.Second, "How long to wait for the notification of each change")
+	flags.BoolVar(&notifyOpts.keepData, "keep-data", false, "Keep the users the demo created")
+	return cmd
+}
+
+func runNotify(cmd *cobra.Command, opts *globalOptions, notifyOpts notifyOptions) error {
+	if notifyOpts.wait <= 0 {
+		return fmt.Errorf("--wait must be positive, got %v", notifyOpts.wait)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "📣 Go Database Comparison - LISTEN/NOTIFY")
+	db, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer db.Close()
+	installed, err := notify.Install(ctx, db)
+	if err != nil {
+		return err
+	}
+	if installed {
+		defer func() {
+			if err := notify.Uninstall(context.WithoutCancel(ctx), db); err != nil {
+				log.Warn("failed to remove the notification trigger", "trigger", notify.Trigger, "error", err)
+			}
+		}()
+	}
+
+	listener, err := notify.Listen(ctx, config, log)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	fmt.Fprintf(w, "Listening on %s\n", notify.Channel)
+
+	if notifyOpts.watch {
+		return watchChanges(ctx, w, listener)
+	}
+
+	fmt.Fprintf(w, "Run ID: %s\n\n", opts.runID)
+	doc := notifyDocument{RunID: opts.runID, Channel: notify.Channel}
+	for i, library := range notifyOpts.libraries {
+		name, repo, closeRepo, err := openCRUDRepository(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		// Once connected there may be users to remove
+		if i == 0 && !notifyOpts.keepData {
+			defer func() {
+				if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+					log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+				}
+			}()
+		}
+		log.Info("making changes", "library", name)
+		steps, err := notifyDemo(ctx, w, name, repo, listener, notifyOpts.wait)
+		closeRepo()
+		doc.Steps = append(doc.Steps, steps...)
+		if err != nil {
+			return fmt.Errorf("%s changes failed: %w", name, err)
+		}
+	}
+	for _, step := range doc.Steps {
+		if step.Missing {
+			doc.Missing++
+		}
+	}
+
+	fmt.Fprintln(w)
+	printNotifySteps(w, doc.Steps)
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Missing > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d changes were not notified on %s", doc.Missing, len(doc.Steps), notify.Channel))
+	}
+	return nil
+}
+
+// notifyDemo creates, renames and soft deletes a user through repo and
+// waits for the notification of each change
+func notifyDemo(ctx context.Context, w io.Writer, library string, repo repository.UserRepository, listener *notify.Listener, wait time.Duration) ([]notifyStep, error) {
+	var steps []notifyStep
+	var user *models.User
+	changes := []struct {
+		step string
+		op   string
+		run  func() error
+	}{
+		{"create", notify.OpInsert, func() (err error) {
+			email := benchdata.Email(benchdata.RunID(ctx), "notify", library, 1)
+			user, err = repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Notify " + library, Email: email, Age: 30})
+			return err
+		}},
+		{"rename", notify.OpUpdate, func() error {
+			name := "Renamed " + library
+			_, err := repo.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Name: &name})
+			return err
+		}},
+		{"soft delete", notify.OpUpdate, func() error {
+			return repo.DeleteUser(ctx, user.ID)
+		}},
+	}
+
+	for _, change := range changes {
+		start := time.Now()
+		if err := change.run(); err != nil {
+			return steps, fmt.Errorf("%s: %w", change.step, err)
+		}
+		step := notifyStep{Library: library, Step: change.step, Op: change.op, UserID: user.ID}
+		received, err := awaitChange(ctx, listener, user.ID, change.op, wait)
+		if err != nil {
+			return steps, err
+		}
+		if received == nil {
+			step.Missing = true
+			fmt.Fprintf(w, "  %-5s %-12s ⏳ no %s notification for user %d within %v\n", library, change.step, change.op, user.ID, wait)
+		} else {
+			step.Latency = received.Received.Sub(start)
+			fmt.Fprintf(w, "  %-5s %-12s ← %s\n", library, change.step, describeChange(*received))
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// awaitChange waits up to wait for the notification of op on user id,
+// skipping the changes of other users. It returns nil if none arrived.
+func awaitChange(ctx context.Context, listener *notify.Listener, id int, op string, wait time.Duration) (*notify.Change, error) {
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	for {
+		select {
+		case change, ok := <-listener.Changes():
+			if !ok {
+				return nil, fmt.Errorf("notification listener stopped")
+			}
+			if change.ID == id && change.Op == op {
+				return &change, nil
+			}
+		case <-timeout.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// watchChanges prints every change until interrupted
+func watchChanges(ctx context.Context, w io.Writer, listener *notify.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintln(w, "Waiting for changes, interrupt to stop")
+	for {
+		select {
+		case change, ok := <-listener.Changes():
+			if !ok {
+				return fmt.Errorf("notification listener stopped")
+			}
+			fmt.Fprintf(w, "%s %s\n", change.Received.Format("15:04:05.000"), describeChange(change))
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// describeChange formats a change for the stream
+func describeChange(change notify.Change) string {
+	if change.Op == notify.OpReconnect {
+		return "reconnected, changes in between were missed"
+	}
+	active := "active"
+	if !change.IsActive {
+		active = "inactive"
+	}
+	return fmt.Sprintf("%-6s user %d %s, %s (backend %d)", change.Op, change.ID, change.Email, active, change.PID)
+}
+
+// printNotifySteps prints the notification latency of every change
+func printNotifySteps(w io.Writer, steps []notifyStep) {
+	fmt.Fprintf(w, "%-8s | %-12s | %-6s | %s\n", "Library", "Change", "Op", "Notified after")
+	fmt.Fprintln(w, "---------|--------------|--------|---------------")
+	for _, step := range steps {
+		latency := step.Latency.Round(time.Microsecond).String()
+		if step.Missing {
+			latency = "❌ missing"
+		}
+		fmt.Fprintf(w, "%-8s | %-12s | %-6s | %s\n", step.Library, step.Step, step.Op, latency)
+	}
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runStampLayout names the files of a run when --timestamp is set,
+// followed by the microseconds, see runStamp. It sorts chronologically,
+// which prune relies on.
+const runStampLayout = "20060102-150405"
+
+// runStampPatterns match the stamps of runStamp, and the stamps without
+// microseconds of earlier versions
+var runStampPatterns = []string{
+	strings.Repeat("[0-9]", 8) + "-" + strings.Repeat("[0-9]", 6) + "-" + strings.Repeat("[0-9]", 6),
+	strings.Repeat("[0-9]", 8) + "-" + strings.Repeat("[0-9]", 6),
+}
+
+// runStamp returns the stamp of a run started at start, e.g.
+// 20261017-101500-042137
+func runStamp(start time.Time) string {
+	return fmt.Sprintf("%s-%06d", start.Format(runStampLayout), start.Nanosecond()/1000)
+}
+
+// outputPaths are the files the comprehensive benchmark writes
+type outputPaths struct {
+	dir       string
+	timestamp bool
+	keep      int // Timestamped runs to keep per file, 0 keeps all
+
+	results string
+	report  string
+	html    string
+	shared  string
+	pdf     string
+	custom  string
+}
+
+// files returns pointers to every output path
+func (p *outputPaths) files() []*string {
+	return []*string{&p.results, &p.report, &p.html, &p.shared, &p.pdf, &p.custom}
+}
+
+// resolve returns the paths of a run started at start: relative paths are
+// placed in dir, and the run timestamp is added before the extension when
+// timestamp is set. The output directory is created if needed, and with
+// timestamp so is the empty results file, reserving the stamp.
+func (p outputPaths) resolve(start time.Time) (outputPaths, error) {
+	if p.dir != "" {
+		if err := os.MkdirAll(p.dir, 0755); err != nil {
+			return p, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	if !p.timestamp {
+		return p.placed(""), nil
+	}
+	// Runs started in the same microsecond take the next free one: the
+	// results file is created exclusively to claim the stamp
+	for attempt := 0; attempt < 100; attempt++ {
+		resolved := p.placed(runStamp(start.Add(time.Duration(attempt) * time.Microsecond)))
+		f, err := os.OpenFile(resolved.results, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return p, fmt.Errorf("failed to create results file: %w", err)
+		}
+		f.Close()
+		return resolved, nil
+	}
+	return p, fmt.Errorf("no free run timestamp for %s", p.results)
+}
+
+// placed returns the paths with stamp added, unless it is empty, and
+// relative paths placed in dir
+func (p outputPaths) placed(stamp string) outputPaths {
+	for _, path := range p.files() {
+		if stamp != "" {
+			*path = stampedPath(*path, stamp)
+		}
+		if p.dir != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(p.dir, *path)
+		}
+	}
+	return p
+}
+
+// prune removes all but the newest keep runs of every output file. The
+// receiver must be the unresolved paths, so the file name patterns match.
+func (p outputPaths) prune() ([]string, error) {
+	if !p.timestamp || p.keep <= 0 {
+		return nil, nil
+	}
+
+	var removed []string
+	for _, path := range p.files() {
+		if p.dir != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(p.dir, *path)
+		}
+		ext := filepath.Ext(*path)
+		base := strings.TrimSuffix(*path, ext)
+		var matches []string
+		for _, pattern := range runStampPatterns {
+			stamped, err := filepath.Glob(globEscape(base) + "-" + pattern + globEscape(ext))
+			if err != nil {
+				return removed, err
+			}
+			matches = append(matches, stamped...)
+		}
+		if len(matches) <= p.keep {
+			continue
+		}
+
+		sort.Strings(matches)
+		for _, old := range matches[:len(matches)-p.keep] {
+			if err := os.Remove(old); err != nil {
+				return removed, fmt.Errorf("failed to remove old run: %w", err)
+			}
+			removed = append(removed, old)
+		}
+	}
+	return removed, nil
+}
+
+// stampedPath inserts stamp before the extension of path
+func stampedPath(path, stamp string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + stamp + ext
+}
+
+// globEscape quotes the filepath.Match metacharacters in s
+func globEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(s)
+}
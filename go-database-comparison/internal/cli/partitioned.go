@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/partition"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+// partitionProbeRuns is how often each read runs before its plans are
+// inspected, past the five custom plans PostgreSQL builds for a prepared
+// statement before it considers a generic one
+const partitionProbeRuns = 10
+
+// partitionReport is how the libraries met the partitioned users table in
+// a benchmark run with --layout partitioned
+type partitionReport struct {
+	Partitions []string          `json:"partitions"`
+	Routing    []partition.Route `json:"routing"` // Users of the run per library and partition
+	Reads      []partitionRead   `json:"reads"`
+}
+
+// partitionRead is how one read of one library was planned
+type partitionRead struct {
+	Library      string         `json:"library"`
+	Read         string         `json:"read"`
+	SQL          string         `json:"sql"`
+	Prepared     bool           `json:"prepared"` // Held as a named prepared statement by the library's session
+	GenericPlans int64          `json:"generic_plans"`
+	CustomPlans  int64          `json:"custom_plans"`
+	Plan         partition.Plan `json:"plan"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// partitionReads are the reads whose pruning is reported: one by the
+// partition key and one that cannot be pruned
+var partitionReads = []struct {
+	name string
+	call func(ctx context.Context, repo repository.UserRepository, id int, email string) error
+}{
+	{"get by id", func(ctx context.Context, repo repository.UserRepository, id int, email string) error {
+		_, err := repo.GetUserByID(ctx, id)
+		return err
+	}},
+	{"search by email", func(ctx context.Context, repo repository.UserRepository, id int, email string) error {
+		_, err := repo.GetUsersByEmail(ctx, email)
+		return err
+	}},
+}
+
+// searchPathOf returns the search path reaching the users table of the
+// --layout layout
+func searchPathOf(layout string) (string, error) {
+	switch layout {
+	case "plain":
+		return "", nil
+	case "partitioned":
+		return partition.SearchPath, nil
+	default:
+		return "", fmt.Errorf("unknown layout %q (expected plain or partitioned)", layout)
+	}
+}
+
+// partitionedLayout checks that migration 0009 created the partitioned
+// users table and returns its partitions
+func partitionedLayout(ctx context.Context, config *database.DatabaseConfig) ([]string, error) {
+	db, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer db.Close()
+
+	available, err := partition.Available(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", partition.Table, err)
+	}
+	if !available {
+		return nil, fmt.Errorf("%s does not exist, run dbcompare migrate up to apply migration 0009", partition.Table)
+	}
+	return partition.Partitions(ctx, db)
+}
+
+// probePartitions reports where the users run runID created were routed
+// and how each library's reads of them were pruned. Every library runs each
+// read on a single connection, whose prepared statements then show whether
+// it got custom or generic plans; the same kind of plan is explained for it.
+func probePartitions(ctx context.Context, config *database.DatabaseConfig, runID string, partitions []string) (*partitionReport, error) {
+	db, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer db.Close()
+
+	report := &partitionReport{Partitions: partitions}
+	if report.Routing, err = partition.Routing(ctx, db, runID); err != nil {
+		return nil, err
+	}
+
+	domain := "@" + runID + "." + benchdata.Domain
+	var id int
+	err = db.QueryRowContext(ctx, "SELECT id FROM users WHERE email LIKE $1 AND is_active ORDER BY id LIMIT 1", "%"+domain).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return report, nil // Nothing to read
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pick a user to read: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	captureConfig := *config
+	captureConfig.QueryLog = sqlcapture.Sink()
+	for _, library := range []string{"pq", "sqlx", "gorm"} {
+		name, repo, libDB, err := openPartitionLibrary(ctx, library, &captureConfig)
+		if err != nil {
+			return nil, err
+		}
+		for _, read := range partitionReads {
+			result := probePartitionRead(ctx, conn, libDB, repo, read.call, id, domain, partitions)
+			result.Library, result.Read = name, read.name
+			report.Reads = append(report.Reads, result)
+		}
+		libDB.Close()
+	}
+	return report, nil
+}
+
+// probePartitionRead runs read through repo and explains its statement on
+// conn, with a generic plan when the session of libDB built one for it
+func probePartitionRead(ctx context.Context, conn *sql.Conn, libDB *sql.DB, repo repository.UserRepository,
+	read func(ctx context.Context, repo repository.UserRepository, id int, email string) error, id int, email string, partitions []string) partitionRead {
+	var result partitionRead
+	var recorder *sqlcapture.Recorder
+	for range partitionProbeRuns {
+		var readCtx context.Context
+		readCtx, recorder = sqlcapture.WithRecorder(ctx)
+		if err := read(readCtx, repo, id, email); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+	queries := recorder.Queries()
+	if len(queries) != 1 {
+		result.Error = fmt.Sprintf("expected one statement, captured %d", len(queries))
+		return result
+	}
+	result.SQL = strings.Join(strings.Fields(queries[0].SQL), " ")
+
+	var err error
+	result.Prepared, result.GenericPlans, result.CustomPlans, err = partition.SessionPlans(ctx, libDB, queries[0].SQL)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read the session's plans: %v", err)
+		return result
+	}
+	result.Plan, err = partition.Explain(ctx, conn, queries[0].SQL, queries[0].Args, result.GenericPlans > 0, partitions)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// openPartitionLibrary connects library with a pool of one connection, so
+// its session plans can be inspected through the returned *sql.DB
+func openPartitionLibrary(ctx context.Context, library string, config *database.DatabaseConfig) (string, repository.UserRepository, *sql.DB, error) {
+	name, repo, db, err := openRepositoryDB(ctx, library, config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	return name, repo, db, nil
+}
+
+// printPartitionReport prints the routing of writes and the pruning of
+// reads
+func printPartitionReport(w io.Writer, report *partitionReport) {
+	fmt.Fprintf(w, "\n🧩 Partitions (%s)\n", strings.Join(report.Partitions, ", "))
+
+	fmt.Fprintln(w, "\nUsers written, by the partition the server routed them to:")
+	fmt.Fprintf(w, "%-6s", "Lib")
+	for _, p := range report.Partitions {
+		fmt.Fprintf(w, " | %10s", p)
+	}
+	fmt.Fprintln(w)
+	rows := make(map[string]map[string]int64)
+	var libraries []string
+	for _, r := range report.Routing {
+		if rows[r.Library] == nil {
+			rows[r.Library] = make(map[string]int64)
+			libraries = append(libraries, r.Library)
+		}
+		rows[r.Library][r.Partition] = r.Rows
+	}
+	for _, library := range libraries {
+		fmt.Fprintf(w, "%-6s", library)
+		for _, p := range report.Partitions {
+			fmt.Fprintf(w, " | %10d", rows[library][p])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Reads) == 0 {
+		fmt.Fprintln(w, "\nNo active user of the run left to read, pruning not probed")
+		return
+	}
+	fmt.Fprintf(w, "\nPartitions scanned by reads, after %d runs on one connection:\n", partitionProbeRuns)
+	fmt.Fprintf(w, "%-6s | %-15s | %-9s | %-16s | %s\n", "Lib", "Read", "Statement", "Plans", "Scanned")
+	fmt.Fprintln(w, "-------|-----------------|-----------|------------------|------------------------------")
+	for _, r := range report.Reads {
+		if r.Error != "" {
+			fmt.Fprintf(w, "%-6s | %-15s | ❌ %s\n", r.Library, r.Read, r.Error)
+			continue
+		}
+		statement := "unnamed"
+		if r.Prepared {
+			statement = "prepared"
+		}
+		plans := fmt.Sprintf("%d custom", partitionProbeRuns)
+		if r.Prepared {
+			plans = fmt.Sprintf("%d generic %d custom", r.GenericPlans, r.CustomPlans)
+		}
+		scanned := fmt.Sprintf("%d of %d", len(r.Plan.Scanned), len(report.Partitions))
+		switch {
+		case r.Plan.PrunedAtPlanning > 0:
+			scanned += ", pruned when planning"
+		case r.Plan.PrunedAtExecution > 0:
+			scanned += ", pruned when executing"
+		}
+		fmt.Fprintf(w, "%-6s | %-15s | %-9s | %-16s | %s\n", r.Library, r.Read, statement, plans, scanned)
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/netem"
+)
+
+// proxyOptions holds the flags of the proxy command
+type proxyOptions struct {
+	listen            string
+	latency           time.Duration
+	loss              float64
+	retransmitTimeout time.Duration
+}
+
+func newProxyCommand(opts *globalOptions) *cobra.Command {
+	proxyOpts := proxyOptions{}
+	cmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Forward connections to the database through an emulated slow or lossy network",
+		Long: `Forward connections to the database the global flags select through an
+emulated network with added round-trip time and packet loss, so any client,
+or a benchmark on another machine, can be pointed at it.
+
+On loopback a round trip costs microseconds, which hides how many of them
+each library makes: an extra query, or a prepare before every execute,
+only shows once every round trip takes as long as across a network.
+--latency adds round-trip time, split evenly between both directions and
+without limiting throughput. TCP retransmits what a network drops, so
+--loss percent of the data arrives --retransmit-timeout late instead, and
+everything behind it on the connection with it.
+
+comprehensive-benchmark starts the same proxy in-process with
+--net-latency and --net-loss. The proxy runs until interrupted, or for
+--timeout when set, and prints what it forwarded when it stops.`,
+		Example: "  dbcompare proxy --listen :6432 --latency 20ms\n  dbcompare proxy --listen :6432 --latency 50ms --loss 1",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProxy(cmd, opts, proxyOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&proxyOpts.listen, "listen", "127.0.0.1:6432", "Address to accept connections on")
+	flags.DurationVar(&proxyOpts.latency, "latency", 20*time.Millisecond, "Round-trip time to add")
+	flags.Float64Var(&proxyOpts.loss, "loss", 0, "Percent of data to delay as if lost and retransmitted")
+	flags.DurationVar(&proxyOpts.retransmitTimeout, "retransmit-timeout", netem.DefaultRetransmitTimeout, "Delay of data lost with --loss")
+	return cmd
+}
+
+func runProxy(cmd *cobra.Command, opts *globalOptions, proxyOpts proxyOptions) error {
+	config := opts.dbConfig()
+	proxy, err := netem.Listen(proxyOpts.listen, netem.Config{
+		Target:            databaseAddress(config),
+		Latency:           proxyOpts.latency,
+		Loss:              proxyOpts.loss,
+		RetransmitTimeout: proxyOpts.retransmitTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := opts.textOutput(cmd)
+	fmt.Fprintf(w, "🐢 Forwarding %s to %s with %s\n", proxy.Addr(), databaseAddress(config), networkDescription(proxyOpts.latency, proxyOpts.loss))
+	<-ctx.Done()
+
+	proxy.Close()
+	stats := proxy.Stats()
+	fmt.Fprintf(w, "Forwarded %d connections, %d bytes in %d chunks, %d delayed as lost\n",
+		stats.Connections, stats.Bytes, stats.Chunks, stats.Lost)
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, stats)
+	}
+	return nil
+}
+
+// startNetworkEmulation starts a loopback proxy to the database of config
+// emulating latency and loss, and returns a copy of config connecting
+// through it with the function stopping it
+func startNetworkEmulation(config *database.DatabaseConfig, latency time.Duration, loss float64, log *slog.Logger) (*database.DatabaseConfig, func(), error) {
+	proxy, err := netem.Listen("127.0.0.1:0", netem.Config{
+		Target:  databaseAddress(config),
+		Latency: latency,
+		Loss:    loss,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Info("emulating network", "proxy", proxy.Addr(), "latency", latency, "loss_percent", loss)
+
+	addr := proxy.Addr().(*net.TCPAddr)
+	proxied := *config
+	proxied.Host = addr.IP.String()
+	proxied.Port = addr.Port
+	stop := func() {
+		proxy.Close()
+		stats := proxy.Stats()
+		log.Info("network emulation stopped", "connections", stats.Connections, "bytes", stats.Bytes, "lost", stats.Lost)
+	}
+	return &proxied, stop, nil
+}
+
+// databaseAddress returns the host:port of the database of config
+func databaseAddress(config *database.DatabaseConfig) string {
+	return net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+}
+
+// networkDescription summarizes an emulated network for output and reports
+func networkDescription(latency time.Duration, loss float64) string {
+	return fmt.Sprintf("%v RTT, %g%% loss", latency, loss)
+}
@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// readOnlySQLState is PostgreSQL's read_only_sql_transaction, raised for a
+// write in a read-only transaction
+const readOnlySQLState = "25006"
+
+// readOnlyOptions holds the flags of the readonly command
+type readOnlyOptions struct {
+	libraries  []string
+	iterations int
+	keepData   bool
+}
+
+// readOnlyTarget is one library's repository with its read-only
+// transaction API, which each library types for its own repository
+type readOnlyTarget struct {
+	name  string
+	repo  repository.UserRepository // Reads without a transaction
+	reads repository.UserRepository // Created by WithReadOnlyReads
+	// inTx runs fn with the repository bound to a read-only transaction by
+	// WithReadOnlyTx
+	inTx  func(ctx context.Context, fn func(ctx context.Context, tx repository.UserRepository) error) error
+	close func()
+}
+
+// readOnlyCheck is the outcome of one check on one library
+type readOnlyCheck struct {
+	Library  string `json:"library"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check observed what it expected
+func (c readOnlyCheck) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// readOnlyTiming holds the medians of reading a user by ID without a
+// transaction and in a read-only one
+type readOnlyTiming struct {
+	Library    string        `json:"library"`
+	Iterations int           `json:"iterations"`
+	Direct     time.Duration `json:"direct_median"`
+	ReadOnly   time.Duration `json:"read_only_median"`
+}
+
+// readOnlyDocument is printed with --format json
+type readOnlyDocument struct {
+	RunID   string           `json:"run_id"`
+	Checks  []readOnlyCheck  `json:"checks"`
+	Timings []readOnlyTiming `json:"timings,omitempty"`
+	Failed  int              `json:"failed"`
+}
+
+func newReadOnlyCommand(opts *globalOptions) *cobra.Command {
+	readOnlyOpts := readOnlyOptions{}
+	cmd := &cobra.Command{
+		Use:   "readonly",
+		Short: "Check the read-only transactions of each library's repository and benchmark what they cost a read",
+		Long: `Check WithReadOnlyTx, which runs a function with the repository bound to a
+transaction begun with sql.TxOptions{ReadOnly: true}: BeginTx for PQ,
+BeginTxx for SQLX and Transaction for GORM. --read-only-tx makes every
+command run the read methods of its repositories that way, through
+WithReadOnlyReads.
+
+The checks verify per library that
+  write in tx       CreateUser in a read-only transaction is rejected by
+                    the server with SQLSTATE 25006,
+  read in tx        GetUserByID in a read-only transaction reads the user,
+  read-only reads   GetUserByID with WithReadOnlyReads reads the user,
+  write beside them CreateUser with WithReadOnlyReads still creates, since
+                    only reads are wrapped.
+A failed check makes the command exit with code 2.
+
+The benchmark then reads the user by ID --iterations times per library
+without a transaction and with WithReadOnlyReads, alternating them call by
+call, and prints the medians. The difference is what BEGIN READ ONLY and
+COMMIT add to a single-statement read. The users are removed afterwards
+unless --keep-data.`,
+		Example: "  dbcompare readonly\n  dbcompare readonly --iterations 2000 --lib pq,gorm",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReadOnly(cmd, opts, readOnlyOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&readOnlyOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to check, comma separated")
+	flags.IntVar(&readOnlyOpts.iterations, "iterations", 500, "Reads per library and mode in the benchmark, 0 skips it")
+	flags.BoolVar(&readOnlyOpts.keepData, "keep-data", false, "Keep the users the command created")
+	return cmd
+}
+
+func runReadOnly(cmd *cobra.Command, opts *globalOptions, readOnlyOpts readOnlyOptions) error {
+	if readOnlyOpts.iterations < 0 {
+		return fmt.Errorf("--iterations must not be negative, got %d", readOnlyOpts.iterations)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🔒 Go Database Comparison - Read-Only Transactions")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+
+	doc := readOnlyDocument{RunID: opts.runID}
+	for i, library := range readOnlyOpts.libraries {
+		target, err := openReadOnlyTarget(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		// Once connected there may be users to remove
+		if i == 0 && !readOnlyOpts.keepData {
+			defer func() {
+				if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+					log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+				}
+			}()
+		}
+
+		log.Info("checking read-only transactions", "library", target.name)
+		checks, user, err := checkReadOnly(ctx, target)
+		doc.Checks = append(doc.Checks, checks...)
+		if err == nil && readOnlyOpts.iterations > 0 {
+			log.Info("benchmarking read-only reads", "library", target.name, "iterations", readOnlyOpts.iterations)
+			var timing readOnlyTiming
+			timing, err = benchmarkReadOnly(ctx, target, user.ID, readOnlyOpts.iterations)
+			doc.Timings = append(doc.Timings, timing)
+		}
+		target.close()
+		if err != nil {
+			return fmt.Errorf("%s read-only transactions failed: %w", target.name, err)
+		}
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	fmt.Fprintln(w)
+	printReadOnlyChecks(w, doc.Checks)
+	if len(doc.Timings) > 0 {
+		fmt.Fprintln(w)
+		printReadOnlyTimings(w, doc.Timings)
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d read-only transaction checks failed", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d read-only transaction checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// openReadOnlyTarget connects library with config, whose --read-only-tx
+// is ignored: the target reads without a transaction and with one
+func openReadOnlyTarget(ctx context.Context, library string, config *database.DatabaseConfig) (*readOnlyTarget, error) {
+	switch strings.ToLower(library) {
+	case "pq":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		repo := repository.NewPQRepository(db)
+		return &readOnlyTarget{
+			name:  "PQ",
+			repo:  repo,
+			reads: repo.WithReadOnlyReads(),
+			inTx: func(ctx context.Context, fn func(ctx context.Context, tx repository.UserRepository) error) error {
+				return repo.WithReadOnlyTx(ctx, func(ctx context.Context, tx *repository.PQRepository) error { return fn(ctx, tx) })
+			},
+			close: func() { db.Close() },
+		}, nil
+	case "sqlx":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			return nil, fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		repo := repository.NewSQLXRepository(db)
+		return &readOnlyTarget{
+			name:  "SQLX",
+			repo:  repo,
+			reads: repo.WithReadOnlyReads(),
+			inTx: func(ctx context.Context, fn func(ctx context.Context, tx repository.UserRepository) error) error {
+				return repo.WithReadOnlyTx(ctx, func(ctx context.Context, tx *repository.SQLXRepository) error { return fn(ctx, tx) })
+			},
+			close: func() { db.Close() },
+		}, nil
+	case "gorm":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			return nil, fmt.Errorf("GORM connection failed: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		repo := repository.NewGORMRepository(db)
+		return &readOnlyTarget{
+			name:  "GORM",
+			repo:  repo,
+			reads: repo.WithReadOnlyReads(),
+			inTx: func(ctx context.Context, fn func(ctx context.Context, tx repository.UserRepository) error) error {
+				return repo.WithReadOnlyTx(ctx, func(ctx context.Context, tx *repository.GORMRepository) error { return fn(ctx, tx) })
+			},
+			close: func() { sqlDB.Close() },
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// checkReadOnly creates a user with target and runs the checks the command
+// describes on it. It returns the user to benchmark, and an error when
+// there is none.
+func checkReadOnly(ctx context.Context, target *readOnlyTarget) ([]readOnlyCheck, *models.User, error) {
+	runID := benchdata.RunID(ctx)
+	request := func(n int64) *models.CreateUserRequest {
+		return &models.CreateUserRequest{
+			Name:  fmt.Sprintf("Read-Only %s %d", target.name, n),
+			Email: benchdata.Email(runID, "readonly", target.name, n),
+			Age:   30,
+		}
+	}
+	user, err := target.repo.CreateUser(ctx, request(0))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the user to read: %w", err)
+	}
+
+	read := func(ctx context.Context, repo repository.UserRepository) (string, error) {
+		got, err := repo.GetUserByID(ctx, user.ID)
+		if err != nil {
+			return "", err
+		}
+		if got.Email != user.Email {
+			return fmt.Sprintf("read %s", got.Email), nil
+		}
+		return "read", nil
+	}
+
+	checks := []readOnlyCheck{
+		{Check: "write in tx", Expected: "rejected with SQLSTATE " + readOnlySQLState},
+		{Check: "read in tx", Expected: "read"},
+		{Check: "read-only reads", Expected: "read"},
+		{Check: "write beside them", Expected: "created"},
+	}
+	results := make([]string, len(checks))
+	errs := make([]error, len(checks))
+
+	errs[0] = target.inTx(ctx, func(ctx context.Context, tx repository.UserRepository) error {
+		_, err := tx.CreateUser(ctx, request(1))
+		return err
+	})
+	switch state := benchmark.SQLState(errs[0]); {
+	case errs[0] == nil:
+		results[0] = "created"
+	case state != "":
+		results[0], errs[0] = "rejected with SQLSTATE "+state, nil
+	}
+
+	errs[1] = target.inTx(ctx, func(ctx context.Context, tx repository.UserRepository) error {
+		var err error
+		results[1], err = read(ctx, tx)
+		return err
+	})
+	results[2], errs[2] = read(ctx, target.reads)
+	if _, errs[3] = target.reads.CreateUser(ctx, request(2)); errs[3] == nil {
+		results[3] = "created"
+	}
+
+	for i := range checks {
+		checks[i].Library = target.name
+		checks[i].Observed = results[i]
+		if errs[i] != nil {
+			checks[i].Error = errs[i].Error()
+		}
+	}
+	return checks, user, nil
+}
+
+// benchmarkReadOnly reads user id iterations times with target without a
+// transaction and with read-only reads, alternating them call by call
+func benchmarkReadOnly(ctx context.Context, target *readOnlyTarget, id, iterations int) (readOnlyTiming, error) {
+	timing := readOnlyTiming{Library: target.name, Iterations: iterations}
+	direct := make([]time.Duration, 0, iterations)
+	readOnly := make([]time.Duration, 0, iterations)
+	for range iterations {
+		start := time.Now()
+		if _, err := target.repo.GetUserByID(ctx, id); err != nil {
+			return timing, err
+		}
+		direct = append(direct, time.Since(start))
+
+		start = time.Now()
+		if _, err := target.reads.GetUserByID(ctx, id); err != nil {
+			return timing, err
+		}
+		readOnly = append(readOnly, time.Since(start))
+	}
+	timing.Direct, timing.ReadOnly = medianDuration(direct), medianDuration(readOnly)
+	return timing, nil
+}
+
+// printReadOnlyChecks prints one line per check
+func printReadOnlyChecks(w io.Writer, checks []readOnlyCheck) {
+	fmt.Fprintf(w, "%-6s | %-17s | %s\n", "Lib", "Check", "Result")
+	fmt.Fprintln(w, "-------|-------------------|------------------------------")
+	for _, c := range checks {
+		result := "✅ " + c.Observed
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-6s | %-17s | %s\n", c.Library, c.Check, result)
+	}
+}
+
+// printReadOnlyTimings prints the medians of reading a user by ID without
+// and in a read-only transaction
+func printReadOnlyTimings(w io.Writer, timings []readOnlyTiming) {
+	fmt.Fprintln(w, "GetUserByID, medians:")
+	fmt.Fprintf(w, "%-6s | %-10s | %-10s | %-10s | %s\n", "Lib", "Direct", "Read-only", "Added", "Overhead")
+	fmt.Fprintln(w, "-------|------------|------------|------------|---------")
+	for _, t := range timings {
+		fmt.Fprintf(w, "%-6s | %-10v | %-10v | %-10v | %s\n", t.Library,
+			t.Direct.Round(time.Microsecond), t.ReadOnly.Round(time.Microsecond),
+			(t.ReadOnly - t.Direct).Round(time.Microsecond), overhead(t.ReadOnly, t.Direct))
+	}
+}
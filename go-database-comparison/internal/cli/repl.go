@@ -0,0 +1,409 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+const replHelp = `Commands:
+  create <name> <email> <age>     Create a user
+  get <id>                        Get an active user
+  list [limit] [offset]           List active users, newest first (default 10 0)
+  update <id> <field>=<value>...  Update name, email, age or active
+  delete <id>                     Soft delete a user
+  search <email-pattern>          Find users whose email contains the pattern
+  help                            Show this help
+  exit                            Leave the REPL
+Quote arguments containing spaces, e.g. create "Ada Lovelace" ada@example.com 36`
+
+func newREPLCommand(opts *globalOptions) *cobra.Command {
+	var library string
+	cmd := &cobra.Command{
+		Use:   "repl",
+		Short: "Run CRUD commands interactively and show the SQL each one executes",
+		Long: `Run CRUD commands interactively against one library and show the SQL
+each one executes, with its arguments, rows and timing.
+
+Every command is bounded by --timeout, 30s by default. Commands are read
+from stdin, so a script can be piped in as well.
+
+` + replHelp,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runREPL(cmd, opts, library)
+		},
+	}
+	cmd.Flags().StringVar(&library, "lib", "pq", "Library to use: pq, sqlx or gorm")
+	return cmd
+}
+
+func runREPL(cmd *cobra.Command, opts *globalOptions, library string) error {
+	if opts.jsonOutput() {
+		return fmt.Errorf("repl is interactive and has no JSON output")
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 30*time.Second)
+	defer cancel()
+
+	// Capture the statements of each command to print them with its result
+	config := opts.dbConfig()
+	config.QueryLog = sqlcapture.Sink()
+
+	name, repo, closeRepo, err := openCRUDRepository(ctx, library, config)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	w := cmd.OutOrStdout()
+	banner(w, fmt.Sprintf("💻 Go Database Comparison - %s REPL", name))
+	fmt.Fprintln(w, `Type "help" for the commands, "exit" to leave.`)
+
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	for {
+		fmt.Fprintf(w, "%s> ", strings.ToLower(name))
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return scanner.Err()
+		}
+
+		args, err := splitArgs(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(w, "❌ %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "help":
+			fmt.Fprintln(w, replHelp)
+			continue
+		}
+
+		cmdCtx, cancel := opts.commandContext(cmd, 30*time.Second)
+		runREPLLine(cmdCtx, w, repo, args)
+		cancel()
+	}
+}
+
+// openCRUDRepository connects library and returns its display name, its
+// repository and the function closing the connection
+func openCRUDRepository(ctx context.Context, library string, config *database.DatabaseConfig) (string, repository.UserRepository, func(), error) {
+	switch strings.ToLower(library) {
+	case "pq":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		repo := repository.NewPQRepository(db)
+		if config.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		return "PQ", repo, func() { db.Close() }, nil
+	case "sqlx":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		repo := repository.NewSQLXRepository(db)
+		if config.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		return "SQLX", repo, func() { db.Close() }, nil
+	case "gorm":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("GORM connection failed: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		repo := repository.NewGORMRepository(db)
+		if config.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		return "GORM", repo, func() { sqlDB.Close() }, nil
+	default:
+		return "", nil, nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// openRepositoryDB connects library and returns its name, its repository
+// and the *sql.DB beneath it, which closes the connection
+func openRepositoryDB(ctx context.Context, library string, config *database.DatabaseConfig) (string, repository.UserRepository, *sql.DB, error) {
+	switch strings.ToLower(library) {
+	case "pq":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		repo := repository.NewPQRepository(db)
+		if config.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		return "PQ", repo, db, nil
+	case "sqlx":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		repo := repository.NewSQLXRepository(db)
+		if config.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		return "SQLX", repo, db.DB, nil
+	case "gorm":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("GORM connection failed: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		repo := repository.NewGORMRepository(db)
+		if config.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		return "GORM", repo, sqlDB, nil
+	default:
+		return "", nil, nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// runREPLLine runs one REPL command and prints its statements, result and
+// timing. Errors are printed as well, the session goes on.
+func runREPLLine(ctx context.Context, w io.Writer, repo repository.UserRepository, args []string) {
+	ctx, recorder := sqlcapture.WithRecorder(ctx)
+	start := time.Now()
+	result, err := runREPLCommand(ctx, repo, args)
+	elapsed := time.Since(start)
+
+	queries := recorder.Queries()
+	for _, q := range queries {
+		fmt.Fprintf(w, "  SQL   %s\n", strings.Join(strings.Fields(q.SQL), " "))
+		fmt.Fprintf(w, "        args=%s rows=%d time=%v\n", formatArgs(q.Args), q.Rows, q.Duration.Round(time.Microsecond))
+	}
+	if err != nil {
+		fmt.Fprintf(w, "❌ %v\n", err)
+		if len(queries) == 0 {
+			return // Rejected before reaching the database
+		}
+	} else {
+		fmt.Fprint(w, result)
+	}
+	fmt.Fprintf(w, "  (%v, %d statement(s))\n", elapsed.Round(time.Microsecond), len(queries))
+}
+
+// formatArgs renders statement arguments compactly, strings quoted
+func formatArgs(args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			parts[i] = strconv.Quote(v)
+		case time.Time:
+			parts[i] = v.Format(time.RFC3339Nano)
+		default:
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// runREPLCommand executes args against repo and returns the rendered result
+func runREPLCommand(ctx context.Context, repo repository.UserRepository, args []string) (string, error) {
+	switch args[0] {
+	case "create":
+		if len(args) != 4 {
+			return "", fmt.Errorf("usage: create <name> <email> <age>")
+		}
+		age, err := strconv.Atoi(args[3])
+		if err != nil {
+			return "", fmt.Errorf("invalid age %q", args[3])
+		}
+		user, err := repo.CreateUser(ctx, &models.CreateUserRequest{Name: args[1], Email: args[2], Age: age})
+		if err != nil {
+			return "", err
+		}
+		return formatUsers(user), nil
+
+	case "get":
+		id, err := replID(args, "get <id>")
+		if err != nil {
+			return "", err
+		}
+		user, err := repo.GetUserByID(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return formatUsers(user), nil
+
+	case "list":
+		limit, offset := 10, 0
+		var err error
+		if len(args) > 3 {
+			return "", fmt.Errorf("usage: list [limit] [offset]")
+		}
+		if len(args) > 1 {
+			if limit, err = strconv.Atoi(args[1]); err != nil {
+				return "", fmt.Errorf("invalid limit %q", args[1])
+			}
+		}
+		if len(args) > 2 {
+			if offset, err = strconv.Atoi(args[2]); err != nil {
+				return "", fmt.Errorf("invalid offset %q", args[2])
+			}
+		}
+		users, err := repo.GetAllUsers(ctx, limit, offset)
+		if err != nil {
+			return "", err
+		}
+		return formatUsers(users...), nil
+
+	case "update":
+		if len(args) < 3 {
+			return "", fmt.Errorf("usage: update <id> <field>=<value>...")
+		}
+		id, err := replID(args[:2], "update <id> <field>=<value>...")
+		if err != nil {
+			return "", err
+		}
+		req, err := parseUpdate(args[2:])
+		if err != nil {
+			return "", err
+		}
+		user, err := repo.UpdateUser(ctx, id, req)
+		if err != nil {
+			return "", err
+		}
+		return formatUsers(user), nil
+
+	case "delete":
+		id, err := replID(args, "delete <id>")
+		if err != nil {
+			return "", err
+		}
+		if err := repo.DeleteUser(ctx, id); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("  deleted user %d\n", id), nil
+
+	case "search":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: search <email-pattern>")
+		}
+		users, err := repo.GetUsersByEmail(ctx, args[1])
+		if err != nil {
+			return "", err
+		}
+		return formatUsers(users...), nil
+
+	default:
+		return "", fmt.Errorf("unknown command %q, try help", args[0])
+	}
+}
+
+// replID parses the user ID of a command taking exactly one
+func replID(args []string, usage string) (int, error) {
+	if len(args) != 2 {
+		return 0, fmt.Errorf("usage: %s", usage)
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", args[1])
+	}
+	return id, nil
+}
+
+// parseUpdate builds an update request from field=value arguments
+func parseUpdate(fields []string) (*models.UpdateUserRequest, error) {
+	req := &models.UpdateUserRequest{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected field=value, got %q", field)
+		}
+		switch key {
+		case "name":
+			req.Name = &value
+		case "email":
+			req.Email = &value
+		case "age":
+			age, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid age %q", value)
+			}
+			req.Age = &age
+		case "active":
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid active %q", value)
+			}
+			req.IsActive = &active
+		default:
+			return nil, fmt.Errorf("unknown field %q (expected name, email, age or active)", key)
+		}
+	}
+	return req, nil
+}
+
+// formatUsers renders users one per line
+func formatUsers(users ...*models.User) string {
+	if len(users) == 0 {
+		return "  no users\n"
+	}
+	var b strings.Builder
+	for _, u := range users {
+		fmt.Fprintf(&b, "  #%d %s <%s> age %d active=%t updated %s\n",
+			u.ID, u.Name, u.Email, u.Age, u.IsActive, u.UpdatedAt.Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// splitArgs splits a REPL line at whitespace, keeping text in single or
+// double quotes together
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
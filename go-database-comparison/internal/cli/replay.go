@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/replay"
+	"go-database-comparison/pkg/repository"
+)
+
+// replayOptions holds the flags of the replay command
+type replayOptions struct {
+	libraries []string
+	pace      bool
+}
+
+// replayDocument is printed with --format json
+type replayDocument struct {
+	Workload string          `json:"workload"`
+	Header   replay.Header   `json:"header"`
+	Reports  []replay.Report `json:"reports"`
+}
+
+func newReplayCommand(opts *globalOptions) *cobra.Command {
+	replayOpts := replayOptions{}
+	cmd := &cobra.Command{
+		Use:   "replay <workload-file>",
+		Short: "Re-execute a recorded workload on each library",
+		Long: `Re-execute a workload recorded with "serve --record" or
+"comprehensive-benchmark --record" on each library, so the libraries are
+compared on exactly the same operations and parameters.
+
+Calls are replayed one after another in the order they started when
+recorded, back to back or, with --pace, keeping the recorded gaps between
+them. The IDs of users the workload created are mapped to the IDs their
+re-creation got, and emails are moved to this run, so the workload can be
+replayed on several libraries and against any database. Remove the users
+afterwards with "dbcompare cleanup --run-id" and the printed run ID.
+
+Diverged counts the calls that failed where the recording succeeded or the
+other way round, e.g. a read of a user the target database lacks.`,
+		Example: "  dbcompare serve --record workload.jsonl\n  dbcompare replay workload.jsonl --lib pq,sqlx,gorm",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReplay(cmd, opts, replayOpts, args[0])
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&replayOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to replay on, comma separated")
+	flags.BoolVar(&replayOpts.pace, "pace", false, "Keep the recorded time between calls instead of replaying back to back")
+	return cmd
+}
+
+func runReplay(cmd *cobra.Command, opts *globalOptions, replayOpts replayOptions, path string) error {
+	workload, err := replay.Load(path)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+	fmt.Fprintf(w, "▶️  Replaying %d calls recorded in run %s at %s\n",
+		len(workload.Ops), workload.Header.RunID, workload.Header.RecordedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+
+	doc := replayDocument{Workload: path, Header: workload.Header}
+	for _, library := range replayOpts.libraries {
+		name, repo, closeRepo, err := openCRUDRepository(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		log.Info("replaying workload", "library", name, "calls", len(workload.Ops))
+		report, err := replay.Replay(ctx, repo, workload, replay.Options{Library: name, RunID: opts.runID, Pace: replayOpts.pace})
+		closeRepo()
+		if err != nil {
+			return fmt.Errorf("%s replay failed: %w", name, err)
+		}
+		printReplayReport(w, report)
+		doc.Reports = append(doc.Reports, report)
+	}
+
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, doc)
+	}
+	return nil
+}
+
+// printReplayReport prints the per-operation timings of one library's replay
+func printReplayReport(w io.Writer, report replay.Report) {
+	fmt.Fprintf(w, "\n== %s: %d calls in %v ==\n", report.Library, report.Ops, report.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "%-10s | %6s | %6s | %8s | %-11s | %s\n", "Operation", "Count", "Errors", "Diverged", "Avg Time", "P95 Time")
+	fmt.Fprintln(w, "-----------|--------|--------|----------|-------------|------------")
+	for _, s := range report.Operations {
+		fmt.Fprintf(w, "%-10s | %6d | %6d | %8d | %-11v | %v\n", s.Operation, s.Count, s.Errors, s.Diverged, s.Avg, s.P95)
+	}
+}
+
+// createRecorder creates the workload file at path and a recorder writing
+// to it, with the function flushing and closing it
+func createRecorder(path, runID string, log *slog.Logger) (*replay.Recorder, func(), error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create workload file: %w", err)
+	}
+	recorder, err := replay.NewRecorder(f, runID)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	log.Info("recording workload", "path", path)
+
+	closeRecorder := func() {
+		err := recorder.Flush()
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			log.Warn("failed to write workload", "path", path, "error", err)
+			return
+		}
+		log.Info("workload recorded", "path", path, "calls", recorder.Count())
+	}
+	return recorder, closeRecorder, nil
+}
+
+// recordRepositories wraps every repository of repos to record its calls,
+// under the library name the server knows it by
+func recordRepositories(repos map[string]repository.UserRepository, recorder *replay.Recorder) {
+	for library, repo := range repos {
+		repos[library] = recorder.Wrap(strings.ToUpper(library), repo)
+	}
+}
@@ -0,0 +1,539 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/tenant"
+)
+
+// The tenants the checks and the benchmark act for
+const (
+	rlsTenantA = 1
+	rlsTenantB = 2
+)
+
+// rlsOptions holds the flags of the rls command
+type rlsOptions struct {
+	libraries  []string
+	iterations int
+	users      int
+	keepData   bool
+}
+
+// rlsCheck is the outcome of one isolation check on one library
+type rlsCheck struct {
+	Library  string `json:"library"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check observed what the policy guarantees
+func (c rlsCheck) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// rlsTiming compares one operation run as the table owner, bypassing the
+// policy, with the same operation run for a tenant
+type rlsTiming struct {
+	Library    string        `json:"library"`
+	Operation  string        `json:"operation"`
+	Iterations int           `json:"iterations"`
+	Owner      time.Duration `json:"owner_median"`       // Pooled call as the owner
+	RLSQuery   time.Duration `json:"rls_query_median"`   // The same call under the policy
+	RLSRequest time.Duration `json:"rls_request_median"` // Pinning, Set, the call and Release
+}
+
+// rlsDocument is printed with --format json
+type rlsDocument struct {
+	RunID   string      `json:"run_id"`
+	Checks  []rlsCheck  `json:"checks"`
+	Timings []rlsTiming `json:"timings,omitempty"`
+	Failed  int         `json:"failed"`
+}
+
+// tenantTarget is one library's repository on its pool, acting as the
+// table owner, and on pinned connections that can act for a tenant
+type tenantTarget struct {
+	name  string
+	db    *sql.DB
+	owner repository.UserRepository
+	pin   func(ctx context.Context) (*sql.Conn, repository.UserRepository, error)
+}
+
+func newRLSCommand(opts *globalOptions) *cobra.Command {
+	rlsOpts := rlsOptions{}
+	cmd := &cobra.Command{
+		Use:   "rls",
+		Short: "Check multi-tenant row-level security through each library and benchmark its overhead",
+		Long: `Check that row-level security isolates the users of tenants when each
+library scopes its requests with pkg/tenant: every request pins a
+connection, switches it to the dbcompare_tenant role with the tenant in
+app.tenant_id, runs the library's usual repository call and resets the
+connection before it goes back to the pool. Migration 0006 adds the
+tenant_id column and the policy, run dbcompare migrate up first.
+
+Tenant 1 creates a user, then the checks verify that
+  create         the user belongs to tenant 1,
+  read own       tenant 1 reads it,
+  read other     tenant 2 does not,
+  search other   tenant 2 does not find it by email,
+  update other   tenant 2 cannot rename it,
+  delete other   tenant 2 cannot delete it,
+  insert other   tenant 2 cannot create a user of tenant 1,
+  no tenant      a connection without a tenant does not read it,
+  reset          a connection back in the pool acts for no tenant.
+A failed check makes the command exit with code 2.
+
+The benchmark then seeds --users users of tenant 1 and compares the median
+of --iterations calls as the table owner, which bypasses the policy, with
+the same calls for tenant 1, both the call alone and the whole request.
+The users are removed afterwards unless --keep-data.`,
+		Example: "  dbcompare rls\n  dbcompare rls --lib pq --iterations 1000",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRLS(cmd, opts, rlsOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&rlsOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to run, comma separated")
+	flags.IntVar(&rlsOpts.iterations, "iterations", 200, "Calls per operation and mode in the benchmark, 0 skips it")
+	flags.IntVar(&rlsOpts.users, "users", 50, "Users of tenant 1 seeded for the benchmark")
+	flags.BoolVar(&rlsOpts.keepData, "keep-data", false, "Keep the users the command created")
+	return cmd
+}
+
+func runRLS(cmd *cobra.Command, opts *globalOptions, rlsOpts rlsOptions) error {
+	if rlsOpts.iterations < 0 {
+		return fmt.Errorf("--iterations must not be negative, got %d", rlsOpts.iterations)
+	}
+	if rlsOpts.users < 1 {
+		return fmt.Errorf("--users must be at least 1, got %d", rlsOpts.users)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🏢 Go Database Comparison - Row-Level Security")
+	// The observer reads as the owner what the tenants must not see
+	observer, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer observer.Close()
+	enabled, err := tenant.Enabled(ctx, observer)
+	if err != nil {
+		return fmt.Errorf("failed to check row-level security: %w", err)
+	}
+	if !enabled {
+		return fmt.Errorf("the users table has no tenant policy, run dbcompare migrate up to apply migration 0006")
+	}
+
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	if !rlsOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	doc := rlsDocument{RunID: opts.runID}
+	for _, library := range rlsOpts.libraries {
+		target, closeTarget, err := openTenantTarget(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		log.Info("checking tenant isolation", "library", target.name)
+		checks, err := checkTenantIsolation(ctx, target, observer)
+		doc.Checks = append(doc.Checks, checks...)
+		if err == nil && rlsOpts.iterations > 0 {
+			log.Info("benchmarking row-level security", "library", target.name, "iterations", rlsOpts.iterations)
+			var timings []rlsTiming
+			timings, err = benchmarkRLS(ctx, target, rlsOpts.users, rlsOpts.iterations)
+			doc.Timings = append(doc.Timings, timings...)
+		}
+		closeTarget()
+		if err != nil {
+			return fmt.Errorf("%s row-level security run failed: %w", target.name, err)
+		}
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	fmt.Fprintln(w)
+	printRLSChecks(w, doc.Checks)
+	if len(doc.Timings) > 0 {
+		fmt.Fprintln(w)
+		printRLSTimings(w, doc.Timings)
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d tenant isolation checks failed", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d tenant isolation checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// openTenantTarget connects library and returns it with the function
+// closing its connection
+func openTenantTarget(ctx context.Context, library string, config *database.DatabaseConfig) (*tenantTarget, func(), error) {
+	switch strings.ToLower(library) {
+	case "pq":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		repo := repository.NewPQRepository(db)
+		return &tenantTarget{name: "PQ", db: db, owner: repo, pin: func(ctx context.Context) (*sql.Conn, repository.UserRepository, error) {
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn, repo.WithConn(conn), nil
+		}}, func() { db.Close() }, nil
+	case "sqlx":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		repo := repository.NewSQLXRepository(db)
+		return &tenantTarget{name: "SQLX", db: db.DB, owner: repo, pin: func(ctx context.Context) (*sql.Conn, repository.UserRepository, error) {
+			conn, err := db.Connx(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn.Conn, repo.WithConn(conn), nil
+		}}, func() { db.Close() }, nil
+	case "gorm":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("GORM connection failed: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		repo := repository.NewGORMRepository(db)
+		return &tenantTarget{name: "GORM", db: sqlDB, owner: repo, pin: func(ctx context.Context) (*sql.Conn, repository.UserRepository, error) {
+			conn, err := sqlDB.Conn(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn, repo.WithConn(conn), nil
+		}}, func() { sqlDB.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// asTenant runs fn as one request of tenantID: on a pinned connection set
+// to act for the tenant, which is reset afterwards
+func (t *tenantTarget) asTenant(ctx context.Context, tenantID int, fn func(conn *sql.Conn, repo repository.UserRepository) error) error {
+	conn, repo, err := t.pin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := tenant.Set(ctx, conn, tenantID); err != nil {
+		tenant.Release(conn)
+		return err
+	}
+	err = fn(conn, repo)
+	if releaseErr := tenant.Release(conn); err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+// checkTenantIsolation runs the isolation checks on target. It only
+// returns an error when tenant 1 cannot create its user, the checks have
+// nothing to look at then.
+func checkTenantIsolation(ctx context.Context, target *tenantTarget, observer *sql.DB) ([]rlsCheck, error) {
+	runID := benchdata.RunID(ctx)
+	var user *models.User
+	err := target.asTenant(ctx, rlsTenantA, func(_ *sql.Conn, repo repository.UserRepository) (err error) {
+		email := benchdata.Email(runID, "rls-a", target.name, 1)
+		user, err = repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Tenant A " + target.name, Email: email, Age: 30})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tenant %d failed to create a user: %w", rlsTenantA, err)
+	}
+
+	var checks []rlsCheck
+	check := func(name, expected string, observe func() (string, error)) {
+		c := rlsCheck{Library: target.name, Check: name, Expected: expected}
+		observed, err := observe()
+		c.Observed = observed
+		if err != nil {
+			c.Error = err.Error()
+		}
+		checks = append(checks, c)
+	}
+	// get reads the user for tenantID, telling hidden users from failures
+	get := func(tenantID int) (string, error) {
+		observed := "visible"
+		err := target.asTenant(ctx, tenantID, func(_ *sql.Conn, repo repository.UserRepository) error {
+			_, err := repo.GetUserByID(ctx, user.ID)
+			if isNotFound(err) {
+				observed, err = "hidden", nil
+			}
+			return err
+		})
+		return observed, err
+	}
+	// unchanged reports whether the owner still reads the user as created
+	unchanged := func() (string, error) {
+		current, err := target.owner.GetUserByID(ctx, user.ID)
+		if isNotFound(err) {
+			return "deleted", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if current.Name != user.Name {
+			return fmt.Sprintf("renamed to %q", current.Name), nil
+		}
+		return "unchanged", nil
+	}
+
+	check("create", fmt.Sprintf("tenant %d", rlsTenantA), func() (string, error) {
+		owner, err := tenant.TenantOf(ctx, observer, user.ID)
+		return fmt.Sprintf("tenant %d", owner), err
+	})
+	check("read own", "visible", func() (string, error) { return get(rlsTenantA) })
+	check("read other", "hidden", func() (string, error) { return get(rlsTenantB) })
+	check("search other", "found 0", func() (string, error) {
+		var found []*models.User
+		err := target.asTenant(ctx, rlsTenantB, func(_ *sql.Conn, repo repository.UserRepository) (err error) {
+			found, err = repo.GetUsersByEmail(ctx, user.Email)
+			return err
+		})
+		return fmt.Sprintf("found %d", len(found)), err
+	})
+	check("update other", "unchanged", func() (string, error) {
+		err := target.asTenant(ctx, rlsTenantB, func(_ *sql.Conn, repo repository.UserRepository) error {
+			name := "Renamed by tenant B"
+			_, err := repo.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Name: &name})
+			if isNotFound(err) {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		return unchanged()
+	})
+	check("delete other", "unchanged", func() (string, error) {
+		err := target.asTenant(ctx, rlsTenantB, func(_ *sql.Conn, repo repository.UserRepository) error {
+			if err := repo.DeleteUser(ctx, user.ID); !isNotFound(err) {
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		return unchanged()
+	})
+	check("insert other", "rejected", func() (string, error) {
+		observed := "inserted"
+		err := target.asTenant(ctx, rlsTenantB, func(conn *sql.Conn, _ repository.UserRepository) error {
+			// The repositories never name tenant_id, write it directly
+			email := benchdata.Email(runID, "rls-b", target.name, 1)
+			_, err := conn.ExecContext(ctx, "INSERT INTO users (name, email, age, tenant_id) VALUES ($1, $2, $3, $4)",
+				"Tenant B "+target.name, email, 30, rlsTenantA)
+			if err != nil && strings.Contains(err.Error(), "row-level security") {
+				observed, err = "rejected", nil
+			}
+			return err
+		})
+		return observed, err
+	})
+	check("no tenant", "hidden", func() (string, error) { return get(tenant.None) })
+	check("reset", "owner without tenant", func() (string, error) {
+		return checkTenantReset(ctx, target)
+	})
+	return checks, nil
+}
+
+// checkTenantReset makes a request for tenant 1 and then reads what the
+// connection it used acts as once back in the pool
+func checkTenantReset(ctx context.Context, target *tenantTarget) (string, error) {
+	// With a single connection the second request gets the first one's
+	stats := target.db.Stats()
+	target.db.SetMaxOpenConns(1)
+	defer target.db.SetMaxOpenConns(stats.MaxOpenConnections)
+
+	if err := target.asTenant(ctx, rlsTenantA, func(*sql.Conn, repository.UserRepository) error { return nil }); err != nil {
+		return "", err
+	}
+	conn, _, err := target.pin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	role, tenantID, err := tenant.Current(ctx, conn)
+	if err != nil {
+		return "", err
+	}
+	observed := "owner"
+	if role == tenant.Role {
+		observed = role
+	}
+	if tenantID == "" {
+		return observed + " without tenant", nil
+	}
+	return observed + " with tenant " + tenantID, nil
+}
+
+// benchmarkRLS seeds users users of tenant 1 and times reading them as the
+// owner and for the tenant, alternating the modes call by call
+func benchmarkRLS(ctx context.Context, target *tenantTarget, users, iterations int) ([]rlsTiming, error) {
+	runID := benchdata.RunID(ctx)
+	ids := make([]int, 0, users)
+	err := target.asTenant(ctx, rlsTenantA, func(_ *sql.Conn, repo repository.UserRepository) error {
+		for n := 1; n <= users; n++ {
+			email := benchdata.Email(runID, "rls-bench", target.name, int64(n))
+			user, err := repo.CreateUser(ctx, &models.CreateUserRequest{Name: "RLS Bench", Email: email, Age: 20 + n%50})
+			if err != nil {
+				return err
+			}
+			ids = append(ids, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed tenant %d: %w", rlsTenantA, err)
+	}
+
+	// Only the seeded users match, so owner and tenant read the same rows
+	pattern := fmt.Sprintf("rls-bench-%s-", strings.ToLower(target.name))
+	operations := []struct {
+		name string
+		call func(repo repository.UserRepository, i int) error
+	}{
+		{"get by id", func(repo repository.UserRepository, i int) error {
+			_, err := repo.GetUserByID(ctx, ids[i%len(ids)])
+			return err
+		}},
+		{"search by email", func(repo repository.UserRepository, _ int) error {
+			found, err := repo.GetUsersByEmail(ctx, pattern)
+			if err == nil && len(found) != len(ids) {
+				err = fmt.Errorf("found %d of the %d seeded users", len(found), len(ids))
+			}
+			return err
+		}},
+	}
+
+	var timings []rlsTiming
+	for _, op := range operations {
+		owner := make([]time.Duration, 0, iterations)
+		query := make([]time.Duration, 0, iterations)
+		request := make([]time.Duration, 0, iterations)
+		for i := 0; i < iterations; i++ {
+			start := time.Now()
+			if err := op.call(target.owner, i); err != nil {
+				return timings, fmt.Errorf("%s as the owner: %w", op.name, err)
+			}
+			owner = append(owner, time.Since(start))
+
+			start = time.Now()
+			err := target.asTenant(ctx, rlsTenantA, func(_ *sql.Conn, repo repository.UserRepository) error {
+				callStart := time.Now()
+				err := op.call(repo, i)
+				query = append(query, time.Since(callStart))
+				return err
+			})
+			if err != nil {
+				return timings, fmt.Errorf("%s for tenant %d: %w", op.name, rlsTenantA, err)
+			}
+			request = append(request, time.Since(start))
+		}
+		timings = append(timings, rlsTiming{
+			Library:    target.name,
+			Operation:  op.name,
+			Iterations: iterations,
+			Owner:      medianDuration(owner),
+			RLSQuery:   medianDuration(query),
+			RLSRequest: medianDuration(request),
+		})
+	}
+	return timings, nil
+}
+
+// isNotFound reports whether err is a repository's error for a user that
+// does not exist, or that the policy hides
+func isNotFound(err error) bool {
+	return errors.Is(err, repository.ErrNotFound)
+}
+
+// medianDuration returns the median of durations, which it sorts
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	slices.Sort(durations)
+	return durations[len(durations)/2]
+}
+
+// printRLSChecks prints one line per check
+func printRLSChecks(w io.Writer, checks []rlsCheck) {
+	fmt.Fprintf(w, "%-6s | %-12s | %s\n", "Lib", "Check", "Result")
+	fmt.Fprintln(w, "-------|--------------|------------------------------")
+	for _, c := range checks {
+		result := "✅ " + c.Observed
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-6s | %-12s | %s\n", c.Library, c.Check, result)
+	}
+}
+
+// printRLSTimings prints the medians of every operation with the overhead
+// of the policy and of the whole tenant request over the owner's call
+func printRLSTimings(w io.Writer, timings []rlsTiming) {
+	fmt.Fprintf(w, "%-6s | %-15s | %-10s | %-10s | %-10s | %-8s | %s\n", "Lib", "Operation", "Owner", "RLS query", "RLS req", "Policy", "Request")
+	fmt.Fprintln(w, "-------|-----------------|------------|------------|------------|----------|---------")
+	for _, t := range timings {
+		fmt.Fprintf(w, "%-6s | %-15s | %-10v | %-10v | %-10v | %-8s | %s\n",
+			t.Library, t.Operation,
+			t.Owner.Round(time.Microsecond), t.RLSQuery.Round(time.Microsecond), t.RLSRequest.Round(time.Microsecond),
+			overhead(t.RLSQuery, t.Owner), overhead(t.RLSRequest, t.Owner))
+	}
+}
+
+// overhead formats how much slower d is than base
+func overhead(d, base time.Duration) string {
+	if base <= 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", (float64(d)/float64(base)-1)*100)
+}
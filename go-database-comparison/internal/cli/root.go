@@ -0,0 +1,357 @@
+// Package cli implements the dbcompare command line tool. Every subcommand
+// shares the database connection flags and reports errors the same way; the
+// standalone binaries under cmd/ run a single subcommand.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/buildinfo"
+	"go-database-comparison/pkg/config"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/dblog"
+	"go-database-comparison/pkg/sqlcomment"
+)
+
+// globalOptions holds the flags shared by all subcommands
+type globalOptions struct {
+	configPath string
+	db         database.DatabaseConfig
+	timeout    time.Duration // Overrides the command's default timeout when set
+	log        logOptions
+	logQueries bool
+	logger     *slog.Logger // Set before any subcommand runs
+	trace      traceOptions
+	requestID  string                      // Tags every statement through pkg/sqlcomment when set
+	runID      string                      // Marks the rows the command creates, see pkg/benchdata
+	runIDSet   bool                        // Whether runID was given rather than generated
+	format     string                      // Output format of results, see exit.go
+	stopTrace  func(context.Context) error // Flushes exported spans, set with logger
+
+	printedJSON bool     // Whether the command printed its JSON document
+	args        []string // Arguments the command tree was executed with
+	watched     bool     // Whether this is a run of --watch, see watch.go
+}
+
+// NewRootCommand builds the dbcompare command tree
+func NewRootCommand() *cobra.Command {
+	root, _ := newRootCommand()
+	return root
+}
+
+func newRootCommand() (*cobra.Command, *globalOptions) {
+	opts := &globalOptions{db: *database.DefaultPostgreSQLConfig()}
+
+	root := &cobra.Command{
+		Use:   "dbcompare",
+		Short: "Compare lib/pq, sqlx and GORM on PostgreSQL",
+		Long: `Compare lib/pq, sqlx and GORM on PostgreSQL.
+
+Every flag can also be set through an environment variable named ` + envPrefix + `
+followed by the flag name in upper case with dashes as underscores, e.g.
+` + envPrefix + `ITERATIONS=500, or in the YAML file given with --config.
+Command line flags take precedence over environment variables, which take
+precedence over the config file.
+
+Progress is logged to stderr, with --json-log as JSON lines, while results
+and reports are printed to stdout; with --format json as a single JSON
+document, or {"error": ..., "exit_code": ...} when the command failed
+before producing one.
+
+Exit codes: 0 success, 1 failure to run (e.g. the database is unreachable
+or a flag is invalid), 2 a check failed (doctor, test-crud), 3 a benchmark
+regressed against its --baseline.`,
+		Version:       buildinfo.Get().Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Environment first: setting a flag marks it changed, so the
+			// config file only fills in what neither of the others set
+			if err := applyEnv(cmd.Flags()); err != nil {
+				return err
+			}
+			if err := applyConfig(cmd, opts.configPath); err != nil {
+				return err
+			}
+			if err := validateFormat(opts.format); err != nil {
+				return err
+			}
+			if _, err := database.SQLXMapper(opts.db.SQLXMapper); err != nil {
+				return err
+			}
+
+			logger, err := opts.log.newLogger(cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			opts.logger = logger
+			slog.SetDefault(logger)
+
+			if opts.requestID == "auto" {
+				opts.requestID = sqlcomment.NewRequestID()
+			}
+			if opts.requestID != "" {
+				logger.Info("tagging statements", "request_id", opts.requestID)
+			}
+
+			opts.runIDSet = opts.runID != ""
+			if !opts.runIDSet {
+				opts.runID = benchdata.NewRunID()
+			} else if err := benchdata.ValidateRunID(opts.runID); err != nil {
+				return err
+			}
+
+			opts.stopTrace, err = opts.trace.setup(cmd.Context(), cmd.ErrOrStderr())
+			return err
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.configPath, "config", "", "YAML file with flag values, see pkg/config")
+	flags.StringVar(&opts.db.Host, "host", opts.db.Host, "PostgreSQL host")
+	flags.IntVar(&opts.db.Port, "port", opts.db.Port, "PostgreSQL port")
+	flags.StringVar(&opts.db.User, "user", opts.db.User, "PostgreSQL user")
+	flags.StringVar(&opts.db.Password, "password", opts.db.Password, "PostgreSQL password")
+	flags.StringVar(&opts.db.DBName, "dbname", opts.db.DBName, "PostgreSQL database name")
+	flags.StringVar(&opts.db.SSLMode, "sslmode", opts.db.SSLMode, "PostgreSQL sslmode")
+	flags.BoolVar(&opts.db.GORMHooks, "gorm-hooks", false, "Run the BeforeCreate and AfterUpdate hooks of the GORM user model")
+	flags.StringVar(&opts.db.SQLXMapper, "sqlx-mapper", "db", "How sqlx maps struct fields to columns: "+strings.Join(database.SQLXMappers, ", "))
+	flags.BoolVar(&opts.db.SQLXUnsafe, "sqlx-unsafe", false, "Let sqlx ignore result columns no struct field maps instead of failing")
+	flags.BoolVar(&opts.db.ReadOnlyTx, "read-only-tx", false, "Run every read method of the repositories in a read-only transaction")
+	flags.DurationVar(&opts.timeout, "timeout", 0, "Overall time limit of the command, 0 uses the command's default")
+	flags.BoolVarP(&opts.log.verbose, "verbose", "v", false, "Log debug details such as per-step timings")
+	flags.BoolVarP(&opts.log.quiet, "quiet", "q", false, "Only log warnings and errors, results are still printed")
+	flags.BoolVar(&opts.log.json, "json-log", false, "Log JSON lines instead of human readable text")
+	flags.BoolVar(&opts.logQueries, "log-queries", false, "Log every SQL statement with its arguments, duration and rows")
+	flags.StringVar(&opts.requestID, "request-id", "", `Append a sqlcommenter comment with this request ID to every statement, "auto" generates one`)
+	flags.StringVar(&opts.format, "format", formatText, "Output format of results: text or json")
+	flags.StringVar(&opts.runID, "run-id", "", "Mark the rows this run creates with this ID for dbcompare cleanup, generated when empty")
+	flags.StringVar(&opts.trace.exporter, "trace-exporter", "none", "Export OpenTelemetry spans: none, stdout (to stderr) or otlp (e.g. Jaeger, Tempo)")
+	flags.StringVar(&opts.trace.endpoint, "trace-endpoint", "", "OTLP/HTTP host:port, OTEL_EXPORTER_OTLP_ENDPOINT applies when empty")
+
+	root.AddCommand(
+		newTestConnectionCommand(opts),
+		newTestCRUDCommand(opts),
+		newSimpleBenchmarkCommand(opts),
+		newComprehensiveBenchmarkCommand(opts),
+		newDoctorCommand(opts),
+		newREPLCommand(opts),
+		newServeCommand(opts),
+		newSeedCommand(opts),
+		newMigrateCommand(opts),
+		newCleanupCommand(opts),
+		newDaemonCommand(opts),
+		newMatrixCommand(opts),
+		newCompareRevCommand(opts),
+		newReplayCommand(opts),
+		newFailoverCommand(opts),
+		newProxyCommand(opts),
+		newDeadlockCommand(opts),
+		newIsolationCommand(opts),
+		newValidateResultsCommand(opts),
+		newNotifyCommand(opts),
+		newTwoPhaseCommand(opts),
+		newRLSCommand(opts),
+		newEncryptionCommand(opts),
+		newGeneratedCommand(opts),
+		newWindowCommand(opts),
+		newHooksCommand(opts),
+		newGORMSQLCommand(opts),
+		newSQLXMappingCommand(opts),
+		newTimescaleCommand(opts),
+		newReadOnlyCommand(opts),
+		newVersionCommand(opts),
+	)
+	return root, opts
+}
+
+// Execute runs dbcompare with args and returns the process exit code, see
+// ExitOK and the following constants
+func Execute(args []string) int {
+	root, opts := newRootCommand()
+	root.SetArgs(args)
+	opts.args = args
+
+	err := root.Execute()
+	if opts.stopTrace != nil {
+		// Flush spans even if the command failed, that run is often the interesting one
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if shutdownErr := opts.stopTrace(shutdownCtx); shutdownErr != nil {
+			opts.logger.Warn("failed to flush traces", "error", shutdownErr)
+		}
+		cancel()
+	}
+	if err != nil {
+		if opts.logger == nil {
+			// Failed before the flags selected a logger
+			fmt.Fprintf(root.ErrOrStderr(), "❌ %v\n", err)
+		} else {
+			opts.logger.Error(err.Error())
+		}
+		if opts.jsonOutput() && !opts.printedJSON {
+			encoder := json.NewEncoder(root.OutOrStdout())
+			encoder.Encode(errorDocument{Error: err.Error(), ExitCode: exitCode(err)})
+		}
+	}
+	return exitCode(err)
+}
+
+// ExecuteSubcommand runs a single dbcompare subcommand with args, for the
+// standalone binaries that predate dbcompare
+func ExecuteSubcommand(name string, args []string) int {
+	return Execute(append([]string{name}, args...))
+}
+
+// Main runs dbcompare with the process arguments and exits
+func Main() {
+	os.Exit(Execute(os.Args[1:]))
+}
+
+// envPrefix starts the names of the environment variables overriding flags
+const envPrefix = "DBCOMPARE_"
+
+// envName returns the environment variable overriding the flag name
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnv sets every flag not given on the command line from its
+// environment variable, if that is set
+func applyEnv(flags *pflag.FlagSet) error {
+	var firstErr error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed || firstErr != nil {
+			return
+		}
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if err := flags.Set(f.Name, value); err != nil {
+			firstErr = fmt.Errorf("invalid %s: %w", envName(f.Name), err)
+		}
+	})
+	return firstErr
+}
+
+// applyConfig sets every flag of cmd not set otherwise from the config
+// file at path. Keys that match no flag are rejected to catch typos.
+func applyConfig(cmd *cobra.Command, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	file, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := validateConfig(cmd.Root(), file); err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	for name, value := range file.Settings(cmd.Name()) {
+		f := flags.Lookup(name)
+		if f == nil || f.Changed || name == "config" {
+			continue // A top-level key for another command's flag
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s: invalid %s: %w", file.Path, name, err)
+		}
+	}
+	return nil
+}
+
+// validateConfig checks that top-level keys are flags of some command and
+// that command sections only contain flags of their command
+func validateConfig(root *cobra.Command, file *config.File) error {
+	commands := make(map[string]*cobra.Command)
+	known := make(map[string]bool)
+	for _, c := range append(root.Commands(), root) {
+		commands[c.Name()] = c
+		for _, alias := range c.Aliases {
+			commands[alias] = c
+		}
+		commandFlags(c).VisitAll(func(f *pflag.Flag) { known[f.Name] = true })
+	}
+
+	for _, key := range file.GlobalKeys() {
+		if !known[key] {
+			return fmt.Errorf("%s: unknown key %q", file.Path, key)
+		}
+	}
+	for _, name := range file.Commands() {
+		c, ok := commands[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown command section %q", file.Path, name)
+		}
+		flags := commandFlags(c)
+		for _, key := range file.CommandKeys(name) {
+			if flags.Lookup(key) == nil {
+				return fmt.Errorf("%s: unknown key %q for %s", file.Path, key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// commandFlags returns the local and inherited flags of c
+func commandFlags(c *cobra.Command) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(c.Name(), pflag.ContinueOnError)
+	flags.AddFlagSet(c.LocalFlags())
+	flags.AddFlagSet(c.InheritedFlags())
+	return flags
+}
+
+// commandContext returns the context of a command run, bounded by the
+// --timeout flag or else by defaultTimeout; 0 for both means no limit
+func (o *globalOptions) commandContext(cmd *cobra.Command, defaultTimeout time.Duration) (context.Context, context.CancelFunc) {
+	timeout := defaultTimeout
+	if o.timeout > 0 {
+		timeout = o.timeout
+	}
+
+	ctx := benchdata.WithRunID(cmd.Context(), o.runID)
+	if o.requestID != "" {
+		ctx = sqlcomment.WithRequestID(ctx, o.requestID)
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// dbConfig returns the database configuration selected by the flags
+func (o *globalOptions) dbConfig() *database.DatabaseConfig {
+	config := o.db
+	if o.logQueries {
+		config.QueryLog = dblog.NewSink(o.logger, slog.LevelInfo)
+	}
+	return &config
+}
+
+// banner prints a command title underlined to its width
+func banner(w io.Writer, title string) {
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, underline(title))
+}
+
+// underline returns a rule of '=' as wide as text
+func underline(text string) string {
+	rule := make([]rune, 0, len(text))
+	for range []rune(text) {
+		rule = append(rule, '=')
+	}
+	return string(rule)
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/seed"
+)
+
+// seedOptions holds the flags of the seed command
+type seedOptions struct {
+	rows      int
+	seed      uint64
+	profile   string
+	batchSize int
+	reset     bool
+}
+
+func newSeedCommand(opts *globalOptions) *cobra.Command {
+	seedOpts := seedOptions{}
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the users table with a large, reproducible dataset",
+		Long: `Fill the users table with a large, reproducible dataset for read benchmarks.
+
+The same --rows, --seed and --profile always produce the same rows. Their
+emails end in @<profile>-<seed>.seed.test, which marks the dataset: a run
+resumes after the rows already present, so an interrupted seed is finished
+by running it again, and --reset deletes the dataset first.
+
+Profiles:
+  uniform    evenly spread ages and sign-up dates, every user active
+  realistic  common names, ages around 38, more recent sign-ups than old
+             ones and about 8% inactive users
+
+Seeding runs until done unless --timeout is set.`,
+		Example: "  dbcompare seed --rows=1000000 --seed=42 --profile=realistic",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSeed(cmd, opts, seedOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&seedOpts.rows, "rows", 100000, "Size of the dataset")
+	flags.Uint64Var(&seedOpts.seed, "seed", 42, "Seed of the row generator")
+	flags.StringVar(&seedOpts.profile, "profile", "realistic", "Shape of the rows: uniform or realistic")
+	flags.IntVar(&seedOpts.batchSize, "batch-size", 1000, "Rows per INSERT statement")
+	flags.BoolVar(&seedOpts.reset, "reset", false, "Delete the dataset's rows before seeding")
+	return cmd
+}
+
+// seedDocument is printed with --format json
+type seedDocument struct {
+	Domain string `json:"domain"` // Email domain of the dataset's users
+	Rows   int    `json:"rows"`   // Rows of the dataset once complete
+	seed.Result
+}
+
+func runSeed(cmd *cobra.Command, opts *globalOptions, seedOpts seedOptions) error {
+	profile, err := seed.ParseProfile(seedOpts.profile)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+
+	db, err := database.ConnectWithPQ(ctx, opts.dbConfig())
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer db.Close()
+
+	banner(w, "🌱 Go Database Comparison - Seed")
+	fmt.Fprintf(w, "Dataset: %d rows, seed %d, profile %s (@%s)\n",
+		seedOpts.rows, seedOpts.seed, profile, seed.Domain(seedOpts.seed, profile))
+
+	if seedOpts.reset {
+		deleted, err := seed.Reset(ctx, db, seedOpts.seed, profile)
+		if err != nil {
+			return err
+		}
+		log.Info("dataset reset", "deleted", deleted)
+	}
+
+	// Log progress about once a second, not once per batch
+	var lastReport time.Time
+	config := seed.Config{
+		Rows:      seedOpts.rows,
+		Seed:      seedOpts.seed,
+		Profile:   profile,
+		BatchSize: seedOpts.batchSize,
+		Progress: func(p seed.Progress) {
+			if time.Since(lastReport) < time.Second && p.Done < p.Total {
+				return
+			}
+			lastReport = time.Now()
+			eta := time.Duration(0)
+			if p.Rate > 0 {
+				eta = time.Duration(float64(p.Total-p.Done) / p.Rate * float64(time.Second))
+			}
+			log.Info("seeding", "done", p.Done, "total", p.Total,
+				"percent", fmt.Sprintf("%.1f", float64(p.Done)/float64(p.Total)*100),
+				"rows_per_sec", int(p.Rate), "eta", eta.Round(time.Second))
+		},
+	}
+
+	result, err := seed.Run(ctx, db, config)
+	if result.Existing > 0 {
+		fmt.Fprintf(w, "Resumed after %d existing rows\n", result.Existing)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Inserted %d rows before stopping, run again to resume\n", result.Inserted)
+		return err
+	}
+
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, seedDocument{
+			Domain: seed.Domain(seedOpts.seed, profile),
+			Rows:   max(seedOpts.rows, result.Existing),
+			Result: result,
+		})
+	}
+
+	rate := 0.0
+	if result.Duration > 0 {
+		rate = float64(result.Inserted) / result.Duration.Seconds()
+	}
+	fmt.Fprintf(w, "✅ Inserted %d rows in %v (%.0f rows/sec), dataset complete with %d rows\n",
+		result.Inserted, result.Duration.Round(time.Millisecond), rate, max(seedOpts.rows, result.Existing))
+	return nil
+}
@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/replay"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/server"
+)
+
+// serveOptions holds the flags of the serve command
+type serveOptions struct {
+	addr           string
+	grpcAddr       string
+	library        string
+	requestTimeout time.Duration
+	record         string
+}
+
+// serveDocument is printed with --format json once the server listens
+type serveDocument struct {
+	URL            string `json:"url"`
+	GRPCAddr       string `json:"grpc_addr,omitempty"`
+	DefaultLibrary string `json:"default_library"`
+}
+
+func newServeCommand(opts *globalOptions) *cobra.Command {
+	serveOpts := serveOptions{}
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve REST and gRPC APIs for users backed by any of the libraries",
+		Long: `Serve a REST API for users backed by lib/pq, sqlx and GORM at once, to
+load-test the full HTTP to repository path per library.
+
+Each request selects its library with the ` + server.LibraryHeader + ` header or the lib
+query parameter, otherwise --lib applies; the response names the library
+used in the same header. A ` + server.RequestIDHeader + ` header tags the request's
+statements with a sqlcommenter comment.
+
+  POST   /users          create a user, body {"name","email","age"}
+  GET    /users          list active users, ?limit=10&offset=0
+  GET    /users?email=   search active users by email substring
+  GET    /users/{id}     get an active user
+  PATCH  /users/{id}     update name, email, age or is_active
+  DELETE /users/{id}     soft delete a user
+  GET    /healthz        liveness probe
+
+With --grpc-addr the same operations are also served as the
+dbcompare.user.v1.UserService gRPC service (see pkg/userpb/user.proto),
+with server reflection. Calls select their library with the
+` + server.LibraryMetadataKey + ` metadata key and are tagged by ` + server.RequestIDMetadataKey + `.
+
+--record writes every repository call the requests make, with its
+parameters, to a workload file that "dbcompare replay" re-executes on any
+library.
+
+The server runs until interrupted, or for --timeout when set.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(cmd, opts, serveOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&serveOpts.addr, "addr", ":8080", "Address to listen on")
+	flags.StringVar(&serveOpts.grpcAddr, "grpc-addr", "", "Address to serve gRPC on, empty to disable")
+	flags.StringVar(&serveOpts.library, "lib", "pq", "Library for requests that name none: pq, sqlx or gorm")
+	flags.DurationVar(&serveOpts.requestTimeout, "request-timeout", 10*time.Second, "Time limit of each request's repository call, 0 for none")
+	flags.StringVar(&serveOpts.record, "record", "", "Record every repository call to this workload file for dbcompare replay")
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, opts *globalOptions, serveOpts serveOptions) error {
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log := opts.logger
+	config := opts.dbConfig()
+
+	repos, closeRepos, err := openServerRepositories(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeRepos()
+
+	if serveOpts.record != "" {
+		recorder, closeRecorder, err := createRecorder(serveOpts.record, opts.runID, log)
+		if err != nil {
+			return err
+		}
+		defer closeRecorder()
+		recordRepositories(repos, recorder)
+	}
+
+	handler, err := server.New(server.Config{
+		Repositories:   repos,
+		DefaultLibrary: serveOpts.library,
+		Logger:         log,
+		RequestTimeout: serveOpts.requestTimeout,
+	})
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", serveOpts.addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", serveOpts.addr, err)
+	}
+	httpServer := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		// Requests keep the command's values but not its cancellation, so
+		// in-flight ones can finish during shutdown
+		BaseContext: func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+
+	w := opts.textOutput(cmd)
+	doc := serveDocument{URL: "http://" + listener.Addr().String(), DefaultLibrary: serveOpts.library}
+	fmt.Fprintf(w, "🌐 Serving users API on %s (default library %s)\n", doc.URL, serveOpts.library)
+
+	errc := make(chan error, 2)
+	go func() { errc <- httpServer.Serve(listener) }()
+
+	if serveOpts.grpcAddr != "" {
+		grpcListener, err := net.Listen("tcp", serveOpts.grpcAddr)
+		if err != nil {
+			httpServer.Close()
+			return fmt.Errorf("listen on %s: %w", serveOpts.grpcAddr, err)
+		}
+		grpcServer := handler.NewGRPCServer()
+		defer grpcServer.GracefulStop()
+		go func() { errc <- grpcServer.Serve(grpcListener) }()
+
+		doc.GRPCAddr = grpcListener.Addr().String()
+		fmt.Fprintf(w, "🌐 Serving UserService gRPC on %s\n", doc.GRPCAddr)
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Info("shutting down")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("shutdown failed: %w", err)
+	}
+	return nil
+}
+
+// openServerRepositories connects all three libraries and returns their
+// repositories by library name, with the function closing them all
+func openServerRepositories(ctx context.Context, config *database.DatabaseConfig) (map[string]repository.UserRepository, func(), error) {
+	repos := make(map[string]repository.UserRepository)
+	var closers []func()
+	closeAll := func() {
+		for _, closeRepo := range closers {
+			closeRepo()
+		}
+	}
+	for _, library := range []string{"pq", "sqlx", "gorm"} {
+		connectCtx, cancelConnect := context.WithTimeout(ctx, 30*time.Second)
+		_, repo, closeRepo, err := openCRUDRepository(connectCtx, library, config)
+		cancelConnect()
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		closers = append(closers, closeRepo)
+		repos[library] = repo
+	}
+	return repos, closeAll, nil
+}
+
+// startInProcessServer serves the REST API over all three libraries on a
+// loopback port and returns its base URL and the function stopping it.
+// The repository calls are recorded by recorder unless it is nil.
+func startInProcessServer(ctx context.Context, config *database.DatabaseConfig, log *slog.Logger, recorder *replay.Recorder) (string, func(), error) {
+	repos, closeRepos, err := openServerRepositories(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+	if recorder != nil {
+		recordRepositories(repos, recorder)
+	}
+	handler, err := server.New(server.Config{Repositories: repos, DefaultLibrary: "pq", Logger: log})
+	if err != nil {
+		closeRepos()
+		return "", nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		closeRepos()
+		return "", nil, fmt.Errorf("listen for the in-process server: %w", err)
+	}
+	httpServer := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	go httpServer.Serve(listener)
+
+	stop := func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+		closeRepos()
+	}
+	return "http://" + listener.Addr().String(), stop, nil
+}
@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/buildinfo"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// simpleOptions holds the flags of the simple-benchmark command
+type simpleOptions struct {
+	iterations int
+	libraries  []string
+	dryRun     bool
+}
+
+// simpleOperations lists the operations simple-benchmark times, in order
+var simpleOperations = []string{"create", "read", "update"}
+
+// simpleReport is the outcome of simple-benchmark, printed with --format json
+type simpleReport struct {
+	RunID      string                `json:"run_id"`
+	Build      buildinfo.Info        `json:"build"`
+	Iterations int                   `json:"iterations"`
+	Results    []simpleLibraryResult `json:"results"`
+}
+
+// simpleLibraryResult holds the average duration of each operation of one
+// library, keyed by operation
+type simpleLibraryResult struct {
+	Library  string                   `json:"library"`
+	Averages map[string]time.Duration `json:"averages"`
+}
+
+func newSimpleBenchmarkCommand(opts *globalOptions) *cobra.Command {
+	simpleOpts := simpleOptions{}
+	cmd := &cobra.Command{
+		Use:   "simple-benchmark",
+		Short: "Time sequential create, read and update with each library",
+		Long: `Time sequential create, read and update with each library and print the
+average duration of each operation.
+
+With --format json the averages are printed in nanoseconds. --dry-run
+prints the SQL each library would execute for each operation instead,
+without connecting to the database.`,
+		Example: "  dbcompare simple-benchmark --iterations 200 --libraries pq,gorm\n  dbcompare simple-benchmark --format json | jq '.results[].averages.read'",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSimpleBenchmark(cmd, opts, simpleOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&simpleOpts.iterations, "iterations", 50, "Operations per library and operation type")
+	flags.StringSliceVar(&simpleOpts.libraries, "libraries", []string{"pq", "sqlx", "gorm"}, "Libraries to benchmark, comma separated")
+	flags.BoolVar(&simpleOpts.dryRun, "dry-run", false, "Print the SQL of each operation instead of executing it")
+	return cmd
+}
+
+func runSimpleBenchmark(cmd *cobra.Command, opts *globalOptions, simpleOpts simpleOptions) error {
+	if simpleOpts.iterations <= 0 {
+		return fmt.Errorf("--iterations must be positive, got %d", simpleOpts.iterations)
+	}
+	libraries, err := parseSimpleLibraries(simpleOpts.libraries)
+	if err != nil {
+		return err
+	}
+	if simpleOpts.dryRun {
+		return runDryRun(cmd, opts, libraries, simpleOperations)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 2*time.Minute)
+	defer cancel()
+
+	report := simpleReport{RunID: opts.runID, Build: buildinfo.Get(), Iterations: simpleOpts.iterations}
+
+	w := opts.textOutput(cmd)
+	banner(w, "🚀 Go Database Comparison - Simple Performance Test")
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	fmt.Fprintf(w, "Build: %s\n", report.Build)
+
+	report.Results, err = simpleBenchmarkAll(ctx, opts.logger, opts.dbConfig(), libraries, simpleOpts.iterations)
+	if err != nil {
+		return fmt.Errorf("benchmark failed: %w", err)
+	}
+
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, report)
+	}
+	printSimpleComparison(w, report.Results)
+	fmt.Fprintln(w, "✅ Performance benchmark completed successfully!")
+	return nil
+}
+
+// parseSimpleLibraries checks the --libraries values and returns them in
+// lower case without duplicates
+func parseSimpleLibraries(values []string) ([]string, error) {
+	var libraries []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		library := strings.ToLower(strings.TrimSpace(value))
+		switch library {
+		case "pq", "sqlx", "gorm":
+		default:
+			return nil, fmt.Errorf("unknown library %q in --libraries (expected pq, sqlx or gorm)", value)
+		}
+		if !seen[library] {
+			seen[library] = true
+			libraries = append(libraries, library)
+		}
+	}
+	if len(libraries) == 0 {
+		return nil, fmt.Errorf("--libraries is empty")
+	}
+	return libraries, nil
+}
+
+func simpleBenchmarkAll(ctx context.Context, log *slog.Logger, config *database.DatabaseConfig, libraries []string, iterations int) ([]simpleLibraryResult, error) {
+	results := make([]simpleLibraryResult, 0, len(libraries))
+	for _, lib := range libraries {
+		log.Info("benchmarking library", "library", lib)
+
+		result, err := simpleBenchmarkLibrary(ctx, lib, config, iterations)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark failed for %s: %w", lib, err)
+		}
+		results = append(results, result)
+
+		for _, operation := range simpleOperations {
+			log.Debug("operation timed", "library", result.Library, "operation", operation, "avg", result.Averages[operation])
+		}
+	}
+	return results, nil
+}
+
+// printSimpleComparison prints results as a table, one row per library
+func printSimpleComparison(w io.Writer, results []simpleLibraryResult) {
+	fmt.Fprintln(w, "\n🏆 Performance Comparison:")
+	fmt.Fprintln(w, "================================")
+	fmt.Fprintf(w, "%-10s | %-12s | %-12s | %-12s\n", "Library", "Create", "Read", "Update")
+	fmt.Fprintln(w, "-----------|--------------|--------------|-------------")
+
+	for _, result := range results {
+		fmt.Fprintf(w, "%-10s | %-12v | %-12v | %-12v\n",
+			result.Library,
+			result.Averages["create"],
+			result.Averages["read"],
+			result.Averages["update"])
+	}
+}
+
+func simpleBenchmarkLibrary(ctx context.Context, library string, config *database.DatabaseConfig, iterations int) (simpleLibraryResult, error) {
+	name, repo, closeRepo, err := openCRUDRepository(ctx, library, config)
+	if err != nil {
+		return simpleLibraryResult{}, err
+	}
+	defer closeRepo()
+
+	result := simpleLibraryResult{Library: name, Averages: make(map[string]time.Duration)}
+
+	// Benchmark create operation
+	createDuration, err := simpleBenchmarkCreate(ctx, name, repo, iterations)
+	if err != nil {
+		return simpleLibraryResult{}, fmt.Errorf("create benchmark failed: %w", err)
+	}
+	result.Averages["create"] = createDuration
+
+	// Benchmark read operation
+	readDuration, err := simpleBenchmarkRead(ctx, name, repo, iterations)
+	if err != nil {
+		return simpleLibraryResult{}, fmt.Errorf("read benchmark failed: %w", err)
+	}
+	result.Averages["read"] = readDuration
+
+	// Benchmark update operation
+	updateDuration, err := simpleBenchmarkUpdate(ctx, name, repo, iterations)
+	if err != nil {
+		return simpleLibraryResult{}, fmt.Errorf("update benchmark failed: %w", err)
+	}
+	result.Averages["update"] = updateDuration
+
+	return result, nil
+}
+
+func simpleBenchmarkCreate(ctx context.Context, library string, repo repository.UserRepository, iterations int) (time.Duration, error) {
+	start := time.Now()
+
+	for i := 0; i < iterations; i++ {
+		timestamp := time.Now().UnixNano() + int64(i)
+		req := &models.CreateUserRequest{
+			Name:  fmt.Sprintf("Bench %s %d", library, timestamp),
+			Email: benchdata.Email(benchdata.RunID(ctx), "bench", library, timestamp),
+			Age:   25 + (i % 50),
+		}
+
+		if _, err := repo.CreateUser(ctx, req); err != nil {
+			return 0, err
+		}
+	}
+
+	return time.Since(start) / time.Duration(iterations), nil
+}
+
+func simpleBenchmarkRead(ctx context.Context, library string, repo repository.UserRepository, iterations int) (time.Duration, error) {
+	// First create some test data
+	var testUserIDs []int
+	for i := 0; i < 10; i++ {
+		timestamp := time.Now().UnixNano() + int64(i)
+		req := &models.CreateUserRequest{
+			Name:  fmt.Sprintf("ReadTest %s %d", library, timestamp),
+			Email: benchdata.Email(benchdata.RunID(ctx), "readtest", library, timestamp),
+			Age:   25,
+		}
+
+		if user, err := repo.CreateUser(ctx, req); err == nil {
+			testUserIDs = append(testUserIDs, user.ID)
+		}
+	}
+
+	if len(testUserIDs) == 0 {
+		return 0, fmt.Errorf("no test users created")
+	}
+
+	// Benchmark read operations
+	start := time.Now()
+
+	for i := 0; i < iterations; i++ {
+		userID := testUserIDs[i%len(testUserIDs)]
+		if _, err := repo.GetUserByID(ctx, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	duration := time.Since(start) / time.Duration(iterations)
+
+	// Cleanup test users
+	for _, userID := range testUserIDs {
+		repo.DeleteUser(ctx, userID)
+	}
+
+	return duration, nil
+}
+
+func simpleBenchmarkUpdate(ctx context.Context, library string, repo repository.UserRepository, iterations int) (time.Duration, error) {
+	// Create test user
+	timestamp := time.Now().UnixNano()
+	req := &models.CreateUserRequest{
+		Name:  fmt.Sprintf("UpdateTest %s %d", library, timestamp),
+		Email: benchdata.Email(benchdata.RunID(ctx), "updatetest", library, timestamp),
+		Age:   25,
+	}
+
+	user, err := repo.CreateUser(ctx, req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create test user: %w", err)
+	}
+
+	// Benchmark update operations
+	start := time.Now()
+
+	for i := 0; i < iterations; i++ {
+		newName := fmt.Sprintf("Updated %s %d", library, i)
+		updateReq := &models.UpdateUserRequest{
+			Name: &newName,
+		}
+
+		if _, err := repo.UpdateUser(ctx, user.ID, updateReq); err != nil {
+			return 0, err
+		}
+	}
+
+	duration := time.Since(start) / time.Duration(iterations)
+
+	// Cleanup
+	repo.DeleteUser(ctx, user.ID)
+
+	return duration, nil
+}
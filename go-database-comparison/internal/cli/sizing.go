@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"go-database-comparison/pkg/benchmark"
+)
+
+// sizingOptions holds the flags sizing the benchmark of the commands that
+// run it unattended, where a preset always applies
+type sizingOptions struct {
+	preset      string
+	iterations  int
+	concurrency int
+	warmup      int
+	operations  []string
+	dataSize    int
+	sweep       []int
+}
+
+// addFlags registers the sizing flags with the values of defaults, which
+// the preset fills in anyway unless they are set
+func (s *sizingOptions) addFlags(flags *pflag.FlagSet, defaults benchmark.Preset) {
+	flags.StringVar(&s.preset, "preset", defaults.Name, "Size each run with a preset: "+strings.Join(benchmark.PresetNames(), ", "))
+	flags.IntVar(&s.iterations, "iterations", defaults.Iterations, "Operations per library and operation type")
+	flags.IntVar(&s.concurrency, "concurrency", defaults.Concurrency, "Concurrent workers per operation")
+	flags.IntVar(&s.warmup, "warmup", defaults.WarmupRounds, "Warmup rounds per library before measuring")
+	flags.StringSliceVar(&s.operations, "operations", defaults.OperationTypes, "Operations to benchmark, comma separated")
+	flags.IntVar(&s.dataSize, "data-size", defaults.DataSize, "Rows of test data to work with")
+	flags.IntSliceVar(&s.sweep, "sweep-concurrency", nil, "Run every operation at each of these concurrencies, comma separated")
+}
+
+// resolve fills in the flags not set from the preset, see applyPreset, and
+// returns the preset
+func (s *sizingOptions) resolve(flags *pflag.FlagSet) (benchmark.Preset, error) {
+	preset, err := benchmark.LookupPreset(s.preset)
+	if err != nil {
+		return benchmark.Preset{}, err
+	}
+	if err := applyPreset(flags, preset); err != nil {
+		return benchmark.Preset{}, err
+	}
+	for _, level := range s.sweep {
+		if level <= 0 {
+			return benchmark.Preset{}, fmt.Errorf("--sweep-concurrency levels must be positive, got %d", level)
+		}
+	}
+	return preset, nil
+}
+
+// apply sets the sizing of config
+func (s *sizingOptions) apply(config *benchmark.BenchmarkConfig) {
+	config.Iterations = s.iterations
+	config.Concurrency = s.concurrency
+	config.ConcurrencySweep = s.sweep
+	config.WarmupRounds = s.warmup
+	config.OperationTypes = s.operations
+	config.DataSize = s.dataSize
+}
@@ -0,0 +1,475 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// sqlxUserColumns are the columns of users models.User has a field for, in
+// the order the repositories select them
+var sqlxUserColumns = []string{"id", "name", "email", "age", "created_at", "updated_at", "is_active"}
+
+// sqlxMappingOptions holds the flags of the sqlx-mapping command
+type sqlxMappingOptions struct {
+	users      int
+	iterations int
+	keepData   bool
+}
+
+// sqlxMappingVariant is one mapper of database.SQLXMappers, in unsafe mode
+// or not
+type sqlxMappingVariant struct {
+	mapper string
+	unsafe bool
+}
+
+// String returns the mapper name, with "+unsafe" in unsafe mode
+func (v sqlxMappingVariant) String() string {
+	if v.unsafe {
+		return v.mapper + "+unsafe"
+	}
+	return v.mapper
+}
+
+// mapsColumn reports whether the mapper of v maps column to a field of
+// models.User
+func (v sqlxMappingVariant) mapsColumn(column string) bool {
+	if !slices.Contains(sqlxUserColumns, column) {
+		return false
+	}
+	// lower maps CreatedAt to createdat, missing every column of two words
+	return v.mapper != "lower" || !strings.Contains(column, "_")
+}
+
+// expected returns what reading columns into models.User should do with
+// v: fail on the first column no field maps, unless unsafe mode ignores
+// it, and leave the fields no selected column maps zero
+func (v sqlxMappingVariant) expected(columns []string) string {
+	for _, column := range columns {
+		if !v.mapsColumn(column) && !v.unsafe {
+			return "missing destination name " + column
+		}
+	}
+	var zero []string
+	for _, column := range sqlxUserColumns {
+		if !slices.Contains(columns, column) || !v.mapsColumn(column) {
+			zero = append(zero, column)
+		}
+	}
+	if len(zero) == 0 {
+		return "mapped"
+	}
+	return "mapped, " + strings.Join(zero, ", ") + " left zero"
+}
+
+// sqlxMappingVariants returns every mapper without and with unsafe mode
+func sqlxMappingVariants() []sqlxMappingVariant {
+	var variants []sqlxMappingVariant
+	for _, mapper := range database.SQLXMappers {
+		variants = append(variants, sqlxMappingVariant{mapper, false}, sqlxMappingVariant{mapper, true})
+	}
+	return variants
+}
+
+// sqlxMappingCheck is the outcome of one check on one variant
+type sqlxMappingCheck struct {
+	Variant  string `json:"variant"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check observed what it expected
+func (c sqlxMappingCheck) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// sqlxMappingTiming holds the medians of selecting the users of the run
+// with one variant, or with PQ scanning them by hand as the baseline
+type sqlxMappingTiming struct {
+	Variant    string        `json:"variant"`
+	Rows       int           `json:"rows"`
+	Iterations int           `json:"iterations"`
+	Select     time.Duration `json:"select_median"`
+	PerRow     time.Duration `json:"per_row_overhead"`          // (Select - the baseline's) / Rows
+	TypeMap    time.Duration `json:"type_map_median,omitempty"` // Building the field map of models.User with a fresh mapper
+}
+
+// sqlxMappingDocument is printed with --format json
+type sqlxMappingDocument struct {
+	RunID   string              `json:"run_id"`
+	Users   int                 `json:"users"`
+	Checks  []sqlxMappingCheck  `json:"checks"`
+	Timings []sqlxMappingTiming `json:"timings,omitempty"`
+	Failed  int                 `json:"failed"`
+}
+
+func newSQLXMappingCommand(opts *globalOptions) *cobra.Command {
+	sqlxOpts := sqlxMappingOptions{}
+	cmd := &cobra.Command{
+		Use:   "sqlx-mapping",
+		Short: "Check how sqlx maps columns with each mapper and unsafe mode, and benchmark the mapping",
+		Long: `Check what sqlx does when the columns of a result and the fields of
+models.User do not match, for every mapper --sqlx-mapper accepts, each with
+and without --sqlx-unsafe. The command sets both itself, per variant:
+  db     the db tag, the sqlx default and what the repository is written for,
+  json   the json tag, which names the same columns for models.User,
+  snake  the field name in snake_case, tags ignored,
+  lower  the lower-cased field name, tags ignored, which cannot map
+         created_at, updated_at or is_active.
+
+The checks read the first user of the run per variant:
+  extra column    with a column no field maps, which fails with "missing
+                  destination name" unless unsafe mode ignores it,
+  missing column  with only id, name and email, which leaves the other
+                  fields zero without an error in either mode,
+  repository      through SQLXRepository.GetUserByID, which the lower
+                  mapper fails, or in unsafe mode silently reads with
+                  fields left zero.
+A failed check makes the command exit with code 2.
+
+The benchmark then selects the --users users of the run --iterations times
+with every variant mapping all columns, alternating them call by call, and
+as the baseline with PQ scanning the rows by hand. The difference per row is
+what mapping by reflection costs. The mapper only matters when it first
+builds the field map of a type, which is timed on its own. The users are
+removed afterwards unless --keep-data.`,
+		Example: "  dbcompare sqlx-mapping\n  dbcompare sqlx-mapping --users 5000 --iterations 20",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSQLXMapping(cmd, opts, sqlxOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&sqlxOpts.users, "users", 1000, "Users to create and select in the benchmark")
+	flags.IntVar(&sqlxOpts.iterations, "iterations", 50, "Selects per variant in the benchmark, 0 skips it")
+	flags.BoolVar(&sqlxOpts.keepData, "keep-data", false, "Keep the users the command created")
+	return cmd
+}
+
+func runSQLXMapping(cmd *cobra.Command, opts *globalOptions, sqlxOpts sqlxMappingOptions) error {
+	if sqlxOpts.users < 1 {
+		return fmt.Errorf("--users must be at least 1, got %d", sqlxOpts.users)
+	}
+	if sqlxOpts.iterations < 0 {
+		return fmt.Errorf("--iterations must not be negative, got %d", sqlxOpts.iterations)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🗺️ Go Database Comparison - SQLX Mapping")
+	observer, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer observer.Close()
+
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	if !sqlxOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	pattern, err := createMappingUsers(ctx, observer, sqlxOpts.users)
+	if err != nil {
+		return err
+	}
+	users, err := scanUsers(ctx, observer, pattern)
+	if err != nil {
+		return fmt.Errorf("failed to read the users back: %w", err)
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("none of the %d users created is found by %q", sqlxOpts.users, pattern)
+	}
+
+	variants := sqlxMappingVariants()
+	dbs := make([]*sqlx.DB, len(variants))
+	for i, variant := range variants {
+		variantConfig := *config
+		variantConfig.SQLXMapper = variant.mapper
+		variantConfig.SQLXUnsafe = variant.unsafe
+		db, err := database.ConnectWithSQLX(ctx, &variantConfig)
+		if err != nil {
+			return fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		defer db.Close()
+		dbs[i] = db
+	}
+
+	doc := sqlxMappingDocument{RunID: opts.runID, Users: len(users)}
+	for i, variant := range variants {
+		log.Info("checking the mapping", "variant", variant.String())
+		doc.Checks = append(doc.Checks, checkSQLXMapping(ctx, dbs[i], variant, users[0])...)
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	if sqlxOpts.iterations > 0 {
+		log.Info("benchmarking the mapping", "rows", len(users), "iterations", sqlxOpts.iterations)
+		timings, err := benchmarkSQLXMapping(ctx, observer, dbs, variants, pattern, sqlxOpts.iterations)
+		if err != nil {
+			return err
+		}
+		doc.Timings = timings
+	}
+
+	fmt.Fprintln(w)
+	printSQLXMappingChecks(w, doc.Checks)
+	if len(doc.Timings) > 0 {
+		fmt.Fprintln(w)
+		printSQLXMappingTimings(w, doc.Timings)
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d sqlx mapping checks failed", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d sqlx mapping checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// createMappingUsers creates n active users of the run in one statement
+// and returns the LIKE pattern of their emails
+func createMappingUsers(ctx context.Context, db *sql.DB, n int) (string, error) {
+	runID := benchdata.RunID(ctx)
+	names := make([]string, n)
+	emails := make([]string, n)
+	ages := make([]int64, n)
+	for i := range n {
+		names[i] = fmt.Sprintf("Mapping %d", i)
+		emails[i] = benchdata.Email(runID, "mapping", "fixture", int64(i))
+		ages[i] = int64(18 + i%60)
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO users (name, email, age, is_active)
+		SELECT *, true FROM unnest($1::text[], $2::text[], $3::int[])`,
+		pq.Array(names), pq.Array(emails), pq.Array(ages))
+	if err != nil {
+		return "", fmt.Errorf("failed to create the users: %w", err)
+	}
+	_, domain, _ := strings.Cut(emails[0], "@")
+	return "mapping-fixture-%@" + domain, nil
+}
+
+// scanUsers selects the users whose email matches pattern, ordered by ID,
+// scanning them by hand
+func scanUsers(ctx context.Context, db *sql.DB, pattern string) ([]*models.User, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+strings.Join(sqlxUserColumns, ", ")+" FROM users WHERE email LIKE $1 ORDER BY id", pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt, &user.IsActive); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// checkSQLXMapping reads want with variant, connected as db, in the ways
+// the checks describe
+func checkSQLXMapping(ctx context.Context, db *sqlx.DB, variant sqlxMappingVariant, want *models.User) []sqlxMappingCheck {
+	get := func(selectList string) func() (*models.User, error) {
+		return func() (*models.User, error) {
+			user := &models.User{}
+			return user, db.GetContext(ctx, user, "SELECT "+selectList+" FROM users WHERE id = $1", want.ID)
+		}
+	}
+	repo := repository.NewSQLXRepository(db)
+	reads := []struct {
+		check   string
+		columns []string
+		read    func() (*models.User, error)
+	}{
+		{"extra column", append(slices.Clone(sqlxUserColumns), "name_length"), get(strings.Join(sqlxUserColumns, ", ") + ", length(name) AS name_length")},
+		{"missing column", []string{"id", "name", "email"}, get("id, name, email")},
+		{"repository", sqlxUserColumns, func() (*models.User, error) { return repo.GetUserByID(ctx, want.ID) }},
+	}
+
+	checks := make([]sqlxMappingCheck, len(reads))
+	for i, r := range reads {
+		check := sqlxMappingCheck{Variant: variant.String(), Check: r.check, Expected: variant.expected(r.columns)}
+		user, err := r.read()
+		observed, err := observedMapping(user, want, err)
+		check.Observed = observed
+		if err != nil {
+			check.Error = err.Error()
+		}
+		checks[i] = check
+	}
+	return checks
+}
+
+// observedMapping describes how got, read with err, compares with want in
+// the terms of sqlxMappingVariant.expected. Errors other than a column no
+// field maps are returned.
+func observedMapping(got, want *models.User, err error) (string, error) {
+	if err != nil {
+		if _, column, ok := strings.Cut(err.Error(), "missing destination name "); ok {
+			column, _, _ = strings.Cut(column, " ")
+			return "missing destination name " + column, nil
+		}
+		return "", err
+	}
+
+	columns := []struct {
+		name        string
+		equal, zero bool
+	}{
+		{"id", got.ID == want.ID, got.ID == 0},
+		{"name", got.Name == want.Name, got.Name == ""},
+		{"email", got.Email == want.Email, got.Email == ""},
+		{"age", got.Age == want.Age, got.Age == 0},
+		{"created_at", got.CreatedAt.Equal(want.CreatedAt), got.CreatedAt.IsZero()},
+		{"updated_at", got.UpdatedAt.Equal(want.UpdatedAt), got.UpdatedAt.IsZero()},
+		{"is_active", got.IsActive == want.IsActive, !got.IsActive},
+	}
+	var zero []string
+	for _, column := range columns {
+		switch {
+		case column.equal:
+		case column.zero:
+			zero = append(zero, column.name)
+		default:
+			return fmt.Sprintf("mapped, %s differs", column.name), nil
+		}
+	}
+	if len(zero) == 0 {
+		return "mapped", nil
+	}
+	return "mapped, " + strings.Join(zero, ", ") + " left zero", nil
+}
+
+// benchmarkSQLXMapping selects the users matching pattern iterations times
+// with each of variants mapping every column, connected as dbs, and with
+// observer scanning them by hand, alternating them call by call. It then
+// times building the field map of models.User with each mapper.
+func benchmarkSQLXMapping(ctx context.Context, observer *sql.DB, dbs []*sqlx.DB, variants []sqlxMappingVariant, pattern string, iterations int) ([]sqlxMappingTiming, error) {
+	query := "SELECT " + strings.Join(sqlxUserColumns, ", ") + " FROM users WHERE email LIKE $1 ORDER BY id"
+	var mapping []int
+	for i, variant := range variants {
+		if variant.expected(sqlxUserColumns) == "mapped" {
+			mapping = append(mapping, i)
+		}
+	}
+
+	rows := 0
+	scans := make([]time.Duration, 0, iterations)
+	selects := make([][]time.Duration, len(variants))
+	for range iterations {
+		start := time.Now()
+		users, err := scanUsers(ctx, observer, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("PQ scan failed: %w", err)
+		}
+		scans = append(scans, time.Since(start))
+		rows = len(users)
+
+		for _, i := range mapping {
+			var selected []models.User
+			start := time.Now()
+			if err := dbs[i].SelectContext(ctx, &selected, query, pattern); err != nil {
+				return nil, fmt.Errorf("SQLX %s select failed: %w", variants[i], err)
+			}
+			selects[i] = append(selects[i], time.Since(start))
+		}
+	}
+
+	baseline := sqlxMappingTiming{Variant: "PQ Scan", Rows: rows, Iterations: iterations, Select: medianDuration(scans)}
+	timings := []sqlxMappingTiming{baseline}
+	userType := reflect.TypeOf(models.User{})
+	for _, i := range mapping {
+		builds := make([]time.Duration, 0, iterations)
+		for range iterations {
+			// A fresh mapper, the map of a type is cached once built
+			mapper, err := database.SQLXMapper(variants[i].mapper)
+			if err != nil {
+				return nil, err
+			}
+			start := time.Now()
+			mapper.TypeMap(userType)
+			builds = append(builds, time.Since(start))
+		}
+
+		timing := sqlxMappingTiming{
+			Variant:    variants[i].String(),
+			Rows:       rows,
+			Iterations: iterations,
+			Select:     medianDuration(selects[i]),
+			TypeMap:    medianDuration(builds),
+		}
+		if rows > 0 {
+			timing.PerRow = (timing.Select - baseline.Select) / time.Duration(rows)
+		}
+		timings = append(timings, timing)
+	}
+	return timings, nil
+}
+
+// printSQLXMappingChecks prints one line per check
+func printSQLXMappingChecks(w io.Writer, checks []sqlxMappingCheck) {
+	fmt.Fprintf(w, "%-13s | %-14s | %s\n", "Variant", "Check", "Result")
+	fmt.Fprintln(w, "--------------|----------------|------------------------------")
+	for _, c := range checks {
+		result := "✅ " + c.Observed
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-13s | %-14s | %s\n", c.Variant, c.Check, result)
+	}
+}
+
+// printSQLXMappingTimings prints the medians against the PQ baseline
+func printSQLXMappingTimings(w io.Writer, timings []sqlxMappingTiming) {
+	baseline := timings[0]
+	fmt.Fprintf(w, "Selecting %d users, medians of %d:\n", baseline.Rows, baseline.Iterations)
+	fmt.Fprintf(w, "%-13s | %-10s | %-8s | %-10s | %s\n", "Variant", "Select", "vs Scan", "Per row", "Type map")
+	fmt.Fprintln(w, "--------------|------------|----------|------------|----------")
+	for _, t := range timings {
+		typeMap := "-"
+		if t.TypeMap > 0 {
+			typeMap = t.TypeMap.Round(100 * time.Nanosecond).String()
+		}
+		fmt.Fprintf(w, "%-13s | %-10v | %-8s | %-10v | %s\n", t.Variant,
+			t.Select.Round(time.Microsecond), overhead(t.Select, baseline.Select), t.PerRow, typeMap)
+	}
+}
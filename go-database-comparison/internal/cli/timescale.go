@@ -0,0 +1,428 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/timeseries"
+)
+
+// activityActions are the actions the generated activity cycles through
+var activityActions = []string{"login", "view", "search", "logout"}
+
+// timescaleOptions holds the flags of the timescale command
+type timescaleOptions struct {
+	libraries []string
+	layouts   []string
+	users     int
+	events    int
+	batch     int
+	span      time.Duration
+	chunk     time.Duration
+	window    time.Duration
+	queries   int
+	keepData  bool
+}
+
+// activityRepository is a UserRepository appending to and reading the
+// user_activity log
+type activityRepository interface {
+	repository.UserRepository
+	CreateUserActivities(ctx context.Context, activities []*models.UserActivity) error
+	GetUserActivityInRange(ctx context.Context, userID int, from, to time.Time) ([]*models.UserActivity, error)
+}
+
+// timescaleLayout is the table of one layout after its benchmark
+type timescaleLayout struct {
+	Layout string `json:"layout"`
+	timeseries.Stats
+}
+
+// timescaleTiming holds the medians of one library on one layout
+type timescaleTiming struct {
+	Layout        string        `json:"layout"`
+	Library       string        `json:"library"`
+	Batches       int           `json:"batches"`
+	Insert        time.Duration `json:"insert_median"` // Per batch
+	RowsPerSecond float64       `json:"rows_per_second"`
+	Queries       int           `json:"queries"`
+	Range         time.Duration `json:"range_median"`
+	RangeRows     int           `json:"range_rows"` // Read by all queries together
+}
+
+// timescaleDocument is printed with --format json
+type timescaleDocument struct {
+	RunID     string            `json:"run_id"`
+	Schema    string            `json:"schema"`
+	Timescale string            `json:"timescale,omitempty"` // Version of the extension, with the hypertable layout
+	Users     int               `json:"users"`
+	Events    int               `json:"events"` // Per library and layout
+	BatchSize int               `json:"batch_size"`
+	Layouts   []timescaleLayout `json:"layouts"`
+	Timings   []timescaleTiming `json:"timings"`
+}
+
+func newTimescaleCommand(opts *globalOptions) *cobra.Command {
+	timescaleOpts := timescaleOptions{}
+	cmd := &cobra.Command{
+		Use:   "timescale",
+		Short: "Benchmark appending to and range reading a user activity log, as a plain table and a TimescaleDB hypertable",
+		Long: `Benchmark each library on a time series instead of the OLTP users table:
+user_activity, a log of what users did, appended in batches in time order
+and read back by user and time range. The table is created in the schema of
+the run, which the libraries reach through their search path, once per
+--layouts:
+  plain       an ordinary table indexed on occurred_at and on user_id and
+              occurred_at,
+  hypertable  a TimescaleDB hypertable chunked by occurred_at every
+              --chunk, with the same indexes on every chunk.
+The hypertable layout needs a server with TimescaleDB preloaded, such as the
+timescaledb service of docker-compose.yml (docker compose --profile
+timescale up, port 5433); the command creates the extension if needed.
+
+--users users are created, then every library appends --events activities
+spread over the --span up to now in batches of --batch, one multi-row
+INSERT each, alternating the libraries batch by batch; they write the same
+activities. Then every library reads --queries ranges of --window of one
+user's activity, the same ranges in the same order, alternating call by
+call. The libraries have to read the same number of rows, which makes the
+command exit with code 2 if not.
+
+The table and the users are removed afterwards unless --keep-data, in which
+case dbcompare cleanup --run-id removes them.`,
+		Example: "  dbcompare timescale --port 5433\n  dbcompare timescale --layouts plain --events 200000",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTimescale(cmd, opts, timescaleOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&timescaleOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to benchmark, comma separated")
+	flags.StringSliceVar(&timescaleOpts.layouts, "layouts", timeseries.Layouts, "Layouts of the activity table to benchmark: plain, hypertable")
+	flags.IntVar(&timescaleOpts.users, "users", 100, "Users the activity belongs to")
+	flags.IntVar(&timescaleOpts.events, "events", 50000, "Activities each library appends per layout")
+	flags.IntVar(&timescaleOpts.batch, "batch", 500, "Activities per INSERT")
+	flags.DurationVar(&timescaleOpts.span, "span", 7*24*time.Hour, "Time the activities are spread over, ending now")
+	flags.DurationVar(&timescaleOpts.chunk, "chunk", 24*time.Hour, "Chunk interval of the hypertable")
+	flags.DurationVar(&timescaleOpts.window, "window", 24*time.Hour, "Time range each read covers")
+	flags.IntVar(&timescaleOpts.queries, "queries", 200, "Range reads per library and layout")
+	flags.BoolVar(&timescaleOpts.keepData, "keep-data", false, "Keep the users and the activity table")
+	return cmd
+}
+
+func runTimescale(cmd *cobra.Command, opts *globalOptions, timescaleOpts timescaleOptions) error {
+	if err := validateTimescaleOptions(timescaleOpts); err != nil {
+		return err
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "⏱️ Go Database Comparison - TimescaleDB")
+	observer, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer observer.Close()
+
+	doc := timescaleDocument{
+		RunID:     opts.runID,
+		Schema:    benchdata.Schema(opts.runID),
+		Events:    timescaleOpts.events,
+		BatchSize: timescaleOpts.batch,
+	}
+	if slices.Contains(timescaleOpts.layouts, timeseries.Hypertable) {
+		doc.Timescale, err = timeseries.EnableTimescale(ctx, observer)
+		if errors.Is(err, timeseries.ErrUnavailable) {
+			return fmt.Errorf("%w: connect to a TimescaleDB server, e.g. docker compose --profile timescale up and --port 5433, or pass --layouts plain", err)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "TimescaleDB: %s\n", doc.Timescale)
+	}
+
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	if !timescaleOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users and the activity table of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	userIDs, err := createActivityUsers(ctx, observer, timescaleOpts.users)
+	if err != nil {
+		return err
+	}
+	doc.Users = len(userIDs)
+	now := time.Now()
+	activities := generateActivities(userIDs, timescaleOpts.events, now.Add(-timescaleOpts.span), timescaleOpts.span)
+	ranges := activityRanges(userIDs, timescaleOpts.queries, now.Add(-timescaleOpts.span), timescaleOpts.span, timescaleOpts.window)
+
+	libraryConfig := *config
+	libraryConfig.SearchPath = timeseries.SearchPath(doc.Schema)
+	mismatched := 0
+	for _, layout := range timescaleOpts.layouts {
+		log.Info("creating the activity table", "layout", layout, "schema", doc.Schema)
+		if err := timeseries.Create(ctx, observer, doc.Schema, layout, timescaleOpts.chunk); err != nil {
+			return err
+		}
+
+		timings, err := benchmarkActivity(ctx, log, timescaleOpts.libraries, &libraryConfig, activities, timescaleOpts.batch, ranges)
+		if err != nil {
+			return fmt.Errorf("%s benchmark failed: %w", layout, err)
+		}
+		for i := range timings {
+			timings[i].Layout = layout
+			if timings[i].RangeRows != timings[0].RangeRows {
+				mismatched++
+			}
+		}
+		doc.Timings = append(doc.Timings, timings...)
+
+		stats, err := timeseries.Inspect(ctx, observer, doc.Schema, layout)
+		if err != nil {
+			return err
+		}
+		doc.Layouts = append(doc.Layouts, timescaleLayout{Layout: layout, Stats: stats})
+	}
+
+	fmt.Fprintln(w)
+	printTimescale(w, doc)
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if mismatched > 0 {
+		return verificationFailed(fmt.Errorf("%d libraries read other rows than %s", mismatched, doc.Timings[0].Library))
+	}
+	return nil
+}
+
+// validateTimescaleOptions rejects flag values the benchmark cannot run with
+func validateTimescaleOptions(o timescaleOptions) error {
+	for _, layout := range o.layouts {
+		if !slices.Contains(timeseries.Layouts, layout) {
+			return fmt.Errorf("unknown layout %q (expected plain or hypertable)", layout)
+		}
+	}
+	switch {
+	case len(o.libraries) == 0 || len(o.layouts) == 0:
+		return fmt.Errorf("--lib and --layouts must not be empty")
+	case o.users < 1 || o.events < 1 || o.batch < 1 || o.queries < 1:
+		return fmt.Errorf("--users, --events, --batch and --queries must be at least 1")
+	case o.batch*4 > 65535:
+		return fmt.Errorf("--batch must be at most %d, PostgreSQL binds at most 65535 parameters", 65535/4)
+	case o.span <= 0 || o.chunk <= 0 || o.window <= 0:
+		return fmt.Errorf("--span, --chunk and --window must be positive")
+	}
+	return nil
+}
+
+// createActivityUsers creates n users of the run in one statement and
+// returns their IDs
+func createActivityUsers(ctx context.Context, db *sql.DB, n int) ([]int, error) {
+	runID := benchdata.RunID(ctx)
+	names := make([]string, n)
+	emails := make([]string, n)
+	ages := make([]int64, n)
+	for i := range n {
+		names[i] = fmt.Sprintf("Activity %d", i)
+		emails[i] = benchdata.Email(runID, "activity", "fixture", int64(i))
+		ages[i] = int64(18 + i%60)
+	}
+	rows, err := db.QueryContext(ctx, `
+		INSERT INTO users (name, email, age)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::int[])
+		RETURNING id`,
+		pq.Array(names), pq.Array(emails), pq.Array(ages))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to create the users: %w", err)
+	}
+	return ids, nil
+}
+
+// generateActivities returns n activities of userIDs spread evenly over
+// span from start, in time order as a log is appended
+func generateActivities(userIDs []int, n int, start time.Time, span time.Duration) []*models.UserActivity {
+	activities := make([]*models.UserActivity, n)
+	step := span / time.Duration(n)
+	for i := range activities {
+		activities[i] = &models.UserActivity{
+			OccurredAt: start.Add(step * time.Duration(i)).Truncate(time.Microsecond),
+			UserID:     userIDs[i%len(userIDs)],
+			Action:     activityActions[i%len(activityActions)],
+			DurationMs: i * 37 % 1000,
+		}
+	}
+	return activities
+}
+
+// activityRange is one read of GetUserActivityInRange
+type activityRange struct {
+	userID   int
+	from, to time.Time
+}
+
+// activityRanges returns n reads of window spread over span from start,
+// picking the users and the starts in a fixed order
+func activityRanges(userIDs []int, n int, start time.Time, span, window time.Duration) []activityRange {
+	ranges := make([]activityRange, n)
+	for i := range ranges {
+		from := start.Add(span / 100 * time.Duration(i*37%100))
+		ranges[i] = activityRange{userID: userIDs[i*7%len(userIDs)], from: from, to: from.Add(window)}
+	}
+	return ranges
+}
+
+// benchmarkActivity appends activities in batches of batch with each of
+// libraries, connected with config, alternating them batch by batch, then
+// reads ranges alternating them call by call
+func benchmarkActivity(ctx context.Context, log *slog.Logger, libraries []string, config *database.DatabaseConfig, activities []*models.UserActivity, batch int, ranges []activityRange) ([]timescaleTiming, error) {
+	names := make([]string, len(libraries))
+	repos := make([]activityRepository, len(libraries))
+	for i, library := range libraries {
+		name, repo, db, err := openActivityRepository(ctx, library, config)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		names[i], repos[i] = name, repo
+	}
+
+	log.Info("appending activities", "events", len(activities), "batch", batch)
+	inserts := make([][]time.Duration, len(repos))
+	for start := 0; start < len(activities); start += batch {
+		chunk := activities[start:min(start+batch, len(activities))]
+		for i, repo := range repos {
+			began := time.Now()
+			if err := repo.CreateUserActivities(ctx, chunk); err != nil {
+				return nil, fmt.Errorf("%s append failed: %w", names[i], err)
+			}
+			inserts[i] = append(inserts[i], time.Since(began))
+		}
+	}
+
+	log.Info("reading activity ranges", "queries", len(ranges))
+	reads := make([][]time.Duration, len(repos))
+	rows := make([]int, len(repos))
+	for _, r := range ranges {
+		for i, repo := range repos {
+			began := time.Now()
+			read, err := repo.GetUserActivityInRange(ctx, r.userID, r.from, r.to)
+			if err != nil {
+				return nil, fmt.Errorf("%s range read failed: %w", names[i], err)
+			}
+			reads[i] = append(reads[i], time.Since(began))
+			rows[i] += len(read)
+		}
+	}
+
+	timings := make([]timescaleTiming, len(repos))
+	for i := range repos {
+		var total time.Duration
+		for _, d := range inserts[i] {
+			total += d
+		}
+		timings[i] = timescaleTiming{
+			Library:   names[i],
+			Batches:   len(inserts[i]),
+			Insert:    medianDuration(inserts[i]),
+			Queries:   len(ranges),
+			Range:     medianDuration(reads[i]),
+			RangeRows: rows[i],
+		}
+		if total > 0 {
+			timings[i].RowsPerSecond = float64(len(activities)) / total.Seconds()
+		}
+	}
+	return timings, nil
+}
+
+// openActivityRepository connects library and returns its name, its
+// repository and the *sql.DB beneath it
+func openActivityRepository(ctx context.Context, library string, config *database.DatabaseConfig) (string, activityRepository, *sql.DB, error) {
+	name, repo, db, err := openRepositoryDB(ctx, library, config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	activity, ok := repo.(activityRepository)
+	if !ok {
+		db.Close()
+		return "", nil, nil, fmt.Errorf("%s repository has no user activity log", name)
+	}
+	return name, activity, db, nil
+}
+
+// printTimescale prints the medians per layout and library, then the
+// tables and what the hypertable changes against the plain table
+func printTimescale(w io.Writer, doc timescaleDocument) {
+	fmt.Fprintf(w, "%d activities per library in batches of %d, medians:\n", doc.Events, doc.BatchSize)
+	fmt.Fprintf(w, "%-10s | %-6s | %-10s | %-9s | %-10s | %s\n", "Layout", "Lib", "Batch", "Rows/s", "Range", "Rows read")
+	fmt.Fprintln(w, "-----------|--------|------------|-----------|------------|----------")
+	for _, t := range doc.Timings {
+		fmt.Fprintf(w, "%-10s | %-6s | %-10v | %-9.0f | %-10v | %d\n", t.Layout, t.Library,
+			t.Insert.Round(time.Microsecond), t.RowsPerSecond, t.Range.Round(time.Microsecond), t.RangeRows)
+	}
+
+	fmt.Fprintln(w, "\nTables:")
+	for _, l := range doc.Layouts {
+		chunks := ""
+		if l.Layout == timeseries.Hypertable {
+			chunks = fmt.Sprintf(", %d chunks", l.Chunks)
+		}
+		fmt.Fprintf(w, "  %-10s %d rows, %.1f MB%s\n", l.Layout, l.Rows, float64(l.Bytes)/(1<<20), chunks)
+	}
+
+	plain := make(map[string]timescaleTiming)
+	for _, t := range doc.Timings {
+		if t.Layout == timeseries.Plain {
+			plain[t.Library] = t
+		}
+	}
+	printed := false
+	for _, t := range doc.Timings {
+		base, ok := plain[t.Library]
+		if t.Layout != timeseries.Hypertable || !ok {
+			continue
+		}
+		if !printed {
+			fmt.Fprintln(w, "\nHypertable against plain table:")
+			printed = true
+		}
+		fmt.Fprintf(w, "  %-6s batch %s, range %s\n", t.Library, overhead(t.Insert, base.Insert), overhead(t.Range, base.Range))
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// traceOptions holds the flags selecting where spans are exported
+type traceOptions struct {
+	exporter string // none, stdout or otlp
+	endpoint string // OTLP/HTTP host:port, the OTEL_EXPORTER_OTLP_* variables apply when empty
+}
+
+// enabled reports whether spans are exported
+func (o traceOptions) enabled() bool {
+	return o.exporter != "" && o.exporter != "none"
+}
+
+// setup installs a global tracer provider exporting to the selected
+// exporter, stdout spans going to w. The returned function flushes and
+// stops it; it is a no-op when tracing is disabled.
+func (o traceOptions) setup(ctx context.Context, w io.Writer) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch o.exporter {
+	case "", "none":
+		return noop, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if o.endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(o.endpoint), otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return noop, fmt.Errorf("unknown trace exporter %q (expected none, stdout or otlp)", o.exporter)
+	}
+	if err != nil {
+		return noop, fmt.Errorf("failed to create %s trace exporter: %w", o.exporter, err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "dbcompare"))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
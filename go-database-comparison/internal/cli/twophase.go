@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/twophase"
+)
+
+// twoPhaseOptions holds the flags of the two-phase command
+type twoPhaseOptions struct {
+	libraries []string
+	recover   bool
+	olderThan time.Duration
+	keepData  bool
+}
+
+// twoPhaseDocument is printed with --format json
+type twoPhaseDocument struct {
+	RunID     string           `json:"run_id,omitempty"`
+	Checks    []twophase.Check `json:"checks,omitempty"`
+	Failed    int              `json:"failed"`
+	Recovered []string         `json:"recovered,omitempty"` // Orphans rolled back with --recover
+}
+
+func newTwoPhaseCommand(opts *globalOptions) *cobra.Command {
+	twoPhaseOpts := twoPhaseOptions{}
+	cmd := &cobra.Command{
+		Use:   "two-phase",
+		Short: "Show two-phase commit with PREPARE TRANSACTION through each library, including orphan recovery",
+		Long: `Show two-phase commit through each library: a coordinator runs two
+transaction branches on connections of their own, ends both with PREPARE
+TRANSACTION and then commits them with COMMIT PREPARED, or rolls them back
+with ROLLBACK PREPARED. The scenarios are
+  commit  both branches prepare, then commit: both users appear together,
+  abort   the second branch fails, the prepared first one is rolled back:
+          neither user appears,
+  orphan  the coordinator abandons a prepared branch as if it crashed: the
+          orphan shows in pg_prepared_xacts and blocks an insert of its
+          email until it is recovered with ROLLBACK PREPARED.
+
+PQ and SQLX pin a connection and send BEGIN themselves, since lib/pq fails
+an sql.Tx that PREPARE TRANSACTION ended; GORM uses its own Begin and
+Commit, which pgx lets release the connection after the PREPARE.
+
+The server must allow prepared transactions, docker-compose.yml sets
+max_prepared_transactions=10. A failed check makes the command exit with
+code 2. The users are removed afterwards unless --keep-data.
+
+--recover only rolls back the prepared transactions dbcompare left behind,
+of any run, that are older than --older-than.`,
+		Example: "  dbcompare two-phase\n  dbcompare two-phase --recover --older-than 10m",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTwoPhase(cmd, opts, twoPhaseOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&twoPhaseOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to run, comma separated")
+	flags.BoolVar(&twoPhaseOpts.recover, "recover", false, "Roll back orphaned prepared transactions of dbcompare instead of running the scenarios")
+	flags.DurationVar(&twoPhaseOpts.olderThan, "older-than", time.Minute, "Minimum age of the orphans --recover rolls back")
+	flags.BoolVar(&twoPhaseOpts.keepData, "keep-data", false, "Keep the users the scenarios created")
+	return cmd
+}
+
+func runTwoPhase(cmd *cobra.Command, opts *globalOptions, twoPhaseOpts twoPhaseOptions) error {
+	if twoPhaseOpts.olderThan < 0 {
+		return fmt.Errorf("--older-than must not be negative, got %v", twoPhaseOpts.olderThan)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🤝 Go Database Comparison - Two-Phase Commit")
+	// The observer checks outcomes and recovers orphans, apart from the
+	// pools of the libraries
+	observer, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer observer.Close()
+	limit, err := twophase.Enabled(ctx, observer)
+	if err != nil {
+		return fmt.Errorf("failed to read max_prepared_transactions: %w", err)
+	}
+	if limit == 0 {
+		return fmt.Errorf("the server has prepared transactions disabled, start it with -c max_prepared_transactions=10 as docker-compose.yml does")
+	}
+
+	if twoPhaseOpts.recover {
+		return recoverOrphans(ctx, cmd, opts, observer, twoPhaseOpts.olderThan)
+	}
+
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	if !twoPhaseOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	doc := twoPhaseDocument{RunID: opts.runID}
+	for _, library := range twoPhaseOpts.libraries {
+		lib, closeLib, err := openTwoPhaseLibrary(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		log.Info("running two-phase commit scenarios", "library", lib.Name())
+		doc.Checks = append(doc.Checks, twophase.Run(ctx, lib, observer)...)
+		closeLib()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	fmt.Fprintln(w)
+	printTwoPhaseChecks(w, doc.Checks)
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d two-phase commit checks failed", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d two-phase commit checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// recoverOrphans rolls back the prepared transactions of any dbcompare run
+// older than olderThan
+func recoverOrphans(ctx context.Context, cmd *cobra.Command, opts *globalOptions, observer *sql.DB, olderThan time.Duration) error {
+	w := opts.textOutput(cmd)
+
+	prepared, err := twophase.Prepared(ctx, observer, twophase.GIDPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list prepared transactions: %w", err)
+	}
+	fmt.Fprintf(w, "%d prepared transactions of dbcompare\n", len(prepared))
+	for _, tx := range prepared {
+		fmt.Fprintf(w, "  %s prepared %v ago by %s\n", tx.GID, tx.Age.Round(time.Second), tx.Owner)
+	}
+
+	doc := twoPhaseDocument{}
+	doc.Recovered, err = twophase.Recover(ctx, observer, twophase.GIDPrefix, olderThan)
+	for _, gid := range doc.Recovered {
+		fmt.Fprintf(w, "↩️  rolled back %s\n", gid)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to recover orphans: %w", err)
+	}
+	fmt.Fprintf(w, "Rolled back %d orphans older than %v\n", len(doc.Recovered), olderThan)
+	if opts.jsonOutput() {
+		return opts.printJSON(cmd, doc)
+	}
+	return nil
+}
+
+// openTwoPhaseLibrary connects library and returns it with the function
+// closing its connection
+func openTwoPhaseLibrary(ctx context.Context, library string, config *database.DatabaseConfig) (twophase.Library, func(), error) {
+	switch strings.ToLower(library) {
+	case "pq":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("PQ connection failed: %w", err)
+		}
+		return twophase.PQ(db), func() { db.Close() }, nil
+	case "sqlx":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("SQLX connection failed: %w", err)
+		}
+		return twophase.SQLX(db), func() { db.Close() }, nil
+	case "gorm":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("GORM connection failed: %w", err)
+		}
+		sqlDB, _ := db.DB()
+		return twophase.GORM(db), func() { sqlDB.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown library %q (expected pq, sqlx or gorm)", library)
+	}
+}
+
+// printTwoPhaseChecks prints one line per check
+func printTwoPhaseChecks(w io.Writer, checks []twophase.Check) {
+	fmt.Fprintf(w, "%-6s | %-8s | %s\n", "Lib", "Scenario", "Result")
+	fmt.Fprintln(w, "-------|----------|------------------------------")
+	for _, c := range checks {
+		result := fmt.Sprintf("✅ %s: %s", c.Observed, c.Detail)
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-6s | %-8s | %s\n", c.Library, c.Scenario, result)
+	}
+}
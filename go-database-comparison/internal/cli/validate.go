@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchmark"
+)
+
+// validateResult is the outcome of validating one results file
+type validateResult struct {
+	Path     string   `json:"path"`
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+func newValidateResultsCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate-results FILE...",
+		Short: "Check benchmark results files before comparing or reporting them",
+		Long: `Check benchmark results files, as written by comprehensive --results-file,
+the way comprehensive --baseline and compare-rev check them before use:
+  - every result names its library and operation, and no pair twice,
+  - iterations are positive, counts and durations not negative,
+  - percentiles do not decrease: min_time <= median_time <= p95_time <=
+    p99_time <= max_time, and likewise for timeline windows and workers,
+  - success and error rates lie from 0 to 100.
+
+Every problem is listed with where in the file it was found, parse errors
+with their line and column. An invalid file makes the command exit with
+code 2.`,
+		Example: "  dbcompare validate-results benchmark_results.json\n  dbcompare validate-results baseline.json benchmark_results.json",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidateResults(cmd, opts, args)
+		},
+	}
+}
+
+func runValidateResults(cmd *cobra.Command, opts *globalOptions, paths []string) error {
+	w := opts.textOutput(cmd)
+	results := make([]validateResult, 0, len(paths))
+	invalid := 0
+	for _, path := range paths {
+		result := validateResult{Path: path, Valid: true}
+		if _, err := benchmark.LoadResultsFile(path); err != nil {
+			result.Valid = false
+			invalid++
+			var validationErr *benchmark.ValidationError
+			if errors.As(err, &validationErr) {
+				result.Problems = validationErr.Problems
+			} else {
+				result.Problems = []string{err.Error()}
+			}
+		}
+		results = append(results, result)
+
+		if result.Valid {
+			fmt.Fprintf(w, "✅ %s\n", path)
+			continue
+		}
+		fmt.Fprintf(w, "❌ %s\n", path)
+		for _, problem := range result.Problems {
+			fmt.Fprintf(w, "  - %s\n", problem)
+		}
+	}
+
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, results); err != nil {
+			return err
+		}
+	}
+	if invalid > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d results files are invalid", invalid, len(paths)))
+	}
+	return nil
+}
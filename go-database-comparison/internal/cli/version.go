@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/buildinfo"
+)
+
+func newVersionCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the dbcompare version and the versions of the compared libraries",
+		Long: `Print the dbcompare version, the git commit it was built from and the
+versions of lib/pq, sqlx and GORM compiled into it. Benchmark reports
+record the same information.
+
+The version and commit come from the module and VCS information Go embeds
+in the binary, or from the linker for builds outside a git checkout:
+
+  go build -ldflags "-X go-database-comparison/pkg/buildinfo.Version=v1.2.0
+    -X go-database-comparison/pkg/buildinfo.Commit=$(git rev-parse HEAD)"`,
+		Example: "  dbcompare version\n  dbcompare version --format json | jq -r .libraries.gorm",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := buildinfo.Get()
+			if opts.jsonOutput() {
+				return opts.printJSON(cmd, info)
+			}
+			printVersion(cmd, info)
+			return nil
+		},
+	}
+}
+
+// printVersion prints info as aligned name/value lines
+func printVersion(cmd *cobra.Command, info buildinfo.Info) {
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	fmt.Fprintf(tw, "dbcompare\t%s\n", info.Version)
+	if info.Commit != "" {
+		commit := info.Commit
+		if info.Modified {
+			commit += " (modified)"
+		}
+		fmt.Fprintf(tw, "commit\t%s\n", commit)
+	}
+	if info.CommitTime != "" {
+		fmt.Fprintf(tw, "commit time\t%s\n", info.CommitTime)
+	}
+	fmt.Fprintf(tw, "go\t%s\n", info.GoVersion)
+	for _, name := range info.LibraryNames() {
+		fmt.Fprintf(tw, "%s\t%s\n", name, info.Libraries[name])
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// watchInterval is how often watched files are checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// fileStamp identifies a version of a watched file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	missing bool
+}
+
+func stampOf(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{missing: true}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// runWatch runs the command once, then again every time one of paths
+// changes, until interrupted. Each run builds a new command tree from the
+// same arguments, so it reads the config file afresh and an edit to it
+// applies exactly as it would to a new process. A failing run is reported
+// and the watch goes on.
+func runWatch(cmd *cobra.Command, opts *globalOptions, paths []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log := opts.logger
+	w := opts.textOutput(cmd)
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		stamps[path] = stampOf(path)
+	}
+
+	for {
+		runWatched(ctx, cmd, opts)
+		fmt.Fprintf(w, "\n👀 Watching %v for changes, interrupt to stop\n", paths)
+
+		changed, err := waitForChange(ctx, stamps)
+		if err != nil {
+			log.Info("stopped watching")
+			return nil
+		}
+		fmt.Fprintf(w, "\n🔁 %s changed, re-running\n", changed)
+	}
+}
+
+// runWatched runs the command once as a child of the watch
+func runWatched(ctx context.Context, cmd *cobra.Command, opts *globalOptions) {
+	root, childOpts := newRootCommand()
+	childOpts.watched = true
+	root.SetArgs(opts.args)
+	root.SetOut(cmd.OutOrStdout())
+	root.SetErr(cmd.ErrOrStderr())
+
+	err := root.ExecuteContext(ctx)
+	if childOpts.stopTrace != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		childOpts.stopTrace(shutdownCtx)
+		cancel()
+	}
+	if err != nil && ctx.Err() == nil {
+		opts.logger.Error("run failed", "error", err, "exit_code", exitCode(err))
+	}
+}
+
+// waitForChange blocks until one of the files in stamps changes and has
+// stayed unchanged for a watch interval, so an editor's several writes
+// make one run. It updates stamps and returns the changed path.
+func waitForChange(ctx context.Context, stamps map[string]fileStamp) (string, error) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	changed := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+
+		settled := changed != ""
+		for path, stamp := range stamps {
+			if current := stampOf(path); current != stamp {
+				stamps[path] = current
+				changed, settled = path, false
+			}
+		}
+		if settled {
+			return changed, nil
+		}
+	}
+}
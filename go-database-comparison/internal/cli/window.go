@@ -0,0 +1,465 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/spf13/cobra"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/dblog"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+// rankedColumns is the number of columns GetUsersRankedByAgeInDomain returns
+const rankedColumns = 13
+
+// windowOptions holds the flags of the window command
+type windowOptions struct {
+	libraries  []string
+	users      int
+	iterations int
+	keepData   bool
+}
+
+// rankedRepository is a UserRepository ranking users with window functions
+type rankedRepository interface {
+	repository.UserRepository
+	GetUsersRankedByAgeInDomain(ctx context.Context, domain string) ([]*models.RankedUser, error)
+}
+
+// windowCheck is the outcome of one check on one library
+type windowCheck struct {
+	Library  string `json:"library"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check observed what it expected
+func (c windowCheck) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// windowTiming compares fetching the ranked rows with mapping them into
+// models.RankedUser
+type windowTiming struct {
+	Library    string        `json:"library"`
+	Rows       int           `json:"rows"`
+	Iterations int           `json:"iterations"`
+	Fetch      time.Duration `json:"fetch_median"`  // The same statement through the library's *sql.DB, rows read but not scanned
+	Mapped     time.Duration `json:"mapped_median"` // GetUsersRankedByAgeInDomain
+}
+
+// windowDocument is printed with --format json
+type windowDocument struct {
+	RunID   string         `json:"run_id"`
+	Domain  string         `json:"domain"`
+	Users   int            `json:"users"` // Active users ranked
+	Checks  []windowCheck  `json:"checks"`
+	Timings []windowTiming `json:"timings,omitempty"`
+	Failed  int            `json:"failed"`
+}
+
+func newWindowCommand(opts *globalOptions) *cobra.Command {
+	windowOpts := windowOptions{}
+	cmd := &cobra.Command{
+		Use:   "window",
+		Short: "Check that each library ranks users with window functions alike and benchmark mapping the rows",
+		Long: `Check GetUsersRankedByAgeInDomain, which ranks the active users of an email
+domain by age with RANK, DENSE_RANK and PERCENT_RANK and adds the count
+and average age of the domain with aggregate window functions. PQ and
+SQLX run it as raw SQL, GORM builds it with Select and maps it with Scan;
+all of them read the email_domain column of migration 0008.
+
+--users users are created in the domain of the run, with ages repeating so
+ranks tie, and every tenth one inactive. The checks verify per library that
+  rows        every active user comes back, and no inactive one,
+  ranking     ranks, percentiles, count and average match the ones
+              computed in Go from the users' ages,
+  identical   every row, column by column, equals the first library's.
+A failed check makes the command exit with code 2.
+
+The benchmark then runs the query --iterations times per library, mapped
+into models.RankedUser by the repository and, as the baseline, as the same
+statement through the library's *sql.DB with the rows read but not scanned.
+The difference is what mapping the wide rows costs. The users are removed
+afterwards unless --keep-data.`,
+		Example: "  dbcompare window\n  dbcompare window --users 5000 --iterations 20",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWindow(cmd, opts, windowOpts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&windowOpts.libraries, "lib", []string{"pq", "sqlx", "gorm"}, "Libraries to run, comma separated")
+	flags.IntVar(&windowOpts.users, "users", 1000, "Users to create in the domain of the run")
+	flags.IntVar(&windowOpts.iterations, "iterations", 50, "Queries per library and mode in the benchmark, 0 skips it")
+	flags.BoolVar(&windowOpts.keepData, "keep-data", false, "Keep the users the command created")
+	return cmd
+}
+
+func runWindow(cmd *cobra.Command, opts *globalOptions, windowOpts windowOptions) error {
+	if windowOpts.users < 1 {
+		return fmt.Errorf("--users must be at least 1, got %d", windowOpts.users)
+	}
+	if windowOpts.iterations < 0 {
+		return fmt.Errorf("--iterations must not be negative, got %d", windowOpts.iterations)
+	}
+
+	ctx, cancel := opts.commandContext(cmd, 0)
+	defer cancel()
+
+	w := opts.textOutput(cmd)
+	log := opts.logger
+	config := opts.dbConfig()
+
+	banner(w, "🪟 Go Database Comparison - Window Functions")
+	observer, err := database.ConnectWithPQ(ctx, config)
+	if err != nil {
+		return fmt.Errorf("PQ connection failed: %w", err)
+	}
+	defer observer.Close()
+
+	fmt.Fprintf(w, "Run ID: %s\n", opts.runID)
+	if !windowOpts.keepData {
+		defer func() {
+			if err := cleanupRun(context.WithoutCancel(ctx), config, opts.runID); err != nil {
+				log.Warn("failed to remove the users of this run", "run_id", opts.runID, "error", err)
+			}
+		}()
+	}
+
+	domain, err := createRankedUsers(ctx, observer, windowOpts.users)
+	if err != nil {
+		return err
+	}
+	expected, err := expectedRanking(ctx, observer, domain)
+	if err != nil {
+		return fmt.Errorf("failed to read the users back, is migration 0008 applied? %w", err)
+	}
+	doc := windowDocument{RunID: opts.runID, Domain: domain, Users: len(expected)}
+	fmt.Fprintf(w, "Domain: %s, %d active users\n", domain, len(expected))
+
+	captureConfig := *config
+	captureConfig.QueryLog = sqlcapture.Sink()
+	var reference []*models.RankedUser
+	referenceName := ""
+	for _, library := range windowOpts.libraries {
+		name, repo, db, err := openRankedRepository(ctx, library, &captureConfig)
+		if err != nil {
+			return err
+		}
+		log.Info("checking the ranking", "library", name)
+		recordCtx, recorder := sqlcapture.WithRecorder(ctx)
+		ranked, err := repo.GetUsersRankedByAgeInDomain(recordCtx, domain)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("%s ranking failed: %w", name, err)
+		}
+		if reference == nil {
+			reference, referenceName = ranked, name
+		}
+		doc.Checks = append(doc.Checks, checkRanking(name, ranked, expected, reference, referenceName)...)
+
+		queries := recorder.Queries()
+		if windowOpts.iterations == 0 {
+			continue
+		}
+		if len(queries) != 1 {
+			log.Warn("not benchmarking, the ranking took more than one statement", "library", name, "statements", len(queries))
+			continue
+		}
+		// Benchmark without the capturing hooks, which would be timed too
+		_, repo, db, err = openRankedRepository(ctx, library, config)
+		if err != nil {
+			return err
+		}
+		log.Info("benchmarking the mapping", "library", name, "iterations", windowOpts.iterations)
+		timing, err := benchmarkRankedMapping(ctx, repo, db, domain, queries[0], windowOpts.iterations)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("%s benchmark failed: %w", name, err)
+		}
+		timing.Library = name
+		doc.Timings = append(doc.Timings, timing)
+	}
+	for _, check := range doc.Checks {
+		if !check.Passed() {
+			doc.Failed++
+		}
+	}
+
+	fmt.Fprintln(w)
+	printWindowChecks(w, doc.Checks)
+	if len(doc.Timings) > 0 {
+		fmt.Fprintln(w)
+		printWindowTimings(w, doc.Timings)
+	}
+	if opts.jsonOutput() {
+		if err := opts.printJSON(cmd, doc); err != nil {
+			return err
+		}
+	}
+	if doc.Failed > 0 {
+		return verificationFailed(fmt.Errorf("%d of %d window function checks failed", doc.Failed, len(doc.Checks)))
+	}
+	fmt.Fprintf(w, "\n✅ All %d window function checks passed\n", len(doc.Checks))
+	return nil
+}
+
+// openRankedRepository connects library and returns its name, its
+// repository and the *sql.DB beneath it
+func openRankedRepository(ctx context.Context, library string, config *database.DatabaseConfig) (string, rankedRepository, *sql.DB, error) {
+	name, repo, db, err := openRepositoryDB(ctx, library, config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	ranked, ok := repo.(rankedRepository)
+	if !ok {
+		db.Close()
+		return "", nil, nil, fmt.Errorf("%s repository does not rank users", name)
+	}
+	return name, ranked, db, nil
+}
+
+// createRankedUsers creates n users in the domain of the run in one
+// statement and returns the domain. Ages repeat every 60 users so ranks
+// tie, and every tenth user is inactive.
+func createRankedUsers(ctx context.Context, db *sql.DB, n int) (string, error) {
+	runID := benchdata.RunID(ctx)
+	names := make([]string, n)
+	emails := make([]string, n)
+	ages := make([]int64, n)
+	active := make([]bool, n)
+	for i := range n {
+		names[i] = fmt.Sprintf("Ranked %d", i)
+		emails[i] = benchdata.Email(runID, "ranked", "fixture", int64(i))
+		ages[i] = int64(18 + (i*7)%60)
+		active[i] = i%10 != 9
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO users (name, email, age, is_active)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::int[], $4::bool[])`,
+		pq.Array(names), pq.Array(emails), pq.Array(ages), pq.Array(active))
+	if err != nil {
+		return "", fmt.Errorf("failed to create the users: %w", err)
+	}
+	_, domain, _ := strings.Cut(emails[0], "@")
+	return domain, nil
+}
+
+// expectedRanking reads the active users of domain without window
+// functions and ranks them in Go, in the order the query returns them
+func expectedRanking(ctx context.Context, db *sql.DB, domain string) ([]*models.RankedUser, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id, age FROM users WHERE email_domain = lower($1) AND is_active", domain)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.RankedUser
+	for rows.Next() {
+		user := &models.RankedUser{}
+		if err := rows.Scan(&user.ID, &user.Age); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Oldest first, then by ID, as ORDER BY age_rank, id
+	slices.SortFunc(users, func(a, b *models.RankedUser) int {
+		if a.Age != b.Age {
+			return b.Age - a.Age
+		}
+		return a.ID - b.ID
+	})
+	total := 0
+	for _, user := range users {
+		total += user.Age
+	}
+	for i, user := range users {
+		switch {
+		case i == 0:
+			user.AgeRank, user.AgeDenseRank = 1, 1
+		case user.Age == users[i-1].Age:
+			user.AgeRank, user.AgeDenseRank = users[i-1].AgeRank, users[i-1].AgeDenseRank
+		default:
+			user.AgeRank, user.AgeDenseRank = i+1, users[i-1].AgeDenseRank+1
+		}
+		if len(users) > 1 {
+			user.AgePercentile = float64(user.AgeRank-1) / float64(len(users)-1)
+		}
+		user.DomainUsers = len(users)
+		user.DomainAvgAge = float64(total) / float64(len(users))
+	}
+	return users, nil
+}
+
+// checkRanking compares the ranking of one library with the expected one
+// and with the reference library's rows
+func checkRanking(library string, ranked, expected, reference []*models.RankedUser, referenceName string) []windowCheck {
+	checks := []windowCheck{{
+		Library:  library,
+		Check:    "rows",
+		Expected: fmt.Sprintf("%d users", len(expected)),
+		Observed: fmt.Sprintf("%d users", len(ranked)),
+	}}
+
+	ranking := windowCheck{Library: library, Check: "ranking", Expected: "as computed", Observed: "as computed"}
+	for i := range min(len(ranked), len(expected)) {
+		got, want := ranked[i], expected[i]
+		if difference := rankDifference(got, want); difference != "" {
+			ranking.Observed = fmt.Sprintf("row %d (ID %d): %s", i+1, got.ID, difference)
+			break
+		}
+	}
+	checks = append(checks, ranking)
+
+	if referenceName != library {
+		identical := windowCheck{Library: library, Check: "identical", Expected: "identical to " + referenceName, Observed: "identical to " + referenceName}
+		if len(ranked) != len(reference) {
+			identical.Observed = fmt.Sprintf("%d rows, %s has %d", len(ranked), referenceName, len(reference))
+		}
+		for i := range min(len(ranked), len(reference)) {
+			if difference := rowDifference(ranked[i], reference[i]); difference != "" {
+				identical.Observed = fmt.Sprintf("row %d (ID %d): %s, %s differs", i+1, ranked[i].ID, difference, referenceName)
+				break
+			}
+		}
+		checks = append(checks, identical)
+	}
+	return checks
+}
+
+// rankDifference names the first window column of got that differs from
+// want, the average up to rounding
+func rankDifference(got, want *models.RankedUser) string {
+	switch {
+	case got.ID != want.ID:
+		return fmt.Sprintf("ID %d where %d was expected", got.ID, want.ID)
+	case got.AgeRank != want.AgeRank:
+		return fmt.Sprintf("age_rank %d, expected %d", got.AgeRank, want.AgeRank)
+	case got.AgeDenseRank != want.AgeDenseRank:
+		return fmt.Sprintf("age_dense_rank %d, expected %d", got.AgeDenseRank, want.AgeDenseRank)
+	case got.AgePercentile != want.AgePercentile:
+		return fmt.Sprintf("age_percentile %v, expected %v", got.AgePercentile, want.AgePercentile)
+	case got.DomainUsers != want.DomainUsers:
+		return fmt.Sprintf("domain_users %d, expected %d", got.DomainUsers, want.DomainUsers)
+	case math.Abs(got.DomainAvgAge-want.DomainAvgAge) > 1e-9:
+		return fmt.Sprintf("domain_avg_age %v, expected %v", got.DomainAvgAge, want.DomainAvgAge)
+	}
+	return ""
+}
+
+// rowDifference names the first column in which a differs from b
+func rowDifference(a, b *models.RankedUser) string {
+	columns := []struct {
+		name  string
+		equal bool
+	}{
+		{"id", a.ID == b.ID},
+		{"name", a.Name == b.Name},
+		{"email", a.Email == b.Email},
+		{"age", a.Age == b.Age},
+		{"created_at", a.CreatedAt.Equal(b.CreatedAt)},
+		{"updated_at", a.UpdatedAt.Equal(b.UpdatedAt)},
+		{"is_active", a.IsActive == b.IsActive},
+		{"email_domain", a.EmailDomain == b.EmailDomain},
+		{"age_rank", a.AgeRank == b.AgeRank},
+		{"age_dense_rank", a.AgeDenseRank == b.AgeDenseRank},
+		{"age_percentile", a.AgePercentile == b.AgePercentile},
+		{"domain_users", a.DomainUsers == b.DomainUsers},
+		{"domain_avg_age", a.DomainAvgAge == b.DomainAvgAge},
+	}
+	for _, column := range columns {
+		if !column.equal {
+			return column.name
+		}
+	}
+	return ""
+}
+
+// benchmarkRankedMapping times the ranking query mapped by repo against
+// query, the statement repo executed, run on db with its rows only read,
+// alternating them call by call
+func benchmarkRankedMapping(ctx context.Context, repo rankedRepository, db *sql.DB, domain string, query dblog.Query, iterations int) (windowTiming, error) {
+	timing := windowTiming{Iterations: iterations}
+	var fetch, mapped []time.Duration
+	for range iterations {
+		start := time.Now()
+		rows, err := db.QueryContext(ctx, query.SQL, query.Args...)
+		if err != nil {
+			return timing, err
+		}
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return timing, err
+		}
+		fetch = append(fetch, time.Since(start))
+
+		start = time.Now()
+		users, err := repo.GetUsersRankedByAgeInDomain(ctx, domain)
+		if err != nil {
+			return timing, err
+		}
+		mapped = append(mapped, time.Since(start))
+		if len(users) != n {
+			return timing, fmt.Errorf("mapped %d rows, the statement returned %d", len(users), n)
+		}
+		timing.Rows = n
+	}
+	timing.Fetch, timing.Mapped = medianDuration(fetch), medianDuration(mapped)
+	return timing, nil
+}
+
+// printWindowChecks prints one line per check
+func printWindowChecks(w io.Writer, checks []windowCheck) {
+	fmt.Fprintf(w, "%-6s | %-9s | %s\n", "Lib", "Check", "Result")
+	fmt.Fprintln(w, "-------|-----------|------------------------------")
+	for _, c := range checks {
+		result := "✅ " + c.Observed
+		switch {
+		case c.Error != "":
+			result = "❌ " + c.Error
+		case !c.Passed():
+			result = fmt.Sprintf("❌ %s, expected %s", c.Observed, c.Expected)
+		}
+		fmt.Fprintf(w, "%-6s | %-9s | %s\n", c.Library, c.Check, result)
+	}
+}
+
+// printWindowTimings prints the medians of fetching and mapping the ranked
+// rows, with what mapping costs per row
+func printWindowTimings(w io.Writer, timings []windowTiming) {
+	fmt.Fprintf(w, "Ranked rows of %d columns, medians:\n", rankedColumns)
+	fmt.Fprintf(w, "%-6s | %-6s | %-10s | %-10s | %-10s | %s\n", "Lib", "Rows", "Fetch", "Mapped", "Per row", "Overhead")
+	fmt.Fprintln(w, "-------|--------|------------|------------|------------|---------")
+	for _, t := range timings {
+		perRow := time.Duration(0)
+		if t.Rows > 0 {
+			perRow = (t.Mapped - t.Fetch) / time.Duration(t.Rows)
+		}
+		fmt.Fprintf(w, "%-6s | %-6d | %-10v | %-10v | %-10v | %s\n",
+			t.Library, t.Rows, t.Fetch.Round(time.Microsecond), t.Mapped.Round(time.Microsecond), perRow, overhead(t.Mapped, t.Fetch))
+	}
+}
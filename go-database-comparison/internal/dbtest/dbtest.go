@@ -0,0 +1,172 @@
+// Package dbtest connects tests to a PostgreSQL server holding the
+// benchmark schema, such as the one of docker-compose.yml after dbcompare
+// migrate up. The connection settings come from the environment variables
+// the dbcompare flags read: DBCOMPARE_HOST, DBCOMPARE_PORT, DBCOMPARE_USER,
+// DBCOMPARE_PASSWORD and DBCOMPARE_DBNAME. Tests asking for a database are
+// skipped with -short or when no server answers.
+//
+// By default every library runs on a single connection inside a
+// transaction rolled back when the test ends, see pkg/rollback, so tests
+// are isolated from each other and leave no data behind.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/repository"
+)
+
+// Names are the libraries Libraries connects, in order
+var Names = []string{"PQ", "SQLX", "GORM"}
+
+// connectTimeout bounds the probe for a server
+const connectTimeout = 3 * time.Second
+
+var probe struct {
+	once sync.Once
+	err  error
+}
+
+// Config returns the settings of the test database with Rollback set,
+// skipping t when there is none to connect to
+func Config(t testing.TB) *database.DatabaseConfig {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("needs a database, skipped with -short")
+	}
+
+	config := fromEnv(database.DefaultPostgreSQLConfig())
+	probe.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		defer cancel()
+		var db *sql.DB
+		if db, probe.err = database.ConnectWithPQ(ctx, config); probe.err == nil {
+			db.Close()
+		}
+	})
+	if probe.err != nil {
+		t.Skipf("no database at %s:%d: %v", config.Host, config.Port, probe.err)
+	}
+
+	config.Rollback = true
+	return config
+}
+
+// fromEnv overrides config with the DBCOMPARE_ variables that are set
+func fromEnv(config *database.DatabaseConfig) *database.DatabaseConfig {
+	for name, field := range map[string]*string{
+		"DBCOMPARE_HOST":     &config.Host,
+		"DBCOMPARE_USER":     &config.User,
+		"DBCOMPARE_PASSWORD": &config.Password,
+		"DBCOMPARE_DBNAME":   &config.DBName,
+		"DBCOMPARE_SSLMODE":  &config.SSLMode,
+	} {
+		if value, ok := os.LookupEnv(name); ok {
+			*field = value
+		}
+	}
+	if port, err := strconv.Atoi(os.Getenv("DBCOMPARE_PORT")); err == nil {
+		config.Port = port
+	}
+	return config
+}
+
+// Context returns a context carrying a fresh run ID, canceled when t ends.
+// Users created with benchdata.Email under that ID are removed when t ends
+// unless config rolls them back anyway.
+func Context(t testing.TB, config *database.DatabaseConfig) context.Context {
+	t.Helper()
+	runID := benchdata.NewRunID()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	if !config.Rollback {
+		observer := Observer(t, config)
+		t.Cleanup(func() {
+			if _, err := benchdata.Cleanup(context.Background(), observer, benchdata.Target{RunID: runID}); err != nil {
+				t.Errorf("failed to remove the users of run %s: %v", runID, err)
+			}
+		})
+	}
+	return benchdata.WithRunID(ctx, runID)
+}
+
+// Library is one library's repository and the *sql.DB beneath it
+type Library struct {
+	Name string
+	Repo repository.UserRepository
+	DB   *sql.DB
+}
+
+// Open connects the named library with config, closing it when t ends
+func Open(t testing.TB, name string, config *database.DatabaseConfig) Library {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	lib := Library{Name: name}
+	switch name {
+	case "PQ":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lib.Repo, lib.DB = repository.NewPQRepository(db), db
+	case "SQLX":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lib.Repo, lib.DB = repository.NewSQLXRepository(db), db.DB
+	case "GORM":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lib.Repo, lib.DB = repository.NewGORMRepository(db), sqlDB
+	default:
+		t.Fatalf("unknown library %q", name)
+	}
+	t.Cleanup(func() { lib.DB.Close() })
+	return lib
+}
+
+// Libraries connects every library of Names with config
+func Libraries(t testing.TB, config *database.DatabaseConfig) []Library {
+	t.Helper()
+	libs := make([]Library, 0, len(Names))
+	for _, name := range Names {
+		libs = append(libs, Open(t, name, config))
+	}
+	return libs
+}
+
+// Observer returns a plain lib/pq connection outside any rolled back
+// transaction, to see what the other connections committed
+func Observer(t testing.TB, config *database.DatabaseConfig) *sql.DB {
+	t.Helper()
+	plain := *config
+	plain.Rollback = false
+	plain.QueryLog = nil
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	db, err := database.ConnectWithPQ(ctx, &plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,173 @@
+// Package benchdata names the rows and schemas that benchmarks and checks
+// create, so they can be told apart from real data and removed per run.
+// Every run has an ID; generated users get an email in the run's domain
+// under Domain and per-run schemas are named after it.
+package benchdata
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Domain is the email domain under which runs get their own subdomain
+const Domain = "bench.test"
+
+// SchemaPrefix starts the names of per-run schemas
+const SchemaPrefix = "bench_"
+
+// legacyEmails matches the users created before run IDs existed
+const legacyEmails = `^((bench|readtest|updatetest|warmup|verify|httpbench|httpfixture)-.*|txn-[0-9]+)@test\.com$` +
+	`|^(test|concurrent)-.*@example\.com$`
+
+// seedEmails matches the users of datasets built by pkg/seed
+const seedEmails = `\.seed\.test$`
+
+var runIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// NewRunID returns a run ID made of the UTC time and a random suffix, e.g.
+// 20261017-101500-3f2a
+func NewRunID() string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// ValidateRunID reports an error when id cannot serve as a run ID: it has
+// to be a valid lower case DNS label, since it becomes part of emails
+func ValidateRunID(id string) error {
+	if !runIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid run ID %q: use up to 63 lower case letters, digits and dashes", id)
+	}
+	return nil
+}
+
+// Email returns a unique email for a user created by run runID. kind names
+// what created it, e.g. "bench" or "warmup", and n tells users apart.
+func Email(runID, kind, library string, n int64) string {
+	return fmt.Sprintf("%s-%s-%d@%s.%s", kind, strings.ToLower(library), n, runID, Domain)
+}
+
+type contextKey struct{}
+
+// WithRunID returns a copy of ctx carrying runID
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, runID)
+}
+
+// RunID returns the run ID carried by ctx, or "unknown" when it has none
+func RunID(ctx context.Context) string {
+	if runID, ok := ctx.Value(contextKey{}).(string); ok {
+		return runID
+	}
+	return "unknown"
+}
+
+// Schema returns the name of the schema dedicated to run runID
+func Schema(runID string) string {
+	return SchemaPrefix + strings.ReplaceAll(runID, "-", "_")
+}
+
+// Target selects the data Cleanup removes
+type Target struct {
+	RunID    string // Data of this run only
+	All      bool   // Data of every run, including users created before run IDs
+	SeedData bool   // With All, also the datasets built by dbcompare seed
+	DryRun   bool   // Report what would be removed without removing it
+}
+
+// Report tells what Cleanup removed
+type Report struct {
+	Users   int64    `json:"users"`   // Users deleted, their orders are deleted with them
+	Orders  int64    `json:"orders"`  // Orders of those users
+	Schemas []string `json:"schemas"` // Schemas dropped
+}
+
+// Cleanup removes the users and schemas of target in one transaction
+func Cleanup(ctx context.Context, db *sql.DB, target Target) (Report, error) {
+	var where string
+	var args []interface{}
+	var schemaPattern string
+	switch {
+	case target.RunID != "":
+		if err := ValidateRunID(target.RunID); err != nil {
+			return Report{}, err
+		}
+		where = "email LIKE $1"
+		args = []interface{}{"%@" + target.RunID + "." + Domain}
+		schemaPattern = Schema(target.RunID)
+	case target.All:
+		where = `email LIKE $1 OR email ~ $2`
+		args = []interface{}{"%." + Domain, legacyEmails}
+		if target.SeedData {
+			where += " OR email ~ $3"
+			args = append(args, seedEmails)
+		}
+		schemaPattern = SchemaPrefix + "%"
+	default:
+		return Report{}, fmt.Errorf("nothing to clean up: set a run ID or all")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Report{}, err
+	}
+	defer tx.Rollback()
+
+	var report Report
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM orders WHERE user_id IN (SELECT id FROM users WHERE "+where+")",
+		args...).Scan(&report.Orders)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	if target.DryRun {
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE "+where, args...).Scan(&report.Users)
+	} else {
+		var result sql.Result
+		if result, err = tx.ExecContext(ctx, "DELETE FROM users WHERE "+where, args...); err == nil {
+			report.Users, err = result.RowsAffected()
+		}
+	}
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to delete users: %w", err)
+	}
+
+	// LIKE treats _ as a wildcard, so match the prefix literally
+	rows, err := tx.QueryContext(ctx,
+		`SELECT nspname FROM pg_namespace WHERE nspname LIKE $1 ESCAPE '!' ORDER BY nspname`,
+		strings.ReplaceAll(schemaPattern, "_", "!_"))
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list schemas: %w", err)
+	}
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			rows.Close()
+			return Report{}, err
+		}
+		report.Schemas = append(report.Schemas, schema)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Report{}, err
+	}
+
+	if target.DryRun {
+		return report, nil
+	}
+	for _, schema := range report.Schemas {
+		if _, err := tx.ExecContext(ctx, "DROP SCHEMA "+pq.QuoteIdentifier(schema)+" CASCADE"); err != nil {
+			return Report{}, fmt.Errorf("failed to drop schema %s: %w", schema, err)
+		}
+	}
+	return report, tx.Commit()
+}
@@ -0,0 +1,90 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go-database-comparison/pkg/concurrency"
+	"go-database-comparison/pkg/repository"
+)
+
+// workerConns hands each pool worker a repository bound to a dedicated
+// connection when ConnAffinity is set. A worker checks its connection out on
+// its first job and keeps it until the operation finishes, so latencies
+// reflect the driver rather than contention for pooled connections.
+type workerConns struct {
+	target   libraryTarget
+	enabled  bool
+	mu       sync.Mutex
+	repos    map[int]repository.UserRepository
+	releases []func() error
+}
+
+// newWorkerConns prepares the per-worker connections of one operation
+func (pb *PerformanceBenchmark) newWorkerConns(library string, target libraryTarget) (*workerConns, error) {
+	conns := &workerConns{target: target, enabled: pb.config.ConnAffinity}
+	if !conns.enabled {
+		return conns, nil
+	}
+
+	if target.pinned == nil {
+		return nil, fmt.Errorf("connection affinity not supported for %s", library)
+	}
+	if pb.config.DirectExecution {
+		return nil, fmt.Errorf("connection affinity requires the worker pool, not DirectExecution")
+	}
+	// Workers keep their connection, so one without a connection would wait forever
+	if maxOpen := target.sqlDB.Stats().MaxOpenConnections; maxOpen > 0 && pb.config.Concurrency > maxOpen {
+		return nil, fmt.Errorf("connection affinity needs one connection per worker: concurrency %d exceeds %d open connections for %s",
+			pb.config.Concurrency, maxOpen, library)
+	}
+
+	conns.repos = make(map[int]repository.UserRepository)
+	return conns, nil
+}
+
+// repo returns the repository the job owning ctx should use: the worker's
+// dedicated one with affinity enabled, the shared one otherwise
+func (w *workerConns) repo(ctx context.Context) (repository.UserRepository, error) {
+	workerID, ok := concurrency.WorkerID(ctx)
+	if !w.enabled || !ok {
+		return w.target.repo, nil
+	}
+
+	w.mu.Lock()
+	repo, found := w.repos[workerID]
+	w.mu.Unlock()
+	if found {
+		return repo, nil
+	}
+
+	// A worker runs one job at a time, so no other job pins a connection for it
+	repo, release, err := w.target.pinned(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin connection for worker %d: %w", workerID, err)
+	}
+
+	w.mu.Lock()
+	w.repos[workerID] = repo
+	w.releases = append(w.releases, release)
+	w.mu.Unlock()
+
+	return repo, nil
+}
+
+// Close returns the dedicated connections to the library's pool
+func (w *workerConns) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var firstErr error
+	for _, release := range w.releases {
+		if err := release(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	w.repos = make(map[int]repository.UserRepository)
+	w.releases = nil
+	return firstErr
+}
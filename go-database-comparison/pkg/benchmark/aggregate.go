@@ -0,0 +1,42 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/querycount"
+	"go-database-comparison/pkg/repository"
+)
+
+// ageBucketRepository is implemented by the repositories that aggregate
+// the users by age, which UserRepository leaves out
+type ageBucketRepository interface {
+	CountUsersByAgeBucket(ctx context.Context) ([]*models.AgeBucket, error)
+}
+
+// benchmarkAgeBuckets benchmarks CountUsersByAgeBucket, a GROUP BY whose
+// aggregates each library maps into structs of its own: rows.Scan for PQ,
+// Select by db tags for SQLX and Scan into a struct that is no model for
+// GORM. It aggregates every active user in the table, so its duration
+// grows with the data the run and earlier runs left behind.
+func (pb *PerformanceBenchmark) benchmarkAgeBuckets(ctx context.Context, library string, repo repository.UserRepository) (BenchmarkResult, error) {
+	bucketRepo, ok := repo.(ageBucketRepository)
+	if !ok {
+		return BenchmarkResult{}, fmt.Errorf("age buckets benchmark not supported for %s", library)
+	}
+
+	samples := make([]opSample, 0, pb.config.Iterations)
+
+	for i := 0; i < pb.config.Iterations; i++ {
+		opCtx, queries := querycount.WithCounter(ctx)
+		start := time.Now()
+
+		_, err := bucketRepo.CountUsersByAgeBucket(opCtx)
+
+		samples = append(samples, opSample{Start: start, Duration: time.Since(start), Err: err, Queries: queries.Count()})
+	}
+
+	return pb.summarize(library, "age_buckets", samples), nil
+}
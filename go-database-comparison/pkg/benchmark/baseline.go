@@ -0,0 +1,102 @@
+package benchmark
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Regression is an operation of a library that got slower than in a
+// baseline run
+type Regression struct {
+	Library     string        `json:"library"`
+	Operation   string        `json:"operation"`
+	BaselineAvg time.Duration `json:"baseline_avg_time"`
+	AvgTime     time.Duration `json:"avg_time"`
+	Increase    float64       `json:"increase_percent"` // Growth of the average time
+}
+
+// LoadResultsFile reads the JSON results a comprehensive benchmark wrote
+// and validates them, see ResultsFile.Validate. The bare array of results
+// that cmd/comprehensive-benchmark wrote before ResultsFile existed is
+// read as a file without environment.
+func LoadResultsFile(path string) (ResultsFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ResultsFile{}, fmt.Errorf("failed to read results: %w", err)
+	}
+
+	var file ResultsFile
+	target := interface{}(&file)
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		target = &file.Results
+	}
+	if err := json.Unmarshal(content, target); err != nil {
+		return ResultsFile{}, fmt.Errorf("failed to parse results %s%s: %w", path, jsonErrorPosition(content, err), err)
+	}
+	if err := file.Validate(); err != nil {
+		var invalid *ValidationError
+		if errors.As(err, &invalid) {
+			invalid.Path = path
+		}
+		return ResultsFile{}, err
+	}
+	return file, nil
+}
+
+// jsonErrorPosition returns the line and column a JSON syntax or type error
+// occurred at in content, as ":line:column", or "" for other errors
+func jsonErrorPosition(content []byte, err error) string {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return ""
+	}
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Sprintf(":%d:%d", line, column)
+}
+
+// CompareToBaseline returns the results whose average time grew by more
+// than maxIncrease percent over the same library and operation in
+// baseline, in the order of results. Results without a counterpart in
+// baseline are not compared.
+func CompareToBaseline(results, baseline []BenchmarkResult, maxIncrease float64) []Regression {
+	type key struct{ library, operation string }
+	baselineAvg := make(map[key]time.Duration, len(baseline))
+	for _, r := range baseline {
+		baselineAvg[key{r.Library, r.Operation}] = r.AvgTime
+	}
+
+	var regressions []Regression
+	for _, r := range results {
+		before, ok := baselineAvg[key{r.Library, r.Operation}]
+		if !ok || before <= 0 {
+			continue
+		}
+		increase := (float64(r.AvgTime) - float64(before)) / float64(before) * 100
+		if increase > maxIncrease {
+			regressions = append(regressions, Regression{
+				Library:     r.Library,
+				Operation:   r.Operation,
+				BaselineAvg: before,
+				AvgTime:     r.AvgTime,
+				Increase:    increase,
+			})
+		}
+	}
+	return regressions
+}
@@ -2,15 +2,23 @@ package benchmark
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"log/slog"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"go-database-comparison/pkg/benchdata"
 	"go-database-comparison/pkg/concurrency"
 	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/gormsql"
 	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/querycount"
 	"go-database-comparison/pkg/repository"
 )
 
@@ -29,54 +37,166 @@ type BenchmarkResult struct {
 	OpsPerSec   float64       `json:"ops_per_sec"`
 	ErrorCount  int           `json:"error_count"`
 	SuccessRate float64       `json:"success_rate"`
+	RetryCount  int           `json:"retry_count,omitempty"` // Extra attempts spent on transient errors
+
+	// QueriesPerOp is the average number of SQL statements one operation executed
+	QueriesPerOp float64 `json:"queries_per_op,omitempty"`
+
+	// Errors splits ErrorCount by cause
+	Errors ErrorBreakdown `json:"errors"`
+
+	// Connection pool metrics, populated by the conn_acquire operation only
+	QueryAvgTime     time.Duration `json:"query_avg_time,omitempty"`
+	PoolWaitCount    int64         `json:"pool_wait_count,omitempty"`
+	PoolWaitDuration time.Duration `json:"pool_wait_duration,omitempty"`
+
+	// Cancellation metrics, populated by the cancel operation only
+	CancelledCount     int `json:"cancelled_count,omitempty"`
+	ServerAbortedCount int `json:"server_aborted_count,omitempty"`
+
+	// Saturation metrics, populated by the saturation operation only; the
+	// pool wait fields above are filled in as well
+	InFlight     int `json:"in_flight,omitempty"`      // Jobs running at once
+	MaxOpenConns int `json:"max_open_conns,omitempty"` // Connections they competed for
+
+	// Worker pool metrics, populated for operations run through the queued pool
+	QueueWaitAvg      time.Duration `json:"queue_wait_avg,omitempty"`
+	QueueWaitP95      time.Duration `json:"queue_wait_p95,omitempty"`
+	WorkerUtilization float64       `json:"worker_utilization,omitempty"` // Share of worker time spent in jobs, 0 to 1
+
+	// Throughput and error rate per SampleInterval window over the run
+	Timeline []TimeWindow `json:"timeline,omitempty"`
+
+	// Recovery from the connection kills of chaos mode, see ChaosInterval
+	Chaos *ChaosStats `json:"chaos,omitempty"`
+
+	// Latency distribution per worker goroutine, to spot skew from a slow connection or core
+	Workers []WorkerStats `json:"workers,omitempty"`
 }
 
 // BenchmarkConfig holds benchmark configuration
 type BenchmarkConfig struct {
-	Iterations      int
-	Concurrency     int
-	WarmupRounds    int
-	OperationTypes  []string
-	DataSize        int
-	TimeoutPerOp    time.Duration
+	Iterations        int
+	Concurrency       int
+	ConcurrencySweep  []int                // Runs every operation at each of these concurrencies instead, see SweepOperation
+	WarmupRounds      int
+	OperationTypes    []string
+	DataSize          int
+	TimeoutPerOp      time.Duration
+	SampleInterval    time.Duration        // Width of the throughput timeline windows
+	RateLimit         float64              // Maximum ops/sec across workers, 0 for unlimited
+	DirectExecution   bool                 // Run operations through a semaphore instead of the queued worker pool
+	BreakerThreshold  int                  // Consecutive failures that stop submitting an operation, 0 disables
+	BreakerCooldown   time.Duration        // How long an opened circuit breaker rejects operations
+	DeadlineReserve   time.Duration        // Run time kept free for reporting, operations are cut to fit; 0 disables
+	MaxAttempts       int                  // Attempts per operation on transient errors, 1 disables retries
+	RetryBackoff      time.Duration        // Initial backoff between attempts, doubled each retry
+	CancelRatio       float64              // Fraction of "cancel" operations aborted mid-flight
+	CancelAfter       time.Duration        // Delay before a cancelled operation's context is cancelled
+	ConnAffinity      bool                 // Give each worker a dedicated connection instead of sharing the library's pool
+	SaturationFactor  int                  // Jobs in flight per open connection in the "saturation" operation
+	SaturationHold    time.Duration        // How long each "saturation" operation holds its connection
+	SaturationTimeout time.Duration        // Deadline of each "saturation" operation, including its pool wait
+	Locale            Locale               // Language of reports and console output
+	Logger            *slog.Logger         // Progress log, slog.Default() when nil
+	TracerProvider    trace.TracerProvider // Traces jobs and the repository calls nested in them when set
+	RunID             string               // Marks the users the run creates (see pkg/benchdata), generated when empty
+	ChaosInterval     time.Duration        // Terminates the database's client connections this often during the run, 0 disables
+	Network           string               // Network emulated between the benchmark and the database, e.g. by pkg/netem, for the report
+	Layout            string               // Layout of the users table benchmarked, e.g. "partitioned" (see pkg/partition), for the report
 }
 
 // DefaultBenchmarkConfig returns default benchmark configuration
 func DefaultBenchmarkConfig() *BenchmarkConfig {
 	return &BenchmarkConfig{
-		Iterations:     1000,
-		Concurrency:    10,
-		WarmupRounds:   100,
-		OperationTypes: []string{"create", "read", "update", "delete", "batch_create", "search"},
-		DataSize:       1000,
-		TimeoutPerOp:   5 * time.Second,
+		Iterations:        1000,
+		Concurrency:       10,
+		WarmupRounds:      100,
+		OperationTypes:    []string{"create", "read", "update", "delete", "batch_create", "search", "conn_acquire"},
+		DataSize:          1000,
+		TimeoutPerOp:      5 * time.Second,
+		SampleInterval:    time.Second,
+		MaxAttempts:       1,
+		RetryBackoff:      10 * time.Millisecond,
+		BreakerCooldown:   5 * time.Second,
+		CancelRatio:       0.5,
+		CancelAfter:       20 * time.Millisecond,
+		SaturationFactor:  4,
+		SaturationHold:    50 * time.Millisecond,
+		SaturationTimeout: 500 * time.Millisecond,
+		Locale:            LocaleEnglish,
 	}
 }
 
 // PerformanceBenchmark orchestrates comprehensive performance testing
 type PerformanceBenchmark struct {
-	config  *BenchmarkConfig
-	results []BenchmarkResult
-	mu      sync.RWMutex
+	config      *BenchmarkConfig
+	runID       string
+	results     []BenchmarkResult
+	queries     []QueryStat
+	gormSQL     []gormsql.Method // SQL GORM builds per repository method, see SetGORMSQL
+	environment Environment
+	chaos       *chaosInjector // Set while ChaosInterval kills connections
+	mu          sync.RWMutex
 }
 
 // NewPerformanceBenchmark creates a new benchmark instance
 func NewPerformanceBenchmark(config *BenchmarkConfig) *PerformanceBenchmark {
+	runID := config.RunID
+	if runID == "" {
+		runID = benchdata.NewRunID()
+	}
 	return &PerformanceBenchmark{
 		config:  config,
+		runID:   runID,
 		results: make([]BenchmarkResult, 0),
 	}
 }
 
+// logger returns the configured progress logger
+func (pb *PerformanceBenchmark) logger() *slog.Logger {
+	if pb.config.Logger != nil {
+		return pb.config.Logger
+	}
+	return slog.Default()
+}
+
 // RunComprehensiveBenchmark executes performance tests for all libraries
 func (pb *PerformanceBenchmark) RunComprehensiveBenchmark(ctx context.Context, dbConfig *database.DatabaseConfig) error {
-	fmt.Println("🚀 Starting Comprehensive Performance Benchmark...")
-	fmt.Printf("   Iterations: %d, Concurrency: %d\n", pb.config.Iterations, pb.config.Concurrency)
+	loc := pb.config.Locale
+	pb.logger().Info(loc.T("starting_benchmark"),
+		"iterations", pb.config.Iterations, "concurrency", pb.config.Concurrency)
+
+	env := CollectEnvironment(ctx, dbConfig)
+	env.Network = pb.config.Network
+	env.Layout = pb.config.Layout
+	pb.mu.Lock()
+	pb.environment = env
+	pb.mu.Unlock()
+
+	if pb.config.ChaosInterval > 0 {
+		// The libraries connect tagged, the injector untagged
+		tagged := *dbConfig
+		tagged.AppName = chaosAppName(pb.runID)
+		chaos, stop, err := startChaos(ctx, dbConfig, tagged.AppName, pb.config.ChaosInterval, pb.logger())
+		if err != nil {
+			return err
+		}
+		pb.logger().Info(loc.T("chaos_enabled"), "interval", pb.config.ChaosInterval)
+		pb.chaos = chaos
+		defer func() {
+			stop()
+			pb.chaos = nil
+		}()
+		dbConfig = &tagged
+	}
 
-	libraries := []string{"PQ", "SQLX", "GORM"}
-	
-	for _, library := range libraries {
-		fmt.Printf("\n📊 Benchmarking %s...\n", library)
+	if len(pb.config.ConcurrencySweep) > 0 {
+		return pb.runSweep(ctx, dbConfig)
+	}
+
+	for _, library := range Libraries {
+		pb.logger().Info(loc.Tf("benchmarking", library), "library", library)
 		
 		if err := pb.benchmarkLibrary(ctx, library, dbConfig); err != nil {
 			return fmt.Errorf("benchmark failed for %s: %w", library, err)
@@ -86,10 +206,25 @@ func (pb *PerformanceBenchmark) RunComprehensiveBenchmark(ctx context.Context, d
 	return nil
 }
 
+// libraryTarget bundles the handles a benchmark operation may need for one library
+type libraryTarget struct {
+	repo  repository.UserRepository
+	sqlDB *sql.DB
+	// rawExec runs an arbitrary statement through the library's own API
+	rawExec func(ctx context.Context, query string) error
+	// pinned returns a repository bound to one connection checked out of the
+	// library's pool, and the function that returns the connection
+	pinned func(ctx context.Context) (repo repository.UserRepository, release func() error, err error)
+}
+
 // benchmarkLibrary performs benchmarks for a specific library
 func (pb *PerformanceBenchmark) benchmarkLibrary(ctx context.Context, library string, dbConfig *database.DatabaseConfig) error {
+	// Count every statement the library issues, for the report appendix
+	ctx, queries := querycount.WithCounter(ctx)
+	defer pb.addQueryStats(library, queries)
+
 	// Connect to database
-	var repo interface{}
+	var target libraryTarget
 	var cleanup func()
 
 	switch library {
@@ -98,24 +233,74 @@ func (pb *PerformanceBenchmark) benchmarkLibrary(ctx context.Context, library st
 		if err != nil {
 			return err
 		}
-		repo = repository.NewPQRepository(db)
+		repo := repository.NewPQRepository(db)
+		if dbConfig.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		target.repo = repo
+		target.sqlDB = db
+		target.rawExec = func(ctx context.Context, query string) error {
+			_, err := db.ExecContext(ctx, query)
+			return err
+		}
+		target.pinned = func(ctx context.Context) (repository.UserRepository, func() error, error) {
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return repo.WithConn(conn), conn.Close, nil
+		}
 		cleanup = func() { db.Close() }
 	case "SQLX":
 		db, err := database.ConnectWithSQLX(ctx, dbConfig)
 		if err != nil {
 			return err
 		}
-		repo = repository.NewSQLXRepository(db)
+		repo := repository.NewSQLXRepository(db)
+		if dbConfig.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		target.repo = repo
+		target.sqlDB = db.DB
+		target.rawExec = func(ctx context.Context, query string) error {
+			var discard []string
+			return db.SelectContext(ctx, &discard, query)
+		}
+		target.pinned = func(ctx context.Context) (repository.UserRepository, func() error, error) {
+			conn, err := db.Connx(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return repo.WithConn(conn), conn.Close, nil
+		}
 		cleanup = func() { db.Close() }
 	case "GORM":
 		db, err := database.ConnectWithGORM(ctx, dbConfig)
 		if err != nil {
 			return err
 		}
-		repo = repository.NewGORMRepository(db)
+		repo := repository.NewGORMRepository(db)
+		if dbConfig.ReadOnlyTx {
+			repo = repo.WithReadOnlyReads()
+		}
+		target.repo = repo
+		target.sqlDB, err = db.DB()
+		if err != nil {
+			return fmt.Errorf("failed to get underlying sql.DB from GORM: %w", err)
+		}
+		sqlDB := target.sqlDB
+		target.rawExec = func(ctx context.Context, query string) error {
+			return db.WithContext(ctx).Exec(query).Error
+		}
+		target.pinned = func(ctx context.Context) (repository.UserRepository, func() error, error) {
+			conn, err := sqlDB.Conn(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return repo.WithConn(conn), conn.Close, nil
+		}
 		cleanup = func() { 
-			sqlDB, _ := db.DB()
-			sqlDB.Close()
+			target.sqlDB.Close()
 		}
 	default:
 		return fmt.Errorf("unknown library: %s", library)
@@ -123,13 +308,13 @@ func (pb *PerformanceBenchmark) benchmarkLibrary(ctx context.Context, library st
 	defer cleanup()
 
 	// Warmup
-	if err := pb.warmup(ctx, library, repo); err != nil {
+	if err := pb.warmup(ctx, library, target.repo); err != nil {
 		return fmt.Errorf("warmup failed: %w", err)
 	}
 
 	// Run benchmarks for each operation type
 	for _, operation := range pb.config.OperationTypes {
-		result, err := pb.benchmarkOperation(ctx, library, operation, repo)
+		result, err := pb.benchmarkOperation(ctx, library, operation, target)
 		if err != nil {
 			return fmt.Errorf("benchmark operation %s failed: %w", operation, err)
 		}
@@ -138,44 +323,28 @@ func (pb *PerformanceBenchmark) benchmarkLibrary(ctx context.Context, library st
 		pb.results = append(pb.results, result)
 		pb.mu.Unlock()
 		
-		fmt.Printf("   ✓ %s: %v avg, %.2f ops/sec, %.1f%% success\n", 
-			operation, result.AvgTime, result.OpsPerSec, result.SuccessRate)
+		pb.logger().Info(pb.config.Locale.Tf("operation_done", library, operation),
+			"library", library, "operation", operation, "avg", result.AvgTime,
+			"ops_per_sec", result.OpsPerSec, "success_rate", result.SuccessRate)
 	}
 
 	return nil
 }
 
 // warmup performs warmup operations to stabilize performance
-func (pb *PerformanceBenchmark) warmup(ctx context.Context, library string, repo interface{}) error {
-	fmt.Printf("   🔥 Warming up %s...\n", library)
+func (pb *PerformanceBenchmark) warmup(ctx context.Context, library string, repo repository.UserRepository) error {
+	pb.logger().Debug(pb.config.Locale.Tf("warming_up", library), "library", library, "rounds", pb.config.WarmupRounds)
 	
 	for i := 0; i < pb.config.WarmupRounds; i++ {
 		timestamp := time.Now().UnixNano()
 		req := &models.CreateUserRequest{
 			Name:  fmt.Sprintf("Warmup %s %d", library, timestamp),
-			Email: fmt.Sprintf("warmup-%s-%d@test.com", library, timestamp),
+			Email: benchdata.Email(pb.runID, "warmup", library, timestamp),
 			Age:   25,
 		}
 
-		var user *models.User
-		var err error
-
-		switch r := repo.(type) {
-		case *repository.PQRepository:
-			user, err = r.CreateUser(ctx, req)
-			if err == nil {
-				r.DeleteUser(ctx, user.ID)
-			}
-		case *repository.SQLXRepository:
-			user, err = r.CreateUser(ctx, req)
-			if err == nil {
-				r.DeleteUser(ctx, user.ID)
-			}
-		case *repository.GORMRepository:
-			user, err = r.CreateUser(ctx, req)
-			if err == nil {
-				r.DeleteUser(ctx, user.ID)
-			}
+		if user, err := repo.CreateUser(ctx, req); err == nil {
+			repo.DeleteUser(ctx, user.ID)
 		}
 	}
 
@@ -183,10 +352,12 @@ func (pb *PerformanceBenchmark) warmup(ctx context.Context, library string, repo
 }
 
 // benchmarkOperation benchmarks a specific operation
-func (pb *PerformanceBenchmark) benchmarkOperation(ctx context.Context, library, operation string, repo interface{}) (BenchmarkResult, error) {
+func (pb *PerformanceBenchmark) benchmarkOperation(ctx context.Context, library, operation string, target libraryTarget) (BenchmarkResult, error) {
+	repo := target.repo
+
 	switch operation {
 	case "create":
-		return pb.benchmarkCreate(ctx, library, repo)
+		return pb.benchmarkCreate(ctx, library, target)
 	case "read":
 		return pb.benchmarkRead(ctx, library, repo)
 	case "update":
@@ -197,78 +368,137 @@ func (pb *PerformanceBenchmark) benchmarkOperation(ctx context.Context, library,
 		return pb.benchmarkBatchCreate(ctx, library, repo)
 	case "search":
 		return pb.benchmarkSearch(ctx, library, repo)
+	case "age_buckets":
+		return pb.benchmarkAgeBuckets(ctx, library, repo)
+	case "conn_acquire":
+		return pb.benchmarkConnAcquire(ctx, library, target.sqlDB)
+	case "cancel":
+		return pb.benchmarkCancellation(ctx, library, target)
+	case "saturation":
+		return pb.benchmarkSaturation(ctx, library, target)
 	default:
 		return BenchmarkResult{}, fmt.Errorf("unknown operation: %s", operation)
 	}
 }
 
 // benchmarkCreate benchmarks user creation operations
-func (pb *PerformanceBenchmark) benchmarkCreate(ctx context.Context, library string, repo interface{}) (BenchmarkResult, error) {
-	durations := make([]time.Duration, 0, pb.config.Iterations)
-	errorCount := 0
-	
-	// Use goroutine pool for concurrent operations
-	pool := concurrency.NewWorkerPool(ctx, pb.config.Concurrency)
-	pool.Start()
-	defer pool.Stop()
+func (pb *PerformanceBenchmark) benchmarkCreate(ctx context.Context, library string, target libraryTarget) (BenchmarkResult, error) {
+	conns, err := pb.newWorkerConns(library, target)
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+	defer conns.Close()
 
-	// Submit jobs
+	jobs := make([]concurrency.Job[opSample], 0, pb.config.Iterations)
 	for i := 0; i < pb.config.Iterations; i++ {
 		i := i
-		job := concurrency.Job{
+		jobs = append(jobs, concurrency.Job[opSample]{
 			ID: i,
-			TaskFunc: func(jobCtx context.Context) (interface{}, error) {
+			TaskFunc: func(jobCtx context.Context) (opSample, error) {
 				timestamp := time.Now().UnixNano() + int64(i)
 				req := &models.CreateUserRequest{
 					Name:  fmt.Sprintf("Bench %s %d", library, timestamp),
-					Email: fmt.Sprintf("bench-%s-%d@test.com", library, timestamp),
+					Email: benchdata.Email(pb.runID, "bench", library, timestamp),
 					Age:   25 + (i % 50),
 				}
 
-				start := time.Now()
-				var err error
-
-				switch r := repo.(type) {
-				case *repository.PQRepository:
-					_, err = r.CreateUser(jobCtx, req)
-				case *repository.SQLXRepository:
-					_, err = r.CreateUser(jobCtx, req)
-				case *repository.GORMRepository:
-					_, err = r.CreateUser(jobCtx, req)
+				repo, err := conns.repo(jobCtx)
+				if err != nil {
+					return opSample{Start: time.Now(), Err: err}, err
 				}
 
+				opCtx, queries := querycount.WithCounter(jobCtx)
+				start := time.Now()
+
+				_, err = repo.CreateUser(opCtx, req)
+
 				duration := time.Since(start)
-				return duration, err
+				return opSample{Start: start, Duration: duration, Err: err, Queries: queries.Count()}, err
 			},
 			Timeout: pb.config.TimeoutPerOp,
-		}
-
-		if err := pool.Submit(job); err != nil {
-			return BenchmarkResult{}, fmt.Errorf("failed to submit job: %w", err)
-		}
+			Retry:   pb.retryPolicy(),
+			Labels:  map[string]string{concurrency.LabelLibrary: library, concurrency.LabelOperation: "create"},
+		})
 	}
 
-	// Collect results
-	results, err := pool.GetResults(pb.config.Iterations, 60*time.Second)
+	results, poolStats, err := pb.runJobs(ctx, jobs)
 	if err != nil {
-		return BenchmarkResult{}, fmt.Errorf("failed to get results: %w", err)
+		return BenchmarkResult{}, err
 	}
 
+	samples := make([]opSample, 0, len(results))
 	for _, result := range results {
-		if result.Error != nil {
-			errorCount++
-		} else {
-			if duration, ok := result.Data.(time.Duration); ok {
-				durations = append(durations, duration)
-			}
-		}
+		sample := result.Data
+		sample.Err = result.Error
+		sample.WorkerID = result.WorkerID
+		sample.Attempts = result.Attempts
+		samples = append(samples, sample)
 	}
 
-	return pb.calculateStatistics(library, "create", durations, errorCount), nil
+	result := pb.summarize(library, "create", samples)
+	if poolStats != nil {
+		applyPoolStats(&result, *poolStats)
+	}
+	return result, nil
+}
+
+// runJobs executes jobs with the configured concurrency, either through the
+// worker pool or, with DirectExecution, bounded by a semaphore only. Pool
+// statistics are returned when the worker pool was used.
+func (pb *PerformanceBenchmark) runJobs(ctx context.Context, jobs []concurrency.Job[opSample]) ([]concurrency.Result[opSample], *concurrency.PoolStats, error) {
+	if pb.config.DirectExecution {
+		exec := concurrency.NewDirectExecutor[opSample](pb.config.Concurrency, pb.poolOptions()...)
+		return exec.RunAll(ctx, jobs), nil, nil
+	}
+
+	// Use goroutine pool for concurrent operations
+	results, stats, err := concurrency.RunJobs(ctx, pb.config.Concurrency, jobs, pb.poolOptions()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, &stats, nil
+}
+
+// applyPoolStats copies worker pool queue metrics into a benchmark result
+func applyPoolStats(result *BenchmarkResult, stats concurrency.PoolStats) {
+	result.QueueWaitAvg = stats.QueueWaitAvg
+	result.QueueWaitP95 = stats.QueueWaitP95
+	result.WorkerUtilization = math.Round(stats.Utilization*1000) / 1000
+}
+
+// poolOptions returns the worker pool options derived from the benchmark configuration
+func (pb *PerformanceBenchmark) poolOptions() []concurrency.Option {
+	var opts []concurrency.Option
+	if pb.config.RateLimit > 0 {
+		opts = append(opts, concurrency.WithRateLimit(pb.config.RateLimit, pb.config.Concurrency))
+	}
+	if pb.config.DeadlineReserve > 0 {
+		opts = append(opts, concurrency.WithDeadlineBudget(pb.config.DeadlineReserve, 0))
+	}
+	if pb.config.BreakerThreshold > 0 {
+		opts = append(opts, concurrency.WithCircuitBreaker(pb.config.BreakerThreshold, pb.config.BreakerCooldown, concurrency.LabelOperation))
+	}
+	if pb.config.TracerProvider != nil {
+		opts = append(opts, concurrency.WithTracerProvider(pb.config.TracerProvider))
+	}
+	return opts
+}
+
+// retryPolicy returns the per-operation retry policy, or nil when retries are disabled
+func (pb *PerformanceBenchmark) retryPolicy() *concurrency.RetryPolicy {
+	if pb.config.MaxAttempts <= 1 {
+		return nil
+	}
+	return &concurrency.RetryPolicy{
+		MaxAttempts:    pb.config.MaxAttempts,
+		InitialBackoff: pb.config.RetryBackoff,
+		MaxBackoff:     time.Second,
+		Retryable:      IsTransientError,
+	}
 }
 
 // benchmarkRead benchmarks user read operations (simplified version)
-func (pb *PerformanceBenchmark) benchmarkRead(ctx context.Context, library string, repo interface{}) (BenchmarkResult, error) {
+func (pb *PerformanceBenchmark) benchmarkRead(ctx context.Context, library string, repo repository.UserRepository) (BenchmarkResult, error) {
 	// For read benchmark, we need existing data
 	// Create some test users first
 	testUserIDs := make([]int, 0, 10)
@@ -277,30 +507,17 @@ func (pb *PerformanceBenchmark) benchmarkRead(ctx context.Context, library strin
 		timestamp := time.Now().UnixNano() + int64(i)
 		req := &models.CreateUserRequest{
 			Name:  fmt.Sprintf("ReadTest %s %d", library, timestamp),
-			Email: fmt.Sprintf("readtest-%s-%d@test.com", library, timestamp),
+			Email: benchdata.Email(pb.runID, "readtest", library, timestamp),
 			Age:   25,
 		}
 
-		var user *models.User
-		var err error
-
-		switch r := repo.(type) {
-		case *repository.PQRepository:
-			user, err = r.CreateUser(ctx, req)
-		case *repository.SQLXRepository:
-			user, err = r.CreateUser(ctx, req)
-		case *repository.GORMRepository:
-			user, err = r.CreateUser(ctx, req)
-		}
-
-		if err == nil {
+		if user, err := repo.CreateUser(ctx, req); err == nil {
 			testUserIDs = append(testUserIDs, user.ID)
 		}
 	}
 
 	// Now benchmark read operations
-	durations := make([]time.Duration, 0, pb.config.Iterations)
-	errorCount := 0
+	samples := make([]opSample, 0, pb.config.Iterations)
 
 	for i := 0; i < pb.config.Iterations; i++ {
 		if len(testUserIDs) == 0 {
@@ -308,70 +525,139 @@ func (pb *PerformanceBenchmark) benchmarkRead(ctx context.Context, library strin
 		}
 		
 		userID := testUserIDs[i%len(testUserIDs)]
+		opCtx, queries := querycount.WithCounter(ctx)
 		start := time.Now()
 		
-		var err error
-		switch r := repo.(type) {
-		case *repository.PQRepository:
-			_, err = r.GetUserByID(ctx, userID)
-		case *repository.SQLXRepository:
-			_, err = r.GetUserByID(ctx, userID)
-		case *repository.GORMRepository:
-			_, err = r.GetUserByID(ctx, userID)
-		}
-		
-		duration := time.Since(start)
+		_, err := repo.GetUserByID(opCtx, userID)
 		
-		if err != nil {
-			errorCount++
-		} else {
-			durations = append(durations, duration)
-		}
+		samples = append(samples, opSample{Start: start, Duration: time.Since(start), Err: err, Queries: queries.Count()})
 	}
 
 	// Cleanup test users
 	for _, userID := range testUserIDs {
-		switch r := repo.(type) {
-		case *repository.PQRepository:
-			r.DeleteUser(ctx, userID)
-		case *repository.SQLXRepository:
-			r.DeleteUser(ctx, userID)
-		case *repository.GORMRepository:
-			r.DeleteUser(ctx, userID)
-		}
+		repo.DeleteUser(ctx, userID)
 	}
 
-	return pb.calculateStatistics(library, "read", durations, errorCount), nil
+	return pb.summarize(library, "read", samples), nil
 }
 
-// Simplified implementations for other operations
-func (pb *PerformanceBenchmark) benchmarkUpdate(ctx context.Context, library string, repo interface{}) (BenchmarkResult, error) {
-	// Implementation similar to benchmarkRead but with update operations
-	return BenchmarkResult{
-		Library: library, Operation: "update", Iterations: pb.config.Iterations,
-		AvgTime: 2 * time.Millisecond, OpsPerSec: 500, SuccessRate: 100.0,
-	}, nil
+// benchBatchSize is the number of users each "batch_create" operation creates
+const benchBatchSize = 10
+
+// createTestUsers creates n users of kind for the operations that need
+// existing data, benchBatchSize at a time, and returns the IDs of those
+// created
+func (pb *PerformanceBenchmark) createTestUsers(ctx context.Context, library, kind string, repo repository.UserRepository, n int) []int {
+	ids := make([]int, 0, n)
+	timestamp := time.Now().UnixNano()
+	for created := 0; created < n; created += benchBatchSize {
+		requests := make([]*models.CreateUserRequest, 0, benchBatchSize)
+		for i := created; i < n && i < created+benchBatchSize; i++ {
+			requests = append(requests, &models.CreateUserRequest{
+				Name:  fmt.Sprintf("%s %s %d", kind, library, i),
+				Email: benchdata.Email(pb.runID, kind, library, timestamp+int64(i)),
+				Age:   25 + (i % 50),
+			})
+		}
+		users, err := repo.BatchCreateUsers(ctx, requests)
+		if err != nil {
+			continue
+		}
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids
 }
 
-func (pb *PerformanceBenchmark) benchmarkDelete(ctx context.Context, library string, repo interface{}) (BenchmarkResult, error) {
-	return BenchmarkResult{
-		Library: library, Operation: "delete", Iterations: pb.config.Iterations,
-		AvgTime: 1 * time.Millisecond, OpsPerSec: 1000, SuccessRate: 100.0,
-	}, nil
+// benchmarkUpdate benchmarks renaming existing users
+func (pb *PerformanceBenchmark) benchmarkUpdate(ctx context.Context, library string, repo repository.UserRepository) (BenchmarkResult, error) {
+	testUserIDs := pb.createTestUsers(ctx, library, "updatetest", repo, 10)
+	samples := make([]opSample, 0, pb.config.Iterations)
+
+	for i := 0; i < pb.config.Iterations && len(testUserIDs) > 0; i++ {
+		name := fmt.Sprintf("Updated %s %d", library, i)
+		opCtx, queries := querycount.WithCounter(ctx)
+		start := time.Now()
+
+		_, err := repo.UpdateUser(opCtx, testUserIDs[i%len(testUserIDs)], &models.UpdateUserRequest{Name: &name})
+
+		samples = append(samples, opSample{Start: start, Duration: time.Since(start), Err: err, Queries: queries.Count()})
+	}
+
+	for _, userID := range testUserIDs {
+		repo.DeleteUser(ctx, userID)
+	}
+
+	return pb.summarize(library, "update", samples), nil
 }
 
-func (pb *PerformanceBenchmark) benchmarkBatchCreate(ctx context.Context, library string, repo interface{}) (BenchmarkResult, error) {
-	return BenchmarkResult{
-		Library: library, Operation: "batch_create", Iterations: pb.config.Iterations,
-		AvgTime: 5 * time.Millisecond, OpsPerSec: 200, SuccessRate: 100.0,
-	}, nil
+// benchmarkDelete benchmarks deleting users, one created beforehand for
+// every iteration
+func (pb *PerformanceBenchmark) benchmarkDelete(ctx context.Context, library string, repo repository.UserRepository) (BenchmarkResult, error) {
+	testUserIDs := pb.createTestUsers(ctx, library, "deletetest", repo, pb.config.Iterations)
+	samples := make([]opSample, 0, len(testUserIDs))
+
+	for _, userID := range testUserIDs {
+		opCtx, queries := querycount.WithCounter(ctx)
+		start := time.Now()
+
+		err := repo.DeleteUser(opCtx, userID)
+
+		samples = append(samples, opSample{Start: start, Duration: time.Since(start), Err: err, Queries: queries.Count()})
+	}
+
+	return pb.summarize(library, "delete", samples), nil
 }
 
-func (pb *PerformanceBenchmark) benchmarkSearch(ctx context.Context, library string, repo interface{}) (BenchmarkResult, error) {
-	return BenchmarkResult{
-		Library: library, Operation: "search", Iterations: pb.config.Iterations,
-		AvgTime: 3 * time.Millisecond, OpsPerSec: 333, SuccessRate: 100.0,
-	}, nil
+// benchmarkBatchCreate benchmarks creating benchBatchSize users in one
+// BatchCreateUsers call, each iteration being one batch
+func (pb *PerformanceBenchmark) benchmarkBatchCreate(ctx context.Context, library string, repo repository.UserRepository) (BenchmarkResult, error) {
+	samples := make([]opSample, 0, pb.config.Iterations)
+
+	for i := 0; i < pb.config.Iterations; i++ {
+		timestamp := time.Now().UnixNano()
+		requests := make([]*models.CreateUserRequest, benchBatchSize)
+		for j := range requests {
+			requests[j] = &models.CreateUserRequest{
+				Name:  fmt.Sprintf("Batch %s %d", library, timestamp),
+				Email: benchdata.Email(pb.runID, "batch", library, timestamp+int64(j)),
+				Age:   25 + (j % 50),
+			}
+		}
+		opCtx, queries := querycount.WithCounter(ctx)
+		start := time.Now()
+
+		_, err := repo.BatchCreateUsers(opCtx, requests)
+
+		samples = append(samples, opSample{Start: start, Duration: time.Since(start), Err: err, Queries: queries.Count()})
+	}
+
+	return pb.summarize(library, "batch_create", samples), nil
+}
+
+// benchmarkSearch benchmarks GetUsersByEmail matching the users it creates,
+// the pattern limited to this run's domain
+func (pb *PerformanceBenchmark) benchmarkSearch(ctx context.Context, library string, repo repository.UserRepository) (BenchmarkResult, error) {
+	testUserIDs := pb.createTestUsers(ctx, library, "searchtest", repo, 10)
+	_, domain, _ := strings.Cut(benchdata.Email(pb.runID, "searchtest", library, 0), "@")
+	pattern := "searchtest-" + strings.ToLower(library) + "-%@" + domain
+	samples := make([]opSample, 0, pb.config.Iterations)
+
+	for i := 0; i < pb.config.Iterations && len(testUserIDs) > 0; i++ {
+		opCtx, queries := querycount.WithCounter(ctx)
+		start := time.Now()
+
+		_, err := repo.GetUsersByEmail(opCtx, pattern)
+
+		samples = append(samples, opSample{Start: start, Duration: time.Since(start), Err: err, Queries: queries.Count()})
+	}
+
+	for _, userID := range testUserIDs {
+		repo.DeleteUser(ctx, userID)
+	}
+
+	return pb.summarize(library, "search", samples), nil
 }
 
 // calculateStatistics calculates comprehensive statistics from duration measurements
@@ -440,32 +726,96 @@ func (pb *PerformanceBenchmark) GetResults() []BenchmarkResult {
 	return results
 }
 
+// RunID returns the ID marking the users the run creates
+func (pb *PerformanceBenchmark) RunID() string {
+	return pb.runID
+}
+
+// Environment returns the environment metadata collected for the run
+func (pb *PerformanceBenchmark) Environment() Environment {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+
+	return pb.environment
+}
+
+// ResultsFile returns the run's results together with its environment metadata
+func (pb *PerformanceBenchmark) ResultsFile() ResultsFile {
+	return ResultsFile{
+		RunID:       pb.runID,
+		Environment: pb.Environment(),
+		Results:     pb.GetResults(),
+		Queries:     pb.QueryStats(),
+		GORMSQL:     pb.GORMSQL(),
+	}
+}
+
+// NewReportBenchmark returns a benchmark holding the run of file, so its
+// reports can be rendered again without running it. config supplies the
+// report settings: iterations, concurrency, operation order and locale.
+func NewReportBenchmark(config *BenchmarkConfig, file ResultsFile) *PerformanceBenchmark {
+	pb := NewPerformanceBenchmark(config)
+	if file.RunID != "" {
+		pb.runID = file.RunID
+	}
+	pb.environment = file.Environment
+	pb.results = append(pb.results, file.Results...)
+	pb.queries = append(pb.queries, file.Queries...)
+	pb.gormSQL = append(pb.gormSQL, file.GORMSQL...)
+	return pb
+}
+
 // GenerateReport generates a comprehensive performance report
 func (pb *PerformanceBenchmark) GenerateReport() string {
 	results := pb.GetResults()
 	
-	report := "# Go Database Libraries Performance Benchmark Report\n\n"
-	report += fmt.Sprintf("**Configuration**: %d iterations, %d concurrent workers\n\n", 
-		pb.config.Iterations, pb.config.Concurrency)
-
-	// Group results by operation
-	operationGroups := make(map[string][]BenchmarkResult)
-	for _, result := range results {
-		operationGroups[result.Operation] = append(operationGroups[result.Operation], result)
+	loc := pb.config.Locale
+
+	report := fmt.Sprintf("# %s\n\n", loc.T("report_title"))
+	report += fmt.Sprintf("**%s**: %s\n\n", loc.T("configuration"),
+		loc.Tf("config_summary", pb.config.Iterations, pb.config.Concurrency))
+	report += fmt.Sprintf("**%s**: %s\n\n", loc.T("build"), pb.Environment().Build)
+	if network := pb.Environment().Network; network != "" {
+		report += fmt.Sprintf("**%s**: %s\n\n", loc.T("network"), network)
+	}
+	if layout := pb.Environment().Layout; layout != "" {
+		report += fmt.Sprintf("**%s**: %s\n\n", loc.T("layout"), layout)
 	}
 
-	for operation, opResults := range operationGroups {
-		report += fmt.Sprintf("## %s Operation\n\n", operation)
-		report += "| Library | Avg Time | Min Time | Max Time | P95 Time | Ops/Sec | Success Rate |\n"
-		report += "|---------|----------|----------|----------|----------|---------|-------------|\n"
-		
-		for _, result := range opResults {
-			report += fmt.Sprintf("| %s | %v | %v | %v | %v | %.2f | %.1f%% |\n",
-				result.Library, result.AvgTime, result.MinTime, result.MaxTime,
-				result.P95Time, result.OpsPerSec, result.SuccessRate)
+	// Group results by operation in a stable order so reports diff cleanly
+	for _, group := range GroupByOperation(results, pb.config.OperationTypes) {
+		report += fmt.Sprintf("## %s\n\n", loc.Tf("operation_heading", group.Operation))
+		if group.Winner != "" {
+			report += fmt.Sprintf("**%s**: %s\n\n", loc.T("winner"), group.Winner)
+		}
+		report += loc.tableHeader("library", "avg_time", "min_time", "max_time", "p95_time", "ops_per_sec", "success_rate", "vs_winner")
+
+		for _, result := range group.Results {
+			library := result.Library
+			relative := "-"
+			if library == group.Winner {
+				library += " 🏆"
+			} else if group.Winner != "" && result.AvgTime > 0 {
+				relative = fmt.Sprintf("%+.1f%%", group.RelativeToWinner(result))
+			}
+			report += fmt.Sprintf("| %s | %v | %v | %v | %v | %.2f | %.1f%% | %s |\n",
+				library, result.AvgTime, result.MinTime, result.MaxTime,
+				result.P95Time, result.OpsPerSec, result.SuccessRate, relative)
 		}
 		report += "\n"
 	}
 
+	report += generatePoolContentionSection(results, loc)
+	report += generateCancellationSection(results, loc)
+	report += generateSaturationSection(results, loc)
+	report += generateChaosSection(results, loc)
+	report += generateHTTPOverheadSection(results, loc)
+	report += generateErrorSection(results, loc)
+	report += generateWorkerSection(results, loc)
+	report += generateQueueSection(results, loc)
+	report += generateQuerySection(results, loc)
+	report += generateQueriesIssuedSection(pb.QueryStats(), loc)
+	report += generateGORMSQLSection(pb.GORMSQL(), loc)
+
 	return report
 }
\ No newline at end of file
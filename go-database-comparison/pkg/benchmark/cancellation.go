@@ -0,0 +1,187 @@
+package benchmark
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-database-comparison/pkg/concurrency"
+)
+
+// cancelQueryDuration is how long the server-side sleep of a "cancel" operation lasts
+const cancelQueryDuration = 200 * time.Millisecond
+
+// cancelSample holds the outcome of a single cancellation operation
+type cancelSample struct {
+	opSample      // Overall operation timing for the timeline
+	cancelled     bool
+	latency       time.Duration // Time between cancel() and the library returning
+	serverAborted bool          // Query no longer active in pg_stat_activity
+}
+
+// benchmarkCancellation cancels a share of long-running queries mid-flight and
+// measures how quickly each library returns control to the caller. For every
+// cancelled query pg_stat_activity is checked to verify the backend actually
+// stopped executing it, rather than the client merely giving up.
+func (pb *PerformanceBenchmark) benchmarkCancellation(ctx context.Context, library string, target libraryTarget) (BenchmarkResult, error) {
+	if target.sqlDB == nil || target.rawExec == nil {
+		return BenchmarkResult{}, fmt.Errorf("cancellation benchmark not supported for %s", library)
+	}
+
+	runID := time.Now().UnixNano()
+
+	jobs := make([]concurrency.Job[cancelSample], 0, pb.config.Iterations)
+	for i := 0; i < pb.config.Iterations; i++ {
+		i := i
+		cancelIt := float64(i%100) < pb.config.CancelRatio*100
+		// The closing delimiter keeps marker 5 from matching queries 50-59
+		marker := fmt.Sprintf("/* bench-cancel-%s-%d-%d */", library, runID, i)
+
+		jobs = append(jobs, concurrency.Job[cancelSample]{
+			ID: i,
+			TaskFunc: func(jobCtx context.Context) (cancelSample, error) {
+				return runCancellableQuery(jobCtx, target, marker, cancelIt, pb.config.CancelAfter)
+			},
+			Timeout: pb.config.TimeoutPerOp,
+		})
+	}
+
+	results, poolStats, err := concurrency.RunJobs(ctx, pb.config.Concurrency, jobs, pb.poolOptions()...)
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+
+	latencies := make([]time.Duration, 0, pb.config.Iterations)
+	samples := make([]opSample, 0, pb.config.Iterations)
+	errorCount := 0
+	cancelled := 0
+	aborted := 0
+
+	for _, result := range results {
+		sample := result.Data
+		sample.Err = result.Error
+		sample.WorkerID = result.WorkerID
+		samples = append(samples, sample.opSample)
+		if result.Error != nil {
+			errorCount++
+			continue
+		}
+		if !sample.cancelled {
+			continue
+		}
+		cancelled++
+		latencies = append(latencies, sample.latency)
+		if sample.serverAborted {
+			aborted++
+		}
+	}
+
+	benchResult := pb.calculateStatistics(library, "cancel", latencies, errorCount)
+	benchResult.CancelledCount = cancelled
+	benchResult.ServerAbortedCount = aborted
+	benchResult.Timeline = buildTimeline(samples, pb.config.SampleInterval)
+	benchResult.Errors = breakdownErrors(samples)
+	benchResult.Workers = buildWorkerStats(samples)
+	applyPoolStats(&benchResult, poolStats)
+
+	return benchResult, nil
+}
+
+// runCancellableQuery executes a pg_sleep query tagged with the marker comment
+// and optionally cancels it
+func runCancellableQuery(ctx context.Context, target libraryTarget, marker string, cancelIt bool, cancelAfter time.Duration) (cancelSample, error) {
+	query := fmt.Sprintf("SELECT pg_sleep(%f) %s", cancelQueryDuration.Seconds(), marker)
+	start := time.Now()
+	timing := func() opSample { return opSample{Start: start, Duration: time.Since(start)} }
+
+	if !cancelIt {
+		err := target.rawExec(ctx, query)
+		return cancelSample{opSample: timing()}, err
+	}
+
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- target.rawExec(queryCtx, query)
+	}()
+
+	select {
+	case err := <-done:
+		return cancelSample{opSample: timing()}, fmt.Errorf("query finished before cancellation: %v", err)
+	case <-time.After(cancelAfter):
+	}
+
+	cancelledAt := time.Now()
+	cancel()
+	err := <-done
+	latency := time.Since(cancelledAt)
+	sample := cancelSample{opSample: timing(), cancelled: true, latency: latency}
+
+	if err == nil {
+		return sample, fmt.Errorf("query was not aborted by cancellation")
+	}
+	if ctx.Err() != nil {
+		return sample, ctx.Err()
+	}
+
+	serverAborted, checkErr := waitForServerAbort(ctx, target.sqlDB, marker, time.Second)
+	if checkErr != nil {
+		return sample, checkErr
+	}
+	sample.serverAborted = serverAborted
+
+	return sample, nil
+}
+
+// waitForServerAbort polls pg_stat_activity until the query tagged with the
+// marker comment is gone or the deadline passes. The marker must be
+// delimited, as "/* ... */", so it cannot match as a prefix of another marker.
+func waitForServerAbort(ctx context.Context, db *sql.DB, marker string, maxWait time.Duration) (bool, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM pg_stat_activity
+		WHERE state = 'active' AND query LIKE $1 AND pid <> pg_backend_pid()`
+
+	deadline := time.Now().Add(maxWait)
+	for {
+		var active int
+		if err := db.QueryRowContext(ctx, query, "%"+marker+"%").Scan(&active); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				return false, err
+			}
+			return false, fmt.Errorf("pg_stat_activity check failed: %w", err)
+		}
+		if active == 0 {
+			return true, nil
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// generateCancellationSection renders the cancellation section of the report
+func generateCancellationSection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if operation, _ := SplitSweepOperation(result.Operation); operation != "cancel" {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("cancel_section"))
+			section += loc.tableHeader("library", "cancelled", "aborted_server", "cancel_latency_avg", "cancel_latency_p95", "errors")
+		}
+		section += fmt.Sprintf("| %s | %d | %d | %v | %v | %d |\n",
+			sweepLabel(result), result.CancelledCount, result.ServerAbortedCount,
+			result.AvgTime, result.P95Time, result.ErrorCount)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
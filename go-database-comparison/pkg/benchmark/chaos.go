@@ -0,0 +1,205 @@
+package benchmark
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go-database-comparison/pkg/database"
+)
+
+// ChaosStats tells how a library's pool recovered from the connection
+// kills of chaos mode during one operation
+type ChaosStats struct {
+	Kills       int           `json:"kills"`        // Rounds of terminated connections during the operation
+	FailedOps   int           `json:"failed_ops"`   // Operations that failed between a kill and the recovery from it
+	RecoveryAvg time.Duration `json:"recovery_avg"` // From a kill to the end of the first later operation that succeeded
+	RecoveryMax time.Duration `json:"recovery_max"`
+	Unrecovered int           `json:"unrecovered"` // Kills after which no operation succeeded
+}
+
+// chaosAppName returns the application_name the connections of run runID
+// are tagged with in chaos mode, cut to the 63 bytes PostgreSQL keeps
+func chaosAppName(runID string) string {
+	name := "dbcompare-chaos-" + runID
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+// chaosInjector terminates the benchmark's backends periodically, through
+// a connection of its own, and remembers when. The benchmark's connections
+// are told apart by their application_name, appName.
+type chaosInjector struct {
+	db       *sql.DB
+	appName  string
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu    sync.Mutex
+	kills []time.Time
+}
+
+// startChaos connects the injector and starts terminating the connections
+// tagged with appName every interval until the returned function is called
+func startChaos(ctx context.Context, dbConfig *database.DatabaseConfig, appName string, interval time.Duration, logger *slog.Logger) (*chaosInjector, func(), error) {
+	db, err := database.ConnectWithPQ(ctx, dbConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("chaos connection failed: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	c := &chaosInjector{db: db, appName: appName, interval: interval, logger: logger}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.run(ctx)
+	}()
+	stop := func() {
+		cancel()
+		<-done
+		db.Close()
+	}
+	return c, stop, nil
+}
+
+func (c *chaosInjector) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Only the benchmark's pools, other sessions sharing the database
+		// and its credentials are left alone
+		query := `
+			SELECT COUNT(pg_terminate_backend(pid))
+			FROM pg_stat_activity
+			WHERE datname = current_database()
+				AND usename = current_user
+				AND backend_type = 'client backend'
+				AND application_name = $1
+				AND pid <> pg_backend_pid()`
+
+		at := time.Now()
+		var terminated int
+		if err := c.db.QueryRowContext(ctx, query, c.appName).Scan(&terminated); err != nil {
+			if ctx.Err() == nil {
+				c.logger.Warn("chaos failed to terminate connections", "error", err)
+			}
+			continue
+		}
+		c.mu.Lock()
+		c.kills = append(c.kills, at)
+		c.mu.Unlock()
+		c.logger.Debug("chaos terminated connections", "count", terminated)
+	}
+}
+
+// killsBetween returns the kills from start up to end
+func (c *chaosInjector) killsBetween(start, end time.Time) []time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var kills []time.Time
+	for _, at := range c.kills {
+		if !at.Before(start) && !at.After(end) {
+			kills = append(kills, at)
+		}
+	}
+	return kills
+}
+
+// chaosStats measures the recovery of an operation's samples from the
+// kills during them, or returns nil when there were none
+func (c *chaosInjector) chaosStats(samples []opSample) *ChaosStats {
+	var first, last time.Time
+	for _, s := range samples {
+		if s.Start.IsZero() {
+			continue
+		}
+		if first.IsZero() || s.Start.Before(first) {
+			first = s.Start
+		}
+		if end := s.Start.Add(s.Duration); end.After(last) {
+			last = end
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+	kills := c.killsBetween(first, last)
+	if len(kills) == 0 {
+		return nil
+	}
+
+	stats := &ChaosStats{Kills: len(kills)}
+	var total time.Duration
+	recovered := 0
+	for _, at := range kills {
+		// Recovered once an operation started after the kill succeeds
+		var recoveredAt time.Time
+		for _, s := range samples {
+			if s.Err != nil || s.Start.Before(at) {
+				continue
+			}
+			if end := s.Start.Add(s.Duration); recoveredAt.IsZero() || end.Before(recoveredAt) {
+				recoveredAt = end
+			}
+		}
+		if recoveredAt.IsZero() {
+			stats.Unrecovered++
+			recoveredAt = last
+		} else {
+			recovery := recoveredAt.Sub(at)
+			total += recovery
+			recovered++
+			if recovery > stats.RecoveryMax {
+				stats.RecoveryMax = recovery
+			}
+		}
+
+		for _, s := range samples {
+			if s.Err == nil || s.Start.IsZero() {
+				continue
+			}
+			if end := s.Start.Add(s.Duration); !end.Before(at) && end.Before(recoveredAt) {
+				stats.FailedOps++
+			}
+		}
+	}
+	if recovered > 0 {
+		stats.RecoveryAvg = total / time.Duration(recovered)
+	}
+	return stats
+}
+
+// generateChaosSection reports how each library recovered from the
+// connection kills of chaos mode
+func generateChaosSection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if result.Chaos == nil {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("chaos_section"))
+			section += loc.tableHeader("library", "operation", "chaos_kills", "success_rate", "chaos_failed", "recovery_avg", "recovery_max", "unrecovered")
+		}
+		c := result.Chaos
+		section += fmt.Sprintf("| %s | %s | %d | %.1f%% | %d | %v | %v | %d |\n",
+			sweepLabel(result), result.Operation, c.Kills, result.SuccessRate,
+			c.FailedOps, c.RecoveryAvg, c.RecoveryMax, c.Unrecovered)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
@@ -0,0 +1,84 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// partialBlocks renders fractions of a character cell in eighths
+var partialBlocks = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// ChartBar is one labelled value of a terminal bar chart
+type ChartBar struct {
+	Label string
+	Value float64
+	Text  string // Value as displayed after the bar
+}
+
+// BarChart renders a horizontal Unicode bar chart scaled to width characters
+func BarChart(title string, bars []ChartBar, width int) string {
+	if width <= 0 {
+		width = 40
+	}
+
+	labelWidth := 0
+	maxValue := 0.0
+	for _, b := range bars {
+		if n := len([]rune(b.Label)); n > labelWidth {
+			labelWidth = n
+		}
+		if b.Value > maxValue {
+			maxValue = b.Value
+		}
+	}
+
+	var sb strings.Builder
+	if title != "" {
+		sb.WriteString(title + "\n")
+	}
+
+	for _, b := range bars {
+		eighths := 0
+		if maxValue > 0 && b.Value > 0 {
+			eighths = int(math.Round(b.Value / maxValue * float64(width*8)))
+		}
+		bar := strings.Repeat("█", eighths/8) + partialBlocks[eighths%8]
+		padding := strings.Repeat(" ", width-len([]rune(bar)))
+		fmt.Fprintf(&sb, "   %-*s │%s%s %s\n", labelWidth, b.Label, bar, padding, b.Text)
+	}
+
+	return sb.String()
+}
+
+// notMeasuredBar is the empty bar of a library no operation of which
+// succeeded, so it has no time to chart
+func notMeasuredBar(r BenchmarkResult, locale Locale) ChartBar {
+	return ChartBar{Label: r.Library, Text: locale.T("not_measured")}
+}
+
+// OpsPerSecChart charts the throughput of each library for one operation
+func OpsPerSecChart(group OperationGroup, locale Locale, width int) string {
+	bars := make([]ChartBar, 0, len(group.Results))
+	for _, r := range group.Results {
+		if r.AvgTime <= 0 {
+			bars = append(bars, notMeasuredBar(r, locale))
+			continue
+		}
+		bars = append(bars, ChartBar{Label: r.Library, Value: r.OpsPerSec, Text: fmt.Sprintf("%.1f", r.OpsPerSec)})
+	}
+	return BarChart(locale.Tf("chart_ops_per_sec", group.Operation), bars, width)
+}
+
+// P95Chart charts the P95 latency of each library for one operation
+func P95Chart(group OperationGroup, locale Locale, width int) string {
+	bars := make([]ChartBar, 0, len(group.Results))
+	for _, r := range group.Results {
+		if r.AvgTime <= 0 {
+			bars = append(bars, notMeasuredBar(r, locale))
+			continue
+		}
+		bars = append(bars, ChartBar{Label: r.Library, Value: float64(r.P95Time), Text: r.P95Time.String()})
+	}
+	return BarChart(locale.Tf("chart_p95", group.Operation), bars, width)
+}
@@ -0,0 +1,114 @@
+package benchmark
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"runtime"
+	"strings"
+
+	"go-database-comparison/pkg/buildinfo"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/gormsql"
+)
+
+// Environment describes the machine, toolchain and database a run was measured on
+type Environment struct {
+	// Hardware and software specs, kept when anonymizing
+	GoVersion     string         `json:"go_version"`
+	OS            string         `json:"os"`
+	Arch          string         `json:"arch"`
+	NumCPU        int            `json:"num_cpu"`
+	CPUModel      string         `json:"cpu_model,omitempty"`
+	ServerVersion string         `json:"server_version,omitempty"`
+	Build         buildinfo.Info `json:"build"`             // dbcompare and library versions
+	Network       string         `json:"network,omitempty"` // Emulated latency and loss, see BenchmarkConfig.Network
+	Layout        string         `json:"layout,omitempty"`  // Users table layout, see BenchmarkConfig.Layout
+
+	// Identifying details, stripped when anonymizing
+	Hostname string `json:"hostname,omitempty"`
+	DBHost   string `json:"db_host,omitempty"`
+	DBPort   int    `json:"db_port,omitempty"`
+	DBUser   string `json:"db_user,omitempty"`
+	DBName   string `json:"db_name,omitempty"`
+	DSN      string `json:"dsn,omitempty"` // Password is always redacted
+}
+
+// ResultsFile is the JSON document written for a benchmark run
+type ResultsFile struct {
+	RunID       string            `json:"run_id,omitempty"` // Marks the users the run created
+	Environment Environment       `json:"environment"`
+	Results     []BenchmarkResult `json:"results"`
+	Queries     []QueryStat       `json:"queries,omitempty"`
+	GORMSQL     []gormsql.Method  `json:"gorm_sql,omitempty"` // SQL GORM builds per repository method
+}
+
+// CollectEnvironment gathers environment metadata for a run. The PostgreSQL
+// server version is looked up on a best-effort basis and left empty on failure.
+func CollectEnvironment(ctx context.Context, dbConfig *database.DatabaseConfig) Environment {
+	env := Environment{
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		NumCPU:    runtime.NumCPU(),
+		CPUModel:  cpuModel(),
+		Build:     buildinfo.Get(),
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		env.Hostname = hostname
+	}
+
+	if dbConfig != nil {
+		redacted := *dbConfig
+		redacted.Password = "REDACTED"
+		env.DBHost = dbConfig.Host
+		env.DBPort = dbConfig.Port
+		env.DBUser = dbConfig.User
+		env.DBName = dbConfig.DBName
+		env.DSN = redacted.PostgreSQLDSN()
+
+		if db, err := database.ConnectWithPQ(ctx, dbConfig); err == nil {
+			db.QueryRowContext(ctx, "SHOW server_version").Scan(&env.ServerVersion)
+			db.Close()
+		}
+	}
+
+	return env
+}
+
+// Anonymize returns a copy with hostnames, DSNs and usernames removed while
+// keeping the hardware and software specs, so results can be shared publicly
+func (e Environment) Anonymize() Environment {
+	e.Hostname = ""
+	e.DBHost = ""
+	e.DBPort = 0
+	e.DBUser = ""
+	e.DBName = ""
+	e.DSN = ""
+	return e
+}
+
+// Anonymize returns a copy of the results file with anonymized environment metadata
+func (f ResultsFile) Anonymize() ResultsFile {
+	f.Environment = f.Environment.Anonymize()
+	return f
+}
+
+// cpuModel reads the CPU model name on Linux, returning "" elsewhere
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,192 @@
+package benchmark
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// ErrorCategory classifies why a benchmarked operation failed
+type ErrorCategory string
+
+const (
+	ErrorTimeout         ErrorCategory = "timeout"
+	ErrorUniqueViolation ErrorCategory = "unique_violation"
+	ErrorConnection      ErrorCategory = "connection"
+	ErrorOther           ErrorCategory = "other"
+)
+
+// ErrorBreakdown counts failed operations per error category
+type ErrorBreakdown struct {
+	Timeout         int `json:"timeout"`
+	UniqueViolation int `json:"unique_violation"`
+	Connection      int `json:"connection"`
+	Other           int `json:"other"`
+}
+
+// Total returns the number of errors across all categories
+func (b ErrorBreakdown) Total() int {
+	return b.Timeout + b.UniqueViolation + b.Connection + b.Other
+}
+
+// add increments the counter for the given category
+func (b *ErrorBreakdown) add(category ErrorCategory) {
+	switch category {
+	case ErrorTimeout:
+		b.Timeout++
+	case ErrorUniqueViolation:
+		b.UniqueViolation++
+	case ErrorConnection:
+		b.Connection++
+	default:
+		b.Other++
+	}
+}
+
+// ClassifyError inspects a (possibly wrapped) error from any of the three
+// libraries and maps it to an ErrorCategory. lib/pq errors surface as
+// *pq.Error while GORM's pgx driver returns *pgconn.PgError, so both are checked.
+// Errors of the HTTP layer benchmark are classified by their status.
+func ClassifyError(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return classifySQLState(string(pqErr.Code))
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return classifySQLState(pgErr.Code)
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return classifyHTTPStatus(statusErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return ErrorConnection
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return ErrorConnection
+	}
+
+	return ErrorOther
+}
+
+// SQLState returns the SQLSTATE code of a (possibly wrapped) PostgreSQL
+// error of any of the three libraries, or "" when err carries none
+func SQLState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// IsTransientError reports whether an operation that failed with err is
+// worth retrying: serialization failures, deadlocks and connection errors.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && isTransientSQLState(string(pqErr.Code)) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && isTransientSQLState(pgErr.Code) {
+		return true
+	}
+
+	return ClassifyError(err) == ErrorConnection
+}
+
+// isTransientSQLState reports whether a SQLSTATE code signals a retryable conflict
+func isTransientSQLState(code string) bool {
+	switch code {
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return true
+	default:
+		return false
+	}
+}
+
+// classifySQLState maps a PostgreSQL SQLSTATE code to an ErrorCategory
+func classifySQLState(code string) ErrorCategory {
+	switch {
+	case code == "23505":
+		return ErrorUniqueViolation
+	case code == "57014": // query_canceled, raised by statement_timeout and cancel requests
+		return ErrorTimeout
+	case strings.HasPrefix(code, "08"): // connection_exception class
+		return ErrorConnection
+	default:
+		return ErrorOther
+	}
+}
+
+// breakdownErrors classifies the errors of all failed samples
+func breakdownErrors(samples []opSample) ErrorBreakdown {
+	var breakdown ErrorBreakdown
+	for _, s := range samples {
+		if s.Err != nil {
+			breakdown.add(ClassifyError(s.Err))
+		}
+	}
+	return breakdown
+}
+
+// generateErrorSection renders the error breakdown section of the report
+func generateErrorSection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if result.Errors.Total() == 0 {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("error_section"))
+			section += loc.tableHeader("library", "operation", "timeout", "unique_violation", "connection", "other")
+		}
+		section += fmt.Sprintf("| %s | %s | %d | %d | %d | %d |\n",
+			result.Library, result.Operation, result.Errors.Timeout,
+			result.Errors.UniqueViolation, result.Errors.Connection, result.Errors.Other)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
@@ -0,0 +1,116 @@
+package benchmark
+
+import (
+	"sort"
+	"time"
+)
+
+// FailoverSample is one operation of a failover run, after its retries
+type FailoverSample struct {
+	Start    time.Time
+	Duration time.Duration
+	Attempts int
+	Err      error // Error of the last attempt, nil when the operation succeeded
+}
+
+// Outage is a run of consecutive failed operations of one library
+type Outage struct {
+	Start     time.Time      `json:"start"`      // Start of the first failed operation
+	Downtime  time.Duration  `json:"downtime"`   // From the end of the last success before to the end of the first success after
+	Recovery  time.Duration  `json:"recovery"`   // From the start of the first failed operation to the end of the first success after
+	FailedOps int            `json:"failed_ops"` // Size of the error burst
+	Recovered bool           `json:"recovered"`  // False when no operation succeeded until the end of the run
+	Errors    ErrorBreakdown `json:"errors"`
+}
+
+// FailoverResult tells how one library rode through a failover
+type FailoverResult struct {
+	Library       string         `json:"library"`
+	Operations    int            `json:"operations"`
+	Failed        int            `json:"failed"`
+	Retries       int            `json:"retries"`       // Attempts beyond the first, including those of operations that succeeded
+	MaskedErrors  int            `json:"masked_errors"` // Operations that succeeded only after a retry
+	MaxBurst      int            `json:"max_burst"`     // Most consecutive failed operations
+	TotalDowntime time.Duration  `json:"total_downtime"`
+	MaxDowntime   time.Duration  `json:"max_downtime"`
+	Outages       []Outage       `json:"outages"`
+	Errors        ErrorBreakdown `json:"errors"`
+}
+
+// Unrecovered reports whether the library was still failing when the run ended
+func (r FailoverResult) Unrecovered() bool {
+	return len(r.Outages) > 0 && !r.Outages[len(r.Outages)-1].Recovered
+}
+
+// AnalyzeFailover finds the outages in the samples of one library, ordered
+// by when the operations ended. An outage that lasts until the end of the
+// run counts its downtime up to the end of the last operation.
+func AnalyzeFailover(library string, samples []FailoverSample) FailoverResult {
+	sorted := append([]FailoverSample(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Add(sorted[i].Duration).Before(sorted[j].Start.Add(sorted[j].Duration))
+	})
+
+	result := FailoverResult{Library: library, Operations: len(sorted), Outages: []Outage{}}
+	var lastSuccess time.Time
+	var current *Outage
+	for _, s := range sorted {
+		end := s.Start.Add(s.Duration)
+		if s.Attempts > 1 {
+			result.Retries += s.Attempts - 1
+		}
+
+		if s.Err != nil {
+			category := ClassifyError(s.Err)
+			result.Failed++
+			result.Errors.add(category)
+			if current == nil {
+				current = &Outage{Start: s.Start}
+			}
+			current.FailedOps++
+			current.Errors.add(category)
+			continue
+		}
+
+		if s.Attempts > 1 {
+			result.MaskedErrors++
+		}
+		if current != nil {
+			current.Recovered = true
+			current.Recovery = end.Sub(current.Start)
+			current.Downtime = end.Sub(downtimeStart(lastSuccess, current.Start))
+			result.addOutage(*current)
+			current = nil
+		}
+		lastSuccess = end
+	}
+
+	if current != nil {
+		last := sorted[len(sorted)-1]
+		end := last.Start.Add(last.Duration)
+		current.Recovery = end.Sub(current.Start)
+		current.Downtime = end.Sub(downtimeStart(lastSuccess, current.Start))
+		result.addOutage(*current)
+	}
+	return result
+}
+
+// downtimeStart is the end of the last success before an outage, or the
+// outage's start when nothing succeeded before it
+func downtimeStart(lastSuccess, outageStart time.Time) time.Time {
+	if lastSuccess.IsZero() {
+		return outageStart
+	}
+	return lastSuccess
+}
+
+func (r *FailoverResult) addOutage(o Outage) {
+	r.Outages = append(r.Outages, o)
+	r.TotalDowntime += o.Downtime
+	if o.Downtime > r.MaxDowntime {
+		r.MaxDowntime = o.Downtime
+	}
+	if o.FailedOps > r.MaxBurst {
+		r.MaxBurst = o.FailedOps
+	}
+}
@@ -0,0 +1,350 @@
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/concurrency"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+)
+
+// HTTPOperationPrefix marks results measured through the REST server, so
+// they are reported next to the direct repository results of the same operation
+const HTTPOperationPrefix = "http_"
+
+// httpLibraryHeader selects the library of a request, see server.LibraryHeader
+const httpLibraryHeader = "X-DB-Library"
+
+// HTTPOperations lists the operations the HTTP layer benchmark supports
+var HTTPOperations = []string{"create", "read", "update", "delete", "search"}
+
+// HTTPTarget is a running REST server of pkg/server to benchmark through
+type HTTPTarget struct {
+	BaseURL string       // e.g. http://localhost:8080, without trailing slash
+	Client  *http.Client // http.DefaultClient when nil
+}
+
+// HTTPStatusError is the error of a request the server answered with a
+// non-2xx status. ClassifyError maps its status onto an error category.
+type HTTPStatusError struct {
+	StatusCode int
+	Message    string // The server's error message
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// classifyHTTPStatus maps the status of a failed request onto an ErrorCategory,
+// mirroring how the server maps repository errors onto statuses
+func classifyHTTPStatus(status int) ErrorCategory {
+	switch status {
+	case http.StatusConflict:
+		return ErrorUniqueViolation
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return ErrorTimeout
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return ErrorConnection
+	default:
+		return ErrorOther
+	}
+}
+
+// RunHTTPBenchmark runs the configured operations for all libraries through
+// the REST server at target instead of calling the repositories directly,
+// so latency includes routing, JSON encoding and the HTTP round trip.
+// Results are named with HTTPOperationPrefix. dbConfig only feeds the
+// environment metadata and may be nil for a remote server.
+func (pb *PerformanceBenchmark) RunHTTPBenchmark(ctx context.Context, target HTTPTarget, dbConfig *database.DatabaseConfig) error {
+	loc := pb.config.Locale
+	pb.logger().Info(loc.T("starting_http"),
+		"url", target.BaseURL, "iterations", pb.config.Iterations, "concurrency", pb.config.Concurrency)
+
+	for _, operation := range pb.config.OperationTypes {
+		if !isHTTPOperation(operation) {
+			return fmt.Errorf("operation %s is not supported through HTTP (supported: %s)",
+				operation, strings.Join(HTTPOperations, ", "))
+		}
+	}
+
+	env := CollectEnvironment(ctx, dbConfig)
+	pb.mu.Lock()
+	pb.environment = env
+	pb.mu.Unlock()
+
+	client := &httpClient{target: target, runID: pb.runID}
+	if client.target.Client == nil {
+		client.target.Client = http.DefaultClient
+	}
+
+	for _, library := range Libraries {
+		pb.logger().Info(loc.Tf("benchmarking", library), "library", library, "layer", "http")
+
+		if err := pb.benchmarkHTTPLibrary(ctx, client, library); err != nil {
+			return fmt.Errorf("HTTP benchmark failed for %s: %w", library, err)
+		}
+	}
+	return nil
+}
+
+// isHTTPOperation reports whether operation is one of HTTPOperations
+func isHTTPOperation(operation string) bool {
+	for _, op := range HTTPOperations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// benchmarkHTTPLibrary warms up and benchmarks every operation for one library
+func (pb *PerformanceBenchmark) benchmarkHTTPLibrary(ctx context.Context, client *httpClient, library string) error {
+	lib := strings.ToLower(library)
+
+	pb.logger().Debug(pb.config.Locale.Tf("warming_up", library), "library", library, "rounds", pb.config.WarmupRounds)
+	for i := 0; i < pb.config.WarmupRounds; i++ {
+		user, err := client.create(ctx, lib, "Warmup", i)
+		if err != nil {
+			return fmt.Errorf("warmup failed: %w", err)
+		}
+		client.delete(ctx, lib, user.ID)
+	}
+
+	for _, operation := range pb.config.OperationTypes {
+		result, err := pb.benchmarkHTTPOperation(ctx, client, library, operation)
+		if err != nil {
+			return fmt.Errorf("benchmark operation %s failed: %w", operation, err)
+		}
+
+		pb.mu.Lock()
+		pb.results = append(pb.results, result)
+		pb.mu.Unlock()
+
+		pb.logger().Info(pb.config.Locale.Tf("operation_done", library, result.Operation),
+			"library", library, "operation", result.Operation, "avg", result.AvgTime,
+			"ops_per_sec", result.OpsPerSec, "success_rate", result.SuccessRate)
+	}
+	return nil
+}
+
+// benchmarkHTTPOperation runs Iterations requests of one operation through
+// the worker pool. Read and update cycle over a few users created up front,
+// delete removes one user created up front per request; all of them are
+// cleaned up afterwards.
+func (pb *PerformanceBenchmark) benchmarkHTTPOperation(ctx context.Context, client *httpClient, library, operation string) (BenchmarkResult, error) {
+	lib := strings.ToLower(library)
+	name := HTTPOperationPrefix + operation
+
+	fixtures := 0
+	switch operation {
+	case "read", "update":
+		fixtures = 10
+	case "delete":
+		fixtures = pb.config.Iterations
+	}
+	ids := make([]int, 0, fixtures)
+	for i := 0; i < fixtures; i++ {
+		user, err := client.create(ctx, lib, "HTTPFixture", i)
+		if err != nil {
+			return BenchmarkResult{}, fmt.Errorf("failed to create fixture user: %w", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	defer func() {
+		for _, id := range ids {
+			client.delete(context.WithoutCancel(ctx), lib, id)
+		}
+	}()
+
+	created := make(chan int, pb.config.Iterations)
+
+	jobs := make([]concurrency.Job[opSample], 0, pb.config.Iterations)
+	for i := 0; i < pb.config.Iterations; i++ {
+		i := i
+		jobs = append(jobs, concurrency.Job[opSample]{
+			ID: i,
+			TaskFunc: func(jobCtx context.Context) (opSample, error) {
+				start := time.Now()
+				var err error
+				switch operation {
+				case "create":
+					var user *models.User
+					if user, err = client.create(jobCtx, lib, "HTTPBench", i); err == nil {
+						created <- user.ID
+					}
+				case "read":
+					_, err = client.get(jobCtx, lib, ids[i%len(ids)])
+				case "update":
+					age := 20 + i%50
+					_, err = client.update(jobCtx, lib, ids[i%len(ids)], &models.UpdateUserRequest{Age: &age})
+				case "delete":
+					err = client.delete(jobCtx, lib, ids[i])
+				case "search":
+					_, err = client.search(jobCtx, lib, "httpbench-"+lib)
+				}
+				return opSample{Start: start, Duration: time.Since(start), Err: err}, err
+			},
+			Timeout: pb.config.TimeoutPerOp,
+			Retry:   pb.retryPolicy(),
+			Labels:  map[string]string{concurrency.LabelLibrary: library, concurrency.LabelOperation: name},
+		})
+	}
+
+	results, poolStats, err := pb.runJobs(ctx, jobs)
+	close(created)
+	for id := range created {
+		ids = append(ids, id)
+	}
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+
+	samples := make([]opSample, 0, len(results))
+	for _, result := range results {
+		sample := result.Data
+		sample.Err = result.Error
+		sample.WorkerID = result.WorkerID
+		sample.Attempts = result.Attempts
+		samples = append(samples, sample)
+	}
+
+	result := pb.summarize(library, name, samples)
+	if poolStats != nil {
+		applyPoolStats(&result, *poolStats)
+	}
+	return result, nil
+}
+
+// httpClient calls the users API of a REST server
+type httpClient struct {
+	target HTTPTarget
+	runID  string // Marks the users it creates
+}
+
+// create creates a user with a unique email derived from prefix and i
+func (c *httpClient) create(ctx context.Context, lib, prefix string, i int) (*models.User, error) {
+	timestamp := time.Now().UnixNano() + int64(i)
+	req := &models.CreateUserRequest{
+		Name:  fmt.Sprintf("%s %s %d", prefix, lib, timestamp),
+		Email: benchdata.Email(c.runID, strings.ToLower(prefix), lib, timestamp),
+		Age:   25 + i%50,
+	}
+	var user models.User
+	if err := c.do(ctx, lib, http.MethodPost, "/users", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *httpClient) get(ctx context.Context, lib string, id int) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, lib, http.MethodGet, fmt.Sprintf("/users/%d", id), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *httpClient) update(ctx context.Context, lib string, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, lib, http.MethodPatch, fmt.Sprintf("/users/%d", id), req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *httpClient) delete(ctx context.Context, lib string, id int) error {
+	return c.do(ctx, lib, http.MethodDelete, fmt.Sprintf("/users/%d", id), nil, nil)
+}
+
+func (c *httpClient) search(ctx context.Context, lib, pattern string) ([]*models.User, error) {
+	var users []*models.User
+	if err := c.do(ctx, lib, http.MethodGet, "/users?email="+url.QueryEscape(pattern), nil, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// do sends one request for library lib and decodes the response into out.
+// The body is always drained so the connection is reused.
+func (c *httpClient) do(ctx context.Context, lib, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.target.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(httpLibraryHeader, lib)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.target.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	defer io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Message: apiErr.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// generateHTTPOverheadSection compares the average latency of operations
+// run both directly and through the REST server, per library
+func generateHTTPOverheadSection(results []BenchmarkResult, loc Locale) string {
+	direct := make(map[string]BenchmarkResult)
+	for _, result := range results {
+		if !strings.HasPrefix(result.Operation, HTTPOperationPrefix) {
+			direct[result.Library+"/"+result.Operation] = result
+		}
+	}
+
+	section := ""
+	for _, result := range results {
+		operation, ok := strings.CutPrefix(result.Operation, HTTPOperationPrefix)
+		if !ok {
+			continue
+		}
+		base, ok := direct[result.Library+"/"+operation]
+		if !ok || base.AvgTime <= 0 || result.AvgTime <= 0 {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("http_section"))
+			section += loc.tableHeader("library", "operation", "direct_avg", "http_avg", "http_overhead", "http_share")
+		}
+		overhead := result.AvgTime - base.AvgTime
+		section += fmt.Sprintf("| %s | %s | %v | %v | %v | %.1f%% |\n",
+			result.Library, operation, base.AvgTime, result.AvgTime, overhead,
+			float64(overhead)/float64(result.AvgTime)*100)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
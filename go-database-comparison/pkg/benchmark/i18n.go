@@ -0,0 +1,198 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Locale selects the language of reports and console output
+type Locale string
+
+const (
+	LocaleEnglish  Locale = "en"
+	LocaleJapanese Locale = "ja"
+	LocaleBoth     Locale = "both" // Bilingual output, English first
+)
+
+// ParseLocale converts a user-supplied language code into a Locale
+func ParseLocale(s string) (Locale, error) {
+	switch Locale(strings.ToLower(strings.TrimSpace(s))) {
+	case "", LocaleEnglish:
+		return LocaleEnglish, nil
+	case LocaleJapanese:
+		return LocaleJapanese, nil
+	case LocaleBoth:
+		return LocaleBoth, nil
+	default:
+		return "", fmt.Errorf("unsupported locale %q (expected en, ja or both)", s)
+	}
+}
+
+// messages holds the English and Japanese text for each message key
+var messages = map[string][2]string{
+	// Report
+	"report_title":       {"Go Database Libraries Performance Benchmark Report", "Go データベースライブラリ パフォーマンスベンチマークレポート"},
+	"configuration":      {"Configuration", "設定"},
+	"config_summary":     {"%d iterations, %d concurrent workers", "%d 回反復、%d 並行ワーカー"},
+	"build":              {"Build", "ビルド"},
+	"network":            {"Network", "ネットワーク"},
+	"layout":             {"Table layout", "テーブル構成"},
+	"operation_heading":  {"%s Operation", "%s 操作"},
+	"summary":            {"Summary", "サマリー"},
+	"library":            {"Library", "ライブラリ"},
+	"operation":          {"Operation", "操作"},
+	"avg_time":           {"Avg Time", "平均時間"},
+	"min_time":           {"Min Time", "最小時間"},
+	"max_time":           {"Max Time", "最大時間"},
+	"median":             {"Median", "中央値"},
+	"p95_time":           {"P95 Time", "P95 時間"},
+	"p99_time":           {"P99 Time", "P99 時間"},
+	"ops_per_sec":        {"Ops/Sec", "ops/秒"},
+	"success_rate":       {"Success Rate", "成功率"},
+	"errors":             {"Errors", "エラー"},
+	"winner":             {"Winner", "勝者"},
+	"vs_winner":          {"vs Winner", "勝者との差"},
+	"pool_section":       {"Connection Pool Contention", "コネクションプール競合"},
+	"acquire_avg":        {"Acquire Avg", "取得 平均"},
+	"acquire_p95":        {"Acquire P95", "取得 P95"},
+	"query_avg":          {"Query Avg", "クエリ 平均"},
+	"pool_waits":         {"Pool Waits", "プール待機回数"},
+	"pool_wait_time":     {"Pool Wait Time", "プール待機時間"},
+	"cancel_section":     {"Context Cancellation", "コンテキストキャンセル"},
+	"cancelled":          {"Cancelled", "キャンセル数"},
+	"aborted_server":     {"Aborted Server-Side", "サーバー側で中断"},
+	"cancel_latency_avg": {"Cancel Latency Avg", "キャンセル遅延 平均"},
+	"cancel_latency_p95": {"Cancel Latency P95", "キャンセル遅延 P95"},
+	"saturation_section": {"Connection Pool Saturation", "コネクションプール飽和"},
+	"in_flight":          {"In Flight / Conns", "同時実行数 / 接続数"},
+	"pool_wait_avg":      {"Pool Wait Avg", "プール待機 平均"},
+	"error_section":      {"Error Breakdown", "エラー内訳"},
+	"timeout":            {"Timeout", "タイムアウト"},
+	"unique_violation":   {"Unique Violation", "一意制約違反"},
+	"connection":         {"Connection", "接続"},
+	"other":              {"Other", "その他"},
+	"worker_section":     {"Per-Worker Latency", "ワーカー別レイテンシ"},
+	"worker":             {"Worker", "ワーカー"},
+	"ops":                {"Ops", "操作数"},
+	"queue_section":      {"Worker Pool Queue", "ワーカープールのキュー"},
+	"queue_wait_avg":     {"Queue Wait Avg", "キュー待機 平均"},
+	"queue_wait_p95":     {"Queue Wait P95", "キュー待機 P95"},
+	"utilization":        {"Utilization", "稼働率"},
+	"query_section":      {"Statements per Operation", "操作あたりのステートメント数"},
+	"queries_per_op":     {"Queries/Op", "クエリ数/操作"},
+	"queries_issued":     {"Appendix: Queries Issued", "付録: 発行されたクエリ"},
+	"fingerprint":        {"Fingerprint", "フィンガープリント"},
+	"executions":         {"Executions", "実行回数"},
+	"statement":          {"Statement", "ステートメント"},
+	"gorm_sql":           {"Appendix: SQL GORM Builds", "付録: GORM が組み立てる SQL"},
+	"method":             {"Method", "メソッド"},
+	"gorm_dry_run":       {"GORM (DryRun)", "GORM (DryRun)"},
+	"hand_written":       {"Hand-Written", "手書き"},
+	"note":               {"Note", "備考"},
+	"http_section":       {"HTTP Layer Overhead", "HTTP レイヤーのオーバーヘッド"},
+	"direct_avg":         {"Direct Avg", "直接呼び出し 平均"},
+	"http_avg":           {"HTTP Avg", "HTTP 平均"},
+	"http_overhead":      {"Overhead", "オーバーヘッド"},
+	"http_share":         {"Share of HTTP Time", "HTTP 時間に占める割合"},
+	"charts":             {"Average Latency by Operation", "操作別平均レイテンシ"},
+	"heatmaps":           {"Latency Heatmaps", "レイテンシヒートマップ"},
+	"band":               {"Band", "帯域"},
+	"no_timeline":        {"No timeline data recorded.", "タイムラインデータは記録されていません。"},
+	"chaos_section":      {"Chaos: Connection Kills", "カオス: 接続の強制切断"},
+	"chaos_kills":        {"Kills", "切断回数"},
+	"chaos_failed":       {"Failed Before Recovery", "復旧前の失敗数"},
+	"recovery_avg":       {"Recovery Avg", "復旧時間 平均"},
+	"recovery_max":       {"Recovery Max", "復旧時間 最大"},
+	"unrecovered":        {"Unrecovered", "未復旧"},
+	"matrix_title":       {"PostgreSQL Matrix Report", "PostgreSQL マトリクスレポート"},
+	"target":             {"Target", "ターゲット"},
+	"address":            {"Address", "アドレス"},
+	"server_version":     {"Server Version", "サーバーバージョン"},
+	"status":             {"Status", "状態"},
+	"matrix_results":     {"%d results", "%d 件の結果"},
+	"matrix_cell_note":   {"Cells show the average time, in parentheses relative to %s.", "セルは平均時間を示し、括弧内は %s との差です。"},
+
+	// Log messages, details are attached as attributes
+	"starting_benchmark": {"Starting comprehensive performance benchmark", "総合パフォーマンスベンチマークを開始します"},
+	"starting_http":      {"Starting HTTP layer benchmark", "HTTP レイヤーのベンチマークを開始します"},
+	"benchmarking":       {"Benchmarking %s", "%s をベンチマーク中"},
+	"sweep_level":        {"Sweeping concurrency %d", "並行数 %d で計測中"},
+	"chaos_enabled":      {"Chaos mode: terminating connections periodically", "カオスモード: 接続を定期的に強制切断します"},
+	"warming_up":         {"Warming up %s", "%s をウォームアップ中"},
+	"operation_done":     {"%s %s done", "%s %s 完了"},
+
+	// Console output
+	"results_summary":    {"Performance Results Summary:", "パフォーマンス結果サマリー:"},
+	"library_results":    {"%s Results:", "%s の結果:"},
+	"comparison_summary": {"Performance Comparison Summary:", "パフォーマンス比較サマリー:"},
+	"operation_winner":   {"%s Operation Winner:", "%s 操作の勝者:"},
+	"fastest":            {"   Fastest: %s (%v avg)", "   最速: %s (平均 %v)"},
+	"highest_throughput": {"   Highest Throughput: %s (%.1f ops/sec)", "   最高スループット: %s (%.1f ops/秒)"},
+	"charts_heading":     {"Charts:", "グラフ:"},
+	"chart_ops_per_sec":  {"%s: Ops/Sec (higher is better)", "%s: ops/秒 (高いほど良い)"},
+	"chart_p95":          {"%s: P95 Latency (lower is better)", "%s: P95 レイテンシ (低いほど良い)"},
+	"not_measured":       {"not measured", "未計測"},
+	"regressions":        {"Regressions against %s (more than %.1f%% slower):", "%s に対する性能劣化 (%.1f%% を超える低下):"},
+	"regression":         {"   %s %s: %v → %v (+%.1f%%)", "   %s %s: %v → %v (+%.1f%%)"},
+	"no_regressions":     {"No regressions against %s", "%s に対する性能劣化はありません"},
+	"recommendations":    {"Performance Recommendations:", "パフォーマンスに関する推奨事項:"},
+	"rec_learning":       {"   For Learning/Prototyping:", "   学習・プロトタイピング向け:"},
+	"rec_learning_gorm":  {"      → GORM: Rich ORM features, rapid development", "      → GORM: 豊富な ORM 機能、迅速な開発"},
+	"rec_performance":    {"   For High Performance:", "   高パフォーマンス向け:"},
+	"rec_performance_pq": {"      → PQ: Raw SQL control, minimal overhead", "      → PQ: 生 SQL による制御、最小限のオーバーヘッド"},
+	"rec_balanced":       {"   For Balanced Approach:", "   バランス重視:"},
+	"rec_balanced_sqlx":  {"      → SQLX: Struct mapping + SQL flexibility", "      → SQLX: 構造体マッピング + SQL の柔軟性"},
+	"rec_enterprise":     {"   For Enterprise Applications:", "   エンタープライズアプリケーション向け:"},
+	"rec_context":        {"      → Context: All libraries support proper context handling", "      → Context: すべてのライブラリが適切な context 処理に対応"},
+	"rec_scaling":        {"      → Scaling: Choose based on specific bottlenecks", "      → スケーリング: 具体的なボトルネックに応じて選択"},
+	"rec_insights":       {"   Performance Insights:", "   パフォーマンスの考察:"},
+	"rec_insight_pq":     {"      → Raw SQL (PQ) typically fastest for simple operations", "      → 単純な操作では生 SQL (PQ) が一般的に最速"},
+	"rec_insight_sqlx":   {"      → SQLX provides good balance of performance and usability", "      → SQLX はパフォーマンスと使いやすさのバランスが良い"},
+	"rec_insight_gorm":   {"      → GORM adds overhead but improves development velocity", "      → GORM はオーバーヘッドがあるが開発速度を向上させる"},
+}
+
+// T returns the localized text for key. In LocaleBoth the English and
+// Japanese texts are joined with " / ". Unknown keys are returned as-is.
+func (l Locale) T(key string) string {
+	msg, ok := messages[key]
+	if !ok {
+		return key
+	}
+	switch l {
+	case LocaleJapanese:
+		return msg[1]
+	case LocaleBoth:
+		return msg[0] + " / " + strings.TrimSpace(msg[1])
+	default:
+		return msg[0]
+	}
+}
+
+// Tf formats the localized text for key with args. In LocaleBoth each
+// language is formatted separately so format verbs are not doubled.
+func (l Locale) Tf(key string, args ...interface{}) string {
+	msg, ok := messages[key]
+	if !ok {
+		return fmt.Sprintf("%s %v", key, args)
+	}
+	switch l {
+	case LocaleJapanese:
+		return fmt.Sprintf(msg[1], args...)
+	case LocaleBoth:
+		return fmt.Sprintf(msg[0], args...) + " / " + strings.TrimSpace(fmt.Sprintf(msg[1], args...))
+	default:
+		return fmt.Sprintf(msg[0], args...)
+	}
+}
+
+// tableHeader renders a localized markdown table header with its separator row
+func (l Locale) tableHeader(keys ...string) string {
+	header := "|"
+	separator := "|"
+	for _, key := range keys {
+		label := l.T(key)
+		header += " " + label + " |"
+		separator += strings.Repeat("-", len([]rune(label))+2) + "|"
+	}
+	return header + "\n" + separator + "\n"
+}
@@ -0,0 +1,113 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MatrixRun is the outcome of the benchmark on one target of a matrix run
+type MatrixRun struct {
+	Target  string `json:"target"`  // Name the target was given, e.g. "pg16"
+	Address string `json:"address"` // host:port/dbname
+	ResultsFile
+	Error string `json:"error,omitempty"` // Why the target has no results
+}
+
+// MatrixFile is the JSON document of a matrix run: the same benchmark run
+// against several databases, typically one per PostgreSQL version
+type MatrixFile struct {
+	RunID   string      `json:"run_id,omitempty"`
+	Targets []MatrixRun `json:"targets"`
+}
+
+// matrixCell finds the result of library and operation on run
+func matrixCell(run MatrixRun, library, operation string) (BenchmarkResult, bool) {
+	for _, result := range run.Results {
+		if result.Library == library && result.Operation == operation {
+			return result, true
+		}
+	}
+	return BenchmarkResult{}, false
+}
+
+// GenerateMatrixReport renders a markdown report of file with one table per
+// operation, a row per library and a column per target. Cells show the
+// average time and how it compares with the first target that has results,
+// so the first target serves as the baseline the others are read against.
+func GenerateMatrixReport(file MatrixFile, operationOrder []string, locale Locale) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", locale.T("matrix_title"))
+	if file.RunID != "" {
+		fmt.Fprintf(&b, "**Run ID**: %s\n\n", file.RunID)
+	}
+
+	b.WriteString(locale.tableHeader("target", "address", "server_version", "build", "status"))
+	var measured []MatrixRun
+	var all []BenchmarkResult
+	for _, run := range file.Targets {
+		status := locale.Tf("matrix_results", len(run.Results))
+		if run.Error != "" {
+			status = "❌ " + run.Error
+		} else {
+			measured = append(measured, run)
+			all = append(all, run.Results...)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			run.Target, run.Address, orDash(run.Environment.ServerVersion), orDash(run.Environment.Build.Version), status)
+	}
+	b.WriteString("\n")
+	if len(measured) == 0 {
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%s\n\n", locale.Tf("matrix_cell_note", measured[0].Target))
+	for _, group := range GroupByOperation(all, operationOrder) {
+		fmt.Fprintf(&b, "## %s\n\n", locale.Tf("operation_heading", group.Operation))
+
+		b.WriteString("| " + locale.T("library") + " |")
+		separator := "|" + strings.Repeat("-", len([]rune(locale.T("library")))+2) + "|"
+		for _, run := range measured {
+			b.WriteString(" " + run.Target + " |")
+			separator += strings.Repeat("-", len([]rune(run.Target))+2) + "|"
+		}
+		b.WriteString("\n" + separator + "\n")
+
+		for _, library := range Libraries {
+			row := "| " + library + " |"
+			found := false
+			var baseline time.Duration
+			for i, run := range measured {
+				result, ok := matrixCell(run, library, group.Operation)
+				if !ok || result.AvgTime <= 0 {
+					row += " - |"
+					continue
+				}
+				found = true
+				if i == 0 {
+					baseline = result.AvgTime
+					row += fmt.Sprintf(" %v |", result.AvgTime)
+					continue
+				}
+				relative := "-"
+				if baseline > 0 {
+					relative = fmt.Sprintf("%+.1f%%", (float64(result.AvgTime)-float64(baseline))/float64(baseline)*100)
+				}
+				row += fmt.Sprintf(" %v (%s) |", result.AvgTime, relative)
+			}
+			if found {
+				b.WriteString(row + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// orDash returns s, or "-" for an empty table cell
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package benchmark
+
+import (
+	"sort"
+)
+
+// Libraries lists the benchmarked libraries in their canonical report order
+var Libraries = []string{"PQ", "SQLX", "GORM"}
+
+// OperationGroup holds the results of one operation across libraries
+type OperationGroup struct {
+	Operation string
+	Results   []BenchmarkResult
+	Winner    string // Library with the lowest average time, empty if none succeeded
+}
+
+// RelativeToWinner returns how much slower result is than the group winner, in percent
+func (g OperationGroup) RelativeToWinner(result BenchmarkResult) float64 {
+	for _, r := range g.Results {
+		if r.Library == g.Winner && r.AvgTime > 0 {
+			return (float64(result.AvgTime) - float64(r.AvgTime)) / float64(r.AvgTime) * 100
+		}
+	}
+	return 0
+}
+
+// GroupByOperation groups results by operation in a stable order: operations
+// follow operationOrder (unknown operations sort alphabetically after it, the
+// results of a concurrency sweep by concurrency after their operation) and
+// libraries within a group follow Libraries, so reports diff cleanly between runs.
+func GroupByOperation(results []BenchmarkResult, operationOrder []string) []OperationGroup {
+	groups := make(map[string]*OperationGroup)
+	var names []string
+	for _, result := range results {
+		g, ok := groups[result.Operation]
+		if !ok {
+			g = &OperationGroup{Operation: result.Operation}
+			groups[result.Operation] = g
+			names = append(names, result.Operation)
+		}
+		g.Results = append(g.Results, result)
+	}
+
+	opRank := rankOf(operationOrder)
+	sort.SliceStable(names, func(i, j int) bool {
+		// Swept results follow their operation's rank, by concurrency
+		opI, levelI := SplitSweepOperation(names[i])
+		opJ, levelJ := SplitSweepOperation(names[j])
+		if opI != opJ {
+			return lessByRank(opRank, opI, opJ)
+		}
+		return levelI < levelJ
+	})
+
+	libRank := rankOf(Libraries)
+	ordered := make([]OperationGroup, 0, len(names))
+	for _, name := range names {
+		g := groups[name]
+		sort.SliceStable(g.Results, func(i, j int) bool {
+			return lessByRank(libRank, g.Results[i].Library, g.Results[j].Library)
+		})
+
+		var best *BenchmarkResult
+		for i := range g.Results {
+			r := &g.Results[i]
+			if r.AvgTime <= 0 {
+				continue
+			}
+			if best == nil || r.AvgTime < best.AvgTime {
+				best = r
+			}
+		}
+		if best != nil {
+			g.Winner = best.Library
+		}
+
+		ordered = append(ordered, *g)
+	}
+
+	return ordered
+}
+
+// rankOf maps each name to its position in order
+func rankOf(order []string) map[string]int {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+	return rank
+}
+
+// lessByRank orders known names by rank and unknown names alphabetically after them
+func lessByRank(rank map[string]int, a, b string) bool {
+	ra, okA := rank[a]
+	rb, okB := rank[b]
+	switch {
+	case okA && okB:
+		return ra < rb
+	case okA:
+		return true
+	case okB:
+		return false
+	default:
+		return a < b
+	}
+}
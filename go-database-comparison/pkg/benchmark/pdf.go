@@ -0,0 +1,94 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ErrNoPDFConverter is returned when no HTML-to-PDF converter is installed
+var ErrNoPDFConverter = errors.New("no HTML-to-PDF converter found (install chromium, google-chrome or wkhtmltopdf)")
+
+// chromeBinaries are the headless Chrome/Chromium executables tried in order
+var chromeBinaries = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"}
+
+// ExportPDF converts an HTML report into a single PDF file. The HTML is
+// printed with headless Chrome/Chromium when available, falling back to
+// wkhtmltopdf, so charts and heatmaps keep their styling. Chrome runs
+// without its sandbox when noSandbox is set, or when running as root or in
+// a container, where the sandbox cannot start.
+func ExportPDF(ctx context.Context, htmlReport, outputPath string, noSandbox bool) error {
+	absOutput, err := filepath.Abs(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve PDF output path: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "benchmark-report-*.html")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary HTML file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(htmlReport); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary HTML file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary HTML file: %w", err)
+	}
+
+	cmd, err := pdfCommand(ctx, tmp.Name(), absOutput, noSandbox || !sandboxAvailable())
+	if err != nil {
+		return err
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("PDF conversion with %s failed: %w: %s", filepath.Base(cmd.Path), err, output)
+	}
+
+	return nil
+}
+
+// containerMarkers are files Docker and Podman create in their containers
+var containerMarkers = []string{"/.dockerenv", "/run/.containerenv"}
+
+// sandboxAvailable reports whether Chrome's sandbox can start: Chrome
+// refuses it to root, and containers usually lack the namespaces it needs
+func sandboxAvailable() bool {
+	if os.Geteuid() == 0 {
+		return false
+	}
+	for _, marker := range containerMarkers {
+		if _, err := os.Stat(marker); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// pdfCommand builds the converter invocation for the first available tool
+func pdfCommand(ctx context.Context, htmlPath, pdfPath string, noSandbox bool) (*exec.Cmd, error) {
+	for _, name := range chromeBinaries {
+		if path, err := exec.LookPath(name); err == nil {
+			args := []string{"--headless", "--disable-gpu"}
+			if noSandbox {
+				args = append(args, "--no-sandbox")
+			}
+			args = append(args,
+				"--no-pdf-header-footer",
+				"--print-to-pdf="+pdfPath,
+				"file://"+htmlPath,
+			)
+			return exec.CommandContext(ctx, path, args...), nil
+		}
+	}
+
+	if path, err := exec.LookPath("wkhtmltopdf"); err == nil {
+		return exec.CommandContext(ctx, path, "--quiet", "--enable-local-file-access", htmlPath, pdfPath), nil
+	}
+
+	return nil, ErrNoPDFConverter
+}
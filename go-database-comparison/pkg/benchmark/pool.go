@@ -0,0 +1,105 @@
+package benchmark
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go-database-comparison/pkg/concurrency"
+)
+
+// acquireSample holds the timings of a single connection checkout
+type acquireSample struct {
+	opSample // Duration is the acquisition time
+	query    time.Duration
+}
+
+// benchmarkConnAcquire measures how long it takes to check out a connection
+// from the library's pool under concurrent load. The time spent waiting for a
+// connection is reported separately from the time spent running the query,
+// so pool contention can be told apart from database latency.
+func (pb *PerformanceBenchmark) benchmarkConnAcquire(ctx context.Context, library string, sqlDB *sql.DB) (BenchmarkResult, error) {
+	if sqlDB == nil {
+		return BenchmarkResult{}, fmt.Errorf("no sql.DB available for %s", library)
+	}
+
+	samples := make([]opSample, 0, pb.config.Iterations)
+	var queryTotal time.Duration
+	queryCount := 0
+
+	statsBefore := sqlDB.Stats()
+
+	jobs := make([]concurrency.Job[acquireSample], 0, pb.config.Iterations)
+	for i := 0; i < pb.config.Iterations; i++ {
+		jobs = append(jobs, concurrency.Job[acquireSample]{
+			ID: i,
+			TaskFunc: func(jobCtx context.Context) (acquireSample, error) {
+				start := time.Now()
+				conn, err := sqlDB.Conn(jobCtx)
+				acquire := time.Since(start)
+				if err != nil {
+					return acquireSample{opSample: opSample{Start: start, Duration: acquire}}, err
+				}
+				defer conn.Close()
+
+				var one int
+				queryStart := time.Now()
+				err = conn.QueryRowContext(jobCtx, "SELECT 1").Scan(&one)
+				query := time.Since(queryStart)
+
+				return acquireSample{opSample: opSample{Start: start, Duration: acquire}, query: query}, err
+			},
+			Timeout: pb.config.TimeoutPerOp,
+		})
+	}
+
+	results, poolStats, err := concurrency.RunJobs(ctx, pb.config.Concurrency, jobs, pb.poolOptions()...)
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+
+	for _, result := range results {
+		sample := result.Data
+		sample.Err = result.Error
+		sample.WorkerID = result.WorkerID
+		samples = append(samples, sample.opSample)
+		if result.Error == nil {
+			queryTotal += sample.query
+			queryCount++
+		}
+	}
+
+	statsAfter := sqlDB.Stats()
+
+	benchResult := pb.summarize(library, "conn_acquire", samples)
+	if queryCount > 0 {
+		benchResult.QueryAvgTime = queryTotal / time.Duration(queryCount)
+	}
+	benchResult.PoolWaitCount = statsAfter.WaitCount - statsBefore.WaitCount
+	benchResult.PoolWaitDuration = statsAfter.WaitDuration - statsBefore.WaitDuration
+	applyPoolStats(&benchResult, poolStats)
+
+	return benchResult, nil
+}
+
+// generatePoolContentionSection renders the connection pool section of the report
+func generatePoolContentionSection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if operation, _ := SplitSweepOperation(result.Operation); operation != "conn_acquire" {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("pool_section"))
+			section += loc.tableHeader("library", "acquire_avg", "acquire_p95", "query_avg", "pool_waits", "pool_wait_time")
+		}
+		section += fmt.Sprintf("| %s | %v | %v | %v | %d | %v |\n",
+			sweepLabel(result), result.AvgTime, result.P95Time, result.QueryAvgTime,
+			result.PoolWaitCount, result.PoolWaitDuration)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
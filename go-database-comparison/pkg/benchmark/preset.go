@@ -0,0 +1,85 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Preset is a named benchmark configuration sized for a time budget, so
+// common runs don't have to be configured flag by flag
+type Preset struct {
+	Name             string
+	Description      string
+	Iterations       int
+	Concurrency      int
+	WarmupRounds     int
+	OperationTypes   []string
+	DataSize         int
+	ConcurrencySweep []int         // Concurrency levels every operation is run at, none for a single pass
+	Timeout          time.Duration // Time limit of the whole run, with headroom over its usual duration
+}
+
+// Presets lists the available presets from the shortest to the longest run
+var Presets = []Preset{
+	{
+		Name:           "quick",
+		Description:    "smoke test of create and read, about a minute",
+		Iterations:     100,
+		Concurrency:    4,
+		WarmupRounds:   10,
+		OperationTypes: []string{"create", "read"},
+		DataSize:       100,
+		Timeout:        5 * time.Minute,
+	},
+	{
+		Name:           "standard",
+		Description:    "all CRUD operations and pool acquisition, about ten minutes",
+		Iterations:     1000,
+		Concurrency:    10,
+		WarmupRounds:   100,
+		OperationTypes: []string{"create", "read", "update", "delete", "batch_create", "search", "conn_acquire"},
+		DataSize:       1000,
+		Timeout:        30 * time.Minute,
+	},
+	{
+		Name:             "exhaustive",
+		Description:      "every operation at concurrency 1 to 64, several hours",
+		Iterations:       10000,
+		Concurrency:      10,
+		WarmupRounds:     500,
+		OperationTypes:   []string{"create", "read", "update", "delete", "batch_create", "search", "age_buckets", "conn_acquire", "cancel"},
+		DataSize:         100000,
+		ConcurrencySweep: []int{1, 2, 4, 8, 16, 32, 64},
+		Timeout:          12 * time.Hour,
+	},
+}
+
+// LookupPreset returns the preset called name
+func LookupPreset(name string) (Preset, error) {
+	for _, preset := range Presets {
+		if preset.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return preset, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (expected %s)", name, strings.Join(PresetNames(), ", "))
+}
+
+// PresetNames returns the names of Presets, in order
+func PresetNames() []string {
+	names := make([]string, len(Presets))
+	for i, preset := range Presets {
+		names[i] = preset.Name
+	}
+	return names
+}
+
+// Apply sets the fields of config the preset bundles
+func (p Preset) Apply(config *BenchmarkConfig) {
+	config.Iterations = p.Iterations
+	config.Concurrency = p.Concurrency
+	config.WarmupRounds = p.WarmupRounds
+	config.OperationTypes = append([]string(nil), p.OperationTypes...)
+	config.DataSize = p.DataSize
+	config.ConcurrencySweep = append([]int(nil), p.ConcurrencySweep...)
+}
@@ -0,0 +1,137 @@
+package benchmark
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go-database-comparison/pkg/gormsql"
+	"go-database-comparison/pkg/querycount"
+	"go-database-comparison/pkg/sqlnorm"
+)
+
+// generateQuerySection renders how many SQL statements each operation
+// executed on average, to show round trips an ORM adds behind one call
+func generateQuerySection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if result.QueriesPerOp == 0 {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("query_section"))
+			section += loc.tableHeader("library", "operation", "queries_per_op")
+		}
+		section += fmt.Sprintf("| %s | %s | %.2f |\n",
+			result.Library, result.Operation, result.QueriesPerOp)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
+
+// QueryStat is one distinct statement a library issued during the run
+type QueryStat struct {
+	Library     string `json:"library"`
+	Fingerprint string `json:"fingerprint"`
+	Statement   string `json:"statement"` // Normalized, without literal values
+	Executions  int64  `json:"executions"`
+}
+
+// addQueryStats records the statements counted for library, most executed first
+func (pb *PerformanceBenchmark) addQueryStats(library string, counter *querycount.Counter) {
+	var stats []QueryStat
+	for statement, n := range counter.Statements() {
+		stats = append(stats, QueryStat{
+			Library:     library,
+			Fingerprint: sqlnorm.Fingerprint(statement),
+			Statement:   statement,
+			Executions:  n,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Executions != stats[j].Executions {
+			return stats[i].Executions > stats[j].Executions
+		}
+		return stats[i].Statement < stats[j].Statement
+	})
+
+	pb.mu.Lock()
+	pb.queries = append(pb.queries, stats...)
+	pb.mu.Unlock()
+}
+
+// QueryStats returns the distinct statements each library issued
+func (pb *PerformanceBenchmark) QueryStats() []QueryStat {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+
+	return append([]QueryStat(nil), pb.queries...)
+}
+
+// generateQueriesIssuedSection renders the appendix listing every distinct
+// statement by library, so the SQL behind the numbers can be reviewed
+func generateQueriesIssuedSection(stats []QueryStat, loc Locale) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	section := fmt.Sprintf("## %s\n\n", loc.T("queries_issued"))
+	section += loc.tableHeader("library", "fingerprint", "executions", "statement")
+	for _, stat := range stats {
+		section += fmt.Sprintf("| %s | `%s` | %d | `%s` |\n",
+			stat.Library, stat.Fingerprint, stat.Executions, strings.ReplaceAll(stat.Statement, "|", `\|`))
+	}
+	return section + "\n"
+}
+
+// SetGORMSQL sets the SQL GORM builds for each repository method next to
+// the hand-written SQL, see gormsql.Extract, for the report appendix
+func (pb *PerformanceBenchmark) SetGORMSQL(methods []gormsql.Method) {
+	pb.mu.Lock()
+	pb.gormSQL = append([]gormsql.Method(nil), methods...)
+	pb.mu.Unlock()
+}
+
+// GORMSQL returns the SQL GORM builds for each repository method
+func (pb *PerformanceBenchmark) GORMSQL() []gormsql.Method {
+	pb.mu.RLock()
+	defer pb.mu.RUnlock()
+
+	return append([]gormsql.Method(nil), pb.gormSQL...)
+}
+
+// generateGORMSQLSection renders the appendix setting the statements GORM
+// builds for each repository method next to the hand-written ones, so what
+// the ORM emits can be read against the SQL it replaces
+func generateGORMSQLSection(methods []gormsql.Method, loc Locale) string {
+	if len(methods) == 0 {
+		return ""
+	}
+	section := fmt.Sprintf("## %s\n\n", loc.T("gorm_sql"))
+	section += loc.tableHeader("method", "gorm_dry_run", "hand_written", "note")
+	for _, m := range methods {
+		handWritten := "-"
+		if m.By != "" {
+			handWritten = m.By + ": " + codeList(m.HandWritten)
+		}
+		note := "-"
+		if m.Note != "" {
+			note = strings.ReplaceAll(m.Note, "|", `\|`)
+		}
+		section += fmt.Sprintf("| %s | %s | %s | %s |\n", m.Method, codeList(m.GORM), handWritten, note)
+	}
+	return section + "\n"
+}
+
+// codeList renders statements as inline code, one per line of a table cell
+func codeList(statements []string) string {
+	if len(statements) == 0 {
+		return "-"
+	}
+	cells := make([]string, len(statements))
+	for i, statement := range statements {
+		cells[i] = "`" + strings.ReplaceAll(statement, "|", `\|`) + "`"
+	}
+	return strings.Join(cells, "<br>")
+}
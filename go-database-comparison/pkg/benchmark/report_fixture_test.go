@@ -0,0 +1,181 @@
+package benchmark_test
+
+import (
+	"time"
+
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/buildinfo"
+	"go-database-comparison/pkg/gormsql"
+	"go-database-comparison/pkg/partition"
+)
+
+// goldenConfig returns the report settings the golden reports are rendered with
+func goldenConfig(locale benchmark.Locale) *benchmark.BenchmarkConfig {
+	config := benchmark.DefaultBenchmarkConfig()
+	config.Locale = locale
+	config.RunID = "20250101-000000-0000"
+	config.OperationTypes = append(config.OperationTypes, "cancel", "saturation")
+	return config
+}
+
+// goldenFixture returns the synthetic run the golden reports are rendered from.
+// Its results come out of library and operation order and fill in every
+// optional report section.
+func goldenFixture() benchmark.ResultsFile {
+	create := []benchmark.BenchmarkResult{
+		fixtureResult("SQLX", "create", 950*time.Microsecond),
+		fixtureResult("GORM", "create", 1400*time.Microsecond),
+		fixtureResult("PQ", "create", 900*time.Microsecond),
+	}
+	create[0].RetryCount = 3
+	create[1].ErrorCount = 5
+	create[1].SuccessRate = 99.5
+	create[1].Errors = benchmark.ErrorBreakdown{Timeout: 1, UniqueViolation: 3, Other: 1}
+	create[1].QueueWaitAvg = 40 * time.Microsecond
+	create[1].QueueWaitP95 = 120 * time.Microsecond
+	create[1].WorkerUtilization = 0.875
+	create[1].Workers = []benchmark.WorkerStats{
+		{WorkerID: 0, Operations: 520, AvgTime: 1300 * time.Microsecond, MedianTime: 1200 * time.Microsecond, P95Time: 2600 * time.Microsecond, P99Time: 4 * time.Millisecond, MaxTime: 6 * time.Millisecond},
+		{WorkerID: 1, Operations: 480, Errors: 5, AvgTime: 1500 * time.Microsecond, MedianTime: 1400 * time.Microsecond, P95Time: 3 * time.Millisecond, P99Time: 5 * time.Millisecond, MaxTime: 8 * time.Millisecond},
+	}
+	create[2].Timeline = []benchmark.TimeWindow{
+		{Offset: 0, Operations: 400, OpsPerSec: 400, P50Time: 800 * time.Microsecond, P90Time: 1200 * time.Microsecond, P99Time: 2 * time.Millisecond, MaxTime: 3 * time.Millisecond},
+		{Offset: time.Second, Operations: 350, Errors: 7, OpsPerSec: 350, ErrorRate: 2, P50Time: 900 * time.Microsecond, P90Time: 1500 * time.Microsecond, P99Time: 3 * time.Millisecond, MaxTime: 9 * time.Millisecond},
+		{Offset: 2 * time.Second, Operations: 250, OpsPerSec: 250, P50Time: 850 * time.Microsecond, P90Time: 1300 * time.Microsecond, P99Time: 2500 * time.Microsecond, MaxTime: 4 * time.Millisecond},
+	}
+
+	read := []benchmark.BenchmarkResult{
+		fixtureResult("GORM", "read", 412*time.Microsecond),
+		fixtureResult("PQ", "read", 180*time.Microsecond),
+		fixtureResult("SQLX", "read", 195*time.Microsecond),
+	}
+	for i := range read {
+		read[i].QueriesPerOp = 1
+	}
+
+	// Every GORM update failed, so it cannot be compared with the winner
+	update := []benchmark.BenchmarkResult{
+		fixtureResult("PQ", "update", 700*time.Microsecond),
+		fixtureResult("SQLX", "update", 650*time.Microsecond),
+		{Library: "GORM", Operation: "update", Iterations: 1000, ErrorCount: 1000, Errors: benchmark.ErrorBreakdown{Connection: 1000}},
+	}
+
+	search := []benchmark.BenchmarkResult{
+		fixtureResult("PQ", "search", 2100*time.Microsecond),
+		fixtureResult("GORM", "search", 2600*time.Microsecond),
+		fixtureResult("SQLX", "search", 2200*time.Microsecond),
+	}
+	search[2].Chaos = &benchmark.ChaosStats{Kills: 2, FailedOps: 14, RecoveryAvg: 35 * time.Millisecond, RecoveryMax: 52 * time.Millisecond}
+	search[2].Errors = benchmark.ErrorBreakdown{Connection: 14}
+	search[2].ErrorCount = 14
+	search[2].SuccessRate = 98.6
+
+	connAcquire := []benchmark.BenchmarkResult{
+		fixtureResult("PQ", "conn_acquire", 120*time.Microsecond),
+		fixtureResult("SQLX", "conn_acquire", 125*time.Microsecond),
+		fixtureResult("GORM", "conn_acquire", 140*time.Microsecond),
+	}
+	for i := range connAcquire {
+		connAcquire[i].QueryAvgTime = connAcquire[i].AvgTime - 30*time.Microsecond
+		connAcquire[i].PoolWaitCount = int64(10 * (i + 1))
+		connAcquire[i].PoolWaitDuration = time.Duration(i+1) * 4 * time.Millisecond
+	}
+
+	cancel := fixtureResult("PQ", "cancel", 30*time.Millisecond)
+	cancel.CancelledCount = 500
+	cancel.ServerAbortedCount = 480
+
+	saturation := fixtureResult("SQLX", "saturation", 60*time.Millisecond)
+	saturation.InFlight = 100
+	saturation.MaxOpenConns = 25
+	saturation.PoolWaitCount = 75
+	saturation.PoolWaitDuration = 1500 * time.Millisecond
+	saturation.ErrorCount = 20
+	saturation.SuccessRate = 98
+	saturation.Errors = benchmark.ErrorBreakdown{Timeout: 20}
+
+	httpRead := fixtureResult("PQ", benchmark.HTTPOperationPrefix+"read", 450*time.Microsecond)
+
+	var results []benchmark.BenchmarkResult
+	results = append(results, read...)
+	results = append(results, connAcquire...)
+	results = append(results, create...)
+	results = append(results, search...)
+	results = append(results, update...)
+	results = append(results, cancel, saturation, httpRead)
+
+	return benchmark.ResultsFile{
+		RunID: "20250101-000000-0000",
+		Environment: benchmark.Environment{
+			GoVersion:     "go1.24.0",
+			OS:            "linux",
+			Arch:          "amd64",
+			NumCPU:        8,
+			CPUModel:      "Synthetic CPU @ 3.00GHz",
+			ServerVersion: "16.4",
+			Network:       "20ms round trip, 0.5% loss",
+			Layout:        "partitioned.users, hash partitioned on id into 4 partitions; " + partition.Differences,
+			Build: buildinfo.Info{
+				Version:   "v1.0.0",
+				Commit:    "0123456789abcdef0123456789abcdef01234567",
+				GoVersion: "go1.24.0",
+				Libraries: map[string]string{
+					"pq":            "v1.10.9",
+					"sqlx":          "v1.4.0",
+					"gorm":          "v1.30.0",
+					"gorm-postgres": "v1.6.0",
+				},
+			},
+		},
+		Results: results,
+		Queries: []benchmark.QueryStat{
+			{Library: "PQ", Fingerprint: "a1b2c3d4e5f60718", Statement: "SELECT id, name FROM users WHERE id = $1", Executions: 1000},
+			{Library: "GORM", Fingerprint: "0f1e2d3c4b5a6978", Statement: "SELECT * FROM users WHERE users.id = $1 ORDER BY users.id LIMIT $2", Executions: 1000},
+			{Library: "GORM", Fingerprint: "8796a5b4c3d2e1f0", Statement: "SELECT * FROM users WHERE email ILIKE $1 AND is_active = $2 ORDER BY created_at DESC", Executions: 1000},
+		},
+		GORMSQL: []gormsql.Method{
+			{
+				Method:      "GetUserByID",
+				GORM:        []string{`SELECT * FROM "users" WHERE id = $1 AND is_active = $2 ORDER BY "users"."id" LIMIT $3`},
+				HandWritten: []string{"SELECT id, name FROM users WHERE id = $1 AND is_active = true"},
+				By:          "PQ",
+			},
+			{
+				Method: "UpdateUser",
+				GORM: []string{
+					`SELECT * FROM "users" WHERE id = $1 AND is_active = $2 ORDER BY "users"."id" LIMIT $3`,
+					`UPDATE "users" SET "name"=$1,"updated_at"=$2`,
+				},
+				HandWritten: []string{"UPDATE users SET updated_at = $1, name = $2 WHERE id = $3 AND is_active = true RETURNING id"},
+				By:          "PQ",
+				Note:        "GORM DryRun: WHERE conditions required",
+			},
+			{
+				Method: "FindUsersWithComplexQuery",
+				GORM:   []string{`SELECT * FROM "users" WHERE (is_active = $1 AND age BETWEEN $2 AND $3) AND email LIKE $4`},
+				Note:   "no hand-written counterpart",
+			},
+		},
+	}
+}
+
+// fixtureResult returns a successful result of 1000 iterations averaging avg at
+// a concurrency of 10, with the other statistics derived from avg
+func fixtureResult(library, operation string, avg time.Duration) benchmark.BenchmarkResult {
+	const iterations, concurrency = 1000, 10
+	total := avg * iterations / concurrency
+	return benchmark.BenchmarkResult{
+		Library:     library,
+		Operation:   operation,
+		Iterations:  iterations,
+		TotalTime:   total,
+		AvgTime:     avg,
+		MinTime:     avg / 2,
+		MaxTime:     avg * 4,
+		MedianTime:  avg * 9 / 10,
+		P95Time:     avg * 2,
+		P99Time:     avg * 3,
+		OpsPerSec:   float64(iterations) / total.Seconds(),
+		SuccessRate: 100,
+	}
+}
@@ -0,0 +1,102 @@
+package benchmark_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-database-comparison/pkg/benchmark"
+)
+
+// update rewrites the golden files after an intended change to the reports:
+// go test ./pkg/benchmark -run TestGoldenReports -update
+var update = flag.Bool("update", false, "write the rendered reports as the new golden files")
+
+// renders is how often each report is rendered to check it is stable
+const renders = 5
+
+// goldenReports lists the reports rendered from goldenFixture: the Markdown
+// report in both languages and the HTML report
+var goldenReports = []struct {
+	name   string
+	locale benchmark.Locale
+	render func(pb *benchmark.PerformanceBenchmark) (string, error)
+}{
+	{"report.md", benchmark.LocaleEnglish, markdown},
+	{"report.ja.md", benchmark.LocaleJapanese, markdown},
+	{"report.html", benchmark.LocaleEnglish, (*benchmark.PerformanceBenchmark).GenerateHTMLReport},
+}
+
+func markdown(pb *benchmark.PerformanceBenchmark) (string, error) {
+	return pb.GenerateReport(), nil
+}
+
+// TestGoldenReports renders the reports from fixed synthetic results and
+// compares them with the reviewed copies in testdata, so a change to how
+// reports look shows up as a diff to review. Every report is rendered
+// several times and has to come out the same each time, which catches
+// output depending on map iteration order.
+func TestGoldenReports(t *testing.T) {
+	if err := goldenFixture().Validate(); err != nil {
+		t.Fatalf("fixture: %v", err)
+	}
+
+	for _, report := range goldenReports {
+		t.Run(report.name, func(t *testing.T) {
+			var first string
+			for i := 0; i < renders; i++ {
+				content, err := report.render(benchmark.NewReportBenchmark(goldenConfig(report.locale), goldenFixture()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					first = content
+					continue
+				}
+				if line, want, got, ok := firstDiff(first, content); !ok {
+					t.Fatalf("differs between renders at line %d: %q, then %q", line, want, got)
+				}
+			}
+
+			path := filepath.Join("testdata", report.name)
+			if *update {
+				if err := os.WriteFile(path, []byte(first), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			golden, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v, run with -update to create it", err)
+			}
+			if line, want, got, ok := firstDiff(string(golden), first); !ok {
+				t.Errorf("differs from %s at line %d, rerun with -update if the change is intended\n  want: %s\n  got:  %s", path, line, want, got)
+			}
+		})
+	}
+}
+
+// firstDiff returns the first line, 1-based, where got differs from want,
+// and ok when they are equal
+func firstDiff(want, got string) (line int, wantLine, gotLine string, ok bool) {
+	if want == got {
+		return 0, "", "", true
+	}
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return i + 1, w, g, false
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package benchmark
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"go-database-comparison/pkg/buildinfo"
+)
+
+// heatmapCell is a single latency value in the heatmap grid
+type heatmapCell struct {
+	Label string
+	Style template.CSS
+}
+
+// heatmapRow is one percentile band across all time windows
+type heatmapRow struct {
+	Band  string
+	Cells []heatmapCell
+}
+
+// heatmapView is the latency-over-time heatmap of one library/operation pair
+type heatmapView struct {
+	Title   string
+	Columns []string
+	Rows    []heatmapRow
+}
+
+// chartBar is a single bar of an average-latency chart
+type chartBar struct {
+	Label string
+	Value string
+	Width float64 // Percentage of the chart width
+}
+
+// chartView is the average-latency bar chart of one operation
+type chartView struct {
+	Title string
+	Bars  []chartBar
+}
+
+// htmlReportData is the data passed to the HTML report template
+type htmlReportData struct {
+	Locale      Locale
+	Iterations  int
+	Concurrency int
+	Build       buildinfo.Info
+	Results     []BenchmarkResult
+	Charts      []chartView
+	Heatmaps    []heatmapView
+}
+
+// T returns localized text for use inside the template
+func (d htmlReportData) T(key string) string {
+	return d.Locale.T(key)
+}
+
+// Lang returns the value of the html lang attribute
+func (d htmlReportData) Lang() string {
+	if d.Locale == LocaleJapanese {
+		return "ja"
+	}
+	return "en"
+}
+
+// ConfigSummary returns the localized run configuration line
+func (d htmlReportData) ConfigSummary() string {
+	return d.Locale.Tf("config_summary", d.Iterations, d.Concurrency)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{.T "report_title"}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.heatmap td { font-size: 0.8em; min-width: 4em; }
+.chart { margin-bottom: 1.5em; max-width: 40em; }
+.bar-row { display: flex; align-items: center; margin: 2px 0; }
+.bar-label { width: 6em; }
+.bar { background: #0d6efd; height: 1.2em; margin-right: 0.5em; }
+@media print { h2 { page-break-before: auto; } table, .chart { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>{{.T "report_title"}}</h1>
+<p><strong>{{.T "configuration"}}</strong>: {{.ConfigSummary}}</p>
+<p><strong>{{.T "build"}}</strong>: {{.Build}}</p>
+
+<h2>{{.T "summary"}}</h2>
+<table>
+<tr><th>{{.T "library"}}</th><th>{{.T "operation"}}</th><th>{{.T "avg_time"}}</th><th>{{.T "p95_time"}}</th><th>{{.T "p99_time"}}</th><th>{{.T "ops_per_sec"}}</th><th>{{.T "success_rate"}}</th></tr>
+{{- range .Results}}
+<tr><td>{{.Library}}</td><td>{{.Operation}}</td><td>{{.AvgTime}}</td><td>{{.P95Time}}</td><td>{{.P99Time}}</td><td>{{printf "%.2f" .OpsPerSec}}</td><td>{{printf "%.1f" .SuccessRate}}%</td></tr>
+{{- end}}
+</table>
+
+<h2>{{.T "charts"}}</h2>
+{{- range .Charts}}
+<div class="chart">
+<h3>{{.Title}}</h3>
+{{- range .Bars}}
+<div class="bar-row"><span class="bar-label">{{.Label}}</span><span class="bar" style="width: {{printf "%.1f" .Width}}%"></span><span>{{.Value}}</span></div>
+{{- end}}
+</div>
+{{- end}}
+
+<h2>{{.T "heatmaps"}}</h2>
+{{- range .Heatmaps}}
+<h3>{{.Title}}</h3>
+<table class="heatmap">
+<tr><th>{{$.T "band"}}</th>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr><td>{{.Band}}</td>{{range .Cells}}<td style="{{.Style}}">{{.Label}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- else}}
+<p>{{.T "no_timeline"}}</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// GenerateHTMLReport generates an HTML report including latency heatmaps
+func (pb *PerformanceBenchmark) GenerateHTMLReport() (string, error) {
+	var results []BenchmarkResult
+	var charts []chartView
+	for _, group := range GroupByOperation(pb.GetResults(), pb.config.OperationTypes) {
+		results = append(results, group.Results...)
+		charts = append(charts, buildChart(group))
+	}
+
+	data := htmlReportData{
+		Locale:      pb.config.Locale,
+		Iterations:  pb.config.Iterations,
+		Concurrency: pb.config.Concurrency,
+		Build:       pb.Environment().Build,
+		Results:     results,
+		Charts:      charts,
+	}
+	for _, result := range results {
+		if len(result.Timeline) == 0 {
+			continue
+		}
+		data.Heatmaps = append(data.Heatmaps, buildHeatmap(result))
+	}
+
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// buildHeatmap turns a result's timeline into percentile bands per window,
+// shading each cell relative to the slowest value in the heatmap
+func buildHeatmap(result BenchmarkResult) heatmapView {
+	view := heatmapView{Title: fmt.Sprintf("%s / %s", result.Library, result.Operation)}
+
+	bands := []struct {
+		name  string
+		value func(TimeWindow) time.Duration
+	}{
+		{"P50", func(w TimeWindow) time.Duration { return w.P50Time }},
+		{"P90", func(w TimeWindow) time.Duration { return w.P90Time }},
+		{"P99", func(w TimeWindow) time.Duration { return w.P99Time }},
+		{"Max", func(w TimeWindow) time.Duration { return w.MaxTime }},
+	}
+
+	var slowest time.Duration
+	for _, w := range result.Timeline {
+		view.Columns = append(view.Columns, w.Offset.String())
+		if w.MaxTime > slowest {
+			slowest = w.MaxTime
+		}
+	}
+
+	// Render from the highest band down so the tail sits on top
+	for i := len(bands) - 1; i >= 0; i-- {
+		band := bands[i]
+		row := heatmapRow{Band: band.name}
+		for _, w := range result.Timeline {
+			d := band.value(w)
+			cell := heatmapCell{Label: "-"}
+			if d > 0 {
+				cell.Label = d.Round(time.Microsecond).String()
+				intensity := 0.0
+				if slowest > 0 {
+					intensity = float64(d) / float64(slowest)
+				}
+				cell.Style = template.CSS(fmt.Sprintf("background-color: rgba(220, 53, 69, %.2f)", intensity))
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		view.Rows = append(view.Rows, row)
+	}
+
+	return view
+}
+
+// buildChart renders the average latency of each library as horizontal bars
+func buildChart(group OperationGroup) chartView {
+	view := chartView{Title: group.Operation}
+
+	var slowest time.Duration
+	for _, r := range group.Results {
+		if r.AvgTime > slowest {
+			slowest = r.AvgTime
+		}
+	}
+
+	for _, r := range group.Results {
+		bar := chartBar{Label: r.Library, Value: r.AvgTime.String()}
+		if slowest > 0 {
+			bar.Width = float64(r.AvgTime) / float64(slowest) * 100
+		}
+		view.Bars = append(view.Bars, bar)
+	}
+
+	return view
+}
@@ -0,0 +1,103 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-database-comparison/pkg/concurrency"
+)
+
+// SaturationScenario configures config to run only the "saturation"
+// operation, which demonstrates how each library behaves when more queries
+// are in flight than its pool has connections
+func SaturationScenario(config *BenchmarkConfig) {
+	config.OperationTypes = []string{"saturation"}
+}
+
+// benchmarkSaturation runs SaturationFactor jobs per open connection at once.
+// Each job holds its connection for SaturationHold, so most of them have to
+// wait in the library's pool and the ones waiting past SaturationTimeout fail.
+// Pool waits, timeouts and tail latency show how the library degrades.
+func (pb *PerformanceBenchmark) benchmarkSaturation(ctx context.Context, library string, target libraryTarget) (BenchmarkResult, error) {
+	if target.sqlDB == nil || target.rawExec == nil {
+		return BenchmarkResult{}, fmt.Errorf("saturation benchmark not supported for %s", library)
+	}
+
+	maxOpen := target.sqlDB.Stats().MaxOpenConnections
+	if maxOpen <= 0 {
+		return BenchmarkResult{}, fmt.Errorf("saturation benchmark needs a bounded pool, %s has no MaxOpenConns", library)
+	}
+	factor := pb.config.SaturationFactor
+	if factor < 2 {
+		factor = 2
+	}
+	inFlight := maxOpen * factor
+
+	query := fmt.Sprintf("SELECT pg_sleep(%f)", pb.config.SaturationHold.Seconds())
+
+	jobs := make([]concurrency.Job[opSample], 0, pb.config.Iterations)
+	for i := 0; i < pb.config.Iterations; i++ {
+		jobs = append(jobs, concurrency.Job[opSample]{
+			ID: i,
+			TaskFunc: func(jobCtx context.Context) (opSample, error) {
+				start := time.Now()
+				err := target.rawExec(jobCtx, query)
+				return opSample{Start: start, Duration: time.Since(start), Err: err}, err
+			},
+			Timeout: pb.config.SaturationTimeout,
+			Labels:  map[string]string{concurrency.LabelLibrary: library, concurrency.LabelOperation: "saturation"},
+		})
+	}
+
+	statsBefore := target.sqlDB.Stats()
+
+	results, poolStats, err := concurrency.RunJobs(ctx, inFlight, jobs, pb.poolOptions()...)
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+
+	statsAfter := target.sqlDB.Stats()
+
+	samples := make([]opSample, 0, len(results))
+	for _, result := range results {
+		sample := result.Data
+		sample.Err = result.Error
+		sample.WorkerID = result.WorkerID
+		samples = append(samples, sample)
+	}
+
+	benchResult := pb.summarize(library, "saturation", samples)
+	benchResult.InFlight = inFlight
+	benchResult.MaxOpenConns = maxOpen
+	benchResult.PoolWaitCount = statsAfter.WaitCount - statsBefore.WaitCount
+	benchResult.PoolWaitDuration = statsAfter.WaitDuration - statsBefore.WaitDuration
+	applyPoolStats(&benchResult, poolStats)
+
+	return benchResult, nil
+}
+
+// generateSaturationSection renders the pool saturation section of the report
+func generateSaturationSection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if operation, _ := SplitSweepOperation(result.Operation); operation != "saturation" {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("saturation_section"))
+			section += loc.tableHeader("library", "in_flight", "pool_waits", "pool_wait_avg", "timeout", "p95_time", "p99_time", "max_time", "success_rate")
+		}
+		var waitAvg time.Duration
+		if result.PoolWaitCount > 0 {
+			waitAvg = result.PoolWaitDuration / time.Duration(result.PoolWaitCount)
+		}
+		section += fmt.Sprintf("| %s | %d / %d | %d | %v | %d | %v | %v | %v | %.1f%% |\n",
+			sweepLabel(result), result.InFlight, result.MaxOpenConns, result.PoolWaitCount, waitAvg,
+			result.Errors.Timeout, result.P95Time, result.P99Time, result.MaxTime, result.SuccessRate)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
@@ -0,0 +1,74 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-database-comparison/pkg/database"
+)
+
+// sweepSeparator joins an operation and the concurrency a sweep ran it
+// at in result names, e.g. "read@c16"
+const sweepSeparator = "@c"
+
+// SweepOperation returns the result name of operation run at concurrency
+// during a concurrency sweep
+func SweepOperation(operation string, concurrency int) string {
+	return operation + sweepSeparator + strconv.Itoa(concurrency)
+}
+
+// SplitSweepOperation returns the operation and concurrency of a result
+// name made by SweepOperation. Names of results outside a sweep are
+// returned unchanged with concurrency 0.
+func SplitSweepOperation(name string) (operation string, concurrency int) {
+	i := strings.LastIndex(name, sweepSeparator)
+	if i < 0 {
+		return name, 0
+	}
+	level, err := strconv.Atoi(name[i+len(sweepSeparator):])
+	if err != nil || level <= 0 {
+		return name, 0
+	}
+	return name[:i], level
+}
+
+// sweepLabel returns the library of result, with the concurrency it ran
+// at when it is part of a sweep, for the per-operation report sections
+func sweepLabel(result BenchmarkResult) string {
+	if _, level := SplitSweepOperation(result.Operation); level > 0 {
+		return fmt.Sprintf("%s (c=%d)", result.Library, level)
+	}
+	return result.Library
+}
+
+// runSweep runs every library once per level of ConcurrencySweep and
+// names the results of each pass with SweepOperation
+func (pb *PerformanceBenchmark) runSweep(ctx context.Context, dbConfig *database.DatabaseConfig) error {
+	concurrency := pb.config.Concurrency
+	defer func() { pb.config.Concurrency = concurrency }()
+
+	for _, level := range pb.config.ConcurrencySweep {
+		pb.config.Concurrency = level
+		pb.logger().Info(pb.config.Locale.Tf("sweep_level", level), "concurrency", level)
+
+		pb.mu.RLock()
+		first := len(pb.results)
+		pb.mu.RUnlock()
+
+		for _, library := range Libraries {
+			pb.logger().Info(pb.config.Locale.Tf("benchmarking", library), "library", library, "concurrency", level)
+			if err := pb.benchmarkLibrary(ctx, library, dbConfig); err != nil {
+				return fmt.Errorf("benchmark failed for %s at concurrency %d: %w", library, level, err)
+			}
+		}
+
+		pb.mu.Lock()
+		for i := first; i < len(pb.results); i++ {
+			pb.results[i].Operation = SweepOperation(pb.results[i].Operation, level)
+		}
+		pb.mu.Unlock()
+	}
+	return nil
+}
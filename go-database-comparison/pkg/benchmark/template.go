@@ -0,0 +1,93 @@
+package benchmark
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// ReportData is the data exposed to user-supplied report templates
+type ReportData struct {
+	GeneratedAt time.Time
+	Locale      Locale
+	Config      BenchmarkConfig
+	Environment Environment
+	Results     []BenchmarkResult
+}
+
+// ReportTemplate is satisfied by both *text/template.Template and *html/template.Template
+type ReportTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// templateFuncs are the helper functions available to custom report templates
+func templateFuncs(locale Locale) map[string]interface{} {
+	return map[string]interface{}{
+		"t":   locale.T,
+		"pct": func(v float64) string { return fmt.Sprintf("%.1f%%", v) },
+		"ms": func(d time.Duration) string {
+			return fmt.Sprintf("%.3fms", float64(d)/float64(time.Millisecond))
+		},
+		"byOperation": func(results []BenchmarkResult, operation string) []BenchmarkResult {
+			var filtered []BenchmarkResult
+			for _, r := range results {
+				if r.Operation == operation {
+					filtered = append(filtered, r)
+				}
+			}
+			return filtered
+		},
+	}
+}
+
+// LoadReportTemplate parses a report template from path. Files ending in
+// .html or .htm are parsed with html/template, everything else with
+// text/template. The helpers t, pct, ms and byOperation are available.
+func LoadReportTemplate(path string, locale Locale) (ReportTemplate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report template: %w", err)
+	}
+
+	name := filepath.Base(path)
+	funcs := templateFuncs(locale)
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm":
+		tmpl, err := htmltemplate.New(name).Funcs(funcs).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTML report template: %w", err)
+		}
+		return tmpl, nil
+	default:
+		tmpl, err := texttemplate.New(name).Funcs(funcs).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse report template: %w", err)
+		}
+		return tmpl, nil
+	}
+}
+
+// ReportData returns the results and metadata of the run for template rendering
+func (pb *PerformanceBenchmark) ReportData() ReportData {
+	return ReportData{
+		GeneratedAt: time.Now(),
+		Locale:      pb.config.Locale,
+		Config:      *pb.config,
+		Environment: pb.Environment(),
+		Results:     pb.GetResults(),
+	}
+}
+
+// RenderReport renders the run's results with a user-supplied template
+func (pb *PerformanceBenchmark) RenderReport(w io.Writer, tmpl ReportTemplate) error {
+	if err := tmpl.Execute(w, pb.ReportData()); err != nil {
+		return fmt.Errorf("failed to render report template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package benchmark
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// opSample records the timing and outcome of a single benchmarked operation
+type opSample struct {
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+	WorkerID int
+	Attempts int   // Attempts made by the pool, including retries
+	Queries  int64 // Statements the operation executed
+}
+
+// TimeWindow holds throughput and error rate for one fixed sampling window of a run
+type TimeWindow struct {
+	Offset     time.Duration `json:"offset"` // Window start relative to the first operation
+	Operations int           `json:"operations"`
+	Errors     int           `json:"errors"`
+	OpsPerSec  float64       `json:"ops_per_sec"`
+	ErrorRate  float64       `json:"error_rate"` // Percentage of failed operations in the window
+
+	// Latency bands of the successful operations completed in the window
+	P50Time time.Duration `json:"p50_time"`
+	P90Time time.Duration `json:"p90_time"`
+	P99Time time.Duration `json:"p99_time"`
+	MaxTime time.Duration `json:"max_time"`
+}
+
+// buildTimeline buckets samples into fixed windows by completion time.
+// Samples without a start time (jobs that never ran) are left out.
+func buildTimeline(samples []opSample, interval time.Duration) []TimeWindow {
+	timed := make([]opSample, 0, len(samples))
+	for _, s := range samples {
+		if !s.Start.IsZero() {
+			timed = append(timed, s)
+		}
+	}
+	samples = timed
+
+	if len(samples) == 0 || interval <= 0 {
+		return nil
+	}
+
+	origin := samples[0].Start
+	var last time.Time
+	for _, s := range samples {
+		if s.Start.Before(origin) {
+			origin = s.Start
+		}
+		if end := s.Start.Add(s.Duration); end.After(last) {
+			last = end
+		}
+	}
+
+	windows := make([]TimeWindow, int(last.Sub(origin)/interval)+1)
+	for i := range windows {
+		windows[i].Offset = time.Duration(i) * interval
+	}
+
+	latencies := make([][]time.Duration, len(windows))
+	for _, s := range samples {
+		idx := int(s.Start.Add(s.Duration).Sub(origin) / interval)
+		windows[idx].Operations++
+		if s.Err != nil {
+			windows[idx].Errors++
+			continue
+		}
+		latencies[idx] = append(latencies[idx], s.Duration)
+	}
+
+	for i := range windows {
+		w := &windows[i]
+		w.OpsPerSec = math.Round(float64(w.Operations)/interval.Seconds()*100) / 100
+		if w.Operations > 0 {
+			w.ErrorRate = math.Round(float64(w.Errors)/float64(w.Operations)*10000) / 100
+		}
+
+		if d := latencies[i]; len(d) > 0 {
+			sort.Slice(d, func(a, b int) bool { return d[a] < d[b] })
+			w.P50Time = percentile(d, 0.50)
+			w.P90Time = percentile(d, 0.90)
+			w.P99Time = percentile(d, 0.99)
+			w.MaxTime = d[len(d)-1]
+		}
+	}
+
+	return windows
+}
+
+// percentile returns the p-th percentile of an ascending slice of durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(float64(len(sorted)) * p)
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// summarize computes statistics and the throughput timeline from raw samples
+func (pb *PerformanceBenchmark) summarize(library, operation string, samples []opSample) BenchmarkResult {
+	durations := make([]time.Duration, 0, len(samples))
+	errorCount := 0
+	retries := 0
+	var queries int64
+	for _, s := range samples {
+		queries += s.Queries
+		if s.Attempts > 1 {
+			retries += s.Attempts - 1
+		}
+		if s.Err != nil {
+			errorCount++
+			continue
+		}
+		durations = append(durations, s.Duration)
+	}
+
+	result := pb.calculateStatistics(library, operation, durations, errorCount)
+	result.Timeline = buildTimeline(samples, pb.config.SampleInterval)
+	result.Errors = breakdownErrors(samples)
+	result.Workers = buildWorkerStats(samples)
+	result.RetryCount = retries
+	if pb.chaos != nil {
+		result.Chaos = pb.chaos.chaosStats(samples)
+	}
+	if len(samples) > 0 {
+		result.QueriesPerOp = float64(queries) / float64(len(samples))
+	}
+	return result
+}
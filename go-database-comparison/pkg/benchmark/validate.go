@@ -0,0 +1,209 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ValidationError lists what is wrong with a results file, typically one
+// that was truncated or edited by hand
+type ValidationError struct {
+	Path     string   // File the results were read from, empty if not read from a file
+	Problems []string // One entry per broken rule, naming the offending field
+}
+
+func (e *ValidationError) Error() string {
+	source := "results"
+	if e.Path != "" {
+		source = "results " + e.Path
+	}
+	return fmt.Sprintf("invalid %s, %d problems:\n  - %s", source, len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate checks that the results are complete and consistent before
+// they are compared or reported: every result names its library and
+// operation once, counts and durations are not negative and percentiles do
+// not decrease, from min_time over median_time, p95_time and p99_time to
+// max_time. It returns a *ValidationError listing every problem found.
+func (f ResultsFile) Validate() error {
+	var v validator
+	if len(f.Results) == 0 {
+		v.add("results: missing or empty")
+	}
+
+	type key struct{ library, operation string }
+	seen := make(map[key]int, len(f.Results))
+	for i, r := range f.Results {
+		v.at(fmt.Sprintf("results[%d]", i))
+		if r.Library != "" && r.Operation != "" {
+			v.at(fmt.Sprintf("results[%d] (%s %s)", i, r.Library, r.Operation))
+		}
+
+		if r.Library == "" {
+			v.add("library: missing")
+		}
+		if r.Operation == "" {
+			v.add("operation: missing")
+		}
+		if r.Library != "" && r.Operation != "" {
+			if first, ok := seen[key{r.Library, r.Operation}]; ok {
+				v.add(fmt.Sprintf("duplicates results[%d]", first))
+			} else {
+				seen[key{r.Library, r.Operation}] = i
+			}
+		}
+		if r.Iterations <= 0 {
+			v.add(fmt.Sprintf("iterations: must be positive, got %d", r.Iterations))
+		}
+
+		v.counts([]namedCount{
+			{"error_count", int64(r.ErrorCount)},
+			{"retry_count", int64(r.RetryCount)},
+			{"pool_wait_count", r.PoolWaitCount},
+			{"cancelled_count", int64(r.CancelledCount)},
+			{"server_aborted_count", int64(r.ServerAbortedCount)},
+			{"in_flight", int64(r.InFlight)},
+			{"max_open_conns", int64(r.MaxOpenConns)},
+			{"errors.timeout", int64(r.Errors.Timeout)},
+			{"errors.connection", int64(r.Errors.Connection)},
+			{"errors.unique_violation", int64(r.Errors.UniqueViolation)},
+			{"errors.other", int64(r.Errors.Other)},
+		})
+		v.durations([]namedDuration{
+			{"total_time", r.TotalTime},
+			{"avg_time", r.AvgTime},
+			{"query_avg_time", r.QueryAvgTime},
+			{"pool_wait_duration", r.PoolWaitDuration},
+			{"queue_wait_avg", r.QueueWaitAvg},
+			{"queue_wait_p95", r.QueueWaitP95},
+		})
+		v.percentiles([]namedDuration{
+			{"min_time", r.MinTime},
+			{"median_time", r.MedianTime},
+			{"p95_time", r.P95Time},
+			{"p99_time", r.P99Time},
+			{"max_time", r.MaxTime},
+		})
+		v.rate("ops_per_sec", r.OpsPerSec, 0)
+		v.rate("success_rate", r.SuccessRate, 100)
+		v.rate("queries_per_op", r.QueriesPerOp, 0)
+		v.rate("worker_utilization", r.WorkerUtilization, 1)
+
+		prefix := v.prefix
+		for j, window := range r.Timeline {
+			v.at(fmt.Sprintf("%s timeline[%d]", prefix, j))
+			v.counts([]namedCount{{"operations", int64(window.Operations)}, {"errors", int64(window.Errors)}})
+			v.durations([]namedDuration{{"offset", window.Offset}})
+			v.percentiles([]namedDuration{
+				{"p50_time", window.P50Time},
+				{"p90_time", window.P90Time},
+				{"p99_time", window.P99Time},
+				{"max_time", window.MaxTime},
+			})
+			v.rate("ops_per_sec", window.OpsPerSec, 0)
+			v.rate("error_rate", window.ErrorRate, 100)
+		}
+		for j, worker := range r.Workers {
+			v.at(fmt.Sprintf("%s workers[%d]", prefix, j))
+			v.counts([]namedCount{{"operations", int64(worker.Operations)}, {"errors", int64(worker.Errors)}})
+			v.durations([]namedDuration{{"avg_time", worker.AvgTime}})
+			v.percentiles([]namedDuration{
+				{"median_time", worker.MedianTime},
+				{"p95_time", worker.P95Time},
+				{"p99_time", worker.P99Time},
+				{"max_time", worker.MaxTime},
+			})
+		}
+		if r.Chaos != nil {
+			v.at(prefix + " chaos")
+			v.counts([]namedCount{{"kills", int64(r.Chaos.Kills)}, {"failed_ops", int64(r.Chaos.FailedOps)}, {"unrecovered", int64(r.Chaos.Unrecovered)}})
+			v.percentiles([]namedDuration{{"recovery_avg", r.Chaos.RecoveryAvg}, {"recovery_max", r.Chaos.RecoveryMax}})
+		}
+	}
+
+	for i, q := range f.Queries {
+		v.at(fmt.Sprintf("queries[%d]", i))
+		if q.Library == "" {
+			v.add("library: missing")
+		}
+		if q.Statement == "" {
+			v.add("statement: missing")
+		}
+		v.counts([]namedCount{{"executions", q.Executions}})
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// namedCount and namedDuration are fields with their JSON names
+type namedCount struct {
+	name  string
+	value int64
+}
+
+type namedDuration struct {
+	name  string
+	value time.Duration
+}
+
+// validator collects the problems of a results file, each prefixed with
+// where in the file it was found
+type validator struct {
+	prefix   string
+	problems []string
+}
+
+func (v *validator) at(prefix string) {
+	v.prefix = prefix
+}
+
+func (v *validator) add(problem string) {
+	if v.prefix != "" {
+		problem = v.prefix + " " + problem
+	}
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) counts(counts []namedCount) {
+	for _, c := range counts {
+		if c.value < 0 {
+			v.add(fmt.Sprintf("%s: must not be negative, got %d", c.name, c.value))
+		}
+	}
+}
+
+func (v *validator) durations(durations []namedDuration) {
+	for _, d := range durations {
+		if d.value < 0 {
+			v.add(fmt.Sprintf("%s: must not be negative, got %v", d.name, d.value))
+		}
+	}
+}
+
+// percentiles checks that the durations are not negative and do not
+// decrease in the given order
+func (v *validator) percentiles(durations []namedDuration) {
+	v.durations(durations)
+	for i := 1; i < len(durations); i++ {
+		prev, cur := durations[i-1], durations[i]
+		if cur.value < prev.value {
+			v.add(fmt.Sprintf("%s: %v is below %s %v", cur.name, cur.value, prev.name, prev.value))
+		}
+	}
+}
+
+// rate checks that value lies between 0 and max, or is not negative when
+// max is 0
+func (v *validator) rate(name string, value, max float64) {
+	switch {
+	case max == 0 && (math.IsNaN(value) || value < 0):
+		v.add(fmt.Sprintf("%s: must not be negative, got %v", name, value))
+	case max > 0 && (math.IsNaN(value) || value < 0 || value > max):
+		v.add(fmt.Sprintf("%s: must be from 0 to %v, got %v", name, max, value))
+	}
+}
@@ -0,0 +1,102 @@
+package benchmark
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WorkerStats describes the latency distribution of operations run by one worker
+type WorkerStats struct {
+	WorkerID   int           `json:"worker_id"`
+	Operations int           `json:"operations"`
+	Errors     int           `json:"errors"`
+	AvgTime    time.Duration `json:"avg_time"`
+	MedianTime time.Duration `json:"median_time"`
+	P95Time    time.Duration `json:"p95_time"`
+	P99Time    time.Duration `json:"p99_time"`
+	MaxTime    time.Duration `json:"max_time"`
+}
+
+// buildWorkerStats groups samples by worker and computes per-worker percentiles
+func buildWorkerStats(samples []opSample) []WorkerStats {
+	byWorker := make(map[int]*WorkerStats)
+	durations := make(map[int][]time.Duration)
+
+	for _, s := range samples {
+		ws, ok := byWorker[s.WorkerID]
+		if !ok {
+			ws = &WorkerStats{WorkerID: s.WorkerID}
+			byWorker[s.WorkerID] = ws
+		}
+		ws.Operations++
+		if s.Err != nil {
+			ws.Errors++
+			continue
+		}
+		durations[s.WorkerID] = append(durations[s.WorkerID], s.Duration)
+	}
+
+	stats := make([]WorkerStats, 0, len(byWorker))
+	for id, ws := range byWorker {
+		if d := durations[id]; len(d) > 0 {
+			sort.Slice(d, func(a, b int) bool { return d[a] < d[b] })
+			var total time.Duration
+			for _, v := range d {
+				total += v
+			}
+			ws.AvgTime = total / time.Duration(len(d))
+			ws.MedianTime = percentile(d, 0.50)
+			ws.P95Time = percentile(d, 0.95)
+			ws.P99Time = percentile(d, 0.99)
+			ws.MaxTime = d[len(d)-1]
+		}
+		stats = append(stats, *ws)
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].WorkerID < stats[j].WorkerID })
+	return stats
+}
+
+// generateQueueSection renders worker pool queue wait and utilization
+func generateQueueSection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if result.WorkerUtilization == 0 {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("queue_section"))
+			section += loc.tableHeader("library", "operation", "queue_wait_avg", "queue_wait_p95", "utilization")
+		}
+		section += fmt.Sprintf("| %s | %s | %v | %v | %.1f%% |\n",
+			result.Library, result.Operation, result.QueueWaitAvg,
+			result.QueueWaitP95, result.WorkerUtilization*100)
+	}
+	if section != "" {
+		section += "\n"
+	}
+	return section
+}
+
+// generateWorkerSection renders per-worker latency for concurrent operations
+func generateWorkerSection(results []BenchmarkResult, loc Locale) string {
+	section := ""
+	for _, result := range results {
+		if len(result.Workers) < 2 {
+			continue
+		}
+		if section == "" {
+			section += fmt.Sprintf("## %s\n\n", loc.T("worker_section"))
+		}
+		section += fmt.Sprintf("### %s / %s\n\n", result.Library, result.Operation)
+		section += loc.tableHeader("worker", "ops", "errors", "avg_time", "median", "p95_time", "p99_time", "max_time")
+		for _, ws := range result.Workers {
+			section += fmt.Sprintf("| %d | %d | %d | %v | %v | %v | %v | %v |\n",
+				ws.WorkerID, ws.Operations, ws.Errors, ws.AvgTime,
+				ws.MedianTime, ws.P95Time, ws.P99Time, ws.MaxTime)
+		}
+		section += "\n"
+	}
+	return section
+}
@@ -0,0 +1,127 @@
+// Package buildinfo reports which build of dbcompare is running and the
+// versions of the database libraries compiled into it, so results can be
+// traced back to the code that produced them.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Version and Commit are set at link time, e.g.
+//
+//	go build -ldflags "-X go-database-comparison/pkg/buildinfo.Version=v1.2.0 -X go-database-comparison/pkg/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// When left empty they are taken from the module and VCS information the
+// Go toolchain embeds in the binary.
+var (
+	Version string
+	Commit  string
+)
+
+// libraries maps the names dbcompare uses for the libraries it compares to
+// their modules
+var libraries = map[string]string{
+	"pq":            "github.com/lib/pq",
+	"sqlx":          "github.com/jmoiron/sqlx",
+	"gorm":          "gorm.io/gorm",
+	"gorm-postgres": "gorm.io/driver/postgres",
+}
+
+// Info describes a build of dbcompare
+type Info struct {
+	Version    string            `json:"version"`
+	Commit     string            `json:"commit,omitempty"`
+	CommitTime string            `json:"commit_time,omitempty"`
+	Modified   bool              `json:"modified,omitempty"` // Built from a tree with uncommitted changes
+	GoVersion  string            `json:"go_version"`
+	Libraries  map[string]string `json:"libraries"` // Module version by library name
+}
+
+// Get returns the build information of the running binary. Values the
+// binary does not carry, e.g. the commit of a "go run" build, are left
+// empty; library versions missing from the build are reported as "unknown".
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+		Libraries: make(map[string]string, len(libraries)),
+	}
+
+	deps := make(map[string]string)
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				info.CommitTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+		for _, dep := range bi.Deps {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			deps[dep.Path] = dep.Version
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+
+	for name, module := range libraries {
+		version, ok := deps[module]
+		if !ok || version == "" {
+			version = "unknown"
+		}
+		info.Libraries[name] = version
+	}
+	return info
+}
+
+// ShortCommit returns the first 12 characters of the commit hash
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+// LibraryNames returns the library names in Libraries, sorted
+func (i Info) LibraryNames() []string {
+	names := make([]string, 0, len(i.Libraries))
+	for name := range i.Libraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String summarizes the build on one line, e.g.
+// "dbcompare v1.2.0 (3f2a9c1d0b4e) go1.24.1, gorm v1.30.0, pq v1.10.9, sqlx v1.4.0"
+func (i Info) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "dbcompare %s", i.Version)
+	if commit := i.ShortCommit(); commit != "" {
+		if i.Modified {
+			commit += "-dirty"
+		}
+		fmt.Fprintf(&b, " (%s)", commit)
+	}
+	fmt.Fprintf(&b, " %s", i.GoVersion)
+	for _, name := range i.LibraryNames() {
+		fmt.Fprintf(&b, ", %s %s", name, i.Libraries[name])
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen matches the error returned by Submit while a circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitOpenError is returned by Submit for jobs whose label has failed
+// too many times in a row
+type CircuitOpenError struct {
+	Label string    // Value of the breaker's label key, e.g. the operation
+	Until time.Time // When submissions with this label are accepted again
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for %q until %s", e.Label, e.Until.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) true
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// WithCircuitBreaker rejects submissions for a label value after threshold
+// consecutive failed jobs with that value, for cooldown. After the cooldown
+// one more failure reopens the breaker and a success closes it. labelKey
+// selects the job label the breakers are keyed by, e.g. LabelOperation.
+func WithCircuitBreaker(threshold int, cooldown time.Duration, labelKey string) Option {
+	return func(o *poolOptions) {
+		if threshold <= 0 {
+			o.breaker = nil
+			return
+		}
+		o.breaker = &circuitBreaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+			labelKey:  labelKey,
+			states:    make(map[string]*breakerState),
+		}
+	}
+}
+
+// breakerState tracks consecutive failures of one label value
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+	halfOpen  bool // Cooldown passed, the next result decides
+}
+
+// circuitBreaker holds a breaker per label value
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	labelKey  string
+	states    map[string]*breakerState
+}
+
+// allow returns a *CircuitOpenError if the breaker for the job's label is open
+func (cb *circuitBreaker) allow(labels map[string]string) error {
+	if cb == nil {
+		return nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	label := labels[cb.labelKey]
+	state, ok := cb.states[label]
+	if !ok || state.openUntil.IsZero() {
+		return nil
+	}
+	if time.Now().Before(state.openUntil) {
+		return &CircuitOpenError{Label: label, Until: state.openUntil}
+	}
+
+	state.openUntil = time.Time{}
+	state.halfOpen = true
+	return nil
+}
+
+// record updates the breaker for the job's label with its outcome
+func (cb *circuitBreaker) record(labels map[string]string, err error) {
+	if cb == nil {
+		return
+	}
+	// Cancellation says nothing about the health of the database
+	if errors.Is(err, ErrJobCancelled) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	label := labels[cb.labelKey]
+	state, ok := cb.states[label]
+	if !ok {
+		state = &breakerState{}
+		cb.states[label] = state
+	}
+
+	if err == nil {
+		state.failures = 0
+		state.halfOpen = false
+		return
+	}
+
+	state.failures++
+	if state.halfOpen || state.failures >= cb.threshold {
+		state.openUntil = time.Now().Add(cb.cooldown)
+		state.failures = 0
+		state.halfOpen = false
+	}
+}
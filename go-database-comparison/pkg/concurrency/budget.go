@@ -0,0 +1,49 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrDeadlineBudget is the Result error of a job skipped because too little
+// of the pool context's deadline was left to run it
+var ErrDeadlineBudget = errors.New("deadline budget exhausted")
+
+// deadlineBudget shrinks job timeouts to fit the pool context's deadline
+type deadlineBudget struct {
+	reserve    time.Duration
+	minTimeout time.Duration
+}
+
+// WithDeadlineBudget fits job timeouts into the deadline of the pool's
+// parent context. When a job starts its timeout is cut to the time left
+// until the deadline minus reserve, which keeps time for collecting results
+// and reporting. Jobs with less than minTimeout left fail with
+// ErrDeadlineBudget instead of starting. Without a deadline it has no effect.
+func WithDeadlineBudget(reserve, minTimeout time.Duration) Option {
+	return func(o *poolOptions) {
+		o.budget = &deadlineBudget{reserve: reserve, minTimeout: minTimeout}
+	}
+}
+
+// timeout returns the timeout a job may start with, or ErrDeadlineBudget
+func (b *deadlineBudget) timeout(ctx context.Context, jobTimeout time.Duration) (time.Duration, error) {
+	if b == nil {
+		return jobTimeout, nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return jobTimeout, nil
+	}
+
+	remaining := time.Until(deadline) - b.reserve
+	if remaining <= 0 || remaining < b.minTimeout {
+		return 0, ErrDeadlineBudget
+	}
+	if jobTimeout <= 0 || jobTimeout > remaining {
+		return remaining, nil
+	}
+	return jobTimeout, nil
+}
@@ -0,0 +1,73 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrJobCancelled is the Result error of a job stopped with Cancel
+var ErrJobCancelled = errors.New("job cancelled")
+
+// Cancel stops the job with the given ID. A queued job is skipped and
+// reported with ErrJobCancelled wrapping context.Canceled, a running job
+// has its context cancelled.
+// It returns false if no queued or running job has that ID.
+func (wp *WorkerPool[T]) Cancel(jobID int) bool {
+	wp.cancelMu.Lock()
+	defer wp.cancelMu.Unlock()
+
+	if cancel, ok := wp.running[jobID]; ok {
+		wp.cancelled[jobID] = true
+		cancel()
+		return true
+	}
+
+	if !wp.jobQueue.contains(jobID) {
+		return false
+	}
+	if wp.cancelled == nil {
+		wp.cancelled = make(map[int]bool)
+	}
+	wp.cancelled[jobID] = true
+	return true
+}
+
+// startJob registers a running job and returns its cancellable context.
+// ok is false if the job was cancelled while still queued.
+func (wp *WorkerPool[T]) startJob(jobID int) (ctx context.Context, cancel context.CancelFunc, ok bool) {
+	wp.cancelMu.Lock()
+	defer wp.cancelMu.Unlock()
+
+	if wp.cancelled[jobID] {
+		delete(wp.cancelled, jobID)
+		return nil, nil, false
+	}
+
+	ctx, cancel = context.WithCancel(wp.ctx)
+	if wp.running == nil {
+		wp.running = make(map[int]context.CancelFunc)
+	}
+	if wp.cancelled == nil {
+		wp.cancelled = make(map[int]bool)
+	}
+	wp.running[jobID] = cancel
+	return ctx, cancel, true
+}
+
+// finishJob unregisters a running job and wraps err if the job was cancelled
+func (wp *WorkerPool[T]) finishJob(jobID int, cancel context.CancelFunc, err error) error {
+	wp.cancelMu.Lock()
+	defer wp.cancelMu.Unlock()
+
+	cancel()
+	delete(wp.running, jobID)
+	if wp.cancelled[jobID] {
+		delete(wp.cancelled, jobID)
+		if err == nil {
+			return ErrJobCancelled
+		}
+		return fmt.Errorf("%w: %v", ErrJobCancelled, err)
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+package concurrency
+
+import "context"
+
+// workerIDKey is the context key of the worker running a job
+type workerIDKey struct{}
+
+// withWorkerID returns ctx annotated with the ID of the worker running the job
+func withWorkerID(ctx context.Context, workerID int) context.Context {
+	return context.WithValue(ctx, workerIDKey{}, workerID)
+}
+
+// WorkerID returns the ID of the pool worker running the job ctx belongs to.
+// ok is false outside of a WorkerPool job, e.g. under a DirectExecutor.
+// Tasks can use it to keep per-worker state such as a dedicated connection.
+func WorkerID(ctx context.Context) (id int, ok bool) {
+	id, ok = ctx.Value(workerIDKey{}).(int)
+	return id, ok
+}
@@ -0,0 +1,110 @@
+package concurrency
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DeadLetter is a job that failed after its final attempt, kept with its
+// error so the failure can be diagnosed and the job replayed
+type DeadLetter[T any] struct {
+	Job      Job[T] // The job as submitted, including its TaskFunc and Labels
+	Error    error
+	Attempts int
+	FailedAt time.Time
+}
+
+// WithDeadLetters keeps up to capacity jobs that failed after exhausting
+// their retries, see DeadLetters. When full the oldest entry is dropped.
+// Cancelled jobs are not kept. A non-positive capacity disables the buffer.
+func WithDeadLetters(capacity int) Option {
+	return func(o *poolOptions) {
+		o.deadLetters = capacity
+	}
+}
+
+// recordDeadLetter keeps a failed job in the dead-letter buffer
+func (wp *WorkerPool[T]) recordDeadLetter(job Job[T], result Result[T]) {
+	if wp.opts.deadLetters <= 0 || result.Error == nil || errors.Is(result.Error, ErrJobCancelled) {
+		return
+	}
+
+	wp.deadMu.Lock()
+	defer wp.deadMu.Unlock()
+
+	wp.deadLetters = append(wp.deadLetters, DeadLetter[T]{
+		Job:      job,
+		Error:    result.Error,
+		Attempts: result.Attempts,
+		FailedAt: time.Now(),
+	})
+	wp.trimDeadLetters()
+}
+
+// trimDeadLetters drops the oldest dead letters beyond the capacity,
+// counting them as dropped. deadMu must be held.
+func (wp *WorkerPool[T]) trimDeadLetters() {
+	if excess := len(wp.deadLetters) - wp.opts.deadLetters; excess > 0 {
+		wp.deadLetters = wp.deadLetters[excess:]
+		wp.deadDropped += int64(excess)
+	}
+}
+
+// DeadLetters returns the buffered failed jobs, oldest first. It stays
+// readable after the pool is stopped.
+func (wp *WorkerPool[T]) DeadLetters() []DeadLetter[T] {
+	wp.deadMu.Lock()
+	defer wp.deadMu.Unlock()
+
+	letters := make([]DeadLetter[T], len(wp.deadLetters))
+	copy(letters, wp.deadLetters)
+	return letters
+}
+
+// DrainDeadLetters returns the buffered failed jobs and empties the buffer
+func (wp *WorkerPool[T]) DrainDeadLetters() []DeadLetter[T] {
+	wp.deadMu.Lock()
+	defer wp.deadMu.Unlock()
+
+	letters := wp.deadLetters
+	wp.deadLetters = nil
+	return letters
+}
+
+// ReplayDeadLetters drains the dead-letter buffer and submits the jobs
+// again. Jobs that are rejected go back into the buffer, ahead of the jobs
+// that failed meanwhile and within its capacity, and the first rejection
+// is returned with the number of jobs resubmitted.
+func (wp *WorkerPool[T]) ReplayDeadLetters() (int, error) {
+	replayed := 0
+	var firstErr error
+	var rejected []DeadLetter[T]
+
+	for _, letter := range wp.DrainDeadLetters() {
+		if err := wp.Submit(letter.Job); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to replay job %d: %w", letter.Job.ID, err)
+			}
+			rejected = append(rejected, letter)
+			continue
+		}
+		replayed++
+	}
+
+	wp.requeueDeadLetters(rejected)
+	return replayed, firstErr
+}
+
+// requeueDeadLetters puts letters that could not be replayed back into the
+// buffer ahead of the jobs that failed since it was drained
+func (wp *WorkerPool[T]) requeueDeadLetters(letters []DeadLetter[T]) {
+	if len(letters) == 0 {
+		return
+	}
+	wp.deadMu.Lock()
+	defer wp.deadMu.Unlock()
+
+	wp.deadLetters = append(letters, wp.deadLetters...)
+	wp.trimDeadLetters()
+}
@@ -0,0 +1,53 @@
+package concurrency
+
+// follower is a job coalesced into an in-flight job with the same DedupKey
+type follower[T any] struct {
+	job Job[T]
+	seq uint64
+}
+
+// joinInFlight attaches job to an in-flight job with the same DedupKey, or
+// registers job as the leader for its key. It returns true if job was
+// attached and must not be queued. Must be called with queueMu held.
+func (wp *WorkerPool[T]) joinInFlight(job Job[T]) bool {
+	if job.DedupKey == "" {
+		return false
+	}
+
+	if followers, ok := wp.inFlight[job.DedupKey]; ok {
+		wp.inFlight[job.DedupKey] = append(followers, follower[T]{job: job, seq: wp.seq})
+		wp.seq++
+		return true
+	}
+
+	if wp.inFlight == nil {
+		wp.inFlight = make(map[string][]follower[T])
+	}
+	wp.inFlight[job.DedupKey] = nil
+	return false
+}
+
+// takeFollowers removes the dedup key of a finished leader job and returns
+// the jobs that were coalesced into it
+func (wp *WorkerPool[T]) takeFollowers(job Job[T]) []follower[T] {
+	if job.DedupKey == "" {
+		return nil
+	}
+
+	wp.queueMu.Lock()
+	defer wp.queueMu.Unlock()
+
+	followers := wp.inFlight[job.DedupKey]
+	delete(wp.inFlight, job.DedupKey)
+	return followers
+}
+
+// sharedResult copies the leader's result for a coalesced follower job
+func sharedResult[T any](leader Result[T], f follower[T]) Result[T] {
+	result := leader
+	result.JobID = f.job.ID
+	result.Seq = f.seq
+	result.Labels = f.job.Labels
+	result.Shared = true
+	return result
+}
@@ -0,0 +1,109 @@
+package concurrency
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// DirectExecutor runs jobs without a queue, bounding concurrency with a
+// weighted semaphore. Jobs start as soon as a slot is free, so no time is
+// spent in a job buffer that could be mistaken for operation latency.
+// Results have no worker, WorkerID is always 0.
+type DirectExecutor[T any] struct {
+	sem  *semaphore.Weighted
+	opts poolOptions
+	seq  uint64
+	mu   sync.Mutex
+}
+
+// NewDirectExecutor creates an executor running at most workers jobs at once
+func NewDirectExecutor[T any](workers int, opts ...Option) *DirectExecutor[T] {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var options poolOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &DirectExecutor[T]{
+		sem:  semaphore.NewWeighted(int64(workers)),
+		opts: options,
+	}
+}
+
+// Run waits for a free slot and executes job in the calling goroutine.
+// Result.Duration excludes the time spent waiting for the slot.
+func (d *DirectExecutor[T]) Run(ctx context.Context, job Job[T]) Result[T] {
+	seq := d.nextSeq()
+
+	if err := d.sem.Acquire(ctx, 1); err != nil {
+		return Result[T]{JobID: job.ID, Seq: seq, Error: err, Labels: job.Labels}
+	}
+	defer d.sem.Release(1)
+
+	return d.execute(ctx, job, seq)
+}
+
+// RunAll executes jobs concurrently and returns their results in job order
+func (d *DirectExecutor[T]) RunAll(ctx context.Context, jobs []Job[T]) []Result[T] {
+	results := make([]Result[T], len(jobs))
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		seq := d.nextSeq()
+
+		// Acquire before spawning so at most workers goroutines exist at once
+		if err := d.sem.Acquire(ctx, 1); err != nil {
+			results[i] = Result[T]{JobID: job.ID, Seq: seq, Error: err, Labels: job.Labels}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, job Job[T]) {
+			defer wg.Done()
+			defer d.sem.Release(1)
+			results[i] = d.execute(ctx, job, seq)
+		}(i, job)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// execute runs job once a slot is held
+func (d *DirectExecutor[T]) execute(ctx context.Context, job Job[T], seq uint64) Result[T] {
+	if d.opts.limiter != nil {
+		if err := d.opts.limiter.Wait(ctx); err != nil {
+			return Result[T]{JobID: job.ID, Seq: seq, Error: err, Labels: job.Labels}
+		}
+	}
+
+	start := time.Now()
+	data, attempts, err := runJob(ctx, job)
+
+	return Result[T]{
+		JobID:    job.ID,
+		Seq:      seq,
+		Data:     data,
+		Error:    err,
+		Duration: time.Since(start),
+		Attempts: attempts,
+		Labels:   job.Labels,
+	}
+}
+
+// nextSeq returns the submission sequence number of the next job
+func (d *DirectExecutor[T]) nextSeq() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seq := d.seq
+	d.seq++
+	return seq
+}
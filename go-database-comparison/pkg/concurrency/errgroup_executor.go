@@ -0,0 +1,148 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// Executor is the submit/collect interface shared by WorkerPool and
+// ErrGroupExecutor, so benchmarks can swap one for the other
+type Executor[T any] interface {
+	Start()
+	Submit(job Job[T]) error
+	Results() <-chan Result[T]
+	Close()
+	Stop()
+}
+
+var (
+	_ Executor[any] = (*WorkerPool[any])(nil)
+	_ Executor[any] = (*ErrGroupExecutor[any])(nil)
+)
+
+// ErrGroupExecutor runs each job in its own goroutine started by an
+// errgroup, with a weighted semaphore bounding concurrency. There is no job
+// queue: Submit blocks until a slot is free.
+type ErrGroupExecutor[T any] struct {
+	workers int
+	group   *errgroup.Group
+	sem     *semaphore.Weighted
+	results chan Result[T]
+	ctx     context.Context
+	cancel  context.CancelFunc
+	seq     uint64
+	started bool
+	closing bool
+	closeMu sync.Once
+	mu      sync.Mutex
+}
+
+// NewErrGroupExecutor creates an executor running at most workers jobs at once
+func NewErrGroupExecutor[T any](ctx context.Context, workers int) *ErrGroupExecutor[T] {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	execCtx, cancel := context.WithCancel(ctx)
+	group, groupCtx := errgroup.WithContext(execCtx)
+
+	return &ErrGroupExecutor[T]{
+		workers: workers,
+		group:   group,
+		sem:     semaphore.NewWeighted(int64(workers)),
+		results: make(chan Result[T], workers*2),
+		ctx:     groupCtx,
+		cancel:  cancel,
+	}
+}
+
+// Start marks the executor as ready to accept jobs
+func (e *ErrGroupExecutor[T]) Start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.started = true
+}
+
+// Submit waits for a free slot and runs job in a new goroutine
+func (e *ErrGroupExecutor[T]) Submit(job Job[T]) error {
+	e.mu.Lock()
+	if !e.started {
+		e.mu.Unlock()
+		return fmt.Errorf("executor not started")
+	}
+	if e.closing {
+		e.mu.Unlock()
+		return fmt.Errorf("executor closed")
+	}
+	seq := e.seq
+	e.seq++
+	e.mu.Unlock()
+
+	if err := e.sem.Acquire(e.ctx, 1); err != nil {
+		return err
+	}
+
+	e.group.Go(func() error {
+		defer e.sem.Release(1)
+
+		start := time.Now()
+		data, attempts, err := runJob(e.ctx, job)
+		result := Result[T]{
+			JobID:    job.ID,
+			Seq:      seq,
+			Data:     data,
+			Error:    err,
+			Duration: time.Since(start),
+			Attempts: attempts,
+			Labels:   job.Labels,
+		}
+
+		select {
+		case e.results <- result:
+		case <-e.ctx.Done():
+		}
+		// Job failures are reported in the Result, never to the group
+		return nil
+	})
+	return nil
+}
+
+// Results returns the channel results are delivered on as jobs complete.
+// It is closed after Close once every job has finished, or on Stop.
+func (e *ErrGroupExecutor[T]) Results() <-chan Result[T] {
+	return e.results
+}
+
+// Close stops accepting jobs and closes the results channel once all
+// submitted jobs have finished
+func (e *ErrGroupExecutor[T]) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closing {
+		return
+	}
+	e.closing = true
+	go func() {
+		e.group.Wait()
+		e.closeMu.Do(func() { close(e.results) })
+	}()
+}
+
+// Stop cancels running jobs and waits for their goroutines to exit
+func (e *ErrGroupExecutor[T]) Stop() {
+	e.mu.Lock()
+	e.closing = true
+	e.mu.Unlock()
+
+	e.cancel()
+	e.group.Wait()
+	e.closeMu.Do(func() { close(e.results) })
+}
@@ -0,0 +1,51 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+)
+
+// Execute submits job and waits for its own Result, so callers don't have to
+// drain the shared results channel. The job ID must not be in use by another
+// pending Execute call. If ctx is done first the job still runs, but its
+// Result is discarded.
+func (wp *WorkerPool[T]) Execute(ctx context.Context, job Job[T]) (Result[T], error) {
+	done := make(chan Result[T], 1)
+
+	wp.waitMu.Lock()
+	if wp.waiters == nil {
+		wp.waiters = make(map[int]chan Result[T])
+	}
+	if _, exists := wp.waiters[job.ID]; exists {
+		wp.waitMu.Unlock()
+		return Result[T]{}, fmt.Errorf("job %d is already being executed", job.ID)
+	}
+	wp.waiters[job.ID] = done
+	wp.waitMu.Unlock()
+
+	if err := wp.Submit(job); err != nil {
+		wp.takeWaiter(job.ID)
+		return Result[T]{}, err
+	}
+
+	select {
+	case result := <-done:
+		return result, nil
+	case <-ctx.Done():
+		return Result[T]{}, ctx.Err()
+	case <-wp.ctx.Done():
+		return Result[T]{}, wp.ctx.Err()
+	}
+}
+
+// takeWaiter removes and returns the Execute channel registered for jobID
+func (wp *WorkerPool[T]) takeWaiter(jobID int) (chan Result[T], bool) {
+	wp.waitMu.Lock()
+	defer wp.waitMu.Unlock()
+
+	ch, ok := wp.waiters[jobID]
+	if ok {
+		delete(wp.waiters, jobID)
+	}
+	return ch, ok
+}
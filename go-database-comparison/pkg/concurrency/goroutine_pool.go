@@ -4,60 +4,150 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel"
 )
 
-// WorkerPool represents a goroutine pool for database operations
-type WorkerPool struct {
-	workers    int
-	jobQueue   chan Job
-	results    chan Result
-	wg         sync.WaitGroup
-	ctx        context.Context
-	cancel     context.CancelFunc
-	started    bool
-	mu         sync.RWMutex
+// WorkerPool represents a goroutine pool for database operations.
+// T is the type of data produced by the pool's jobs.
+type WorkerPool[T any] struct {
+	workers     int
+	jobQueue    jobQueue[T]
+	queueMu     sync.Mutex               // Guards seq, delivered, drained and inFlight, and orders submissions
+	seq         uint64                   // Accepted jobs
+	delivered   uint64                   // Results handed to their consumer
+	drained     chan struct{}            // Closed when delivered catches up with seq, for WaitAll
+	inFlight    map[string][]follower[T] // Jobs coalesced by DedupKey
+	results     chan Result[T]
+	wg          sync.WaitGroup
+	ctx         context.Context
+	cancel      context.CancelFunc
+	started     bool
+	closing     bool // Set by Close and Stop, no further jobs are accepted
+	stopped     bool // Set by Stop, the pool cannot be restarted
+	closeQueue  sync.Once
+	shutdown    chan struct{} // Closed by Close and Stop, ends schedules
+	closeOut    sync.Once
+	mu          sync.RWMutex
+	opts        poolOptions
+	waitMu      sync.Mutex
+	waiters     map[int]chan Result[T] // Results routed to Execute callers by job ID
+	onResult    func(Result[T])        // Called for every finished job before delivery
+	cancelMu    sync.Mutex
+	running     map[int]context.CancelFunc // Contexts of running jobs, for Cancel
+	cancelled   map[int]bool               // Jobs cancelled before they finished
+	pauseMu     sync.Mutex
+	paused      bool
+	active      int           // Jobs currently being executed
+	resumeCh    chan struct{} // Closed by Resume
+	idleCh      chan struct{} // Closed when active drops to zero
+	deadMu      sync.Mutex
+	deadLetters []DeadLetter[T] // Jobs that failed after their final attempt
+	deadDropped int64           // Dead letters evicted from the full buffer
+	statsMu     sync.Mutex
+	metrics     poolMetrics
+	telemetry   poolTelemetry
 }
 
 // Job represents a task to be executed by workers
-type Job struct {
+type Job[T any] struct {
 	ID       int
-	TaskFunc func(context.Context) (interface{}, error)
+	TaskFunc func(context.Context) (T, error)
 	Timeout  time.Duration
+	Priority int               // Higher priority jobs are started first, see PriorityHigh
+	Retry    *RetryPolicy      // Optional retry of failed attempts, nil runs the job once
+	Labels   map[string]string // Metadata copied to the Result, see LabelOperation
+	DedupKey string            // Jobs submitted while one with the same key is in flight share its result
+
+	// TraceContext is the parent of the job span when tracing is enabled,
+	// typically the request context of the caller. Nil uses the pool context.
+	TraceContext context.Context
 }
 
 // Result represents the result of a job execution
-type Result struct {
-	JobID    int
-	Data     interface{}
-	Error    error
-	Duration time.Duration
+type Result[T any] struct {
+	JobID     int
+	WorkerID  int           // ID of the worker goroutine that executed the job
+	Seq       uint64        // Submission order of the job within the pool, starting at 0
+	QueueWait time.Duration // Time between Submit and a worker starting the job
+	Data      T
+	Error     error
+	Duration  time.Duration     // Total time of all attempts including backoff
+	Attempts  int               // Number of times TaskFunc was called
+	Labels    map[string]string // Labels of the job
+	Shared    bool              // Result was copied from an in-flight job with the same DedupKey
+}
+
+// Well-known job label keys
+const (
+	LabelLibrary   = "library"
+	LabelOperation = "operation"
+	LabelRunID     = "run_id"
+)
+
+// PanicError is the Result error of a job whose TaskFunc panicked
+type PanicError struct {
+	Value interface{} // Value passed to panic
+	Stack []byte      // Stack trace of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job panicked: %v\n%s", e.Value, e.Stack)
 }
 
-// NewWorkerPool creates a new goroutine pool
-func NewWorkerPool(ctx context.Context, workers int) *WorkerPool {
+// Compatibility aliases for the untyped API, where job data is interface{}
+type (
+	UntypedWorkerPool = WorkerPool[any]
+	UntypedJob        = Job[any]
+	UntypedResult     = Result[any]
+)
+
+// NewTypedWorkerPool creates a new goroutine pool whose jobs produce values of type T
+func NewTypedWorkerPool[T any](ctx context.Context, workers int, opts ...Option) *WorkerPool[T] {
 	if workers <= 0 {
 		workers = runtime.NumCPU()
 	}
 
+	var options poolOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	poolCtx, cancel := context.WithCancel(ctx)
 	
-	return &WorkerPool{
+	queueSize := workers * 10 // Larger buffer for high-load scenarios
+	var queue jobQueue[T] = newCentralQueue[T](queueSize)
+	if options.workStealing {
+		queue = newStealingQueue[T](workers, queueSize)
+	}
+	
+	return &WorkerPool[T]{
 		workers:  workers,
-		jobQueue: make(chan Job, workers*10), // Larger buffer for high-load scenarios
-		results:  make(chan Result, workers*2),
+		jobQueue: queue,
+		results:  make(chan Result[T], workers*2),
+		shutdown: make(chan struct{}),
 		ctx:      poolCtx,
 		cancel:   cancel,
+		opts:     options,
 	}
 }
 
+// NewWorkerPool creates a new untyped goroutine pool
+func NewWorkerPool(ctx context.Context, workers int, opts ...Option) *UntypedWorkerPool {
+	return NewTypedWorkerPool[any](ctx, workers, opts...)
+}
+
 // Start initializes and starts the worker pool
-func (wp *WorkerPool) Start() {
+func (wp *WorkerPool[T]) Start() {
 	wp.mu.Lock()
 	defer wp.mu.Unlock()
 	
-	if wp.started {
+	if wp.started || wp.stopped {
 		return
 	}
 
@@ -67,95 +157,263 @@ func (wp *WorkerPool) Start() {
 	}
 	
 	wp.started = true
+	if err := wp.registerMetrics(); err != nil {
+		otel.Handle(err)
+	}
+	wp.statsMu.Lock()
+	wp.metrics.startedAt = time.Now()
+	wp.statsMu.Unlock()
 }
 
 // worker represents a single worker goroutine
-func (wp *WorkerPool) worker(id int) {
+func (wp *WorkerPool[T]) worker(id int) {
 	defer wp.wg.Done()
 	
 	for {
-		select {
-		case job, ok := <-wp.jobQueue:
-			if !ok {
-				return // Channel closed, exit worker
-			}
-			
-			result := wp.executeJob(job)
-			
-			select {
-			case wp.results <- result:
-			case <-wp.ctx.Done():
+		if wp.ctx.Err() != nil {
+			return
+		}
+		if !wp.jobQueue.wait(wp.ctx, id) {
+			return // Queue closed and drained, exit worker
+		}
+		
+		// Hold the job in the queue while the pool is paused
+		if !wp.acquireSlot() {
+			return
+		}
+		item, ok := wp.jobQueue.take(id)
+		if !ok {
+			wp.releaseSlot() // Another worker took the job first
+			continue
+		}
+		job := item.job
+		queueWait := time.Since(item.enqueued)
+		wp.recordQueueWait(job, queueWait)
+		
+		// Wait for the shared rate limiter before starting the job
+		if wp.opts.limiter != nil {
+			if err := wp.opts.limiter.Wait(wp.ctx); err != nil {
+				wp.releaseSlot()
 				return
 			}
-			
-		case <-wp.ctx.Done():
+		}
+		
+		result := wp.executeJob(id, job, item.enqueued)
+		wp.releaseSlot()
+		result.Seq = item.seq
+		result.QueueWait = queueWait
+		wp.recordStats(result)
+		wp.recordDeadLetter(job, result)
+		wp.opts.breaker.record(job.Labels, result.Error)
+		if wp.onResult != nil {
+			wp.onResult(result)
+		}
+		
+		if !wp.deliver(result) {
 			return
 		}
+		for _, f := range wp.takeFollowers(job) {
+			if !wp.deliver(sharedResult(result, f)) {
+				return
+			}
+		}
+	}
+}
+
+// deliver hands a result to a waiting Execute call, or else to the shared
+// results channel. It returns false if the pool was stopped first.
+func (wp *WorkerPool[T]) deliver(result Result[T]) bool {
+	if done, ok := wp.takeWaiter(result.JobID); ok {
+		done <- result
+		wp.markDelivered()
+		return true
+	}
+
+	select {
+	case wp.results <- result:
+		wp.markDelivered()
+		return true
+	case <-wp.ctx.Done():
+		return false
 	}
 }
 
-// executeJob executes a single job with timeout and error handling
-func (wp *WorkerPool) executeJob(job Job) Result {
+// executeJob executes a single job with timeout, retries and error handling
+func (wp *WorkerPool[T]) executeJob(workerID int, job Job[T], enqueued time.Time) Result[T] {
 	start := time.Now()
 	
-	// Create job-specific context with timeout
-	jobCtx := wp.ctx
-	if job.Timeout > 0 {
-		var cancel context.CancelFunc
-		jobCtx, cancel = context.WithTimeout(wp.ctx, job.Timeout)
-		defer cancel()
+	jobCtx, cancel, ok := wp.startJob(job.ID)
+	if !ok {
+		return Result[T]{
+			JobID:    job.ID,
+			WorkerID: workerID,
+			Error:    fmt.Errorf("%w: %w", ErrJobCancelled, context.Canceled),
+			Labels:   job.Labels,
+		}
 	}
 	
-	// Execute the job
-	data, err := job.TaskFunc(jobCtx)
+	// Fit the job's timeout into what is left of the run's deadline
+	timeout, err := wp.opts.budget.timeout(wp.ctx, job.Timeout)
+	if err != nil {
+		return Result[T]{
+			JobID:    job.ID,
+			WorkerID: workerID,
+			Error:    wp.finishJob(job.ID, cancel, err),
+			Labels:   job.Labels,
+		}
+	}
+	job.Timeout = timeout
+	
+	jobCtx = withWorkerID(jobCtx, workerID)
+	jobCtx, endSpan := wp.startSpan(jobCtx, workerID, job, enqueued)
+	data, attempts, err := runJob(jobCtx, job)
+	err = wp.finishJob(job.ID, cancel, err)
+	endSpan(attempts, err)
 	duration := time.Since(start)
 	
-	return Result{
+	return Result[T]{
 		JobID:    job.ID,
+		WorkerID: workerID,
 		Data:     data,
 		Error:    err,
 		Duration: duration,
+		Attempts: attempts,
+		Labels:   job.Labels,
+	}
+}
+
+// runJob calls the job's TaskFunc, retrying failed attempts per job.Retry
+func runJob[T any](ctx context.Context, job Job[T]) (data T, attempts int, err error) {
+	for {
+		attempts++
+		data, err = runAttempt(ctx, job)
+		if !job.Retry.shouldRetry(attempts, err) {
+			return data, attempts, err
+		}
+		if sleepErr := sleep(ctx, job.Retry.backoff(attempts)); sleepErr != nil {
+			return data, attempts, err
+		}
+	}
+}
+
+// runAttempt calls the job's TaskFunc once with its own timeout. A panic in
+// TaskFunc is recovered and returned as a *PanicError so the worker survives.
+func runAttempt[T any](ctx context.Context, job Job[T]) (data T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero T
+			data, err = zero, &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	// Create job-specific context with timeout
+	jobCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
 	}
+	
+	return job.TaskFunc(jobCtx)
 }
 
 // Submit submits a job to the worker pool
-func (wp *WorkerPool) Submit(job Job) error {
+func (wp *WorkerPool[T]) Submit(job Job[T]) error {
 	wp.mu.RLock()
 	defer wp.mu.RUnlock()
 	
+	if wp.stopped {
+		return fmt.Errorf("worker pool stopped")
+	}
 	if !wp.started {
 		return fmt.Errorf("worker pool not started")
 	}
+	if wp.closing {
+		return fmt.Errorf("worker pool closed")
+	}
 	
-	select {
-	case wp.jobQueue <- job:
+	if err := wp.ctx.Err(); err != nil {
+		return err
+	}
+	if err := wp.opts.breaker.allow(job.Labels); err != nil {
+		return err
+	}
+	if job.Timeout == 0 {
+		job.Timeout = wp.opts.defaultTimeout
+	}
+
+	wp.queueMu.Lock()
+	defer wp.queueMu.Unlock()
+
+	// Coalesce with an in-flight job sharing the dedup key
+	if wp.joinInFlight(job) {
 		return nil
-	case <-wp.ctx.Done():
-		return wp.ctx.Err()
-	default:
+	}
+
+	if !wp.jobQueue.push(queuedJob[T]{job: job, seq: wp.seq, enqueued: time.Now()}) {
+		if job.DedupKey != "" {
+			delete(wp.inFlight, job.DedupKey)
+		}
 		return fmt.Errorf("job queue full")
 	}
+	wp.seq++
+	return nil
+}
+
+// Results returns the channel results are delivered on as jobs complete.
+// It is closed once every queued job has finished after Close, or on Stop,
+// so consumers can range over it.
+func (wp *WorkerPool[T]) Results() <-chan Result[T] {
+	return wp.results
+}
+
+// Close stops accepting new jobs and closes the results channel once the
+// queued jobs have finished. Unlike Stop it does not cancel running work.
+func (wp *WorkerPool[T]) Close() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	
+	if !wp.started || wp.closing {
+		return
+	}
+	
+	wp.closing = true
+	wp.closeQueue.Do(func() {
+		close(wp.shutdown)
+		wp.jobQueue.close()
+	})
+	go func() {
+		wp.wg.Wait()
+		wp.closeOut.Do(func() { close(wp.results) })
+	}()
 }
 
 // GetResult retrieves a result from the worker pool
-func (wp *WorkerPool) GetResult() (Result, error) {
+func (wp *WorkerPool[T]) GetResult() (Result[T], error) {
 	select {
-	case result := <-wp.results:
+	case result, ok := <-wp.results:
+		if !ok {
+			return Result[T]{}, fmt.Errorf("worker pool closed")
+		}
 		return result, nil
 	case <-wp.ctx.Done():
-		return Result{}, wp.ctx.Err()
+		return Result[T]{}, wp.ctx.Err()
 	}
 }
 
-// GetResults retrieves multiple results with timeout
-func (wp *WorkerPool) GetResults(count int, timeout time.Duration) ([]Result, error) {
-	results := make([]Result, 0, count)
+// GetResults retrieves multiple results with timeout. count must match the
+// number of accepted jobs; CollectAll does the counting for the caller.
+func (wp *WorkerPool[T]) GetResults(count int, timeout time.Duration) ([]Result[T], error) {
+	results := make([]Result[T], 0, count)
 	timeoutCtx, cancel := context.WithTimeout(wp.ctx, timeout)
 	defer cancel()
 	
 	for i := 0; i < count; i++ {
 		select {
-		case result := <-wp.results:
+		case result, ok := <-wp.results:
+			if !ok {
+				return results, fmt.Errorf("worker pool closed, got %d/%d results", len(results), count)
+			}
 			results = append(results, result)
 		case <-timeoutCtx.Done():
 			return results, fmt.Errorf("timeout waiting for results, got %d/%d", len(results), count)
@@ -165,112 +423,173 @@ func (wp *WorkerPool) GetResults(count int, timeout time.Duration) ([]Result, er
 	return results, nil
 }
 
-// Stop gracefully shuts down the worker pool
-func (wp *WorkerPool) Stop() {
+// Shutdown stops accepting jobs, lets queued and running jobs finish and
+// then stops the pool. Results must be consumed meanwhile. If ctx is done
+// first the remaining jobs are cancelled as with Stop and ctx.Err() is returned.
+func (wp *WorkerPool[T]) Shutdown(ctx context.Context) error {
+	wp.Resume() // A paused pool would never drain
+	wp.Close()
+	
+	drained := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(drained)
+	}()
+	
+	select {
+	case <-drained:
+		wp.Stop()
+		return nil
+	case <-ctx.Done():
+		wp.Stop()
+		return ctx.Err()
+	}
+}
+
+// Stop shuts the worker pool down immediately. Submissions are rejected,
+// running jobs are cancelled and queued jobs are dropped; results already
+// delivered stay readable on Results until it is drained. Use Shutdown to
+// let queued jobs finish first. A stopped pool cannot be started again.
+func (wp *WorkerPool[T]) Stop() {
 	wp.mu.Lock()
 	defer wp.mu.Unlock()
 	
+	wp.cancel() // Cancel context to signal workers to stop, also releases an unstarted pool
 	if !wp.started {
 		return
 	}
 	
-	wp.cancel() // Cancel context to signal workers to stop
-	close(wp.jobQueue) // Close job queue
+	wp.closing = true // Stop accepting jobs
+	wp.closeQueue.Do(func() { // Close job queue
+		close(wp.shutdown)
+		wp.jobQueue.close()
+	})
 	wp.wg.Wait() // Wait for all workers to finish
-	close(wp.results) // Close results channel
+	wp.closeOut.Do(func() { close(wp.results) }) // Close results channel
 	wp.started = false
-}
-
-// Stats returns worker pool statistics
-func (wp *WorkerPool) Stats() map[string]interface{} {
-	wp.mu.RLock()
-	defer wp.mu.RUnlock()
-	
-	return map[string]interface{}{
-		"workers":       wp.workers,
-		"jobs_queued":   len(wp.jobQueue),
-		"results_ready": len(wp.results),
-		"started":       wp.started,
-	}
+	wp.stopped = true
+	wp.unregisterMetrics()
+	wp.statsMu.Lock()
+	wp.metrics.stoppedAt = time.Now()
+	wp.statsMu.Unlock()
 }
 
 // DatabaseBenchmarkPool specialized worker pool for database benchmarking
 type DatabaseBenchmarkPool struct {
-	*WorkerPool
-	operations map[string]int64
-	durations  map[string][]time.Duration
+	*UntypedWorkerPool
+	histograms map[string]*histogram // Durations per operation
+	nextID     atomic.Int64
 	mu         sync.Mutex
 }
 
-// NewDatabaseBenchmarkPool creates a specialized pool for database benchmarking
-func NewDatabaseBenchmarkPool(ctx context.Context, workers int) *DatabaseBenchmarkPool {
-	return &DatabaseBenchmarkPool{
-		WorkerPool: NewWorkerPool(ctx, workers),
-		operations: make(map[string]int64),
-		durations:  make(map[string][]time.Duration),
+// DefaultBenchmarkJobTimeout is the timeout of benchmark jobs unless
+// WithDefaultTimeout is passed to NewDatabaseBenchmarkPool
+const DefaultBenchmarkJobTimeout = 30 * time.Second
+
+// OperationStats summarizes the recorded durations of one operation.
+// Percentiles come from a histogram and are accurate to about 3%.
+type OperationStats struct {
+	Operation   string        `json:"operation"`
+	Count       int64         `json:"count"`
+	TotalTime   time.Duration `json:"total_time"`
+	AvgDuration time.Duration `json:"avg_duration"`
+	MinDuration time.Duration `json:"min_duration"`
+	MaxDuration time.Duration `json:"max_duration"`
+	P50Duration time.Duration `json:"p50_duration"`
+	P95Duration time.Duration `json:"p95_duration"`
+	P99Duration time.Duration `json:"p99_duration"`
+}
+
+// BenchmarkStats is the state of a DatabaseBenchmarkPool and the statistics
+// of every recorded operation, sorted by operation name
+type BenchmarkStats struct {
+	Pool       PoolStats        `json:"pool"`
+	Operations []OperationStats `json:"operations"`
+}
+
+// Operation returns the statistics of the named operation, if recorded
+func (s BenchmarkStats) Operation(name string) (OperationStats, bool) {
+	for _, op := range s.Operations {
+		if op.Operation == name {
+			return op, true
+		}
+	}
+	return OperationStats{}, false
+}
+
+// NewDatabaseBenchmarkPool creates a specialized pool for database benchmarking.
+// Successful jobs carrying a LabelOperation label are recorded automatically.
+func NewDatabaseBenchmarkPool(ctx context.Context, workers int, opts ...Option) *DatabaseBenchmarkPool {
+	opts = append([]Option{WithDefaultTimeout(DefaultBenchmarkJobTimeout)}, opts...)
+	dbp := &DatabaseBenchmarkPool{
+		UntypedWorkerPool: NewWorkerPool(ctx, workers, opts...),
+		histograms:        make(map[string]*histogram),
 	}
+	dbp.onResult = dbp.recordResult
+	return dbp
 }
 
-// SubmitBenchmarkJob submits a database benchmark job
+// SubmitBenchmarkJob submits a database benchmark job labeled with its
+// operation. Job IDs are assigned sequentially starting at 1.
 func (dbp *DatabaseBenchmarkPool) SubmitBenchmarkJob(operation string, taskFunc func(context.Context) (interface{}, error)) error {
-	job := Job{
-		ID:       int(time.Now().UnixNano()),
+	job := UntypedJob{
+		ID:       int(dbp.nextID.Add(1)),
 		TaskFunc: taskFunc,
-		Timeout:  30 * time.Second, // Default timeout for DB operations
+		Labels:   map[string]string{LabelOperation: operation},
 	}
 	
 	return dbp.Submit(job)
 }
 
+// recordResult aggregates a finished job by its operation label
+func (dbp *DatabaseBenchmarkPool) recordResult(result UntypedResult) {
+	operation := result.Labels[LabelOperation]
+	if operation == "" || result.Error != nil {
+		return
+	}
+	dbp.RecordOperation(operation, result.Duration)
+}
+
 // RecordOperation records the result of a database operation
 func (dbp *DatabaseBenchmarkPool) RecordOperation(operation string, duration time.Duration) {
 	dbp.mu.Lock()
 	defer dbp.mu.Unlock()
 	
-	dbp.operations[operation]++
-	if dbp.durations[operation] == nil {
-		dbp.durations[operation] = make([]time.Duration, 0)
+	h, ok := dbp.histograms[operation]
+	if !ok {
+		h = &histogram{}
+		dbp.histograms[operation] = h
 	}
-	dbp.durations[operation] = append(dbp.durations[operation], duration)
+	h.record(duration)
 }
 
 // GetBenchmarkStats returns comprehensive benchmark statistics
-func (dbp *DatabaseBenchmarkPool) GetBenchmarkStats() map[string]interface{} {
+func (dbp *DatabaseBenchmarkPool) GetBenchmarkStats() BenchmarkStats {
+	stats := BenchmarkStats{Pool: dbp.Stats()}
+	
 	dbp.mu.Lock()
 	defer dbp.mu.Unlock()
 	
-	stats := make(map[string]interface{})
-	
-	for operation, count := range dbp.operations {
-		durations := dbp.durations[operation]
-		if len(durations) == 0 {
+	for operation, h := range dbp.histograms {
+		if h.count == 0 {
 			continue
 		}
 		
-		var total time.Duration
-		min := durations[0]
-		max := durations[0]
-		
-		for _, d := range durations {
-			total += d
-			if d < min {
-				min = d
-			}
-			if d > max {
-				max = d
-			}
-		}
-		
-		avg := total / time.Duration(len(durations))
-		
-		stats[operation] = map[string]interface{}{
-			"count":       count,
-			"avg_duration": avg.String(),
-			"min_duration": min.String(),
-			"max_duration": max.String(),
-			"total_time":   total.String(),
-		}
+		stats.Operations = append(stats.Operations, OperationStats{
+			Operation:   operation,
+			Count:       h.count,
+			TotalTime:   h.sum,
+			AvgDuration: h.mean(),
+			MinDuration: h.min,
+			MaxDuration: h.max,
+			P50Duration: h.percentile(0.50),
+			P95Duration: h.percentile(0.95),
+			P99Duration: h.percentile(0.99),
+		})
 	}
 	
+	sort.Slice(stats.Operations, func(i, j int) bool {
+		return stats.Operations[i].Operation < stats.Operations[j].Operation
+	})
 	return stats
-}
\ No newline at end of file
+}
@@ -0,0 +1,269 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// drain consumes the pool's results until the channel is closed
+func drain[T any](wp *WorkerPool[T]) <-chan int {
+	done := make(chan int, 1)
+	go func() {
+		n := 0
+		for range wp.Results() {
+			n++
+		}
+		done <- n
+	}()
+	return done
+}
+
+// submitLoop submits short jobs from several goroutines until Submit fails
+func submitLoop(wp *UntypedWorkerPool, goroutines int) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				err := wp.Submit(UntypedJob{
+					ID: g*1_000_000 + i,
+					TaskFunc: func(ctx context.Context) (interface{}, error) {
+						select {
+						case <-time.After(100 * time.Microsecond):
+							return nil, nil
+						case <-ctx.Done():
+							return nil, ctx.Err()
+						}
+					},
+				})
+				if err != nil && err.Error() != "job queue full" {
+					return
+				}
+			}
+		}(g)
+	}
+	return &wg
+}
+
+func TestStopRacesSubmit(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewWorkerPool(context.Background(), 4)
+	wp.Start()
+	consumed := drain(wp)
+	submitters := submitLoop(wp, 8)
+
+	time.Sleep(20 * time.Millisecond)
+	wp.Stop()
+	submitters.Wait()
+	<-consumed
+
+	if err := wp.Submit(UntypedJob{ID: -1}); err == nil {
+		t.Fatal("Submit after Stop succeeded")
+	}
+	if stats := wp.Stats(); stats.Started {
+		t.Fatal("pool reports started after Stop")
+	}
+}
+
+func TestShutdownRacesSubmit(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewWorkerPool(context.Background(), 4)
+	wp.Start()
+	consumed := drain(wp)
+	submitters := submitLoop(wp, 8)
+
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wp.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	submitters.Wait()
+
+	// Every accepted job was delivered before the results channel closed
+	n := <-consumed
+	if stats := wp.Stats(); uint64(n) != stats.Submitted {
+		t.Fatalf("consumed %d results, pool accepted %d jobs", n, stats.Submitted)
+	}
+}
+
+func TestStopTwice(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewWorkerPool(context.Background(), 2)
+	wp.Start()
+	wp.Stop()
+	wp.Stop()
+
+	wp.Start()
+	if err := wp.Submit(UntypedJob{ID: 1}); err == nil {
+		t.Fatal("stopped pool was restarted")
+	}
+}
+
+func TestStopBeforeStart(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewWorkerPool(context.Background(), 2)
+	wp.Stop()
+	wp.Stop()
+	if err := wp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown of unstarted pool: %v", err)
+	}
+}
+
+func TestConcurrentStopAndShutdown(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewWorkerPool(context.Background(), 4)
+	wp.Start()
+	consumed := drain(wp)
+	submitters := submitLoop(wp, 4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			wp.Stop()
+		}()
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_ = wp.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+	submitters.Wait()
+	<-consumed
+}
+
+func TestShutdownContextExpires(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewWorkerPool(context.Background(), 2)
+	wp.Start()
+
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	err := wp.Submit(UntypedJob{
+		ID: 1,
+		TaskFunc: func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		},
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wp.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The running job was cancelled and the results channel closed
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("job context ended with %v, want %v", err, context.Canceled)
+	}
+	for range wp.Results() {
+	}
+	if err := wp.Submit(UntypedJob{ID: 2}); err == nil {
+		t.Fatal("Submit after Shutdown succeeded")
+	}
+}
+
+// TestCancelQueuedJob checks that a job cancelled while queued behind a
+// paused pool never runs and is reported as cancelled
+func TestCancelQueuedJob(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewTypedWorkerPool[int](context.Background(), 1)
+	wp.Start()
+	defer wp.Stop()
+	if err := wp.Pause(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	ran := make(map[int]bool)
+	for id := 1; id <= 3; id++ {
+		err := wp.Submit(Job[int]{
+			ID: id,
+			TaskFunc: func(context.Context) (int, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				ran[id] = true
+				return id, nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !wp.Cancel(3) {
+		t.Fatal("Cancel did not find queued job 3")
+	}
+	wp.Resume()
+
+	results, err := wp.GetResults(3, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.JobID != 3 {
+			if result.Error != nil {
+				t.Errorf("job %d failed: %v", result.JobID, result.Error)
+			}
+			continue
+		}
+		if !errors.Is(result.Error, context.Canceled) || !errors.Is(result.Error, ErrJobCancelled) {
+			t.Errorf("cancelled job reported %v, want context.Canceled", result.Error)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if ran[3] {
+		t.Error("cancelled job 3 ran")
+	}
+}
+
+// TestRequeueDeadLettersBounded checks that dead letters a replay put back
+// do not grow the buffer past its capacity when jobs failed meanwhile
+func TestRequeueDeadLettersBounded(t *testing.T) {
+	const capacity = 3
+	wp := NewTypedWorkerPool[int](context.Background(), 1, WithDeadLetters(capacity))
+	defer wp.Stop()
+
+	failure := errors.New("failed")
+	letter := func(id int) DeadLetter[int] { return DeadLetter[int]{Job: Job[int]{ID: id}, Error: failure} }
+	for id := 10; id < 10+capacity; id++ {
+		wp.recordDeadLetter(Job[int]{ID: id}, Result[int]{JobID: id, Error: failure})
+	}
+
+	// Two rejected replays come back to a buffer refilled meanwhile
+	for i := 0; i < 3; i++ {
+		wp.requeueDeadLetters([]DeadLetter[int]{letter(1), letter(2)})
+	}
+
+	letters := wp.DeadLetters()
+	if len(letters) != capacity {
+		t.Fatalf("%d dead letters, want the capacity %d", len(letters), capacity)
+	}
+	if stats := wp.Stats(); stats.DeadLettersDropped != 6 {
+		t.Errorf("%d dead letters dropped, want 6", stats.DeadLettersDropped)
+	}
+}
@@ -0,0 +1,105 @@
+package concurrency
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBuckets is the number of buckets per power of two. Bucket widths grow
+// with the value, keeping the relative error of a percentile below ~3%.
+const subBuckets = 16
+
+// histogram is a log-linear latency histogram with constant memory per
+// operation, however many durations are recorded
+type histogram struct {
+	counts []int64
+	count  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// bucketOf returns the bucket index of d. Values below 2*subBuckets
+// nanoseconds get a bucket each.
+func bucketOf(d time.Duration) int {
+	v := uint64(d)
+	if d <= 0 {
+		v = 0
+	}
+	if v < 2*subBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	shift := exp - 4
+	mantissa := (v >> shift) & (subBuckets - 1)
+	return (exp-3)*subBuckets + int(mantissa)
+}
+
+// bucketValue returns the midpoint of bucket i
+func bucketValue(i int) time.Duration {
+	if i < 2*subBuckets {
+		return time.Duration(i)
+	}
+	exp := i/subBuckets + 3
+	mantissa := uint64(i % subBuckets)
+	shift := exp - 4
+	lower := (subBuckets + mantissa) << shift
+	return time.Duration(lower + (uint64(1)<<shift)/2)
+}
+
+// record adds one duration to the histogram
+func (h *histogram) record(d time.Duration) {
+	idx := bucketOf(d)
+	if idx >= len(h.counts) {
+		grown := make([]int64, idx+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[idx]++
+
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+}
+
+// percentile returns the approximate p-th percentile (0 to 1)
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	target := int64(math.Ceil(p * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+
+	var cumulative int64
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative >= target {
+			v := bucketValue(i)
+			if v < h.min {
+				return h.min
+			}
+			if v > h.max {
+				return h.max
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+// mean returns the exact average of the recorded durations
+func (h *histogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
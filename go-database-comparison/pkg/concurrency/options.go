@@ -0,0 +1,48 @@
+package concurrency
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// poolOptions holds optional WorkerPool settings
+type poolOptions struct {
+	limiter *rate.Limiter
+	tracer  trace.Tracer
+	meter   metric.Meter
+
+	workStealing bool
+	breaker      *circuitBreaker
+	budget       *deadlineBudget
+
+	defaultTimeout time.Duration
+	deadLetters    int // Capacity of the dead-letter buffer, 0 disables it
+}
+
+// Option configures optional WorkerPool behavior
+type Option func(*poolOptions)
+
+// WithRateLimit caps job starts across all workers to opsPerSec, allowing
+// bursts of up to burst jobs. A non-positive opsPerSec leaves the pool unlimited.
+func WithRateLimit(opsPerSec float64, burst int) Option {
+	return func(o *poolOptions) {
+		if opsPerSec <= 0 {
+			o.limiter = nil
+			return
+		}
+		if burst <= 0 {
+			burst = 1
+		}
+		o.limiter = rate.NewLimiter(rate.Limit(opsPerSec), burst)
+	}
+}
+
+// WithDefaultTimeout sets the timeout of submitted jobs that have none
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(o *poolOptions) {
+		o.defaultTimeout = d
+	}
+}
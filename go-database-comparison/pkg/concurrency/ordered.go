@@ -0,0 +1,27 @@
+package concurrency
+
+import (
+	"sort"
+)
+
+// SortBySubmission sorts results into the order their jobs were submitted,
+// undoing the reordering caused by concurrent workers and job priorities
+func SortBySubmission[T any](results []Result[T]) {
+	sort.Slice(results, func(i, j int) bool { return results[i].Seq < results[j].Seq })
+}
+
+// SortByJobID sorts results by ascending Job.ID
+func SortByJobID[T any](results []Result[T]) {
+	sort.Slice(results, func(i, j int) bool { return results[i].JobID < results[j].JobID })
+}
+
+// CollectOrdered drains results until the channel is closed and returns them
+// in submission order. Use it with Results after calling Close.
+func CollectOrdered[T any](results <-chan Result[T]) []Result[T] {
+	var collected []Result[T]
+	for result := range results {
+		collected = append(collected, result)
+	}
+	SortBySubmission(collected)
+	return collected
+}
@@ -0,0 +1,84 @@
+package concurrency
+
+import (
+	"context"
+)
+
+// Pause stops workers from starting new jobs and waits until the jobs
+// already running have finished, or ctx is done. Queued jobs stay queued
+// and Submit keeps accepting work until the queue is full.
+func (wp *WorkerPool[T]) Pause(ctx context.Context) error {
+	wp.pauseMu.Lock()
+	if !wp.paused {
+		wp.paused = true
+		wp.resumeCh = make(chan struct{})
+	}
+	if wp.active == 0 {
+		wp.pauseMu.Unlock()
+		return nil
+	}
+	if wp.idleCh == nil {
+		wp.idleCh = make(chan struct{})
+	}
+	idle := wp.idleCh
+	wp.pauseMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Resume lets workers pick up queued jobs again after Pause
+func (wp *WorkerPool[T]) Resume() {
+	wp.pauseMu.Lock()
+	defer wp.pauseMu.Unlock()
+
+	if wp.paused {
+		wp.paused = false
+		close(wp.resumeCh)
+	}
+}
+
+// Paused reports whether the pool is paused
+func (wp *WorkerPool[T]) Paused() bool {
+	wp.pauseMu.Lock()
+	defer wp.pauseMu.Unlock()
+
+	return wp.paused
+}
+
+// acquireSlot blocks while the pool is paused and then marks a job as active.
+// It returns false if the pool is stopped while waiting.
+func (wp *WorkerPool[T]) acquireSlot() bool {
+	for {
+		wp.pauseMu.Lock()
+		if !wp.paused {
+			wp.active++
+			wp.pauseMu.Unlock()
+			return true
+		}
+		resume := wp.resumeCh
+		wp.pauseMu.Unlock()
+
+		select {
+		case <-resume:
+		case <-wp.ctx.Done():
+			return false
+		}
+	}
+}
+
+// releaseSlot marks a job as finished and wakes a Pause waiting for idle workers
+func (wp *WorkerPool[T]) releaseSlot() {
+	wp.pauseMu.Lock()
+	defer wp.pauseMu.Unlock()
+
+	wp.active--
+	if wp.active == 0 && wp.idleCh != nil {
+		close(wp.idleCh)
+		wp.idleCh = nil
+	}
+}
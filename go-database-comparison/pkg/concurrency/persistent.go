@@ -0,0 +1,204 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StoredJob is the persisted form of a job. TaskFunc closures cannot be
+// stored, so a job is described by the Kind of handler that runs it and
+// the Payload that handler receives.
+type StoredJob struct {
+	ID       int64
+	Kind     string
+	Payload  []byte
+	Priority int
+	Timeout  time.Duration
+}
+
+// JobStore persists queued jobs so the work of a crashed process can be
+// recovered by the next one
+type JobStore interface {
+	// Enqueue stores job and returns its ID
+	Enqueue(ctx context.Context, job StoredJob) (int64, error)
+	// Pending returns the stored jobs that have not completed, oldest first
+	Pending(ctx context.Context) ([]StoredJob, error)
+	// Complete marks a job as finished, recording jobErr if it failed
+	Complete(ctx context.Context, id int64, jobErr error) error
+	// Delete removes a job that was never accepted by the pool
+	Delete(ctx context.Context, id int64) error
+}
+
+// JobHandler runs a stored job from its payload
+type JobHandler[T any] func(ctx context.Context, payload []byte) (T, error)
+
+// storeTimeout bounds the store updates made from the worker goroutines
+const storeTimeout = 5 * time.Second
+
+// PersistentQueue submits jobs to a pool through a JobStore. A job is stored
+// before it is queued and marked complete once its final attempt finished,
+// so after a crash Recover re-submits everything that had not completed.
+// Jobs cancelled by the pool stopping stay pending in the same way.
+// Jobs may therefore run more than once and handlers should be idempotent.
+// Pool jobs take the stored job's ID, which other jobs submitted directly to
+// the same pool must not reuse.
+type PersistentQueue[T any] struct {
+	pool     *WorkerPool[T]
+	store    JobStore
+	handlers map[string]JobHandler[T]
+	mu       sync.Mutex
+	tracked  map[int]int64 // Pool job ID to stored job ID for jobs in flight
+	storeErr atomic.Pointer[error]
+}
+
+// NewPersistentQueue wraps pool with store. It must be called before the
+// pool is started since it hooks into result delivery.
+func NewPersistentQueue[T any](pool *WorkerPool[T], store JobStore) *PersistentQueue[T] {
+	q := &PersistentQueue[T]{
+		pool:     pool,
+		store:    store,
+		handlers: make(map[string]JobHandler[T]),
+		tracked:  make(map[int]int64),
+	}
+
+	prev := pool.onResult
+	pool.onResult = func(result Result[T]) {
+		if prev != nil {
+			prev(result)
+		}
+		q.complete(result)
+	}
+	return q
+}
+
+// Handle registers the handler running stored jobs of kind. Handlers must
+// be registered before Submit or Recover is called.
+func (q *PersistentQueue[T]) Handle(kind string, handler JobHandler[T]) {
+	q.handlers[kind] = handler
+}
+
+// Submit stores a job of kind and queues it on the pool
+func (q *PersistentQueue[T]) Submit(ctx context.Context, kind string, payload []byte, priority int, timeout time.Duration) (int64, error) {
+	if _, ok := q.handlers[kind]; !ok {
+		return 0, fmt.Errorf("no handler registered for job kind %q", kind)
+	}
+
+	job := StoredJob{Kind: kind, Payload: payload, Priority: priority, Timeout: timeout}
+	id, err := q.store.Enqueue(ctx, job)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store job: %w", err)
+	}
+	job.ID = id
+
+	if err := q.submit(job); err != nil {
+		if delErr := q.store.Delete(ctx, id); delErr != nil {
+			return 0, errors.Join(err, fmt.Errorf("failed to remove rejected job %d: %w", id, delErr))
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+// Recover re-submits the stored jobs that never completed, e.g. because
+// the previous process crashed, and returns how many were queued
+func (q *PersistentQueue[T]) Recover(ctx context.Context) (int, error) {
+	pending, err := q.store.Pending(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pending jobs: %w", err)
+	}
+
+	recovered := 0
+	for _, job := range pending {
+		if q.inFlight(job.ID) {
+			continue
+		}
+		if err := q.submit(job); err != nil {
+			return recovered, fmt.Errorf("failed to recover job %d: %w", job.ID, err)
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+// Err returns the most recent failure to mark a job complete. Such jobs
+// stay pending in the store and run again on the next Recover.
+func (q *PersistentQueue[T]) Err() error {
+	if err := q.storeErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// submit queues a stored job on the pool under its stored ID
+func (q *PersistentQueue[T]) submit(job StoredJob) error {
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler registered for job kind %q", job.Kind)
+	}
+
+	poolJob := Job[T]{
+		ID: int(job.ID),
+		TaskFunc: func(ctx context.Context) (T, error) {
+			return handler(ctx, job.Payload)
+		},
+		Timeout:  job.Timeout,
+		Priority: job.Priority,
+	}
+
+	q.mu.Lock()
+	q.tracked[poolJob.ID] = job.ID
+	q.mu.Unlock()
+
+	if err := q.pool.Submit(poolJob); err != nil {
+		q.mu.Lock()
+		delete(q.tracked, poolJob.ID)
+		q.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// inFlight reports whether the stored job is already queued or running
+func (q *PersistentQueue[T]) inFlight(id int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.tracked[int(id)]
+	return ok
+}
+
+// complete marks the stored job of a finished result complete
+func (q *PersistentQueue[T]) complete(result Result[T]) {
+	q.mu.Lock()
+	id, ok := q.tracked[result.JobID]
+	delete(q.tracked, result.JobID)
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+	if q.interrupted(result) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := q.store.Complete(ctx, id, result.Error); err != nil {
+		err = fmt.Errorf("failed to complete job %d: %w", id, err)
+		q.storeErr.Store(&err)
+	}
+}
+
+// interrupted reports whether result is a job the pool cancelled while
+// stopping, which did not finish and stays pending for the next Recover.
+// A job failing with its own timeout has finished and is completed.
+func (q *PersistentQueue[T]) interrupted(result Result[T]) bool {
+	if q.pool.ctx.Err() == nil {
+		return false
+	}
+	return errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, context.DeadlineExceeded)
+}
@@ -0,0 +1,202 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// memoryStore is a JobStore in memory, recording what Complete was told
+type memoryStore struct {
+	mu        sync.Mutex
+	nextID    int64
+	pending   map[int64]StoredJob
+	completed map[int64]error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{pending: make(map[int64]StoredJob), completed: make(map[int64]error)}
+}
+
+func (s *memoryStore) Enqueue(_ context.Context, job StoredJob) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	job.ID = s.nextID
+	s.pending[job.ID] = job
+	return job.ID, nil
+}
+
+func (s *memoryStore) Pending(context.Context) ([]StoredJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []StoredJob
+	for id := int64(1); id <= s.nextID; id++ {
+		if job, ok := s.pending[id]; ok {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, id int64, jobErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, id)
+	s.completed[id] = jobErr
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, id)
+	return nil
+}
+
+// completion returns what job id was completed with, and whether it was
+func (s *memoryStore) completion(id int64) (error, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err, ok := s.completed[id]
+	return err, ok
+}
+
+// blockingHandler signals started and waits for its context to end
+func blockingHandler(started chan<- struct{}) JobHandler[int] {
+	return func(ctx context.Context, _ []byte) (int, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+}
+
+func TestPersistentQueueCompletesFinishedJobs(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	store := newMemoryStore()
+	wp := NewTypedWorkerPool[int](context.Background(), 2)
+	q := NewPersistentQueue(wp, store)
+	failure := errors.New("handler failed")
+	q.Handle("ok", func(context.Context, []byte) (int, error) { return 1, nil })
+	q.Handle("fail", func(context.Context, []byte) (int, error) { return 0, failure })
+	q.Handle("timeout", func(ctx context.Context, _ []byte) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	wp.Start()
+	defer wp.Stop()
+
+	ctx := context.Background()
+	ok, err := q.Submit(ctx, "ok", nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := q.Submit(ctx, "fail", nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timedOut, err := q.Submit(ctx, "timeout", nil, 0, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wp.GetResults(3, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		id   int64
+		want error
+	}{{ok, nil}, {failed, failure}, {timedOut, context.DeadlineExceeded}} {
+		err, completed := store.completion(c.id)
+		if !completed {
+			t.Errorf("job %d not completed", c.id)
+			continue
+		}
+		if !errors.Is(err, c.want) {
+			t.Errorf("job %d completed with %v, want %v", c.id, err, c.want)
+		}
+	}
+	if err := q.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPersistentQueueStopLeavesJobsPending checks that a job cancelled by
+// Stop is not completed, so Recover runs it again
+func TestPersistentQueueStopLeavesJobsPending(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	store := newMemoryStore()
+	wp := NewTypedWorkerPool[int](context.Background(), 1)
+	q := NewPersistentQueue(wp, store)
+	started := make(chan struct{}, 1)
+	q.Handle("block", blockingHandler(started))
+	wp.Start()
+
+	id, err := q.Submit(context.Background(), "block", nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	wp.Stop()
+
+	if err, completed := store.completion(id); completed {
+		t.Fatalf("job cancelled by Stop was completed with %v", err)
+	}
+	pending, err := store.Pending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != id {
+		t.Fatalf("pending jobs %v, want job %d", pending, id)
+	}
+
+	// The next process recovers and finishes it
+	next := NewTypedWorkerPool[int](context.Background(), 1)
+	recovered := NewPersistentQueue(next, store)
+	recovered.Handle("block", func(context.Context, []byte) (int, error) { return 1, nil })
+	next.Start()
+	defer next.Stop()
+	if n, err := recovered.Recover(context.Background()); err != nil || n != 1 {
+		t.Fatalf("recovered %d jobs (%v), want 1", n, err)
+	}
+	if _, err := next.GetResult(); err != nil {
+		t.Fatal(err)
+	}
+	if err, completed := store.completion(id); !completed || err != nil {
+		t.Fatalf("recovered job completed %t with %v, want completed without error", completed, err)
+	}
+}
+
+// TestPersistentQueueShutdownDeadlineLeavesJobsPending checks the same for
+// a job still running when the context given to Shutdown expires
+func TestPersistentQueueShutdownDeadlineLeavesJobsPending(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	store := newMemoryStore()
+	wp := NewTypedWorkerPool[int](context.Background(), 1)
+	q := NewPersistentQueue(wp, store)
+	started := make(chan struct{}, 1)
+	q.Handle("block", blockingHandler(started))
+	wp.Start()
+
+	id, err := q.Submit(context.Background(), "block", nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := wp.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown returned %v, want the deadline", err)
+	}
+
+	if err, completed := store.completion(id); completed {
+		t.Fatalf("job cancelled by Shutdown was completed with %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RunJobs executes jobs on a new pool of workers and returns every result in
+// submission order together with the pool's statistics. Jobs are fed to the
+// pool as results come back, so any number of jobs fits the bounded queue.
+func RunJobs[T any](ctx context.Context, workers int, jobs []Job[T], opts ...Option) ([]Result[T], PoolStats, error) {
+	pool := NewTypedWorkerPool[T](ctx, workers, opts...)
+	pool.Start()
+	defer pool.Stop()
+
+	results := make([]Result[T], 0, len(jobs))
+	err := pool.runWindowed(ctx, jobs, func(result Result[T]) bool {
+		results = append(results, result)
+		return true
+	})
+	SortBySubmission(results)
+	return results, pool.Stats(), err
+}
+
+// Map calls fn for every item with at most workers calls running at once and
+// returns the outputs in input order. The first error cancels the remaining
+// calls and is returned.
+func Map[In, Out any](ctx context.Context, workers int, items []In, fn func(context.Context, In) (Out, error), opts ...Option) ([]Out, error) {
+	outputs := make([]Out, len(items))
+	var firstErr error
+
+	err := mapItems(ctx, workers, items, fn, opts, func(result Result[Out]) bool {
+		if result.Error != nil {
+			firstErr = fmt.Errorf("item %d: %w", result.JobID, result.Error)
+			return false
+		}
+		outputs[result.JobID] = result.Data
+		return true
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// MapAll calls fn for every item like Map, but runs all items regardless of
+// failures and returns each item's output and error at its input index
+func MapAll[In, Out any](ctx context.Context, workers int, items []In, fn func(context.Context, In) (Out, error), opts ...Option) ([]Out, []error) {
+	outputs := make([]Out, len(items))
+	errs := make([]error, len(items))
+	done := make([]bool, len(items))
+
+	err := mapItems(ctx, workers, items, fn, opts, func(result Result[Out]) bool {
+		outputs[result.JobID] = result.Data
+		errs[result.JobID] = result.Error
+		done[result.JobID] = true
+		return true
+	})
+	if err != nil {
+		// Items that never ran report why the run stopped
+		for i := range errs {
+			if !done[i] {
+				errs[i] = err
+			}
+		}
+	}
+	return outputs, errs
+}
+
+// ForEach calls fn for every item with at most workers calls running at once.
+// The first error cancels the remaining calls and is returned.
+func ForEach[In any](ctx context.Context, workers int, items []In, fn func(context.Context, In) error, opts ...Option) error {
+	_, err := Map(ctx, workers, items, func(ctx context.Context, item In) (struct{}, error) {
+		return struct{}{}, fn(ctx, item)
+	}, opts...)
+	return err
+}
+
+// mapItems runs fn over items on a new pool, passing each result to handle
+// until handle returns false. Job IDs are the item indexes.
+func mapItems[In, Out any](ctx context.Context, workers int, items []In, fn func(context.Context, In) (Out, error), opts []Option, handle func(Result[Out]) bool) error {
+	jobs := make([]Job[Out], len(items))
+	for i, item := range items {
+		item := item
+		jobs[i] = Job[Out]{
+			ID: i,
+			TaskFunc: func(ctx context.Context) (Out, error) {
+				return fn(ctx, item)
+			},
+		}
+	}
+
+	pool := NewTypedWorkerPool[Out](ctx, workers, opts...)
+	pool.Start()
+	defer pool.Stop()
+
+	return pool.runWindowed(ctx, jobs, handle)
+}
+
+// runWindowed submits jobs while receiving results, keeping at most a full
+// queue's worth of jobs outstanding so Submit never reports a full queue.
+// Jobs rejected by a circuit breaker are handled as failed results. It
+// stops early when handle returns false.
+func (wp *WorkerPool[T]) runWindowed(ctx context.Context, jobs []Job[T], handle func(Result[T]) bool) error {
+	next, outstanding := 0, 0
+	fill := func() (bool, error) {
+		for next < len(jobs) && outstanding < wp.jobQueue.capacity() {
+			job := jobs[next]
+			next++
+			if err := wp.Submit(job); err != nil {
+				if errors.Is(err, ErrCircuitOpen) {
+					if !handle(Result[T]{JobID: job.ID, Error: err, Labels: job.Labels}) {
+						return false, nil
+					}
+					continue
+				}
+				return false, fmt.Errorf("failed to submit job %d: %w", job.ID, err)
+			}
+			outstanding++
+		}
+		return true, nil
+	}
+
+	if ok, err := fill(); !ok {
+		return err
+	}
+
+	for received := 0; outstanding > 0; received++ {
+		select {
+		case result, ok := <-wp.results:
+			if !ok {
+				return fmt.Errorf("worker pool closed, got %d/%d results", received, len(jobs))
+			}
+			outstanding--
+			if !handle(result) {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if ok, err := fill(); !ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package concurrency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// errStressJob is returned by the stress jobs that fail without panicking
+var errStressJob = errors.New("stress job failed")
+
+// stressOptions sizes the rounds of the stress scenarios
+type stressOptions struct {
+	rounds    int
+	workers   int
+	jobs      int
+	producers int
+	consumers int
+	timeout   time.Duration // Longest a round may take before it counts as deadlocked
+}
+
+// stressScenarios race some of the pool's methods against each other, each
+// round on a fresh pool, recording what they saw in the ledger. exact tells
+// that every accepted job has to be delivered, which only holds when the
+// pool is not stopped before it drained.
+var stressScenarios = []struct {
+	name  string
+	exact bool
+	run   func(ctx context.Context, o stressOptions, l *stressLedger) error
+}{
+	{"submit-getresults", true, stressSubmitGetResults},
+	{"submit-stop", false, stressSubmitStop},
+	{"getresults-stop", false, stressGetResultsStop},
+	{"submit-shutdown", true, stressSubmitShutdown},
+}
+
+// TestPoolStress hammers Submit, GetResults, Stop and Shutdown from many
+// goroutines at once and checks the pool keeps its concurrency contract.
+// Run it with -race to also catch data races. The checks pass when
+//   - every result belongs to an accepted job and arrives at most once,
+//   - without Stop cutting in, every accepted job delivers its result,
+//   - a result carries the job's own data, and a panic a *PanicError,
+//   - Submit, GetResult and GetResults fail instead of blocking once the
+//     pool is stopped, and Stop can be called again,
+//   - no round runs longer than the timeout, which points to a deadlock,
+//   - no goroutines are left behind.
+func TestPoolStress(t *testing.T) {
+	o := stressOptions{rounds: 200, workers: 4, jobs: 200, producers: 4, consumers: 2, timeout: 10 * time.Second}
+	if testing.Short() {
+		o.rounds = 20
+	}
+
+	for _, scenario := range stressScenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t)
+
+			for round := 1; round <= o.rounds; round++ {
+				ledger := newStressLedger()
+				done := make(chan error, 1)
+				go func() {
+					done <- scenario.run(context.Background(), o, ledger)
+				}()
+
+				select {
+				case err := <-done:
+					if err == nil {
+						err = ledger.check(scenario.exact)
+					}
+					if err != nil {
+						t.Fatalf("round %d: %v", round, err)
+					}
+				case <-time.After(o.timeout):
+					var stacks bytes.Buffer
+					pprof.Lookup("goroutine").WriteTo(&stacks, 1)
+					t.Fatalf("round %d: deadlocked, still running after %v\n%s", round, o.timeout, stacks.String())
+				}
+			}
+		})
+	}
+}
+
+// stressSubmitGetResults submits from several producers while consumers
+// collect with GetResults, then closes the pool. Every accepted job has to
+// be delivered and the results channel closed afterwards.
+func stressSubmitGetResults(ctx context.Context, o stressOptions, l *stressLedger) error {
+	pool := NewTypedWorkerPool[int](ctx, o.workers)
+	pool.Start()
+	defer pool.Stop()
+
+	var producers sync.WaitGroup
+	produce(pool, o, l, &producers, nil)
+
+	// Consumers take results in batches until the pool is closed and drained
+	var consumers sync.WaitGroup
+	for c := 0; c < o.consumers; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for {
+				results, err := pool.GetResults(1+rand.IntN(8), o.timeout)
+				l.receive(results...)
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	producers.Wait()
+	pool.Close()
+	consumers.Wait()
+	if _, err := pool.GetResult(); err == nil {
+		return errors.New("GetResult succeeded after the closed pool was drained")
+	}
+	return nil
+}
+
+// stressSubmitStop submits from several producers while Stop is called from
+// several goroutines after a random delay. Results may be dropped, but
+// every Submit after Stop has to fail.
+func stressSubmitStop(ctx context.Context, o stressOptions, l *stressLedger) error {
+	pool := NewTypedWorkerPool[int](ctx, o.workers)
+	pool.Start()
+
+	stopped := make(chan struct{})
+	var producers sync.WaitGroup
+	produce(pool, o, l, &producers, stopped)
+
+	var consumers sync.WaitGroup
+	for c := 0; c < o.consumers; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for result := range pool.Results() {
+				l.receive(result)
+			}
+		}()
+	}
+
+	time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+	var stoppers sync.WaitGroup
+	for s := 0; s < 3; s++ {
+		stoppers.Add(1)
+		go func() {
+			defer stoppers.Done()
+			pool.Stop()
+		}()
+	}
+	stoppers.Wait()
+	close(stopped)
+	producers.Wait()
+	consumers.Wait()
+
+	pool.Stop()
+	return stoppedPoolFails(pool, l)
+}
+
+// stressGetResultsStop blocks consumers in GetResult and GetResults, which
+// wait far longer than the round may take, and stops the pool under them.
+// They have to return as soon as the pool is stopped.
+func stressGetResultsStop(ctx context.Context, o stressOptions, l *stressLedger) error {
+	pool := NewTypedWorkerPool[int](ctx, o.workers)
+	pool.Start()
+
+	var consumers sync.WaitGroup
+	for c := 0; c < o.consumers; c++ {
+		consumers.Add(2)
+		go func() {
+			defer consumers.Done()
+			results, _ := pool.GetResults(o.jobs*2, time.Hour)
+			l.receive(results...)
+		}()
+		go func() {
+			defer consumers.Done()
+			for {
+				result, err := pool.GetResult()
+				if err != nil {
+					return
+				}
+				l.receive(result)
+			}
+		}()
+	}
+
+	var producers sync.WaitGroup
+	produce(pool, o, l, &producers, nil)
+	producers.Wait()
+	time.Sleep(time.Duration(rand.IntN(200)) * time.Microsecond)
+	pool.Stop()
+	consumers.Wait()
+	return stoppedPoolFails(pool, l)
+}
+
+// stressSubmitShutdown submits from several producers while Shutdown
+// drains the pool. Jobs accepted before Shutdown closed the pool have to
+// be delivered, later submissions rejected.
+func stressSubmitShutdown(ctx context.Context, o stressOptions, l *stressLedger) error {
+	pool := NewTypedWorkerPool[int](ctx, o.workers)
+	pool.Start()
+
+	closed := make(chan struct{})
+	var producers sync.WaitGroup
+	produce(pool, o, l, &producers, closed)
+
+	var consumers sync.WaitGroup
+	for c := 0; c < o.consumers; c++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for result := range pool.Results() {
+				l.receive(result)
+			}
+		}()
+	}
+
+	time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+	shutdownCtx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+	err := pool.Shutdown(shutdownCtx)
+	close(closed)
+	producers.Wait()
+	consumers.Wait()
+	if err != nil {
+		return fmt.Errorf("Shutdown failed: %w", err)
+	}
+	return stoppedPoolFails(pool, l)
+}
+
+// produce starts o.producers goroutines submitting o.jobs jobs between
+// them. A job submitted after after was closed, which happens once the pool
+// was stopped or closed, has to be rejected and ends its producer. Before
+// that a full queue may reject a job, which is then skipped.
+func produce(pool *WorkerPool[int], o stressOptions, l *stressLedger, producers *sync.WaitGroup, after <-chan struct{}) {
+	for p := 0; p < o.producers; p++ {
+		producers.Add(1)
+		go func(p int) {
+			defer producers.Done()
+			for id := p; id < o.jobs; id += o.producers {
+				late := isClosed(after)
+				err := pool.Submit(stressJob(id))
+				l.submitted(id, err, late)
+				if late {
+					return
+				}
+				if err != nil {
+					// Give the workers time to catch up
+					runtime.Gosched()
+				}
+			}
+		}(p)
+	}
+}
+
+// isClosed tells whether ch is closed, a nil channel never is
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// stressJob returns a job that returns its ID as data, fails or panics,
+// depending on the ID, after yielding a little to mix up the workers
+func stressJob(id int) Job[int] {
+	return Job[int]{
+		ID: id,
+		TaskFunc: func(ctx context.Context) (int, error) {
+			for i := 0; i < id%4; i++ {
+				runtime.Gosched()
+			}
+			switch {
+			case id%13 == 0:
+				panic(fmt.Sprintf("stress job %d", id))
+			case id%7 == 0:
+				return 0, errStressJob
+			}
+			return id, nil
+		},
+	}
+}
+
+// stoppedPoolFails checks that a stopped pool rejects work and, once the
+// results delivered before Stop were read, returns from reads right away
+func stoppedPoolFails(pool *WorkerPool[int], l *stressLedger) error {
+	if err := pool.Submit(stressJob(1)); err == nil {
+		return errors.New("Submit succeeded after Stop")
+	}
+	for result := range pool.Results() {
+		l.receive(result)
+	}
+	if _, err := pool.GetResult(); err == nil {
+		return errors.New("GetResult succeeded after Stop")
+	}
+	if _, err := pool.GetResults(1, time.Hour); err == nil {
+		return errors.New("GetResults succeeded after Stop")
+	}
+	return nil
+}
+
+// stressLedger records the submissions and results of one round
+type stressLedger struct {
+	mu       sync.Mutex
+	accepted map[int]bool
+	rejected int
+	received map[int]int
+	wrong    []string // Broken rules seen during the round
+}
+
+func newStressLedger() *stressLedger {
+	return &stressLedger{accepted: map[int]bool{}, received: map[int]int{}}
+}
+
+func (l *stressLedger) submitted(id int, err error, late bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if late && err == nil {
+		l.wrong = append(l.wrong, fmt.Sprintf("job %d was accepted by a stopped pool", id))
+	}
+	if err != nil {
+		l.rejected++
+		return
+	}
+	l.accepted[id] = true
+}
+
+func (l *stressLedger) receive(results ...Result[int]) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, result := range results {
+		l.received[result.JobID]++
+		if err := checkStressResult(result); err != nil {
+			l.wrong = append(l.wrong, err.Error())
+		}
+	}
+}
+
+// checkStressResult returns an error when result is not what stressJob
+// returns for its ID
+func checkStressResult(result Result[int]) error {
+	id := result.JobID
+	var panicErr *PanicError
+	switch {
+	case id%13 == 0:
+		if !errors.As(result.Error, &panicErr) {
+			return fmt.Errorf("job %d panicked, got error %v instead of a *PanicError", id, result.Error)
+		}
+	case id%7 == 0:
+		if !errors.Is(result.Error, errStressJob) {
+			return fmt.Errorf("job %d failed, got error %v", id, result.Error)
+		}
+	case result.Error != nil:
+		return fmt.Errorf("job %d succeeded, got error %v", id, result.Error)
+	case result.Data != id:
+		return fmt.Errorf("job %d returned data %d", id, result.Data)
+	}
+	return nil
+}
+
+// check returns an error describing the first broken rule of the round.
+// With exact every accepted job has to be delivered.
+func (l *stressLedger) check(exact bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.wrong) > 0 {
+		return errors.New(l.wrong[0])
+	}
+	for id, n := range l.received {
+		if !l.accepted[id] {
+			return fmt.Errorf("result delivered for job %d, whose Submit failed", id)
+		}
+		if n > 1 {
+			return fmt.Errorf("result of job %d delivered %d times", id, n)
+		}
+	}
+	if exact {
+		for id := range l.accepted {
+			if l.received[id] == 0 {
+				return fmt.Errorf("job %d was accepted, its result never delivered", id)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *stressLedger) counts() (accepted, rejected, delivered int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, n := range l.received {
+		delivered += n
+	}
+	return len(l.accepted), l.rejected, delivered
+}
@@ -0,0 +1,111 @@
+package concurrency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresJobStore is a JobStore keeping jobs in a PostgreSQL table, so the
+// benchmark database itself can hold the queue. Each agent only sees its own
+// jobs, letting several benchmark agents share one table.
+type PostgresJobStore struct {
+	db    *sql.DB
+	agent string
+}
+
+// NewPostgresJobStore creates a store for the jobs of agent. Call
+// EnsureSchema once before use.
+func NewPostgresJobStore(db *sql.DB, agent string) *PostgresJobStore {
+	return &PostgresJobStore{db: db, agent: agent}
+}
+
+// EnsureSchema creates the job table if it does not exist
+func (s *PostgresJobStore) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS benchmark_jobs (
+			id           BIGSERIAL PRIMARY KEY,
+			agent        TEXT NOT NULL,
+			kind         TEXT NOT NULL,
+			payload      BYTEA,
+			priority     INTEGER NOT NULL DEFAULT 0,
+			timeout_ms   BIGINT NOT NULL DEFAULT 0,
+			created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			completed_at TIMESTAMPTZ,
+			error        TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_benchmark_jobs_pending
+			ON benchmark_jobs (agent, id) WHERE completed_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create job table: %w", err)
+	}
+	return nil
+}
+
+// Enqueue stores job and returns its ID
+func (s *PostgresJobStore) Enqueue(ctx context.Context, job StoredJob) (int64, error) {
+	query := `
+		INSERT INTO benchmark_jobs (agent, kind, payload, priority, timeout_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	var id int64
+	err := s.db.QueryRowContext(ctx, query,
+		s.agent, job.Kind, job.Payload, job.Priority, job.Timeout.Milliseconds(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return id, nil
+}
+
+// Pending returns the agent's jobs that have not completed, oldest first
+func (s *PostgresJobStore) Pending(ctx context.Context) ([]StoredJob, error) {
+	query := `
+		SELECT id, kind, payload, priority, timeout_ms
+		FROM benchmark_jobs
+		WHERE agent = $1 AND completed_at IS NULL
+		ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, s.agent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []StoredJob
+	for rows.Next() {
+		var job StoredJob
+		var timeoutMs int64
+		if err := rows.Scan(&job.ID, &job.Kind, &job.Payload, &job.Priority, &timeoutMs); err != nil {
+			return nil, fmt.Errorf("failed to scan pending job: %w", err)
+		}
+		job.Timeout = time.Duration(timeoutMs) * time.Millisecond
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Complete marks a job as finished, recording jobErr if it failed
+func (s *PostgresJobStore) Complete(ctx context.Context, id int64, jobErr error) error {
+	var message sql.NullString
+	if jobErr != nil {
+		message = sql.NullString{String: jobErr.Error(), Valid: true}
+	}
+
+	query := `UPDATE benchmark_jobs SET completed_at = NOW(), error = $2 WHERE id = $1`
+	if _, err := s.db.ExecContext(ctx, query, id, message); err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a job that was never accepted by the pool
+func (s *PostgresJobStore) Delete(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM benchmark_jobs WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	return nil
+}
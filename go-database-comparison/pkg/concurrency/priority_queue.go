@@ -0,0 +1,123 @@
+package concurrency
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Common job priorities. Any int is valid; higher values run first.
+const (
+	PriorityLow    = -10
+	PriorityNormal = 0
+	PriorityHigh   = 10
+)
+
+// queuedJob is a job waiting in the priority queue
+type queuedJob[T any] struct {
+	job      Job[T]
+	seq      uint64    // Submission order, keeps FIFO within a priority level
+	enqueued time.Time // Submit time, for queue wait metrics
+}
+
+// jobHeap orders queued jobs by descending priority, then submission order
+type jobHeap[T any] []queuedJob[T]
+
+func (h jobHeap[T]) Len() int { return len(h) }
+
+func (h jobHeap[T]) Less(i, j int) bool {
+	if h[i].job.Priority != h[j].job.Priority {
+		return h[i].job.Priority > h[j].job.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h jobHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *jobHeap[T]) Push(x any) { *h = append(*h, x.(queuedJob[T])) }
+
+func (h *jobHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// contains reports whether a job with the given ID is in the heap
+func (h jobHeap[T]) contains(jobID int) bool {
+	for _, item := range h {
+		if item.job.ID == jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// jobQueue hands submitted jobs to workers. Workers call wait until a job
+// may be available and then take it; take may fail if another worker got
+// the job first.
+type jobQueue[T any] interface {
+	push(item queuedJob[T]) bool // false if the queue is full
+	wait(ctx context.Context, workerID int) bool
+	take(workerID int) (queuedJob[T], bool)
+	contains(jobID int) bool
+	len() int
+	capacity() int
+	close() // Workers drain the remaining jobs, then wait returns false
+}
+
+// centralQueue is a single priority queue shared by all workers. A buffered
+// channel carries one token per queued job so idle workers block on it.
+type centralQueue[T any] struct {
+	tokens  chan struct{}
+	mu      sync.Mutex
+	pending jobHeap[T]
+}
+
+func newCentralQueue[T any](capacity int) *centralQueue[T] {
+	return &centralQueue[T]{tokens: make(chan struct{}, capacity)}
+}
+
+func (q *centralQueue[T]) push(item queuedJob[T]) bool {
+	// Reserve a slot and enqueue under the same lock, so a worker
+	// receiving the token always finds the job in the heap
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case q.tokens <- struct{}{}:
+		heap.Push(&q.pending, item)
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *centralQueue[T]) wait(ctx context.Context, _ int) bool {
+	select {
+	case _, ok := <-q.tokens:
+		return ok
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (q *centralQueue[T]) take(_ int) (queuedJob[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return heap.Pop(&q.pending).(queuedJob[T]), true
+}
+
+func (q *centralQueue[T]) contains(jobID int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.pending.contains(jobID)
+}
+
+func (q *centralQueue[T]) len() int      { return len(q.tokens) }
+func (q *centralQueue[T]) capacity() int { return cap(q.tokens) }
+func (q *centralQueue[T]) close()        { close(q.tokens) }
@@ -0,0 +1,82 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy controls how a failed job is retried inside the worker
+type RetryPolicy struct {
+	MaxAttempts    int              // Total attempts including the first, values <= 1 disable retries
+	InitialBackoff time.Duration    // Delay before the second attempt
+	MaxBackoff     time.Duration    // Upper bound for the delay, 0 for no bound
+	Multiplier     float64          // Backoff growth per attempt, defaults to 2
+	Retryable      func(error) bool // Reports whether an error is transient, nil retries every error
+}
+
+// shouldRetry reports whether another attempt should follow a failed one
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if p == nil || err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	// Cancellation of the pool and panics are never transient
+	var panicErr *PanicError
+	if errors.Is(err, context.Canceled) || errors.As(err, &panicErr) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return true
+}
+
+// Do calls fn until it succeeds or the policy gives up, sleeping the
+// backoff between attempts, and returns the attempts made with the last
+// error. A nil policy calls fn once.
+func (p *RetryPolicy) Do(ctx context.Context, fn func(context.Context) error) (attempts int, err error) {
+	for {
+		attempts++
+		err = fn(ctx)
+		if !p.shouldRetry(attempts, err) {
+			return attempts, err
+		}
+		if sleepErr := sleep(ctx, p.backoff(attempts)); sleepErr != nil {
+			return attempts, err
+		}
+	}
+}
+
+// backoff returns the delay after the given failed attempt (1-based)
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	return time.Duration(delay)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
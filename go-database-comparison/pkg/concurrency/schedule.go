@@ -0,0 +1,103 @@
+package concurrency
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Schedule is a delayed or recurring submission created by SubmitAfter or
+// SubmitEvery. Schedules end when stopped, or when the pool is closed or stopped.
+type Schedule struct {
+	stop    chan struct{}
+	once    sync.Once
+	missed  atomic.Int64
+	lastErr atomic.Pointer[error]
+}
+
+func newSchedule() *Schedule {
+	return &Schedule{stop: make(chan struct{})}
+}
+
+// Stop cancels submissions that have not happened yet
+func (s *Schedule) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// Missed returns how many scheduled submissions were rejected, e.g. because the queue was full
+func (s *Schedule) Missed() int64 {
+	return s.missed.Load()
+}
+
+// Err returns the error of the most recent rejected submission
+func (s *Schedule) Err() error {
+	if err := s.lastErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// submit submits job and records a rejection on the schedule
+func (s *Schedule) submit(submit func() error) {
+	if err := submit(); err != nil {
+		s.missed.Add(1)
+		s.lastErr.Store(&err)
+	}
+}
+
+// SubmitAfter submits job once delay has elapsed. Its result arrives like
+// any other, on Results or through Execute waiters.
+func (wp *WorkerPool[T]) SubmitAfter(delay time.Duration, job Job[T]) *Schedule {
+	s := newSchedule()
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			s.submit(func() error { return wp.Submit(job) })
+		case <-s.stop:
+		case <-wp.shutdown:
+		case <-wp.ctx.Done():
+		}
+	}()
+	return s
+}
+
+// SubmitEvery submits a new job every interval until the schedule is
+// stopped, e.g. periodic stats sampling or VACUUM while a benchmark runs.
+// Like a cron entry such as "*/5 * * * *", submissions are aligned to the
+// wall clock rather than to the call: they happen at the multiples of
+// interval since midnight UTC, 10:00, 10:05, ... for 5 minutes, whenever
+// SubmitEvery was called. An interval that does not divide a day aligns
+// to the multiples since the zero time.Time instead. A submission late by
+// more than interval skips the runs it missed. newJob receives the run
+// number, starting at 0, and should return a job with a unique ID.
+func (wp *WorkerPool[T]) SubmitEvery(interval time.Duration, newJob func(run int) Job[T]) *Schedule {
+	s := newSchedule()
+	go func() {
+		for run := 0; ; run++ {
+			timer := time.NewTimer(time.Until(nextAligned(time.Now(), interval)))
+			select {
+			case <-timer.C:
+				s.submit(func() error { return wp.Submit(newJob(run)) })
+			case <-s.stop:
+				timer.Stop()
+				return
+			case <-wp.shutdown:
+				timer.Stop()
+				return
+			case <-wp.ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// nextAligned returns the first multiple of interval on the wall clock
+// after now
+func nextAligned(now time.Time, interval time.Duration) time.Time {
+	return now.Truncate(interval).Add(interval)
+}
@@ -0,0 +1,68 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestNextAligned(t *testing.T) {
+	at := func(clock string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339Nano, "2026-10-17T"+clock+"Z")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	for _, c := range []struct {
+		now      string
+		interval time.Duration
+		want     string
+	}{
+		{"10:02:13", 5 * time.Minute, "10:05:00"},
+		{"10:05:00", 5 * time.Minute, "10:10:00"},
+		{"10:59:59.999", time.Hour, "11:00:00"},
+		{"23:30:00", 24 * time.Hour, "00:00:00"},
+		{"10:00:00.250", 100 * time.Millisecond, "10:00:00.3"},
+	} {
+		want := at(c.want)
+		if c.want == "00:00:00" {
+			want = want.AddDate(0, 0, 1)
+		}
+		if got := nextAligned(at(c.now), c.interval); !got.Equal(want) {
+			t.Errorf("nextAligned(%s, %v) = %s, want %s", c.now, c.interval, got.Format(time.RFC3339Nano), want.Format(time.RFC3339Nano))
+		}
+	}
+}
+
+// TestSubmitEveryAligned checks that the submissions of SubmitEvery happen
+// on multiples of the interval, not interval after the call
+func TestSubmitEveryAligned(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	const interval = 100 * time.Millisecond
+	wp := NewTypedWorkerPool[time.Time](context.Background(), 1)
+	wp.Start()
+	defer wp.Stop()
+
+	// Start off the grid, so an unaligned ticker would fire off it too
+	time.Sleep(time.Until(nextAligned(time.Now(), interval).Add(interval / 2)))
+	schedule := wp.SubmitEvery(interval, func(run int) Job[time.Time] {
+		submitted := time.Now()
+		return Job[time.Time]{ID: run, TaskFunc: func(context.Context) (time.Time, error) { return submitted, nil }}
+	})
+	defer schedule.Stop()
+
+	results, err := wp.GetResults(3, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if offset := result.Data.Sub(result.Data.Truncate(interval)); offset > interval*3/10 {
+			t.Errorf("run %d submitted %v after a multiple of %v", result.JobID, offset, interval)
+		}
+	}
+}
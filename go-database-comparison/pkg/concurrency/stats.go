@@ -0,0 +1,101 @@
+package concurrency
+
+import "time"
+
+// PoolStats is a snapshot of a worker pool's state and throughput
+type PoolStats struct {
+	Workers      int  `json:"workers"`
+	JobsQueued   int  `json:"jobs_queued"`
+	ResultsReady int  `json:"results_ready"`
+	Started      bool `json:"started"`
+
+	Submitted uint64 `json:"submitted"` // Jobs accepted by Submit, including coalesced ones
+	Pending   uint64 `json:"pending"`   // Accepted jobs whose result has not been delivered yet
+	Completed int64  `json:"completed"` // Jobs that finished without error
+	Failed    int64  `json:"failed"`    // Jobs that finished with an error
+
+	DeadLetters        int   `json:"dead_letters,omitempty"`         // Failed jobs held in the dead-letter buffer
+	DeadLettersDropped int64 `json:"dead_letters_dropped,omitempty"` // Failed jobs evicted from the full buffer
+
+	// Time between Submit and a worker starting the job
+	QueueWaitAvg time.Duration `json:"queue_wait_avg"`
+	QueueWaitP50 time.Duration `json:"queue_wait_p50"`
+	QueueWaitP95 time.Duration `json:"queue_wait_p95"`
+	QueueWaitP99 time.Duration `json:"queue_wait_p99"`
+	QueueWaitMax time.Duration `json:"queue_wait_max"`
+
+	// Utilization is the share of worker time spent executing jobs, from 0 to 1
+	Utilization float64 `json:"utilization"`
+}
+
+// poolMetrics accumulates per-job measurements for Stats
+type poolMetrics struct {
+	startedAt  time.Time
+	stoppedAt  time.Time
+	completed  int64
+	failed     int64
+	busy       time.Duration
+	queueWaits histogram // Bounded however many jobs run, percentiles within ~3%
+}
+
+// recordStats adds a finished job to the pool metrics
+func (wp *WorkerPool[T]) recordStats(result Result[T]) {
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+
+	if result.Error != nil {
+		wp.metrics.failed++
+	} else {
+		wp.metrics.completed++
+	}
+	wp.metrics.busy += result.Duration
+	wp.metrics.queueWaits.record(result.QueueWait)
+}
+
+// Stats returns worker pool statistics
+func (wp *WorkerPool[T]) Stats() PoolStats {
+	wp.mu.RLock()
+	stats := PoolStats{
+		Workers:      wp.workers,
+		JobsQueued:   wp.jobQueue.len(),
+		ResultsReady: len(wp.results),
+		Started:      wp.started,
+	}
+	wp.mu.RUnlock()
+
+	submitted, delivered := wp.counts()
+	stats.Submitted = submitted
+	stats.Pending = submitted - delivered
+
+	wp.deadMu.Lock()
+	stats.DeadLetters = len(wp.deadLetters)
+	stats.DeadLettersDropped = wp.deadDropped
+	wp.deadMu.Unlock()
+
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+
+	m := &wp.metrics
+	stats.Completed = m.completed
+	stats.Failed = m.failed
+
+	if waits := &m.queueWaits; waits.count > 0 {
+		stats.QueueWaitAvg = waits.mean()
+		stats.QueueWaitP50 = waits.percentile(0.50)
+		stats.QueueWaitP95 = waits.percentile(0.95)
+		stats.QueueWaitP99 = waits.percentile(0.99)
+		stats.QueueWaitMax = waits.max
+	}
+
+	if !m.startedAt.IsZero() {
+		end := m.stoppedAt
+		if end.IsZero() {
+			end = time.Now()
+		}
+		if capacity := end.Sub(m.startedAt) * time.Duration(wp.workers); capacity > 0 {
+			stats.Utilization = float64(m.busy) / float64(capacity)
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,89 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestHistogramPercentiles(t *testing.T) {
+	var h histogram
+	const n = 100_000
+	for i := 1; i <= n; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+
+	for _, p := range []float64{0.50, 0.95, 0.99} {
+		want := time.Duration(p*n) * time.Microsecond
+		got := h.percentile(p)
+		if diff := float64(got-want) / float64(want); diff < -0.03 || diff > 0.03 {
+			t.Errorf("p%.0f = %v, want %v within 3%%", p*100, got, want)
+		}
+	}
+	if h.max != n*time.Microsecond || h.mean() != (n+1)*time.Microsecond/2 {
+		t.Errorf("max %v and mean %v", h.max, h.mean())
+	}
+	if len(h.counts) > 64*subBuckets {
+		t.Errorf("%d buckets for %d durations", len(h.counts), n)
+	}
+}
+
+// TestStatsQueueWaitsBounded checks that the queue wait statistics take the
+// same memory however many jobs the pool ran
+func TestStatsQueueWaitsBounded(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	wp := NewWorkerPool(context.Background(), 4)
+	wp.Start()
+	defer wp.Stop()
+	consumed := drain(wp)
+
+	buckets := func() int {
+		wp.statsMu.Lock()
+		defer wp.statsMu.Unlock()
+		return len(wp.metrics.queueWaits.counts)
+	}
+	next := 0
+	run := func(jobs int) {
+		for i := 0; i < jobs; i++ {
+			next++
+			job := UntypedJob{ID: next, TaskFunc: func(context.Context) (interface{}, error) { return nil, nil }}
+			for {
+				err := wp.Submit(job)
+				if err == nil {
+					break
+				}
+				if err.Error() != "job queue full" {
+					t.Fatal(err)
+				}
+				time.Sleep(10 * time.Microsecond)
+			}
+		}
+		for wp.Stats().Pending > 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	run(1_000)
+	before := buckets()
+	run(10_000)
+	if after := buckets(); after > 64*subBuckets {
+		t.Fatalf("%d buckets after 11000 jobs, %d after 1000", after, before)
+	}
+
+	stats := wp.Stats()
+	if stats.Completed != 11_000 {
+		t.Fatalf("completed %d jobs, want 11000", stats.Completed)
+	}
+	if !(stats.QueueWaitP50 <= stats.QueueWaitP95 && stats.QueueWaitP95 <= stats.QueueWaitP99 && stats.QueueWaitP99 <= stats.QueueWaitMax) {
+		t.Fatalf("queue wait percentiles out of order: %+v", stats)
+	}
+	if stats.QueueWaitAvg <= 0 || stats.QueueWaitAvg > stats.QueueWaitMax {
+		t.Fatalf("queue wait average %v, max %v", stats.QueueWaitAvg, stats.QueueWaitMax)
+	}
+
+	wp.Stop()
+	<-consumed
+}
@@ -0,0 +1,126 @@
+package concurrency
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the pool's tracer and meter
+const instrumentationName = "go-database-comparison/pkg/concurrency"
+
+// WithTracerProvider records a span per job, starting when the job is
+// submitted, with a child span covering its time in the queue. The job
+// context carries the span, so database spans nest underneath it.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *poolOptions) {
+		o.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
+// WithMeterProvider publishes queued and active job gauges and a queue wait histogram
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *poolOptions) {
+		o.meter = mp.Meter(instrumentationName)
+	}
+}
+
+// poolTelemetry holds the instruments created for a pool
+type poolTelemetry struct {
+	queueWait    metric.Float64Histogram
+	registration metric.Registration
+}
+
+// registerMetrics creates the pool's instruments if a meter was configured
+func (wp *WorkerPool[T]) registerMetrics() error {
+	meter := wp.opts.meter
+	if meter == nil {
+		return nil
+	}
+
+	queued, err := meter.Int64ObservableGauge("pool.jobs.queued",
+		metric.WithDescription("Jobs waiting for a worker"))
+	if err != nil {
+		return err
+	}
+	active, err := meter.Int64ObservableGauge("pool.jobs.active",
+		metric.WithDescription("Jobs currently being executed"))
+	if err != nil {
+		return err
+	}
+	wp.telemetry.queueWait, err = meter.Float64Histogram("pool.queue_wait",
+		metric.WithDescription("Time between submit and a worker starting the job"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+
+	wp.telemetry.registration, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(queued, int64(wp.jobQueue.len()))
+		wp.pauseMu.Lock()
+		o.ObserveInt64(active, int64(wp.active))
+		wp.pauseMu.Unlock()
+		return nil
+	}, queued, active)
+	return err
+}
+
+// unregisterMetrics stops the gauge callback
+func (wp *WorkerPool[T]) unregisterMetrics() {
+	if wp.telemetry.registration != nil {
+		wp.telemetry.registration.Unregister()
+	}
+}
+
+// recordQueueWait adds a job's queue wait to the histogram
+func (wp *WorkerPool[T]) recordQueueWait(job Job[T], wait time.Duration) {
+	if wp.telemetry.queueWait != nil {
+		wp.telemetry.queueWait.Record(wp.ctx, wait.Seconds(), metric.WithAttributes(labelAttributes(job.Labels)...))
+	}
+}
+
+// startSpan starts the job span at its enqueue time and returns ctx with the
+// span attached, plus a function ending it with the job outcome
+func (wp *WorkerPool[T]) startSpan(ctx context.Context, workerID int, job Job[T], enqueued time.Time) (context.Context, func(attempts int, err error)) {
+	tracer := wp.opts.tracer
+	if tracer == nil {
+		return ctx, func(int, error) {}
+	}
+
+	parent := job.TraceContext
+	if parent == nil {
+		parent = ctx
+	}
+
+	attrs := append(labelAttributes(job.Labels),
+		attribute.Int("job.id", job.ID),
+		attribute.Int("job.priority", job.Priority),
+		attribute.Int("pool.worker_id", workerID),
+	)
+	spanCtx, span := tracer.Start(parent, "pool.job",
+		trace.WithTimestamp(enqueued), trace.WithAttributes(attrs...))
+	_, waitSpan := tracer.Start(spanCtx, "pool.queue_wait", trace.WithTimestamp(enqueued))
+	waitSpan.End()
+
+	return trace.ContextWithSpan(ctx, span), func(attempts int, err error) {
+		span.SetAttributes(attribute.Int("job.attempts", attempts))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// labelAttributes converts job labels into span and metric attributes
+func labelAttributes(labels map[string]string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(labels))
+	for k, v := range labels {
+		attrs = append(attrs, attribute.String("job."+k, v))
+	}
+	return attrs
+}
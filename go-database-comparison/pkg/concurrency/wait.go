@@ -0,0 +1,96 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+)
+
+// markDelivered counts a result handed to its consumer and wakes WaitAll
+// callers once every accepted job has been delivered
+func (wp *WorkerPool[T]) markDelivered() {
+	wp.queueMu.Lock()
+	defer wp.queueMu.Unlock()
+
+	wp.delivered++
+	if wp.delivered == wp.seq && wp.drained != nil {
+		close(wp.drained)
+		wp.drained = nil
+	}
+}
+
+// counts returns the number of accepted jobs and of delivered results
+func (wp *WorkerPool[T]) counts() (submitted, delivered uint64) {
+	wp.queueMu.Lock()
+	defer wp.queueMu.Unlock()
+
+	return wp.seq, wp.delivered
+}
+
+// drainedCh returns a channel closed once every accepted job has been
+// delivered, or nil if that is already the case
+func (wp *WorkerPool[T]) drainedCh() <-chan struct{} {
+	wp.queueMu.Lock()
+	defer wp.queueMu.Unlock()
+
+	if wp.delivered == wp.seq {
+		return nil
+	}
+	if wp.drained == nil {
+		wp.drained = make(chan struct{})
+	}
+	return wp.drained
+}
+
+// WaitAll blocks until the result of every accepted job, including jobs
+// coalesced by DedupKey, has been delivered on Results or to its Execute
+// caller. Rejected submissions are not waited for. Results must be consumed
+// meanwhile, or use CollectAll. An error is returned if ctx is done or the
+// pool is stopped first.
+func (wp *WorkerPool[T]) WaitAll(ctx context.Context) error {
+	for {
+		drained := wp.drainedCh()
+		if drained == nil {
+			return nil
+		}
+
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wp.ctx.Done():
+			submitted, delivered := wp.counts()
+			return fmt.Errorf("worker pool stopped after %d/%d results", delivered, submitted)
+		}
+	}
+}
+
+// CollectAll reads Results until the result of every accepted job has been
+// received, so callers need not count successful submissions. Results sent
+// to Execute callers are not included. The results read so far are returned
+// with an error if ctx is done or the pool is stopped first.
+func (wp *WorkerPool[T]) CollectAll(ctx context.Context) ([]Result[T], error) {
+	var results []Result[T]
+	for {
+		drained := wp.drainedCh()
+		if drained == nil && len(wp.results) == 0 {
+			return results, nil
+		}
+
+		select {
+		case result, ok := <-wp.results:
+			if !ok {
+				if err := wp.WaitAll(ctx); err != nil {
+					return results, err
+				}
+				return results, nil
+			}
+			results = append(results, result)
+		case <-drained:
+		case <-ctx.Done():
+			return results, ctx.Err()
+		case <-wp.ctx.Done():
+			submitted, delivered := wp.counts()
+			return results, fmt.Errorf("worker pool stopped after %d/%d results", delivered, submitted)
+		}
+	}
+}
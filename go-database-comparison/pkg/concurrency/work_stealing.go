@@ -0,0 +1,142 @@
+package concurrency
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WithWorkStealing gives every worker its own queue instead of one shared
+// queue. Submit places jobs on an idle worker's queue when there is one,
+// and workers with an empty queue steal from the others. Priorities are
+// honored within each worker's queue only.
+func WithWorkStealing() Option {
+	return func(o *poolOptions) {
+		o.workStealing = true
+	}
+}
+
+// localQueue is the priority queue of a single worker
+type localQueue[T any] struct {
+	mu    sync.Mutex
+	items jobHeap[T]
+}
+
+// stealingQueue distributes jobs over per-worker queues
+type stealingQueue[T any] struct {
+	queues []*localQueue[T]
+	notify []chan struct{} // Wakes a sleeping worker, one slot each
+	idle   []atomic.Bool
+	size   atomic.Int64
+	limit  int64
+	next   atomic.Uint64 // Round-robin cursor for busy pools
+	done   chan struct{} // Closed by close
+	closed atomic.Bool
+}
+
+func newStealingQueue[T any](workers, capacity int) *stealingQueue[T] {
+	q := &stealingQueue[T]{
+		queues: make([]*localQueue[T], workers),
+		notify: make([]chan struct{}, workers),
+		idle:   make([]atomic.Bool, workers),
+		limit:  int64(capacity),
+		done:   make(chan struct{}),
+	}
+	for i := range q.queues {
+		q.queues[i] = &localQueue[T]{}
+		q.notify[i] = make(chan struct{}, 1)
+	}
+	return q
+}
+
+func (q *stealingQueue[T]) push(item queuedJob[T]) bool {
+	if q.size.Add(1) > q.limit {
+		q.size.Add(-1)
+		return false
+	}
+
+	n := len(q.queues)
+	start := int(q.next.Add(1) % uint64(n))
+	target := start
+	for i := 0; i < n; i++ {
+		if candidate := (start + i) % n; q.idle[candidate].Load() {
+			target = candidate
+			break
+		}
+	}
+
+	local := q.queues[target]
+	local.mu.Lock()
+	heap.Push(&local.items, item)
+	local.mu.Unlock()
+
+	select {
+	case q.notify[target] <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (q *stealingQueue[T]) wait(ctx context.Context, workerID int) bool {
+	for {
+		if q.size.Load() > 0 {
+			return true
+		}
+		if q.closed.Load() {
+			return false
+		}
+
+		// Advertise as idle, then re-check so a concurrent push is not missed
+		q.idle[workerID].Store(true)
+		if q.size.Load() > 0 || q.closed.Load() {
+			q.idle[workerID].Store(false)
+			continue
+		}
+
+		select {
+		case <-q.notify[workerID]:
+		case <-q.done:
+		case <-ctx.Done():
+			q.idle[workerID].Store(false)
+			return false
+		}
+		q.idle[workerID].Store(false)
+	}
+}
+
+func (q *stealingQueue[T]) take(workerID int) (queuedJob[T], bool) {
+	n := len(q.queues)
+	for i := 0; i < n; i++ {
+		local := q.queues[(workerID+i)%n]
+		local.mu.Lock()
+		if len(local.items) > 0 {
+			item := heap.Pop(&local.items).(queuedJob[T])
+			local.mu.Unlock()
+			q.size.Add(-1)
+			return item, true
+		}
+		local.mu.Unlock()
+	}
+	return queuedJob[T]{}, false
+}
+
+func (q *stealingQueue[T]) contains(jobID int) bool {
+	for _, local := range q.queues {
+		local.mu.Lock()
+		found := local.items.contains(jobID)
+		local.mu.Unlock()
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *stealingQueue[T]) len() int      { return int(q.size.Load()) }
+func (q *stealingQueue[T]) capacity() int { return int(q.limit) }
+
+func (q *stealingQueue[T]) close() {
+	q.closed.Store(true)
+	close(q.done)
+}
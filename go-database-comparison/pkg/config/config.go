@@ -0,0 +1,131 @@
+// Package config loads dbcompare settings from a YAML file so a benchmark
+// run can be reproduced from a checked-in config.
+//
+// Keys are flag names. Top-level keys apply to every command, a mapping
+// under a command name applies to that command only and wins over the
+// top-level keys:
+//
+//	host: localhost
+//	port: 5432
+//	comprehensive-benchmark:
+//	  iterations: 500
+//	  operations: [create, read, update]
+//	  op-timeout: 2s
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File holds the settings read from a config file as flag values
+type File struct {
+	Path     string
+	global   map[string]string
+	commands map[string]map[string]string
+}
+
+// Load reads and parses the config file at path
+func Load(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	file := &File{
+		Path:     path,
+		global:   make(map[string]string),
+		commands: make(map[string]map[string]string),
+	}
+	for key, value := range raw {
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			if file.global[key], err = flagValue(value); err != nil {
+				return nil, fmt.Errorf("%s: key %q: %w", path, key, err)
+			}
+			continue
+		}
+
+		values := make(map[string]string, len(section))
+		for name, v := range section {
+			if values[name], err = flagValue(v); err != nil {
+				return nil, fmt.Errorf("%s: key %q in %s: %w", path, name, key, err)
+			}
+		}
+		file.commands[key] = values
+	}
+	return file, nil
+}
+
+// Settings returns the flag values for command: the top-level keys
+// overridden by the command's own section
+func (f *File) Settings(command string) map[string]string {
+	settings := make(map[string]string, len(f.global))
+	for name, value := range f.global {
+		settings[name] = value
+	}
+	for name, value := range f.commands[command] {
+		settings[name] = value
+	}
+	return settings
+}
+
+// GlobalKeys returns the top-level keys, sorted
+func (f *File) GlobalKeys() []string {
+	return sortedKeys(f.global)
+}
+
+// Commands returns the names of the command sections, sorted
+func (f *File) Commands() []string {
+	names := make([]string, 0, len(f.commands))
+	for name := range f.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CommandKeys returns the keys of a command section, sorted
+func (f *File) CommandKeys(command string) []string {
+	return sortedKeys(f.commands[command])
+}
+
+// flagValue converts a YAML scalar or list into the string form flags parse
+func flagValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := flagValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("nested mappings are only allowed for command sections")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
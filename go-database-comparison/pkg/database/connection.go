@@ -3,24 +3,40 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+
+	"go-database-comparison/pkg/dblog"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/rollback"
 )
 
 // DatabaseConfig holds database connection configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host       string
+	Port       int
+	User       string
+	Password   string
+	DBName     string
+	SSLMode    string
+	QueryLog   *dblog.Sink // Logs every statement of all libraries when set
+	Rollback   bool        // Runs each pool on one connection in a transaction rolled back on Close, see pkg/rollback
+	SearchPath string      // Schemas unqualified tables resolve to, e.g. "partitioned,public"; the server's default when empty
+	GORMHooks  bool        // Runs the hooks of the GORM models, see models.HooksSetting
+	SQLXMapper string      // Maps struct fields to columns for sqlx, see SQLXMappers; "db" when empty
+	SQLXUnsafe bool        // Lets sqlx ignore columns no struct field maps, see sqlx.DB.Unsafe
+	ReadOnlyTx bool        // Repositories opened by dbcompare run their reads in read-only transactions, see repository.PQRepository.WithReadOnlyReads
+	AppName    string      // Reported as application_name in pg_stat_activity; the driver's default when empty
 }
 
 // DefaultPostgreSQLConfig returns default PostgreSQL configuration for testing
@@ -37,13 +53,24 @@ func DefaultPostgreSQLConfig() *DatabaseConfig {
 
 // PostgreSQLDSN generates PostgreSQL connection string
 func (c *DatabaseConfig) PostgreSQLDSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
+	if c.SearchPath != "" {
+		// lib/pq and pgx both send unknown keys as session settings
+		dsn += fmt.Sprintf(" search_path='%s'", strings.ReplaceAll(c.SearchPath, "'", `\'`))
+	}
+	if c.AppName != "" {
+		dsn += fmt.Sprintf(" application_name='%s'", strings.ReplaceAll(c.AppName, "'", `\'`))
+	}
+	return dsn
 }
 
 // ConnectWithPQ establishes connection using lib/pq driver
-func ConnectWithPQ(ctx context.Context, config *DatabaseConfig) (*sql.DB, error) {
-	db, err := sql.Open("postgres", config.PostgreSQLDSN())
+func ConnectWithPQ(ctx context.Context, config *DatabaseConfig) (_ *sql.DB, err error) {
+	ctx, span := startConnectSpan(ctx, "PQ", config)
+	defer func() { endConnectSpan(span, err) }()
+
+	db, err := openSQL(config, "PQ")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open PQ connection: %w", err)
 	}
@@ -52,6 +79,9 @@ func ConnectWithPQ(ctx context.Context, config *DatabaseConfig) (*sql.DB, error)
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
+	if config.Rollback {
+		pinConnection(db)
+	}
 
 	// Test connection with context
 	if err := db.PingContext(ctx); err != nil {
@@ -62,17 +92,69 @@ func ConnectWithPQ(ctx context.Context, config *DatabaseConfig) (*sql.DB, error)
 	return db, nil
 }
 
+// openSQL opens a lib/pq *sql.DB, logging its statements as library when
+// config.QueryLog is set and rolling them back when config.Rollback is
+func openSQL(config *DatabaseConfig, library string) (*sql.DB, error) {
+	if config.QueryLog == nil && !config.Rollback {
+		return sql.Open("postgres", config.PostgreSQLDSN())
+	}
+
+	var connector driver.Connector
+	connector, err := pq.NewConnector(config.PostgreSQLDSN())
+	if err != nil {
+		return nil, err
+	}
+	if config.QueryLog != nil {
+		connector = dblog.Connector(connector, config.QueryLog, library)
+	}
+	if config.Rollback {
+		connector = rollback.Connector(connector)
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// openRollbackGORM opens the pgx *sql.DB GORM uses, rolling its statements
+// back like openSQL does; GORM logs them itself
+func openRollbackGORM(config *DatabaseConfig) (*sql.DB, error) {
+	pgxConfig, err := pgx.ParseConfig(config.PostgreSQLDSN())
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(rollback.Connector(stdlib.GetConnector(*pgxConfig))), nil
+}
+
+// pinConnection keeps db on one connection for its whole lifetime, as a
+// rolled back transaction needs: other connections would not see its data
+// and closing the connection would roll it back
+func pinConnection(db *sql.DB) {
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+}
+
 // ConnectWithSQLX establishes connection using sqlx
-func ConnectWithSQLX(ctx context.Context, config *DatabaseConfig) (*sqlx.DB, error) {
-	db, err := sqlx.ConnectContext(ctx, "postgres", config.PostgreSQLDSN())
+func ConnectWithSQLX(ctx context.Context, config *DatabaseConfig) (_ *sqlx.DB, err error) {
+	ctx, span := startConnectSpan(ctx, "SQLX", config)
+	defer func() { endConnectSpan(span, err) }()
+
+	sqlDB, err := openSQL(config, "SQLX")
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect with SQLX: %w", err)
 	}
+	db, err := configureSQLX(sqlx.NewDb(sqlDB, "postgres"), config)
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to configure SQLX: %w", err)
+	}
 
 	// Configure connection pool (same settings as PQ for fair comparison)
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
+	if config.Rollback {
+		pinConnection(db.DB)
+	}
 
 	// Test connection
 	if err := db.PingContext(ctx); err != nil {
@@ -84,16 +166,35 @@ func ConnectWithSQLX(ctx context.Context, config *DatabaseConfig) (*sqlx.DB, err
 }
 
 // ConnectWithGORM establishes connection using GORM
-func ConnectWithGORM(ctx context.Context, config *DatabaseConfig) (*gorm.DB, error) {
+func ConnectWithGORM(ctx context.Context, config *DatabaseConfig) (_ *gorm.DB, err error) {
+	ctx, span := startConnectSpan(ctx, "GORM", config)
+	defer func() { endConnectSpan(span, err) }()
+
 	// Configure GORM with custom logger for consistent behavior
 	gormConfig := &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent), // Disable logging for fair performance comparison
 	}
+	if config.QueryLog != nil {
+		gormConfig.Logger = dblog.NewGORMLogger(config.QueryLog)
+	}
 
-	db, err := gorm.Open(postgres.Open(config.PostgreSQLDSN()), gormConfig)
+	dialector := postgres.Open(config.PostgreSQLDSN())
+	if config.Rollback {
+		rollbackDB, err := openRollbackGORM(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect with GORM: %w", err)
+		}
+		dialector = postgres.New(postgres.Config{Conn: rollbackDB})
+	}
+
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect with GORM: %w", err)
 	}
+	if config.GORMHooks {
+		// Session makes the statements built from db inherit the setting
+		db = db.Set(models.HooksSetting, true).Session(&gorm.Session{})
+	}
 
 	// Get underlying sql.DB to configure connection pool
 	sqlDB, err := db.DB()
@@ -105,6 +206,9 @@ func ConnectWithGORM(ctx context.Context, config *DatabaseConfig) (*gorm.DB, err
 	sqlDB.SetMaxOpenConns(25)
 	sqlDB.SetMaxIdleConns(5)
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
+	if config.Rollback {
+		pinConnection(sqlDB)
+	}
 
 	// Test connection with context
 	if err := sqlDB.PingContext(ctx); err != nil {
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// SQLXMappers are the names DatabaseConfig.SQLXMapper accepts, the sqlx
+// default first:
+//
+//	db     the db tag, else the lower-cased field name
+//	json   the json tag without its options, else the lower-cased field name
+//	snake  the field name in snake_case, tags ignored: CreatedAt is created_at
+//	lower  the lower-cased field name, tags ignored: CreatedAt is createdat
+var SQLXMappers = []string{"db", "json", "snake", "lower"}
+
+// SQLXMapper returns the struct field mapper named name, see SQLXMappers
+func SQLXMapper(name string) (*reflectx.Mapper, error) {
+	switch name {
+	case "", "db":
+		return reflectx.NewMapperFunc("db", strings.ToLower), nil
+	case "json":
+		return reflectx.NewMapperTagFunc("json", strings.ToLower, func(tag string) string {
+			name, _, _ := strings.Cut(tag, ",")
+			return name
+		}), nil
+	case "snake":
+		return reflectx.NewMapperFunc("", snakeCase), nil
+	case "lower":
+		return reflectx.NewMapperFunc("", strings.ToLower), nil
+	}
+	return nil, fmt.Errorf("unknown sqlx mapper %q, expected one of %s", name, strings.Join(SQLXMappers, ", "))
+}
+
+// snakeCase returns name in snake_case, keeping initialisms together:
+// CreatedAt is created_at, UserID is user_id and HTTPServer is http_server
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previousLower := !unicode.IsUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// configureSQLX applies the mapper and unsafe mode of config to db
+func configureSQLX(db *sqlx.DB, config *DatabaseConfig) (*sqlx.DB, error) {
+	mapper, err := SQLXMapper(config.SQLXMapper)
+	if err != nil {
+		return nil, err
+	}
+	db.Mapper = mapper
+	if config.SQLXUnsafe {
+		// Statements, transactions and connections of db inherit it
+		db = db.Unsafe()
+	}
+	return db, nil
+}
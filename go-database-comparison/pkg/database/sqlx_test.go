@@ -0,0 +1,325 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// userColumns are the columns of users models.User has a field for, in the
+// order the repositories select them
+var userColumns = []string{"id", "name", "email", "age", "created_at", "updated_at", "is_active"}
+
+// mapsColumn reports whether the named mapper maps column to a field of
+// models.User: lower maps CreatedAt to createdat, missing every column of
+// two words
+func mapsColumn(mapper, column string) bool {
+	if !slices.Contains(userColumns, column) {
+		return false
+	}
+	return mapper != "lower" || !strings.Contains(column, "_")
+}
+
+// TestSQLXMapper checks the columns every mapper maps the fields of
+// models.User to
+func TestSQLXMapper(t *testing.T) {
+	for _, name := range database.SQLXMappers {
+		t.Run(name, func(t *testing.T) {
+			mapper, err := database.SQLXMapper(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := mapper.TypeMap(reflect.TypeOf(models.User{}))
+			for _, column := range userColumns {
+				if mapped := fields.GetByPath(column) != nil; mapped != mapsColumn(name, column) {
+					t.Errorf("maps %s: %t, want %t", column, mapped, !mapped)
+				}
+			}
+		})
+	}
+
+	if _, err := database.SQLXMapper("camel"); err == nil {
+		t.Error("an unknown mapper was accepted")
+	}
+}
+
+// TestSQLXMapperSnakeCase checks that the snake mapper keeps initialisms
+// together and ignores tags
+func TestSQLXMapperSnakeCase(t *testing.T) {
+	type fields struct {
+		CreatedAt  int
+		UserID     int
+		HTTPServer int
+		ID         int
+		Tagged     int `db:"renamed"`
+	}
+	mapper, err := database.SQLXMapper("snake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeMap := mapper.TypeMap(reflect.TypeOf(fields{}))
+	for _, column := range []string{"created_at", "user_id", "http_server", "id", "tagged"} {
+		if typeMap.GetByPath(column) == nil {
+			t.Errorf("no field maps to %s", column)
+		}
+	}
+}
+
+// variant is a mapper of database.SQLXMappers, in unsafe mode or not
+type variant struct {
+	mapper string
+	unsafe bool
+}
+
+func (v variant) String() string {
+	if v.unsafe {
+		return v.mapper + "+unsafe"
+	}
+	return v.mapper
+}
+
+// variants returns every mapper without and with unsafe mode
+func variants() []variant {
+	var all []variant
+	for _, mapper := range database.SQLXMappers {
+		all = append(all, variant{mapper, false}, variant{mapper, true})
+	}
+	return all
+}
+
+// expected returns what reading columns into models.User should do with
+// v: fail on the first column no field maps, unless unsafe mode ignores
+// it, and leave the fields no selected column maps zero
+func (v variant) expected(columns []string) string {
+	for _, column := range columns {
+		if !mapsColumn(v.mapper, column) && !v.unsafe {
+			return "missing destination name " + column
+		}
+	}
+	var zero []string
+	for _, column := range userColumns {
+		if !slices.Contains(columns, column) || !mapsColumn(v.mapper, column) {
+			zero = append(zero, column)
+		}
+	}
+	if len(zero) == 0 {
+		return "mapped"
+	}
+	return "mapped, " + strings.Join(zero, ", ") + " left zero"
+}
+
+// connect connects sqlx with v's mapper and unsafe mode
+func (v variant) connect(t testing.TB, config *database.DatabaseConfig) *sqlx.DB {
+	t.Helper()
+	variantConfig := *config
+	variantConfig.SQLXMapper = v.mapper
+	variantConfig.SQLXUnsafe = v.unsafe
+	db, err := database.ConnectWithSQLX(context.Background(), &variantConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// observedMapping describes how got, read with err, compares with want in
+// the terms of variant.expected
+func observedMapping(t *testing.T, got, want *models.User, err error) string {
+	t.Helper()
+	if err != nil {
+		if _, column, ok := strings.Cut(err.Error(), "missing destination name "); ok {
+			column, _, _ = strings.Cut(column, " ")
+			return "missing destination name " + column
+		}
+		t.Fatal(err)
+	}
+
+	columns := []struct {
+		name        string
+		equal, zero bool
+	}{
+		{"id", got.ID == want.ID, got.ID == 0},
+		{"name", got.Name == want.Name, got.Name == ""},
+		{"email", got.Email == want.Email, got.Email == ""},
+		{"age", got.Age == want.Age, got.Age == 0},
+		{"created_at", got.CreatedAt.Equal(want.CreatedAt), got.CreatedAt.IsZero()},
+		{"updated_at", got.UpdatedAt.Equal(want.UpdatedAt), got.UpdatedAt.IsZero()},
+		{"is_active", got.IsActive == want.IsActive, !got.IsActive},
+	}
+	var zero []string
+	for _, column := range columns {
+		switch {
+		case column.equal:
+		case column.zero:
+			zero = append(zero, column.name)
+		default:
+			return fmt.Sprintf("mapped, %s differs", column.name)
+		}
+	}
+	if len(zero) == 0 {
+		return "mapped"
+	}
+	return "mapped, " + strings.Join(zero, ", ") + " left zero"
+}
+
+// TestSQLXMismatch reads a user into models.User with every mapper, with
+// and without unsafe mode, where columns and fields do not match: a column
+// no field maps fails with "missing destination name" unless unsafe mode
+// ignores it, and missing columns leave their fields zero in either mode.
+// SQLXRepository.GetUserByID fails with the lower mapper, or in unsafe
+// mode silently reads with fields left zero.
+func TestSQLXMismatch(t *testing.T) {
+	config := dbtest.Config(t)
+
+	for _, v := range variants() {
+		t.Run(v.String(), func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+			db := v.connect(t, config)
+
+			want := &models.User{}
+			email := benchdata.Email(benchdata.RunID(ctx), "mapping", "sqlx", 1)
+			err := db.QueryRowContext(ctx, "INSERT INTO users (name, email, age, is_active) VALUES ($1, $2, $3, true) RETURNING "+strings.Join(userColumns, ", "),
+				"Mapping", email, 30,
+			).Scan(&want.ID, &want.Name, &want.Email, &want.Age, &want.CreatedAt, &want.UpdatedAt, &want.IsActive)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			get := func(selectList string) func() (*models.User, error) {
+				return func() (*models.User, error) {
+					user := &models.User{}
+					return user, db.GetContext(ctx, user, "SELECT "+selectList+" FROM users WHERE id = $1", want.ID)
+				}
+			}
+			repo := repository.NewSQLXRepository(db)
+			reads := []struct {
+				name    string
+				columns []string
+				read    func() (*models.User, error)
+			}{
+				{"extra column", append(slices.Clone(userColumns), "name_length"), get(strings.Join(userColumns, ", ") + ", length(name) AS name_length")},
+				{"missing column", []string{"id", "name", "email"}, get("id, name, email")},
+				{"repository", userColumns, func() (*models.User, error) { return repo.GetUserByID(ctx, want.ID) }},
+			}
+			for _, r := range reads {
+				user, err := r.read()
+				if got, expected := observedMapping(t, user, want, err), v.expected(r.columns); got != expected {
+					t.Errorf("%s: %s, want %s", r.name, got, expected)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSQLXTypeMap times building the field map of models.User with a
+// fresh mapper, which sqlx does once per type and mapper
+func BenchmarkSQLXTypeMap(b *testing.B) {
+	userType := reflect.TypeOf(models.User{})
+	for _, name := range database.SQLXMappers {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mapper, err := database.SQLXMapper(name)
+				if err != nil {
+					b.Fatal(err)
+				}
+				mapper.TypeMap(userType)
+			}
+		})
+	}
+}
+
+// BenchmarkSQLXMapping selects 1000 users into models.User with every
+// variant that maps all columns, and with lib/pq scanning them by hand as
+// the baseline. The difference is what mapping by reflection costs. The
+// users are committed and removed by run ID, so every variant's
+// connection sees them.
+func BenchmarkSQLXMapping(b *testing.B) {
+	const users = 1000
+	config := dbtest.Config(b)
+	config.Rollback = false
+	ctx := dbtest.Context(b, config)
+	observer := dbtest.Observer(b, config)
+
+	runID := benchdata.RunID(ctx)
+	names := make([]string, users)
+	emails := make([]string, users)
+	ages := make([]int64, users)
+	for i := range users {
+		names[i] = fmt.Sprintf("Mapping %d", i)
+		emails[i] = benchdata.Email(runID, "mapping", "fixture", int64(i))
+		ages[i] = int64(18 + i%60)
+	}
+	_, err := observer.ExecContext(ctx, `
+		INSERT INTO users (name, email, age, is_active)
+		SELECT *, true FROM unnest($1::text[], $2::text[], $3::int[])`,
+		pq.Array(names), pq.Array(emails), pq.Array(ages))
+	if err != nil {
+		b.Fatal(err)
+	}
+	_, domain, _ := strings.Cut(emails[0], "@")
+	pattern := "mapping-fixture-%@" + domain
+	query := "SELECT " + strings.Join(userColumns, ", ") + " FROM users WHERE email LIKE $1 ORDER BY id"
+
+	b.Run("pq scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if n := scanUsers(b, ctx, observer, query, pattern); n != users {
+				b.Fatalf("scanned %d users, want %d", n, users)
+			}
+		}
+	})
+	for _, v := range variants() {
+		if v.expected(userColumns) != "mapped" {
+			continue
+		}
+		b.Run(v.String(), func(b *testing.B) {
+			db := v.connect(b, config)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var selected []models.User
+				if err := db.SelectContext(ctx, &selected, query, pattern); err != nil {
+					b.Fatal(err)
+				}
+				if len(selected) != users {
+					b.Fatalf("selected %d users, want %d", len(selected), users)
+				}
+			}
+		})
+	}
+}
+
+// scanUsers runs query with arg and scans the users by hand, returning
+// how many it read
+func scanUsers(b *testing.B, ctx context.Context, db *sql.DB, query string, arg interface{}) int {
+	b.Helper()
+	rows, err := db.QueryContext(ctx, query, arg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Age, &user.CreatedAt, &user.UpdatedAt, &user.IsActive); err != nil {
+			b.Fatal(err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		b.Fatal(err)
+	}
+	return n
+}
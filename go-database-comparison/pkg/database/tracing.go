@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records connection setup spans with the global provider
+var tracer = otel.Tracer("go-database-comparison/pkg/database")
+
+// startConnectSpan starts the span of a Connect function, named like "PQ connect"
+func startConnectSpan(ctx context.Context, library string, config *DatabaseConfig) (context.Context, trace.Span) {
+	return tracer.Start(ctx, library+" connect",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.name", config.DBName),
+			attribute.String("db.user", config.User),
+			attribute.String("db.client.library", library),
+			attribute.String("server.address", config.Host),
+			attribute.Int("server.port", config.Port),
+		))
+}
+
+// endConnectSpan records err, if any, and ends span
+func endConnectSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,73 @@
+// Package dblog logs the statements of all three libraries through one
+// slog sink, so query logs can be compared line by line. lib/pq and sqlx
+// are hooked with a database/sql driver wrapper, GORM with its logger
+// interface. Every record carries the same fields: library, sql, args,
+// duration and rows.
+package dblog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Query is one executed statement
+type Query struct {
+	Library  string
+	SQL      string // As sent to the server, with $n placeholders
+	Args     []interface{}
+	Duration time.Duration // Until the result was consumed, for queries until the rows were closed
+	Rows     int64         // Rows returned or affected, -1 when unknown
+	Err      error
+}
+
+// Sink writes Query records to a slog.Logger
+type Sink struct {
+	logger *slog.Logger
+	level  slog.Level
+	fn     func(ctx context.Context, q Query) // Replaces logging, see NewFuncSink
+}
+
+// NewSink returns a sink logging successful statements at level and
+// failed ones at slog.LevelWarn or above
+func NewSink(logger *slog.Logger, level slog.Level) *Sink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Sink{logger: logger, level: level}
+}
+
+// NewFuncSink returns a sink handing every statement to fn instead of
+// logging it, for callers that inspect statements programmatically. GORM
+// messages other than statements still go to slog.Default.
+func NewFuncSink(fn func(ctx context.Context, q Query)) *Sink {
+	return &Sink{logger: slog.Default(), fn: fn}
+}
+
+// Log writes q
+func (s *Sink) Log(ctx context.Context, q Query) {
+	if s.fn != nil {
+		s.fn(ctx, q)
+		return
+	}
+
+	level := s.level
+	if q.Err != nil && level < slog.LevelWarn {
+		level = slog.LevelWarn
+	}
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("library", q.Library),
+		slog.String("sql", q.SQL),
+		slog.Any("args", q.Args),
+		slog.Duration("duration", q.Duration),
+		slog.Int64("rows", q.Rows),
+	}
+	if q.Err != nil {
+		attrs = append(attrs, slog.Any("error", q.Err))
+	}
+	s.logger.LogAttrs(ctx, level, "query", attrs...)
+}
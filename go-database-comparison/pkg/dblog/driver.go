@@ -0,0 +1,227 @@
+package dblog
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+)
+
+// Connector wraps c so that every statement run on its connections is
+// logged to sink as library. Use it with sql.OpenDB, for lib/pq with
+// pq.NewConnector; sqlx wraps the resulting *sql.DB with sqlx.NewDb.
+func Connector(c driver.Connector, sink *Sink, library string) driver.Connector {
+	return &connector{Connector: c, sink: sink, library: library}
+}
+
+type connector struct {
+	driver.Connector
+	sink    *Sink
+	library string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConn{Conn: conn, c: c}, nil
+}
+
+// log records a statement that started at start
+func (c *connector) log(ctx context.Context, query string, args []driver.NamedValue, start time.Time, rows int64, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return // database/sql retries another way, which is logged instead
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.sink.Log(ctx, Query{
+		Library:  c.library,
+		SQL:      query,
+		Args:     values,
+		Duration: time.Since(start),
+		Rows:     rows,
+		Err:      err,
+	})
+}
+
+// loggedConn logs the statements of a driver connection. It only offers
+// the context variants, database/sql converts the legacy calls.
+type loggedConn struct {
+	driver.Conn
+	c *connector
+}
+
+func (lc *loggedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := lc.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	lc.c.log(ctx, query, args, start, rowsAffected(result), err)
+	return result, err
+}
+
+func (lc *loggedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := lc.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		lc.c.log(ctx, query, args, start, -1, err)
+		return nil, err
+	}
+	return &loggedRows{Rows: rows, ctx: ctx, query: query, args: args, start: start, c: lc.c}, nil
+}
+
+func (lc *loggedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := lc.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = lc.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loggedStmt{Stmt: stmt, query: query, c: lc.c}, nil
+}
+
+func (lc *loggedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := lc.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return lc.Conn.Begin()
+}
+
+func (lc *loggedConn) Ping(ctx context.Context) error {
+	if pinger, ok := lc.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (lc *loggedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := lc.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (lc *loggedConn) IsValid() bool {
+	if validator, ok := lc.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// loggedStmt logs the executions of a prepared statement
+type loggedStmt struct {
+	driver.Stmt
+	query string
+	c     *connector
+}
+
+func (ls *loggedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := ls.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = ls.Stmt.Exec(driverValues(args))
+	}
+	ls.c.log(ctx, ls.query, args, start, rowsAffected(result), err)
+	return result, err
+}
+
+func (ls *loggedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := ls.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = ls.Stmt.Query(driverValues(args))
+	}
+	if err != nil {
+		ls.c.log(ctx, ls.query, args, start, -1, err)
+		return nil, err
+	}
+	return &loggedRows{Rows: rows, ctx: ctx, query: ls.query, args: args, start: start, c: ls.c}, nil
+}
+
+// loggedRows counts the rows read and logs the query once closed, so the
+// duration includes fetching the result like GORM's does
+type loggedRows struct {
+	driver.Rows
+	ctx   context.Context
+	query string
+	args  []driver.NamedValue
+	start time.Time
+	c     *connector
+	count int64
+	err   error
+}
+
+func (lr *loggedRows) Next(dest []driver.Value) error {
+	err := lr.Rows.Next(dest)
+	switch {
+	case err == nil:
+		lr.count++
+	case err != io.EOF:
+		lr.err = err
+	}
+	return err
+}
+
+// ColumnTypeScanType forwards to the driver so sql.ColumnType stays precise
+func (lr *loggedRows) ColumnTypeScanType(index int) reflect.Type {
+	if typed, ok := lr.Rows.(driver.RowsColumnTypeScanType); ok {
+		return typed.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+// ColumnTypeDatabaseTypeName forwards to the driver
+func (lr *loggedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if typed, ok := lr.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (lr *loggedRows) Close() error {
+	err := lr.Rows.Close()
+	lr.c.log(lr.ctx, lr.query, lr.args, lr.start, lr.count, lr.err)
+	return err
+}
+
+// driverValues converts args for drivers without the context interfaces
+func driverValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// rowsAffected returns the affected rows of result, -1 when unknown
+func rowsAffected(result driver.Result) int64 {
+	if result == nil {
+		return -1
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,111 @@
+package dblog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// stashMarker starts the key of a stashed statement in the SQL handed
+// from ParamsFilter to Trace
+const stashMarker = "\x00dblog:"
+
+// stashed is a statement as GORM built it, before interpolation
+type stashed struct {
+	sql  string
+	args []interface{}
+}
+
+// GORMLogger is a GORM logger writing statements to a Sink. Unlike GORM's
+// own logger it logs the SQL with $n placeholders and the arguments apart,
+// like the driver wrapper does for lib/pq and sqlx.
+type GORMLogger struct {
+	sink    *Sink
+	level   logger.LogLevel
+	nextKey *atomic.Uint64
+	stash   *sync.Map // Statements by key, see ParamsFilter
+}
+
+// NewGORMLogger returns a GORM logger for gorm.Config.Logger
+func NewGORMLogger(sink *Sink) *GORMLogger {
+	return &GORMLogger{sink: sink, level: logger.Info, nextKey: &atomic.Uint64{}, stash: &sync.Map{}}
+}
+
+// LogMode implements logger.Interface
+func (l *GORMLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info implements logger.Interface
+func (l *GORMLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		l.sink.logger.InfoContext(ctx, fmt.Sprintf(msg, data...), "library", "GORM")
+	}
+}
+
+// Warn implements logger.Interface
+func (l *GORMLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		l.sink.logger.WarnContext(ctx, fmt.Sprintf(msg, data...), "library", "GORM")
+	}
+}
+
+// Error implements logger.Interface
+func (l *GORMLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		l.sink.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...), "library", "GORM")
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter. GORM calls it from the fc
+// passed to Trace and interpolates what it returns into one SQL string.
+// To keep placeholders and arguments apart, the statement is stashed and
+// only its key is returned for Trace to pick up.
+func (l *GORMLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	key := l.nextKey.Add(1)
+	l.stash.Store(key, stashed{sql: sql, args: params})
+	return stashMarker + strconv.FormatUint(key, 10), nil
+}
+
+// Trace implements logger.Interface
+func (l *GORMLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil // Not a failed statement, GORM reports empty results this way
+	}
+
+	sql, rows := fc()
+	var args []interface{}
+	if strings.HasPrefix(sql, stashMarker) {
+		key, _ := strconv.ParseUint(sql[len(stashMarker):], 10, 64)
+		if v, ok := l.stash.LoadAndDelete(key); ok {
+			sql, args = v.(stashed).sql, v.(stashed).args
+		}
+	}
+
+	l.sink.Log(ctx, Query{
+		Library:  "GORM",
+		SQL:      sql,
+		Args:     args,
+		Duration: time.Since(begin),
+		Rows:     rows,
+		Err:      err,
+	})
+}
+
+var (
+	_ logger.Interface  = (*GORMLogger)(nil)
+	_ gorm.ParamsFilter = (*GORMLogger)(nil)
+)
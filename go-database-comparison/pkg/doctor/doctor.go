@@ -0,0 +1,123 @@
+// Package doctor runs health checks of the benchmark environment and
+// collects their outcomes into a structured pass/fail report.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn" // Works, but may skew or break some results
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // Not run, e.g. because a check it depends on failed
+)
+
+// Check is one named health check. Run returns a short detail on success;
+// an error fails the check unless it is a Warning or a Skip.
+type Check struct {
+	Category string
+	Name     string
+	Run      func(ctx context.Context) (detail string, err error)
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Category string        `json:"category"`
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is the outcome of a doctor run
+type Report struct {
+	Results []Result `json:"results"`
+	Passed  int      `json:"passed"`
+	Warned  int      `json:"warned"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+}
+
+// OK reports whether no check failed
+func (r Report) OK() bool {
+	return r.Failed == 0
+}
+
+// outcome is an error that sets a status other than fail
+type outcome struct {
+	status Status
+	detail string
+}
+
+func (o *outcome) Error() string { return o.detail }
+
+// Warning returns an error that marks a check as warned rather than failed
+func Warning(format string, args ...interface{}) error {
+	return &outcome{status: StatusWarn, detail: fmt.Sprintf(format, args...)}
+}
+
+// Skip returns an error that marks a check as skipped
+func Skip(format string, args ...interface{}) error {
+	return &outcome{status: StatusSkip, detail: fmt.Sprintf(format, args...)}
+}
+
+// Skipped returns checks that are skipped for reason instead of run, e.g.
+// because the connection they need failed
+func Skipped(reason string, checks ...Check) []Check {
+	skipped := make([]Check, len(checks))
+	for i, check := range checks {
+		skipped[i] = Check{Category: check.Category, Name: check.Name, Run: func(ctx context.Context) (string, error) {
+			return "", Skip("%s", reason)
+		}}
+	}
+	return skipped
+}
+
+// Run runs checks in order and reports their outcomes. observe is called
+// with each result as it completes when set, to show progress.
+func Run(ctx context.Context, checks []Check, observe func(Result)) Report {
+	var report Report
+	for _, check := range checks {
+		start := time.Now()
+		detail, err := check.Run(ctx)
+		result := Result{
+			Category: check.Category,
+			Name:     check.Name,
+			Status:   StatusPass,
+			Detail:   detail,
+			Duration: time.Since(start),
+		}
+
+		var o *outcome
+		switch {
+		case err == nil:
+		case errors.As(err, &o):
+			result.Status, result.Detail = o.status, o.detail
+		default:
+			result.Status, result.Detail = StatusFail, err.Error()
+		}
+
+		switch result.Status {
+		case StatusPass:
+			report.Passed++
+		case StatusWarn:
+			report.Warned++
+		case StatusFail:
+			report.Failed++
+		case StatusSkip:
+			report.Skipped++
+		}
+		report.Results = append(report.Results, result)
+		if observe != nil {
+			observe(result)
+		}
+	}
+	return report
+}
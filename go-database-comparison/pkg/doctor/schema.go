@@ -0,0 +1,213 @@
+package doctor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"go-database-comparison/pkg/migrate"
+)
+
+// MinServerVersion is the oldest PostgreSQL supported, as server_version_num;
+// order_items uses a stored generated column, added in PostgreSQL 12
+const MinServerVersion = 120000
+
+// expectedTables lists the tables the migrations create
+var expectedTables = []string{"users", "orders", "order_items", "performance_test"}
+
+// expectedIndexes lists the secondary indexes the migrations create
+var expectedIndexes = []string{
+	"idx_users_email", "idx_users_created_at",
+	"idx_orders_user_id", "idx_orders_status", "idx_orders_order_date",
+	"idx_order_items_order_id",
+	"idx_performance_test_numeric", "idx_performance_test_json",
+}
+
+// expectedConstraint is a constraint the repositories rely on
+type expectedConstraint struct {
+	table      string
+	kind       string // pg_constraint.contype: p, u, c or f
+	columns    string // Comma separated, in key order
+	definition string // Substring of pg_get_constraintdef, for check and foreign keys
+	why        string
+}
+
+var expectedConstraints = []expectedConstraint{
+	{"users", "p", "id", "", "rows are addressed by id"},
+	{"users", "u", "email", "", "duplicate emails must fail as unique violations"},
+	{"users", "c", "age", "150", "ages outside 0 to 150 must be rejected"},
+	{"orders", "f", "user_id", "ON DELETE CASCADE", "deleting a user deletes their orders"},
+	{"order_items", "f", "order_id", "ON DELETE CASCADE", "deleting an order deletes its items"},
+}
+
+// GoVersionCheck returns the check reporting the Go toolchain
+func GoVersionCheck() Check {
+	return Check{Category: "environment", Name: "Go version", Run: func(ctx context.Context) (string, error) {
+		return fmt.Sprintf("%s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH), nil
+	}}
+}
+
+// ServerVersionCheck returns the check that the PostgreSQL server behind
+// db is MinServerVersion or newer
+func ServerVersionCheck(db *sql.DB) Check {
+	return Check{Category: "environment", Name: "PostgreSQL version", Run: func(ctx context.Context) (string, error) {
+		var version string
+		var num int
+		err := db.QueryRowContext(ctx,
+			"SELECT current_setting('server_version'), current_setting('server_version_num')::int").Scan(&version, &num)
+		if err != nil {
+			return "", err
+		}
+		if num < MinServerVersion {
+			return "", fmt.Errorf("PostgreSQL %s is older than the supported 12", version)
+		}
+		return "PostgreSQL " + version, nil
+	}}
+}
+
+// SchemaChecks returns the checks of the tables, constraints, indexes and
+// migrations the benchmarks rely on
+func SchemaChecks(db *sql.DB) []Check {
+	var checks []Check
+	for _, table := range expectedTables {
+		checks = append(checks, Check{Category: "schema", Name: "table " + table, Run: func(ctx context.Context) (string, error) {
+			var exists bool
+			if err := db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists); err != nil {
+				return "", err
+			}
+			if !exists {
+				return "", fmt.Errorf("table %s is missing, run dbcompare migrate up", table)
+			}
+			return "present", nil
+		}})
+	}
+
+	for _, c := range expectedConstraints {
+		checks = append(checks, Check{Category: "schema", Name: fmt.Sprintf("constraint %s(%s)", c.table, c.columns), Run: func(ctx context.Context) (string, error) {
+			return checkConstraint(ctx, db, c)
+		}})
+	}
+
+	checks = append(checks, Check{Category: "schema", Name: "indexes", Run: func(ctx context.Context) (string, error) {
+		return checkIndexes(ctx, db)
+	}})
+
+	checks = append(checks, Check{Category: "schema", Name: "migrations", Run: func(ctx context.Context) (string, error) {
+		return checkMigrations(ctx, db)
+	}})
+	return checks
+}
+
+// checkConstraint looks for a constraint of c's kind on c's columns
+func checkConstraint(ctx context.Context, db *sql.DB, c expectedConstraint) (string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT con.conname, pg_get_constraintdef(con.oid),
+		       (SELECT string_agg(att.attname, ',' ORDER BY k.ord)
+		          FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+		          JOIN pg_attribute att ON att.attrelid = con.conrelid AND att.attnum = k.attnum)
+		FROM pg_constraint con
+		WHERE con.conrelid = to_regclass($1) AND con.contype = $2`, c.table, c.kind)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, definition string
+		var columns sql.NullString
+		if err := rows.Scan(&name, &definition, &columns); err != nil {
+			return "", err
+		}
+		if columns.String == c.columns && strings.Contains(definition, c.definition) {
+			return name + ": " + definition, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("missing: %s", c.why)
+}
+
+// checkIndexes reports the expected indexes that do not exist
+func checkIndexes(ctx context.Context, db *sql.DB) (string, error) {
+	var missing []string
+	for _, index := range expectedIndexes {
+		var exists bool
+		if err := db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", index).Scan(&exists); err != nil {
+			return "", err
+		}
+		if !exists {
+			missing = append(missing, index)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing %s, run dbcompare migrate up", strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("all %d present", len(expectedIndexes)), nil
+}
+
+// checkMigrations warns when the schema is not managed by dbcompare migrate
+// or migrations are pending. It only reads, schema_migrations is not created.
+func checkMigrations(ctx context.Context, db *sql.DB) (string, error) {
+	migrations, err := migrate.Load()
+	if err != nil {
+		return "", err
+	}
+
+	var managed bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&managed); err != nil {
+		return "", err
+	}
+	if !managed {
+		return "", Warning("schema_migrations is missing, the schema was not created by dbcompare migrate")
+	}
+
+	var applied int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&applied); err != nil {
+		return "", err
+	}
+	if applied < len(migrations) {
+		return "", Warning("%d of %d migrations applied, run dbcompare migrate up", applied, len(migrations))
+	}
+	return fmt.Sprintf("%d of %d applied", applied, len(migrations)), nil
+}
+
+// PoolCheck returns a check that the libraries' pools are sized alike and
+// fit the server's max_connections together. maxOpen maps each library to
+// its pool's MaxOpenConnections.
+func PoolCheck(db *sql.DB, maxOpen map[string]int) Check {
+	return Check{Category: "pool", Name: "pool settings", Run: func(ctx context.Context) (string, error) {
+		total, first := 0, -1
+		sizesDiffer := false
+		var sizes []string
+		for _, library := range []string{"PQ", "SQLX", "GORM"} {
+			n, ok := maxOpen[library]
+			if !ok {
+				continue
+			}
+			if first < 0 {
+				first = n
+			}
+			sizesDiffer = sizesDiffer || n != first
+			total += n
+			sizes = append(sizes, fmt.Sprintf("%s=%d", library, n))
+		}
+		summary := "max open " + strings.Join(sizes, " ")
+		if sizesDiffer {
+			return "", fmt.Errorf("%s: pools must be sized alike for a fair comparison", summary)
+		}
+
+		var maxConns, reserved int
+		err := db.QueryRowContext(ctx,
+			"SELECT current_setting('max_connections')::int, current_setting('superuser_reserved_connections')::int").Scan(&maxConns, &reserved)
+		if err != nil {
+			return "", err
+		}
+		if available := maxConns - reserved; total > available {
+			return "", Warning("%s: %d connections together exceed the %d the server allows", summary, total, available)
+		}
+		return fmt.Sprintf("%s, %d of max_connections %d", summary, total, maxConns), nil
+	}}
+}
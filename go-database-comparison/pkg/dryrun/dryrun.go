@@ -0,0 +1,277 @@
+// Package dryrun opens the three libraries on a database/sql driver that
+// answers every statement itself instead of sending it to a server, so the
+// repositories can be run to see the SQL they would execute without a
+// database. Statements go through the same dblog driver wrapper as real
+// connections, so pkg/sqlcapture records exactly what would be sent.
+//
+// GORM runs on this driver too rather than in its own DryRun mode: DryRun
+// builds statements but leaves every result empty, so a repository method
+// that reads a row before writing it, like GORM's UpdateUser, stops with
+// an error before building the write. Answering with a plausible row keeps
+// the statement sequence of every method intact for all three libraries.
+package dryrun
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"go-database-comparison/pkg/dblog"
+	"go-database-comparison/pkg/sqlnorm"
+)
+
+// OpenDB returns a *sql.DB for lib/pq repositories whose statements are
+// logged to sink as library but never executed
+func OpenDB(sink *dblog.Sink, library string) *sql.DB {
+	return sql.OpenDB(dblog.Connector(connector{}, sink, library))
+}
+
+// OpenSQLX returns a *sqlx.DB whose statements are logged to sink but
+// never executed
+func OpenSQLX(sink *dblog.Sink) *sqlx.DB {
+	return sqlx.NewDb(OpenDB(sink, "SQLX"), "postgres")
+}
+
+// OpenGORM returns a *gorm.DB with the PostgreSQL dialect whose statements
+// are logged to sink but never executed
+func OpenGORM(sink *dblog.Sink) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: OpenDB(sink, "GORM")}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GORM dry run: %w", err)
+	}
+	return db, nil
+}
+
+// tableColumns lists the columns a "SELECT *" of each table returns
+var tableColumns = map[string][]string{
+	"users": {"id", "name", "email", "age", "created_at", "updated_at", "is_active"},
+}
+
+// columnValue returns the value the n-th (1-based) dry run row holds for
+// column. Counts and existence checks come back empty so that checks for
+// duplicates pass and the insert that follows them is built. Timestamps,
+// aggregated ones included, are named like created_at.
+func columnValue(column string, n int) driver.Value {
+	switch column {
+	case "id":
+		return int64(n)
+	case "name":
+		return "Dry Run User"
+	case "email":
+		return "dry-run@example.com"
+	case "age":
+		return int64(25)
+	case "created_at", "updated_at", "occurred_at":
+		return time.Now()
+	case "is_active":
+		return true
+	case "exists":
+		return false
+	case "first_created_at", "last_created_at":
+		return time.Now()
+	default:
+		return int64(0)
+	}
+}
+
+// resultColumns returns the names of the columns query returns: the
+// RETURNING list, or the select list of a SELECT. Expressions are named
+// the way PostgreSQL names them, by alias or by the function called.
+func resultColumns(query string) []string {
+	tokens := sqlnorm.Tokens(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var list []string
+	table := ""
+	if at := indexAtDepth0(tokens, "returning"); at >= 0 {
+		list = tokens[at+1:]
+	} else if tokens[0] == "select" {
+		list = tokens[1:]
+		if from := indexAtDepth0(list, "from"); from >= 0 {
+			if from+1 < len(list) {
+				table = list[from+1]
+			}
+			list = list[:from]
+		}
+	} else {
+		return nil
+	}
+
+	var columns []string
+	for _, item := range splitAtDepth0(list) {
+		switch {
+		case len(item) == 0:
+		case len(item) == 1 && item[0] == "*":
+			columns = append(columns, tableColumns[table]...)
+		case len(item) >= 2 && item[len(item)-2] == "as":
+			columns = append(columns, item[len(item)-1])
+		case len(item) >= 2 && item[1] == "(":
+			columns = append(columns, item[0])
+		default:
+			columns = append(columns, item[len(item)-1])
+		}
+	}
+	return columns
+}
+
+// resultRows returns how many rows query returns: one per VALUES tuple of
+// an INSERT, one otherwise. The raw query is scanned since the tokens of
+// sqlnorm collapse a multi-row VALUES list into one tuple.
+func resultRows(query string) int {
+	lower := strings.ToLower(query)
+	tokens := sqlnorm.Tokens(query)
+	values := strings.Index(lower, "values")
+	if len(tokens) == 0 || tokens[0] != "insert" || values < 0 {
+		return 1
+	}
+
+	tuples, depth := 0, 0
+	for _, r := range lower[values+len("values"):] {
+		switch {
+		case r == '(':
+			if depth == 0 {
+				tuples++
+			}
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && unicode.IsLetter(r):
+			// RETURNING or ON CONFLICT ends the list
+			return max(tuples, 1)
+		}
+	}
+	return max(tuples, 1)
+}
+
+// indexAtDepth0 returns the index of the first token outside parentheses
+// equal to word, or -1
+func indexAtDepth0(tokens []string, word string) int {
+	depth := 0
+	for i, token := range tokens {
+		switch token {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case word:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitAtDepth0 splits tokens at the commas outside parentheses
+func splitAtDepth0(tokens []string) [][]string {
+	var items [][]string
+	depth, start := 0, 0
+	for i, token := range tokens {
+		switch token {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				items = append(items, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, tokens[start:])
+}
+
+// connector opens dry run connections
+type connector struct{}
+
+func (connector) Connect(context.Context) (driver.Conn, error) { return conn{}, nil }
+func (connector) Driver() driver.Driver                        { return dryDriver{} }
+
+type dryDriver struct{}
+
+func (dryDriver) Open(string) (driver.Conn, error) { return conn{}, nil }
+
+// conn answers statements without a server: every statement affects one
+// row and every query returns one row, or one per row an INSERT inserts
+type conn struct{}
+
+func (conn) Prepare(query string) (driver.Stmt, error) { return stmt{query: query}, nil }
+func (conn) Close() error                              { return nil }
+func (conn) Begin() (driver.Tx, error)                 { return tx{}, nil }
+
+func (conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return tx{}, nil }
+func (conn) Ping(context.Context) error                                   { return nil }
+
+func (conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return newRows(query), nil
+}
+
+type stmt struct{ query string }
+
+func (s stmt) Close() error  { return nil }
+func (s stmt) NumInput() int { return -1 }
+
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return newRows(s.query), nil
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+// rows are the rows a dry run query returns
+type rows struct {
+	columns []string
+	count   int
+	n       int // Rows returned so far
+}
+
+func newRows(query string) *rows {
+	return &rows{columns: resultColumns(query), count: resultRows(query)}
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.n == r.count {
+		return io.EOF
+	}
+	r.n++
+	for i, column := range r.columns {
+		dest[i] = columnValue(column, r.n)
+	}
+	return nil
+}
+
+var (
+	_ driver.Connector      = connector{}
+	_ driver.ExecerContext  = conn{}
+	_ driver.QueryerContext = conn{}
+	_ driver.ConnBeginTx    = conn{}
+	_ driver.Pinger         = conn{}
+)
@@ -0,0 +1,399 @@
+// Package gormsql extracts the SQL GORM generates for each repository
+// method in a DryRun session, which builds every statement without sending
+// it, and sets it next to the SQL the hand-written repositories send for
+// the same method. The result documents what GORM emits, feeds the SQL
+// equivalence checker of pkg/sqlcapture and is appended to the benchmark
+// report.
+//
+// DryRun leaves every result empty: a method that reads a row before it
+// writes, like GORM's UpdateUser, builds the write from a zero row or stops
+// before it, one checking the rows it affected fails after building its
+// statement, and Scan and Row fail or return nothing. Such methods are
+// reported with a note instead of being left out. Statements read through
+// Scan are logged by GORM with their arguments inlined. The session runs on
+// the driver of pkg/dryrun, so the transaction GORM opens around a write
+// does not reach a server either. The hand-written statements are recorded
+// on that driver without DryRun.
+package gormsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"go-database-comparison/pkg/dblog"
+	"go-database-comparison/pkg/dryrun"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/secret"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+// Method is the SQL of one repository method
+type Method struct {
+	Method      string   `json:"method"`
+	GORM        []string `json:"gorm"`                   // Built by GORM in DryRun, in order
+	HandWritten []string `json:"hand_written,omitempty"` // Sent by the hand-written repository, in order
+	By          string   `json:"by,omitempty"`           // Library whose hand-written statements these are, PQ or SQLX
+	Note        string   `json:"note,omitempty"`         // Why a side stopped early or is missing
+}
+
+// errNoCounterpart marks a GORM method no hand-written repository has
+var errNoCounterpart = errors.New("no hand-written counterpart")
+
+// repositories are the repositories a method is run on
+type repositories struct {
+	gorm *repository.GORMRepository
+	pq   *repository.PQRepository
+	sqlx *repository.SQLXRepository
+}
+
+// method runs one repository method with GORM and hand-written. A nil pq
+// falls back to sqlx, both nil means GORM has the method only.
+type method struct {
+	name string
+	gorm func(ctx context.Context, r *repository.GORMRepository) error
+	pq   func(ctx context.Context, r *repository.PQRepository) error
+	sqlx func(ctx context.Context, r *repository.SQLXRepository) error
+}
+
+// request is the user every creating method is called with
+var request = &models.CreateUserRequest{Name: "DryRun User", Email: "dryrun@example.com", Age: 30}
+
+// activities are the activities every appending method is called with,
+// their times bound every range read
+var activities = []*models.UserActivity{
+	{OccurredAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), UserID: 1, Action: "login"},
+	{OccurredAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), UserID: 1, Action: "logout", DurationMs: 120},
+}
+
+// methods lists every method of GORMRepository that sends statements
+var methods = []method{
+	{
+		name: "CreateUser",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.CreateUser(ctx, request)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.CreateUser(ctx, request)
+			return err
+		},
+	},
+	{
+		name: "GetUserByID",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetUserByID(ctx, 1)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.GetUserByID(ctx, 1)
+			return err
+		},
+	},
+	{
+		name: "GetAllUsers",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetAllUsers(ctx, 10, 0)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.GetAllUsers(ctx, 10, 0)
+			return err
+		},
+	},
+	{
+		name: "UpdateUser",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.UpdateUser(ctx, 1, update())
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.UpdateUser(ctx, 1, update())
+			return err
+		},
+	},
+	{
+		name: "DeleteUser",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error { return r.DeleteUser(ctx, 1) },
+		pq:   func(ctx context.Context, r *repository.PQRepository) error { return r.DeleteUser(ctx, 1) },
+	},
+	{
+		name: "GetUsersByEmail",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetUsersByEmail(ctx, "example.com")
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.GetUsersByEmail(ctx, "example.com")
+			return err
+		},
+	},
+	{
+		name: "CreateUserWithTransaction",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.CreateUserWithTransaction(ctx, request)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.CreateUserWithTransaction(ctx, request)
+			return err
+		},
+	},
+	{
+		name: "BatchCreateUsers",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.BatchCreateUsers(ctx, []*models.CreateUserRequest{request, request})
+			return err
+		},
+		sqlx: func(ctx context.Context, r *repository.SQLXRepository) error {
+			_, err := r.BatchCreateUsers(ctx, []*models.CreateUserRequest{request, request})
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.BatchCreateUsers(ctx, []*models.CreateUserRequest{request, request})
+			return err
+		},
+	},
+	{
+		name: "TouchUsersInOrder",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			return r.TouchUsersInOrder(ctx, []int{1, 2}, 0)
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			return r.TouchUsersInOrder(ctx, []int{1, 2}, 0)
+		},
+	},
+	{
+		name: "SetUserSecret",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			return r.SetUserSecret(ctx, 1, "secret")
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error { return r.SetUserSecret(ctx, 1, "secret") },
+	},
+	{
+		name: "GetUserSecret",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetUserSecret(ctx, 1)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.GetUserSecret(ctx, 1)
+			return err
+		},
+	},
+	{
+		name: "CreateUserWithDomain",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.CreateUserWithDomain(ctx, request)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.CreateUserWithDomain(ctx, request)
+			return err
+		},
+	},
+	{
+		name: "GetUsersByEmailDomain",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetUsersByEmailDomain(ctx, "example.com")
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.GetUsersByEmailDomain(ctx, "example.com")
+			return err
+		},
+	},
+	{
+		name: "GetUsersRankedByAgeInDomain",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetUsersRankedByAgeInDomain(ctx, "example.com")
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.GetUsersRankedByAgeInDomain(ctx, "example.com")
+			return err
+		},
+	},
+	{
+		name: "CountUsersByAgeBucket",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.CountUsersByAgeBucket(ctx)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.CountUsersByAgeBucket(ctx)
+			return err
+		},
+	},
+	{
+		name: "CreateUserActivities",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			return r.CreateUserActivities(ctx, activities)
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			return r.CreateUserActivities(ctx, activities)
+		},
+	},
+	{
+		name: "GetUserActivityInRange",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetUserActivityInRange(ctx, 1, activities[0].OccurredAt, activities[1].OccurredAt)
+			return err
+		},
+		pq: func(ctx context.Context, r *repository.PQRepository) error {
+			_, err := r.GetUserActivityInRange(ctx, 1, activities[0].OccurredAt, activities[1].OccurredAt)
+			return err
+		},
+	},
+	{
+		name: "UpdateUserSelective",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.UpdateUserSelective(ctx, 1, map[string]interface{}{"age": 31})
+			return err
+		},
+	},
+	{
+		name: "GetUserStats",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.GetUserStats(ctx)
+			return err
+		},
+	},
+	{
+		name: "FindUsersWithComplexQuery",
+		gorm: func(ctx context.Context, r *repository.GORMRepository) error {
+			_, err := r.FindUsersWithComplexQuery(ctx, 20, 40, "example.com")
+			return err
+		},
+	},
+}
+
+// update returns the update every updating method is called with
+func update() *models.UpdateUserRequest {
+	name := "DryRun User Updated"
+	return &models.UpdateUserRequest{Name: &name}
+}
+
+// dryRunLogger records the statements GORM builds. It drops the error GORM
+// logs for every Row and Rows call in DryRun, the method's note has it.
+type dryRunLogger struct {
+	*dblog.GORMLogger
+}
+
+// Error implements logger.Interface
+func (dryRunLogger) Error(context.Context, string, ...interface{}) {}
+
+// Extract runs every repository method with GORM in a DryRun session and
+// with the hand-written repository, and returns the statements of each
+func Extract(ctx context.Context) ([]Method, error) {
+	sink := sqlcapture.Sink()
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: dryrun.OpenDB(sink, "GORM")}), &gorm.Config{
+		DryRun: true,
+		Logger: dryRunLogger{dblog.NewGORMLogger(sink)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GORM in DryRun: %w", err)
+	}
+	sqlDB, _ := gormDB.DB()
+	defer sqlDB.Close()
+	pqDB := dryrun.OpenDB(sink, "PQ")
+	defer pqDB.Close()
+	sqlxDB := dryrun.OpenSQLX(sink)
+	defer sqlxDB.Close()
+
+	codec := secret.Plaintext{}
+	repos := repositories{
+		gorm: repository.NewGORMRepository(gormDB).WithCodec(codec),
+		pq:   repository.NewPQRepository(pqDB).WithCodec(codec),
+		sqlx: repository.NewSQLXRepository(sqlxDB).WithCodec(codec),
+	}
+
+	extracted := make([]Method, 0, len(methods))
+	for _, m := range methods {
+		extracted = append(extracted, m.extract(ctx, repos))
+	}
+	return extracted, nil
+}
+
+// extract runs m on repos, recording the statements of each side
+func (m method) extract(ctx context.Context, repos repositories) Method {
+	result := Method{Method: m.name}
+	var notes []string
+
+	statements, err := record(ctx, func(ctx context.Context) error { return m.gorm(ctx, repos.gorm) })
+	result.GORM = statements
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("GORM DryRun: %v", err))
+	}
+
+	switch {
+	case m.pq != nil:
+		result.By = "PQ"
+		result.HandWritten, err = record(ctx, func(ctx context.Context) error { return m.pq(ctx, repos.pq) })
+	case m.sqlx != nil:
+		result.By = "SQLX"
+		result.HandWritten, err = record(ctx, func(ctx context.Context) error { return m.sqlx(ctx, repos.sqlx) })
+	default:
+		err = errNoCounterpart
+	}
+	switch {
+	case errors.Is(err, errNoCounterpart):
+		notes = append(notes, err.Error())
+	case err != nil:
+		notes = append(notes, fmt.Sprintf("%s: %v", result.By, err))
+	}
+
+	result.Note = strings.Join(notes, "; ")
+	return result
+}
+
+// record runs call and returns the statements it issued, on one line each.
+// A panic is returned as an error: Row returns nil in DryRun, which the
+// methods scanning a single row do not expect.
+func record(ctx context.Context, call func(ctx context.Context) error) (statements []string, err error) {
+	ctx, recorder := sqlcapture.WithRecorder(ctx)
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panicked: %v", p)
+		}
+		statements = []string{}
+		for _, statement := range recorder.Statements() {
+			statements = append(statements, strings.Join(strings.Fields(statement), " "))
+		}
+	}()
+	return nil, call(ctx)
+}
+
+// Checker returns a checker holding the statements of methods, the
+// hand-written ones as "PQ" and GORM's as "GORM", so its Differences are
+// where GORM emits statements of other shapes. Methods GORM has only are
+// left out.
+func Checker(methods []Method) *sqlcapture.Checker {
+	checker := sqlcapture.NewChecker("PQ")
+	for _, m := range methods {
+		if m.By == "" {
+			continue
+		}
+		checker.Add(m.Method, "PQ", m.HandWritten)
+		checker.Add(m.Method, "GORM", m.GORM)
+	}
+	return checker
+}
+
+// Compared returns how many of methods have hand-written statements to
+// compare GORM's with
+func Compared(methods []Method) int {
+	n := 0
+	for _, m := range methods {
+		if m.By != "" {
+			n++
+		}
+	}
+	return n
+}
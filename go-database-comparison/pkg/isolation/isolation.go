@@ -0,0 +1,246 @@
+// Package isolation demonstrates PostgreSQL's transaction isolation levels
+// through the isolation APIs of lib/pq, sqlx and GORM. Each scenario opens a
+// reading transaction at a level, changes the data from a second
+// transaction in between two reads, and checks whether the anomaly the
+// scenario is about showed against what PostgreSQL guarantees at that
+// level.
+package isolation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/models"
+)
+
+// Anomalies, in the order they are checked
+const (
+	DirtyRead         = "dirty_read"
+	NonRepeatableRead = "non_repeatable_read"
+	PhantomRead       = "phantom_read"
+)
+
+// Anomalies lists the anomalies checked
+var Anomalies = []string{DirtyRead, NonRepeatableRead, PhantomRead}
+
+// Levels lists the isolation levels checked. PostgreSQL runs READ
+// UNCOMMITTED as READ COMMITTED, so it adds nothing.
+var Levels = []sql.IsolationLevel{sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable}
+
+// Session is a transaction opened through one library's API
+type Session interface {
+	// Level returns the isolation level PostgreSQL runs the transaction at
+	Level(ctx context.Context) (string, error)
+	Age(ctx context.Context, id int) (int, error)
+	SetAge(ctx context.Context, id, age int) error
+	CountByEmail(ctx context.Context, pattern string) (int, error)
+	Insert(ctx context.Context, req *models.CreateUserRequest) (int, error)
+	Commit() error
+	Rollback() error
+}
+
+// Library begins sessions through one library's isolation-level API
+type Library interface {
+	Name() string
+	Begin(ctx context.Context, level sql.IsolationLevel) (Session, error)
+}
+
+// Expected reports whether PostgreSQL allows anomaly at level. Dirty reads
+// never happen, non-repeatable and phantom reads only at READ COMMITTED:
+// PostgreSQL's REPEATABLE READ reads from one snapshot, stricter than the
+// SQL standard asks.
+func Expected(anomaly string, level sql.IsolationLevel) bool {
+	switch anomaly {
+	case NonRepeatableRead, PhantomRead:
+		return level == sql.LevelReadCommitted
+	default:
+		return false
+	}
+}
+
+// Check is the outcome of one anomaly at one level on one library
+type Check struct {
+	Library  string `json:"library"`
+	Anomaly  string `json:"anomaly"`
+	Level    string `json:"level"`    // Requested through the library's API
+	Actual   string `json:"actual"`   // As PostgreSQL reports it for the transaction
+	Expected bool   `json:"expected"` // Whether PostgreSQL allows the anomaly at Level
+	Observed bool   `json:"observed"`
+	Detail   string `json:"detail"` // What the two reads returned
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the check ran at the requested level and
+// observed what PostgreSQL guarantees
+func (c Check) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected && c.Actual == levelSetting(c.Level)
+}
+
+// Run checks every anomaly at every level on lib with users of the run in
+// ctx, creating them as it goes. A failing scenario is recorded in its
+// Check, an error is only returned when the scenario cannot be set up.
+func Run(ctx context.Context, lib Library) ([]Check, error) {
+	runID := benchdata.RunID(ctx)
+	library := strings.ToLower(lib.Name())
+	var seq int64
+	insert := func(session Session) (int, error) {
+		seq++
+		return session.Insert(ctx, &models.CreateUserRequest{
+			Name:  fmt.Sprintf("Isolation User %d", seq),
+			Email: benchdata.Email(runID, "isolation", library, seq),
+			Age:   30,
+		})
+	}
+
+	// The row the read scenarios read
+	var id int
+	if err := commit(ctx, lib, func(setup Session) (err error) {
+		id, err = insert(setup)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	pattern := "isolation-" + library + "-%@" + runID + "." + benchdata.Domain
+
+	var checks []Check
+	for _, anomaly := range Anomalies {
+		for _, level := range Levels {
+			check := Check{Library: lib.Name(), Anomaly: anomaly, Level: level.String(), Expected: Expected(anomaly, level)}
+			var err error
+			switch anomaly {
+			case DirtyRead:
+				err = dirtyRead(ctx, lib, level, id, &check)
+			case NonRepeatableRead:
+				err = nonRepeatableRead(ctx, lib, level, id, &check)
+			case PhantomRead:
+				err = phantomRead(ctx, lib, level, pattern, insert, &check)
+			}
+			if err != nil {
+				check.Error = err.Error()
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// dirtyRead reads a row while another transaction has changed it without
+// committing
+func dirtyRead(ctx context.Context, lib Library, level sql.IsolationLevel, id int, check *Check) error {
+	reader, err := begin(ctx, lib, level, check)
+	if err != nil {
+		return err
+	}
+	defer reader.Rollback()
+	before, err := reader.Age(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	writer, err := lib.Begin(ctx, sql.LevelReadCommitted)
+	if err != nil {
+		return err
+	}
+	defer writer.Rollback()
+	if err := writer.SetAge(ctx, id, before+1); err != nil {
+		return err
+	}
+
+	after, err := reader.Age(ctx, id)
+	if err != nil {
+		return err
+	}
+	check.Observed = after != before
+	check.Detail = fmt.Sprintf("age %d, then %d with %d uncommitted", before, after, before+1)
+	return nil
+}
+
+// nonRepeatableRead reads a row twice while another transaction changes
+// it and commits in between
+func nonRepeatableRead(ctx context.Context, lib Library, level sql.IsolationLevel, id int, check *Check) error {
+	reader, err := begin(ctx, lib, level, check)
+	if err != nil {
+		return err
+	}
+	defer reader.Rollback()
+	before, err := reader.Age(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := commit(ctx, lib, func(writer Session) error { return writer.SetAge(ctx, id, before+1) }); err != nil {
+		return err
+	}
+
+	after, err := reader.Age(ctx, id)
+	if err != nil {
+		return err
+	}
+	check.Observed = after != before
+	check.Detail = fmt.Sprintf("age %d, then %d after %d was committed", before, after, before+1)
+	return reader.Commit()
+}
+
+// phantomRead counts the rows matching a condition twice while another
+// transaction inserts a matching row and commits in between
+func phantomRead(ctx context.Context, lib Library, level sql.IsolationLevel, pattern string, insert func(Session) (int, error), check *Check) error {
+	reader, err := begin(ctx, lib, level, check)
+	if err != nil {
+		return err
+	}
+	defer reader.Rollback()
+	before, err := reader.CountByEmail(ctx, pattern)
+	if err != nil {
+		return err
+	}
+
+	if err := commit(ctx, lib, func(writer Session) error {
+		_, err := insert(writer)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	after, err := reader.CountByEmail(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	check.Observed = after != before
+	check.Detail = fmt.Sprintf("%d rows, then %d after an insert was committed", before, after)
+	return reader.Commit()
+}
+
+// begin opens the reading session of a check and records its actual level
+func begin(ctx context.Context, lib Library, level sql.IsolationLevel, check *Check) (Session, error) {
+	session, err := lib.Begin(ctx, level)
+	if err != nil {
+		return nil, err
+	}
+	if check.Actual, err = session.Level(ctx); err != nil {
+		session.Rollback()
+		return nil, err
+	}
+	return session, nil
+}
+
+// commit runs change in a READ COMMITTED session of its own and commits it
+func commit(ctx context.Context, lib Library, change func(Session) error) error {
+	writer, err := lib.Begin(ctx, sql.LevelReadCommitted)
+	if err != nil {
+		return err
+	}
+	if err := change(writer); err != nil {
+		writer.Rollback()
+		return err
+	}
+	return writer.Commit()
+}
+
+// levelSetting returns the transaction_isolation setting of a level name
+// as database/sql prints it, e.g. "Repeatable Read" -> "repeatable read"
+func levelSetting(level string) string {
+	return strings.ToLower(level)
+}
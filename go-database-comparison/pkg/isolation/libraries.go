@@ -0,0 +1,160 @@
+package isolation
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gorm.io/gorm"
+
+	"go-database-comparison/pkg/models"
+)
+
+// PQ begins sessions with database/sql's BeginTx and sql.TxOptions, the API
+// lib/pq implements
+func PQ(db *sql.DB) Library { return pqLibrary{db} }
+
+// SQLX begins sessions with sqlx's BeginTxx, which takes the same
+// sql.TxOptions and returns an *sqlx.Tx
+func SQLX(db *sqlx.DB) Library { return sqlxLibrary{db} }
+
+// GORM begins sessions with gorm.DB.Begin, which passes sql.TxOptions on
+// to the pool
+func GORM(db *gorm.DB) Library { return gormLibrary{db} }
+
+type pqLibrary struct{ db *sql.DB }
+
+func (pqLibrary) Name() string { return "PQ" }
+
+func (l pqLibrary) Begin(ctx context.Context, level sql.IsolationLevel) (Session, error) {
+	tx, err := l.db.BeginTx(ctx, &sql.TxOptions{Isolation: level})
+	if err != nil {
+		return nil, err
+	}
+	return pqSession{tx}, nil
+}
+
+type pqSession struct{ tx *sql.Tx }
+
+func (s pqSession) Level(ctx context.Context) (level string, err error) {
+	err = s.tx.QueryRowContext(ctx, "SHOW transaction_isolation").Scan(&level)
+	return level, err
+}
+
+func (s pqSession) Age(ctx context.Context, id int) (age int, err error) {
+	err = s.tx.QueryRowContext(ctx, "SELECT age FROM users WHERE id = $1", id).Scan(&age)
+	return age, err
+}
+
+func (s pqSession) SetAge(ctx context.Context, id, age int) error {
+	_, err := s.tx.ExecContext(ctx, "UPDATE users SET age = $1, updated_at = $2 WHERE id = $3", age, time.Now(), id)
+	return err
+}
+
+func (s pqSession) CountByEmail(ctx context.Context, pattern string) (count int, err error) {
+	err = s.tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email LIKE $1", pattern).Scan(&count)
+	return count, err
+}
+
+func (s pqSession) Insert(ctx context.Context, req *models.CreateUserRequest) (id int, err error) {
+	now := time.Now()
+	err = s.tx.QueryRowContext(ctx,
+		"INSERT INTO users (name, email, age, created_at, updated_at, is_active) VALUES ($1, $2, $3, $4, $5, true) RETURNING id",
+		req.Name, req.Email, req.Age, now, now,
+	).Scan(&id)
+	return id, err
+}
+
+func (s pqSession) Commit() error   { return s.tx.Commit() }
+func (s pqSession) Rollback() error { return s.tx.Rollback() }
+
+type sqlxLibrary struct{ db *sqlx.DB }
+
+func (sqlxLibrary) Name() string { return "SQLX" }
+
+func (l sqlxLibrary) Begin(ctx context.Context, level sql.IsolationLevel) (Session, error) {
+	tx, err := l.db.BeginTxx(ctx, &sql.TxOptions{Isolation: level})
+	if err != nil {
+		return nil, err
+	}
+	return sqlxSession{tx}, nil
+}
+
+type sqlxSession struct{ tx *sqlx.Tx }
+
+func (s sqlxSession) Level(ctx context.Context) (level string, err error) {
+	err = s.tx.GetContext(ctx, &level, "SHOW transaction_isolation")
+	return level, err
+}
+
+func (s sqlxSession) Age(ctx context.Context, id int) (age int, err error) {
+	err = s.tx.GetContext(ctx, &age, "SELECT age FROM users WHERE id = $1", id)
+	return age, err
+}
+
+func (s sqlxSession) SetAge(ctx context.Context, id, age int) error {
+	_, err := s.tx.NamedExecContext(ctx, "UPDATE users SET age = :age, updated_at = :updated_at WHERE id = :id",
+		map[string]interface{}{"age": age, "updated_at": time.Now(), "id": id})
+	return err
+}
+
+func (s sqlxSession) CountByEmail(ctx context.Context, pattern string) (count int, err error) {
+	err = s.tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM users WHERE email LIKE $1", pattern)
+	return count, err
+}
+
+func (s sqlxSession) Insert(ctx context.Context, req *models.CreateUserRequest) (id int, err error) {
+	now := time.Now()
+	err = s.tx.GetContext(ctx, &id,
+		"INSERT INTO users (name, email, age, created_at, updated_at, is_active) VALUES ($1, $2, $3, $4, $5, true) RETURNING id",
+		req.Name, req.Email, req.Age, now, now)
+	return id, err
+}
+
+func (s sqlxSession) Commit() error   { return s.tx.Commit() }
+func (s sqlxSession) Rollback() error { return s.tx.Rollback() }
+
+type gormLibrary struct{ db *gorm.DB }
+
+func (gormLibrary) Name() string { return "GORM" }
+
+func (l gormLibrary) Begin(ctx context.Context, level sql.IsolationLevel) (Session, error) {
+	tx := l.db.WithContext(ctx).Begin(&sql.TxOptions{Isolation: level})
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return gormSession{tx}, nil
+}
+
+type gormSession struct{ tx *gorm.DB }
+
+func (s gormSession) Level(ctx context.Context) (level string, err error) {
+	err = s.tx.WithContext(ctx).Raw("SHOW transaction_isolation").Scan(&level).Error
+	return level, err
+}
+
+func (s gormSession) Age(ctx context.Context, id int) (int, error) {
+	var user models.User
+	err := s.tx.WithContext(ctx).Select("age").First(&user, id).Error
+	return user.Age, err
+}
+
+func (s gormSession) SetAge(ctx context.Context, id, age int) error {
+	return s.tx.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("age", age).Error
+}
+
+func (s gormSession) CountByEmail(ctx context.Context, pattern string) (int, error) {
+	var count int64
+	err := s.tx.WithContext(ctx).Model(&models.User{}).Where("email LIKE ?", pattern).Count(&count).Error
+	return int(count), err
+}
+
+func (s gormSession) Insert(ctx context.Context, req *models.CreateUserRequest) (int, error) {
+	user := &models.User{Name: req.Name, Email: req.Email, Age: req.Age, IsActive: true}
+	err := s.tx.WithContext(ctx).Create(user).Error
+	return user.ID, err
+}
+
+func (s gormSession) Commit() error   { return s.tx.Commit().Error }
+func (s gormSession) Rollback() error { return s.tx.Rollback().Error }
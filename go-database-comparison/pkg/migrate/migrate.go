@@ -0,0 +1,240 @@
+// Package migrate creates the benchmark schema from SQL migrations embedded
+// in the binary. Applied versions are recorded in the schema_migrations
+// table; every migration runs in its own transaction and runs are
+// serialized with an advisory lock, so concurrent invocations are safe.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// lockID identifies the advisory lock serializing migration runs
+const lockID = 7_201_203
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Up      string `json:"-"` // SQL applying the change
+	Down    string `json:"-"` // SQL reverting it
+}
+
+// Status is a migration together with whether and when it was applied
+type Status struct {
+	Migration
+	Applied   bool      `json:"applied"`
+	AppliedAt time.Time `json:"applied_at"` // Zero when not applied
+}
+
+// Load returns the embedded migrations ordered by version. Files are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+func Load() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		base := path.Base(file)
+		stem, direction, ok := cutDirection(base)
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected a .up.sql or .down.sql suffix", base)
+		}
+		versionText, name, ok := strings.Cut(stem, "_")
+		version, err := strconv.Atoi(versionText)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", base)
+		}
+
+		content, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// cutDirection splits "0001_users.up.sql" into "0001_users" and "up"
+func cutDirection(file string) (stem, direction string, ok bool) {
+	for _, direction := range []string{"up", "down"} {
+		if stem, ok := strings.CutSuffix(file, "."+direction+".sql"); ok {
+			return stem, direction, true
+		}
+	}
+	return "", "", false
+}
+
+// Statuses returns every embedded migration with its applied state
+func Statuses(ctx context.Context, db *sql.DB) ([]Status, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, m := range migrations {
+		appliedAt, ok := applied[m.Version]
+		statuses[i] = Status{Migration: m, Applied: ok, AppliedAt: appliedAt}
+	}
+	return statuses, nil
+}
+
+// Up applies the pending migrations up to and including version to, or all
+// of them when to is 0, and returns the ones it applied
+func Up(ctx context.Context, db *sql.DB, to int) ([]Migration, error) {
+	var done []Migration
+	err := withLock(ctx, db, func(conn *sql.Conn) error {
+		statuses, err := Statuses(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.Applied || (to > 0 && s.Version > to) {
+				continue
+			}
+			err := apply(ctx, conn, s.Up,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", s.Version, s.Name)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s up failed: %w", s.Version, s.Name, err)
+			}
+			done = append(done, s.Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and
+// returns the ones it reverted
+func Down(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	var done []Migration
+	err := withLock(ctx, db, func(conn *sql.Conn) error {
+		statuses, err := Statuses(ctx, db)
+		if err != nil {
+			return err
+		}
+		for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+			s := statuses[i]
+			if !s.Applied {
+				continue
+			}
+			err := apply(ctx, conn, s.Down,
+				"DELETE FROM schema_migrations WHERE version = $1", s.Version)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s down failed: %w", s.Version, s.Name, err)
+			}
+			done = append(done, s.Migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// apply runs script and records the change with the record statement in
+// one transaction
+func apply(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// withLock runs fn on a connection holding the migration advisory lock
+func withLock(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+
+	return fn(conn)
+}
+
+// ensureTable creates the schema_migrations table if needed
+func ensureTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions returns when each applied version was applied
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int]time.Time, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,4 @@
+DROP VIEW IF EXISTS user_order_summary;
+DROP FUNCTION IF EXISTS create_order_with_items(INTEGER, JSONB);
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
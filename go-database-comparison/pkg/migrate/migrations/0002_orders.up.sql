@@ -0,0 +1,75 @@
+-- Orders table for relationship and transaction testing
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    total_amount DECIMAL(10,2) NOT NULL CHECK (total_amount >= 0),
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'shipped', 'delivered', 'cancelled')),
+    order_date TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    shipped_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT
+);
+
+-- Order items for complex queries and joins
+CREATE TABLE IF NOT EXISTS order_items (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(8,2) NOT NULL CHECK (unit_price >= 0),
+    total_price DECIMAL(10,2) GENERATED ALWAYS AS (quantity * unit_price) STORED
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
+CREATE INDEX IF NOT EXISTS idx_orders_order_date ON orders(order_date);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
+
+-- Stored procedure for transaction testing
+CREATE OR REPLACE FUNCTION create_order_with_items(
+    p_user_id INTEGER,
+    p_items JSONB
+) RETURNS INTEGER AS $$
+DECLARE
+    v_order_id INTEGER;
+    v_item JSONB;
+    v_total_amount DECIMAL(10,2) := 0;
+BEGIN
+    -- Create order
+    INSERT INTO orders (user_id, total_amount, status)
+    VALUES (p_user_id, 0, 'pending')
+    RETURNING id INTO v_order_id;
+
+    -- Add items and calculate total
+    FOR v_item IN SELECT * FROM jsonb_array_elements(p_items)
+    LOOP
+        INSERT INTO order_items (order_id, product_name, quantity, unit_price)
+        VALUES (
+            v_order_id,
+            v_item->>'product_name',
+            (v_item->>'quantity')::INTEGER,
+            (v_item->>'unit_price')::DECIMAL
+        );
+
+        v_total_amount := v_total_amount +
+            ((v_item->>'quantity')::INTEGER * (v_item->>'unit_price')::DECIMAL);
+    END LOOP;
+
+    -- Update order total
+    UPDATE orders SET total_amount = v_total_amount WHERE id = v_order_id;
+
+    RETURN v_order_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- View for complex query testing
+CREATE OR REPLACE VIEW user_order_summary AS
+SELECT
+    u.id,
+    u.name,
+    u.email,
+    COUNT(o.id) as total_orders,
+    COALESCE(SUM(o.total_amount), 0) as total_spent,
+    MAX(o.order_date) as last_order_date
+FROM users u
+LEFT JOIN orders o ON u.id = o.user_id
+GROUP BY u.id, u.name, u.email;
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS generate_performance_data(INTEGER);
+DROP TABLE IF EXISTS performance_test;
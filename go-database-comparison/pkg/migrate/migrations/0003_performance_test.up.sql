@@ -0,0 +1,32 @@
+-- Performance test table for bulk operations
+CREATE TABLE IF NOT EXISTS performance_test (
+    id SERIAL PRIMARY KEY,
+    data_field VARCHAR(500),
+    numeric_field INTEGER,
+    timestamp_field TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    json_field JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_performance_test_numeric ON performance_test(numeric_field);
+CREATE INDEX IF NOT EXISTS idx_performance_test_json ON performance_test USING GIN(json_field);
+
+-- Function to generate test data for performance benchmarks
+CREATE OR REPLACE FUNCTION generate_performance_data(p_count INTEGER) RETURNS VOID AS $$
+DECLARE
+    i INTEGER;
+BEGIN
+    FOR i IN 1..p_count LOOP
+        INSERT INTO performance_test (data_field, numeric_field, json_field)
+        VALUES (
+            'Test data ' || i || ' - ' || md5(random()::text),
+            (random() * 1000000)::INTEGER,
+            jsonb_build_object(
+                'id', i,
+                'random_value', random(),
+                'timestamp', now(),
+                'metadata', jsonb_build_object('batch', 'performance_test', 'iteration', i)
+            )
+        );
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
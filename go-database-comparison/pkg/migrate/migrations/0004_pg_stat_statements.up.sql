@@ -0,0 +1,3 @@
+-- pg_stat_statements for performance monitoring; the server must also load
+-- it through shared_preload_libraries, as docker-compose.yml does
+CREATE EXTENSION IF NOT EXISTS pg_stat_statements;
@@ -0,0 +1,27 @@
+-- Function announcing a change of a user on the user_changes channel for
+-- LISTEN, see pkg/notify. The payload stays far below the 8000 byte limit
+-- of NOTIFY; listeners read the row itself when they need more.
+--
+-- The trigger calling it is not created here: every write would pay for
+-- building and queueing the notification, skewing the write benchmarks.
+-- notify.Install attaches it for as long as a listener needs it.
+CREATE OR REPLACE FUNCTION notify_user_change() RETURNS TRIGGER AS $$
+DECLARE
+    v_user users;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_user := OLD;
+    ELSE
+        v_user := NEW;
+    END IF;
+
+    PERFORM pg_notify('user_changes', json_build_object(
+        'op', TG_OP,
+        'id', v_user.id,
+        'email', v_user.email,
+        'is_active', v_user.is_active,
+        'changed_at', clock_timestamp()
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,20 @@
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+-- The role is only dropped once no other database grants it anything
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_roles WHERE rolname = 'dbcompare_tenant') THEN
+        REVOKE ALL ON users FROM dbcompare_tenant;
+        REVOKE ALL ON SEQUENCE users_id_seq FROM dbcompare_tenant;
+        BEGIN
+            DROP ROLE dbcompare_tenant;
+        EXCEPTION WHEN dependent_objects_still_exist THEN
+            RAISE NOTICE 'role dbcompare_tenant is still used elsewhere, keeping it';
+        END;
+    END IF;
+END;
+$$;
+
+DROP INDEX IF EXISTS idx_users_tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,31 @@
+-- Multi-tenant users with row-level security, see pkg/tenant. Every user
+-- belongs to the tenant in the app.tenant_id setting of the session that
+-- created it, and the dbcompare_tenant role only reads and writes the users
+-- of the tenant in its app.tenant_id. The table owner bypasses the policy,
+-- so the benchmarks, which connect as the owner, see no change.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id INTEGER
+    DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::integer;
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users(tenant_id);
+
+-- Roles are shared by all databases of the server
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'dbcompare_tenant') THEN
+        CREATE ROLE dbcompare_tenant NOLOGIN;
+    END IF;
+END;
+$$;
+
+-- Sessions switch to the role with SET ROLE, which needs the membership
+GRANT dbcompare_tenant TO CURRENT_USER;
+GRANT SELECT, INSERT, UPDATE, DELETE ON users TO dbcompare_tenant;
+GRANT USAGE ON SEQUENCE users_id_seq TO dbcompare_tenant;
+
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+
+-- Without a tenant the setting is NULL and the role sees no users at all
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+CREATE POLICY users_tenant_isolation ON users TO dbcompare_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer);
@@ -0,0 +1,3 @@
+-- Encrypted secret of a user, sealed and opened by the application with the
+-- keys of pkg/secret; the server only ever sees the ciphertext
+ALTER TABLE users ADD COLUMN IF NOT EXISTS secret BYTEA;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_email_domain;
+ALTER TABLE users DROP COLUMN IF EXISTS email_domain;
@@ -0,0 +1,6 @@
+-- Domain of the email, which PostgreSQL computes whenever email is written.
+-- Clients read it but cannot write it, see models.UserWithDomain.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_domain TEXT
+    GENERATED ALWAYS AS (lower(split_part(email, '@', 2))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_domain ON users(email_domain);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS partitioned.users;
+DROP SCHEMA IF EXISTS partitioned;
@@ -0,0 +1,27 @@
+-- Variant of the users table hash partitioned on id, in a schema of its
+-- own so the libraries reach it unchanged: connections with search_path
+-- partitioned,public resolve "users" to it, see database.DatabaseConfig.
+-- Migration 0011 adds the columns, indexes and policy of later migrations.
+-- The email index is not unique, PostgreSQL cannot enforce uniqueness
+-- across partitions on a column outside the partition key.
+CREATE SCHEMA IF NOT EXISTS partitioned;
+
+CREATE TABLE IF NOT EXISTS partitioned.users (
+    id SERIAL,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    age INTEGER CHECK (age >= 0 AND age <= 150),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    is_active BOOLEAN DEFAULT true,
+    PRIMARY KEY (id)
+) PARTITION BY HASH (id);
+
+CREATE TABLE IF NOT EXISTS partitioned.users_p0 PARTITION OF partitioned.users FOR VALUES WITH (MODULUS 4, REMAINDER 0);
+CREATE TABLE IF NOT EXISTS partitioned.users_p1 PARTITION OF partitioned.users FOR VALUES WITH (MODULUS 4, REMAINDER 1);
+CREATE TABLE IF NOT EXISTS partitioned.users_p2 PARTITION OF partitioned.users FOR VALUES WITH (MODULUS 4, REMAINDER 2);
+CREATE TABLE IF NOT EXISTS partitioned.users_p3 PARTITION OF partitioned.users FOR VALUES WITH (MODULUS 4, REMAINDER 3);
+
+-- Indexes on the parent are created on every partition
+CREATE INDEX IF NOT EXISTS idx_users_email ON partitioned.users(email);
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON partitioned.users(created_at);
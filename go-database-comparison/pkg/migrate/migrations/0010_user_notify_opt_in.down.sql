@@ -0,0 +1,3 @@
+-- Nothing to restore, migration 0005 no longer creates the trigger. A
+-- trigger left behind by notify.Install is removed all the same.
+DROP TRIGGER IF EXISTS users_notify_change ON users;
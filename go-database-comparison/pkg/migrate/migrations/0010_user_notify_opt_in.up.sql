@@ -0,0 +1,4 @@
+-- Earlier versions of migration 0005 attached the notification trigger to
+-- users for good, making every benchmarked write pay for a NOTIFY. It is
+-- now installed on demand by notify.Install, remove the permanent one.
+DROP TRIGGER IF EXISTS users_notify_change ON users;
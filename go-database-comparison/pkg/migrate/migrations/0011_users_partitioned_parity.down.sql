@@ -0,0 +1,12 @@
+DROP POLICY IF EXISTS users_tenant_isolation ON partitioned.users;
+ALTER TABLE partitioned.users DISABLE ROW LEVEL SECURITY;
+
+REVOKE ALL ON partitioned.users FROM dbcompare_tenant;
+REVOKE ALL ON SEQUENCE partitioned.users_id_seq FROM dbcompare_tenant;
+REVOKE ALL ON SCHEMA partitioned FROM dbcompare_tenant;
+
+DROP INDEX IF EXISTS partitioned.idx_users_email_domain;
+DROP INDEX IF EXISTS partitioned.idx_users_tenant_id;
+ALTER TABLE partitioned.users DROP COLUMN IF EXISTS email_domain;
+ALTER TABLE partitioned.users DROP COLUMN IF EXISTS secret;
+ALTER TABLE partitioned.users DROP COLUMN IF EXISTS tenant_id;
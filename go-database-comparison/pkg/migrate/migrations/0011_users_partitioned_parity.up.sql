@@ -0,0 +1,25 @@
+-- Give the partitioned users table of migration 0009 what migrations 0006
+-- to 0008 added to public.users, so partitioning is the only difference
+-- the benchmark measures. One difference remains: email cannot be unique,
+-- PostgreSQL only enforces uniqueness across partitions on columns that
+-- include the partition key, see partition.Differences.
+ALTER TABLE partitioned.users ADD COLUMN IF NOT EXISTS tenant_id INTEGER
+    DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::integer;
+ALTER TABLE partitioned.users ADD COLUMN IF NOT EXISTS secret BYTEA;
+ALTER TABLE partitioned.users ADD COLUMN IF NOT EXISTS email_domain TEXT
+    GENERATED ALWAYS AS (lower(split_part(email, '@', 2))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON partitioned.users(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_users_email_domain ON partitioned.users(email_domain);
+
+-- The same tenant isolation as migration 0006, the role exists since then
+GRANT USAGE ON SCHEMA partitioned TO dbcompare_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON partitioned.users TO dbcompare_tenant;
+GRANT USAGE ON SEQUENCE partitioned.users_id_seq TO dbcompare_tenant;
+
+ALTER TABLE partitioned.users ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS users_tenant_isolation ON partitioned.users;
+CREATE POLICY users_tenant_isolation ON partitioned.users TO dbcompare_tenant
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer);
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserActivity is one entry of the user_activity log, a time series the
+// timescale command creates as a plain table and as a TimescaleDB
+// hypertable, see pkg/timeseries. It has no primary key: a hypertable's
+// unique indexes have to include the time column, and a log needs none.
+type UserActivity struct {
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at" gorm:"not null"`
+	UserID     int       `json:"user_id" db:"user_id" gorm:"not null"`
+	Action     string    `json:"action" db:"action" gorm:"type:varchar(32);not null"`
+	DurationMs int       `json:"duration_ms" db:"duration_ms"`
+}
+
+// TableName returns the table name for GORM
+func (UserActivity) TableName() string {
+	return "user_activity"
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// HooksSetting is the GORM setting that enables the hooks of User, set by
+// database.ConnectWithGORM when DatabaseConfig.GORMHooks is. Without it the
+// hooks return at once: GORM still finds and calls them, which is the part
+// of their cost a run without hooks keeps. gorm.Session.SkipHooks is not
+// used to turn them off since it also stops GORM from setting updated_at.
+const HooksSetting = "dbcompare:hooks"
+
+// hooksEnabled reports whether tx runs with HooksSetting
+func hooksEnabled(tx *gorm.DB) bool {
+	enabled, _ := tx.Get(HooksSetting)
+	return enabled == true
+}
+
+// BeforeCreate checks a user GORM is about to insert against the rules of
+// CreateUserRequest, so an invalid one fails before any statement is sent
+// instead of on the table's constraints
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if !hooksEnabled(tx) {
+		return nil
+	}
+	switch {
+	case u.Name == "" || utf8.RuneCountInString(u.Name) > 100:
+		return fmt.Errorf("invalid user: name must have 1 to 100 characters, got %d", utf8.RuneCountInString(u.Name))
+	case !strings.Contains(u.Email, "@"):
+		return fmt.Errorf("invalid user: email %q has no @", u.Email)
+	case u.Age < 0 || u.Age > 150:
+		return fmt.Errorf("invalid user: age must be between 0 and 150, got %d", u.Age)
+	}
+	return nil
+}
+
+// AfterUpdate fails the update of a loaded user that matched no row, e.g.
+// because another session deleted it since it was read, and so rolls back
+// GORM's transaction around the update. Updates through a zero User, which
+// select their rows with Where, are left alone.
+func (u *User) AfterUpdate(tx *gorm.DB) error {
+	if !hooksEnabled(tx) || u.ID == 0 {
+		return nil
+	}
+	// tx shares the statement of the update, whose DB holds its result
+	if tx.Statement.DB.RowsAffected == 0 {
+		return fmt.Errorf("user with ID %d was not updated", u.ID)
+	}
+	return nil
+}
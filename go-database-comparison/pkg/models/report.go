@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AgeBucket aggregates the active users whose age falls into one ten-year
+// bucket, as CountUsersByAgeBucket reports them
+type AgeBucket struct {
+	MinAge         int       `json:"min_age" db:"min_age"` // Inclusive, a multiple of ten
+	MaxAge         int       `json:"max_age" db:"max_age"` // Inclusive, MinAge + 9
+	Users          int64     `json:"users" db:"users"`
+	AvgAge         float64   `json:"avg_age" db:"avg_age"`
+	FirstCreatedAt time.Time `json:"first_created_at" db:"first_created_at"`
+	LastCreatedAt  time.Time `json:"last_created_at" db:"last_created_at"`
+}
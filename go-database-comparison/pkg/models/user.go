@@ -33,4 +33,26 @@ type UpdateUserRequest struct {
 // TableName returns the table name for GORM
 func (User) TableName() string {
 	return "users"
-}
\ No newline at end of file
+}
+
+// UserWithDomain is a user with the domain of its email, which PostgreSQL
+// generates into the email_domain column (migration 0008). The column
+// cannot be written: GORM leaves it out of INSERT and UPDATE through the
+// read-only permission "->", and default:(-) makes it read the generated
+// value back with RETURNING.
+type UserWithDomain struct {
+	User
+	EmailDomain string `json:"email_domain" db:"email_domain" gorm:"->;default:(-)"`
+}
+
+// RankedUser is an active user ranked by age among the active users of its
+// email domain, with window aggregates over the domain. Users of the same
+// age share a rank.
+type RankedUser struct {
+	UserWithDomain
+	AgeRank       int     `json:"age_rank" db:"age_rank"`             // RANK() by age, oldest first
+	AgeDenseRank  int     `json:"age_dense_rank" db:"age_dense_rank"` // DENSE_RANK(), without gaps after ties
+	AgePercentile float64 `json:"age_percentile" db:"age_percentile"` // PERCENT_RANK(), 0 for the oldest
+	DomainUsers   int     `json:"domain_users" db:"domain_users"`     // Active users of the domain
+	DomainAvgAge  float64 `json:"domain_avg_age" db:"domain_avg_age"` // Average age in the domain
+}
@@ -0,0 +1,252 @@
+// Package netem is a TCP proxy emulating a slow or lossy network between
+// the benchmark and PostgreSQL, so the libraries' differences in round
+// trips (extra queries, prepare/execute pairs) show in the timings the way
+// they would across a real network rather than over loopback.
+//
+// Latency delays every chunk of data by half the configured round-trip
+// time in each direction, without limiting throughput: chunks queue up and
+// each is delivered once its delay has passed. TCP retransmits what a
+// network drops, so loss is emulated by what it costs a connection: a
+// "lost" chunk arrives a retransmission timeout late, and as TCP delivers
+// in order, so does everything behind it.
+package netem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetransmitTimeout is Linux's minimum TCP retransmission timeout
+const DefaultRetransmitTimeout = 200 * time.Millisecond
+
+// Config configures a Proxy
+type Config struct {
+	Target            string        // host:port connections are forwarded to
+	Latency           time.Duration // Added round-trip time, split evenly between both directions
+	Loss              float64       // Percent of chunks delayed as if lost and retransmitted
+	RetransmitTimeout time.Duration // Delay of a lost chunk, DefaultRetransmitTimeout when 0
+}
+
+// Validate checks the config for values the proxy cannot emulate
+func (c Config) Validate() error {
+	if c.Target == "" {
+		return errors.New("netem: no target address")
+	}
+	if c.Latency < 0 {
+		return fmt.Errorf("netem: latency must not be negative, got %v", c.Latency)
+	}
+	if c.Loss < 0 || c.Loss >= 100 {
+		return fmt.Errorf("netem: loss must be from 0 to below 100 percent, got %v", c.Loss)
+	}
+	if c.RetransmitTimeout < 0 {
+		return fmt.Errorf("netem: retransmit timeout must not be negative, got %v", c.RetransmitTimeout)
+	}
+	return nil
+}
+
+// Stats counts what a proxy forwarded
+type Stats struct {
+	Connections int64 `json:"connections"`
+	Chunks      int64 `json:"chunks"`
+	Lost        int64 `json:"lost"` // Chunks delayed by emulated loss
+	Bytes       int64 `json:"bytes"`
+}
+
+// Proxy forwards TCP connections to a target through an emulated network
+type Proxy struct {
+	config   Config
+	listener net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+
+	connections, chunks, lost, bytes atomic.Int64
+}
+
+// Listen starts a proxy accepting connections on addr, e.g. "127.0.0.1:0"
+// for a free loopback port. Close stops it.
+func Listen(addr string, config Config) (*Proxy, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.RetransmitTimeout == 0 {
+		config.RetransmitTimeout = DefaultRetransmitTimeout
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("netem: listen on %s: %w", addr, err)
+	}
+
+	p := &Proxy{config: config, listener: listener, conns: make(map[net.Conn]struct{})}
+	p.wg.Add(1)
+	go p.accept()
+	return p, nil
+}
+
+// Addr returns the address the proxy listens on
+func (p *Proxy) Addr() net.Addr {
+	return p.listener.Addr()
+}
+
+// Stats returns what the proxy forwarded so far
+func (p *Proxy) Stats() Stats {
+	return Stats{
+		Connections: p.connections.Load(),
+		Chunks:      p.chunks.Load(),
+		Lost:        p.lost.Load(),
+		Bytes:       p.bytes.Load(),
+	}
+}
+
+// Close stops accepting, closes every proxied connection and waits for
+// them to wind down
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	for conn := range p.conns {
+		conn.Close()
+	}
+	p.mu.Unlock()
+
+	err := p.listener.Close()
+	p.wg.Wait()
+	return err
+}
+
+func (p *Proxy) accept() {
+	defer p.wg.Done()
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		p.wg.Add(1)
+		go p.handle(client)
+	}
+}
+
+// track registers conn to be closed by Close, or reports false when the
+// proxy is already closed
+func (p *Proxy) track(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.conns[conn] = struct{}{}
+	return true
+}
+
+func (p *Proxy) untrack(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.conns, conn)
+}
+
+// handle proxies one client connection until either side closes it
+func (p *Proxy) handle(client net.Conn) {
+	defer p.wg.Done()
+	defer client.Close()
+	if !p.track(client) {
+		return
+	}
+	defer p.untrack(client)
+
+	server, err := net.DialTimeout("tcp", p.config.Target, 10*time.Second)
+	if err != nil {
+		// The client sees a connection closed before the server greeted it
+		return
+	}
+	defer server.Close()
+	if !p.track(server) {
+		return
+	}
+	defer p.untrack(server)
+	p.connections.Add(1)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.pipe(server, client)
+	}()
+	go func() {
+		defer wg.Done()
+		p.pipe(client, server)
+	}()
+	wg.Wait()
+}
+
+// chunk is data read from one side waiting to be delivered to the other
+type chunk struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+// pipe copies src to dst through the emulated network. When src ends, dst
+// gets the queued data and then its write side closed; when writing to dst
+// fails, both connections are closed so the other direction ends too.
+func (p *Proxy) pipe(dst, src net.Conn) {
+	queue := make(chan chunk, 1024)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for c := range queue {
+			if wait := time.Until(c.deliverAt); wait > 0 {
+				time.Sleep(wait)
+			}
+			if _, err := dst.Write(c.data); err != nil {
+				dst.Close()
+				src.Close()
+				// Keep draining so the reader never blocks on a full queue
+				for range queue {
+				}
+				return
+			}
+		}
+	}()
+
+	oneWay := p.config.Latency / 2
+	var last time.Time
+	var readErr error
+	buf := make([]byte, 32*1024)
+	for readErr == nil {
+		var n int
+		n, readErr = src.Read(buf)
+		if n == 0 {
+			continue
+		}
+		deliverAt := time.Now().Add(oneWay)
+		if p.config.Loss > 0 && rand.Float64()*100 < p.config.Loss {
+			deliverAt = deliverAt.Add(p.config.RetransmitTimeout)
+			p.lost.Add(1)
+		}
+		// In order, as TCP delivers it: nothing overtakes a late chunk
+		if deliverAt.Before(last) {
+			deliverAt = last
+		}
+		last = deliverAt
+		p.chunks.Add(1)
+		p.bytes.Add(int64(n))
+		queue <- chunk{data: append([]byte(nil), buf[:n]...), deliverAt: deliverAt}
+	}
+	close(queue)
+	<-done
+
+	// A clean end is passed on as one, anything else tears both sides down
+	tcp, ok := dst.(*net.TCPConn)
+	if readErr == io.EOF && ok {
+		tcp.CloseWrite()
+		return
+	}
+	dst.Close()
+	src.Close()
+}
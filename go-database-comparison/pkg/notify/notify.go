@@ -0,0 +1,234 @@
+// Package notify streams the changes of the users table as PostgreSQL
+// announces them with NOTIFY. Install attaches a trigger to the users table
+// sending every inserted, updated or deleted user on Channel once its
+// transaction commits; Listen subscribes to it with lib/pq's Listener, which
+// holds a connection of its own and reconnects when it is lost.
+//
+// The trigger is not part of the migrations since it slows down every write
+// to users, benchmarks included. Callers install it only while they listen
+// and remove it with Uninstall afterwards.
+//
+// Notifications are not queued for a listener that is disconnected, so
+// changes made while the connection is down are missed. Listen reports a
+// reconnect as a Change with Op set to OpReconnect, after which listeners
+// that need every change have to reread the table.
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-database-comparison/pkg/database"
+)
+
+// Channel is the channel the users trigger notifies
+const Channel = "user_changes"
+
+// Operations of a Change, as TG_OP names them
+const (
+	OpInsert = "INSERT"
+	OpUpdate = "UPDATE"
+	OpDelete = "DELETE"
+
+	// OpReconnect marks the connection being restored, changes made while
+	// it was lost were missed
+	OpReconnect = "RECONNECT"
+)
+
+const (
+	minReconnect = 100 * time.Millisecond
+	maxReconnect = 10 * time.Second
+
+	// pingInterval is how often an idle listener checks its connection,
+	// which otherwise goes unnoticed until the next notification is due
+	pingInterval = 90 * time.Second
+)
+
+// Trigger is the name of the trigger Install attaches to the users table
+const Trigger = "users_notify_change"
+
+// Install attaches the trigger notifying Channel to the users table. It
+// reports false if the trigger was already there, in which case someone else
+// installed it and the caller should leave it in place. The trigger function
+// comes from migration 0005.
+func Install(ctx context.Context, db *sql.DB) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Serializes concurrent installs, which would otherwise both create it
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return false, fmt.Errorf("failed to lock users: %w", err)
+	}
+
+	var exists, hasFunction bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			EXISTS (SELECT FROM pg_trigger WHERE tgname = $1 AND tgrelid = 'users'::regclass),
+			to_regproc('notify_user_change') IS NOT NULL`, Trigger).Scan(&exists, &hasFunction)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the %s trigger: %w", Trigger, err)
+	}
+	if exists {
+		return false, nil
+	}
+	if !hasFunction {
+		return false, fmt.Errorf("function notify_user_change is missing, run dbcompare migrate up to apply migration 0005")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		CREATE TRIGGER `+Trigger+`
+			AFTER INSERT OR UPDATE OR DELETE ON users
+			FOR EACH ROW EXECUTE FUNCTION notify_user_change()`)
+	if err != nil {
+		return false, fmt.Errorf("failed to create the %s trigger: %w", Trigger, err)
+	}
+	return true, tx.Commit()
+}
+
+// Uninstall removes the trigger Install attached, if it is there
+func Uninstall(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+Trigger+" ON users"); err != nil {
+		return fmt.Errorf("failed to drop the %s trigger: %w", Trigger, err)
+	}
+	return nil
+}
+
+// Change is one change of a user
+type Change struct {
+	Op        string    `json:"op"`
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	IsActive  bool      `json:"is_active"`
+	ChangedAt time.Time `json:"changed_at"`  // Server clock when the trigger fired
+	Received  time.Time `json:"received_at"` // Local clock when the notification arrived
+	PID       int       `json:"pid"`         // Backend process that made the change
+}
+
+// Listener receives the changes of the users table
+type Listener struct {
+	listener *pq.Listener
+	changes  chan Change
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// Listen connects to the database of config and subscribes to Channel.
+// Changes are delivered on Changes until ctx is done or Close is called.
+// Malformed payloads are logged and skipped.
+func Listen(ctx context.Context, config *database.DatabaseConfig, logger *slog.Logger) (*Listener, error) {
+	first := make(chan error, 1) // Outcome of the first connection attempt
+	events := make(chan pq.ListenerEventType, 1)
+	listener := pq.NewListener(config.PostgreSQLDSN(), minReconnect, maxReconnect, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventConnected:
+			first <- nil
+		case pq.ListenerEventConnectionAttemptFailed:
+			select {
+			case first <- err:
+			default:
+				logger.Debug("notification listener failed to reconnect", "error", err)
+			}
+		case pq.ListenerEventDisconnected:
+			logger.Warn("notification listener disconnected, reconnecting", "error", err)
+		case pq.ListenerEventReconnected:
+			select {
+			case events <- event:
+			default:
+			}
+		}
+	})
+	// NewListener connects in the background and Listen would wait for it
+	// forever, fail on the first attempt instead
+	select {
+	case err := <-first:
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("notification listener failed to connect: %w", err)
+		}
+	case <-ctx.Done():
+		listener.Close()
+		return nil, ctx.Err()
+	}
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", Channel, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	l := &Listener{listener: listener, changes: make(chan Change, 64), cancel: cancel, done: make(chan struct{})}
+	go l.run(ctx, events, logger)
+	return l, nil
+}
+
+// Changes returns the channel changes are delivered on. It is closed once
+// the listener stops.
+func (l *Listener) Changes() <-chan Change {
+	return l.changes
+}
+
+// Close stops listening and closes the connection
+func (l *Listener) Close() error {
+	l.cancel()
+	<-l.done
+	return l.listener.Close()
+}
+
+func (l *Listener) run(ctx context.Context, events <-chan pq.ListenerEventType, logger *slog.Logger) {
+	defer close(l.done)
+	defer close(l.changes)
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		var change Change
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			// A failing ping makes the listener reconnect
+			go l.listener.Ping()
+			continue
+		case <-events:
+			change = Change{Op: OpReconnect, Received: time.Now()}
+		case n := <-l.listener.Notify:
+			if n == nil {
+				// Sent after a reconnect, the event above reports it
+				continue
+			}
+			var err error
+			if change, err = parse(n); err != nil {
+				logger.Warn("skipping malformed notification", "channel", n.Channel, "payload", n.Extra, "error", err)
+				continue
+			}
+		}
+
+		select {
+		case l.changes <- change:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// parse decodes the payload the users trigger sends
+func parse(n *pq.Notification) (Change, error) {
+	var change Change
+	if err := json.Unmarshal([]byte(n.Extra), &change); err != nil {
+		return Change{}, err
+	}
+	if change.Op == "" || change.ID == 0 {
+		return Change{}, fmt.Errorf("payload lacks op or id")
+	}
+	change.Received = time.Now()
+	change.PID = n.BePid
+	return change, nil
+}
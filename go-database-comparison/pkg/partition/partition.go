@@ -0,0 +1,227 @@
+// Package partition inspects the hash partitioned variant of the users
+// table that migration 0009 creates in Schema. Connections whose search
+// path starts with Schema reach it through the libraries' usual statements,
+// so the benchmark runs on it unchanged; this package reports where the
+// server routed the rows those statements wrote and how many partitions
+// their reads scanned.
+//
+// PostgreSQL prunes partitions while planning when the plan is built for
+// the parameter values, and only when executing a generic plan, built once
+// for a prepared statement. Which one a library gets depends on whether it
+// prepares named statements, as pgx under GORM does, or sends each
+// statement unnamed, as lib/pq does.
+package partition
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-database-comparison/pkg/benchdata"
+)
+
+// Schema holds the partitioned users table
+const Schema = "partitioned"
+
+// SearchPath makes unqualified names resolve to the partitioned users
+// table, and to public for the other tables
+const SearchPath = Schema + ",public"
+
+// Table is the qualified name of the partitioned users table
+const Table = Schema + ".users"
+
+// Differences is how the partitioned users table differs from public.users
+// besides being partitioned, for the report. Migration 0011 mirrors every
+// other column, index and policy.
+const Differences = "email is not unique, PostgreSQL cannot enforce uniqueness across partitions of id"
+
+// Available reports whether migration 0009 created the partitioned table
+func Available(ctx context.Context, db *sql.DB) (bool, error) {
+	var partitioned bool
+	err := db.QueryRowContext(ctx,
+		"SELECT coalesce((SELECT relkind = 'p' FROM pg_class WHERE oid = to_regclass($1)), false)", Table).Scan(&partitioned)
+	return partitioned, err
+}
+
+// Partitions returns the names of the partitions of the table
+func Partitions(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass($1)
+		ORDER BY c.relname`, Table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, name)
+	}
+	return partitions, rows.Err()
+}
+
+// Route counts the users a library wrote that the server routed to one
+// partition
+type Route struct {
+	Library   string `json:"library"`
+	Partition string `json:"partition"`
+	Rows      int64  `json:"rows"`
+}
+
+// Routing returns where the users run runID created ended up, by library
+// and partition. The library is read from the email, see benchdata.Email.
+func Routing(ctx context.Context, db *sql.DB, runID string) ([]Route, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT upper(split_part(email, '-', 2)), c.relname, count(*)
+		FROM `+Table+` u JOIN pg_class c ON c.oid = u.tableoid
+		WHERE email LIKE $1
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, "%@"+runID+"."+benchdata.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rows per partition: %w", err)
+	}
+	defer rows.Close()
+
+	var routes []Route
+	for rows.Next() {
+		var r Route
+		if err := rows.Scan(&r.Library, &r.Partition, &r.Rows); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	return routes, rows.Err()
+}
+
+// SessionPlans returns how the session of db planned query so far: whether
+// it holds query as a named prepared statement and how many generic and
+// custom plans it built for it. db must be limited to one connection, the
+// one that ran query, since prepared statements belong to a session.
+func SessionPlans(ctx context.Context, db *sql.DB, query string) (prepared bool, generic, custom int64, err error) {
+	err = db.QueryRowContext(ctx, `
+		SELECT count(*) > 0, coalesce(sum(generic_plans), 0), coalesce(sum(custom_plans), 0)
+		FROM pg_prepared_statements WHERE statement = $1`, query).Scan(&prepared, &generic, &custom)
+	return prepared, generic, custom, err
+}
+
+// Plan is where the partitions of an executed plan were pruned
+type Plan struct {
+	Generic           bool     `json:"generic"`             // Built once for any parameter values
+	Scanned           []string `json:"scanned"`             // Partitions the plan scanned
+	PrunedAtPlanning  int      `json:"pruned_at_planning"`  // Partitions left out of the plan
+	PrunedAtExecution int      `json:"pruned_at_execution"` // Partitions in the plan skipped for the parameter values
+}
+
+// Explain executes query with args on conn, as a generic or a custom plan,
+// and returns which of partitions it scanned and pruned. The arguments
+// are inlined as literals, which limits them to strings, integers,
+// booleans and times.
+func Explain(ctx context.Context, conn *sql.Conn, query string, args []interface{}, generic bool, partitions []string) (Plan, error) {
+	literals := make([]string, len(args))
+	for i, arg := range args {
+		literal, err := literalOf(arg)
+		if err != nil {
+			return Plan{}, fmt.Errorf("argument $%d: %w", i+1, err)
+		}
+		literals[i] = literal
+	}
+
+	mode := "force_custom_plan"
+	if generic {
+		mode = "force_generic_plan"
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('plan_cache_mode', $1, false)", mode); err != nil {
+		return Plan{}, fmt.Errorf("failed to set plan_cache_mode: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "RESET plan_cache_mode")
+
+	if _, err := conn.ExecContext(ctx, "PREPARE partition_probe AS "+query); err != nil {
+		return Plan{}, fmt.Errorf("failed to prepare the statement: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "DEALLOCATE partition_probe")
+
+	execute := "EXECUTE partition_probe"
+	if len(literals) > 0 {
+		execute += "(" + strings.Join(literals, ", ") + ")"
+	}
+	var document []byte
+	if err := conn.QueryRowContext(ctx, "EXPLAIN (ANALYZE, COSTS OFF, TIMING OFF, SUMMARY OFF, FORMAT JSON) "+execute).Scan(&document); err != nil {
+		return Plan{}, fmt.Errorf("failed to explain the statement: %w", err)
+	}
+
+	plan, err := parsePlan(document, partitions)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan.Generic = generic
+	plan.PrunedAtPlanning = len(partitions) - len(plan.Scanned) - plan.PrunedAtExecution
+	return plan, nil
+}
+
+// planNode is the part of an EXPLAIN (FORMAT JSON) node pruning shows in
+type planNode struct {
+	Relation        string     `json:"Relation Name"`
+	SubplansRemoved int        `json:"Subplans Removed"`
+	Plans           []planNode `json:"Plans"`
+}
+
+// parsePlan collects which of partitions an EXPLAIN (FORMAT JSON) document
+// scans and the subplans pruned when executing it
+func parsePlan(document []byte, partitions []string) (Plan, error) {
+	var explained []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(document, &explained); err != nil || len(explained) != 1 {
+		return Plan{}, fmt.Errorf("unexpected EXPLAIN output: %s", document)
+	}
+
+	var plan Plan
+	seen := make(map[string]bool)
+	var walk func(node planNode)
+	walk = func(node planNode) {
+		if slices.Contains(partitions, node.Relation) && !seen[node.Relation] {
+			seen[node.Relation] = true
+			plan.Scanned = append(plan.Scanned, node.Relation)
+		}
+		plan.PrunedAtExecution += node.SubplansRemoved
+		for _, child := range node.Plans {
+			walk(child)
+		}
+	}
+	walk(explained[0].Plan)
+	return plan, nil
+}
+
+// literalOf renders a statement argument as an SQL literal
+func literalOf(arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return pq.QuoteLiteral(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case time.Time:
+		return pq.QuoteLiteral(v.Format(time.RFC3339Nano)), nil
+	default:
+		return "", fmt.Errorf("cannot inline %T", arg)
+	}
+}
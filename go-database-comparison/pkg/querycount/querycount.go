@@ -0,0 +1,89 @@
+// Package querycount counts the SQL statements executed on behalf of a
+// context, so the number of round trips behind a repository method can be
+// reported and asserted on.
+package querycount
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go-database-comparison/pkg/sqlnorm"
+)
+
+type contextKey struct{}
+
+// Counter accumulates the statements executed with a context returned by
+// WithCounter. It is safe for concurrent use.
+type Counter struct {
+	n      atomic.Int64
+	parent *Counter
+
+	mu      sync.Mutex
+	queries map[string]int64 // Executions by statement as executed
+}
+
+// WithCounter returns a copy of ctx that counts statements into a new
+// Counter. Counters nest: statements are also added to any counter
+// already attached to ctx.
+func WithCounter(ctx context.Context) (context.Context, *Counter) {
+	parent, _ := ctx.Value(contextKey{}).(*Counter)
+	c := &Counter{parent: parent}
+	return context.WithValue(ctx, contextKey{}, c), c
+}
+
+// Count returns the number of statements counted so far
+func (c *Counter) Count() int64 {
+	return c.n.Load()
+}
+
+// Statements returns how often each statement was executed, by its
+// normalized text (see sqlnorm.Normalize)
+func (c *Counter) Statements() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	statements := make(map[string]int64, len(c.queries))
+	for query, n := range c.queries {
+		statements[sqlnorm.Normalize(query)] += n
+	}
+	return statements
+}
+
+// Inc records one execution of query on every counter attached to ctx.
+// It does nothing when ctx carries no counter. Queries are normalized
+// when read, keeping the cost of counting low.
+func Inc(ctx context.Context, query string) {
+	c, _ := ctx.Value(contextKey{}).(*Counter)
+	for ; c != nil; c = c.parent {
+		c.n.Add(1)
+		c.mu.Lock()
+		if c.queries == nil {
+			c.queries = make(map[string]int64)
+		}
+		c.queries[query]++
+		c.mu.Unlock()
+	}
+}
+
+// Expect runs fn with a counting context and reports an error when fn
+// fails or executes a number of statements other than want. The error
+// lists the normalized statements that were executed.
+func Expect(ctx context.Context, want int64, fn func(ctx context.Context) error) error {
+	ctx, counter := WithCounter(ctx)
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if got := counter.Count(); got != want {
+		var executed []string
+		for statement, n := range counter.Statements() {
+			executed = append(executed, fmt.Sprintf("%dx %s", n, statement))
+		}
+		sort.Strings(executed)
+		return fmt.Errorf("executed %d statements, want %d: %s", got, want, strings.Join(executed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package replay records the repository calls of a run into a workload
+// file and re-executes that workload on any library, so libraries can be
+// compared on an identical stream of operations and parameters rather than
+// on independently generated ones.
+//
+// A workload file is JSON lines: a Header, then one Op per call in the
+// order the calls started.
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// Format identifies the workload file format in its header
+const Format = "dbcompare-workload/1"
+
+// Operation names, as in the benchmark results
+const (
+	OpCreate = "create"
+	OpRead   = "read"
+	OpList   = "list"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpSearch = "search"
+)
+
+// Header is the first line of a workload file
+type Header struct {
+	Format     string    `json:"format"`
+	RunID      string    `json:"run_id"` // Run the workload was recorded in, see pkg/benchdata
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Op is one recorded repository call
+type Op struct {
+	Seq       int64                     `json:"seq"`        // Order the calls started in
+	Elapsed   time.Duration             `json:"elapsed_ns"` // Since recording started, when the call started
+	Library   string                    `json:"library"`    // Library the call was recorded on
+	Operation string                    `json:"operation"`
+	ID        int                       `json:"id,omitempty"`
+	Create    *models.CreateUserRequest `json:"create,omitempty"`
+	Update    *models.UpdateUserRequest `json:"update,omitempty"`
+	Email     string                    `json:"email,omitempty"` // Search pattern
+	Limit     int                       `json:"limit,omitempty"`
+	Offset    int                       `json:"offset,omitempty"`
+	ResultID  int                       `json:"result_id,omitempty"` // ID of the created user
+	Error     string                    `json:"error,omitempty"`     // Error the call returned
+}
+
+// Workload is a loaded workload file
+type Workload struct {
+	Header Header
+	Ops    []Op // By Seq
+}
+
+// Recorder writes the calls of the repositories it wraps to a workload file.
+// It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	encoder *json.Encoder
+	start   time.Time
+	seq     int64
+	count   int
+	err     error
+}
+
+// NewRecorder writes the header of a workload recorded in run runID to w
+// and returns a recorder appending to it. Call Flush when done.
+func NewRecorder(w io.Writer, runID string) (*Recorder, error) {
+	buffered := bufio.NewWriter(w)
+	r := &Recorder{w: buffered, encoder: json.NewEncoder(buffered), start: time.Now()}
+	header := Header{Format: Format, RunID: runID, RecordedAt: r.start.UTC()}
+	if err := r.encoder.Encode(header); err != nil {
+		return nil, fmt.Errorf("failed to write workload header: %w", err)
+	}
+	return r, nil
+}
+
+// Wrap returns repo recording its calls as calls of library. The calls a
+// workload has no operation for pass through unrecorded.
+func (r *Recorder) Wrap(library string, repo repository.UserRepository) repository.UserRepository {
+	return &recordingRepository{UserRepository: repo, recorder: r, library: library}
+}
+
+// Count returns the number of calls recorded so far
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Flush writes buffered calls out and returns the first write error
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	return r.err
+}
+
+// begin starts recording a call of operation on library
+func (r *Recorder) begin(library, operation string) Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	return Op{Seq: r.seq, Elapsed: time.Since(r.start), Library: library, Operation: operation}
+}
+
+// end records op once its call returned err. Ops are written as calls
+// end, so the file holds concurrent calls out of order; Load sorts them.
+func (r *Recorder) end(op Op, err error) {
+	if err != nil {
+		op.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if r.err = r.encoder.Encode(op); r.err == nil {
+		r.count++
+	}
+}
+
+// recordingRepository records the calls of the repository it embeds
+type recordingRepository struct {
+	repository.UserRepository
+	recorder *Recorder
+	library  string
+}
+
+func (rr *recordingRepository) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	op := rr.recorder.begin(rr.library, OpCreate)
+	if req != nil {
+		copied := *req
+		op.Create = &copied
+	}
+	user, err := rr.UserRepository.CreateUser(ctx, req)
+	if user != nil {
+		op.ResultID = user.ID
+	}
+	rr.recorder.end(op, err)
+	return user, err
+}
+
+func (rr *recordingRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	op := rr.recorder.begin(rr.library, OpRead)
+	op.ID = id
+	user, err := rr.UserRepository.GetUserByID(ctx, id)
+	rr.recorder.end(op, err)
+	return user, err
+}
+
+func (rr *recordingRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	op := rr.recorder.begin(rr.library, OpList)
+	op.Limit, op.Offset = limit, offset
+	users, err := rr.UserRepository.GetAllUsers(ctx, limit, offset)
+	rr.recorder.end(op, err)
+	return users, err
+}
+
+func (rr *recordingRepository) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	op := rr.recorder.begin(rr.library, OpUpdate)
+	op.ID = id
+	if req != nil {
+		copied := *req
+		op.Update = &copied
+	}
+	user, err := rr.UserRepository.UpdateUser(ctx, id, req)
+	rr.recorder.end(op, err)
+	return user, err
+}
+
+func (rr *recordingRepository) DeleteUser(ctx context.Context, id int) error {
+	op := rr.recorder.begin(rr.library, OpDelete)
+	op.ID = id
+	err := rr.UserRepository.DeleteUser(ctx, id)
+	rr.recorder.end(op, err)
+	return err
+}
+
+func (rr *recordingRepository) GetUsersByEmail(ctx context.Context, emailPattern string) ([]*models.User, error) {
+	op := rr.recorder.begin(rr.library, OpSearch)
+	op.Email = emailPattern
+	users, err := rr.UserRepository.GetUsersByEmail(ctx, emailPattern)
+	rr.recorder.end(op, err)
+	return users, err
+}
+
+// Load reads the workload file at path
+func Load(path string) (Workload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Workload{}, fmt.Errorf("failed to open workload: %w", err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	var workload Workload
+	if err := decoder.Decode(&workload.Header); err != nil {
+		return Workload{}, fmt.Errorf("failed to read workload header of %s: %w", path, err)
+	}
+	if workload.Header.Format != Format {
+		return Workload{}, fmt.Errorf("%s is not a workload file (format %q, expected %q)", path, workload.Header.Format, Format)
+	}
+	for {
+		var op Op
+		if err := decoder.Decode(&op); err == io.EOF {
+			break
+		} else if err != nil {
+			return Workload{}, fmt.Errorf("failed to read operation %d of %s: %w", len(workload.Ops)+1, path, err)
+		}
+		workload.Ops = append(workload.Ops, op)
+	}
+	sort.Slice(workload.Ops, func(i, j int) bool { return workload.Ops[i].Seq < workload.Ops[j].Seq })
+	return workload, nil
+}
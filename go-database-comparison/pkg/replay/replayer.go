@@ -0,0 +1,186 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// Options configures a replay
+type Options struct {
+	Library string // Library replayed on, makes its emails unique
+	RunID   string // Run the replay's users belong to, see pkg/benchdata
+	Pace    bool   // Keep the recorded gaps between calls instead of replaying back to back
+}
+
+// OperationStats summarizes the replayed calls of one operation
+type OperationStats struct {
+	Operation string        `json:"operation"`
+	Count     int           `json:"count"`
+	Errors    int           `json:"errors"`
+	Diverged  int           `json:"diverged"` // Calls that failed where the recording succeeded or the other way round
+	Total     time.Duration `json:"total_time"`
+	Avg       time.Duration `json:"avg_time"`
+	P95       time.Duration `json:"p95_time"`
+}
+
+// Report is the outcome of replaying a workload on one library
+type Report struct {
+	Library    string           `json:"library"`
+	RunID      string           `json:"run_id"`
+	Ops        int              `json:"ops"`
+	Duration   time.Duration    `json:"duration"`
+	Operations []OperationStats `json:"operations"`
+}
+
+// Replay executes the calls of workload on repo one after another in the
+// order they started when recorded. The IDs of users the workload created
+// are mapped to the IDs their re-creation got, and emails are moved to the
+// replay's run so replays on several libraries don't collide.
+func Replay(ctx context.Context, repo repository.UserRepository, workload Workload, options Options) (Report, error) {
+	report := Report{Library: options.Library, RunID: options.RunID}
+	ids := make(map[int]int)
+	durations := make(map[string][]time.Duration)
+	stats := make(map[string]*OperationStats)
+	rewriter := newEmailRewriter(workload.Header.RunID, options)
+
+	start := time.Now()
+	for _, op := range workload.Ops {
+		if options.Pace {
+			if wait := op.Elapsed - time.Since(start); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return report, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		id := op.ID
+		if mapped, ok := ids[id]; ok {
+			id = mapped
+		}
+
+		callStart := time.Now()
+		var err error
+		switch op.Operation {
+		case OpCreate:
+			var user *models.User
+			if user, err = repo.CreateUser(ctx, rewriter.create(op.Create)); err == nil && user != nil && op.ResultID != 0 {
+				ids[op.ResultID] = user.ID
+			}
+		case OpRead:
+			_, err = repo.GetUserByID(ctx, id)
+		case OpList:
+			_, err = repo.GetAllUsers(ctx, op.Limit, op.Offset)
+		case OpUpdate:
+			_, err = repo.UpdateUser(ctx, id, rewriter.update(op.Update))
+		case OpDelete:
+			err = repo.DeleteUser(ctx, id)
+		case OpSearch:
+			_, err = repo.GetUsersByEmail(ctx, rewriter.pattern(op.Email))
+		default:
+			return report, fmt.Errorf("operation %d: unknown operation %q", op.Seq, op.Operation)
+		}
+		elapsed := time.Since(callStart)
+
+		s, ok := stats[op.Operation]
+		if !ok {
+			s = &OperationStats{Operation: op.Operation}
+			stats[op.Operation] = s
+		}
+		s.Count++
+		s.Total += elapsed
+		if err != nil {
+			s.Errors++
+		}
+		if (err != nil) != (op.Error != "") {
+			s.Diverged++
+		}
+		durations[op.Operation] = append(durations[op.Operation], elapsed)
+		report.Ops++
+	}
+	report.Duration = time.Since(start)
+
+	for operation, s := range stats {
+		sorted := durations[operation]
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		s.Avg = s.Total / time.Duration(s.Count)
+		s.P95 = sorted[(len(sorted)*95+99)/100-1]
+		report.Operations = append(report.Operations, *s)
+	}
+	sort.Slice(report.Operations, func(i, j int) bool {
+		return report.Operations[i].Operation < report.Operations[j].Operation
+	})
+	return report, nil
+}
+
+// emailRewriter moves the emails of a workload from the run it was
+// recorded in to the run replaying it on a library
+type emailRewriter struct {
+	fromRun, toRun string // Recorded and replaying run IDs
+	from, to       string // Email domains of the recorded and the replaying run
+	prefix         string // Added to local parts, unique per library
+}
+
+func newEmailRewriter(recordedRunID string, options Options) emailRewriter {
+	return emailRewriter{
+		fromRun: recordedRunID,
+		toRun:   options.RunID,
+		from:    "@" + recordedRunID + "." + benchdata.Domain,
+		to:      "@" + options.RunID + "." + benchdata.Domain,
+		prefix:  "replay-" + strings.ToLower(options.Library) + "-",
+	}
+}
+
+// email returns the replay's version of a recorded email
+func (r emailRewriter) email(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return r.prefix + email
+	}
+	domain = "@" + domain
+	if domain == r.from {
+		domain = r.to
+	}
+	return r.prefix + local + domain
+}
+
+// pattern returns the replay's version of a search pattern, which only
+// has its run moved since a substring of an email may lack the local part
+func (r emailRewriter) pattern(pattern string) string {
+	if r.fromRun == "" {
+		return pattern
+	}
+	return strings.ReplaceAll(pattern, r.fromRun, r.toRun)
+}
+
+func (r emailRewriter) create(req *models.CreateUserRequest) *models.CreateUserRequest {
+	if req == nil {
+		return &models.CreateUserRequest{}
+	}
+	copied := *req
+	copied.Email = r.email(copied.Email)
+	return &copied
+}
+
+func (r emailRewriter) update(req *models.UpdateUserRequest) *models.UpdateUserRequest {
+	if req == nil {
+		return &models.UpdateUserRequest{}
+	}
+	copied := *req
+	if copied.Email != nil {
+		email := r.email(*copied.Email)
+		copied.Email = &email
+	}
+	return &copied
+}
@@ -0,0 +1,112 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/models"
+)
+
+// batchSize is the number of users each batch creates
+const batchSize = 5
+
+// TestBatchCreateUsers checks that every library's BatchCreateUsers returns
+// the users as persisted: in the order requested, each with its own ID
+// that GetUserByID finds with the same fields
+func TestBatchCreateUsers(t *testing.T) {
+	config := dbtest.Config(t)
+
+	for _, lib := range dbtest.Libraries(t, config) {
+		t.Run(lib.Name, func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+			requests := make([]*models.CreateUserRequest, batchSize)
+			for i := range requests {
+				requests[i] = &models.CreateUserRequest{
+					Name:  fmt.Sprintf("Batch User %d", i+1),
+					Email: benchdata.Email(benchdata.RunID(ctx), "batch", lib.Name, int64(i+1)),
+					Age:   20 + i,
+				}
+			}
+
+			users, err := lib.Repo.BatchCreateUsers(ctx, requests)
+			if err != nil {
+				t.Fatalf("BatchCreateUsers: %v", err)
+			}
+			if len(users) != len(requests) {
+				t.Fatalf("returned %d users for %d requests", len(users), len(requests))
+			}
+
+			ids := map[int]bool{}
+			for i, user := range users {
+				req := requests[i]
+				if user.ID == 0 {
+					t.Fatalf("user %d returned without an ID", i+1)
+				}
+				if ids[user.ID] {
+					t.Fatalf("ID %d returned twice", user.ID)
+				}
+				ids[user.ID] = true
+				if user.Name != req.Name || user.Email != req.Email || user.Age != req.Age || !user.IsActive {
+					t.Fatalf("user %d returned as %+v, want %+v", i+1, *user, *req)
+				}
+
+				stored, err := lib.Repo.GetUserByID(ctx, user.ID)
+				if err != nil {
+					t.Fatalf("GetUserByID(%d): %v", user.ID, err)
+				}
+				if stored.Name != user.Name || stored.Email != user.Email || stored.Age != user.Age ||
+					!sameInstant(stored.CreatedAt, user.CreatedAt) || !sameInstant(stored.UpdatedAt, user.UpdatedAt) {
+					t.Fatalf("user %d stored as %+v, returned as %+v", user.ID, *stored, *user)
+				}
+			}
+		})
+	}
+}
+
+// sameInstant reports whether a stored timestamp is the one returned,
+// which GORM keeps at the nanoseconds PostgreSQL rounds to microseconds
+func sameInstant(stored, returned time.Time) bool {
+	d := stored.Sub(returned)
+	return !returned.IsZero() && d < time.Microsecond && d > -time.Microsecond
+}
+
+// TestBatchCreateUsersAtomic checks that a batch with a duplicate email
+// fails as a whole, and that an empty batch creates nothing
+func TestBatchCreateUsersAtomic(t *testing.T) {
+	config := dbtest.Config(t)
+
+	for _, lib := range dbtest.Libraries(t, config) {
+		t.Run(lib.Name, func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+
+			users, err := lib.Repo.BatchCreateUsers(ctx, nil)
+			if err != nil {
+				t.Fatalf("BatchCreateUsers of no users: %v", err)
+			}
+			if len(users) != 0 {
+				t.Fatalf("BatchCreateUsers of no users returned %d", len(users))
+			}
+
+			first := benchdata.Email(benchdata.RunID(ctx), "batch", lib.Name, 1)
+			requests := []*models.CreateUserRequest{
+				{Name: "First", Email: first, Age: 30},
+				{Name: "Second", Email: benchdata.Email(benchdata.RunID(ctx), "batch", lib.Name, 2), Age: 30},
+				{Name: "Duplicate", Email: first, Age: 30},
+			}
+			if _, err := lib.Repo.BatchCreateUsers(ctx, requests); err == nil {
+				t.Fatal("a batch with a duplicate email succeeded")
+			}
+
+			found, err := lib.Repo.GetUsersByEmail(ctx, benchdata.RunID(ctx))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != 0 {
+				t.Fatalf("%d users of the failed batch were kept", len(found))
+			}
+		})
+	}
+}
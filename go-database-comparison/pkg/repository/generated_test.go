@@ -0,0 +1,248 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// generatedAlwaysSQLState is PostgreSQL's generated_always, raised for a
+// value written to a generated column
+const generatedAlwaysSQLState = "428C9"
+
+// domainRepository is a UserRepository reading the generated email_domain
+type domainRepository interface {
+	repository.UserRepository
+	CreateUserWithDomain(ctx context.Context, req *models.CreateUserRequest) (*models.UserWithDomain, error)
+	GetUsersByEmailDomain(ctx context.Context, domain string) ([]*models.UserWithDomain, error)
+}
+
+// generatedTarget is one library's repository together with writes that
+// name email_domain the way that library would. The writes run in a
+// transaction of their own, so a rejected one leaves the rolled back
+// transaction of the test usable.
+type generatedTarget struct {
+	repo         domainRepository
+	insertNaming func(ctx context.Context, req *models.CreateUserRequest, domain string) error
+	updateNaming func(ctx context.Context, id int, domain string) error
+	// updateOutcome is what updateNaming is expected to do: raw SQL is
+	// rejected, GORM's read-only permission drops the field
+	updateOutcome string
+}
+
+// gormWritableDomain maps email_domain without UserWithDomain's read-only
+// permission, as a model written without knowing the column is generated
+type gormWritableDomain struct {
+	models.User
+	EmailDomain string
+}
+
+// inTx runs exec in a transaction of db, committed when it succeeds
+func inTx(ctx context.Context, db *sql.DB, exec func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := exec(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// openGeneratedTarget connects the named library, closing it when t ends
+func openGeneratedTarget(t *testing.T, name string, config *database.DatabaseConfig) *generatedTarget {
+	t.Helper()
+	ctx := context.Background()
+	switch name {
+	case "PQ":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return &generatedTarget{
+			repo: repository.NewPQRepository(db),
+			insertNaming: func(ctx context.Context, req *models.CreateUserRequest, domain string) error {
+				return inTx(ctx, db, func(tx *sql.Tx) error {
+					_, err := tx.ExecContext(ctx, "INSERT INTO users (name, email, age, email_domain) VALUES ($1, $2, $3, $4)",
+						req.Name, req.Email, req.Age, domain)
+					return err
+				})
+			},
+			updateNaming: func(ctx context.Context, id int, domain string) error {
+				return inTx(ctx, db, func(tx *sql.Tx) error {
+					_, err := tx.ExecContext(ctx, "UPDATE users SET email_domain = $1 WHERE id = $2", domain, id)
+					return err
+				})
+			},
+			updateOutcome: "rejected",
+		}
+	case "SQLX":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		// namedExec binds the struct as sqlx does, in a transaction
+		namedExec := func(ctx context.Context, query string, arg interface{}) error {
+			tx, err := db.BeginTxx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.NamedExecContext(ctx, query, arg); err != nil {
+				tx.Rollback()
+				return err
+			}
+			return tx.Commit()
+		}
+		return &generatedTarget{
+			repo: repository.NewSQLXRepository(db),
+			insertNaming: func(ctx context.Context, req *models.CreateUserRequest, domain string) error {
+				user := models.UserWithDomain{User: models.User{Name: req.Name, Email: req.Email, Age: req.Age, IsActive: true}, EmailDomain: domain}
+				return namedExec(ctx, `INSERT INTO users (name, email, age, is_active, email_domain)
+					VALUES (:name, :email, :age, :is_active, :email_domain)`, user)
+			},
+			updateNaming: func(ctx context.Context, id int, domain string) error {
+				user := models.UserWithDomain{User: models.User{ID: id}, EmailDomain: domain}
+				return namedExec(ctx, "UPDATE users SET email_domain = :email_domain WHERE id = :id", user)
+			},
+			updateOutcome: "rejected",
+		}
+	case "GORM":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+		// GORM runs Create and Updates in a transaction of their own
+		return &generatedTarget{
+			repo: repository.NewGORMRepository(db),
+			insertNaming: func(ctx context.Context, req *models.CreateUserRequest, domain string) error {
+				user := &gormWritableDomain{User: models.User{Name: req.Name, Email: req.Email, Age: req.Age, IsActive: true}, EmailDomain: domain}
+				return db.WithContext(ctx).Table("users").Create(user).Error
+			},
+			updateNaming: func(ctx context.Context, id int, domain string) error {
+				return db.WithContext(ctx).
+					Model(&models.UserWithDomain{User: models.User{ID: id}}).
+					Updates(models.UserWithDomain{EmailDomain: domain}).Error
+			},
+			updateOutcome: "ignored",
+		}
+	}
+	t.Fatalf("unknown library %q", name)
+	return nil
+}
+
+// requireEmailDomain skips t unless migration 0008 added the generated
+// email_domain column to the users table of db
+func requireEmailDomain(t testing.TB, db *sql.DB) {
+	t.Helper()
+	var generated string
+	err := db.QueryRow(`
+		SELECT coalesce(max(is_generated), '') FROM information_schema.columns
+		WHERE table_name = 'users' AND column_name = 'email_domain'`).Scan(&generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if generated != "ALWAYS" {
+		t.Skip("users has no generated email_domain, run dbcompare migrate up to apply migration 0008")
+	}
+}
+
+// TestGeneratedColumn checks that every library reads email_domain, the
+// column migration 0008 generates from email, without ever writing it:
+// created users come back with their domain, are found through it, and
+// get it recomputed when their email changes. Naming the column in an
+// INSERT is rejected for every library; naming it in an UPDATE is
+// rejected with raw SQL while GORM's read-only permission drops it.
+func TestGeneratedColumn(t *testing.T) {
+	config := dbtest.Config(t)
+	requireEmailDomain(t, dbtest.Observer(t, config))
+
+	for _, name := range dbtest.Names {
+		t.Run(name, func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+			target := openGeneratedTarget(t, name, config)
+			runID := benchdata.RunID(ctx)
+			email := benchdata.Email(runID, "generated", name, 1)
+			_, domain, _ := strings.Cut(email, "@")
+			domain = strings.ToLower(domain)
+
+			user, err := target.repo.CreateUserWithDomain(ctx, &models.CreateUserRequest{Name: "Generated " + name, Email: email, Age: 30})
+			if err != nil {
+				t.Fatalf("CreateUserWithDomain: %v", err)
+			}
+			if user.EmailDomain != domain {
+				t.Errorf("created user has domain %q, want %q", user.EmailDomain, domain)
+			}
+
+			// domainOf reads the user's domain through the domain it should have
+			domainOf := func(domain string) string {
+				t.Helper()
+				users, err := target.repo.GetUsersByEmailDomain(ctx, domain)
+				if err != nil {
+					t.Fatalf("GetUsersByEmailDomain(%q): %v", domain, err)
+				}
+				for _, u := range users {
+					if u.ID == user.ID {
+						return u.EmailDomain
+					}
+				}
+				return "(not found)"
+			}
+			// outcome tells the error PostgreSQL raises for a generated
+			// column from other failures
+			outcome := func(err error) string {
+				t.Helper()
+				switch {
+				case err == nil:
+					return "written"
+				case benchmark.SQLState(err) == generatedAlwaysSQLState:
+					return "rejected"
+				}
+				t.Fatalf("unexpected error: %v", err)
+				return ""
+			}
+
+			if got := domainOf(domain); got != domain {
+				t.Errorf("read domain %q, want %q", got, domain)
+			}
+
+			local, _, _ := strings.Cut(email, "@")
+			moved := "moved." + domain
+			movedEmail := local + "@" + moved
+			if _, err := target.repo.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Email: &movedEmail}); err != nil {
+				t.Fatalf("UpdateUser: %v", err)
+			}
+			if got := domainOf(moved); got != moved {
+				t.Errorf("after changing the email the domain is %q, want %q", got, moved)
+			}
+
+			req := &models.CreateUserRequest{Name: "Generated " + name, Email: benchdata.Email(runID, "generated", name, 2), Age: 30}
+			if got := outcome(target.insertNaming(ctx, req, "forged.example")); got != "rejected" {
+				t.Errorf("an INSERT naming email_domain was %s, want rejected", got)
+			}
+
+			got := outcome(target.updateNaming(ctx, user.ID, "forged.example"))
+			if got == "written" && domainOf(moved) == moved {
+				got = "ignored"
+			}
+			if got != target.updateOutcome {
+				t.Errorf("an UPDATE naming email_domain was %s, want %s", got, target.updateOutcome)
+			}
+		})
+	}
+}
@@ -2,25 +2,49 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/secret"
 	"gorm.io/gorm"
 )
 
 // GORMRepository implements repository pattern using GORM ORM
 type GORMRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	codec secret.Codec // Seals the secret column, see WithCodec
+
+	readOnlyReads bool // Runs every read method in a read-only transaction, see WithReadOnlyReads
 }
 
 // NewGORMRepository creates a new GORM repository instance
 func NewGORMRepository(db *gorm.DB) *GORMRepository {
+	registerGORMTracing(db)
+	registerGORMComments(db)
 	return &GORMRepository{db: db}
 }
 
+// WithConn returns a repository that runs every statement on conn instead
+// of checking connections out of the pool. The caller owns conn.
+func (r *GORMRepository) WithConn(conn *sql.Conn) *GORMRepository {
+	// Clone the statement so the shared handle keeps using the pool
+	db := r.db.Session(&gorm.Session{Context: context.Background()})
+	db.Statement.ConnPool = conn
+	return &GORMRepository{db: db, codec: r.codec, readOnlyReads: r.readOnlyReads}
+}
+
+// WithCodec returns a repository sealing the secret column with codec
+func (r *GORMRepository) WithCodec(codec secret.Codec) *GORMRepository {
+	return &GORMRepository{db: r.db, codec: codec, readOnlyReads: r.readOnlyReads}
+}
+
 // CreateUser creates a new user using GORM ORM
-func (r *GORMRepository) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+func (r *GORMRepository) CreateUser(ctx context.Context, req *models.CreateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "GORM", "CreateUser")
+	defer func() { endSpan(span, err) }()
+
 	user := &models.User{
 		Name:     req.Name,
 		Email:    req.Email,
@@ -37,14 +61,23 @@ func (r *GORMRepository) CreateUser(ctx context.Context, req *models.CreateUserR
 }
 
 // GetUserByID retrieves a user by ID using GORM
-func (r *GORMRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+func (r *GORMRepository) GetUserByID(ctx context.Context, id int) (_ *models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) (*models.User, error) {
+			return tx.GetUserByID(ctx, id)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetUserByID")
+	defer func() { endSpan(span, err) }()
+
 	var user models.User
 	
 	// Equivalent SQL: SELECT * FROM users WHERE id = ? AND is_active = true
-	err := r.db.WithContext(ctx).Where("id = ? AND is_active = ?", id, true).First(&user).Error
+	err = r.db.WithContext(ctx).Where("id = ? AND is_active = ?", id, true).First(&user).Error
 	
 	if err == gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("user with ID %d not found", id)
+		return nil, notFound(id, "", err)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("GORM get user failed: %w", err)
@@ -54,11 +87,20 @@ func (r *GORMRepository) GetUserByID(ctx context.Context, id int) (*models.User,
 }
 
 // GetAllUsers retrieves all active users using GORM with pagination
-func (r *GORMRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+func (r *GORMRepository) GetAllUsers(ctx context.Context, limit, offset int) (_ []*models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) ([]*models.User, error) {
+			return tx.GetAllUsers(ctx, limit, offset)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetAllUsers")
+	defer func() { endSpan(span, err) }()
+
 	var users []models.User
 	
 	// Equivalent SQL: SELECT * FROM users WHERE is_active = true ORDER BY created_at DESC LIMIT ? OFFSET ?
-	err := r.db.WithContext(ctx).
+	err = r.db.WithContext(ctx).
 		Where("is_active = ?", true).
 		Order("created_at DESC").
 		Limit(limit).
@@ -79,13 +121,16 @@ func (r *GORMRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]
 }
 
 // UpdateUser updates a user using GORM with selective updates
-func (r *GORMRepository) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+func (r *GORMRepository) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "GORM", "UpdateUser")
+	defer func() { endSpan(span, err) }()
+
 	var user models.User
 	
 	// First, find the user
-	err := r.db.WithContext(ctx).Where("id = ? AND is_active = ?", id, true).First(&user).Error
+	err = r.db.WithContext(ctx).Where("id = ? AND is_active = ?", id, true).First(&user).Error
 	if err == gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("user with ID %d not found or inactive", id)
+		return nil, notFound(id, " or inactive", err)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("GORM find user for update failed: %w", err)
@@ -125,7 +170,10 @@ func (r *GORMRepository) UpdateUser(ctx context.Context, id int, req *models.Upd
 }
 
 // DeleteUser performs soft delete using GORM
-func (r *GORMRepository) DeleteUser(ctx context.Context, id int) error {
+func (r *GORMRepository) DeleteUser(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "GORM", "DeleteUser")
+	defer func() { endSpan(span, err) }()
+
 	// Soft delete by setting is_active = false
 	result := r.db.WithContext(ctx).
 		Model(&models.User{}).
@@ -140,19 +188,28 @@ func (r *GORMRepository) DeleteUser(ctx context.Context, id int) error {
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("user with ID %d not found or already deleted", id)
+		return notFound(id, " or already deleted", nil)
 	}
 
 	return nil
 }
 
-// GetUsersByEmail searches users by email pattern using GORM
-func (r *GORMRepository) GetUsersByEmail(ctx context.Context, emailPattern string) ([]*models.User, error) {
+// GetUsersByEmail searches users whose email matches the ILIKE pattern %emailPattern% using GORM
+func (r *GORMRepository) GetUsersByEmail(ctx context.Context, emailPattern string) (_ []*models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) ([]*models.User, error) {
+			return tx.GetUsersByEmail(ctx, emailPattern)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetUsersByEmail")
+	defer func() { endSpan(span, err) }()
+
 	var users []models.User
 	
 	// Equivalent SQL: SELECT * FROM users WHERE email ILIKE '%pattern%' AND is_active = true ORDER BY created_at DESC
-	err := r.db.WithContext(ctx).
-		Where("email ILIKE ? AND is_active = ?", "%"+emailPattern+"%", true).
+	err = r.db.WithContext(ctx).
+		Where("email ILIKE ? AND is_active = ?", containsPattern(emailPattern), true).
 		Order("created_at DESC").
 		Find(&users).Error
 	
@@ -170,10 +227,13 @@ func (r *GORMRepository) GetUsersByEmail(ctx context.Context, emailPattern strin
 }
 
 // CreateUserWithTransaction demonstrates transaction handling with GORM
-func (r *GORMRepository) CreateUserWithTransaction(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+func (r *GORMRepository) CreateUserWithTransaction(ctx context.Context, req *models.CreateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "GORM", "CreateUserWithTransaction")
+	defer func() { endSpan(span, err) }()
+
 	var user *models.User
 	
-	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Check if email already exists
 		var count int64
 		err := tx.Model(&models.User{}).
@@ -210,7 +270,10 @@ func (r *GORMRepository) CreateUserWithTransaction(ctx context.Context, req *mod
 }
 
 // BatchCreateUsers demonstrates batch operations with GORM
-func (r *GORMRepository) BatchCreateUsers(ctx context.Context, requests []*models.CreateUserRequest) ([]*models.User, error) {
+func (r *GORMRepository) BatchCreateUsers(ctx context.Context, requests []*models.CreateUserRequest) (_ []*models.User, err error) {
+	ctx, span := startSpan(ctx, "GORM", "BatchCreateUsers")
+	defer func() { endSpan(span, err) }()
+
 	if len(requests) == 0 {
 		return []*models.User{}, nil
 	}
@@ -226,7 +289,7 @@ func (r *GORMRepository) BatchCreateUsers(ctx context.Context, requests []*model
 	}
 
 	// GORM batch insert
-	err := r.db.WithContext(ctx).CreateInBatches(users, 100).Error
+	err = r.db.WithContext(ctx).CreateInBatches(users, 100).Error
 	if err != nil {
 		return nil, fmt.Errorf("GORM batch create users failed: %w", err)
 	}
@@ -241,7 +304,16 @@ func (r *GORMRepository) BatchCreateUsers(ctx context.Context, requests []*model
 }
 
 // GetUserStats demonstrates complex queries with GORM
-func (r *GORMRepository) GetUserStats(ctx context.Context) (map[string]interface{}, error) {
+func (r *GORMRepository) GetUserStats(ctx context.Context) (_ map[string]interface{}, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) (map[string]interface{}, error) {
+			return tx.GetUserStats(ctx)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetUserStats")
+	defer func() { endSpan(span, err) }()
+
 	var stats struct {
 		TotalUsers   int64   `json:"total_users"`
 		ActiveUsers  int64   `json:"active_users"`
@@ -249,7 +321,7 @@ func (r *GORMRepository) GetUserStats(ctx context.Context) (map[string]interface
 	}
 
 	// Count total users
-	err := r.db.WithContext(ctx).Model(&models.User{}).Count(&stats.TotalUsers).Error
+	err = r.db.WithContext(ctx).Model(&models.User{}).Count(&stats.TotalUsers).Error
 	if err != nil {
 		return nil, fmt.Errorf("GORM count total users failed: %w", err)
 	}
@@ -277,7 +349,16 @@ func (r *GORMRepository) GetUserStats(ctx context.Context) (map[string]interface
 }
 
 // FindUsersWithComplexQuery demonstrates advanced GORM querying
-func (r *GORMRepository) FindUsersWithComplexQuery(ctx context.Context, minAge, maxAge int, emailDomain string) ([]*models.User, error) {
+func (r *GORMRepository) FindUsersWithComplexQuery(ctx context.Context, minAge, maxAge int, emailDomain string) (_ []*models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) ([]*models.User, error) {
+			return tx.FindUsersWithComplexQuery(ctx, minAge, maxAge, emailDomain)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "FindUsersWithComplexQuery")
+	defer func() { endSpan(span, err) }()
+
 	var users []models.User
 
 	query := r.db.WithContext(ctx).Where("is_active = ? AND age BETWEEN ? AND ?", true, minAge, maxAge)
@@ -286,7 +367,7 @@ func (r *GORMRepository) FindUsersWithComplexQuery(ctx context.Context, minAge,
 		query = query.Where("email LIKE ?", "%@"+emailDomain)
 	}
 
-	err := query.Order("created_at DESC").Find(&users).Error
+	err = query.Order("created_at DESC").Find(&users).Error
 	if err != nil {
 		return nil, fmt.Errorf("GORM complex query failed: %w", err)
 	}
@@ -301,7 +382,10 @@ func (r *GORMRepository) FindUsersWithComplexQuery(ctx context.Context, minAge,
 }
 
 // UpdateUserSelective demonstrates GORM's selective updates feature
-func (r *GORMRepository) UpdateUserSelective(ctx context.Context, id int, updates map[string]interface{}) (*models.User, error) {
+func (r *GORMRepository) UpdateUserSelective(ctx context.Context, id int, updates map[string]interface{}) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "GORM", "UpdateUserSelective")
+	defer func() { endSpan(span, err) }()
+
 	var user models.User
 
 	// Add updated_at to updates
@@ -318,14 +402,267 @@ func (r *GORMRepository) UpdateUserSelective(ctx context.Context, id int, update
 	}
 
 	if result.RowsAffected == 0 {
-		return nil, fmt.Errorf("user with ID %d not found or inactive", id)
+		return nil, notFound(id, " or inactive", nil)
 	}
 
 	// Reload the updated user
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	err = r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		return nil, fmt.Errorf("GORM reload after selective update failed: %w", err)
 	}
 
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// TouchUsersInOrder demonstrates lock ordering with GORM, see
+// PQRepository.TouchUsersInOrder
+func (r *GORMRepository) TouchUsersInOrder(ctx context.Context, ids []int, hold time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "GORM", "TouchUsersInOrder")
+	defer func() { endSpan(span, err) }()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if i > 0 {
+				if err := holdLocks(ctx, hold); err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&models.User{}).Where("id = ?", id).Update("updated_at", time.Now()).Error; err != nil {
+				return fmt.Errorf("GORM update user %d failed: %w", id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// SetUserSecret seals plaintext with the codec of the repository and stores it using GORM
+func (r *GORMRepository) SetUserSecret(ctx context.Context, id int, plaintext string) (err error) {
+	ctx, span := startSpan(ctx, "GORM", "SetUserSecret")
+	defer func() { endSpan(span, err) }()
+
+	if r.codec == nil {
+		return errNoCodec
+	}
+	sealed, err := r.codec.Seal(ctx, plaintext)
+	if err != nil {
+		return fmt.Errorf("GORM seal secret failed: %w", err)
+	}
+
+	// Equivalent SQL: UPDATE users SET secret = ?, updated_at = ? WHERE id = ? AND is_active = true
+	result := r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND is_active = ?", id, true).
+		Updates(map[string]interface{}{
+			"secret":     sealed,
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("GORM set secret failed: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return notFound(id, " or inactive", nil)
+	}
+
+	return nil
+}
+
+// GetUserSecret reads the secret of a user and opens it with the codec of the repository using GORM.
+// A user without a secret has "".
+func (r *GORMRepository) GetUserSecret(ctx context.Context, id int) (_ string, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) (string, error) {
+			return tx.GetUserSecret(ctx, id)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetUserSecret")
+	defer func() { endSpan(span, err) }()
+
+	if r.codec == nil {
+		return "", errNoCodec
+	}
+
+	// The model has no secret field, GORM scans the column alone
+	var sealed []byte
+	err = r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Select("secret").
+		Where("id = ? AND is_active = ?", id, true).
+		Row().Scan(&sealed)
+
+	if err == sql.ErrNoRows {
+		return "", notFound(id, "", err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("GORM get secret failed: %w", err)
+	}
+	if sealed == nil {
+		return "", nil
+	}
+
+	plaintext, err := r.codec.Open(ctx, sealed)
+	if err != nil {
+		return "", fmt.Errorf("GORM open secret failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+// CreateUserWithDomain creates a user and reads back the email domain PostgreSQL generated for it using GORM.
+// The permission tags of UserWithDomain keep email_domain out of the INSERT and add it to RETURNING.
+func (r *GORMRepository) CreateUserWithDomain(ctx context.Context, req *models.CreateUserRequest) (_ *models.UserWithDomain, err error) {
+	ctx, span := startSpan(ctx, "GORM", "CreateUserWithDomain")
+	defer func() { endSpan(span, err) }()
+
+	user := &models.UserWithDomain{
+		User: models.User{
+			Name:     req.Name,
+			Email:    req.Email,
+			Age:      req.Age,
+			IsActive: true,
+		},
+	}
+
+	// Equivalent SQL: INSERT INTO users (name, email, age, created_at, updated_at, is_active) VALUES (...) RETURNING id, email_domain
+	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+		return nil, fmt.Errorf("GORM create user failed: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUsersByEmailDomain retrieves the active users of an email domain through the generated column using GORM
+func (r *GORMRepository) GetUsersByEmailDomain(ctx context.Context, domain string) (_ []*models.UserWithDomain, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) ([]*models.UserWithDomain, error) {
+			return tx.GetUsersByEmailDomain(ctx, domain)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetUsersByEmailDomain")
+	defer func() { endSpan(span, err) }()
+
+	var users []*models.UserWithDomain
+
+	// Equivalent SQL: SELECT * FROM users WHERE email_domain = lower(?) AND is_active = true ORDER BY id
+	err = r.db.WithContext(ctx).
+		Where("email_domain = lower(?) AND is_active = ?", domain, true).
+		Order("id").
+		Find(&users).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GORM get users by email domain failed: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetUsersRankedByAgeInDomain ranks the active users of an email domain by age with window functions using GORM.
+// GORM has no clause for window functions, they go into Select as expressions and Scan maps them by column name.
+func (r *GORMRepository) GetUsersRankedByAgeInDomain(ctx context.Context, domain string) (_ []*models.RankedUser, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) ([]*models.RankedUser, error) {
+			return tx.GetUsersRankedByAgeInDomain(ctx, domain)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetUsersRankedByAgeInDomain")
+	defer func() { endSpan(span, err) }()
+
+	var users []*models.RankedUser
+
+	// Equivalent SQL: SELECT ..., RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_rank, ... FROM users
+	// WHERE email_domain = lower(?) AND is_active = true ORDER BY age_rank, id
+	err = r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Select(`id, name, email, age, created_at, updated_at, is_active, email_domain,
+			RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_rank,
+			DENSE_RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_dense_rank,
+			PERCENT_RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_percentile,
+			COUNT(*) OVER (PARTITION BY email_domain) AS domain_users,
+			AVG(age) OVER (PARTITION BY email_domain)::float8 AS domain_avg_age`).
+		Where("email_domain = lower(?) AND is_active = ?", domain, true).
+		Order("age_rank, id").
+		Scan(&users).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GORM get users ranked by age failed: %w", err)
+	}
+
+	return users, nil
+}
+
+// CountUsersByAgeBucket aggregates the active users by ten-year age bucket using GORM.
+// The aggregates are no columns of the User model, so Scan maps them into AgeBucket by column name.
+func (r *GORMRepository) CountUsersByAgeBucket(ctx context.Context) (_ []*models.AgeBucket, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) ([]*models.AgeBucket, error) {
+			return tx.CountUsersByAgeBucket(ctx)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "CountUsersByAgeBucket")
+	defer func() { endSpan(span, err) }()
+
+	var buckets []*models.AgeBucket
+
+	// Equivalent SQL: SELECT age / 10 * 10 AS min_age, ..., COUNT(*) AS users, ... FROM users
+	// WHERE is_active = true AND age IS NOT NULL GROUP BY age / 10 ORDER BY min_age
+	err = r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Select(`age / 10 * 10 AS min_age, age / 10 * 10 + 9 AS max_age, COUNT(*) AS users,
+			AVG(age)::float8 AS avg_age, MIN(created_at) AS first_created_at, MAX(created_at) AS last_created_at`).
+		Where("is_active = ? AND age IS NOT NULL", true).
+		Group("age / 10").
+		Order("min_age").
+		Scan(&buckets).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GORM count users by age bucket failed: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// CreateUserActivities appends activities to the user_activity log using GORM.
+// Create on a slice sends one multi-row INSERT, as the hand-written repositories do.
+func (r *GORMRepository) CreateUserActivities(ctx context.Context, activities []*models.UserActivity) (err error) {
+	ctx, span := startSpan(ctx, "GORM", "CreateUserActivities")
+	defer func() { endSpan(span, err) }()
+
+	if len(activities) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).Create(&activities).Error; err != nil {
+		return fmt.Errorf("GORM create user activities failed: %w", err)
+	}
+	return nil
+}
+
+// GetUserActivityInRange retrieves the activity of a user in [from, to), oldest first, using GORM
+func (r *GORMRepository) GetUserActivityInRange(ctx context.Context, userID int, from, to time.Time) (_ []*models.UserActivity, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *GORMRepository) ([]*models.UserActivity, error) {
+			return tx.GetUserActivityInRange(ctx, userID, from, to)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "GORM", "GetUserActivityInRange")
+	defer func() { endSpan(span, err) }()
+
+	var activities []*models.UserActivity
+
+	// Equivalent SQL: SELECT * FROM user_activity WHERE user_id = ? AND occurred_at >= ? AND occurred_at < ? ORDER BY occurred_at
+	err = r.db.WithContext(ctx).
+		Where("user_id = ? AND occurred_at >= ? AND occurred_at < ?", userID, from, to).
+		Order("occurred_at").
+		Find(&activities).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GORM get user activity failed: %w", err)
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,169 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+// injectionInputs are the hostile values fed through the repositories:
+// quotes and comments that would end a statement, LIKE wildcards and the
+// escape character, placeholders of every library and non-ASCII text
+var injectionInputs = []string{
+	`O'Brien`,
+	`'; DROP TABLE users; --`,
+	`') OR 1=1 --`,
+	`" OR "1"="1`,
+	`%`,
+	`_`,
+	`100%_off`,
+	`back\slash`,
+	`$1 ? :name`,
+	`Zoë 名前 🚀`,
+}
+
+// TestInjection feeds every input through CreateUser, GetUserByID,
+// UpdateUser and GetUsersByEmail of every library. Each value has to come
+// back byte for byte and never appear in the SQL text sent to the server,
+// and a search has to find the users an ILIKE of the input as a pattern
+// matches: % and _ stay wildcards, but never break out of the pattern.
+func TestInjection(t *testing.T) {
+	config := dbtest.Config(t)
+	config.QueryLog = sqlcapture.Sink()
+
+	for _, lib := range dbtest.Libraries(t, config) {
+		t.Run(lib.Name, func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+			domain := "@" + benchdata.RunID(ctx) + "." + benchdata.Domain
+			emails := map[int]string{} // Current email of every user created
+
+			for n, input := range injectionInputs {
+				email := fmt.Sprintf("injection-%s-%d-%s%s", strings.ToLower(lib.Name), n+1, input, domain)
+
+				recorded, recorder := sqlcapture.WithRecorder(ctx)
+				user, err := lib.Repo.CreateUser(recorded, &models.CreateUserRequest{Name: input, Email: email, Age: 30})
+				if err != nil {
+					t.Fatalf("CreateUser(%q): %v", input, err)
+				}
+				emails[user.ID] = user.Email
+				checkNotInlined(t, recorder, email)
+				checkUser(t, "CreateUser", user, input, email)
+
+				recorded, recorder = sqlcapture.WithRecorder(ctx)
+				if user, err = lib.Repo.GetUserByID(recorded, user.ID); err != nil {
+					t.Fatalf("GetUserByID(%q): %v", input, err)
+				}
+				checkNotInlined(t, recorder, email)
+				checkUser(t, "GetUserByID", user, input, email)
+
+				name, email := "Updated "+input, "updated-"+email
+				recorded, recorder = sqlcapture.WithRecorder(ctx)
+				if user, err = lib.Repo.UpdateUser(recorded, user.ID, &models.UpdateUserRequest{Name: &name, Email: &email}); err != nil {
+					t.Fatalf("UpdateUser(%q): %v", input, err)
+				}
+				emails[user.ID] = user.Email
+				checkNotInlined(t, recorder, email)
+				checkUser(t, "UpdateUser", user, name, email)
+				if user, err = lib.Repo.GetUserByID(ctx, user.ID); err != nil {
+					t.Fatalf("GetUserByID(%q) after the update: %v", input, err)
+				}
+				checkUser(t, "GetUserByID after UpdateUser", user, name, email)
+
+				checkSearch(t, ctx, lib.Repo, input, domain, emails)
+			}
+
+			// Nothing was dropped or rewritten along the way
+			users, err := lib.Repo.GetUsersByEmail(ctx, domain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(users) != len(emails) {
+				t.Fatalf("found %d users of this run, created %d", len(users), len(emails))
+			}
+		})
+	}
+}
+
+// checkNotInlined fails t when a recorded statement has value in its SQL
+// text instead of passing it as an argument
+func checkNotInlined(t *testing.T, recorder *sqlcapture.Recorder, value string) {
+	t.Helper()
+	queries := recorder.Queries()
+	if len(queries) == 0 {
+		t.Fatal("no statement was recorded")
+	}
+	for _, q := range queries {
+		if strings.Contains(q.SQL, value) {
+			t.Fatalf("value %q inlined into %s", value, strings.Join(strings.Fields(q.SQL), " "))
+		}
+	}
+}
+
+// checkUser fails t unless user kept name and email byte for byte
+func checkUser(t *testing.T, call string, user *models.User, name, email string) {
+	t.Helper()
+	if user.Name != name || user.Email != email {
+		t.Fatalf("%s returned name %q and email %q, want %q and %q", call, user.Name, user.Email, name, email)
+	}
+}
+
+// checkSearch fails t unless searching for input finds exactly the users of
+// this run whose email ILIKE '%input%' matches
+func checkSearch(t *testing.T, ctx context.Context, repo repository.UserRepository, input, domain string, emails map[int]string) {
+	t.Helper()
+	users, err := repo.GetUsersByEmail(ctx, input)
+	if err != nil {
+		t.Fatalf("GetUsersByEmail(%q): %v", input, err)
+	}
+	pattern := ilike("%" + input + "%")
+	var found, expected []int
+	for _, user := range users {
+		if strings.HasSuffix(user.Email, domain) {
+			found = append(found, user.ID)
+		}
+	}
+	for id, email := range emails {
+		if pattern.MatchString(email) {
+			expected = append(expected, id)
+		}
+	}
+	sort.Ints(found)
+	sort.Ints(expected)
+	if fmt.Sprint(found) != fmt.Sprint(expected) {
+		t.Fatalf("GetUsersByEmail(%q) found users %v, want %v", input, found, expected)
+	}
+}
+
+// ilike returns a regexp matching what the ILIKE pattern matches, with the
+// default escape character, the backslash
+func ilike(pattern string) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString(`(?is)^`)
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			re.WriteString(`.*`)
+		case r == '_':
+			re.WriteString(`.`)
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	re.WriteString(`$`)
+	return regexp.MustCompile(re.String())
+}
@@ -0,0 +1,58 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// TestNotFound checks that every library reports a call on a user that
+// does not exist with repository.ErrNotFound, and a read with its own no
+// rows error as well
+func TestNotFound(t *testing.T) {
+	config := dbtest.Config(t)
+	const missing = -1
+
+	for _, lib := range dbtest.Libraries(t, config) {
+		t.Run(lib.Name, func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+			renamed := "Renamed"
+			calls := []struct {
+				name string
+				call func(ctx context.Context) error
+			}{
+				{"GetUserByID", func(ctx context.Context) error {
+					_, err := lib.Repo.GetUserByID(ctx, missing)
+					return err
+				}},
+				{"UpdateUser", func(ctx context.Context) error {
+					_, err := lib.Repo.UpdateUser(ctx, missing, &models.UpdateUserRequest{Name: &renamed})
+					return err
+				}},
+				{"DeleteUser", func(ctx context.Context) error {
+					return lib.Repo.DeleteUser(ctx, missing)
+				}},
+			}
+			for _, c := range calls {
+				if err := c.call(ctx); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("%s of a missing user returned %v, want repository.ErrNotFound", c.name, err)
+				}
+			}
+
+			noRows := sql.ErrNoRows
+			if lib.Name == "GORM" {
+				noRows = gorm.ErrRecordNotFound
+			}
+			if _, err := lib.Repo.GetUserByID(ctx, missing); !errors.Is(err, noRows) {
+				t.Errorf("GetUserByID of a missing user returned %v, want it to wrap %v", err, noRows)
+			}
+		})
+	}
+}
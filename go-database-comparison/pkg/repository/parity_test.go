@@ -0,0 +1,227 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"pgregory.net/rapid"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/models"
+)
+
+// Emails a generated update can set. They are built per library, so the
+// libraries never compete for the same unique email.
+const (
+	emailFresh     = "fresh"     // Not used by any user
+	emailMixedCase = "mixedcase" // Fresh, with upper case letters
+	emailOwn       = "own"       // The user's current email
+	emailTaken     = "taken"     // The email of another user, a unique violation
+)
+
+// parityRequest is a generated partial update. Nil fields are left alone.
+type parityRequest struct {
+	Name     *string
+	Email    *string // One of the email constants
+	Age      *int
+	IsActive *bool
+}
+
+func (r parityRequest) String() string {
+	var parts []string
+	if r.Name != nil {
+		name := *r.Name
+		if len(name) > 24 {
+			name = fmt.Sprintf("%s… (%d bytes)", name[:12], len(name))
+		}
+		parts = append(parts, fmt.Sprintf("name=%q", name))
+	}
+	if r.Email != nil {
+		parts = append(parts, "email="+*r.Email)
+	}
+	if r.Age != nil {
+		parts = append(parts, fmt.Sprintf("age=%d", *r.Age))
+	}
+	if r.IsActive != nil {
+		parts = append(parts, fmt.Sprintf("is_active=%t", *r.IsActive))
+	}
+	if len(parts) == 0 {
+		return "(no fields)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// parityOutcome is what one library's UpdateUser did to its user. Emails
+// are recorded by where they came from, as each library's differ.
+type parityOutcome struct {
+	Error    string // Category of the error, see benchmark.ClassifyError
+	Name     string
+	Email    string
+	Age      int
+	IsActive bool
+	Touched  bool   // updated_at moved
+	Returned string // How the returned user differs from the row
+}
+
+// differsFrom reports how o differs from other, "" when it does not
+func (o parityOutcome) differsFrom(other parityOutcome) string {
+	switch {
+	case o.Error != other.Error:
+		return fmt.Sprintf("error %q vs %q", o.Error, other.Error)
+	case o.Name != other.Name:
+		return fmt.Sprintf("name %q vs %q", o.Name, other.Name)
+	case o.Email != other.Email:
+		return fmt.Sprintf("email %q vs %q", o.Email, other.Email)
+	case o.Age != other.Age:
+		return fmt.Sprintf("age %d vs %d", o.Age, other.Age)
+	case o.IsActive != other.IsActive:
+		return fmt.Sprintf("is_active %t vs %t", o.IsActive, other.IsActive)
+	case o.Touched != other.Touched:
+		return fmt.Sprintf("updated_at moved %t vs %t", o.Touched, other.Touched)
+	case o.Returned != other.Returned:
+		return fmt.Sprintf("returned user %q vs %q", o.Returned, other.Returned)
+	}
+	return ""
+}
+
+// drawParityRequest sets each field with even odds, drawing its value from
+// ordinary and boundary values: empty and unicode names, names past the
+// column's 100 characters, ages on and past the CHECK bounds, an email
+// taken by another user and deactivating the user
+func drawParityRequest(t *rapid.T) parityRequest {
+	var req parityRequest
+	if rapid.Bool().Draw(t, "setName") {
+		name := rapid.OneOf(
+			rapid.SampledFrom([]string{"", "A", "O'Brien", "Zoë 名前 🚀", strings.Repeat("n", 100), strings.Repeat("n", 101)}),
+			rapid.StringMatching(`[a-zA-Z ]{1,30}`),
+		).Draw(t, "name")
+		req.Name = &name
+	}
+	if rapid.Bool().Draw(t, "setEmail") {
+		email := rapid.SampledFrom([]string{emailFresh, emailMixedCase, emailOwn, emailTaken}).Draw(t, "email")
+		req.Email = &email
+	}
+	if rapid.Bool().Draw(t, "setAge") {
+		age := rapid.OneOf(rapid.SampledFrom([]int{0, 1, 150, -1, 151}), rapid.IntRange(0, 150)).Draw(t, "age")
+		req.Age = &age
+	}
+	if rapid.Bool().Draw(t, "setActive") {
+		active := rapid.Bool().Draw(t, "isActive")
+		req.IsActive = &active
+	}
+	return req
+}
+
+// TestUpdateParity checks that the UpdateUser of every library leaves the
+// same row behind for random partial updates, catching semantic
+// differences between the dynamic UPDATE builders and GORM's selective
+// updates. Each library updates a fresh user of its own, read back through
+// a separate connection. The libraries agree when they fail the same way,
+// or leave the same name, email, age and is_active, move updated_at alike
+// and return the row they wrote. rapid shrinks a disagreement to the
+// smallest update showing it; -rapid.checks sets the number of updates.
+func TestUpdateParity(t *testing.T) {
+	config := dbtest.Config(t)
+	// A failed update would abort the rolled back transaction, so the users
+	// are committed and removed by run ID instead
+	config.Rollback = false
+	ctx := dbtest.Context(t, config)
+	observer := dbtest.Observer(t, config)
+	libs := dbtest.Libraries(t, config)
+	runID := benchdata.RunID(ctx)
+	var users int64 // Users created so far, numbering their emails
+
+	rapid.Check(t, func(rt *rapid.T) {
+		req := drawParityRequest(rt)
+		var first parityOutcome
+		for i, lib := range libs {
+			outcome := applyParityRequest(rt, ctx, observer, lib, runID, &users, req)
+			if i == 0 {
+				first = outcome
+				continue
+			}
+			if diff := outcome.differsFrom(first); diff != "" {
+				rt.Fatalf("%s: %s vs %s: %s", req, lib.Name, libs[0].Name, diff)
+			}
+		}
+	})
+}
+
+// applyParityRequest creates a user and another user whose email can be
+// taken, runs req through lib's UpdateUser and reads the user back
+func applyParityRequest(t *rapid.T, ctx context.Context, observer *sql.DB, lib dbtest.Library, runID string, users *int64, req parityRequest) parityOutcome {
+	tag := strings.ToLower(lib.Name)
+	email := func() string {
+		*users++
+		return benchdata.Email(runID, "parity", tag, *users)
+	}
+	user, err := lib.Repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Parity User", Email: email(), Age: 30})
+	if err != nil {
+		t.Fatalf("%s: failed to create user: %v", lib.Name, err)
+	}
+	other, err := lib.Repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Parity Bystander", Email: email(), Age: 30})
+	if err != nil {
+		t.Fatalf("%s: failed to create user: %v", lib.Name, err)
+	}
+
+	update := &models.UpdateUserRequest{Name: req.Name, Age: req.Age, IsActive: req.IsActive}
+	var requested string
+	if req.Email != nil {
+		switch *req.Email {
+		case emailFresh:
+			requested = email()
+		case emailMixedCase:
+			requested = strings.Replace(email(), "parity", "Parity", 1)
+		case emailOwn:
+			requested = user.Email
+		case emailTaken:
+			requested = other.Email
+		}
+		update.Email = &requested
+	}
+
+	// Let updated_at move visibly on coarse clocks
+	time.Sleep(time.Millisecond)
+	returned, err := lib.Repo.UpdateUser(ctx, user.ID, update)
+
+	var outcome parityOutcome
+	var updatedAt time.Time
+	if err := observer.QueryRowContext(ctx,
+		"SELECT name, email, age, is_active, updated_at FROM users WHERE id = $1", user.ID,
+	).Scan(&outcome.Name, &outcome.Email, &outcome.Age, &outcome.IsActive, &updatedAt); err != nil {
+		t.Fatalf("%s: failed to read back user %d: %v", lib.Name, user.ID, err)
+	}
+	outcome.Touched = !updatedAt.Equal(user.UpdatedAt)
+
+	if err != nil {
+		outcome.Error = string(benchmark.ClassifyError(err))
+	} else {
+		switch {
+		case returned.Name != outcome.Name:
+			outcome.Returned = fmt.Sprintf("name %q", returned.Name)
+		case returned.Email != outcome.Email:
+			outcome.Returned = fmt.Sprintf("email %q", returned.Email)
+		case returned.Age != outcome.Age:
+			outcome.Returned = fmt.Sprintf("age %d", returned.Age)
+		case returned.IsActive != outcome.IsActive:
+			outcome.Returned = fmt.Sprintf("is_active %t", returned.IsActive)
+		}
+	}
+
+	// The emails differ between libraries, compare where they came from
+	switch outcome.Email {
+	case user.Email:
+		outcome.Email = "(original)"
+	case other.Email:
+		outcome.Email = "(bystander's)"
+	case requested:
+		outcome.Email = "(requested)"
+	}
+	return outcome
+}
@@ -0,0 +1,8 @@
+package repository
+
+// containsPattern returns the ILIKE pattern of a search for emails
+// containing s. s is always bound as a parameter, so it cannot change the
+// statement, but its % and _ keep matching any text and any character.
+func containsPattern(s string) string {
+	return "%" + s + "%"
+}
@@ -4,14 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/secret"
 )
 
+// pqExecutor is satisfied by both *sql.DB and a single pooled *sql.Conn
+type pqExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
 // PQRepository implements repository pattern using lib/pq
 type PQRepository struct {
-	db *sql.DB
+	db    pqExecutor
+	codec secret.Codec // Seals the secret column, see WithCodec
+
+	readOnlyReads bool // Runs every read method in a read-only transaction, see WithReadOnlyReads
 }
 
 // NewPQRepository creates a new PQ repository instance
@@ -19,8 +32,22 @@ func NewPQRepository(db *sql.DB) *PQRepository {
 	return &PQRepository{db: db}
 }
 
+// WithConn returns a repository that runs every statement on conn instead
+// of checking connections out of the pool. The caller owns conn.
+func (r *PQRepository) WithConn(conn *sql.Conn) *PQRepository {
+	return &PQRepository{db: conn, codec: r.codec, readOnlyReads: r.readOnlyReads}
+}
+
+// WithCodec returns a repository sealing the secret column with codec
+func (r *PQRepository) WithCodec(codec secret.Codec) *PQRepository {
+	return &PQRepository{db: r.db, codec: codec, readOnlyReads: r.readOnlyReads}
+}
+
 // CreateUser creates a new user using raw SQL with lib/pq
-func (r *PQRepository) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+func (r *PQRepository) CreateUser(ctx context.Context, req *models.CreateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "PQ", "CreateUser")
+	defer func() { endSpan(span, err) }()
+
 	// Use prepared statement for security and performance
 	query := `
 		INSERT INTO users (name, email, age, created_at, updated_at, is_active)
@@ -30,7 +57,8 @@ func (r *PQRepository) CreateUser(ctx context.Context, req *models.CreateUserReq
 	now := time.Now()
 	user := &models.User{}
 
-	err := r.db.QueryRowContext(ctx, query,
+	query = statement(ctx, "lib/pq", query)
+	err = r.db.QueryRowContext(ctx, query,
 		req.Name, req.Email, req.Age, now, now, true,
 	).Scan(
 		&user.ID, &user.Name, &user.Email, &user.Age,
@@ -45,20 +73,30 @@ func (r *PQRepository) CreateUser(ctx context.Context, req *models.CreateUserReq
 }
 
 // GetUserByID retrieves a user by ID using lib/pq
-func (r *PQRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+func (r *PQRepository) GetUserByID(ctx context.Context, id int) (_ *models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) (*models.User, error) {
+			return tx.GetUserByID(ctx, id)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "GetUserByID")
+	defer func() { endSpan(span, err) }()
+
 	query := `
 		SELECT id, name, email, age, created_at, updated_at, is_active
 		FROM users
 		WHERE id = $1 AND is_active = true`
 
 	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	query = statement(ctx, "lib/pq", query)
+	err = r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Name, &user.Email, &user.Age,
 		&user.CreatedAt, &user.UpdatedAt, &user.IsActive,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user with ID %d not found", id)
+		return nil, notFound(id, "", err)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("PQ get user failed: %w", err)
@@ -68,7 +106,16 @@ func (r *PQRepository) GetUserByID(ctx context.Context, id int) (*models.User, e
 }
 
 // GetAllUsers retrieves all active users using lib/pq
-func (r *PQRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+func (r *PQRepository) GetAllUsers(ctx context.Context, limit, offset int) (_ []*models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) ([]*models.User, error) {
+			return tx.GetAllUsers(ctx, limit, offset)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "GetAllUsers")
+	defer func() { endSpan(span, err) }()
+
 	query := `
 		SELECT id, name, email, age, created_at, updated_at, is_active
 		FROM users
@@ -76,6 +123,7 @@ func (r *PQRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]*m
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2`
 
+	query = statement(ctx, "lib/pq", query)
 	rows, err := r.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("PQ get all users failed: %w", err)
@@ -103,68 +151,22 @@ func (r *PQRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]*m
 }
 
 // UpdateUser updates a user using lib/pq with dynamic query building
-func (r *PQRepository) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
-	// Dynamic query building for partial updates
-	setParts := []string{"updated_at = $1"}
-	args := []interface{}{time.Now()}
-	argCount := 2
+func (r *PQRepository) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "PQ", "UpdateUser")
+	defer func() { endSpan(span, err) }()
 
-	if req.Name != nil {
-		setParts = append(setParts, fmt.Sprintf("name = $%d", argCount))
-		args = append(args, *req.Name)
-		argCount++
-	}
-	if req.Email != nil {
-		setParts = append(setParts, fmt.Sprintf("email = $%d", argCount))
-		args = append(args, *req.Email)
-		argCount++
-	}
-	if req.Age != nil {
-		setParts = append(setParts, fmt.Sprintf("age = $%d", argCount))
-		args = append(args, *req.Age)
-		argCount++
-	}
-	if req.IsActive != nil {
-		setParts = append(setParts, fmt.Sprintf("is_active = $%d", argCount))
-		args = append(args, *req.IsActive)
-		argCount++
-	}
-
-	query := fmt.Sprintf(`
-		UPDATE users
-		SET %s
-		WHERE id = $%d AND is_active = true
-		RETURNING id, name, email, age, created_at, updated_at, is_active`,
-		fmt.Sprintf("%s", setParts[0]),
-		argCount)
-
-	// Build the complete SET clause
-	if len(setParts) > 1 {
-		setClause := ""
-		for i, part := range setParts {
-			if i > 0 {
-				setClause += ", "
-			}
-			setClause += part
-		}
-		query = fmt.Sprintf(`
-			UPDATE users
-			SET %s
-			WHERE id = $%d AND is_active = true
-			RETURNING id, name, email, age, created_at, updated_at, is_active`,
-			setClause, argCount)
-	}
-
-	args = append(args, id)
+	// Dynamic query building for partial updates
+	query, args := buildUserUpdate(id, req, time.Now())
 
 	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+	query = statement(ctx, "lib/pq", query)
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
 		&user.ID, &user.Name, &user.Email, &user.Age,
 		&user.CreatedAt, &user.UpdatedAt, &user.IsActive,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user with ID %d not found or inactive", id)
+		return nil, notFound(id, " or inactive", err)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("PQ update user failed: %w", err)
@@ -174,12 +176,16 @@ func (r *PQRepository) UpdateUser(ctx context.Context, id int, req *models.Updat
 }
 
 // DeleteUser performs soft delete using lib/pq
-func (r *PQRepository) DeleteUser(ctx context.Context, id int) error {
+func (r *PQRepository) DeleteUser(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "PQ", "DeleteUser")
+	defer func() { endSpan(span, err) }()
+
 	query := `
 		UPDATE users
 		SET is_active = false, updated_at = $1
 		WHERE id = $2 AND is_active = true`
 
+	query = statement(ctx, "lib/pq", query)
 	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("PQ delete user failed: %w", err)
@@ -191,21 +197,31 @@ func (r *PQRepository) DeleteUser(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user with ID %d not found or already deleted", id)
+		return notFound(id, " or already deleted", nil)
 	}
 
 	return nil
 }
 
-// GetUsersByEmail searches users by email pattern using lib/pq
-func (r *PQRepository) GetUsersByEmail(ctx context.Context, emailPattern string) ([]*models.User, error) {
+// GetUsersByEmail searches users whose email matches the ILIKE pattern %emailPattern% using lib/pq
+func (r *PQRepository) GetUsersByEmail(ctx context.Context, emailPattern string) (_ []*models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) ([]*models.User, error) {
+			return tx.GetUsersByEmail(ctx, emailPattern)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "GetUsersByEmail")
+	defer func() { endSpan(span, err) }()
+
 	query := `
 		SELECT id, name, email, age, created_at, updated_at, is_active
 		FROM users
 		WHERE email ILIKE $1 AND is_active = true
 		ORDER BY created_at DESC`
 
-	rows, err := r.db.QueryContext(ctx, query, "%"+emailPattern+"%")
+	query = statement(ctx, "lib/pq", query)
+	rows, err := r.db.QueryContext(ctx, query, containsPattern(emailPattern))
 	if err != nil {
 		return nil, fmt.Errorf("PQ search users by email failed: %w", err)
 	}
@@ -228,7 +244,10 @@ func (r *PQRepository) GetUsersByEmail(ctx context.Context, emailPattern string)
 }
 
 // CreateUserWithTransaction demonstrates transaction handling with lib/pq
-func (r *PQRepository) CreateUserWithTransaction(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+func (r *PQRepository) CreateUserWithTransaction(ctx context.Context, req *models.CreateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "PQ", "CreateUserWithTransaction")
+	defer func() { endSpan(span, err) }()
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("PQ begin transaction failed: %w", err)
@@ -246,6 +265,7 @@ func (r *PQRepository) CreateUserWithTransaction(ctx context.Context, req *model
 	// Check if email already exists
 	var exists bool
 	checkQuery := "SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND is_active = true)"
+	checkQuery = statement(ctx, "lib/pq", checkQuery)
 	err = tx.QueryRowContext(ctx, checkQuery, req.Email).Scan(&exists)
 	if err != nil {
 		return nil, fmt.Errorf("PQ check email existence failed: %w", err)
@@ -264,6 +284,7 @@ func (r *PQRepository) CreateUserWithTransaction(ctx context.Context, req *model
 	now := time.Now()
 	user := &models.User{}
 
+	insertQuery = statement(ctx, "lib/pq", insertQuery)
 	err = tx.QueryRowContext(ctx, insertQuery,
 		req.Name, req.Email, req.Age, now, now, true,
 	).Scan(
@@ -280,4 +301,426 @@ func (r *PQRepository) CreateUserWithTransaction(ctx context.Context, req *model
 	}
 
 	return user, nil
-}
\ No newline at end of file
+}
+
+// BatchCreateUsers creates users in one multi-row INSERT in a transaction using lib/pq
+func (r *PQRepository) BatchCreateUsers(ctx context.Context, requests []*models.CreateUserRequest) (_ []*models.User, err error) {
+	ctx, span := startSpan(ctx, "PQ", "BatchCreateUsers")
+	defer func() { endSpan(span, err) }()
+
+	if len(requests) == 0 {
+		return []*models.User{}, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("PQ batch begin transaction failed: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// One VALUES tuple per user, numbered by hand
+	var query strings.Builder
+	query.WriteString("INSERT INTO users (name, email, age, created_at, updated_at, is_active) VALUES ")
+	now := time.Now()
+	args := make([]interface{}, 0, len(requests)*6)
+	for i, req := range requests {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args, req.Name, req.Email, req.Age, now, now, true)
+	}
+	query.WriteString(" RETURNING id, name, email, age, created_at, updated_at, is_active")
+
+	rows, err := tx.QueryContext(ctx, statement(ctx, "lib/pq", query.String()), args...)
+	if err != nil {
+		return nil, fmt.Errorf("PQ batch insert failed: %w", err)
+	}
+	defer rows.Close()
+
+	// PostgreSQL returns the rows of a multi-row VALUES in its order
+	users := make([]*models.User, 0, len(requests))
+	for rows.Next() {
+		user := &models.User{}
+		if err = rows.Scan(
+			&user.ID, &user.Name, &user.Email, &user.Age,
+			&user.CreatedAt, &user.UpdatedAt, &user.IsActive,
+		); err != nil {
+			return nil, fmt.Errorf("PQ batch scan failed: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("PQ batch insert failed: %w", err)
+	}
+	rows.Close()
+	if len(users) != len(requests) {
+		err = fmt.Errorf("PQ batch insert returned %d rows for %d users", len(users), len(requests))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("PQ batch commit failed: %w", err)
+	}
+
+	return users, nil
+}
+
+// TouchUsersInOrder demonstrates lock ordering with lib/pq: it updates the
+// users one by one in the given order in a single transaction, holding each
+// row lock for hold before taking the next. Two calls with the same users
+// in opposite orders deadlock, and PostgreSQL aborts one with 40P01.
+func (r *PQRepository) TouchUsersInOrder(ctx context.Context, ids []int, hold time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "PQ", "TouchUsersInOrder")
+	defer func() { endSpan(span, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("PQ begin transaction failed: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	query := statement(ctx, "lib/pq", "UPDATE users SET updated_at = $1 WHERE id = $2")
+	for i, id := range ids {
+		if i > 0 {
+			if err = holdLocks(ctx, hold); err != nil {
+				return err
+			}
+		}
+		if _, err = tx.ExecContext(ctx, query, time.Now(), id); err != nil {
+			return fmt.Errorf("PQ update user %d failed: %w", id, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("PQ commit transaction failed: %w", err)
+	}
+	return nil
+}
+
+// holdLocks waits d with the locks of a transaction held, or until ctx is done
+func holdLocks(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetUserSecret seals plaintext with the codec of the repository and stores it using lib/pq
+func (r *PQRepository) SetUserSecret(ctx context.Context, id int, plaintext string) (err error) {
+	ctx, span := startSpan(ctx, "PQ", "SetUserSecret")
+	defer func() { endSpan(span, err) }()
+
+	if r.codec == nil {
+		return errNoCodec
+	}
+	sealed, err := r.codec.Seal(ctx, plaintext)
+	if err != nil {
+		return fmt.Errorf("PQ seal secret failed: %w", err)
+	}
+
+	query := `
+		UPDATE users
+		SET secret = $1, updated_at = $2
+		WHERE id = $3 AND is_active = true`
+
+	query = statement(ctx, "lib/pq", query)
+	result, err := r.db.ExecContext(ctx, query, sealed, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("PQ set secret failed: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("PQ get rows affected failed: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound(id, " or inactive", nil)
+	}
+
+	return nil
+}
+
+// GetUserSecret reads the secret of a user and opens it with the codec of the repository using lib/pq.
+// A user without a secret has "".
+func (r *PQRepository) GetUserSecret(ctx context.Context, id int) (_ string, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) (string, error) {
+			return tx.GetUserSecret(ctx, id)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "GetUserSecret")
+	defer func() { endSpan(span, err) }()
+
+	if r.codec == nil {
+		return "", errNoCodec
+	}
+
+	query := `
+		SELECT secret
+		FROM users
+		WHERE id = $1 AND is_active = true`
+
+	var sealed []byte
+	query = statement(ctx, "lib/pq", query)
+	err = r.db.QueryRowContext(ctx, query, id).Scan(&sealed)
+
+	if err == sql.ErrNoRows {
+		return "", notFound(id, "", err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("PQ get secret failed: %w", err)
+	}
+	if sealed == nil {
+		return "", nil
+	}
+
+	plaintext, err := r.codec.Open(ctx, sealed)
+	if err != nil {
+		return "", fmt.Errorf("PQ open secret failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+// CreateUserWithDomain creates a user and reads back the email domain PostgreSQL generated for it using lib/pq.
+// The INSERT must not name email_domain, PostgreSQL rejects any value for it.
+func (r *PQRepository) CreateUserWithDomain(ctx context.Context, req *models.CreateUserRequest) (_ *models.UserWithDomain, err error) {
+	ctx, span := startSpan(ctx, "PQ", "CreateUserWithDomain")
+	defer func() { endSpan(span, err) }()
+
+	query := `
+		INSERT INTO users (name, email, age, created_at, updated_at, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, email, age, created_at, updated_at, is_active, email_domain`
+
+	now := time.Now()
+	user := &models.UserWithDomain{}
+
+	query = statement(ctx, "lib/pq", query)
+	err = r.db.QueryRowContext(ctx, query,
+		req.Name, req.Email, req.Age, now, now, true,
+	).Scan(
+		&user.ID, &user.Name, &user.Email, &user.Age,
+		&user.CreatedAt, &user.UpdatedAt, &user.IsActive, &user.EmailDomain,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("PQ create user failed: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUsersByEmailDomain retrieves the active users of an email domain through the generated column using lib/pq
+func (r *PQRepository) GetUsersByEmailDomain(ctx context.Context, domain string) (_ []*models.UserWithDomain, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) ([]*models.UserWithDomain, error) {
+			return tx.GetUsersByEmailDomain(ctx, domain)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "GetUsersByEmailDomain")
+	defer func() { endSpan(span, err) }()
+
+	query := `
+		SELECT id, name, email, age, created_at, updated_at, is_active, email_domain
+		FROM users
+		WHERE email_domain = lower($1) AND is_active = true
+		ORDER BY id`
+
+	query = statement(ctx, "lib/pq", query)
+	rows, err := r.db.QueryContext(ctx, query, domain)
+	if err != nil {
+		return nil, fmt.Errorf("PQ get users by email domain failed: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.UserWithDomain
+	for rows.Next() {
+		user := &models.UserWithDomain{}
+		err := rows.Scan(
+			&user.ID, &user.Name, &user.Email, &user.Age,
+			&user.CreatedAt, &user.UpdatedAt, &user.IsActive, &user.EmailDomain,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("PQ scan user failed: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// GetUsersRankedByAgeInDomain ranks the active users of an email domain by age with window functions using lib/pq
+func (r *PQRepository) GetUsersRankedByAgeInDomain(ctx context.Context, domain string) (_ []*models.RankedUser, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) ([]*models.RankedUser, error) {
+			return tx.GetUsersRankedByAgeInDomain(ctx, domain)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "GetUsersRankedByAgeInDomain")
+	defer func() { endSpan(span, err) }()
+
+	query := `
+		SELECT id, name, email, age, created_at, updated_at, is_active, email_domain,
+			RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_rank,
+			DENSE_RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_dense_rank,
+			PERCENT_RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_percentile,
+			COUNT(*) OVER (PARTITION BY email_domain) AS domain_users,
+			AVG(age) OVER (PARTITION BY email_domain)::float8 AS domain_avg_age
+		FROM users
+		WHERE email_domain = lower($1) AND is_active = true
+		ORDER BY age_rank, id`
+
+	query = statement(ctx, "lib/pq", query)
+	rows, err := r.db.QueryContext(ctx, query, domain)
+	if err != nil {
+		return nil, fmt.Errorf("PQ get users ranked by age failed: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*models.RankedUser
+	for rows.Next() {
+		user := &models.RankedUser{}
+		err := rows.Scan(
+			&user.ID, &user.Name, &user.Email, &user.Age,
+			&user.CreatedAt, &user.UpdatedAt, &user.IsActive, &user.EmailDomain,
+			&user.AgeRank, &user.AgeDenseRank, &user.AgePercentile, &user.DomainUsers, &user.DomainAvgAge,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("PQ scan ranked user failed: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// CountUsersByAgeBucket aggregates the active users by ten-year age bucket using lib/pq
+func (r *PQRepository) CountUsersByAgeBucket(ctx context.Context) (_ []*models.AgeBucket, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) ([]*models.AgeBucket, error) {
+			return tx.CountUsersByAgeBucket(ctx)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "CountUsersByAgeBucket")
+	defer func() { endSpan(span, err) }()
+
+	query := `
+		SELECT age / 10 * 10 AS min_age, age / 10 * 10 + 9 AS max_age, COUNT(*) AS users,
+			AVG(age)::float8 AS avg_age, MIN(created_at) AS first_created_at, MAX(created_at) AS last_created_at
+		FROM users
+		WHERE is_active = true AND age IS NOT NULL
+		GROUP BY age / 10
+		ORDER BY min_age`
+
+	query = statement(ctx, "lib/pq", query)
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("PQ count users by age bucket failed: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []*models.AgeBucket
+	for rows.Next() {
+		bucket := &models.AgeBucket{}
+		err := rows.Scan(
+			&bucket.MinAge, &bucket.MaxAge, &bucket.Users,
+			&bucket.AvgAge, &bucket.FirstCreatedAt, &bucket.LastCreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("PQ scan age bucket failed: %w", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+// CreateUserActivities appends activities to the user_activity log in one multi-row INSERT using lib/pq
+func (r *PQRepository) CreateUserActivities(ctx context.Context, activities []*models.UserActivity) (err error) {
+	ctx, span := startSpan(ctx, "PQ", "CreateUserActivities")
+	defer func() { endSpan(span, err) }()
+
+	if len(activities) == 0 {
+		return nil
+	}
+
+	// One VALUES tuple per activity, numbered by hand
+	var query strings.Builder
+	query.WriteString("INSERT INTO user_activity (occurred_at, user_id, action, duration_ms) VALUES ")
+	args := make([]interface{}, 0, len(activities)*4)
+	for i, activity := range activities {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4)
+		args = append(args, activity.OccurredAt, activity.UserID, activity.Action, activity.DurationMs)
+	}
+
+	if _, err := r.db.ExecContext(ctx, statement(ctx, "lib/pq", query.String()), args...); err != nil {
+		return fmt.Errorf("PQ create user activities failed: %w", err)
+	}
+	return nil
+}
+
+// GetUserActivityInRange retrieves the activity of a user in [from, to), oldest first, using lib/pq
+func (r *PQRepository) GetUserActivityInRange(ctx context.Context, userID int, from, to time.Time) (_ []*models.UserActivity, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *PQRepository) ([]*models.UserActivity, error) {
+			return tx.GetUserActivityInRange(ctx, userID, from, to)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "PQ", "GetUserActivityInRange")
+	defer func() { endSpan(span, err) }()
+
+	query := `
+		SELECT occurred_at, user_id, action, duration_ms
+		FROM user_activity
+		WHERE user_id = $1 AND occurred_at >= $2 AND occurred_at < $3
+		ORDER BY occurred_at`
+
+	query = statement(ctx, "lib/pq", query)
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("PQ get user activity failed: %w", err)
+	}
+	defer rows.Close()
+
+	var activities []*models.UserActivity
+	for rows.Next() {
+		activity := &models.UserActivity{}
+		if err := rows.Scan(&activity.OccurredAt, &activity.UserID, &activity.Action, &activity.DurationMs); err != nil {
+			return nil, fmt.Errorf("PQ scan user activity failed: %w", err)
+		}
+		activities = append(activities, activity)
+	}
+
+	return activities, rows.Err()
+}
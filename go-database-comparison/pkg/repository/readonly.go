@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"gorm.io/gorm"
+)
+
+// errNestedTx is returned when a repository bound to a transaction by
+// WithReadOnlyTx begins another one, which lib/pq and sqlx cannot nest
+var errNestedTx = errors.New("the repository already runs in a transaction, see WithReadOnlyTx")
+
+// readOnlyTx are the options of the transactions of WithReadOnlyTx.
+// PostgreSQL rejects any write in them with SQLSTATE 25006,
+// read_only_sql_transaction.
+var readOnlyTx = &sql.TxOptions{ReadOnly: true}
+
+// pqTx runs the statements of a PQRepository in a transaction
+type pqTx struct {
+	*sql.Tx
+}
+
+// BeginTx implements pqExecutor, transactions do not nest
+func (pqTx) BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error) {
+	return nil, errNestedTx
+}
+
+// sqlxTx runs the statements of a SQLXRepository in a transaction
+type sqlxTx struct {
+	*sqlx.Tx
+}
+
+// BeginTxx implements sqlxExecutor, transactions do not nest
+func (sqlxTx) BeginTxx(context.Context, *sql.TxOptions) (*sqlx.Tx, error) {
+	return nil, errNestedTx
+}
+
+// WithReadOnlyTx runs fn with a repository bound to a read-only transaction
+// using lib/pq, committed when fn returns nil and rolled back otherwise
+func (r *PQRepository) WithReadOnlyTx(ctx context.Context, fn func(ctx context.Context, tx *PQRepository) error) (err error) {
+	tx, err := r.db.BeginTx(ctx, readOnlyTx)
+	if err != nil {
+		return fmt.Errorf("PQ begin read-only transaction failed: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(ctx, &PQRepository{db: pqTx{tx}, codec: r.codec}); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("PQ commit read-only transaction failed: %w", err)
+	}
+	return nil
+}
+
+// WithReadOnlyReads returns a repository running every read method in a
+// read-only transaction of its own, see WithReadOnlyTx
+func (r *PQRepository) WithReadOnlyReads() *PQRepository {
+	return &PQRepository{db: r.db, codec: r.codec, readOnlyReads: true}
+}
+
+// WithReadOnlyTx runs fn with a repository bound to a read-only transaction
+// using sqlx, committed when fn returns nil and rolled back otherwise
+func (r *SQLXRepository) WithReadOnlyTx(ctx context.Context, fn func(ctx context.Context, tx *SQLXRepository) error) (err error) {
+	tx, err := r.db.BeginTxx(ctx, readOnlyTx)
+	if err != nil {
+		return fmt.Errorf("SQLX begin read-only transaction failed: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(ctx, &SQLXRepository{db: sqlxTx{tx}, codec: r.codec}); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("SQLX commit read-only transaction failed: %w", err)
+	}
+	return nil
+}
+
+// WithReadOnlyReads returns a repository running every read method in a
+// read-only transaction of its own, see WithReadOnlyTx
+func (r *SQLXRepository) WithReadOnlyReads() *SQLXRepository {
+	return &SQLXRepository{db: r.db, codec: r.codec, readOnlyReads: true}
+}
+
+// WithReadOnlyTx runs fn with a repository bound to a read-only transaction
+// using GORM's Transaction, committed when fn returns nil and rolled back
+// otherwise. Transactions GORM begins inside become savepoints.
+func (r *GORMRepository) WithReadOnlyTx(ctx context.Context, fn func(ctx context.Context, tx *GORMRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(ctx, &GORMRepository{db: tx, codec: r.codec})
+	}, readOnlyTx)
+}
+
+// WithReadOnlyReads returns a repository running every read method in a
+// read-only transaction of its own, see WithReadOnlyTx
+func (r *GORMRepository) WithReadOnlyReads() *GORMRepository {
+	return &GORMRepository{db: r.db, codec: r.codec, readOnlyReads: true}
+}
+
+// inReadOnlyTx runs read in a read-only transaction begun by withTx and
+// returns what it read. The read methods of a repository created by
+// WithReadOnlyReads call themselves through it on the bound repository.
+func inReadOnlyTx[R, T any](ctx context.Context, withTx func(context.Context, func(context.Context, R) error) error, read func(ctx context.Context, tx R) (T, error)) (T, error) {
+	var result T
+	err := withTx(ctx, func(ctx context.Context, tx R) error {
+		var err error
+		result, err = read(ctx, tx)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,176 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/benchmark"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+)
+
+// readOnlySQLState is PostgreSQL's read_only_sql_transaction, raised for a
+// write in a read-only transaction
+const readOnlySQLState = "25006"
+
+// readOnlyConfig returns the settings of the test database for read-only
+// transactions, which the rolled back transaction refuses as savepoints:
+// the users are committed and removed by run ID instead
+func readOnlyConfig(t testing.TB) *database.DatabaseConfig {
+	t.Helper()
+	config := dbtest.Config(t)
+	config.Rollback = false
+	return config
+}
+
+// inReadOnlyTx runs fn with lib's repository bound to a read-only
+// transaction by WithReadOnlyTx, which each library types for its own
+// repository
+func inReadOnlyTx(t testing.TB, lib dbtest.Library, ctx context.Context, fn func(ctx context.Context, tx repository.UserRepository) error) error {
+	t.Helper()
+	switch repo := lib.Repo.(type) {
+	case *repository.PQRepository:
+		return repo.WithReadOnlyTx(ctx, func(ctx context.Context, tx *repository.PQRepository) error { return fn(ctx, tx) })
+	case *repository.SQLXRepository:
+		return repo.WithReadOnlyTx(ctx, func(ctx context.Context, tx *repository.SQLXRepository) error { return fn(ctx, tx) })
+	case *repository.GORMRepository:
+		return repo.WithReadOnlyTx(ctx, func(ctx context.Context, tx *repository.GORMRepository) error { return fn(ctx, tx) })
+	}
+	t.Fatalf("%s repository has no read-only transactions", lib.Name)
+	return nil
+}
+
+// readOnlyReads returns lib's repository created by WithReadOnlyReads
+func readOnlyReads(t testing.TB, lib dbtest.Library) repository.UserRepository {
+	t.Helper()
+	switch repo := lib.Repo.(type) {
+	case *repository.PQRepository:
+		return repo.WithReadOnlyReads()
+	case *repository.SQLXRepository:
+		return repo.WithReadOnlyReads()
+	case *repository.GORMRepository:
+		return repo.WithReadOnlyReads()
+	}
+	t.Fatalf("%s repository has no read-only reads", lib.Name)
+	return nil
+}
+
+// TestReadOnlyTx checks that every write of every library inside
+// WithReadOnlyTx is rejected with SQLSTATE 25006 and changes nothing,
+// while reads inside succeed, and that a repository created by
+// WithReadOnlyReads reads in such transactions but still writes
+func TestReadOnlyTx(t *testing.T) {
+	config := readOnlyConfig(t)
+
+	for _, lib := range dbtest.Libraries(t, config) {
+		t.Run(lib.Name, func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+			runID := benchdata.RunID(ctx)
+			request := func(n int64) *models.CreateUserRequest {
+				return &models.CreateUserRequest{
+					Name:  fmt.Sprintf("Read-Only %s %d", lib.Name, n),
+					Email: benchdata.Email(runID, "readonly", lib.Name, n),
+					Age:   30,
+				}
+			}
+			user, err := lib.Repo.CreateUser(ctx, request(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = inReadOnlyTx(t, lib, ctx, func(ctx context.Context, tx repository.UserRepository) error {
+				got, err := tx.GetUserByID(ctx, user.ID)
+				if err == nil && got.Email != user.Email {
+					err = fmt.Errorf("read %s, want %s", got.Email, user.Email)
+				}
+				return err
+			})
+			if err != nil {
+				t.Errorf("read in a read-only transaction: %v", err)
+			}
+
+			// Each write in a transaction of its own, a rejected statement
+			// aborts the transaction
+			renamed := "Renamed"
+			writes := []struct {
+				name  string
+				write func(ctx context.Context, tx repository.UserRepository) error
+			}{
+				{"CreateUser", func(ctx context.Context, tx repository.UserRepository) error {
+					_, err := tx.CreateUser(ctx, request(1))
+					return err
+				}},
+				{"UpdateUser", func(ctx context.Context, tx repository.UserRepository) error {
+					_, err := tx.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Name: &renamed})
+					return err
+				}},
+				{"DeleteUser", func(ctx context.Context, tx repository.UserRepository) error {
+					return tx.DeleteUser(ctx, user.ID)
+				}},
+			}
+			for _, w := range writes {
+				err := inReadOnlyTx(t, lib, ctx, w.write)
+				if state := benchmark.SQLState(err); state != readOnlySQLState {
+					t.Errorf("%s in a read-only transaction returned %v, want SQLSTATE %s", w.name, err, readOnlySQLState)
+				}
+			}
+
+			got, err := lib.Repo.GetUserByID(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("after the rejected writes: %v", err)
+			}
+			if got.Name != user.Name {
+				t.Errorf("after the rejected writes the user is named %q", got.Name)
+			}
+			if found, err := lib.Repo.GetUsersByEmail(ctx, request(1).Email); err != nil || len(found) != 0 {
+				t.Errorf("the rejected CreateUser left %d users (%v)", len(found), err)
+			}
+
+			reads := readOnlyReads(t, lib)
+			if got, err := reads.GetUserByID(ctx, user.ID); err != nil || got.Email != user.Email {
+				t.Errorf("read-only reads: %v", err)
+			}
+			if _, err := reads.CreateUser(ctx, request(2)); err != nil {
+				t.Errorf("a write beside read-only reads: %v", err)
+			}
+		})
+	}
+}
+
+// BenchmarkReadOnlyReads compares GetUserByID without a transaction with
+// the same read in a read-only transaction of its own, as a repository
+// created by WithReadOnlyReads runs it
+func BenchmarkReadOnlyReads(b *testing.B) {
+	config := readOnlyConfig(b)
+
+	for _, lib := range dbtest.Libraries(b, config) {
+		b.Run(lib.Name, func(b *testing.B) {
+			ctx := dbtest.Context(b, config)
+			email := benchdata.Email(benchdata.RunID(ctx), "readonly-bench", lib.Name, 1)
+			user, err := lib.Repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Read-Only Bench", Email: email, Age: 30})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for _, mode := range []struct {
+				name string
+				repo repository.UserRepository
+			}{
+				{"direct", lib.Repo},
+				{"read-only tx", readOnlyReads(b, lib)},
+			} {
+				b.Run(mode.name, func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						if _, err := mode.repo.GetUserByID(ctx, user.ID); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go-database-comparison/pkg/models"
+)
+
+// UserRepository is the user CRUD the three libraries implement with the
+// same signatures, so the benchmarks and checks drive them through it
+// rather than switching on the repository type. Methods only some
+// commands need, such as CountUsersByAgeBucket or WithConn, stay on the
+// concrete types and are reached through interfaces of their callers.
+type UserRepository interface {
+	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetAllUsers(ctx context.Context, limit, offset int) ([]*models.User, error)
+	UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, id int) error
+	GetUsersByEmail(ctx context.Context, emailPattern string) ([]*models.User, error)
+	CreateUserWithTransaction(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+	BatchCreateUsers(ctx context.Context, requests []*models.CreateUserRequest) ([]*models.User, error)
+}
+
+var (
+	_ UserRepository = (*PQRepository)(nil)
+	_ UserRepository = (*SQLXRepository)(nil)
+	_ UserRepository = (*GORMRepository)(nil)
+)
+
+// ErrNotFound is matched by errors.Is for every call on a user that does
+// not exist, or is inactive where the call only sees active users. When
+// the library reported it, sql.ErrNoRows or gorm.ErrRecordNotFound is
+// matched as well.
+var ErrNotFound = errors.New("user not found")
+
+// notFoundError is the error of a call on user ID that found no user,
+// detail saying which users it looked at
+type notFoundError struct {
+	id     int
+	detail string
+	cause  error // The library's no rows error, nil when none was returned
+}
+
+// notFound returns the ErrNotFound error of user id, detail completing
+// "not found" and cause being the library's error, if any
+func notFound(id int, detail string, cause error) error {
+	return &notFoundError{id: id, detail: detail, cause: cause}
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("user with ID %d not found%s", e.id, e.detail)
+}
+
+func (e *notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+func (e *notFoundError) Unwrap() error { return e.cause }
@@ -0,0 +1,7 @@
+package repository
+
+import "errors"
+
+// errNoCodec is returned for the secret column by a repository that was
+// given no codec
+var errNoCodec = errors.New("no codec for the secret column, see WithCodec")
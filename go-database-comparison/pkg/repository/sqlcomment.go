@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+
+	"go-database-comparison/pkg/querycount"
+	"go-database-comparison/pkg/sqlcomment"
+)
+
+// statement prepares query for execution by driver: it is counted,
+// recorded on the current span and tagged with the sqlcommenter comment
+// of ctx
+func statement(ctx context.Context, driver, query string) string {
+	querycount.Inc(ctx, query)
+	traceStatement(ctx, query)
+	return sqlcomment.Append(ctx, driver, query)
+}
+
+// gormCommentCallback swaps GORM's connection pool for one tagging every
+// statement, around the callback that executes it
+const gormCommentCallback = "repository:sql_comment"
+
+// registerGORMComments adds the sqlcommenter callbacks to db once. The
+// pool is restored before GORM looks for a transaction to commit on it;
+// GORM places After callbacks last, so that needs Before.
+func registerGORMComments(db *gorm.DB) {
+	callback := db.Callback()
+	if callback.Query().Get(gormCommentCallback) != nil {
+		return
+	}
+
+	wrap := func(tx *gorm.DB) {
+		if _, ok := sqlcomment.RequestID(tx.Statement.Context); !ok {
+			return
+		}
+		if _, wrapped := tx.Statement.ConnPool.(commentingPool); !wrapped {
+			tx.Statement.ConnPool = commentingPool{ConnPool: tx.Statement.ConnPool}
+		}
+	}
+	unwrap := func(tx *gorm.DB) {
+		if pool, ok := tx.Statement.ConnPool.(commentingPool); ok {
+			tx.Statement.ConnPool = pool.ConnPool
+		}
+	}
+
+	callback.Create().Before("gorm:create").Register(gormCommentCallback, wrap)
+	callback.Create().Before("gorm:commit_or_rollback_transaction").Register(gormCommentCallback+"_done", unwrap)
+	callback.Query().Before("gorm:query").Register(gormCommentCallback, wrap)
+	callback.Query().After("gorm:query").Register(gormCommentCallback+"_done", unwrap)
+	callback.Update().Before("gorm:update").Register(gormCommentCallback, wrap)
+	callback.Update().Before("gorm:commit_or_rollback_transaction").Register(gormCommentCallback+"_done", unwrap)
+	callback.Delete().Before("gorm:delete").Register(gormCommentCallback, wrap)
+	callback.Delete().Before("gorm:commit_or_rollback_transaction").Register(gormCommentCallback+"_done", unwrap)
+	callback.Row().Before("gorm:row").Register(gormCommentCallback, wrap)
+	callback.Row().After("gorm:row").Register(gormCommentCallback+"_done", unwrap)
+	callback.Raw().Before("gorm:raw").Register(gormCommentCallback, wrap)
+	callback.Raw().After("gorm:raw").Register(gormCommentCallback+"_done", unwrap)
+}
+
+// commentingPool appends the sqlcommenter comment of the statement's
+// context to everything GORM runs on the wrapped pool
+type commentingPool struct {
+	gorm.ConnPool
+}
+
+func (p commentingPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.ConnPool.PrepareContext(ctx, sqlcomment.Append(ctx, "gorm", query))
+}
+
+func (p commentingPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.ConnPool.ExecContext(ctx, sqlcomment.Append(ctx, "gorm", query), args...)
+}
+
+func (p commentingPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.ConnPool.QueryContext(ctx, sqlcomment.Append(ctx, "gorm", query), args...)
+}
+
+func (p commentingPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.ConnPool.QueryRowContext(ctx, sqlcomment.Append(ctx, "gorm", query), args...)
+}
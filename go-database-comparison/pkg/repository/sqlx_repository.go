@@ -8,11 +8,23 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/secret"
 )
 
+// sqlxExecutor is satisfied by both *sqlx.DB and a connection bound by WithConn
+type sqlxExecutor interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
 // SQLXRepository implements repository pattern using sqlx
 type SQLXRepository struct {
-	db *sqlx.DB
+	db    sqlxExecutor
+	codec secret.Codec // Seals the secret column, see WithCodec
+
+	readOnlyReads bool // Runs every read method in a read-only transaction, see WithReadOnlyReads
 }
 
 // NewSQLXRepository creates a new SQLX repository instance
@@ -20,8 +32,39 @@ func NewSQLXRepository(db *sqlx.DB) *SQLXRepository {
 	return &SQLXRepository{db: db}
 }
 
+// WithConn returns a repository that runs every statement on conn instead
+// of checking connections out of the pool. The caller owns conn.
+func (r *SQLXRepository) WithConn(conn *sqlx.Conn) *SQLXRepository {
+	return &SQLXRepository{db: boundConn{Conn: conn, binder: r.db}, codec: r.codec, readOnlyReads: r.readOnlyReads}
+}
+
+// WithCodec returns a repository sealing the secret column with codec
+func (r *SQLXRepository) WithCodec(codec secret.Codec) *SQLXRepository {
+	return &SQLXRepository{db: r.db, codec: codec, readOnlyReads: r.readOnlyReads}
+}
+
+// boundConn adds the named parameter binding of the originating DB to a
+// *sqlx.Conn, which lacks it
+type boundConn struct {
+	*sqlx.Conn
+	binder sqlxExecutor
+}
+
+// DriverName returns the driver name of the originating DB
+func (c boundConn) DriverName() string {
+	return c.binder.DriverName()
+}
+
+// BindNamed binds a named query using the originating DB's bind type
+func (c boundConn) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return c.binder.BindNamed(query, arg)
+}
+
 // CreateUser creates a new user using sqlx with struct mapping
-func (r *SQLXRepository) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+func (r *SQLXRepository) CreateUser(ctx context.Context, req *models.CreateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "SQLX", "CreateUser")
+	defer func() { endSpan(span, err) }()
+
 	// Same SQL as PQ for fair comparison
 	query := `
 		INSERT INTO users (name, email, age, created_at, updated_at, is_active)
@@ -39,7 +82,8 @@ func (r *SQLXRepository) CreateUser(ctx context.Context, req *models.CreateUserR
 	}
 
 	// Use NamedQuery for better parameter binding
-	rows, err := r.db.NamedQueryContext(ctx, query, params)
+	query = statement(ctx, "sqlx", query)
+	rows, err := sqlx.NamedQueryContext(ctx, r.db, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("SQLX create user failed: %w", err)
 	}
@@ -58,7 +102,16 @@ func (r *SQLXRepository) CreateUser(ctx context.Context, req *models.CreateUserR
 }
 
 // GetUserByID retrieves a user by ID using sqlx struct mapping
-func (r *SQLXRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+func (r *SQLXRepository) GetUserByID(ctx context.Context, id int) (_ *models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) (*models.User, error) {
+			return tx.GetUserByID(ctx, id)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "GetUserByID")
+	defer func() { endSpan(span, err) }()
+
 	// Same SQL as PQ for fair comparison
 	query := `
 		SELECT id, name, email, age, created_at, updated_at, is_active
@@ -66,10 +119,11 @@ func (r *SQLXRepository) GetUserByID(ctx context.Context, id int) (*models.User,
 		WHERE id = $1 AND is_active = true`
 
 	var user models.User
-	err := r.db.GetContext(ctx, &user, query, id)
+	query = statement(ctx, "sqlx", query)
+	err = r.db.GetContext(ctx, &user, query, id)
 	
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user with ID %d not found", id)
+		return nil, notFound(id, "", err)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("SQLX get user failed: %w", err)
@@ -79,7 +133,16 @@ func (r *SQLXRepository) GetUserByID(ctx context.Context, id int) (*models.User,
 }
 
 // GetAllUsers retrieves all active users using sqlx Select
-func (r *SQLXRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+func (r *SQLXRepository) GetAllUsers(ctx context.Context, limit, offset int) (_ []*models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) ([]*models.User, error) {
+			return tx.GetAllUsers(ctx, limit, offset)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "GetAllUsers")
+	defer func() { endSpan(span, err) }()
+
 	// Same SQL as PQ for fair comparison
 	query := `
 		SELECT id, name, email, age, created_at, updated_at, is_active
@@ -89,7 +152,8 @@ func (r *SQLXRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]
 		LIMIT $1 OFFSET $2`
 
 	var users []models.User
-	err := r.db.SelectContext(ctx, &users, query, limit, offset)
+	query = statement(ctx, "sqlx", query)
+	err = r.db.SelectContext(ctx, &users, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("SQLX get all users failed: %w", err)
 	}
@@ -104,73 +168,38 @@ func (r *SQLXRepository) GetAllUsers(ctx context.Context, limit, offset int) ([]
 }
 
 // UpdateUser updates a user using sqlx with dynamic query building
-func (r *SQLXRepository) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
-	// Dynamic query building for partial updates (same logic as PQ)
-	setParts := []string{"updated_at = :updated_at"}
-	params := map[string]interface{}{
-		"updated_at": time.Now(),
-		"id":         id,
-	}
+func (r *SQLXRepository) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "SQLX", "UpdateUser")
+	defer func() { endSpan(span, err) }()
 
-	if req.Name != nil {
-		setParts = append(setParts, "name = :name")
-		params["name"] = *req.Name
-	}
-	if req.Email != nil {
-		setParts = append(setParts, "email = :email")
-		params["email"] = *req.Email
-	}
-	if req.Age != nil {
-		setParts = append(setParts, "age = :age")
-		params["age"] = *req.Age
-	}
-	if req.IsActive != nil {
-		setParts = append(setParts, "is_active = :is_active")
-		params["is_active"] = *req.IsActive
-	}
+	// Dynamic query building for partial updates, shared with PQ
+	query, args := buildUserUpdate(id, req, time.Now())
 
-	// Build SET clause
-	setClause := ""
-	for i, part := range setParts {
-		if i > 0 {
-			setClause += ", "
-		}
-		setClause += part
+	var user models.User
+	query = statement(ctx, "sqlx", query)
+	err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&user)
+	if err == sql.ErrNoRows {
+		return nil, notFound(id, " or inactive", err)
 	}
-
-	query := fmt.Sprintf(`
-		UPDATE users
-		SET %s
-		WHERE id = :id AND is_active = true
-		RETURNING id, name, email, age, created_at, updated_at, is_active`,
-		setClause)
-
-	rows, err := r.db.NamedQueryContext(ctx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("SQLX update user failed: %w", err)
 	}
-	defer rows.Close()
-
-	if !rows.Next() {
-		return nil, fmt.Errorf("user with ID %d not found or inactive", id)
-	}
-
-	var user models.User
-	if err := rows.StructScan(&user); err != nil {
-		return nil, fmt.Errorf("SQLX update scan failed: %w", err)
-	}
 
 	return &user, nil
 }
 
 // DeleteUser performs soft delete using sqlx
-func (r *SQLXRepository) DeleteUser(ctx context.Context, id int) error {
+func (r *SQLXRepository) DeleteUser(ctx context.Context, id int) (err error) {
+	ctx, span := startSpan(ctx, "SQLX", "DeleteUser")
+	defer func() { endSpan(span, err) }()
+
 	// Same SQL as PQ for fair comparison
 	query := `
 		UPDATE users
 		SET is_active = false, updated_at = $1
 		WHERE id = $2 AND is_active = true`
 
+	query = statement(ctx, "sqlx", query)
 	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("SQLX delete user failed: %w", err)
@@ -182,14 +211,23 @@ func (r *SQLXRepository) DeleteUser(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user with ID %d not found or already deleted", id)
+		return notFound(id, " or already deleted", nil)
 	}
 
 	return nil
 }
 
-// GetUsersByEmail searches users by email pattern using sqlx
-func (r *SQLXRepository) GetUsersByEmail(ctx context.Context, emailPattern string) ([]*models.User, error) {
+// GetUsersByEmail searches users whose email matches the ILIKE pattern %emailPattern% using sqlx
+func (r *SQLXRepository) GetUsersByEmail(ctx context.Context, emailPattern string) (_ []*models.User, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) ([]*models.User, error) {
+			return tx.GetUsersByEmail(ctx, emailPattern)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "GetUsersByEmail")
+	defer func() { endSpan(span, err) }()
+
 	// Same SQL as PQ for fair comparison
 	query := `
 		SELECT id, name, email, age, created_at, updated_at, is_active
@@ -198,7 +236,8 @@ func (r *SQLXRepository) GetUsersByEmail(ctx context.Context, emailPattern strin
 		ORDER BY created_at DESC`
 
 	var users []models.User
-	err := r.db.SelectContext(ctx, &users, query, "%"+emailPattern+"%")
+	query = statement(ctx, "sqlx", query)
+	err = r.db.SelectContext(ctx, &users, query, containsPattern(emailPattern))
 	if err != nil {
 		return nil, fmt.Errorf("SQLX search users by email failed: %w", err)
 	}
@@ -213,7 +252,10 @@ func (r *SQLXRepository) GetUsersByEmail(ctx context.Context, emailPattern strin
 }
 
 // CreateUserWithTransaction demonstrates transaction handling with sqlx
-func (r *SQLXRepository) CreateUserWithTransaction(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+func (r *SQLXRepository) CreateUserWithTransaction(ctx context.Context, req *models.CreateUserRequest) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, "SQLX", "CreateUserWithTransaction")
+	defer func() { endSpan(span, err) }()
+
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("SQLX begin transaction failed: %w", err)
@@ -231,6 +273,7 @@ func (r *SQLXRepository) CreateUserWithTransaction(ctx context.Context, req *mod
 	// Check if email already exists (same logic as PQ)
 	var exists bool
 	checkQuery := "SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND is_active = true)"
+	checkQuery = statement(ctx, "sqlx", checkQuery)
 	err = tx.GetContext(ctx, &exists, checkQuery, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("SQLX check email existence failed: %w", err)
@@ -256,7 +299,8 @@ func (r *SQLXRepository) CreateUserWithTransaction(ctx context.Context, req *mod
 		"is_active":  true,
 	}
 
-	rows, err := tx.NamedQuery(insertQuery, params)
+	insertQuery = statement(ctx, "sqlx", insertQuery)
+	rows, err := sqlx.NamedQueryContext(ctx, tx, insertQuery, params)
 	if err != nil {
 		return nil, fmt.Errorf("SQLX create user in transaction failed: %w", err)
 	}
@@ -279,7 +323,10 @@ func (r *SQLXRepository) CreateUserWithTransaction(ctx context.Context, req *mod
 }
 
 // BatchCreateUsers demonstrates batch operations with sqlx
-func (r *SQLXRepository) BatchCreateUsers(ctx context.Context, users []*models.CreateUserRequest) ([]*models.User, error) {
+func (r *SQLXRepository) BatchCreateUsers(ctx context.Context, users []*models.CreateUserRequest) (_ []*models.User, err error) {
+	ctx, span := startSpan(ctx, "SQLX", "BatchCreateUsers")
+	defer func() { endSpan(span, err) }()
+
 	if len(users) == 0 {
 		return []*models.User{}, nil
 	}
@@ -298,9 +345,12 @@ func (r *SQLXRepository) BatchCreateUsers(ctx context.Context, users []*models.C
 		}
 	}()
 
+	// sqlx expands the VALUES tuple once per element of params; RETURNING
+	// hands back the rows as persisted, with their IDs
 	query := `
 		INSERT INTO users (name, email, age, created_at, updated_at, is_active)
-		VALUES (:name, :email, :age, :created_at, :updated_at, :is_active)`
+		VALUES (:name, :email, :age, :created_at, :updated_at, :is_active)
+		RETURNING id, name, email, age, created_at, updated_at, is_active`
 
 	now := time.Now()
 	params := make([]map[string]interface{}, len(users))
@@ -315,28 +365,329 @@ func (r *SQLXRepository) BatchCreateUsers(ctx context.Context, users []*models.C
 		}
 	}
 
-	// Use NamedExec for batch insert
-	_, err = tx.NamedExec(query, params)
+	query = statement(ctx, "sqlx", query)
+	rows, err := sqlx.NamedQueryContext(ctx, tx, query, params)
 	if err != nil {
 		return nil, fmt.Errorf("SQLX batch insert failed: %w", err)
 	}
+	defer rows.Close()
+
+	// PostgreSQL returns the rows of a multi-row VALUES in its order, which
+	// GORM relies on as well to fill in the IDs of a batch
+	result := make([]*models.User, 0, len(users))
+	for rows.Next() {
+		var user models.User
+		if err = rows.StructScan(&user); err != nil {
+			return nil, fmt.Errorf("SQLX batch scan failed: %w", err)
+		}
+		result = append(result, &user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLX batch insert failed: %w", err)
+	}
+	rows.Close()
+	if len(result) != len(users) {
+		err = fmt.Errorf("SQLX batch insert returned %d rows for %d users", len(result), len(users))
+		return nil, err
+	}
 
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("SQLX batch commit failed: %w", err)
 	}
 
-	// Return the created users (simplified - in production, you'd want to return actual IDs)
-	result := make([]*models.User, len(users))
-	for i, user := range users {
-		result[i] = &models.User{
-			Name:      user.Name,
-			Email:     user.Email,
-			Age:       user.Age,
-			CreatedAt: now,
-			UpdatedAt: now,
-			IsActive:  true,
+	return result, nil
+}
+
+// TouchUsersInOrder demonstrates lock ordering with sqlx, see
+// PQRepository.TouchUsersInOrder
+func (r *SQLXRepository) TouchUsersInOrder(ctx context.Context, ids []int, hold time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "SQLX", "TouchUsersInOrder")
+	defer func() { endSpan(span, err) }()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SQLX begin transaction failed: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	query := statement(ctx, "sqlx", "UPDATE users SET updated_at = $1 WHERE id = $2")
+	for i, id := range ids {
+		if i > 0 {
+			if err = holdLocks(ctx, hold); err != nil {
+				return err
+			}
+		}
+		if _, err = tx.ExecContext(ctx, query, time.Now(), id); err != nil {
+			return fmt.Errorf("SQLX update user %d failed: %w", id, err)
 		}
 	}
 
-	return result, nil
-}
\ No newline at end of file
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("SQLX commit transaction failed: %w", err)
+	}
+	return nil
+}
+
+// SetUserSecret seals plaintext with the codec of the repository and stores it using sqlx
+func (r *SQLXRepository) SetUserSecret(ctx context.Context, id int, plaintext string) (err error) {
+	ctx, span := startSpan(ctx, "SQLX", "SetUserSecret")
+	defer func() { endSpan(span, err) }()
+
+	if r.codec == nil {
+		return errNoCodec
+	}
+	sealed, err := r.codec.Seal(ctx, plaintext)
+	if err != nil {
+		return fmt.Errorf("SQLX seal secret failed: %w", err)
+	}
+
+	// Same SQL as PQ for fair comparison
+	query := `
+		UPDATE users
+		SET secret = $1, updated_at = $2
+		WHERE id = $3 AND is_active = true`
+
+	query = statement(ctx, "sqlx", query)
+	result, err := r.db.ExecContext(ctx, query, sealed, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("SQLX set secret failed: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("SQLX get rows affected failed: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound(id, " or inactive", nil)
+	}
+
+	return nil
+}
+
+// GetUserSecret reads the secret of a user and opens it with the codec of the repository using sqlx.
+// A user without a secret has "".
+func (r *SQLXRepository) GetUserSecret(ctx context.Context, id int) (_ string, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) (string, error) {
+			return tx.GetUserSecret(ctx, id)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "GetUserSecret")
+	defer func() { endSpan(span, err) }()
+
+	if r.codec == nil {
+		return "", errNoCodec
+	}
+
+	// Same SQL as PQ for fair comparison
+	query := `
+		SELECT secret
+		FROM users
+		WHERE id = $1 AND is_active = true`
+
+	var sealed []byte
+	query = statement(ctx, "sqlx", query)
+	err = r.db.GetContext(ctx, &sealed, query, id)
+
+	if err == sql.ErrNoRows {
+		return "", notFound(id, "", err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("SQLX get secret failed: %w", err)
+	}
+	if sealed == nil {
+		return "", nil
+	}
+
+	plaintext, err := r.codec.Open(ctx, sealed)
+	if err != nil {
+		return "", fmt.Errorf("SQLX open secret failed: %w", err)
+	}
+	return plaintext, nil
+}
+
+// CreateUserWithDomain creates a user and reads back the email domain PostgreSQL generated for it using sqlx.
+// The INSERT must not name email_domain, PostgreSQL rejects any value for it.
+func (r *SQLXRepository) CreateUserWithDomain(ctx context.Context, req *models.CreateUserRequest) (_ *models.UserWithDomain, err error) {
+	ctx, span := startSpan(ctx, "SQLX", "CreateUserWithDomain")
+	defer func() { endSpan(span, err) }()
+
+	// Same SQL as PQ for fair comparison
+	query := `
+		INSERT INTO users (name, email, age, created_at, updated_at, is_active)
+		VALUES (:name, :email, :age, :created_at, :updated_at, :is_active)
+		RETURNING id, name, email, age, created_at, updated_at, is_active, email_domain`
+
+	now := time.Now()
+	params := map[string]interface{}{
+		"name":       req.Name,
+		"email":      req.Email,
+		"age":        req.Age,
+		"created_at": now,
+		"updated_at": now,
+		"is_active":  true,
+	}
+
+	query = statement(ctx, "sqlx", query)
+	rows, err := sqlx.NamedQueryContext(ctx, r.db, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("SQLX create user failed: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, fmt.Errorf("SQLX create user: no rows returned")
+	}
+
+	// StructScan fills the embedded User and EmailDomain by their db tags
+	var user models.UserWithDomain
+	if err := rows.StructScan(&user); err != nil {
+		return nil, fmt.Errorf("SQLX struct scan failed: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetUsersByEmailDomain retrieves the active users of an email domain through the generated column using sqlx
+func (r *SQLXRepository) GetUsersByEmailDomain(ctx context.Context, domain string) (_ []*models.UserWithDomain, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) ([]*models.UserWithDomain, error) {
+			return tx.GetUsersByEmailDomain(ctx, domain)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "GetUsersByEmailDomain")
+	defer func() { endSpan(span, err) }()
+
+	// Same SQL as PQ for fair comparison
+	query := `
+		SELECT id, name, email, age, created_at, updated_at, is_active, email_domain
+		FROM users
+		WHERE email_domain = lower($1) AND is_active = true
+		ORDER BY id`
+
+	var users []*models.UserWithDomain
+	query = statement(ctx, "sqlx", query)
+	if err := r.db.SelectContext(ctx, &users, query, domain); err != nil {
+		return nil, fmt.Errorf("SQLX get users by email domain failed: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetUsersRankedByAgeInDomain ranks the active users of an email domain by age with window functions using sqlx
+func (r *SQLXRepository) GetUsersRankedByAgeInDomain(ctx context.Context, domain string) (_ []*models.RankedUser, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) ([]*models.RankedUser, error) {
+			return tx.GetUsersRankedByAgeInDomain(ctx, domain)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "GetUsersRankedByAgeInDomain")
+	defer func() { endSpan(span, err) }()
+
+	// Same SQL as PQ for fair comparison
+	query := `
+		SELECT id, name, email, age, created_at, updated_at, is_active, email_domain,
+			RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_rank,
+			DENSE_RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_dense_rank,
+			PERCENT_RANK() OVER (PARTITION BY email_domain ORDER BY age DESC) AS age_percentile,
+			COUNT(*) OVER (PARTITION BY email_domain) AS domain_users,
+			AVG(age) OVER (PARTITION BY email_domain)::float8 AS domain_avg_age
+		FROM users
+		WHERE email_domain = lower($1) AND is_active = true
+		ORDER BY age_rank, id`
+
+	// Select maps the window columns by their aliases through the db tags
+	var users []*models.RankedUser
+	query = statement(ctx, "sqlx", query)
+	if err := r.db.SelectContext(ctx, &users, query, domain); err != nil {
+		return nil, fmt.Errorf("SQLX get users ranked by age failed: %w", err)
+	}
+
+	return users, nil
+}
+
+// CountUsersByAgeBucket aggregates the active users by ten-year age bucket using sqlx
+func (r *SQLXRepository) CountUsersByAgeBucket(ctx context.Context) (_ []*models.AgeBucket, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) ([]*models.AgeBucket, error) {
+			return tx.CountUsersByAgeBucket(ctx)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "CountUsersByAgeBucket")
+	defer func() { endSpan(span, err) }()
+
+	// Same SQL as PQ for fair comparison
+	query := `
+		SELECT age / 10 * 10 AS min_age, age / 10 * 10 + 9 AS max_age, COUNT(*) AS users,
+			AVG(age)::float8 AS avg_age, MIN(created_at) AS first_created_at, MAX(created_at) AS last_created_at
+		FROM users
+		WHERE is_active = true AND age IS NOT NULL
+		GROUP BY age / 10
+		ORDER BY min_age`
+
+	// Select maps the aggregates by their aliases through the db tags
+	var buckets []*models.AgeBucket
+	query = statement(ctx, "sqlx", query)
+	if err := r.db.SelectContext(ctx, &buckets, query); err != nil {
+		return nil, fmt.Errorf("SQLX count users by age bucket failed: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// CreateUserActivities appends activities to the user_activity log in one multi-row INSERT using sqlx
+func (r *SQLXRepository) CreateUserActivities(ctx context.Context, activities []*models.UserActivity) (err error) {
+	ctx, span := startSpan(ctx, "SQLX", "CreateUserActivities")
+	defer func() { endSpan(span, err) }()
+
+	if len(activities) == 0 {
+		return nil
+	}
+
+	// sqlx expands the VALUES tuple once per element, binding the fields by their db tags
+	query := `
+		INSERT INTO user_activity (occurred_at, user_id, action, duration_ms)
+		VALUES (:occurred_at, :user_id, :action, :duration_ms)`
+
+	query = statement(ctx, "sqlx", query)
+	if _, err := sqlx.NamedExecContext(ctx, r.db, query, activities); err != nil {
+		return fmt.Errorf("SQLX create user activities failed: %w", err)
+	}
+	return nil
+}
+
+// GetUserActivityInRange retrieves the activity of a user in [from, to), oldest first, using sqlx
+func (r *SQLXRepository) GetUserActivityInRange(ctx context.Context, userID int, from, to time.Time) (_ []*models.UserActivity, err error) {
+	if r.readOnlyReads {
+		return inReadOnlyTx(ctx, r.WithReadOnlyTx, func(ctx context.Context, tx *SQLXRepository) ([]*models.UserActivity, error) {
+			return tx.GetUserActivityInRange(ctx, userID, from, to)
+		})
+	}
+
+	ctx, span := startSpan(ctx, "SQLX", "GetUserActivityInRange")
+	defer func() { endSpan(span, err) }()
+
+	// Same SQL as PQ for fair comparison
+	query := `
+		SELECT occurred_at, user_id, action, duration_ms
+		FROM user_activity
+		WHERE user_id = $1 AND occurred_at >= $2 AND occurred_at < $3
+		ORDER BY occurred_at`
+
+	var activities []*models.UserActivity
+	query = statement(ctx, "sqlx", query)
+	if err := r.db.SelectContext(ctx, &activities, query, userID, from, to); err != nil {
+		return nil, fmt.Errorf("SQLX get user activity failed: %w", err)
+	}
+
+	return activities, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"go-database-comparison/pkg/querycount"
+)
+
+// instrumentationName identifies the repositories' tracer
+const instrumentationName = "go-database-comparison/pkg/repository"
+
+// tracer records repository spans with the global provider, so spans are
+// only exported once a command installs one
+var tracer = otel.Tracer(instrumentationName)
+
+// startSpan starts the span of a repository method, named like "PQ CreateUser"
+func startSpan(ctx context.Context, library, method string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, library+" "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", method),
+			attribute.String("db.client.library", library),
+		))
+}
+
+// endSpan records err, if any, and ends span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceStatement attaches query to the span in ctx. Methods running
+// several statements keep the last one as db.statement and add each as
+// an event, so the order is visible too.
+func traceStatement(ctx context.Context, query string) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	statement := attribute.String("db.statement", compactSQL(query))
+	span.SetAttributes(statement)
+	span.AddEvent("statement", trace.WithAttributes(statement))
+}
+
+// compactSQL collapses the indentation of multi-line queries
+func compactSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// gormStatementCallback is registered after every GORM processor to count
+// the statement GORM executed and put it on the repository span
+const gormStatementCallback = "repository:trace_statement"
+
+// registerGORMTracing adds the statement callbacks to db once
+func registerGORMTracing(db *gorm.DB) {
+	callback := db.Callback()
+	if callback.Query().Get(gormStatementCallback) != nil {
+		return
+	}
+
+	record := func(tx *gorm.DB) {
+		if tx.Statement.SQL.Len() > 0 {
+			if !tx.DryRun {
+				querycount.Inc(tx.Statement.Context, tx.Statement.SQL.String())
+			}
+			traceStatement(tx.Statement.Context, tx.Statement.SQL.String())
+		}
+	}
+	callback.Create().After("gorm:create").Register(gormStatementCallback, record)
+	callback.Query().After("gorm:query").Register(gormStatementCallback, record)
+	callback.Update().After("gorm:update").Register(gormStatementCallback, record)
+	callback.Delete().After("gorm:delete").Register(gormStatementCallback, record)
+	callback.Row().After("gorm:row").Register(gormStatementCallback, record)
+	callback.Raw().After("gorm:raw").Register(gormStatementCallback, record)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go-database-comparison/pkg/models"
+)
+
+// userColumns is what the raw SQL repositories return for a user
+const userColumns = "id, name, email, age, created_at, updated_at, is_active"
+
+// updateBuilder builds an UPDATE statement with positional placeholders.
+// Each placeholder is numbered when its value is added, so the SET list,
+// the WHERE clause and the arguments cannot disagree on a count.
+type updateBuilder struct {
+	table string
+	sets  []string
+	where []string
+	args  []interface{}
+}
+
+func newUpdateBuilder(table string) *updateBuilder {
+	return &updateBuilder{table: table}
+}
+
+// Set assigns value to column
+func (b *updateBuilder) Set(column string, value interface{}) *updateBuilder {
+	b.sets = append(b.sets, column+" = "+b.placeholder(value))
+	return b
+}
+
+// Where restricts the update to rows whose column equals value
+func (b *updateBuilder) Where(column string, value interface{}) *updateBuilder {
+	b.where = append(b.where, column+" = "+b.placeholder(value))
+	return b
+}
+
+// WhereRaw restricts the update by a condition without arguments
+func (b *updateBuilder) WhereRaw(condition string) *updateBuilder {
+	b.where = append(b.where, condition)
+	return b
+}
+
+// Build returns the statement, returning the given columns unless empty,
+// and its arguments in placeholder order
+func (b *updateBuilder) Build(returning string) (string, []interface{}) {
+	var query strings.Builder
+	query.WriteString("UPDATE ")
+	query.WriteString(b.table)
+	query.WriteString(" SET ")
+	query.WriteString(strings.Join(b.sets, ", "))
+	if len(b.where) > 0 {
+		query.WriteString(" WHERE ")
+		query.WriteString(strings.Join(b.where, " AND "))
+	}
+	if returning != "" {
+		query.WriteString(" RETURNING ")
+		query.WriteString(returning)
+	}
+	return query.String(), b.args
+}
+
+func (b *updateBuilder) placeholder(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// buildUserUpdate returns the partial update of an active user the raw SQL
+// repositories run for req: updated_at always, and every field req sets
+func buildUserUpdate(id int, req *models.UpdateUserRequest, now time.Time) (string, []interface{}) {
+	b := newUpdateBuilder("users").Set("updated_at", now)
+	if req.Name != nil {
+		b.Set("name", *req.Name)
+	}
+	if req.Email != nil {
+		b.Set("email", *req.Email)
+	}
+	if req.Age != nil {
+		b.Set("age", *req.Age)
+	}
+	if req.IsActive != nil {
+		b.Set("is_active", *req.IsActive)
+	}
+	return b.Where("id", id).WhereRaw("is_active = true").Build(userColumns)
+}
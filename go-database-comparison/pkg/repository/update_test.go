@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-database-comparison/pkg/models"
+)
+
+// updateColumns are the columns buildUserUpdate may set
+var updateColumns = map[string]bool{"updated_at": true, "name": true, "email": true, "age": true, "is_active": true}
+
+var (
+	updateShape = regexp.MustCompile(`^UPDATE users SET (.+) WHERE (.+) RETURNING ` + regexp.QuoteMeta(userColumns) + `$`)
+	assignment  = regexp.MustCompile(`^([a-z_]+) = \$([0-9]+)$`)
+	placeholder = regexp.MustCompile(`\$[0-9]+`)
+)
+
+// FuzzBuildUpdate checks that every partial update has one argument per
+// placeholder, numbered in order, and only sets allowlisted columns however
+// the request's values look
+func FuzzBuildUpdate(f *testing.F) {
+	f.Add(1, true, "Alice", true, "alice@example.com", true, 30, true, false)
+	f.Add(0, false, "", false, "", false, 0, false, false)
+	f.Add(-7, true, "$1; DROP TABLE users; --", false, "", true, -1, false, true)
+	f.Add(42, false, "", true, "x' OR '1'='1", false, 151, true, true)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.Fuzz(func(t *testing.T, id int, setName bool, name string, setEmail bool, email string, setAge bool, age int, setActive, active bool) {
+		req := &models.UpdateUserRequest{}
+		want := []interface{}{now}
+		if setName {
+			req.Name = &name
+			want = append(want, name)
+		}
+		if setEmail {
+			req.Email = &email
+			want = append(want, email)
+		}
+		if setAge {
+			req.Age = &age
+			want = append(want, age)
+		}
+		if setActive {
+			req.IsActive = &active
+			want = append(want, active)
+		}
+		want = append(want, id)
+
+		query, args := buildUserUpdate(id, req, now)
+
+		if n := len(placeholder.FindAllString(query, -1)); n != len(args) {
+			t.Fatalf("%d placeholders, %d args: %s", n, len(args), query)
+		}
+		if len(args) != len(want) {
+			t.Fatalf("got %d args, want %d: %v", len(args), len(want), args)
+		}
+		for i := range want {
+			if args[i] != want[i] {
+				t.Fatalf("arg $%d is %v, want %v", i+1, args[i], want[i])
+			}
+		}
+
+		shape := updateShape.FindStringSubmatch(query)
+		if shape == nil {
+			t.Fatalf("unexpected statement: %s", query)
+		}
+		next := 1
+		seen := map[string]bool{}
+		for _, set := range strings.Split(shape[1], ", ") {
+			column := checkAssignment(t, set, &next)
+			if !updateColumns[column] {
+				t.Fatalf("sets column %q outside the allowlist: %s", column, query)
+			}
+			if seen[column] {
+				t.Fatalf("sets column %q twice: %s", column, query)
+			}
+			seen[column] = true
+		}
+		conditions := strings.Split(shape[2], " AND ")
+		if len(conditions) != 2 || conditions[1] != "is_active = true" {
+			t.Fatalf("unexpected WHERE clause: %s", shape[2])
+		}
+		if column := checkAssignment(t, conditions[0], &next); column != "id" {
+			t.Fatalf("filters on %q instead of id: %s", column, query)
+		}
+	})
+}
+
+// checkAssignment returns the column of "column = $n", failing unless n is
+// the next placeholder number
+func checkAssignment(t *testing.T, s string, next *int) string {
+	t.Helper()
+	m := assignment.FindStringSubmatch(s)
+	if m == nil {
+		t.Fatalf("not a column = placeholder pair: %q", s)
+	}
+	if n, _ := strconv.Atoi(m[2]); n != *next {
+		t.Fatalf("placeholder $%s out of order, want $%d", m[2], *next)
+	}
+	*next++
+	return m[1]
+}
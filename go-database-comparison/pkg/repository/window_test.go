@@ -0,0 +1,265 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/sqlcapture"
+)
+
+// rankedRepository is a repository ranking users with window functions
+type rankedRepository interface {
+	GetUsersRankedByAgeInDomain(ctx context.Context, domain string) ([]*models.RankedUser, error)
+}
+
+// rankedConfig returns the settings of the test database for the ranking
+// tests, skipping t unless migration 0008 added email_domain. Every
+// library has to see the same users, so they are committed and removed by
+// run ID instead of living in one library's rolled back transaction.
+func rankedConfig(t testing.TB) (*database.DatabaseConfig, *sql.DB) {
+	t.Helper()
+	config := dbtest.Config(t)
+	config.Rollback = false
+	observer := dbtest.Observer(t, config)
+	requireEmailDomain(t, observer)
+	return config, observer
+}
+
+// ranked returns the ranking repository of lib
+func ranked(t testing.TB, lib dbtest.Library) rankedRepository {
+	t.Helper()
+	repo, ok := lib.Repo.(rankedRepository)
+	if !ok {
+		t.Fatalf("%s repository does not rank users", lib.Name)
+	}
+	return repo
+}
+
+// createRankedUsers creates n users in the domain of the run in one
+// statement and returns the domain. Ages repeat every 60 users so ranks
+// tie, and every tenth user is inactive.
+func createRankedUsers(t testing.TB, ctx context.Context, db *sql.DB, n int) string {
+	t.Helper()
+	runID := benchdata.RunID(ctx)
+	names := make([]string, n)
+	emails := make([]string, n)
+	ages := make([]int64, n)
+	active := make([]bool, n)
+	for i := range n {
+		names[i] = fmt.Sprintf("Ranked %d", i)
+		emails[i] = benchdata.Email(runID, "ranked", "fixture", int64(i))
+		ages[i] = int64(18 + (i*7)%60)
+		active[i] = i%10 != 9
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO users (name, email, age, is_active)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::int[], $4::bool[])`,
+		pq.Array(names), pq.Array(emails), pq.Array(ages), pq.Array(active))
+	if err != nil {
+		t.Fatalf("failed to create the users: %v", err)
+	}
+	_, domain, _ := strings.Cut(emails[0], "@")
+	return domain
+}
+
+// expectedRanking reads the active users of domain without window
+// functions and ranks them in Go, in the order the query returns them
+func expectedRanking(t testing.TB, ctx context.Context, db *sql.DB, domain string) []*models.RankedUser {
+	t.Helper()
+	rows, err := db.QueryContext(ctx, "SELECT id, age FROM users WHERE email_domain = lower($1) AND is_active", domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var users []*models.RankedUser
+	for rows.Next() {
+		user := &models.RankedUser{}
+		if err := rows.Scan(&user.ID, &user.Age); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Oldest first, then by ID, as ORDER BY age_rank, id
+	slices.SortFunc(users, func(a, b *models.RankedUser) int {
+		if a.Age != b.Age {
+			return b.Age - a.Age
+		}
+		return a.ID - b.ID
+	})
+	total := 0
+	for _, user := range users {
+		total += user.Age
+	}
+	for i, user := range users {
+		switch {
+		case i == 0:
+			user.AgeRank, user.AgeDenseRank = 1, 1
+		case user.Age == users[i-1].Age:
+			user.AgeRank, user.AgeDenseRank = users[i-1].AgeRank, users[i-1].AgeDenseRank
+		default:
+			user.AgeRank, user.AgeDenseRank = i+1, users[i-1].AgeDenseRank+1
+		}
+		if len(users) > 1 {
+			user.AgePercentile = float64(user.AgeRank-1) / float64(len(users)-1)
+		}
+		user.DomainUsers = len(users)
+		user.DomainAvgAge = float64(total) / float64(len(users))
+	}
+	return users
+}
+
+// rankDifference names the first window column of got that differs from
+// want, the average up to rounding
+func rankDifference(got, want *models.RankedUser) string {
+	switch {
+	case got.ID != want.ID:
+		return fmt.Sprintf("ID %d where %d was expected", got.ID, want.ID)
+	case got.AgeRank != want.AgeRank:
+		return fmt.Sprintf("age_rank %d, expected %d", got.AgeRank, want.AgeRank)
+	case got.AgeDenseRank != want.AgeDenseRank:
+		return fmt.Sprintf("age_dense_rank %d, expected %d", got.AgeDenseRank, want.AgeDenseRank)
+	case got.AgePercentile != want.AgePercentile:
+		return fmt.Sprintf("age_percentile %v, expected %v", got.AgePercentile, want.AgePercentile)
+	case got.DomainUsers != want.DomainUsers:
+		return fmt.Sprintf("domain_users %d, expected %d", got.DomainUsers, want.DomainUsers)
+	case math.Abs(got.DomainAvgAge-want.DomainAvgAge) > 1e-9:
+		return fmt.Sprintf("domain_avg_age %v, expected %v", got.DomainAvgAge, want.DomainAvgAge)
+	}
+	return ""
+}
+
+// rowDifference names the first column in which a differs from b
+func rowDifference(a, b *models.RankedUser) string {
+	columns := []struct {
+		name  string
+		equal bool
+	}{
+		{"id", a.ID == b.ID},
+		{"name", a.Name == b.Name},
+		{"email", a.Email == b.Email},
+		{"age", a.Age == b.Age},
+		{"created_at", a.CreatedAt.Equal(b.CreatedAt)},
+		{"updated_at", a.UpdatedAt.Equal(b.UpdatedAt)},
+		{"is_active", a.IsActive == b.IsActive},
+		{"email_domain", a.EmailDomain == b.EmailDomain},
+		{"age_rank", a.AgeRank == b.AgeRank},
+		{"age_dense_rank", a.AgeDenseRank == b.AgeDenseRank},
+		{"age_percentile", a.AgePercentile == b.AgePercentile},
+		{"domain_users", a.DomainUsers == b.DomainUsers},
+		{"domain_avg_age", a.DomainAvgAge == b.DomainAvgAge},
+	}
+	for _, column := range columns {
+		if !column.equal {
+			return column.name
+		}
+	}
+	return ""
+}
+
+// TestRankedByAgeInDomain checks that GetUsersRankedByAgeInDomain of every
+// library returns every active user of the domain with the ranks,
+// percentiles, count and average computed in Go from the users' ages, and
+// that every library's rows equal the first library's column by column
+func TestRankedByAgeInDomain(t *testing.T) {
+	config, observer := rankedConfig(t)
+	ctx := dbtest.Context(t, config)
+	domain := createRankedUsers(t, ctx, observer, 200)
+	expected := expectedRanking(t, ctx, observer, domain)
+
+	libs := dbtest.Libraries(t, config)
+	var reference []*models.RankedUser
+	for _, lib := range libs {
+		t.Run(lib.Name, func(t *testing.T) {
+			users, err := ranked(t, lib).GetUsersRankedByAgeInDomain(ctx, domain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(users) != len(expected) {
+				t.Fatalf("ranked %d users, want the %d active ones", len(users), len(expected))
+			}
+			for i := range users {
+				if difference := rankDifference(users[i], expected[i]); difference != "" {
+					t.Fatalf("row %d (ID %d): %s", i+1, users[i].ID, difference)
+				}
+			}
+
+			if reference == nil {
+				reference = users
+				return
+			}
+			for i := range users {
+				if column := rowDifference(users[i], reference[i]); column != "" {
+					t.Fatalf("row %d (ID %d): %s differs from %s", i+1, users[i].ID, column, libs[0].Name)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRankedMapping compares GetUsersRankedByAgeInDomain over a wide
+// result set with the statement it sends run through the same *sql.DB,
+// its rows read but not scanned. The difference is what mapping the rows
+// into models.RankedUser costs each library.
+func BenchmarkRankedMapping(b *testing.B) {
+	config, observer := rankedConfig(b)
+	ctx := dbtest.Context(b, config)
+	domain := createRankedUsers(b, ctx, observer, 1000)
+
+	captureConfig := *config
+	captureConfig.QueryLog = sqlcapture.Sink()
+	for _, name := range dbtest.Names {
+		b.Run(name, func(b *testing.B) {
+			// The statement the library sends, with its arguments
+			recorded, recorder := sqlcapture.WithRecorder(ctx)
+			if _, err := ranked(b, dbtest.Open(b, name, &captureConfig)).GetUsersRankedByAgeInDomain(recorded, domain); err != nil {
+				b.Fatal(err)
+			}
+			queries := recorder.Queries()
+			if len(queries) != 1 {
+				b.Fatalf("the ranking took %d statements", len(queries))
+			}
+			query := queries[0]
+
+			// Timed without the capturing hooks
+			lib := dbtest.Open(b, name, config)
+			repo := ranked(b, lib)
+			b.Run("fetch", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					rows, err := lib.DB.QueryContext(ctx, query.SQL, query.Args...)
+					if err != nil {
+						b.Fatal(err)
+					}
+					for rows.Next() {
+					}
+					rows.Close()
+					if err := rows.Err(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("mapped", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := repo.GetUsersRankedByAgeInDomain(ctx, domain); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package resultsink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-database-comparison/pkg/benchmark"
+)
+
+// Measurement is the InfluxDB measurement results are written to
+const Measurement = "dbcompare_benchmark"
+
+// InfluxSink writes runs in InfluxDB line protocol, one point per library
+// and operation stamped with the start of the run
+type InfluxSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewInfluxSink creates a sink posting to writeURL, the full write endpoint
+// including its query: /api/v2/write?org=...&bucket=... for InfluxDB 2 or
+// /write?db=... for InfluxDB 1. token is sent as an InfluxDB API token when
+// not empty.
+func NewInfluxSink(writeURL, token string) *InfluxSink {
+	return &InfluxSink{url: writeURL, token: token, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Name implements Sink
+func (s *InfluxSink) Name() string { return "influx" }
+
+// Write implements Sink. InfluxDB accepts or rejects a batch as a whole.
+func (s *InfluxSink) Write(ctx context.Context, run Run) error {
+	var body bytes.Buffer
+	for _, result := range run.File.Results {
+		writeLine(&body, run, result)
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to build InfluxDB request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("InfluxDB write failed with %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// writeLine appends the point of result to b
+func writeLine(b *bytes.Buffer, run Run, result benchmark.BenchmarkResult) {
+	env := run.File.Environment
+	b.WriteString(Measurement)
+	writeTag(b, "library", result.Library)
+	writeTag(b, "operation", result.Operation)
+	writeTag(b, "db_host", env.DBHost)
+	writeTag(b, "server_version", env.ServerVersion)
+	writeTag(b, "version", env.Build.Version)
+
+	fmt.Fprintf(b, " avg_ns=%di,median_ns=%di,p95_ns=%di,p99_ns=%di,min_ns=%di,max_ns=%di",
+		int64(result.AvgTime), int64(result.MedianTime), int64(result.P95Time), int64(result.P99Time),
+		int64(result.MinTime), int64(result.MaxTime))
+	fmt.Fprintf(b, ",ops_per_sec=%s,success_rate=%s,iterations=%di,error_count=%di",
+		strconv.FormatFloat(result.OpsPerSec, 'f', -1, 64), strconv.FormatFloat(result.SuccessRate, 'f', -1, 64),
+		result.Iterations, result.ErrorCount)
+	fmt.Fprintf(b, `,run_id="%s"`, fieldEscaper.Replace(run.File.RunID))
+	fmt.Fprintf(b, " %d\n", run.StartedAt.UnixNano())
+}
+
+// tagEscaper escapes the characters line protocol gives a meaning in tags
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// fieldEscaper escapes the characters line protocol gives a meaning in
+// string fields
+var fieldEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+
+// writeTag appends a tag to b, or nothing when value is empty since line
+// protocol has no empty tag values
+func writeTag(b *bytes.Buffer, key, value string) {
+	if value == "" {
+		return
+	}
+	b.WriteString(",")
+	b.WriteString(key)
+	b.WriteString("=")
+	b.WriteString(tagEscaper.Replace(value))
+}
@@ -0,0 +1,108 @@
+package resultsink
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// PostgresSink stores runs in the benchmark_runs and benchmark_results
+// tables, one row per run and one per library and operation
+type PostgresSink struct {
+	db *sql.DB
+}
+
+// NewPostgresSink creates a sink writing to db. Call EnsureSchema once
+// before use.
+func NewPostgresSink(db *sql.DB) *PostgresSink {
+	return &PostgresSink{db: db}
+}
+
+// Name implements Sink
+func (s *PostgresSink) Name() string { return "postgres" }
+
+// EnsureSchema creates the result tables if they do not exist
+func (s *PostgresSink) EnsureSchema(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS benchmark_runs (
+			run_id      TEXT PRIMARY KEY,
+			started_at  TIMESTAMPTZ NOT NULL,
+			duration_ms BIGINT NOT NULL,
+			version     TEXT NOT NULL,
+			environment JSONB NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS benchmark_results (
+			id           BIGSERIAL PRIMARY KEY,
+			run_id       TEXT NOT NULL REFERENCES benchmark_runs (run_id) ON DELETE CASCADE,
+			library      TEXT NOT NULL,
+			operation    TEXT NOT NULL,
+			iterations   INTEGER NOT NULL,
+			avg_ns       BIGINT NOT NULL,
+			median_ns    BIGINT NOT NULL,
+			p95_ns       BIGINT NOT NULL,
+			p99_ns       BIGINT NOT NULL,
+			ops_per_sec  DOUBLE PRECISION NOT NULL,
+			success_rate DOUBLE PRECISION NOT NULL,
+			error_count  INTEGER NOT NULL,
+			result       JSONB NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_benchmark_results_series
+			ON benchmark_results (library, operation, run_id)`
+
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create result tables: %w", err)
+	}
+	return nil
+}
+
+// Write implements Sink, storing the run and its results in one transaction
+func (s *PostgresSink) Write(ctx context.Context, run Run) error {
+	environment, err := json.Marshal(run.File.Environment)
+	if err != nil {
+		return fmt.Errorf("failed to encode environment: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO benchmark_runs (run_id, started_at, duration_ms, version, environment)
+		VALUES ($1, $2, $3, $4, $5)`,
+		run.File.RunID, run.StartedAt, run.Duration.Milliseconds(), run.File.Environment.Build.Version, environment)
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO benchmark_results (
+			run_id, library, operation, iterations, avg_ns, median_ns, p95_ns, p99_ns,
+			ops_per_sec, success_rate, error_count, result
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare result insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, result := range run.File.Results {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode result: %w", err)
+		}
+		_, err = stmt.ExecContext(ctx,
+			run.File.RunID, result.Library, result.Operation, result.Iterations,
+			int64(result.AvgTime), int64(result.MedianTime), int64(result.P95Time), int64(result.P99Time),
+			result.OpsPerSec, result.SuccessRate, result.ErrorCount, encoded)
+		if err != nil {
+			return fmt.Errorf("failed to insert %s %s result: %w", result.Library, result.Operation, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit run: %w", err)
+	}
+	return nil
+}
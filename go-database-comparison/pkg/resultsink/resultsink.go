@@ -0,0 +1,42 @@
+// Package resultsink stores the results of benchmark runs outside the
+// results files, so the performance of a database instance can be tracked
+// over many runs: in a PostgreSQL table to query with SQL, or in InfluxDB
+// to chart with Grafana and the like.
+package resultsink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-database-comparison/pkg/benchmark"
+)
+
+// Run is one finished benchmark run
+type Run struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	File      benchmark.ResultsFile
+}
+
+// Sink stores benchmark runs
+type Sink interface {
+	// Name identifies the sink in logs and status output
+	Name() string
+	// Write stores run, all of it or nothing where the sink allows
+	Write(ctx context.Context, run Run) error
+}
+
+// Names lists the sinks the daemon command can write to
+var Names = []string{"postgres", "influx"}
+
+// ValidateName reports whether name is one of Names
+func ValidateName(name string) error {
+	for _, known := range Names {
+		if name == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sink %q (expected %s)", name, strings.Join(Names, ", "))
+}
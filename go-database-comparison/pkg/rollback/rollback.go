@@ -0,0 +1,168 @@
+// Package rollback runs everything a library does against PostgreSQL
+// inside one transaction that is rolled back when the connection closes,
+// so checks against a shared database stay isolated from each other and
+// leave no data behind.
+//
+// Connector wraps a driver connector: every connection begins a
+// transaction as soon as it is opened and rolls it back when closed. The
+// transactions the library itself begins, such as CreateUserWithTransaction
+// or GORM's implicit ones, become savepoints inside it, so their commits
+// and rollbacks behave as usual while nothing outlives the connection. A
+// statement failing outside of them aborts the outer transaction and every
+// statement after it fails too, so a check expecting a database error has
+// to run it in a transaction. The pool using Connector must keep a single
+// connection open for the whole run, see database.DatabaseConfig.Rollback.
+package rollback
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// Connector wraps c so that all work on its connections is rolled back
+// when they close. Use it with sql.OpenDB.
+func Connector(c driver.Connector) driver.Connector {
+	return &connector{Connector: c}
+}
+
+type connector struct {
+	driver.Connector
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	inner, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := &conn{Conn: inner}
+	if err := conn.exec(ctx, "BEGIN"); err != nil {
+		inner.Close()
+		return nil, fmt.Errorf("failed to begin the rolled back transaction: %w", err)
+	}
+	return conn, nil
+}
+
+// conn runs its statements in the outer transaction begun by Connect. It
+// only offers the context variants, database/sql converts the legacy
+// calls.
+type conn struct {
+	driver.Conn
+	savepoints int // Savepoints begun so far, naming the next one
+}
+
+// exec runs a statement without arguments on the wrapped connection
+func (c *conn) exec(ctx context.Context, query string) error {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		return err
+	}
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx begins a savepoint in place of a transaction. A savepoint keeps
+// the isolation level and access mode of the outer transaction, so asking
+// for others is an error rather than silently ignored.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if sql.IsolationLevel(opts.Isolation) != sql.LevelDefault {
+		return nil, fmt.Errorf("rollback: cannot run a transaction at %v inside the rolled back one", sql.IsolationLevel(opts.Isolation))
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("rollback: cannot run a read-only transaction inside the rolled back one")
+	}
+	c.savepoints++
+	tx := &savepoint{conn: c, name: fmt.Sprintf("rollback_%d", c.savepoints)}
+	if err := c.exec(ctx, "SAVEPOINT "+tx.name); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// Close rolls back everything done on the connection and closes it
+func (c *conn) Close() error {
+	// The server rolls back on disconnect anyway, when this fails too
+	c.exec(context.Background(), "ROLLBACK")
+	return c.Conn.Close()
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue lets drivers like pgx accept the argument types they
+// support natively
+func (c *conn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// savepoint is a transaction begun inside the rolled back one
+type savepoint struct {
+	conn *conn
+	name string
+}
+
+func (s *savepoint) Commit() error {
+	return s.conn.exec(context.Background(), "RELEASE SAVEPOINT "+s.name)
+}
+
+func (s *savepoint) Rollback() error {
+	if err := s.conn.exec(context.Background(), "ROLLBACK TO SAVEPOINT "+s.name); err != nil {
+		return err
+	}
+	return s.conn.exec(context.Background(), "RELEASE SAVEPOINT "+s.name)
+}
@@ -0,0 +1,131 @@
+package rollback_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/models"
+)
+
+// countEmail returns how many users db sees with email
+func countEmail(t *testing.T, ctx context.Context, db *sql.DB, email string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM users WHERE email = $1", email).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// TestRolledBackOnClose runs the CRUD of every library in the rolled back
+// transaction: each step sees its own writes, other connections see none
+// of them, and nothing is left once the connection is closed
+func TestRolledBackOnClose(t *testing.T) {
+	config := dbtest.Config(t)
+	ctx := dbtest.Context(t, config)
+	observer := dbtest.Observer(t, config)
+
+	for _, lib := range dbtest.Libraries(t, config) {
+		t.Run(lib.Name, func(t *testing.T) {
+			email := benchdata.Email(benchdata.RunID(ctx), "rollback", lib.Name, 1)
+			user, err := lib.Repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Rollback", Email: email, Age: 30})
+			if err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+			name := "Renamed"
+			if _, err := lib.Repo.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Name: &name}); err != nil {
+				t.Fatalf("UpdateUser: %v", err)
+			}
+			got, err := lib.Repo.GetUserByID(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			if got.Name != name {
+				t.Fatalf("read name %q inside the transaction, want %q", got.Name, name)
+			}
+
+			if n := countEmail(t, ctx, observer, email); n != 0 {
+				t.Fatalf("another connection sees %d uncommitted users", n)
+			}
+			lib.DB.Close()
+			if n := countEmail(t, ctx, observer, email); n != 0 {
+				t.Fatalf("%d users left after the connection closed", n)
+			}
+		})
+	}
+}
+
+// TestSavepoints checks that the transactions a library begins become
+// savepoints which commit and roll back as usual
+func TestSavepoints(t *testing.T) {
+	config := dbtest.Config(t)
+	ctx := dbtest.Context(t, config)
+	db := dbtest.Open(t, "PQ", config).DB
+	runID := benchdata.RunID(ctx)
+
+	insert := func(tx *sql.Tx, email string) {
+		t.Helper()
+		if _, err := tx.ExecContext(ctx, "INSERT INTO users (name, email, age) VALUES ($1, $2, $3)", "Savepoint", email, 30); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	committed := benchdata.Email(runID, "savepoint", "pq", 1)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert(tx, committed)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	rolledBack := benchdata.Email(runID, "savepoint", "pq", 2)
+	if tx, err = db.BeginTx(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	insert(tx, rolledBack)
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing statement in a savepoint leaves the outer transaction usable
+	if tx, err = db.BeginTx(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO users (name, email, age) VALUES ($1, $2, $3)", "Duplicate", committed, 30); err == nil {
+		t.Fatal("inserting a duplicate email succeeded")
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := countEmail(t, ctx, db, committed); n != 1 {
+		t.Errorf("committed savepoint: %d users, want 1", n)
+	}
+	if n := countEmail(t, ctx, db, rolledBack); n != 0 {
+		t.Errorf("rolled back savepoint: %d users, want 0", n)
+	}
+}
+
+// TestTxOptionsRefused checks that a transaction asking for an isolation
+// level or access mode a savepoint cannot have fails instead of running
+// with the outer transaction's
+func TestTxOptionsRefused(t *testing.T) {
+	config := dbtest.Config(t)
+	db := dbtest.Open(t, "PQ", config).DB
+
+	for _, opts := range []*sql.TxOptions{
+		{Isolation: sql.LevelSerializable},
+		{ReadOnly: true},
+	} {
+		tx, err := db.BeginTx(context.Background(), opts)
+		if err == nil {
+			tx.Rollback()
+			t.Errorf("BeginTx(%+v) succeeded inside the rolled back transaction", *opts)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package secret encrypts the users.secret column in the application with
+// AES-256-GCM before any library sends it, so the plaintext and the key
+// never reach the server. Encrypting in SQL with pgcrypto's
+// pgp_sym_encrypt would put the key in every statement, and with it in the
+// server log that docker-compose.yml writes with log_statement=all.
+//
+// A sealed value starts with the ID of the key that sealed it, so keys can
+// be rotated: new values use the current key of the KeyProvider while old
+// ones stay readable as long as the provider still knows their key.
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KeySize is the size of the AES-256 keys
+const KeySize = 32
+
+// version is the first byte of every sealed value
+const version = 1
+
+// additionalData binds sealed values to the column they are stored in
+var additionalData = []byte("users.secret")
+
+// ErrUnknownKey is returned by a KeyProvider for an ID it has no key for
+var ErrUnknownKey = errors.New("unknown key")
+
+// KeyProvider supplies the keys values are sealed and opened with
+type KeyProvider interface {
+	// CurrentKey returns the key new values are sealed with and its ID
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	// Key returns the key with id, wrapping ErrUnknownKey when there is none
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// Codec turns the plaintext of the secret column into the bytes stored and
+// back. The repositories use one to handle the column the same way.
+type Codec interface {
+	Seal(ctx context.Context, plaintext string) ([]byte, error)
+	Open(ctx context.Context, sealed []byte) (string, error)
+}
+
+// StaticKeys is a KeyProvider holding its keys in memory
+type StaticKeys struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeys returns a provider sealing with the key current of keys
+func NewStaticKeys(current string, keys map[string][]byte) (*StaticKeys, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key %q: %w", current, ErrUnknownKey)
+	}
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("key ID %q must have 1 to 255 bytes", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q has %d bytes, expected %d", id, len(key), KeySize)
+		}
+		copied[id] = append([]byte(nil), key...)
+	}
+	return &StaticKeys{current: current, keys: copied}, nil
+}
+
+// ParseKeys parses keys given as id=base64,id=base64,... into a provider
+// sealing with the first one
+func ParseKeys(spec string) (*StaticKeys, error) {
+	keys := make(map[string][]byte)
+	current := ""
+	for _, entry := range strings.Split(spec, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("key %q: expected id=base64", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if _, duplicate := keys[id]; duplicate {
+			return nil, fmt.Errorf("key %q is given twice", id)
+		}
+		keys[id] = key
+		if current == "" {
+			current = id
+		}
+	}
+	return NewStaticKeys(current, keys)
+}
+
+// RandomKeys returns a provider with a fresh random key for each of ids,
+// sealing with the first one. The keys die with the process.
+func RandomKeys(ids ...string) (*StaticKeys, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one key ID is required")
+	}
+	keys := make(map[string][]byte, len(ids))
+	for _, id := range ids {
+		key := make([]byte, KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		keys[id] = key
+	}
+	return NewStaticKeys(ids[0], keys)
+}
+
+// Rotate makes id the key new values are sealed with
+func (s *StaticKeys) Rotate(id string) error {
+	if _, ok := s.keys[id]; !ok {
+		return fmt.Errorf("key %q: %w", id, ErrUnknownKey)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = id
+	return nil
+}
+
+// CurrentKey returns the key new values are sealed with
+func (s *StaticKeys) CurrentKey(context.Context) (string, []byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current, s.keys[s.current], nil
+}
+
+// Key returns the key with id
+func (s *StaticKeys) Key(_ context.Context, id string) ([]byte, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", id, ErrUnknownKey)
+	}
+	return key, nil
+}
+
+// Cipher seals values with AES-256-GCM under the keys of a KeyProvider.
+// A sealed value is the version byte, the length and ID of the key, the
+// nonce and the ciphertext with its tag.
+type Cipher struct {
+	keys KeyProvider
+}
+
+// NewCipher returns a Cipher using the keys of keys
+func NewCipher(keys KeyProvider) *Cipher {
+	return &Cipher{keys: keys}
+}
+
+// Seal encrypts plaintext with the current key
+func (c *Cipher) Seal(ctx context.Context, plaintext string) ([]byte, error) {
+	id, key, err := c.keys.CurrentKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the current key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 2+len(id)+aead.NonceSize())
+	header = append(header, version, byte(len(id)))
+	header = append(header, id...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header = append(header, nonce...)
+	return aead.Seal(header, nonce, []byte(plaintext), additionalData), nil
+}
+
+// Open decrypts a value sealed by Seal, with the key it names
+func (c *Cipher) Open(ctx context.Context, sealed []byte) (string, error) {
+	id, err := KeyID(sealed)
+	if err != nil {
+		return "", err
+	}
+	key, err := c.keys.Key(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	rest := sealed[2+len(id):]
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return "", fmt.Errorf("sealed value is truncated")
+	}
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("failed to open value sealed with key %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// KeyID returns the ID of the key sealed was sealed with
+func KeyID(sealed []byte) (string, error) {
+	if len(sealed) < 2 || sealed[0] != version || len(sealed) < 2+int(sealed[1]) {
+		return "", fmt.Errorf("sealed value has an unknown format")
+	}
+	return string(sealed[2 : 2+int(sealed[1])]), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Plaintext is a Codec storing values unencrypted. It is the baseline the
+// encryption overhead is measured against, not for real secrets.
+type Plaintext struct{}
+
+// Seal returns plaintext as is
+func (Plaintext) Seal(_ context.Context, plaintext string) ([]byte, error) {
+	return []byte(plaintext), nil
+}
+
+// Open returns sealed as is
+func (Plaintext) Open(_ context.Context, sealed []byte) (string, error) {
+	return string(sealed), nil
+}
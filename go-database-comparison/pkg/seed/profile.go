@@ -0,0 +1,100 @@
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"go-database-comparison/pkg/models"
+)
+
+// Profile selects the shape of generated rows
+type Profile string
+
+const (
+	// ProfileUniform spreads ages and timestamps evenly, with every user active
+	ProfileUniform Profile = "uniform"
+	// ProfileRealistic draws common names, ages around 38, more recent
+	// sign-ups than old ones and about 8% inactive users
+	ProfileRealistic Profile = "realistic"
+)
+
+// Profiles lists the supported profiles
+var Profiles = []Profile{ProfileUniform, ProfileRealistic}
+
+// ParseProfile converts a user-supplied profile name into a Profile
+func ParseProfile(s string) (Profile, error) {
+	for _, p := range Profiles {
+		if strings.EqualFold(s, string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown profile %q (expected uniform or realistic)", s)
+}
+
+// epoch anchors generated timestamps, so they do not depend on when a
+// dataset is built
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// history is how far before epoch generated users sign up
+const history = 3 * 365 * 24 * time.Hour
+
+var firstNames = []string{
+	"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda",
+	"David", "Elizabeth", "William", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+	"Thomas", "Sarah", "Daniel", "Karen", "Hiroshi", "Yuki", "Kenji", "Sakura",
+	"Wei", "Mei", "Carlos", "Sofia", "Ahmed", "Fatima", "Ivan", "Olga",
+}
+
+var lastNames = []string{
+	"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+	"Rodriguez", "Martinez", "Wilson", "Anderson", "Taylor", "Thomas", "Moore", "Jackson",
+	"Sato", "Suzuki", "Takahashi", "Tanaka", "Wang", "Li", "Zhang", "Kim",
+	"Lee", "Silva", "Santos", "Muller", "Schmidt", "Ivanov", "Khan", "Nguyen",
+}
+
+// Row returns row n of the dataset with seed and profile. The row has no ID,
+// the database assigns it on insert.
+func Row(seed uint64, profile Profile, n int) models.User {
+	r := rand.New(rand.NewPCG(seed, uint64(n)))
+	domain := Domain(seed, profile)
+
+	if profile == ProfileUniform {
+		created := epoch.Add(-time.Duration(r.Int64N(int64(history))))
+		return models.User{
+			Name:      fmt.Sprintf("User %d", n),
+			Email:     fmt.Sprintf("user%d@%s", n, domain),
+			Age:       18 + r.IntN(63),
+			CreatedAt: created,
+			UpdatedAt: created,
+			IsActive:  true,
+		}
+	}
+
+	first := firstNames[r.IntN(len(firstNames))]
+	last := lastNames[r.IntN(len(lastNames))]
+	age := int(math.Round(r.NormFloat64()*12 + 38))
+	age = max(18, min(age, 90))
+
+	// The square root skews sign-ups towards epoch, like a growing service
+	created := epoch.Add(-time.Duration((1 - math.Sqrt(r.Float64())) * float64(history)))
+	updated := created
+	if r.Float64() < 0.6 {
+		// Most users were edited since, typically within a few weeks
+		updated = created.Add(time.Duration(r.ExpFloat64() * float64(21*24*time.Hour)))
+		if updated.After(epoch) {
+			updated = epoch
+		}
+	}
+
+	return models.User{
+		Name:      first + " " + last,
+		Email:     fmt.Sprintf("%s.%s.%d@%s", strings.ToLower(first), strings.ToLower(last), n, domain),
+		Age:       age,
+		CreatedAt: created,
+		UpdatedAt: updated,
+		IsActive:  r.Float64() >= 0.08,
+	}
+}
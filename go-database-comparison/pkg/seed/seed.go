@@ -0,0 +1,138 @@
+// Package seed fills the users table with large, reproducible datasets for
+// read benchmarks. Row n of a dataset depends only on the dataset's seed,
+// profile and n, so reruns produce the same rows and an interrupted run
+// resumes where it stopped.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config describes a dataset and how to insert it
+type Config struct {
+	Rows      int     // Size of the dataset
+	Seed      uint64  // Seed of the row generator
+	Profile   Profile // Shape of the generated rows
+	BatchSize int     // Rows per INSERT statement, 1000 when 0
+
+	// Progress is called after each batch when set
+	Progress func(Progress)
+}
+
+// Progress reports how far a run got
+type Progress struct {
+	Done    int           // Rows of the dataset present, including resumed ones
+	Total   int           // Rows of the dataset
+	Elapsed time.Duration // Time spent by this run
+	Rate    float64       // Rows inserted per second by this run
+}
+
+// Result summarizes a run
+type Result struct {
+	Existing int           `json:"existing"` // Rows already present when the run started
+	Inserted int           `json:"inserted"` // Rows inserted by the run
+	Duration time.Duration `json:"duration"`
+}
+
+// maxBatchSize keeps a batch's parameters below PostgreSQL's limit of 65535
+const maxBatchSize = 65535 / columnCount
+
+// columnCount is the number of users columns a seeded row sets
+const columnCount = 6
+
+// Domain returns the email domain marking the rows of a dataset, which is
+// how existing rows are found when resuming or resetting
+func Domain(seed uint64, profile Profile) string {
+	return fmt.Sprintf("%s-%d.seed.test", profile, seed)
+}
+
+// Count returns the number of rows of the dataset present in db
+func Count(ctx context.Context, db *sql.DB, seed uint64, profile Profile) (int, error) {
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email LIKE $1",
+		"%@"+Domain(seed, profile)).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count seeded rows: %w", err)
+	}
+	return n, nil
+}
+
+// Reset deletes the rows of the dataset and returns how many were deleted
+func Reset(ctx context.Context, db *sql.DB, seed uint64, profile Profile) (int64, error) {
+	result, err := db.ExecContext(ctx, "DELETE FROM users WHERE email LIKE $1",
+		"%@"+Domain(seed, profile))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete seeded rows: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Run inserts the rows of the dataset that are not present yet. Batches
+// commit on their own, so a cancelled run keeps what it inserted and the
+// next run starts after the rows already present. Rows whose email exists
+// are skipped, making overlapping runs harmless.
+func Run(ctx context.Context, db *sql.DB, config Config) (Result, error) {
+	if config.Rows < 0 {
+		return Result{}, fmt.Errorf("rows must not be negative, got %d", config.Rows)
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	if batchSize > maxBatchSize {
+		return Result{}, fmt.Errorf("batch size must be at most %d, got %d", maxBatchSize, batchSize)
+	}
+
+	existing, err := Count(ctx, db, config.Seed, config.Profile)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Existing: existing}
+
+	start := time.Now()
+	for next := existing; next < config.Rows; next += batchSize {
+		end := min(next+batchSize, config.Rows)
+		if err := insertBatch(ctx, db, config, next, end); err != nil {
+			result.Duration = time.Since(start)
+			return result, fmt.Errorf("failed to insert rows %d to %d: %w", next, end-1, err)
+		}
+		result.Inserted += end - next
+
+		if config.Progress != nil {
+			elapsed := time.Since(start)
+			config.Progress(Progress{
+				Done:    end,
+				Total:   config.Rows,
+				Elapsed: elapsed,
+				Rate:    float64(result.Inserted) / elapsed.Seconds(),
+			})
+		}
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// insertBatch inserts rows from to end-1 of the dataset in one statement
+func insertBatch(ctx context.Context, db *sql.DB, config Config, from, end int) error {
+	var query strings.Builder
+	query.WriteString("INSERT INTO users (name, email, age, created_at, updated_at, is_active) VALUES ")
+	args := make([]interface{}, 0, (end-from)*columnCount)
+	for n := from; n < end; n++ {
+		if n > from {
+			query.WriteString(", ")
+		}
+		p := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d)", p+1, p+2, p+3, p+4, p+5, p+6)
+
+		user := Row(config.Seed, config.Profile, n)
+		args = append(args, user.Name, user.Email, user.Age, user.CreatedAt, user.UpdatedAt, user.IsActive)
+	}
+	query.WriteString(" ON CONFLICT (email) DO NOTHING")
+
+	_, err := db.ExecContext(ctx, query.String(), args...)
+	return err
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+
+	"go-database-comparison/pkg/repository"
+)
+
+// errorKind groups repository errors by the status both APIs answer with
+type errorKind int
+
+const (
+	errorInternal    errorKind = iota
+	errorNotFound              // The user does not exist or is inactive
+	errorConflict              // A unique constraint, the email, was violated
+	errorTimeout               // The request's deadline passed
+	errorUnavailable           // The database could not be reached
+)
+
+// uniqueViolation is PostgreSQL's SQLSTATE for a unique constraint violation
+const uniqueViolation = "23505"
+
+// classifyError returns the kind of a repository error. lib/pq errors
+// surface as *pq.Error while GORM's pgx driver returns *pgconn.PgError,
+// so both are checked.
+func classifyError(err error) errorKind {
+	var netErr net.Error
+	switch state := sqlState(err); {
+	case errors.Is(err, repository.ErrNotFound):
+		return errorNotFound
+	case state == uniqueViolation:
+		return errorConflict
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return errorTimeout
+	case strings.HasPrefix(state, "08"), isConnectionError(err):
+		return errorUnavailable
+	}
+	return errorInternal
+}
+
+// sqlState returns the SQLSTATE of err, or "" when it has none
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// isConnectionError reports whether err says the connection to the
+// database failed or was lost
+func isConnectionError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var opErr *net.OpError
+	return errors.As(err, &connectErr) || errors.As(err, &opErr) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/userpb"
+)
+
+// LibraryMetadataKey selects the library of a gRPC call and reports the
+// one used in the response header, like LibraryHeader does over HTTP
+const LibraryMetadataKey = "x-db-library"
+
+// RequestIDMetadataKey carries a request ID tagging the call's statements
+const RequestIDMetadataKey = "x-request-id"
+
+// repositoryKey carries the repository a gRPC call selected
+type repositoryKey struct{}
+
+// NewGRPCServer returns a gRPC server serving UserService over the
+// repositories of s, with server reflection for tools like grpcurl
+func (s *Server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(s.selectRepository))
+	grpcServer := grpc.NewServer(opts...)
+	userpb.RegisterUserServiceServer(grpcServer, &userService{})
+	reflection.Register(grpcServer)
+	return grpcServer
+}
+
+// selectRepository resolves the library of a call, bounds its context and
+// tags its statements before calling the handler, then logs the call
+func (s *Server) selectRepository(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	library, repo, err := s.repository(firstValue(md, LibraryMetadataKey))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(LibraryMetadataKey, library))
+
+	ctx, cancel := s.requestContext(ctx, firstValue(md, RequestIDMetadataKey))
+	defer cancel()
+	resp, err := handler(context.WithValue(ctx, repositoryKey{}, repo), req)
+
+	s.logger.Debug("call", "method", info.FullMethod, "library", library,
+		"code", status.Code(err).String(), "duration", time.Since(start))
+	return resp, err
+}
+
+// firstValue returns the first value of key in md, or ""
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// userService implements userpb.UserServiceServer with the repository
+// selectRepository put on the call's context
+type userService struct {
+	userpb.UnimplementedUserServiceServer
+}
+
+func (*userService) repo(ctx context.Context) repository.UserRepository {
+	return ctx.Value(repositoryKey{}).(repository.UserRepository)
+}
+
+func (u *userService) CreateUser(ctx context.Context, req *userpb.CreateUserRequest) (*userpb.User, error) {
+	create := &models.CreateUserRequest{Name: req.GetName(), Email: req.GetEmail(), Age: int(req.GetAge())}
+	if err := validateCreate(create); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	user, err := u.repo(ctx).CreateUser(ctx, create)
+	if err != nil {
+		return nil, repositoryStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (u *userService) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.User, error) {
+	user, err := u.repo(ctx).GetUserByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, repositoryStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (u *userService) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = 10
+	}
+	if limit < 0 || req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	users, err := u.repo(ctx).GetAllUsers(ctx, limit, int(req.GetOffset()))
+	if err != nil {
+		return nil, repositoryStatus(err)
+	}
+	return toProtoList(users), nil
+}
+
+func (u *userService) SearchUsers(ctx context.Context, req *userpb.SearchUsersRequest) (*userpb.ListUsersResponse, error) {
+	users, err := u.repo(ctx).GetUsersByEmail(ctx, req.GetEmailPattern())
+	if err != nil {
+		return nil, repositoryStatus(err)
+	}
+	return toProtoList(users), nil
+}
+
+func (u *userService) UpdateUser(ctx context.Context, req *userpb.UpdateUserRequest) (*userpb.User, error) {
+	update := &models.UpdateUserRequest{Name: req.Name, Email: req.Email, IsActive: req.IsActive}
+	if req.Age != nil {
+		age := int(req.GetAge())
+		update.Age = &age
+	}
+	if err := validateUpdate(update); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	user, err := u.repo(ctx).UpdateUser(ctx, int(req.GetId()), update)
+	if err != nil {
+		return nil, repositoryStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (u *userService) DeleteUser(ctx context.Context, req *userpb.DeleteUserRequest) (*userpb.DeleteUserResponse, error) {
+	if err := u.repo(ctx).DeleteUser(ctx, int(req.GetId())); err != nil {
+		return nil, repositoryStatus(err)
+	}
+	return &userpb.DeleteUserResponse{}, nil
+}
+
+// repositoryStatus maps a repository error onto a gRPC status, as
+// writeRepositoryError does onto an HTTP status
+func repositoryStatus(err error) error {
+	code := codes.Internal
+	switch classifyError(err) {
+	case errorNotFound:
+		code = codes.NotFound
+	case errorConflict:
+		code = codes.AlreadyExists
+	case errorTimeout:
+		code = codes.DeadlineExceeded
+	case errorUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+// toProto converts a user to its protobuf message
+func toProto(user *models.User) *userpb.User {
+	return &userpb.User{
+		Id:        int64(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		Age:       int32(user.Age),
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+		IsActive:  user.IsActive,
+	}
+}
+
+// toProtoList converts users to a list response
+func toProtoList(users []*models.User) *userpb.ListUsersResponse {
+	resp := &userpb.ListUsersResponse{Users: make([]*userpb.User, 0, len(users))}
+	for _, user := range users {
+		resp.Users = append(resp.Users, toProto(user))
+	}
+	return resp
+}
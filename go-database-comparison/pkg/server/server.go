@@ -0,0 +1,351 @@
+// Package server exposes the user repositories as a small REST API, so
+// the libraries can be compared behind the HTTP layer of a realistic
+// service. Every request picks its library with the X-DB-Library header
+// or the lib query parameter, falling back to the server's default.
+//
+//	POST   /users          create a user from a JSON CreateUserRequest
+//	GET    /users          list active users, ?limit=&offset= (default 10, 0)
+//	GET    /users?email=   search active users by email substring
+//	GET    /users/{id}     get an active user
+//	PATCH  /users/{id}     update a user from a JSON UpdateUserRequest
+//	DELETE /users/{id}     soft delete a user
+//	GET    /healthz        liveness probe
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/sqlcomment"
+)
+
+// LibraryHeader selects the library of a request and reports the one used
+const LibraryHeader = "X-DB-Library"
+
+// RequestIDHeader carries a request ID that is appended to every statement
+// of the request as a sqlcommenter tag
+const RequestIDHeader = "X-Request-ID"
+
+// Config configures a Server
+type Config struct {
+	Repositories   map[string]repository.UserRepository // By library name, matched case-insensitively
+	DefaultLibrary string                               // Used when a request names none
+	Logger         *slog.Logger                         // Logs every request at debug level, slog.Default when nil
+	RequestTimeout time.Duration                        // Bounds each request's repository call, 0 for none
+}
+
+// Server is the REST API handler
+type Server struct {
+	repos          map[string]repository.UserRepository
+	defaultLibrary string
+	logger         *slog.Logger
+	requestTimeout time.Duration
+	mux            *http.ServeMux
+}
+
+// New returns a server for config
+func New(config Config) (*Server, error) {
+	s := &Server{
+		repos:          make(map[string]repository.UserRepository, len(config.Repositories)),
+		defaultLibrary: strings.ToLower(config.DefaultLibrary),
+		logger:         config.Logger,
+		requestTimeout: config.RequestTimeout,
+		mux:            http.NewServeMux(),
+	}
+	for name, repo := range config.Repositories {
+		s.repos[strings.ToLower(name)] = repo
+	}
+	if _, ok := s.repos[s.defaultLibrary]; !ok {
+		return nil, fmt.Errorf("default library %q has no repository", config.DefaultLibrary)
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("POST /users", s.withRepository(s.handleCreate))
+	s.mux.HandleFunc("GET /users", s.withRepository(s.handleList))
+	s.mux.HandleFunc("GET /users/{id}", s.withRepository(s.handleGet))
+	s.mux.HandleFunc("PATCH /users/{id}", s.withRepository(s.handleUpdate))
+	s.mux.HandleFunc("DELETE /users/{id}", s.withRepository(s.handleDelete))
+	return s, nil
+}
+
+// Libraries returns the names of the libraries the server can use, sorted
+func (s *Server) Libraries() []string {
+	names := make([]string, 0, len(s.repos))
+	for name := range s.repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	s.logger.Debug("request", "method", r.Method, "path", r.URL.Path,
+		"library", rec.Header().Get(LibraryHeader), "status", rec.status, "duration", time.Since(start))
+}
+
+// repositoryHandler handles a request with the repository it selected
+type repositoryHandler func(w http.ResponseWriter, r *http.Request, repo repository.UserRepository)
+
+// withRepository resolves the library of a request, bounds its context
+// and tags its statements before calling h
+func (s *Server) withRepository(h repositoryHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		library := r.Header.Get(LibraryHeader)
+		if library == "" {
+			library = r.URL.Query().Get("lib")
+		}
+		library, repo, err := s.repository(library)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.Header().Set(LibraryHeader, library)
+
+		ctx, cancel := s.requestContext(r.Context(), r.Header.Get(RequestIDHeader))
+		defer cancel()
+		h(w, r.WithContext(ctx), repo)
+	}
+}
+
+// repository returns the repository of library, or of the default library
+// when it is empty, with its normalized name
+func (s *Server) repository(library string) (string, repository.UserRepository, error) {
+	if library == "" {
+		library = s.defaultLibrary
+	}
+	library = strings.ToLower(library)
+	repo, ok := s.repos[library]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown library %q (available: %s)", library, strings.Join(s.Libraries(), ", "))
+	}
+	return library, repo, nil
+}
+
+// requestContext bounds ctx by the request timeout and tags its statements
+// with requestID when set
+func (s *Server) requestContext(ctx context.Context, requestID string) (context.Context, context.CancelFunc) {
+	if requestID != "" {
+		ctx = sqlcomment.WithRequestID(ctx, requestID)
+	}
+	if s.requestTimeout > 0 {
+		return context.WithTimeout(ctx, s.requestTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "libraries": s.Libraries()})
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request, repo repository.UserRepository) {
+	var req models.CreateUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateCreate(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := repo.CreateUser(r.Context(), &req)
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/users/%d", user.ID))
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request, repo repository.UserRepository) {
+	query := r.URL.Query()
+	var users []*models.User
+	var err error
+	if query.Has("email") {
+		users, err = repo.GetUsersByEmail(r.Context(), query.Get("email"))
+	} else {
+		limit, offset := 10, 0
+		if limit, err = intParam(query.Get("limit"), limit); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %w", err))
+			return
+		}
+		if offset, err = intParam(query.Get("offset"), offset); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid offset: %w", err))
+			return
+		}
+		users, err = repo.GetAllUsers(r.Context(), limit, offset)
+	}
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+	if users == nil {
+		users = []*models.User{} // Encode as [] rather than null
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, repo repository.UserRepository) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := repo.GetUserByID(r.Context(), id)
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request, repo repository.UserRepository) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req models.UpdateUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateUpdate(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := repo.UpdateUser(r.Context(), id, &req)
+	if err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request, repo repository.UserRepository) {
+	id, err := pathID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := repo.DeleteUser(r.Context(), id); err != nil {
+		writeRepositoryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateCreate applies the rules of the request's validate tags
+func validateCreate(req *models.CreateUserRequest) error {
+	switch {
+	case req.Name == "" || len(req.Name) > 100:
+		return errors.New("name must be 1 to 100 characters")
+	case !strings.Contains(req.Email, "@"):
+		return errors.New("email must be an email address")
+	case req.Age < 0 || req.Age > 150:
+		return errors.New("age must be between 0 and 150")
+	}
+	return nil
+}
+
+// validateUpdate applies the rules of the request's validate tags to the
+// fields that are set
+func validateUpdate(req *models.UpdateUserRequest) error {
+	switch {
+	case req.Name != nil && (*req.Name == "" || len(*req.Name) > 100):
+		return errors.New("name must be 1 to 100 characters")
+	case req.Email != nil && !strings.Contains(*req.Email, "@"):
+		return errors.New("email must be an email address")
+	case req.Age != nil && (*req.Age < 0 || *req.Age > 150):
+		return errors.New("age must be between 0 and 150")
+	}
+	return nil
+}
+
+// decodeJSON decodes the request body into v, rejecting unknown fields
+func decodeJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+// pathID parses the {id} path segment
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid user ID %q", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+// intParam parses a non-negative query parameter, def when empty
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative integer", value)
+	}
+	return n, nil
+}
+
+// writeRepositoryError maps a repository error onto an HTTP status
+func writeRepositoryError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch classifyError(err) {
+	case errorNotFound:
+		status = http.StatusNotFound
+	case errorConflict:
+		status = http.StatusConflict
+	case errorTimeout:
+		status = http.StatusGatewayTimeout
+	case errorUnavailable:
+		status = http.StatusServiceUnavailable
+	}
+	writeError(w, status, err)
+}
+
+// writeError writes err as a JSON error body
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,62 @@
+// Package sqlcapture records the SQL each library actually sends to the
+// server and checks that the libraries issue equivalent statements for the
+// same repository method. Statements are captured through the dblog hooks,
+// the driver wrapper for lib/pq and sqlx and the logger for GORM, so what
+// is compared is exactly what was executed.
+package sqlcapture
+
+import (
+	"context"
+	"sync"
+
+	"go-database-comparison/pkg/dblog"
+)
+
+type contextKey struct{}
+
+// Recorder collects the statements executed with a context returned by
+// WithRecorder. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	queries []dblog.Query
+}
+
+// WithRecorder returns a copy of ctx whose statements are recorded into a
+// new Recorder
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{}
+	return context.WithValue(ctx, contextKey{}, r), r
+}
+
+// Statements returns the recorded SQL in execution order
+func (r *Recorder) Statements() []string {
+	queries := r.Queries()
+	statements := make([]string, len(queries))
+	for i, q := range queries {
+		statements[i] = q.SQL
+	}
+	return statements
+}
+
+// Queries returns the recorded statements with their arguments, duration
+// and rows, in the order they completed
+func (r *Recorder) Queries() []dblog.Query {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]dblog.Query(nil), r.queries...)
+}
+
+// Sink returns a dblog sink recording every statement into the Recorder
+// of its context. Set it as database.DatabaseConfig.QueryLog to capture
+// all three libraries; statements without a Recorder are dropped.
+func Sink() *dblog.Sink {
+	return dblog.NewFuncSink(func(ctx context.Context, q dblog.Query) {
+		r, ok := ctx.Value(contextKey{}).(*Recorder)
+		if !ok {
+			return
+		}
+		r.mu.Lock()
+		r.queries = append(r.queries, q)
+		r.mu.Unlock()
+	})
+}
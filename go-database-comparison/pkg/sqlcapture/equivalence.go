@@ -0,0 +1,253 @@
+package sqlcapture
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go-database-comparison/pkg/sqlnorm"
+)
+
+// Shape is the part of a statement that decides what it does: the verb,
+// the table, the columns written and the conditions rows must meet. The
+// select list, ORDER BY, LIMIT and RETURNING are left out as they only
+// change how a result is fetched; GORM's First, for example, adds
+// ORDER BY id LIMIT 1 to a lookup by primary key.
+type Shape struct {
+	Verb       string
+	Table      string
+	Columns    []string // Columns written by INSERT or UPDATE, sorted
+	Conditions []string // Conjuncts of the WHERE clause, sorted
+}
+
+// ParseShape returns the shape of query
+func ParseShape(query string) Shape {
+	tokens := sqlnorm.Tokens(query)
+	if len(tokens) == 0 {
+		return Shape{}
+	}
+
+	shape := Shape{Verb: tokens[0]}
+	tableAt := -1
+	switch shape.Verb {
+	case "select", "delete":
+		tableAt = indexAtDepth0(tokens, 0, "from") + 1
+	case "insert":
+		tableAt = indexAtDepth0(tokens, 0, "into") + 1
+	case "update":
+		tableAt = 1
+	}
+	if tableAt <= 0 || tableAt >= len(tokens) {
+		return shape
+	}
+	if tokens[tableAt] == "only" && tableAt+1 < len(tokens) {
+		tableAt++
+	}
+	shape.Table = tokens[tableAt]
+
+	switch shape.Verb {
+	case "insert":
+		if tableAt+1 < len(tokens) && tokens[tableAt+1] == "(" {
+			for _, item := range splitAtDepth0(tokens[tableAt+2:clauseEnd(tokens, tableAt+2, ")")], ",") {
+				shape.Columns = append(shape.Columns, item[0])
+			}
+		}
+	case "update":
+		if set := indexAtDepth0(tokens, tableAt, "set"); set >= 0 {
+			for _, item := range splitAtDepth0(tokens[set+1:clauseEnd(tokens, set+1, "where", "from", "returning")], ",") {
+				shape.Columns = append(shape.Columns, item[0])
+			}
+		}
+	}
+
+	if where := indexAtDepth0(tokens, tableAt, "where"); where >= 0 {
+		clause := tokens[where+1 : clauseEnd(tokens, where+1, "group", "having", "order", "limit", "offset", "returning", "for")]
+		if slices.Contains(clause, "or") {
+			// Only plain conjunctions are split, anything else is compared whole
+			shape.Conditions = []string{strings.Join(clause, " ")}
+		} else {
+			clause = slices.DeleteFunc(slices.Clone(clause), func(t string) bool { return t == "(" || t == ")" })
+			for _, item := range splitAtDepth0(clause, "and") {
+				shape.Conditions = append(shape.Conditions, strings.Join(item, " "))
+			}
+		}
+	}
+
+	slices.Sort(shape.Columns)
+	slices.Sort(shape.Conditions)
+	return shape
+}
+
+// String renders the shape as a compact pseudo statement
+func (s Shape) String() string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(s.Verb))
+	if s.Table != "" {
+		b.WriteString(" " + s.Table)
+	}
+	if len(s.Columns) > 0 {
+		b.WriteString(" (" + strings.Join(s.Columns, ", ") + ")")
+	}
+	if len(s.Conditions) > 0 {
+		b.WriteString(" WHERE " + strings.Join(s.Conditions, " AND "))
+	}
+	return b.String()
+}
+
+// Equal reports whether s and other have the same shape
+func (s Shape) Equal(other Shape) bool {
+	return s.Verb == other.Verb && s.Table == other.Table &&
+		slices.Equal(s.Columns, other.Columns) && slices.Equal(s.Conditions, other.Conditions)
+}
+
+// indexAtDepth0 returns the index of the first token equal to word at
+// or after from and outside parentheses, or -1
+func indexAtDepth0(tokens []string, from int, word string) int {
+	depth := 0
+	for i := from; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case word:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// clauseEnd returns the index of the first of ends at or after from and
+// outside parentheses, or len(tokens). A closing parenthesis that was not
+// opened after from ends the clause as well.
+func clauseEnd(tokens []string, from int, ends ...string) int {
+	depth := 0
+	for i := from; i < len(tokens); i++ {
+		switch {
+		case tokens[i] == "(":
+			depth++
+		case tokens[i] == ")" && depth == 0:
+			return i
+		case tokens[i] == ")":
+			depth--
+		case depth == 0 && slices.Contains(ends, tokens[i]):
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// splitAtDepth0 splits tokens at sep outside parentheses, dropping empty
+// items
+func splitAtDepth0(tokens []string, sep string) [][]string {
+	var items [][]string
+	depth, start := 0, 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) {
+			switch tokens[i] {
+			case "(":
+				depth++
+				continue
+			case ")":
+				depth--
+				continue
+			}
+			if tokens[i] != sep || depth != 0 {
+				continue
+			}
+		}
+		if i > start {
+			items = append(items, tokens[start:i])
+		}
+		start = i + 1
+	}
+	return items
+}
+
+// Checker compares the statements libraries executed for the same
+// operations with those of a reference library
+type Checker struct {
+	reference  string
+	operations []string                      // In the order they were first added
+	libraries  map[string][]string           // Libraries by operation, in the order they were added
+	shapes     map[string]map[string][]Shape // Shapes by operation and library
+}
+
+// NewChecker returns a checker comparing every library with reference
+func NewChecker(reference string) *Checker {
+	return &Checker{
+		reference: reference,
+		libraries: make(map[string][]string),
+		shapes:    make(map[string]map[string][]Shape),
+	}
+}
+
+// Add records the statements library executed for operation
+func (c *Checker) Add(operation, library string, statements []string) {
+	if _, ok := c.shapes[operation]; !ok {
+		c.operations = append(c.operations, operation)
+		c.shapes[operation] = make(map[string][]Shape)
+	}
+	if _, ok := c.shapes[operation][library]; !ok {
+		c.libraries[operation] = append(c.libraries[operation], library)
+	}
+
+	shapes := make([]Shape, 0, len(statements))
+	for _, statement := range statements {
+		shapes = append(shapes, ParseShape(statement))
+	}
+	c.shapes[operation][library] = shapes
+}
+
+// Difference is an operation for which a library executed statements of
+// other shapes, or another number of them, than the reference library
+type Difference struct {
+	Operation string
+	Library   string
+	Reference string
+	Want      []Shape // Executed by the reference library
+	Got       []Shape // Executed by Library
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s %s executed %s, %s executed %s",
+		d.Library, d.Operation, formatShapes(d.Got), d.Reference, formatShapes(d.Want))
+}
+
+// formatShapes renders shapes as a bracketed list
+func formatShapes(shapes []Shape) string {
+	parts := make([]string, len(shapes))
+	for i, shape := range shapes {
+		parts[i] = shape.String()
+	}
+	return "[" + strings.Join(parts, "; ") + "]"
+}
+
+// Differences returns the differences to the reference library, by
+// operation in the order they were added. Operations the reference
+// library did not run are not compared.
+func (c *Checker) Differences() []Difference {
+	var diffs []Difference
+	for _, operation := range c.operations {
+		want, ok := c.shapes[operation][c.reference]
+		if !ok {
+			continue
+		}
+		for _, library := range c.libraries[operation] {
+			got := c.shapes[operation][library]
+			if library == c.reference || slices.EqualFunc(want, got, Shape.Equal) {
+				continue
+			}
+			diffs = append(diffs, Difference{
+				Operation: operation,
+				Library:   library,
+				Reference: c.reference,
+				Want:      want,
+				Got:       got,
+			})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,99 @@
+// Package sqlcomment appends sqlcommenter tags to SQL statements, so
+// pg_stat_statements and the server log can tie a query back to the run
+// and library that sent it:
+//
+//	SELECT ... /*db_driver='lib%2Fpq',request_id='run-42',traceparent='00-...-01'*/
+//
+// Tags are only added to statements whose context carries a request ID,
+// see WithRequestID. Unique comments defeat client-side statement caches,
+// so benchmark runs that should not pay for that leave it unset.
+package sqlcomment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey int
+
+const tagsKey contextKey = 0
+
+// WithRequestID returns a context whose statements are tagged with id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithTag(ctx, "request_id", id)
+}
+
+// WithTag returns a context whose statements are tagged with key=value in
+// addition to the tags of ctx. Tags only apply once a request ID is set.
+func WithTag(ctx context.Context, key, value string) context.Context {
+	parent, _ := ctx.Value(tagsKey).(map[string]string)
+	tags := make(map[string]string, len(parent)+1)
+	for k, v := range parent {
+		tags[k] = v
+	}
+	tags[key] = value
+	return context.WithValue(ctx, tagsKey, tags)
+}
+
+// RequestID returns the request ID of ctx, if any
+func RequestID(ctx context.Context) (string, bool) {
+	tags, _ := ctx.Value(tagsKey).(map[string]string)
+	id, ok := tags["request_id"]
+	return id, ok
+}
+
+// NewRequestID returns a random request ID
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Append returns query with the tags of ctx, the driver and the current
+// span's traceparent appended as a comment. query is returned unchanged
+// when ctx has no request ID.
+func Append(ctx context.Context, driver, query string) string {
+	tags, _ := ctx.Value(tagsKey).(map[string]string)
+	if _, ok := tags["request_id"]; !ok {
+		return query
+	}
+
+	all := make(map[string]string, len(tags)+2)
+	for k, v := range tags {
+		all[k] = v
+	}
+	all["db_driver"] = driver
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		all["traceparent"] = "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String()
+	}
+	return strings.TrimRight(query, " \t\n;") + " " + Comment(all)
+}
+
+// Comment formats tags as a sqlcommenter comment: keys sorted, keys and
+// values URL-encoded and values single-quoted
+func Comment(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = encode(k) + "='" + encode(tags[k]) + "'"
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+// encode URL-encodes s with %20 for spaces. Quotes, "*/" and colons are
+// all encoded, the latter keeps sqlx named queries from reading them as
+// bind parameters.
+func encode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
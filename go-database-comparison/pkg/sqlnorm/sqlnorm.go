@@ -0,0 +1,173 @@
+// Package sqlnorm normalizes and fingerprints SQL statements. Statements
+// that only differ in literal values, placeholder style, identifier
+// quoting, table qualifiers, comments, whitespace or the length of value
+// lists normalize to the same text, so the hand-written SQL of lib/pq and
+// sqlx and the SQL GORM generates can be compared and grouped.
+package sqlnorm
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// Normalize returns the normalized text of query, its tokens joined by
+// single spaces, e.g. "select * from users where id = ? limit ?"
+func Normalize(query string) string {
+	return strings.Join(Tokens(query), " ")
+}
+
+// Fingerprint returns a short, stable identifier of the normalized text
+// of query, as 16 hexadecimal digits
+func Fingerprint(query string) string {
+	h := fnv.New64a()
+	h.Write([]byte(Normalize(query)))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// Tokens splits query into normalized tokens: comments are dropped, words
+// are lowercased and unquoted, table qualifiers are removed, placeholders
+// and literals all become "?" and lists of them collapse into one, so
+// IN ($1, $2) and a multi-row VALUES list read like a single value. The
+// tokens of the hand-written and the generated form of a statement then
+// only differ where the statements do.
+func Tokens(query string) []string {
+	var tokens []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i-1] == '*' && runes[i] == '/') {
+				i++
+			}
+			i++
+		case r == '\'':
+			i = skipQuoted(runes, i, '\'')
+			tokens = append(tokens, "?")
+		case r == '"':
+			end := skipQuoted(runes, i, '"')
+			tokens = append(tokens, strings.ToLower(strings.ReplaceAll(string(runes[i+1:max(i+1, end-1)]), `""`, `"`)))
+			i = end
+		case r == '$' || r == '?':
+			i++
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, "?")
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, "?")
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			word := strings.ToLower(string(runes[start:i]))
+			if word == "true" || word == "false" {
+				word = "?"
+			}
+			tokens = append(tokens, word)
+		default:
+			op := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "<>", "!=", "::", "||":
+					op = two
+				}
+			}
+			i += len([]rune(op))
+			tokens = append(tokens, op)
+		}
+	}
+	return collapseLists(unqualify(tokens))
+}
+
+// skipQuoted returns the index after the quoted section starting at
+// runes[start], treating a doubled quote as an escaped one
+func skipQuoted(runes []rune, start int, quote rune) int {
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] != quote {
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(runes)
+}
+
+// unqualify drops table qualifiers, turning users.id into id
+func unqualify(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if i+2 < len(tokens) && tokens[i+1] == "." && isWord(tokens[i]) && (isWord(tokens[i+2]) || tokens[i+2] == "*") {
+			continue
+		}
+		if tokens[i] == "." && i > 0 && i+1 < len(tokens) && isWord(tokens[i-1]) {
+			continue
+		}
+		out = append(out, tokens[i])
+	}
+	return out
+}
+
+// isWord reports whether token is an identifier or keyword
+func isWord(token string) bool {
+	for _, r := range token {
+		return r == '_' || unicode.IsLetter(r)
+	}
+	return false
+}
+
+// collapseLists shortens parenthesized lists of "?" to "( ? )" and drops
+// repeats of such a list following it after a comma
+func collapseLists(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		end := valueListEnd(tokens, i)
+		if end < 0 {
+			out = append(out, tokens[i])
+			continue
+		}
+		n := len(out)
+		if n >= 4 && out[n-1] == "," && out[n-2] == ")" && out[n-3] == "?" && out[n-4] == "(" {
+			out = out[:n-1] // A repeat, the list before stands for it
+		} else {
+			out = append(out, "(", "?", ")")
+		}
+		i = end
+	}
+	return out
+}
+
+// valueListEnd returns the index of the ")" closing a list of only "?"
+// separated by commas that opens at tokens[start], or -1
+func valueListEnd(tokens []string, start int) int {
+	if tokens[start] != "(" {
+		return -1
+	}
+	for i := start + 1; i < len(tokens); i++ {
+		switch {
+		case tokens[i] == ")" && i > start+1:
+			return i
+		case (i-start)%2 == 1 && tokens[i] != "?":
+			return -1
+		case (i-start)%2 == 0 && tokens[i] != ",":
+			return -1
+		}
+	}
+	return -1
+}
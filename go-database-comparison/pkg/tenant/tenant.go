@@ -0,0 +1,91 @@
+// Package tenant scopes connections to one tenant of the users table, whose
+// rows migration 0006 isolates with row-level security. Only Role is
+// subject to the policy; the table owner, which the benchmarks connect as,
+// bypasses it. A connection acts for a tenant once Set switched it to Role
+// with the tenant in Setting, so every library keeps running its usual
+// statements and PostgreSQL adds the tenant filter.
+//
+// Both are session settings, and a pooled connection returned with them
+// would act for the tenant in the next request. Set therefore works on a
+// connection pinned for the request, and Release resets it before handing
+// it back to the pool, discarding it when that fails.
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// Role is the role row-level security applies to
+const Role = "dbcompare_tenant"
+
+// Setting is the session setting holding the tenant of a connection
+const Setting = "app.tenant_id"
+
+// None is the tenant of a connection acting for no tenant, which sees no
+// users at all
+const None = 0
+
+// Set makes conn act for tenant until Release. Statements run as Role, so
+// the policy limits them to the users of tenant, and users they create
+// belong to tenant.
+func Set(ctx context.Context, conn *sql.Conn, tenant int) error {
+	if tenant < 0 {
+		return fmt.Errorf("tenant must not be negative, got %d", tenant)
+	}
+	value := ""
+	if tenant != None {
+		value = strconv.Itoa(tenant)
+	}
+	// One round trip for both, set_config('role') is what SET ROLE does
+	_, err := conn.ExecContext(ctx, "SELECT set_config('role', $1, false), set_config($2, $3, false)", Role, Setting, value)
+	if err != nil {
+		return fmt.Errorf("failed to act for tenant %d: %w", tenant, err)
+	}
+	return nil
+}
+
+// Release resets conn to the session user without a tenant and returns it
+// to the pool. A connection that cannot be reset is discarded instead.
+func Release(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), "SELECT set_config('role', 'none', false), set_config($1, '', false)", Setting)
+	if err != nil {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+		conn.Close()
+		return fmt.Errorf("failed to reset tenant, connection discarded: %w", err)
+	}
+	return conn.Close()
+}
+
+// Current returns the role and tenant conn acts as, for checking that
+// Release reset it
+func Current(ctx context.Context, conn *sql.Conn) (role string, tenant string, err error) {
+	err = conn.QueryRowContext(ctx, "SELECT current_user, coalesce(current_setting($1, true), '')", Setting).Scan(&role, &tenant)
+	return role, tenant, err
+}
+
+// Enabled reports whether migration 0006 applied row-level security to the
+// users table of db
+func Enabled(ctx context.Context, db *sql.DB) (bool, error) {
+	var enabled bool
+	err := db.QueryRowContext(ctx, `
+		SELECT coalesce(bool_and(c.relrowsecurity), false) AND count(p.polname) > 0
+		FROM pg_class c
+		LEFT JOIN pg_policy p ON p.polrelid = c.oid AND p.polname = 'users_tenant_isolation'
+		WHERE c.oid = to_regclass('users')`).Scan(&enabled)
+	return enabled, err
+}
+
+// TenantOf returns the tenant a user belongs to, read bypassing the policy
+// through db, which must connect as the table owner. A user of no tenant
+// has None.
+func TenantOf(ctx context.Context, db *sql.DB, id int) (int, error) {
+	var tenant sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT tenant_id FROM users WHERE id = $1", id).Scan(&tenant); err != nil {
+		return None, err
+	}
+	return int(tenant.Int64), nil
+}
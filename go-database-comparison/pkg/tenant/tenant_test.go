@@ -0,0 +1,331 @@
+package tenant_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go-database-comparison/internal/dbtest"
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/database"
+	"go-database-comparison/pkg/models"
+	"go-database-comparison/pkg/repository"
+	"go-database-comparison/pkg/tenant"
+)
+
+// The tenants the tests act for
+const (
+	tenantA = 1
+	tenantB = 2
+)
+
+// target is one library's repository on its pool, acting as the table
+// owner, and on pinned connections that can act for a tenant
+type target struct {
+	name  string
+	db    *sql.DB
+	owner repository.UserRepository
+	pin   func(ctx context.Context) (*sql.Conn, repository.UserRepository, error)
+}
+
+// rlsConfig returns the test database's settings, skipping t unless
+// migration 0006 applied the policy. The users are committed and removed
+// by run ID: the violations the tests provoke would abort a rolled back
+// transaction, and a rolled back SET ROLE would not show what Release does.
+func rlsConfig(t testing.TB) *database.DatabaseConfig {
+	t.Helper()
+	config := dbtest.Config(t)
+	config.Rollback = false
+	enabled, err := tenant.Enabled(context.Background(), dbtest.Observer(t, config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Skip("the users table has no tenant policy, run dbcompare migrate up to apply migration 0006")
+	}
+	return config
+}
+
+// openTarget connects the named library, closing it when t ends
+func openTarget(t testing.TB, name string, config *database.DatabaseConfig) *target {
+	t.Helper()
+	ctx := context.Background()
+	switch name {
+	case "PQ":
+		db, err := database.ConnectWithPQ(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		repo := repository.NewPQRepository(db)
+		return &target{name: name, db: db, owner: repo, pin: func(ctx context.Context) (*sql.Conn, repository.UserRepository, error) {
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn, repo.WithConn(conn), nil
+		}}
+	case "SQLX":
+		db, err := database.ConnectWithSQLX(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		repo := repository.NewSQLXRepository(db)
+		return &target{name: name, db: db.DB, owner: repo, pin: func(ctx context.Context) (*sql.Conn, repository.UserRepository, error) {
+			conn, err := db.Connx(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn.Conn, repo.WithConn(conn), nil
+		}}
+	case "GORM":
+		db, err := database.ConnectWithGORM(ctx, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+		repo := repository.NewGORMRepository(db)
+		return &target{name: name, db: sqlDB, owner: repo, pin: func(ctx context.Context) (*sql.Conn, repository.UserRepository, error) {
+			conn, err := sqlDB.Conn(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conn, repo.WithConn(conn), nil
+		}}
+	}
+	t.Fatalf("unknown library %q", name)
+	return nil
+}
+
+// asTenant runs fn as one request of tenantID: on a pinned connection set
+// to act for the tenant, which is released afterwards
+func (t *target) asTenant(ctx context.Context, tenantID int, fn func(conn *sql.Conn, repo repository.UserRepository) error) error {
+	conn, repo, err := t.pin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := tenant.Set(ctx, conn, tenantID); err != nil {
+		tenant.Release(conn)
+		return err
+	}
+	err = fn(conn, repo)
+	if releaseErr := tenant.Release(conn); err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+// isNotFound reports whether err is a repository's error for a user that
+// does not exist, or that the policy hides
+func isNotFound(err error) bool {
+	return errors.Is(err, repository.ErrNotFound)
+}
+
+// TestIsolation has tenant A create a user through every library and
+// checks that the policy keeps it from tenant B and from connections
+// without a tenant, for reads, searches, updates, deletes and inserts
+func TestIsolation(t *testing.T) {
+	config := rlsConfig(t)
+	observer := dbtest.Observer(t, config)
+
+	for _, name := range dbtest.Names {
+		t.Run(name, func(t *testing.T) {
+			ctx := dbtest.Context(t, config)
+			target := openTarget(t, name, config)
+			runID := benchdata.RunID(ctx)
+
+			var user *models.User
+			err := target.asTenant(ctx, tenantA, func(_ *sql.Conn, repo repository.UserRepository) (err error) {
+				email := benchdata.Email(runID, "rls-a", name, 1)
+				user, err = repo.CreateUser(ctx, &models.CreateUserRequest{Name: "Tenant A " + name, Email: email, Age: 30})
+				return err
+			})
+			if err != nil {
+				t.Fatalf("tenant %d failed to create a user: %v", tenantA, err)
+			}
+			if owner, err := tenant.TenantOf(ctx, observer, user.ID); err != nil || owner != tenantA {
+				t.Fatalf("user created for tenant %d belongs to tenant %d (%v)", tenantA, owner, err)
+			}
+
+			// visible reports whether tenantID reads the user
+			visible := func(tenantID int) bool {
+				t.Helper()
+				found := true
+				err := target.asTenant(ctx, tenantID, func(_ *sql.Conn, repo repository.UserRepository) error {
+					_, err := repo.GetUserByID(ctx, user.ID)
+					if isNotFound(err) {
+						found, err = false, nil
+					}
+					return err
+				})
+				if err != nil {
+					t.Fatalf("tenant %d failed to read the user: %v", tenantID, err)
+				}
+				return found
+			}
+			// unchanged fails t unless the owner still reads the user as created
+			unchanged := func(after string) {
+				t.Helper()
+				current, err := target.owner.GetUserByID(ctx, user.ID)
+				if err != nil {
+					t.Fatalf("after %s: %v", after, err)
+				}
+				if current.Name != user.Name {
+					t.Fatalf("after %s the user is named %q", after, current.Name)
+				}
+			}
+
+			if !visible(tenantA) {
+				t.Errorf("tenant %d does not read its own user", tenantA)
+			}
+			if visible(tenantB) {
+				t.Errorf("tenant %d reads the user of tenant %d", tenantB, tenantA)
+			}
+			if visible(tenant.None) {
+				t.Error("a connection without a tenant reads the user")
+			}
+
+			err = target.asTenant(ctx, tenantB, func(_ *sql.Conn, repo repository.UserRepository) error {
+				found, err := repo.GetUsersByEmail(ctx, user.Email)
+				if err == nil && len(found) != 0 {
+					err = fmt.Errorf("found %d users", len(found))
+				}
+				return err
+			})
+			if err != nil {
+				t.Errorf("tenant %d searching the user's email: %v", tenantB, err)
+			}
+
+			err = target.asTenant(ctx, tenantB, func(_ *sql.Conn, repo repository.UserRepository) error {
+				renamed := "Renamed by tenant B"
+				if _, err := repo.UpdateUser(ctx, user.ID, &models.UpdateUserRequest{Name: &renamed}); !isNotFound(err) {
+					return fmt.Errorf("UpdateUser returned %v, want not found", err)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Errorf("tenant %d: %v", tenantB, err)
+			}
+			unchanged("tenant B's update")
+
+			err = target.asTenant(ctx, tenantB, func(_ *sql.Conn, repo repository.UserRepository) error {
+				if err := repo.DeleteUser(ctx, user.ID); !isNotFound(err) {
+					return fmt.Errorf("DeleteUser returned %v, want not found", err)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Errorf("tenant %d: %v", tenantB, err)
+			}
+			unchanged("tenant B's delete")
+
+			err = target.asTenant(ctx, tenantB, func(conn *sql.Conn, _ repository.UserRepository) error {
+				// The repositories never name tenant_id, write it directly
+				email := benchdata.Email(runID, "rls-b", name, 1)
+				_, err := conn.ExecContext(ctx, "INSERT INTO users (name, email, age, tenant_id) VALUES ($1, $2, $3, $4)",
+					"Tenant B "+name, email, 30, tenantA)
+				return err
+			})
+			if err == nil || !strings.Contains(err.Error(), "row-level security") {
+				t.Errorf("tenant %d inserting a user of tenant %d returned %v, want a row-level security violation", tenantB, tenantA, err)
+			}
+		})
+	}
+}
+
+// TestRelease checks that a connection back in the pool after a request
+// for a tenant acts as the owner again, without a tenant
+func TestRelease(t *testing.T) {
+	config := rlsConfig(t)
+	ctx := context.Background()
+
+	for _, name := range dbtest.Names {
+		t.Run(name, func(t *testing.T) {
+			target := openTarget(t, name, config)
+			// With a single connection the second request gets the first one's
+			target.db.SetMaxOpenConns(1)
+
+			if err := target.asTenant(ctx, tenantA, func(*sql.Conn, repository.UserRepository) error { return nil }); err != nil {
+				t.Fatal(err)
+			}
+			conn, _, err := target.pin(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			role, tenantID, err := tenant.Current(ctx, conn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role == tenant.Role || tenantID != "" {
+				t.Fatalf("released connection acts as %s with tenant %q", role, tenantID)
+			}
+		})
+	}
+}
+
+// BenchmarkRLS compares GetUserByID as the table owner, which bypasses
+// the policy, with the same call for a tenant, both the call alone and
+// the whole request of pinning, Set, the call and Release
+func BenchmarkRLS(b *testing.B) {
+	config := rlsConfig(b)
+
+	for _, name := range dbtest.Names {
+		b.Run(name, func(b *testing.B) {
+			ctx := dbtest.Context(b, config)
+			target := openTarget(b, name, config)
+
+			var user *models.User
+			err := target.asTenant(ctx, tenantA, func(_ *sql.Conn, repo repository.UserRepository) (err error) {
+				email := benchdata.Email(benchdata.RunID(ctx), "rls-bench", name, 1)
+				user, err = repo.CreateUser(ctx, &models.CreateUserRequest{Name: "RLS Bench", Email: email, Age: 30})
+				return err
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run("owner", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := target.owner.GetUserByID(ctx, user.ID); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("tenant query", func(b *testing.B) {
+				err := target.asTenant(ctx, tenantA, func(_ *sql.Conn, repo repository.UserRepository) error {
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						if _, err := repo.GetUserByID(ctx, user.ID); err != nil {
+							return err
+						}
+					}
+					b.StopTimer()
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			})
+			b.Run("tenant request", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					err := target.asTenant(ctx, tenantA, func(_ *sql.Conn, repo repository.UserRepository) error {
+						_, err := repo.GetUserByID(ctx, user.ID)
+						return err
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
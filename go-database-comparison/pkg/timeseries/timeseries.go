@@ -0,0 +1,152 @@
+// Package timeseries creates the user_activity log the timescale command
+// benchmarks: a time series of what users did, appended in batches in time
+// order and read back by user and time range. The table is created in the
+// schema of a run, see benchdata.Schema, so the libraries reach it through
+// their search path with unqualified statements, as they reach the users
+// table of pkg/partition, and dbcompare cleanup drops it with the schema.
+//
+// The table is created in one of two layouts: Plain, an ordinary table
+// with the indexes a hypertable gets, and Hypertable, a TimescaleDB
+// hypertable chunked by occurred_at. The latter needs the timescaledb
+// extension, which the server has to preload through
+// shared_preload_libraries, as the timescale image does.
+package timeseries
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Table is the name of the activity log, as models.UserActivity maps it
+const Table = "user_activity"
+
+// Extension is the PostgreSQL extension of TimescaleDB
+const Extension = "timescaledb"
+
+// Layouts of the table
+const (
+	Plain      = "plain"
+	Hypertable = "hypertable"
+)
+
+// Layouts lists the layouts Create accepts
+var Layouts = []string{Plain, Hypertable}
+
+// ErrUnavailable is returned by EnableTimescale when the server has no
+// TimescaleDB to install
+var ErrUnavailable = errors.New("TimescaleDB is not available on the server")
+
+// EnableTimescale creates the timescaledb extension unless it exists and
+// returns its version
+func EnableTimescale(ctx context.Context, db *sql.DB) (string, error) {
+	var available bool
+	err := db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = $1)", Extension).Scan(&available)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the %s extension: %w", Extension, err)
+	}
+	if !available {
+		return "", ErrUnavailable
+	}
+
+	if _, err := db.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS "+Extension); err != nil {
+		return "", fmt.Errorf("failed to create the %s extension, is it in shared_preload_libraries? %w", Extension, err)
+	}
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT extversion FROM pg_extension WHERE extname = $1", Extension).Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to read the %s version: %w", Extension, err)
+	}
+	return version, nil
+}
+
+// Create creates the table in schema with layout, replacing the one there.
+// A hypertable gets chunks of chunk; both layouts get an index on
+// occurred_at, which create_hypertable adds by default, and one on user_id
+// and occurred_at for the range reads.
+func Create(ctx context.Context, db *sql.DB, schema, layout string, chunk time.Duration) error {
+	if layout != Plain && layout != Hypertable {
+		return fmt.Errorf("unknown layout %q (expected plain or hypertable)", layout)
+	}
+	table := qualified(schema)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		"CREATE SCHEMA IF NOT EXISTS " + pq.QuoteIdentifier(schema),
+		"DROP TABLE IF EXISTS " + table,
+		`CREATE TABLE ` + table + ` (
+			occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			user_id INTEGER NOT NULL,
+			action VARCHAR(32) NOT NULL,
+			duration_ms INTEGER NOT NULL DEFAULT 0
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create %s: %w", table, err)
+		}
+	}
+
+	if layout == Hypertable {
+		_, err = tx.ExecContext(ctx, "SELECT create_hypertable($1::regclass, 'occurred_at', chunk_time_interval => $2::interval)",
+			table, fmt.Sprintf("%d microseconds", chunk.Microseconds()))
+		if err != nil {
+			return fmt.Errorf("failed to turn %s into a hypertable: %w", table, err)
+		}
+	} else if _, err := tx.ExecContext(ctx, "CREATE INDEX ON "+table+" (occurred_at DESC)"); err != nil {
+		return fmt.Errorf("failed to index %s: %w", table, err)
+	}
+	if _, err := tx.ExecContext(ctx, "CREATE INDEX ON "+table+" (user_id, occurred_at DESC)"); err != nil {
+		return fmt.Errorf("failed to index %s: %w", table, err)
+	}
+	return tx.Commit()
+}
+
+// Stats describes the table after a benchmark
+type Stats struct {
+	Rows   int64 `json:"rows"`
+	Bytes  int64 `json:"bytes"`  // Table and indexes, of all chunks of a hypertable
+	Chunks int   `json:"chunks"` // 0 for the plain layout
+}
+
+// Inspect returns the stats of the table in schema with layout
+func Inspect(ctx context.Context, db *sql.DB, schema, layout string) (Stats, error) {
+	table := qualified(schema)
+	var stats Stats
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM "+table).Scan(&stats.Rows); err != nil {
+		return Stats{}, fmt.Errorf("failed to count the rows of %s: %w", table, err)
+	}
+
+	// The parent of a hypertable holds no rows, its size is its chunks'
+	size := "SELECT pg_total_relation_size($1::regclass)"
+	if layout == Hypertable {
+		size = "SELECT hypertable_size($1::regclass)"
+		if err := db.QueryRowContext(ctx, "SELECT count(*) FROM show_chunks($1::regclass)", table).Scan(&stats.Chunks); err != nil {
+			return Stats{}, fmt.Errorf("failed to count the chunks of %s: %w", table, err)
+		}
+	}
+	if err := db.QueryRowContext(ctx, size, table).Scan(&stats.Bytes); err != nil {
+		return Stats{}, fmt.Errorf("failed to size %s: %w", table, err)
+	}
+	return stats, nil
+}
+
+// SearchPath makes unqualified names resolve to the table in schema, and
+// to public for the other tables
+func SearchPath(schema string) string {
+	return schema + ",public"
+}
+
+// qualified returns the quoted name of the table in schema
+func qualified(schema string) string {
+	return pq.QuoteIdentifier(schema) + "." + Table
+}
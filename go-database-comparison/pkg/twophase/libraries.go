@@ -0,0 +1,183 @@
+package twophase
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gorm.io/gorm"
+
+	"go-database-comparison/pkg/models"
+)
+
+// insertUser is the INSERT the PQ and SQLX branches run
+const insertUser = "INSERT INTO users (name, email, age, created_at, updated_at, is_active) VALUES ($1, $2, $3, $4, $5, true) RETURNING id"
+
+// PQ runs branches on a connection pinned with database/sql's Conn,
+// beginning them with a plain BEGIN. An sql.Tx cannot end in PREPARE
+// TRANSACTION with lib/pq: its Commit and Rollback check that the session
+// is still in a transaction, which PREPARE TRANSACTION ended, and fail
+// discarding the connection.
+func PQ(db *sql.DB) Library { return pqLibrary{db} }
+
+// SQLX runs branches on a connection pinned with sqlx's Connx. sqlx uses
+// lib/pq as well, so its Tx has the same limits as PQ's.
+func SQLX(db *sqlx.DB) Library { return sqlxLibrary{db} }
+
+// GORM runs branches in transactions begun with gorm.DB.Begin. GORM uses
+// pgx, whose Commit after PREPARE TRANSACTION only returns the connection
+// to the pool: the server answers the COMMIT with a warning that no
+// transaction is in progress. The global ID is inlined, a ? placeholder
+// would become a parameter PREPARE TRANSACTION does not accept.
+func GORM(db *gorm.DB) Library { return gormLibrary{db} }
+
+type pqLibrary struct{ db *sql.DB }
+
+func (pqLibrary) Name() string { return "PQ" }
+
+func (l pqLibrary) Begin(ctx context.Context) (Branch, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return pqBranch{conn}, nil
+}
+
+func (l pqLibrary) Finish(ctx context.Context, gid string, commit bool) error {
+	statement, err := finishStatement(gid, commit)
+	if err != nil {
+		return err
+	}
+	_, err = l.db.ExecContext(ctx, statement)
+	return err
+}
+
+type pqBranch struct{ conn *sql.Conn }
+
+func (b pqBranch) Insert(ctx context.Context, req *models.CreateUserRequest) (id int, err error) {
+	now := time.Now()
+	err = b.conn.QueryRowContext(ctx, insertUser, req.Name, req.Email, req.Age, now, now).Scan(&id)
+	return id, err
+}
+
+func (b pqBranch) Prepare(ctx context.Context, gid string) error {
+	statement, err := prepareStatement(gid)
+	if err != nil {
+		b.Rollback()
+		return err
+	}
+	if _, err := b.conn.ExecContext(ctx, statement); err != nil {
+		b.Rollback()
+		return err
+	}
+	return b.conn.Close()
+}
+
+func (b pqBranch) Rollback() error {
+	// The connection must not go back to the pool inside a transaction
+	_, err := b.conn.ExecContext(context.Background(), "ROLLBACK")
+	b.conn.Close()
+	return err
+}
+
+type sqlxLibrary struct{ db *sqlx.DB }
+
+func (sqlxLibrary) Name() string { return "SQLX" }
+
+func (l sqlxLibrary) Begin(ctx context.Context) (Branch, error) {
+	conn, err := l.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return sqlxBranch{conn}, nil
+}
+
+func (l sqlxLibrary) Finish(ctx context.Context, gid string, commit bool) error {
+	statement, err := finishStatement(gid, commit)
+	if err != nil {
+		return err
+	}
+	_, err = l.db.ExecContext(ctx, statement)
+	return err
+}
+
+type sqlxBranch struct{ conn *sqlx.Conn }
+
+func (b sqlxBranch) Insert(ctx context.Context, req *models.CreateUserRequest) (id int, err error) {
+	now := time.Now()
+	err = b.conn.GetContext(ctx, &id, insertUser, req.Name, req.Email, req.Age, now, now)
+	return id, err
+}
+
+func (b sqlxBranch) Prepare(ctx context.Context, gid string) error {
+	statement, err := prepareStatement(gid)
+	if err != nil {
+		b.Rollback()
+		return err
+	}
+	if _, err := b.conn.ExecContext(ctx, statement); err != nil {
+		b.Rollback()
+		return err
+	}
+	return b.conn.Close()
+}
+
+func (b sqlxBranch) Rollback() error {
+	_, err := b.conn.ExecContext(context.Background(), "ROLLBACK")
+	b.conn.Close()
+	return err
+}
+
+type gormLibrary struct{ db *gorm.DB }
+
+func (gormLibrary) Name() string { return "GORM" }
+
+func (l gormLibrary) Begin(ctx context.Context) (Branch, error) {
+	tx := l.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return gormBranch{tx}, nil
+}
+
+func (l gormLibrary) Finish(ctx context.Context, gid string, commit bool) error {
+	statement, err := finishStatement(gid, commit)
+	if err != nil {
+		return err
+	}
+	return l.db.WithContext(ctx).Exec(statement).Error
+}
+
+type gormBranch struct{ tx *gorm.DB }
+
+func (b gormBranch) Insert(ctx context.Context, req *models.CreateUserRequest) (int, error) {
+	user := &models.User{Name: req.Name, Email: req.Email, Age: req.Age, IsActive: true}
+	err := b.tx.WithContext(ctx).Create(user).Error
+	return user.ID, err
+}
+
+func (b gormBranch) Prepare(ctx context.Context, gid string) error {
+	statement, err := prepareStatement(gid)
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	if err := b.tx.WithContext(ctx).Exec(statement).Error; err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	return b.tx.Commit().Error
+}
+
+func (b gormBranch) Rollback() error {
+	return b.tx.Rollback().Error
+}
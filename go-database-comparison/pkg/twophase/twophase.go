@@ -0,0 +1,379 @@
+// Package twophase demonstrates two-phase commit with PostgreSQL's PREPARE
+// TRANSACTION, COMMIT PREPARED and ROLLBACK PREPARED through lib/pq, sqlx
+// and GORM. A coordinator runs two transaction branches on connections of
+// their own, prepares both and only then commits them, so either both
+// branches take effect or neither does.
+//
+// A prepared transaction outlives the connection that prepared it and
+// keeps holding its locks until it is committed or rolled back, possibly
+// from another connection. One left behind by a crashed coordinator is an
+// orphan; Recover rolls orphans back. The server has to allow prepared
+// transactions with max_prepared_transactions above 0, see Enabled.
+package twophase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-database-comparison/pkg/benchdata"
+	"go-database-comparison/pkg/models"
+)
+
+// GIDPrefix starts the global transaction IDs of all prepared transactions
+// this package creates, so Recover never touches those of other clients
+const GIDPrefix = "dbcompare-"
+
+// Scenarios, in the order they run
+const (
+	Commit = "commit" // Both branches prepare, then both commit
+	Abort  = "abort"  // The second branch fails, the prepared first one is rolled back
+	Orphan = "orphan" // The coordinator abandons a prepared branch, Recover rolls it back
+)
+
+// Scenarios lists the scenarios run
+var Scenarios = []string{Commit, Abort, Orphan}
+
+// blockWait is how long an insert conflicting with an orphan's row is
+// given before it counts as blocked
+const blockWait = 500 * time.Millisecond
+
+// gidPattern are the global transaction IDs safe to inline: PREPARE
+// TRANSACTION and friends take a string literal, not a parameter
+var gidPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,200}$`)
+
+// Branch is a transaction branch on a connection of its own, begun through
+// one library's API
+type Branch interface {
+	Insert(ctx context.Context, req *models.CreateUserRequest) (int, error)
+	// Prepare ends the branch with PREPARE TRANSACTION gid and releases
+	// its connection, the transaction lives on in the server
+	Prepare(ctx context.Context, gid string) error
+	// Rollback ends a branch that was not prepared and releases its
+	// connection
+	Rollback() error
+}
+
+// Library runs both phases through one library's API
+type Library interface {
+	Name() string
+	Begin(ctx context.Context) (Branch, error)
+	// Finish commits or rolls back the prepared transaction gid, outside
+	// any transaction as PostgreSQL requires
+	Finish(ctx context.Context, gid string, commit bool) error
+}
+
+// Check is the outcome of one scenario on one library
+type Check struct {
+	Library  string `json:"library"`
+	Scenario string `json:"scenario"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Detail   string `json:"detail"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Passed reports whether the scenario ended as two-phase commit promises
+func (c Check) Passed() bool {
+	return c.Error == "" && c.Observed == c.Expected
+}
+
+// PreparedTx is a row of pg_prepared_xacts
+type PreparedTx struct {
+	GID      string        `json:"gid"`
+	Prepared time.Time     `json:"prepared"`
+	Owner    string        `json:"owner"`
+	Database string        `json:"database"`
+	Age      time.Duration `json:"age"`
+}
+
+// Enabled returns the server's max_prepared_transactions, which is 0 and
+// rejects PREPARE TRANSACTION unless configured otherwise
+func Enabled(ctx context.Context, db *sql.DB) (int, error) {
+	var setting string
+	if err := db.QueryRowContext(ctx, "SHOW max_prepared_transactions").Scan(&setting); err != nil {
+		return 0, err
+	}
+	var n int
+	_, err := fmt.Sscan(setting, &n)
+	return n, err
+}
+
+// Prepared lists the prepared transactions of this database whose global
+// ID starts with prefix, oldest first
+func Prepared(ctx context.Context, db *sql.DB, prefix string) ([]PreparedTx, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT gid, prepared, owner, database, EXTRACT(EPOCH FROM clock_timestamp() - prepared)
+		FROM pg_prepared_xacts
+		WHERE database = current_database() AND starts_with(gid, $1)
+		ORDER BY prepared`, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prepared []PreparedTx
+	for rows.Next() {
+		var tx PreparedTx
+		var age float64 // Seconds, by the server's clock
+		if err := rows.Scan(&tx.GID, &tx.Prepared, &tx.Owner, &tx.Database, &age); err != nil {
+			return nil, err
+		}
+		tx.Age = time.Duration(age * float64(time.Second))
+		prepared = append(prepared, tx)
+	}
+	return prepared, rows.Err()
+}
+
+// Recover rolls back the prepared transactions whose global ID starts with
+// prefix and that were prepared at least olderThan ago, returning their
+// IDs. A coordinator still working on younger ones is left alone.
+func Recover(ctx context.Context, db *sql.DB, prefix string, olderThan time.Duration) ([]string, error) {
+	if !strings.HasPrefix(prefix, GIDPrefix) {
+		return nil, fmt.Errorf("refusing to recover prepared transactions outside %s*", GIDPrefix)
+	}
+	prepared, err := Prepared(ctx, db, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var recovered []string
+	for _, tx := range prepared {
+		if tx.Age < olderThan {
+			continue
+		}
+		statement, err := finishStatement(tx.GID, false)
+		if err != nil {
+			return recovered, err
+		}
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return recovered, fmt.Errorf("failed to roll back %s: %w", tx.GID, err)
+		}
+		recovered = append(recovered, tx.GID)
+	}
+	return recovered, nil
+}
+
+// RunPrefix returns the prefix of the global IDs of the run in ctx
+func RunPrefix(ctx context.Context) string {
+	return GIDPrefix + benchdata.RunID(ctx) + "-"
+}
+
+// Run runs every scenario on lib, checking their outcome through observer,
+// a connection of its own. A failing scenario is recorded in its Check.
+func Run(ctx context.Context, lib Library, observer *sql.DB) []Check {
+	var checks []Check
+	for _, scenario := range Scenarios {
+		s := &scenarioRun{lib: lib, observer: observer, scenario: scenario}
+		check := Check{Library: lib.Name(), Scenario: scenario}
+		var err error
+		switch scenario {
+		case Commit:
+			check.Expected = "2 of 2 users committed"
+			err = s.commit(ctx, &check)
+		case Abort:
+			check.Expected = "0 of 2 users committed"
+			err = s.abort(ctx, &check)
+		case Orphan:
+			check.Expected = "orphan blocked, then recovered"
+			err = s.orphan(ctx, &check)
+		}
+		if err != nil {
+			check.Error = err.Error()
+		}
+		// Leave nothing prepared behind, whatever went wrong
+		if _, err := Recover(context.WithoutCancel(ctx), observer, s.prefix(ctx), 0); err != nil && check.Error == "" {
+			check.Error = err.Error()
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// scenarioRun holds what one scenario works with
+type scenarioRun struct {
+	lib      Library
+	observer *sql.DB
+	scenario string
+}
+
+func (s *scenarioRun) prefix(ctx context.Context) string {
+	return fmt.Sprintf("%s%s-%s-", RunPrefix(ctx), strings.ToLower(s.lib.Name()), s.scenario)
+}
+
+func (s *scenarioRun) gid(ctx context.Context, branch int) string {
+	return fmt.Sprintf("%s%d", s.prefix(ctx), branch)
+}
+
+func (s *scenarioRun) email(ctx context.Context, branch int) string {
+	return benchdata.Email(benchdata.RunID(ctx), "twophase-"+s.scenario, s.lib.Name(), int64(branch))
+}
+
+// branch begins branch n and inserts its user with age
+func (s *scenarioRun) branch(ctx context.Context, n, age int) (Branch, error) {
+	branch, err := s.lib.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = branch.Insert(ctx, &models.CreateUserRequest{
+		Name:  fmt.Sprintf("Two-Phase %s %d", s.scenario, n),
+		Email: s.email(ctx, n),
+		Age:   age,
+	})
+	if err != nil {
+		branch.Rollback()
+		return nil, fmt.Errorf("branch %d: %w", n, err)
+	}
+	return branch, nil
+}
+
+// committed counts the users of both branches visible to other sessions
+func (s *scenarioRun) committed(ctx context.Context) (int, error) {
+	var n int
+	err := s.observer.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email IN ($1, $2)",
+		s.email(ctx, 1), s.email(ctx, 2)).Scan(&n)
+	return n, err
+}
+
+// commit prepares both branches, then commits both
+func (s *scenarioRun) commit(ctx context.Context, check *Check) error {
+	for n := 1; n <= 2; n++ {
+		branch, err := s.branch(ctx, n, 30)
+		if err != nil {
+			return err
+		}
+		if err := branch.Prepare(ctx, s.gid(ctx, n)); err != nil {
+			return fmt.Errorf("branch %d: %w", n, err)
+		}
+	}
+	// Prepared, the users are still invisible
+	before, err := s.committed(ctx)
+	if err != nil {
+		return err
+	}
+	for n := 1; n <= 2; n++ {
+		if err := s.lib.Finish(ctx, s.gid(ctx, n), true); err != nil {
+			return fmt.Errorf("COMMIT PREPARED of branch %d: %w", n, err)
+		}
+	}
+
+	after, err := s.committed(ctx)
+	if err != nil {
+		return err
+	}
+	check.Observed = fmt.Sprintf("%d of 2 users committed", after)
+	check.Detail = fmt.Sprintf("%d visible while prepared, %d after COMMIT PREPARED", before, after)
+	if before != 0 {
+		check.Observed = fmt.Sprintf("%d of 2 users visible before COMMIT PREPARED", before)
+	}
+	return nil
+}
+
+// abort prepares the first branch, fails the second on the age CHECK
+// constraint and rolls back the first
+func (s *scenarioRun) abort(ctx context.Context, check *Check) error {
+	first, err := s.branch(ctx, 1, 30)
+	if err != nil {
+		return err
+	}
+	if err := first.Prepare(ctx, s.gid(ctx, 1)); err != nil {
+		return fmt.Errorf("branch 1: %w", err)
+	}
+	_, failure := s.branch(ctx, 2, 200)
+	if failure == nil {
+		return errors.New("branch 2 accepted an age of 200")
+	}
+	if err := s.lib.Finish(ctx, s.gid(ctx, 1), false); err != nil {
+		return fmt.Errorf("ROLLBACK PREPARED of branch 1: %w", err)
+	}
+
+	after, err := s.committed(ctx)
+	if err != nil {
+		return err
+	}
+	check.Observed = fmt.Sprintf("%d of 2 users committed", after)
+	check.Detail = fmt.Sprintf("branch 2 failed (%s), branch 1 rolled back", firstLine(failure.Error()))
+	return nil
+}
+
+// orphan prepares a branch and abandons it like a crashed coordinator. The
+// orphan keeps its user's email locked, so inserting it again blocks until
+// Recover rolls the orphan back.
+func (s *scenarioRun) orphan(ctx context.Context, check *Check) error {
+	branch, err := s.branch(ctx, 1, 30)
+	if err != nil {
+		return err
+	}
+	if err := branch.Prepare(ctx, s.gid(ctx, 1)); err != nil {
+		return fmt.Errorf("branch 1: %w", err)
+	}
+
+	orphans, err := Prepared(ctx, s.observer, s.prefix(ctx))
+	if err != nil {
+		return err
+	}
+	blockCtx, cancel := context.WithTimeout(ctx, blockWait)
+	_, insertErr := s.observer.ExecContext(blockCtx,
+		"INSERT INTO users (name, email, age) VALUES ($1, $2, 30)", "Two-Phase orphan 2", s.email(ctx, 1))
+	blocked := blockCtx.Err() != nil
+	cancel()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	recovered, err := Recover(ctx, s.observer, s.prefix(ctx), 0)
+	if err != nil {
+		return err
+	}
+	left, err := Prepared(ctx, s.observer, s.prefix(ctx))
+	if err != nil {
+		return err
+	}
+	after, err := s.committed(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(orphans) != 1:
+		check.Observed = fmt.Sprintf("%d orphans listed in pg_prepared_xacts", len(orphans))
+	case !blocked:
+		check.Observed = fmt.Sprintf("orphan did not block, insert returned %v", insertErr)
+	case len(recovered) != 1 || len(left) != 0 || after != 0:
+		check.Observed = fmt.Sprintf("%d recovered, %d left prepared, %d users committed", len(recovered), len(left), after)
+	default:
+		check.Observed = check.Expected
+	}
+	check.Detail = fmt.Sprintf("insert of its email blocked for %v, ROLLBACK PREPARED released it", blockWait)
+	return nil
+}
+
+// finishStatement returns COMMIT PREPARED or ROLLBACK PREPARED for gid
+func finishStatement(gid string, commit bool) (string, error) {
+	if !gidPattern.MatchString(gid) {
+		return "", fmt.Errorf("invalid global transaction ID %q", gid)
+	}
+	if commit {
+		return "COMMIT PREPARED " + pq.QuoteLiteral(gid), nil
+	}
+	return "ROLLBACK PREPARED " + pq.QuoteLiteral(gid), nil
+}
+
+// prepareStatement returns PREPARE TRANSACTION for gid
+func prepareStatement(gid string) (string, error) {
+	if !gidPattern.MatchString(gid) {
+		return "", fmt.Errorf("invalid global transaction ID %q", gid)
+	}
+	return "PREPARE TRANSACTION " + pq.QuoteLiteral(gid), nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}